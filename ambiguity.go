@@ -0,0 +1,81 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// CheckAmbiguity returns ErrAmbiguousTree if the root of the tree is also the root of a tree over fewer
+// leaves. Odd levels are padded by duplicating their last node, so a level of even size ending with two
+// identical nodes hashes to the same parents as the level without its last node: the tree over the
+// leaves below the other nodes has the same root, as in the duplicate transaction mutation of Bitcoin
+// (CVE-2012-2459). Such trees should be rejected when the root alone must identify the leaves.
+// The two last nodes of each level are read from the proofs, so the check works in every mode.
+func (m *MerkleTree) CheckAmbiguity() error {
+	// The level below the root always has 2 nodes: without its last one, the tree would be shallower.
+	for level := 0; level < m.Depth-1; level++ {
+		size := levelSize(m.NumLeaves, level)
+		if size&1 == 1 {
+			continue
+		}
+
+		last, err := m.levelNode(level, size-1)
+		if err != nil {
+			return err
+		}
+
+		prev, err := m.levelNode(level, size-2)
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(last, prev) {
+			return fmt.Errorf("%w: level %d ends with two identical nodes, the tree over the first %d leaves "+
+				"has the same root", ErrAmbiguousTree, level, (size-1)<<level)
+		}
+	}
+
+	return nil
+}
+
+// levelNode returns the node at idx of the level, read as the sibling of the other node of its pair,
+// or as its own padding duplicate if it is the last node of an odd level.
+func (m *MerkleTree) levelNode(level, idx int) ([]byte, error) {
+	if level == m.Depth {
+		return m.Root, nil
+	}
+
+	leaf := (idx ^ 1) << level
+	if leaf >= m.NumLeaves {
+		leaf = idx << level
+	}
+
+	proof, err := m.proofByIndex(leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	return proof.Siblings[level], nil
+}
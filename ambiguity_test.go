@@ -0,0 +1,89 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// letterBlocks returns a data block per letter of s.
+func letterBlocks(s string) []DataBlock {
+	blocks := make([]DataBlock, len(s))
+	for i := range s {
+		blocks[i] = &mock.DataBlock{Data: []byte{s[i]}}
+	}
+
+	return blocks
+}
+
+func TestMerkleTree_CheckAmbiguity(t *testing.T) {
+	tests := []struct {
+		name      string
+		letters   string
+		config    *Config
+		wantAlias int
+	}{
+		{name: "test_distinct", letters: "abcdefg", config: &Config{}},
+		{name: "test_two_identical_leaves", letters: "aa", config: &Config{}},
+		{name: "test_identical_leaves_inside", letters: "abbc", config: &Config{}},
+		{name: "test_duplicated_last_leaf", letters: "abcc", config: &Config{}, wantAlias: 3},
+		{name: "test_duplicated_last_pair", letters: "abcdefef", config: &Config{}, wantAlias: 6},
+		{name: "test_tree_build", letters: "abcdefef", config: &Config{Mode: ModeTreeBuild}, wantAlias: 6},
+		{name: "test_low_memory", letters: "abcdefghijkk", config: &Config{Mode: ModeLowMemory}, wantAlias: 11},
+		{name: "test_parallel", letters: "abcdefef", config: &Config{RunInParallel: true}, wantAlias: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.config, letterBlocks(tt.letters))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			err = m.CheckAmbiguity()
+			if tt.wantAlias == 0 {
+				if err != nil {
+					t.Errorf("CheckAmbiguity() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrAmbiguousTree) {
+				t.Fatalf("CheckAmbiguity() error = %v, want %v", err, ErrAmbiguousTree)
+			}
+			alias, err := New(nil, letterBlocks(tt.letters[:tt.wantAlias]))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(alias.Root, m.Root) {
+				t.Errorf("alias Root = %x, want %x", alias.Root, m.Root)
+			}
+			config := *tt.config
+			config.RejectAmbiguousTrees = true
+			if _, err := New(&config, letterBlocks(tt.letters)); !errors.Is(err, ErrAmbiguousTree) {
+				t.Errorf("New() error = %v, want %v", err, ErrAmbiguousTree)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package attest emits and verifies in-toto attestations of Merkle Tree dataset commitments.
+//
+// A Statement carries the Merkle root, leaf count and hashing configuration of a dataset build in
+// its predicate. It is signed into a DSSE envelope, the format consumed by SLSA/in-toto tooling,
+// so that CI pipelines can attest dataset commitments built with go-merkletree.
+package attest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+const (
+	// StatementType is the in-toto statement type.
+	StatementType = "https://in-toto.io/Statement/v1"
+	// PredicateType is the type of the Merkle root predicate.
+	PredicateType = "https://github.com/txaty/go-merkletree/attestation/merkle-root/v1"
+	// PayloadType is the DSSE payload type of in-toto statements.
+	PayloadType = "application/vnd.in-toto+json"
+	// RootDigestAlgorithm is the subject digest algorithm name under which the Merkle root is recorded.
+	RootDigestAlgorithm = "merkleRoot"
+)
+
+var (
+	// ErrInvalidEnvelope is the error for an envelope that is not a signed in-toto statement.
+	ErrInvalidEnvelope = errors.New("invalid attestation envelope")
+	// ErrSignature is the error for an envelope without a valid signature.
+	ErrSignature = errors.New("invalid attestation signature")
+)
+
+// Signer signs attestation payloads. mt.Ed25519Signer implements it.
+type Signer interface {
+	Sign(message []byte) ([]byte, error)
+}
+
+// Verifier verifies attestation signatures. mt.Ed25519Verifier implements it.
+type Verifier interface {
+	Verify(message, signature []byte) error
+}
+
+// Subject is the attested artifact: the dataset identified by its Merkle root.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Predicate is the Merkle root predicate.
+type Predicate struct {
+	Root               mt.HexBytes `json:"root"`
+	LeafCount          int         `json:"leafCount"`
+	Depth              int         `json:"depth"`
+	HashFunction       string      `json:"hashFunction"`
+	SortSiblingPairs   bool        `json:"sortSiblingPairs"`
+	DisableLeafHashing bool        `json:"disableLeafHashing"`
+}
+
+// Statement is an in-toto statement attesting a Merkle root.
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// NewStatement creates the statement attesting the tree built over the dataset named name.
+// hashFunction names the hash function used to build the tree (e.g. "sha256"), as functions cannot be inspected.
+func NewStatement(name string, tree *mt.MerkleTree, hashFunction string) *Statement {
+	return &Statement{
+		Type: StatementType,
+		Subject: []Subject{{
+			Name:   name,
+			Digest: map[string]string{RootDigestAlgorithm: hex.EncodeToString(tree.Root)},
+		}},
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			Root:               tree.Root,
+			LeafCount:          tree.NumLeaves,
+			Depth:              tree.Depth,
+			HashFunction:       hashFunction,
+			SortSiblingPairs:   tree.SortSiblingPairs,
+			DisableLeafHashing: tree.DisableLeafHashing,
+		},
+	}
+}
+
+// Matches reports whether the statement attests the tree: same root, leaf count and configuration flags.
+func (s *Statement) Matches(tree *mt.MerkleTree) bool {
+	p := s.Predicate
+
+	return bytes.Equal(p.Root, tree.Root) && p.LeafCount == tree.NumLeaves &&
+		p.SortSiblingPairs == tree.SortSiblingPairs && p.DisableLeafHashing == tree.DisableLeafHashing
+}
+
+// Signature is a DSSE signature.
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   []byte `json:"sig"`
+}
+
+// Envelope is a DSSE envelope carrying a signed statement.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     []byte      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Sign serializes the statement and signs it into a DSSE envelope.
+func Sign(statement *Statement, keyID string, signer Signer) (*Envelope, error) {
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := signer.Sign(preAuthEncoding(PayloadType, payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		PayloadType: PayloadType,
+		Payload:     payload,
+		Signatures:  []Signature{{KeyID: keyID, Sig: sig}},
+	}, nil
+}
+
+// Verify checks that at least one signature of the envelope is valid and returns the attested statement.
+func Verify(envelope *Envelope, verifier Verifier) (*Statement, error) {
+	if envelope == nil || envelope.PayloadType != PayloadType {
+		return nil, ErrInvalidEnvelope
+	}
+
+	message := preAuthEncoding(envelope.PayloadType, envelope.Payload)
+	valid := false
+
+	for _, sig := range envelope.Signatures {
+		if verifier.Verify(message, sig.Sig) == nil {
+			valid = true
+
+			break
+		}
+	}
+
+	if !valid {
+		return nil, ErrSignature
+	}
+
+	statement := new(Statement)
+	if err := json.Unmarshal(envelope.Payload, statement); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+	}
+
+	if statement.Type != StatementType || statement.PredicateType != PredicateType {
+		return nil, ErrInvalidEnvelope
+	}
+
+	return statement, nil
+}
+
+// preAuthEncoding is the DSSE pre-authentication encoding of the payload, which is what gets signed.
+func preAuthEncoding(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
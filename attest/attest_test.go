@@ -0,0 +1,88 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package attest
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+
+	mt "github.com/txaty/go-merkletree"
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func testTree(t *testing.T, data ...string) *mt.MerkleTree {
+	blocks := make([]mt.DataBlock, len(data))
+	for i, d := range data {
+		blocks[i] = &mock.DataBlock{Data: []byte(d)}
+	}
+	tree, err := mt.New(&mt.Config{SortSiblingPairs: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return tree
+}
+
+func TestSignVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	tree := testTree(t, "a", "b", "c")
+	envelope, err := Sign(NewStatement("dataset", tree, "sha256"), "ci-key", mt.Ed25519Signer(priv))
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	// The envelope survives a JSON round trip, as it is usually stored next to the build outputs.
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	decoded := new(Envelope)
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	statement, err := Verify(decoded, mt.Ed25519Verifier(pub))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !statement.Matches(tree) {
+		t.Errorf("Matches() attested tree should match")
+	}
+	if statement.Matches(testTree(t, "a", "b", "d")) {
+		t.Errorf("Matches() different tree should not match")
+	}
+	if _, err := Verify(decoded, mt.Ed25519Verifier(otherPub)); err != ErrSignature {
+		t.Errorf("Verify() wrong key error = %v", err)
+	}
+	decoded.Payload = []byte(`{"_type":"forged"}`)
+	if _, err := Verify(decoded, mt.Ed25519Verifier(pub)); err != ErrSignature {
+		t.Errorf("Verify() tampered payload error = %v", err)
+	}
+}
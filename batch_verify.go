@@ -0,0 +1,218 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/txaty/go-merkletree/verifier"
+)
+
+// defaultBatchMemoEntries is the default maximum number of memoized nodes of a BatchVerifier.
+const defaultBatchMemoEntries = 1 << 20
+
+// batchNodeKey is the position of a memoized interior node.
+type batchNodeKey struct {
+	level, index int
+}
+
+// batchNode is a memoized interior node with the children it was hashed from.
+type batchNode struct {
+	left, right, hash []byte
+}
+
+// batchPending is a node hashed by the current verification, memoized if it succeeds.
+type batchPending struct {
+	key  batchNodeKey
+	node batchNode
+}
+
+// BatchVerifier verifies many proofs against the same root, memoizing the interior nodes computed by each
+// verification by their level and index, so that the subpaths shared by the proofs are hashed once: a node
+// whose children match the memoized ones is not hashed again. Proofs of neighboring leaves share all the nodes
+// above their lowest common ancestor, so verifying the proofs in leaf order saves most of the hashing.
+// Results are those of Verify with the same configuration. A BatchVerifier is not safe for concurrent use:
+// parallel verification uses one per goroutine, each over a contiguous range of leaves.
+type BatchVerifier struct {
+	config     *Config
+	root       []byte
+	memo       map[batchNodeKey]batchNode
+	pending    []batchPending
+	maxEntries int
+	// boundRoot memoizes the last size-bound root if BindLeafCount is set, with unboundRoot its root.
+	unboundRoot, boundRoot []byte
+	hashes, memoHits       int
+}
+
+// NewBatchVerifier creates a BatchVerifier of the proofs of the tree with the root. At most maxEntries nodes
+// are memoized, 2^20 if it is 0; the memo is cleared when it is full, keeping the nodes of the latest proofs.
+func NewBatchVerifier(root []byte, config *Config, maxEntries int) *BatchVerifier {
+	c := new(Config)
+	if config != nil {
+		*c = *config
+	}
+
+	if c.HashFunc == nil {
+		c.HashFunc = DefaultHashFunc
+	}
+
+	if maxEntries <= 0 {
+		maxEntries = defaultBatchMemoEntries
+	}
+
+	return &BatchVerifier{
+		config:     c,
+		root:       root,
+		memo:       make(map[batchNodeKey]batchNode),
+		maxEntries: maxEntries,
+	}
+}
+
+// Verify checks the data block with the proof against the root of the BatchVerifier, see Verify.
+func (b *BatchVerifier) Verify(dataBlock DataBlock, proof *Proof) (bool, error) {
+	if dataBlock == nil {
+		return false, ErrDataBlockIsNil
+	}
+
+	if b.config.TimestampLeaves {
+		var err error
+		if dataBlock, err = commitTimestamp(dataBlock); err != nil {
+			return false, err
+		}
+	}
+
+	leaf, err := dataBlockToLeaf(b.config.fitBlock(dataBlock), b.config.leafHashFunc(), b.config.DisableLeafHashing)
+	if err != nil {
+		return false, err
+	}
+
+	return b.VerifyLeaf(leaf, proof)
+}
+
+// VerifyLeaf checks the leaf with the proof against the root of the BatchVerifier.
+func (b *BatchVerifier) VerifyLeaf(leaf []byte, proof *Proof) (bool, error) {
+	if proof == nil {
+		return false, ErrProofIsNil
+	}
+
+	var (
+		c        = b.config
+		siblings = proof.Siblings
+		path     = proof.Path
+		strict   = c.StrictVerification || c.BindLeafCount
+	)
+
+	if c.BindLeafCount && c.ExpectedNumLeaves == 0 {
+		return false, ErrLeafCountRequired
+	}
+
+	if strict {
+		if err := verifier.CheckProof(leaf, siblings, path, c.ExpectedNumLeaves); err != nil {
+			return false, err
+		}
+	}
+
+	concatFunc := verifier.Concat
+	if c.SortSiblingPairs {
+		concatFunc = verifier.ConcatSorted
+	}
+
+	var (
+		index  = proof.Index()
+		size   = c.ExpectedNumLeaves
+		result = leaf
+	)
+
+	for i, sib := range siblings {
+		if c.StrictVerification && size > 0 && (index>>i)^1 >= size && !bytes.Equal(result, sib) {
+			return false, fmt.Errorf("%w: level %d: padding sibling is not a duplicate of the node",
+				verifier.ErrMalformedProof, i)
+		}
+
+		left, right := sib, result
+		if path>>i&1 == 1 {
+			left, right = result, sib
+		}
+
+		key := batchNodeKey{level: i + 1, index: index >> (i + 1)}
+		if node, ok := b.memo[key]; ok && bytes.Equal(node.left, left) && bytes.Equal(node.right, right) {
+			result = node.hash
+			b.memoHits++
+		} else {
+			parent, err := c.hashNode(i+1, key.index, concatFunc(left, right))
+			if err != nil {
+				return false, err
+			}
+
+			b.hashes++
+			b.pending = append(b.pending, batchPending{key: key, node: batchNode{left: left, right: right, hash: parent}})
+			result = parent
+		}
+
+		size = (size + 1) >> 1
+	}
+
+	if c.BindLeafCount {
+		if !bytes.Equal(result, b.unboundRoot) {
+			bound, err := SizeBoundRoot(result, c.ExpectedNumLeaves, c)
+			if err != nil {
+				return false, err
+			}
+
+			b.unboundRoot, b.boundRoot = result, bound
+		}
+
+		result = b.boundRoot
+	}
+
+	ok := bytes.Equal(result, b.root)
+	if ok {
+		b.remember()
+	}
+
+	b.pending = b.pending[:0]
+
+	return ok, nil
+}
+
+// remember memoizes the nodes hashed by the last verification, which succeeded, clearing the memo first
+// if it is full. Nodes of failed verifications are not memoized, so that they do not evict valid ones.
+func (b *BatchVerifier) remember() {
+	if len(b.memo)+len(b.pending) > b.maxEntries {
+		clear(b.memo)
+	}
+
+	for _, p := range b.pending {
+		// The children may belong to the proof of the caller, so they are copied.
+		p.node.left = append([]byte(nil), p.node.left...)
+		p.node.right = append([]byte(nil), p.node.right...)
+		b.memo[p.key] = p.node
+	}
+}
+
+// Stats returns the number of interior nodes hashed by the BatchVerifier and the number of nodes found
+// in its memo instead.
+func (b *BatchVerifier) Stats() (hashes, memoHits int) {
+	return b.hashes, b.memoHits
+}
@@ -0,0 +1,92 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"testing"
+)
+
+func TestBatchVerifier(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		num    int
+	}{
+		{name: "test_2", config: &Config{}, num: 2},
+		{name: "test_100", config: &Config{}, num: 100},
+		{name: "test_33_sorted", config: &Config{SortSiblingPairs: true}, num: 33},
+		{name: "test_37_positional", config: &Config{PositionalHashFunc: PositionPrefixed(DefaultHashFunc)}, num: 37},
+		{name: "test_21_strict", config: &Config{StrictVerification: true, ExpectedNumLeaves: 21}, num: 21},
+		{name: "test_19_size_bound", config: &Config{BindLeafCount: true, ExpectedNumLeaves: 19}, num: 19},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.num)
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			root := m.Root
+			if tt.config.BindLeafCount {
+				if root, err = m.SizeBoundRoot(); err != nil {
+					t.Fatalf("SizeBoundRoot() error = %v", err)
+				}
+			}
+			b := NewBatchVerifier(root, tt.config, 0)
+			for i, block := range blocks {
+				ok, err := b.Verify(block, m.Proofs[i])
+				if err != nil || !ok {
+					t.Fatalf("Verify() %d = %v, error = %v", i, ok, err)
+				}
+			}
+			// Every interior node is hashed once, the padding ones included.
+			if hashes, hits := b.Stats(); hashes > 2*tt.num || hashes+hits != tt.num*m.Depth {
+				t.Errorf("Stats() hashes = %d, hits = %d, want at most %d hashes", hashes, hits, 2*tt.num)
+			}
+			for i := range blocks {
+				other := blocks[(i+1)%tt.num]
+				ok, err := b.Verify(other, m.Proofs[i])
+				if want, _ := Verify(other, m.Proofs[i], root, tt.config); ok != want {
+					t.Errorf("Verify() %d of another block = %v, want %v, error = %v", i, ok, want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchVerifier_memoLimit(t *testing.T) {
+	blocks := mockDataBlocks(64)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	b := NewBatchVerifier(m.Root, nil, 4)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if ok, err := b.Verify(blocks[i], m.Proofs[i]); err != nil || !ok {
+			t.Fatalf("Verify() %d = %v, error = %v", i, ok, err)
+		}
+	}
+	if len(b.memo) > 4 {
+		t.Errorf("memo entries = %d, want at most 4", len(b.memo))
+	}
+}
@@ -0,0 +1,559 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+)
+
+// Tree bundle layout (all integers big-endian):
+//
+//	header:   magic "MKBN" | version (uint8) | flags (uint8) | reserved (uint16) |
+//	          number of leaves (uint64) | depth (uint32) | fingerprint | root
+//	sections: leaves | node levels | proofs, each prefixed by its length in bytes (uint64)
+//
+// Variable-length values (fingerprint, root, leaves, nodes, siblings) are prefixed by their length (uint32).
+// The leaves section holds the leaves in order, the node levels section holds levels 1 to depth-1 as in
+// the built tree, each prefixed by its number of nodes (uint64), and the proofs section holds, for every
+// leaf, the path (uint32) and the number of siblings (uint32) followed by the siblings.
+// Omitted leaves or node levels leave an empty section, so that the proofs can always be reached by
+// skipping the sections before them.
+const (
+	bundleEncodingVersion = 1
+
+	bundleFlagSortSiblingPairs   = 1 << 0
+	bundleFlagDisableLeafHashing = 1 << 1
+	bundleFlagLeaves             = 1 << 2
+	bundleFlagNodes              = 1 << 3
+)
+
+// bundleEncodingMagic identifies a tree bundle.
+var bundleEncodingMagic = [4]byte{'M', 'K', 'B', 'N'}
+
+// fingerprintProbe is the input hashed by the configured hash functions to fingerprint them.
+var fingerprintProbe = []byte("go-merkletree config fingerprint")
+
+// BundleLoadMode selects the sections of a tree bundle loaded by LoadBundle.
+type BundleLoadMode int
+
+const (
+	// LoadAll loads every section stored in the bundle.
+	LoadAll BundleLoadMode = iota
+	// LoadProofsOnly loads the header and the proofs, skipping the leaves and the node levels.
+	LoadProofsOnly
+)
+
+// BundleOptions selects the optional sections written by WriteBundle.
+// The zero value writes the leaves and the node levels along with the proofs.
+type BundleOptions struct {
+	// OmitLeaves, if true, does not write the leaves.
+	OmitLeaves bool
+	// OmitNodes, if true, does not write the interior node levels.
+	OmitNodes bool
+}
+
+// Bundle is a tree bundle loaded by LoadBundle: a complete verification kit holding the proofs of all
+// leaves of a tree, with its root, the fingerprint of its configuration and, optionally, its leaves and
+// interior node levels.
+type Bundle struct {
+	// Fingerprint is the fingerprint of the configuration the tree was built with, see Config.Fingerprint.
+	Fingerprint []byte
+	// SortSiblingPairs and DisableLeafHashing are the flags of the configuration the tree was built with.
+	SortSiblingPairs   bool
+	DisableLeafHashing bool
+	// NumLeaves is the number of leaves of the tree.
+	NumLeaves int
+	// Depth is the depth of the tree.
+	Depth int
+	// Root is the Merkle root of the tree.
+	Root []byte
+	// Leaves are the leaves of the tree, nil if they were omitted or not loaded.
+	Leaves [][]byte
+	// Nodes are the interior node levels 1 to Depth-1 of the tree, nil if they were omitted or not loaded.
+	Nodes [][][]byte
+	// Proofs are the proofs of all leaves of the tree.
+	Proofs []*Proof
+}
+
+// Fingerprint returns the fingerprint of the configuration: the SHA-256 hash of the flags changing the
+// hashes of the tree and of the hashes of a fixed input by the leaf and interior node hash functions.
+// Trees built with different hash functions or flags have different fingerprints, so that a tree bundle
+// can be checked against the configuration of its verifier.
+func (c *Config) Fingerprint() ([]byte, error) {
+	cfg := *c
+	if cfg.HashFunc == nil {
+		cfg.HashFunc = DefaultHashFunc
+	}
+
+	leaf, err := cfg.leafHashFunc()(fingerprintProbe)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := cfg.hashNode(1, 0, fingerprintProbe)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	h.Write(bundleEncodingMagic[:])
+	h.Write([]byte{cfg.bundleFlags()})
+	h.Write(leaf)
+	h.Write(node)
+
+	return h.Sum(nil), nil
+}
+
+// bundleFlags returns the bundle flags of the configuration.
+func (c *Config) bundleFlags() uint8 {
+	var flags uint8
+	if c.SortSiblingPairs {
+		flags |= bundleFlagSortSiblingPairs
+	}
+
+	if c.DisableLeafHashing {
+		flags |= bundleFlagDisableLeafHashing
+	}
+
+	return flags
+}
+
+// WriteBundle writes the tree bundle of the tree to the file at path, through a temporary file renamed
+// into place. The proofs are generated from the tree in any mode; the node levels of trees that do not
+// keep their structure are reassembled from the proofs.
+func WriteBundle(path string, m *MerkleTree, opts *BundleOptions) error {
+	if opts == nil {
+		opts = new(BundleOptions)
+	}
+
+	fingerprint, err := m.Config.Fingerprint()
+	if err != nil {
+		return err
+	}
+
+	proofs := m.Proofs
+	if proofs == nil {
+		proofs = make([]*Proof, m.NumLeaves)
+		for i := range proofs {
+			if proofs[i], err = m.proofByIndex(i); err != nil {
+				return err
+			}
+		}
+	}
+
+	flags := m.bundleFlags()
+
+	var leaves [][]byte
+	if !opts.OmitLeaves {
+		leaves = m.Leaves
+		flags |= bundleFlagLeaves
+	}
+
+	var nodes [][][]byte
+	if !opts.OmitNodes {
+		nodes = m.nodes
+		if nodes == nil {
+			tree := &MerkleTree{Config: m.Config, Leaves: m.Leaves, Proofs: proofs, NumLeaves: m.NumLeaves, Depth: m.Depth}
+			nodes = tree.nodesFromProofs()
+		}
+
+		nodes = nodes[1:]
+		flags |= bundleFlagNodes
+	}
+
+	tmp := path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	bw := &bundleWriter{w: bufio.NewWriter(f)}
+	bw.write(bundleEncodingMagic[:])
+	bw.write([]byte{bundleEncodingVersion, flags, 0, 0})
+	bw.uint64(m.NumLeaves)
+	bw.uint32(m.Depth)
+	bw.bytes(fingerprint)
+	bw.bytes(m.Root)
+	bw.leaves(leaves)
+	bw.nodes(nodes)
+	bw.proofs(proofs)
+
+	if bw.err == nil {
+		bw.err = bw.w.Flush()
+	}
+
+	if err := f.Close(); bw.err == nil {
+		bw.err = err
+	}
+
+	if bw.err != nil {
+		os.Remove(tmp)
+		return bw.err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// bundleWriter writes the values of a tree bundle, keeping the first error.
+type bundleWriter struct {
+	w   *bufio.Writer
+	buf [8]byte
+	err error
+}
+
+func (bw *bundleWriter) write(p []byte) {
+	if bw.err == nil {
+		_, bw.err = bw.w.Write(p)
+	}
+}
+
+func (bw *bundleWriter) uint32(v int) {
+	bw.write(binary.BigEndian.AppendUint32(bw.buf[:0], uint32(v)))
+}
+
+func (bw *bundleWriter) uint64(v int) {
+	bw.write(binary.BigEndian.AppendUint64(bw.buf[:0], uint64(v)))
+}
+
+func (bw *bundleWriter) bytes(b []byte) {
+	bw.uint32(len(b))
+	bw.write(b)
+}
+
+func (bw *bundleWriter) leaves(leaves [][]byte) {
+	size := 0
+	for _, leaf := range leaves {
+		size += 4 + len(leaf)
+	}
+
+	bw.uint64(size)
+
+	for _, leaf := range leaves {
+		bw.bytes(leaf)
+	}
+}
+
+func (bw *bundleWriter) nodes(nodes [][][]byte) {
+	size := 0
+	for _, level := range nodes {
+		size += 8
+		for _, node := range level {
+			size += 4 + len(node)
+		}
+	}
+
+	bw.uint64(size)
+
+	for _, level := range nodes {
+		bw.uint64(len(level))
+		for _, node := range level {
+			bw.bytes(node)
+		}
+	}
+}
+
+func (bw *bundleWriter) proofs(proofs []*Proof) {
+	size := 0
+	for _, proof := range proofs {
+		size += 8
+		for _, sib := range proof.Siblings {
+			size += 4 + len(sib)
+		}
+	}
+
+	bw.uint64(size)
+
+	for _, proof := range proofs {
+		bw.uint32(int(proof.Path))
+		bw.uint32(len(proof.Siblings))
+
+		for _, sib := range proof.Siblings {
+			bw.bytes(sib)
+		}
+	}
+}
+
+// LoadBundle loads the tree bundle written by WriteBundle from the file at path.
+// With LoadProofsOnly, the leaves and the node levels are skipped without being read.
+func LoadBundle(path string, mode BundleLoadMode) (*Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	br := &bundleReader{f: f, r: bufio.NewReader(f), size: info.Size()}
+
+	b, err := br.bundle(mode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+
+	return b, nil
+}
+
+// bundleReader reads the values of a tree bundle, keeping the first error.
+type bundleReader struct {
+	f      *os.File
+	r      *bufio.Reader
+	offset int64
+	size   int64
+	err    error
+}
+
+func (br *bundleReader) read(n int) []byte {
+	if br.err != nil {
+		return nil
+	}
+
+	if int64(n) > br.size-br.offset {
+		br.err = io.ErrUnexpectedEOF
+		return nil
+	}
+
+	buf := make([]byte, n)
+	_, br.err = io.ReadFull(br.r, buf)
+	br.offset += int64(n)
+
+	return buf
+}
+
+func (br *bundleReader) uint32() int {
+	if buf := br.read(4); buf != nil {
+		return int(binary.BigEndian.Uint32(buf))
+	}
+
+	return 0
+}
+
+func (br *bundleReader) uint64() int64 {
+	if buf := br.read(8); buf != nil {
+		return int64(binary.BigEndian.Uint64(buf))
+	}
+
+	return 0
+}
+
+// length reads a section size or a count, which cannot exceed the remaining bytes.
+func (br *bundleReader) length() int64 {
+	n := br.uint64()
+	if br.err == nil && (n < 0 || n > br.size-br.offset) {
+		br.err = fmt.Errorf("invalid length %d", uint64(n))
+		return 0
+	}
+
+	return n
+}
+
+func (br *bundleReader) bytes() []byte {
+	return br.read(br.uint32())
+}
+
+// skip skips n bytes by seeking the file past them.
+func (br *bundleReader) skip(n int64) {
+	if br.err != nil {
+		return
+	}
+
+	if n < 0 || n > br.size-br.offset {
+		br.err = io.ErrUnexpectedEOF
+		return
+	}
+
+	br.offset += n
+	if _, br.err = br.f.Seek(br.offset, io.SeekStart); br.err == nil {
+		br.r.Reset(br.f)
+	}
+}
+
+// section reads a section with read, or skips it if skip is true, checking that it fills its length.
+func (br *bundleReader) section(skip bool, read func()) {
+	if br.err != nil {
+		return
+	}
+
+	size := br.length()
+	if skip {
+		br.skip(size)
+		return
+	}
+
+	end := br.offset + size
+	read()
+
+	if br.err == nil && br.offset != end {
+		br.err = fmt.Errorf("section length mismatch")
+	}
+}
+
+func (br *bundleReader) bundle(mode BundleLoadMode) (*Bundle, error) {
+	head := br.read(8)
+	if br.err != nil {
+		return nil, br.err
+	}
+
+	if [4]byte(head[:4]) != bundleEncodingMagic {
+		return nil, fmt.Errorf("bad magic")
+	}
+
+	if head[4] != bundleEncodingVersion {
+		return nil, fmt.Errorf("unsupported version %d", head[4])
+	}
+
+	flags := head[5]
+	b := &Bundle{
+		SortSiblingPairs:   flags&bundleFlagSortSiblingPairs != 0,
+		DisableLeafHashing: flags&bundleFlagDisableLeafHashing != 0,
+		NumLeaves:          int(br.uint64()),
+		Depth:              br.uint32(),
+	}
+
+	if br.err != nil {
+		return nil, br.err
+	}
+
+	if b.NumLeaves <= 1 || int64(b.NumLeaves) > br.size || b.Depth != bits.Len(uint(b.NumLeaves-1)) {
+		return nil, fmt.Errorf("invalid number of leaves or depth")
+	}
+
+	b.Fingerprint = br.bytes()
+	b.Root = br.bytes()
+
+	skip := mode == LoadProofsOnly
+	br.section(skip || flags&bundleFlagLeaves == 0, func() {
+		b.Leaves = make([][]byte, b.NumLeaves)
+		for i := range b.Leaves {
+			b.Leaves[i] = br.bytes()
+		}
+	})
+	br.section(skip || flags&bundleFlagNodes == 0, func() {
+		b.Nodes = make([][][]byte, max(b.Depth-1, 0))
+		for level := range b.Nodes {
+			count := br.length()
+			if br.err != nil {
+				return
+			}
+
+			b.Nodes[level] = make([][]byte, count)
+			for i := range b.Nodes[level] {
+				b.Nodes[level][i] = br.bytes()
+			}
+		}
+	})
+	br.section(false, func() {
+		b.Proofs = make([]*Proof, b.NumLeaves)
+		for i := range b.Proofs {
+			proof := &Proof{Path: uint32(br.uint32())}
+			count := br.uint32()
+			if int64(count) > br.size-br.offset {
+				br.err = io.ErrUnexpectedEOF
+				return
+			}
+
+			proof.Siblings = make([][]byte, count)
+			for j := range proof.Siblings {
+				proof.Siblings[j] = br.bytes()
+			}
+
+			b.Proofs[i] = proof
+		}
+	})
+
+	if br.err == nil && br.offset != br.size {
+		return nil, fmt.Errorf("trailing data")
+	}
+
+	if br.err != nil {
+		return nil, br.err
+	}
+
+	return b, nil
+}
+
+// CheckConfig returns ErrBundleConfigMismatch if the fingerprint of the configuration differs from the
+// fingerprint recorded in the bundle.
+func (b *Bundle) CheckConfig(config *Config) error {
+	if config == nil {
+		config = new(Config)
+	}
+
+	fingerprint, err := config.Fingerprint()
+	if err != nil {
+		return err
+	}
+
+	if string(fingerprint) != string(b.Fingerprint) {
+		return fmt.Errorf("%w: bundle %x, configuration %x", ErrBundleConfigMismatch, b.Fingerprint, fingerprint)
+	}
+
+	return nil
+}
+
+// Tree reassembles the tree of the bundle in ModeProofGen, after checking the configuration against the
+// bundle, see CheckConfig. The flags SortSiblingPairs and DisableLeafHashing are restored from the bundle.
+// The bundle must be loaded with its leaves.
+func (b *Bundle) Tree(config *Config) (*MerkleTree, error) {
+	if b.Leaves == nil {
+		return nil, ErrBundleLeavesMissing
+	}
+
+	if config == nil {
+		config = new(Config)
+	}
+
+	c := *config
+	c.Mode = ModeProofGen
+	c.SortSiblingPairs = b.SortSiblingPairs
+	c.DisableLeafHashing = b.DisableLeafHashing
+
+	if c.HashFunc == nil {
+		c.HashFunc = DefaultHashFunc
+	}
+
+	if err := b.CheckConfig(&c); err != nil {
+		return nil, err
+	}
+
+	m := &MerkleTree{
+		Config:    &c,
+		Leaves:    b.Leaves,
+		Proofs:    b.Proofs,
+		Root:      b.Root,
+		NumLeaves: b.NumLeaves,
+		Depth:     b.Depth,
+	}
+
+	return m, nil
+}
@@ -0,0 +1,236 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteBundle(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *Config
+		opts      *BundleOptions
+		mode      BundleLoadMode
+		wantLeafs bool
+		wantNodes bool
+	}{
+		{name: "test_proof_gen", config: &Config{}, wantLeafs: true, wantNodes: true},
+		{name: "test_tree_build", config: &Config{Mode: ModeTreeBuild}, wantLeafs: true, wantNodes: true},
+		{name: "test_lazy", config: &Config{Mode: ModeLazy, SortSiblingPairs: true}, wantLeafs: true, wantNodes: true},
+		{name: "test_low_memory", config: &Config{Mode: ModeLowMemory}, wantLeafs: true, wantNodes: true},
+		{name: "test_omit_leaves", config: &Config{}, opts: &BundleOptions{OmitLeaves: true}, wantNodes: true},
+		{name: "test_omit_nodes", config: &Config{}, opts: &BundleOptions{OmitNodes: true}, wantLeafs: true},
+		{name: "test_proofs_only", config: &Config{Mode: ModeTreeBuild}, mode: LoadProofsOnly},
+	}
+	blocks := mockDataBlocks(13)
+	want, err := New(&Config{Mode: ModeProofGenAndTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			path := filepath.Join(t.TempDir(), "tree.bundle")
+			if err := WriteBundle(path, m, tt.opts); err != nil {
+				t.Fatalf("WriteBundle() error = %v", err)
+			}
+			b, err := LoadBundle(path, tt.mode)
+			if err != nil {
+				t.Fatalf("LoadBundle() error = %v", err)
+			}
+			if err := b.CheckConfig(m.Config); err != nil {
+				t.Errorf("CheckConfig() error = %v", err)
+			}
+			if b.NumLeaves != m.NumLeaves || b.Depth != m.Depth || !reflect.DeepEqual(b.Root, m.Root) {
+				t.Errorf("LoadBundle() = %d leaves, depth %d, root %x, want %d, %d, %x",
+					b.NumLeaves, b.Depth, b.Root, m.NumLeaves, m.Depth, m.Root)
+			}
+			for i, proof := range b.Proofs {
+				wantProof, err := m.proofByIndex(i)
+				if err != nil {
+					t.Fatalf("proofByIndex() error = %v", err)
+				}
+				if !proof.Equal(wantProof) {
+					t.Errorf("Proofs[%d] = %v, want %v", i, proof, wantProof)
+				}
+			}
+			if got := b.Leaves != nil; got != tt.wantLeafs {
+				t.Fatalf("Leaves loaded = %t, want %t", got, tt.wantLeafs)
+			}
+			if tt.wantLeafs && !reflect.DeepEqual(b.Leaves, m.Leaves) {
+				t.Errorf("Leaves = %x, want %x", b.Leaves, m.Leaves)
+			}
+			if got := b.Nodes != nil; got != tt.wantNodes {
+				t.Fatalf("Nodes loaded = %t, want %t", got, tt.wantNodes)
+			}
+			if tt.wantNodes && !tt.config.SortSiblingPairs && !reflect.DeepEqual(b.Nodes, want.nodes[1:]) {
+				t.Errorf("Nodes = %x, want %x", b.Nodes, want.nodes[1:])
+			}
+			if !tt.wantLeafs {
+				if _, err := b.Tree(nil); !errors.Is(err, ErrBundleLeavesMissing) {
+					t.Errorf("Tree() error = %v, want %v", err, ErrBundleLeavesMissing)
+				}
+				return
+			}
+			tree, err := b.Tree(nil)
+			if err != nil {
+				t.Fatalf("Tree() error = %v", err)
+			}
+			for i, block := range blocks {
+				if ok, err := tree.Verify(block, tree.Proofs[i]); err != nil || !ok {
+					t.Errorf("Verify(%d) = %t, %v, want true", i, ok, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBundle_CheckConfig(t *testing.T) {
+	m, err := New(nil, mockDataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "tree.bundle")
+	if err := WriteBundle(path, m, nil); err != nil {
+		t.Fatalf("WriteBundle() error = %v", err)
+	}
+	b, err := LoadBundle(path, LoadAll)
+	if err != nil {
+		t.Fatalf("LoadBundle() error = %v", err)
+	}
+	if err := b.CheckConfig(nil); err != nil {
+		t.Errorf("CheckConfig(nil) error = %v", err)
+	}
+	sha := func(data []byte) ([]byte, error) {
+		sum := sha256.Sum256(append([]byte{0}, data...))
+		return sum[:], nil
+	}
+	configs := []*Config{
+		{HashFunc: sha},
+		{NodeHashFunc: sha},
+		{DisableLeafHashing: true},
+		{PositionalHashFunc: PositionPrefixed(nil)},
+	}
+	for _, config := range configs {
+		if err := b.CheckConfig(config); !errors.Is(err, ErrBundleConfigMismatch) {
+			t.Errorf("CheckConfig(%+v) error = %v, want %v", config, err, ErrBundleConfigMismatch)
+		}
+	}
+	if _, err := b.Tree(&Config{HashFunc: sha}); !errors.Is(err, ErrBundleConfigMismatch) {
+		t.Errorf("Tree() error = %v, want %v", err, ErrBundleConfigMismatch)
+	}
+}
+
+func TestLoadBundle_invalid(t *testing.T) {
+	m, err := New(nil, mockDataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "tree.bundle")
+	if err := WriteBundle(path, m, nil); err != nil {
+		t.Fatalf("WriteBundle() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	// The leaves section follows the header, the number of leaves, the depth, the fingerprint and the root,
+	// and the nodes section, whose first value is the node count of level 1, follows the leaves section.
+	leavesOffset := 20
+	for i := 0; i < 2; i++ {
+		leavesOffset += 4 + int(binary.BigEndian.Uint32(data[leavesOffset:]))
+	}
+	countOffset := leavesOffset + 8 + int(binary.BigEndian.Uint64(data[leavesOffset:])) + 8
+	patch := func(offset int, v uint64) []byte {
+		patched := bytes.Clone(data)
+		binary.BigEndian.PutUint64(patched[offset:], v)
+		return patched
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "test_empty", data: nil},
+		{name: "test_truncated_header", data: []byte("MKBN\x0100000000000")},
+		{name: "test_truncated_depth", data: data[:18]},
+		{name: "test_oversized_leaf_count", data: patch(8, 1<<40)},
+		{name: "test_oversized_section_size", data: patch(leavesOffset, 1<<40)},
+		{name: "test_oversized_node_count", data: patch(countOffset, 1<<40)},
+		{name: "test_negative_section_size", data: patch(leavesOffset, math.MaxUint64)},
+		{name: "test_negative_node_count", data: patch(countOffset, math.MaxUint64)},
+		{name: "test_bad_magic", data: append([]byte("XXXX"), data[4:]...)},
+		{name: "test_truncated", data: data[:len(data)-1]},
+		{name: "test_trailing_bytes", data: append(data[:len(data):len(data)], 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tree.bundle")
+			if err := os.WriteFile(path, tt.data, 0o600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			if _, err := LoadBundle(path, LoadAll); !errors.Is(err, ErrInvalidBundle) {
+				t.Errorf("LoadBundle() error = %v, want %v", err, ErrInvalidBundle)
+			}
+		})
+	}
+}
+
+func FuzzLoadBundle(f *testing.F) {
+	m, err := New(nil, mockDataBlocks(5))
+	if err != nil {
+		f.Fatalf("New() error = %v", err)
+	}
+	path := filepath.Join(f.TempDir(), "tree.bundle")
+	if err := WriteBundle(path, m, nil); err != nil {
+		f.Fatalf("WriteBundle() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		f.Fatalf("ReadFile() error = %v", err)
+	}
+	f.Add(data)
+	f.Add([]byte("MKBN\x0100000000000"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(t.TempDir(), "tree.bundle")
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		for _, mode := range []BundleLoadMode{LoadAll, LoadProofsOnly} {
+			if _, err := LoadBundle(path, mode); err != nil && !errors.Is(err, ErrInvalidBundle) {
+				t.Errorf("LoadBundle() error = %v, want %v", err, ErrInvalidBundle)
+			}
+		}
+	})
+}
@@ -0,0 +1,328 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// checkpointStripeSize is the number of leaves per checkpointed stripe of leaves.
+const checkpointStripeSize = 1 << 16
+
+const (
+	checkpointVersion      = 1
+	checkpointManifestName = "manifest.json"
+)
+
+// checkpointManifest describes the build a checkpoint directory belongs to.
+type checkpointManifest struct {
+	Version            int  `json:"version"`
+	NumLeaves          int  `json:"numLeaves"`
+	StripeSize         int  `json:"stripeSize"`
+	SortSiblingPairs   bool `json:"sortSiblingPairs"`
+	DisableLeafHashing bool `json:"disableLeafHashing"`
+}
+
+// ResumeBuild resumes the build checkpointed in checkpointDir by New with Config.CheckpointDir set,
+// after a crash or a preemption. The stripes of leaves and the levels already completed are read back;
+// the data blocks are only needed if some leaves were not checkpointed and may be nil otherwise.
+// The configuration must provide the hash function of the interrupted build; its CheckpointDir,
+// SortSiblingPairs and DisableLeafHashing fields are taken from the checkpoint.
+func ResumeBuild(checkpointDir string, config *Config, blocks []DataBlock) (*MerkleTree, error) {
+	data, err := os.ReadFile(filepath.Join(checkpointDir, checkpointManifestName))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCheckpoint, err)
+	}
+
+	var manifest checkpointManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCheckpoint, err)
+	}
+
+	if manifest.Version != checkpointVersion || manifest.StripeSize != checkpointStripeSize ||
+		(blocks != nil && len(blocks) != manifest.NumLeaves) {
+		return nil, ErrInvalidCheckpoint
+	}
+
+	c := new(Config)
+	if config != nil {
+		*c = *config
+	}
+
+	c.CheckpointDir = checkpointDir
+	c.SortSiblingPairs = manifest.SortSiblingPairs
+	c.DisableLeafHashing = manifest.DisableLeafHashing
+
+	if manifest.NumLeaves <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	m, err := newMerkleTree(c, manifest.NumLeaves)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.buildCheckpointed(blocks, true); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// buildCheckpointed builds the tree level by level, writing every stripe of leaves and every level
+// to the checkpoint directory once computed. When resuming, the checkpointed parts are read back instead.
+// The checkpoint is removed once the build completes.
+func (m *MerkleTree) buildCheckpointed(blocks []DataBlock, resume bool) error {
+	if m.RunInParallel {
+		m.initParallel()
+	} else {
+		m.init()
+	}
+
+	if !resume {
+		if err := m.startCheckpoint(); err != nil {
+			return err
+		}
+	}
+
+	if err := m.checkpointedLeaves(blocks, resume); err != nil {
+		return err
+	}
+
+	m.initNodes()
+
+	var err error
+
+	for i := 0; i < m.Depth-1; i++ {
+		m.nodes[i] = appendNodeIfOdd(m.nodes[i])
+
+		path := m.checkpointPath(fmt.Sprintf("level-%02d.bin", i+1))
+		if resume {
+			if m.nodes[i+1], err = readCheckpointNodes(path, len(m.nodes[i])>>1); err == nil {
+				continue
+			}
+		}
+
+		if m.nodes[i+1], err = m.hashLevel(m.buildChildren, i+1, 0, m.nodes[i]); err != nil {
+			return err
+		}
+
+		if err := writeCheckpointNodes(path, m.nodes[i+1]); err != nil {
+			return err
+		}
+	}
+
+	if m.Root, err = m.buildChildren(m.Depth, 0, m.nodes[m.Depth-1][0], m.nodes[m.Depth-1][1]); err != nil {
+		return err
+	}
+
+	// The proofs written to ProofSink are served from the nodes afterwards, which are kept.
+	if m.Mode == ModeProofGen && m.ProofSink != nil {
+		for i := 0; i < m.NumLeaves; i++ {
+			if err := m.ProofSink.WriteProof(i, m.injectProofFault(i, m.proofFromNodes(i))); err != nil {
+				return err
+			}
+		}
+	} else if m.Mode == ModeProofGen || m.Mode == ModeProofGenAndTreeBuild {
+		m.Proofs = make([]*Proof, m.NumLeaves)
+		for i := range m.Proofs {
+			m.Proofs[i] = m.proofFromNodes(i)
+		}
+	}
+
+	if m.Mode == ModeProofGen && m.ProofSink == nil {
+		m.nodes = nil
+	} else {
+		m.leafMap = make(map[string]int, m.NumLeaves)
+		for i, leaf := range m.Leaves {
+			m.leafMap[string(leaf)] = i
+		}
+	}
+
+	return m.removeCheckpoint()
+}
+
+// checkpointedLeaves computes the leaves stripe by stripe, checkpointing every stripe.
+func (m *MerkleTree) checkpointedLeaves(blocks []DataBlock, resume bool) (err error) {
+	m.Leaves = make([][]byte, m.NumLeaves)
+
+	for start := 0; start < m.NumLeaves; start += checkpointStripeSize {
+		var (
+			end    = min(start+checkpointStripeSize, m.NumLeaves)
+			path   = m.checkpointPath(fmt.Sprintf("leaves-%08d.bin", start/checkpointStripeSize))
+			stripe [][]byte
+		)
+
+		if resume {
+			if stripe, err = readCheckpointNodes(path, end-start); err == nil {
+				copy(m.Leaves[start:end], stripe)
+				continue
+			}
+		}
+
+		if blocks == nil {
+			return fmt.Errorf("%w: leaves %d to %d are not checkpointed", ErrInvalidCheckpoint, start, end)
+		}
+
+		if m.RunInParallel {
+			stripe, err = m.computeLeafNodesParallel(blocks[start:end])
+		} else {
+			stripe = make([][]byte, end-start)
+			for i := range stripe {
+				stripe[i], err = cachedDataBlockToLeaf(blocks[start+i], m.leafHashFunc(), m.DisableLeafHashing, m.LeafCache)
+				if err != nil {
+					break
+				}
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		copy(m.Leaves[start:end], stripe)
+
+		if err := writeCheckpointNodes(path, stripe); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MerkleTree) checkpointPath(name string) string {
+	return filepath.Join(m.CheckpointDir, name)
+}
+
+// startCheckpoint removes any previous checkpoint and writes the manifest of the build.
+func (m *MerkleTree) startCheckpoint() error {
+	if err := os.MkdirAll(m.CheckpointDir, 0o700); err != nil {
+		return err
+	}
+
+	if err := m.removeCheckpoint(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&checkpointManifest{
+		Version:            checkpointVersion,
+		NumLeaves:          m.NumLeaves,
+		StripeSize:         checkpointStripeSize,
+		SortSiblingPairs:   m.SortSiblingPairs,
+		DisableLeafHashing: m.DisableLeafHashing,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(m.checkpointPath(checkpointManifestName), data)
+}
+
+// removeCheckpoint removes the checkpoint files, keeping the directory.
+func (m *MerkleTree) removeCheckpoint() error {
+	for _, pattern := range []string{checkpointManifestName, "leaves-*.bin", "level-*.bin"} {
+		matches, err := filepath.Glob(m.checkpointPath(pattern))
+		if err != nil {
+			return err
+		}
+
+		for _, match := range matches {
+			if err := os.Remove(match); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeCheckpointNodes writes the nodes as their count (uint64) followed by each node length (uint32)
+// and bytes, atomically so that an interrupted write leaves no partial file.
+func writeCheckpointNodes(path string, nodes [][]byte) error {
+	size := 8
+	for _, node := range nodes {
+		size += 4 + len(node)
+	}
+
+	buf := make([]byte, 0, size)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(len(nodes)))
+
+	for _, node := range nodes {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(node)))
+		buf = append(buf, node...)
+	}
+
+	return writeFileAtomic(path, buf)
+}
+
+// readCheckpointNodes reads the count nodes written by writeCheckpointNodes.
+func readCheckpointNodes(path string, count int) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		r   = bufio.NewReader(f)
+		hdr = make([]byte, 8)
+	)
+
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, err
+	}
+
+	if binary.BigEndian.Uint64(hdr) != uint64(count) {
+		return nil, ErrInvalidCheckpoint
+	}
+
+	nodes := make([][]byte, count)
+	for i := range nodes {
+		if _, err := io.ReadFull(r, hdr[:4]); err != nil {
+			return nil, err
+		}
+
+		nodes[i] = make([]byte, binary.BigEndian.Uint32(hdr))
+		if _, err := io.ReadFull(r, nodes[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return nodes, nil
+}
+
+// writeFileAtomic writes the file through a temporary file renamed into place.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,125 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestResumeBuild(t *testing.T) {
+	errCrash := errors.New("crash")
+	tests := []struct {
+		name       string
+		mode       TypeConfigMode
+		parallel   bool
+		num        int
+		crashAfter int64
+		nilBlocks  bool
+		wantErr    error
+	}{
+		{name: "test_crash_in_levels", num: 100, crashAfter: 130, nilBlocks: true},
+		{name: "test_crash_in_levels_tree_build", mode: ModeTreeBuild, num: 100, crashAfter: 150, nilBlocks: true},
+		{name: "test_crash_in_levels_parallel", mode: ModeProofGenAndTreeBuild, parallel: true, num: 1000, crashAfter: 1200, nilBlocks: true},
+		{name: "test_crash_in_leaves", num: 100, crashAfter: 50},
+		{name: "test_crash_in_leaves_nil_blocks", num: 100, crashAfter: 50, nilBlocks: true, wantErr: ErrInvalidCheckpoint},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				dir    = t.TempDir()
+				blocks = mockDataBlocks(tt.num)
+				calls  atomic.Int64
+			)
+			crashing := func(data []byte) ([]byte, error) {
+				if calls.Add(1) > tt.crashAfter {
+					return nil, errCrash
+				}
+				return DefaultHashFunc(data)
+			}
+			_, err := New(&Config{HashFunc: crashing, Mode: tt.mode, RunInParallel: tt.parallel, CheckpointDir: dir}, blocks)
+			if !errors.Is(err, errCrash) {
+				t.Fatalf("New() error = %v, want %v", err, errCrash)
+			}
+
+			resumeBlocks := blocks
+			if tt.nilBlocks {
+				resumeBlocks = nil
+			}
+			calls.Store(0)
+			config := &Config{HashFunc: DefaultHashFunc, Mode: tt.mode, RunInParallel: tt.parallel}
+			got, err := ResumeBuild(dir, config, resumeBlocks)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResumeBuild() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if calls.Load() != 0 {
+				t.Errorf("ResumeBuild() config hash function was called %d times, want 0", calls.Load())
+			}
+
+			want, err := New(&Config{Mode: tt.mode, RunInParallel: tt.parallel}, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(got.Root, want.Root) {
+				t.Fatal("ResumeBuild() root differs from New()")
+			}
+			for i, block := range blocks {
+				var proof *Proof
+				if tt.mode == ModeTreeBuild {
+					if proof, err = got.Proof(block); err != nil {
+						t.Fatalf("Proof() error = %v", err)
+					}
+				} else {
+					proof = got.Proofs[i]
+				}
+				ok, err := Verify(block, proof, got.Root, config)
+				if err != nil || !ok {
+					t.Fatalf("Verify() of block %d = %v, %v", i, ok, err)
+				}
+			}
+			if matches, _ := filepath.Glob(filepath.Join(dir, "*")); len(matches) != 0 {
+				t.Errorf("checkpoint files left after the build: %v", matches)
+			}
+		})
+	}
+}
+
+func TestResumeBuild_invalidCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := ResumeBuild(dir, nil, nil); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Errorf("ResumeBuild() error = %v, want %v", err, ErrInvalidCheckpoint)
+	}
+	if err := os.WriteFile(filepath.Join(dir, checkpointManifestName), []byte(`{"version":1,"numLeaves":4,"stripeSize":65536}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ResumeBuild(dir, nil, mockDataBlocks(5)); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Errorf("ResumeBuild() error = %v, want %v", err, ErrInvalidCheckpoint)
+	}
+}
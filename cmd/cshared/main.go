@@ -0,0 +1,257 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Command cshared exports the core Merkle Tree API as a C shared library,
+// so that non-Go services (Python, Rust, ... via FFI) reuse this implementation
+// and obtain byte-identical roots and proofs.
+//
+// Build it with `make build_cshared`, which produces libmerkletree.so. C callers include merkletree.h,
+// the API of the library, which is also included here so that the compiler checks it against the exported
+// functions; the libmerkletree.h generated alongside the library declares the same functions.
+// Buffer lengths above math.MaxInt32 are rejected.
+//
+// Proofs cross the boundary in the following binary encoding (all integers big-endian):
+//
+//	path (uint32) | number of siblings (uint32) | for each sibling: length (uint32) | bytes
+package main
+
+// #include "merkletree.h"
+import "C"
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"runtime/cgo"
+	"unsafe"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+const (
+	flagSortSiblingPairs   = C.MT_SORT_SIBLING_PAIRS
+	flagDisableLeafHashing = C.MT_DISABLE_LEAF_HASHING
+	flagRunInParallel      = C.MT_RUN_IN_PARALLEL
+)
+
+var (
+	errMalformedProof = errors.New("malformed proof encoding")
+	errBufferTooLarge = errors.New("buffer length exceeds math.MaxInt32")
+)
+
+// rawBlock is a DataBlock backed by the bytes passed from C.
+type rawBlock []byte
+
+// Serialize returns the raw bytes of the block.
+func (b rawBlock) Serialize() ([]byte, error) {
+	return b, nil
+}
+
+func main() {}
+
+// MerkleTreeBuild builds a tree over n buffers (leaves[i] of length lens[i]) and returns a handle,
+// or 0 on failure. The handle must be released with MerkleTreeFree.
+//
+//export MerkleTreeBuild
+func MerkleTreeBuild(leaves **C.uint8_t, lens *C.size_t, n C.size_t, flags C.int) C.uintptr_t {
+	var (
+		leafPtrs = unsafe.Slice(leaves, int(n))
+		leafLens = unsafe.Slice(lens, int(n))
+		blocks   = make([][]byte, int(n))
+		err      error
+	)
+
+	for i := range blocks {
+		if blocks[i], err = goBytes(leafPtrs[i], leafLens[i]); err != nil {
+			return 0
+		}
+	}
+
+	tree, err := build(blocks, int(flags))
+	if err != nil {
+		return 0
+	}
+
+	return C.uintptr_t(cgo.NewHandle(tree))
+}
+
+// MerkleTreeFree releases the tree referenced by handle.
+//
+//export MerkleTreeFree
+func MerkleTreeFree(handle C.uintptr_t) {
+	cgo.Handle(handle).Delete()
+}
+
+// MerkleTreeRoot copies the root into out if outCap is large enough and returns the root length.
+// Call it with outCap 0 to query the required size.
+//
+//export MerkleTreeRoot
+func MerkleTreeRoot(handle C.uintptr_t, out *C.uint8_t, outCap C.size_t) C.size_t {
+	tree := cgo.Handle(handle).Value().(*mt.MerkleTree)
+
+	return copyOut(tree.Root, out, outCap)
+}
+
+// MerkleTreeProof copies the encoded proof of leaf index into out if outCap is large enough and returns
+// the encoded length, or 0 if index is out of range. Call it with outCap 0 to query the required size.
+//
+//export MerkleTreeProof
+func MerkleTreeProof(handle C.uintptr_t, index C.size_t, out *C.uint8_t, outCap C.size_t) C.size_t {
+	tree := cgo.Handle(handle).Value().(*mt.MerkleTree)
+	if int(index) >= len(tree.Proofs) {
+		return 0
+	}
+
+	return copyOut(encodeProof(tree.Proofs[index]), out, outCap)
+}
+
+// MerkleTreeVerify verifies leaf against root with the encoded proof.
+// It returns 1 if the proof is valid, 0 if it is not and -1 on error.
+//
+//export MerkleTreeVerify
+func MerkleTreeVerify(
+	leaf *C.uint8_t, leafLen C.size_t,
+	proof *C.uint8_t, proofLen C.size_t,
+	root *C.uint8_t, rootLen C.size_t,
+	flags C.int,
+) C.int {
+	leafBytes, err := goBytes(leaf, leafLen)
+	if err != nil {
+		return -1
+	}
+
+	proofBytes, err := goBytes(proof, proofLen)
+	if err != nil {
+		return -1
+	}
+
+	rootBytes, err := goBytes(root, rootLen)
+	if err != nil {
+		return -1
+	}
+
+	return C.int(verify(leafBytes, proofBytes, rootBytes, int(flags)))
+}
+
+// build builds the tree over the blocks with the configuration of the flags.
+func build(blocks [][]byte, flags int) (*mt.MerkleTree, error) {
+	dataBlocks := make([]mt.DataBlock, len(blocks))
+	for i, block := range blocks {
+		dataBlocks[i] = rawBlock(block)
+	}
+
+	return mt.New(configFromFlags(flags), dataBlocks)
+}
+
+// verify verifies the leaf against the root with the encoded proof, returning the result of MerkleTreeVerify.
+func verify(leaf, proof, root []byte, flags int) int {
+	p, err := decodeProof(proof)
+	if err != nil {
+		return -1
+	}
+
+	ok, err := mt.Verify(rawBlock(leaf), p, root, configFromFlags(flags))
+	if err != nil {
+		return -1
+	}
+
+	if ok {
+		return 1
+	}
+
+	return 0
+}
+
+// goBytes copies the n bytes at p, rejecting the lengths C.GoBytes would truncate.
+func goBytes(p *C.uint8_t, n C.size_t) ([]byte, error) {
+	if uint64(n) > math.MaxInt32 {
+		return nil, errBufferTooLarge
+	}
+
+	return C.GoBytes(unsafe.Pointer(p), C.int(n)), nil
+}
+
+func configFromFlags(flags int) *mt.Config {
+	return &mt.Config{
+		SortSiblingPairs:   flags&flagSortSiblingPairs != 0,
+		DisableLeafHashing: flags&flagDisableLeafHashing != 0,
+		RunInParallel:      flags&flagRunInParallel != 0,
+	}
+}
+
+func copyOut(b []byte, out *C.uint8_t, outCap C.size_t) C.size_t {
+	if out != nil && int(outCap) >= len(b) {
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(out)), len(b)), b)
+	}
+
+	return C.size_t(len(b))
+}
+
+func encodeProof(p *mt.Proof) []byte {
+	size := 8
+	for _, sib := range p.Siblings {
+		size += 4 + len(sib)
+	}
+
+	buf := make([]byte, 0, size)
+	buf = binary.BigEndian.AppendUint32(buf, p.Path)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(p.Siblings)))
+
+	for _, sib := range p.Siblings {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(sib)))
+		buf = append(buf, sib...)
+	}
+
+	return buf
+}
+
+func decodeProof(buf []byte) (*mt.Proof, error) {
+	if len(buf) < 8 {
+		return nil, errMalformedProof
+	}
+
+	p := &mt.Proof{Path: binary.BigEndian.Uint32(buf)}
+	numSiblings := binary.BigEndian.Uint32(buf[4:])
+	buf = buf[8:]
+
+	for i := uint32(0); i < numSiblings; i++ {
+		if len(buf) < 4 {
+			return nil, errMalformedProof
+		}
+
+		sibLen := binary.BigEndian.Uint32(buf)
+		buf = buf[4:]
+
+		if uint32(len(buf)) < sibLen {
+			return nil, errMalformedProof
+		}
+
+		p.Siblings = append(p.Siblings, buf[:sibLen])
+		buf = buf[sibLen:]
+	}
+
+	if len(buf) != 0 {
+		return nil, errMalformedProof
+	}
+
+	return p, nil
+}
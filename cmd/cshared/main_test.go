@@ -0,0 +1,127 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build cgo
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+func TestEncodeProof(t *testing.T) {
+	tests := []struct {
+		name  string
+		proof *mt.Proof
+	}{
+		{name: "test_no_siblings", proof: &mt.Proof{}},
+		{name: "test_siblings", proof: &mt.Proof{Path: 5, Siblings: [][]byte{{1, 2, 3}, {}, bytes.Repeat([]byte{4}, 32)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := encodeProof(tt.proof)
+			got, err := decodeProof(buf)
+			if err != nil {
+				t.Fatalf("decodeProof() error = %v", err)
+			}
+			if got.Path != tt.proof.Path || len(got.Siblings) != len(tt.proof.Siblings) {
+				t.Fatalf("decodeProof() = %v, want %v", got, tt.proof)
+			}
+			for i := range got.Siblings {
+				if !bytes.Equal(got.Siblings[i], tt.proof.Siblings[i]) {
+					t.Errorf("decodeProof() sibling %d = %x, want %x", i, got.Siblings[i], tt.proof.Siblings[i])
+				}
+			}
+			for n := 0; n < len(buf); n++ {
+				if _, err := decodeProof(buf[:n]); !errors.Is(err, errMalformedProof) {
+					t.Errorf("decodeProof() of %d bytes error = %v, want %v", n, err, errMalformedProof)
+				}
+			}
+			if _, err := decodeProof(append(buf, 0)); !errors.Is(err, errMalformedProof) {
+				t.Errorf("decodeProof() with a trailing byte error = %v, want %v", err, errMalformedProof)
+			}
+		})
+	}
+}
+
+func TestBuildAndVerify(t *testing.T) {
+	blocks := make([][]byte, 7)
+	dataBlocks := make([]mt.DataBlock, len(blocks))
+	for i := range blocks {
+		blocks[i] = []byte(fmt.Sprintf("block %d", i))
+		dataBlocks[i] = rawBlock(blocks[i])
+	}
+
+	tests := []struct {
+		name   string
+		flags  int
+		config *mt.Config
+	}{
+		{name: "test_default", config: &mt.Config{}},
+		{name: "test_sort_sibling_pairs", flags: flagSortSiblingPairs, config: &mt.Config{SortSiblingPairs: true}},
+		{name: "test_disable_leaf_hashing", flags: flagDisableLeafHashing, config: &mt.Config{DisableLeafHashing: true}},
+		{name: "test_run_in_parallel", flags: flagRunInParallel | flagSortSiblingPairs, config: &mt.Config{SortSiblingPairs: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := mt.New(tt.config, dataBlocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			tree, err := build(blocks, tt.flags)
+			if err != nil {
+				t.Fatalf("build() error = %v", err)
+			}
+			if !bytes.Equal(tree.Root, want.Root) || !reflect.DeepEqual(tree.Proofs, want.Proofs) {
+				t.Fatalf("build() root = %x, want the Go root %x", tree.Root, want.Root)
+			}
+			for i, block := range blocks {
+				proof := encodeProof(want.Proofs[i])
+				if got := verify(block, proof, want.Root, tt.flags); got != 1 {
+					t.Errorf("verify() %d = %d, want 1", i, got)
+				}
+				if got := verify(blocks[(i+1)%len(blocks)], proof, want.Root, tt.flags); got != 0 {
+					t.Errorf("verify() %d with another block = %d, want 0", i, got)
+				}
+			}
+			if got := verify(blocks[0], []byte{0}, want.Root, tt.flags); got != -1 {
+				t.Errorf("verify() with a malformed proof = %d, want -1", got)
+			}
+		})
+	}
+}
+
+func TestGoBytes(t *testing.T) {
+	if _, err := goBytes(nil, math.MaxInt32+1); !errors.Is(err, errBufferTooLarge) {
+		t.Errorf("goBytes() error = %v, want %v", err, errBufferTooLarge)
+	}
+	if b, err := goBytes(nil, 0); err != nil || len(b) != 0 {
+		t.Errorf("goBytes() = %x, %v, want no bytes", b, err)
+	}
+}
@@ -0,0 +1,66 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// C API of libmerkletree, built with `make build_cshared`. See main.go for the proof encoding.
+// The library is built with this header, so that its declarations match the exported functions.
+// Buffer lengths above INT32_MAX are rejected.
+
+#ifndef MERKLETREE_H
+#define MERKLETREE_H
+
+#include <stddef.h>
+#include <stdint.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// Flags accepted by MerkleTreeBuild and MerkleTreeVerify.
+#define MT_SORT_SIBLING_PAIRS   1
+#define MT_DISABLE_LEAF_HASHING 2
+#define MT_RUN_IN_PARALLEL      4
+
+// MerkleTreeBuild builds a tree over n buffers (leaves[i] of length lens[i]) and returns a handle,
+// or 0 on failure. The handle must be released with MerkleTreeFree.
+extern uintptr_t MerkleTreeBuild(uint8_t** leaves, size_t* lens, size_t n, int flags);
+
+// MerkleTreeFree releases the tree referenced by handle.
+extern void MerkleTreeFree(uintptr_t handle);
+
+// MerkleTreeRoot copies the root into out if outCap is large enough and returns the root length.
+// Call it with outCap 0 to query the required size.
+extern size_t MerkleTreeRoot(uintptr_t handle, uint8_t* out, size_t outCap);
+
+// MerkleTreeProof copies the encoded proof of leaf index into out if outCap is large enough and returns
+// the encoded length, or 0 if index is out of range. Call it with outCap 0 to query the required size.
+extern size_t MerkleTreeProof(uintptr_t handle, size_t index, uint8_t* out, size_t outCap);
+
+// MerkleTreeVerify verifies leaf against root with the encoded proof.
+// It returns 1 if the proof is valid, 0 if it is not and -1 on error.
+extern int MerkleTreeVerify(uint8_t* leaf, size_t leafLen, uint8_t* proof, size_t proofLen,
+	uint8_t* root, size_t rootLen, int flags);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif
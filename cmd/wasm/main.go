@@ -0,0 +1,225 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build js && wasm
+
+// Command wasm exposes the Merkle Tree build, proof and verification API to JavaScript.
+// Build it with `make build_wasm` and load it through merkletree.js, so that browsers verify
+// proofs with exactly the same code that produced them.
+package main
+
+import (
+	"errors"
+	"sync"
+	"syscall/js"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+// errInvalidHandle is returned when JavaScript refers to a tree that does not exist.
+var errInvalidHandle = errors.New("invalid merkle tree handle")
+
+// rawBlock is a DataBlock backed by the bytes passed from JavaScript.
+type rawBlock []byte
+
+// Serialize returns the raw bytes of the block.
+func (b rawBlock) Serialize() ([]byte, error) {
+	return b, nil
+}
+
+// trees holds the trees built from JavaScript, indexed by their handle.
+var (
+	trees      = make(map[int]*mt.MerkleTree)
+	treesMu    sync.Mutex
+	nextHandle = 1
+)
+
+func main() {
+	js.Global().Set("goMerkleTree", js.ValueOf(map[string]any{
+		"build":   js.FuncOf(build),
+		"proof":   js.FuncOf(proof),
+		"verify":  js.FuncOf(verify),
+		"release": js.FuncOf(release),
+	}))
+
+	// Keep the Go runtime alive so that the exported functions stay callable.
+	select {}
+}
+
+// build(leaves: Uint8Array[], options?: object) => {handle: number, root: Uint8Array}.
+func build(_ js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return jsError(errors.New("build: missing leaves argument"))
+	}
+
+	leaves := args[0]
+	blocks := make([]mt.DataBlock, leaves.Length())
+
+	for i := range blocks {
+		blocks[i] = rawBlock(bytesFromJS(leaves.Index(i)))
+	}
+
+	config := configFromJS(optionalArg(args, 1))
+	config.Mode = mt.ModeTreeBuild
+
+	tree, err := mt.New(config, blocks)
+	if err != nil {
+		return jsError(err)
+	}
+
+	treesMu.Lock()
+	handle := nextHandle
+	nextHandle++
+	trees[handle] = tree
+	treesMu.Unlock()
+
+	return map[string]any{
+		"handle": handle,
+		"root":   bytesToJS(tree.Root),
+	}
+}
+
+// proof(handle: number, leaf: Uint8Array) => {siblings: Uint8Array[], path: number}.
+func proof(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return jsError(errors.New("proof: missing handle or leaf argument"))
+	}
+
+	treesMu.Lock()
+	tree, ok := trees[args[0].Int()]
+	treesMu.Unlock()
+
+	if !ok {
+		return jsError(errInvalidHandle)
+	}
+
+	p, err := tree.Proof(rawBlock(bytesFromJS(args[1])))
+	if err != nil {
+		return jsError(err)
+	}
+
+	return proofToJS(p)
+}
+
+// verify(leaf: Uint8Array, proof: object, root: Uint8Array, options?: object) => boolean.
+func verify(_ js.Value, args []js.Value) any {
+	if len(args) < 3 {
+		return jsError(errors.New("verify: missing leaf, proof or root argument"))
+	}
+
+	ok, err := mt.Verify(
+		rawBlock(bytesFromJS(args[0])),
+		proofFromJS(args[1]),
+		bytesFromJS(args[2]),
+		configFromJS(optionalArg(args, 3)),
+	)
+	if err != nil {
+		return jsError(err)
+	}
+
+	return ok
+}
+
+// release(handle: number) frees the tree referenced by the handle.
+func release(_ js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return jsError(errInvalidHandle)
+	}
+
+	treesMu.Lock()
+	delete(trees, args[0].Int())
+	treesMu.Unlock()
+
+	return nil
+}
+
+func optionalArg(args []js.Value, idx int) js.Value {
+	if idx < len(args) {
+		return args[idx]
+	}
+
+	return js.Undefined()
+}
+
+// configFromJS reads {sortSiblingPairs?: boolean, disableLeafHashing?: boolean}.
+// A fresh config is returned on every call, as the default hash function is not concurrent-safe.
+func configFromJS(v js.Value) *mt.Config {
+	config := new(mt.Config)
+	if v.IsUndefined() || v.IsNull() {
+		return config
+	}
+
+	if opt := v.Get("sortSiblingPairs"); opt.Truthy() {
+		config.SortSiblingPairs = true
+	}
+
+	if opt := v.Get("disableLeafHashing"); opt.Truthy() {
+		config.DisableLeafHashing = true
+	}
+
+	return config
+}
+
+func proofToJS(p *mt.Proof) any {
+	siblings := make([]any, len(p.Siblings))
+	for i, sib := range p.Siblings {
+		siblings[i] = bytesToJS(sib)
+	}
+
+	return map[string]any{
+		"siblings": siblings,
+		"path":     p.Path,
+	}
+}
+
+func proofFromJS(v js.Value) *mt.Proof {
+	jsSiblings := v.Get("siblings")
+	p := &mt.Proof{
+		Siblings: make([][]byte, jsSiblings.Length()),
+		Path:     uint32(v.Get("path").Int()),
+	}
+
+	for i := range p.Siblings {
+		p.Siblings[i] = bytesFromJS(jsSiblings.Index(i))
+	}
+
+	return p
+}
+
+func bytesFromJS(v js.Value) []byte {
+	b := make([]byte, v.Length())
+	js.CopyBytesToGo(b, v)
+
+	return b
+}
+
+func bytesToJS(b []byte) js.Value {
+	v := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(v, b)
+
+	return v
+}
+
+// jsError converts a Go error into a JavaScript Error object, which merkletree.js throws.
+func jsError(err error) js.Value {
+	return js.Global().Get("Error").New(err.Error())
+}
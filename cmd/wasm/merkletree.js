@@ -0,0 +1,38 @@
+// Thin JavaScript wrapper around the go-merkletree WebAssembly module.
+//
+// Usage:
+//   const mt = await loadMerkleTree("merkletree.wasm");
+//   const { handle, root } = mt.build([leafA, leafB, leafC]);
+//   const proof = mt.proof(handle, leafB);
+//   mt.verify(leafB, proof, root); // true
+//   mt.release(handle);
+//
+// wasm_exec.js from the Go distribution ($(go env GOROOT)/lib/wasm) must be loaded first.
+
+async function loadMerkleTree(wasmURL) {
+  const go = new Go();
+  const source = fetch(wasmURL);
+  const { instance } = WebAssembly.instantiateStreaming
+    ? await WebAssembly.instantiateStreaming(source, go.importObject)
+    : await WebAssembly.instantiate(await (await source).arrayBuffer(), go.importObject);
+  go.run(instance);
+
+  const api = globalThis.goMerkleTree;
+  const unwrap = (result) => {
+    if (result instanceof Error) {
+      throw result;
+    }
+    return result;
+  };
+
+  return {
+    build: (leaves, options) => unwrap(api.build(leaves, options)),
+    proof: (handle, leaf) => unwrap(api.proof(handle, leaf)),
+    verify: (leaf, proof, root, options) => unwrap(api.verify(leaf, proof, root, options)),
+    release: (handle) => unwrap(api.release(handle)),
+  };
+}
+
+if (typeof module !== "undefined") {
+  module.exports = { loadMerkleTree };
+}
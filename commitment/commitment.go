@@ -0,0 +1,228 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package commitment implements the hash commitment pattern used by privacy-preserving audits
+// (e.g. proof of liabilities): every leaf commits to Hash(value || nonce) with a random nonce,
+// the nonces are exported encrypted to their subjects, and individual leaves are later opened with proofs.
+package commitment
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+// NonceSize is the size in bytes of the random nonce of every commitment.
+const NonceSize = 32
+
+var (
+	// ErrRecipientsMismatch is the error for exporting a commitment file with a number of recipients
+	// different from the number of leaves.
+	ErrRecipientsMismatch = errors.New("number of recipients does not match number of leaves")
+	// ErrDecryption is the error for an encrypted nonce that cannot be decrypted with the key.
+	ErrDecryption = errors.New("cannot decrypt nonce")
+)
+
+// committedValue is the data block Hash(value || nonce) is computed over.
+type committedValue struct {
+	value []byte
+	nonce []byte
+}
+
+// Serialize returns the value followed by the nonce.
+func (c *committedValue) Serialize() ([]byte, error) {
+	buf := make([]byte, 0, len(c.value)+len(c.nonce))
+	buf = append(buf, c.value...)
+
+	return append(buf, c.nonce...), nil
+}
+
+// Tree is a Merkle Tree of commitments.
+type Tree struct {
+	*mt.MerkleTree
+	values [][]byte
+	nonces [][]byte
+}
+
+// Opening opens a single commitment: the value, its nonce and the inclusion proof.
+type Opening struct {
+	Index int                 `json:"index"`
+	Value mt.HexBytes         `json:"value"`
+	Nonce mt.HexBytes         `json:"nonce"`
+	Proof *mt.SerializedProof `json:"proof"`
+}
+
+// Build commits to the values with fresh random nonces and builds the tree.
+// Leaf hashing must stay enabled for the commitments to hide the values, and neither LeafLess nor
+// SkipNilBlocks may be set for the openings to match the value indexes.
+func Build(config *mt.Config, values [][]byte) (*Tree, error) {
+	if config != nil && config.DisableLeafHashing {
+		return nil, mt.ErrSaltedLeafHashingDisabled
+	}
+
+	if config != nil && (config.LeafLess != nil || config.SkipNilBlocks) {
+		return nil, fmt.Errorf("Build: %w", mt.ErrBlockOrderUnsupported)
+	}
+
+	var (
+		nonces   = make([][]byte, len(values))
+		blocks   = make([]mt.DataBlock, len(values))
+		nonceBuf = make([]byte, NonceSize*len(values))
+	)
+
+	if _, err := rand.Read(nonceBuf); err != nil {
+		return nil, fmt.Errorf("Build: %w", err)
+	}
+
+	for i, v := range values {
+		nonces[i] = nonceBuf[i*NonceSize : (i+1)*NonceSize]
+		blocks[i] = &committedValue{value: v, nonce: nonces[i]}
+	}
+
+	m, err := mt.New(config, blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tree{MerkleTree: m, values: values, nonces: nonces}, nil
+}
+
+// Open returns the opening of the commitment at idx. The tree must have been built with proofs.
+func (t *Tree) Open(idx int) (*Opening, error) {
+	if idx < 0 || idx >= len(t.values) {
+		return nil, mt.ErrIndexOutOfRange
+	}
+
+	if t.Proofs == nil {
+		return nil, mt.ErrProofIsNil
+	}
+
+	return &Opening{
+		Index: idx,
+		Value: t.values[idx],
+		Nonce: t.nonces[idx],
+		Proof: mt.NewSerializedProof(t.Proofs[idx]),
+	}, nil
+}
+
+// VerifyOpening checks that the opened value and nonce are committed under the root.
+func VerifyOpening(opening *Opening, root []byte, config *mt.Config) (bool, error) {
+	if opening == nil || opening.Proof == nil {
+		return false, mt.ErrProofIsNil
+	}
+
+	block := &committedValue{value: opening.Value, nonce: opening.Nonce}
+
+	return mt.Verify(block, opening.Proof.Proof(), root, config)
+}
+
+// EncryptedNonce is the nonce of a commitment encrypted to its subject with X25519 and AES-256-GCM.
+type EncryptedNonce struct {
+	Index        int         `json:"index"`
+	EphemeralKey mt.HexBytes `json:"ephemeralKey"`
+	Ciphertext   mt.HexBytes `json:"ciphertext"`
+}
+
+// CommitmentFile is the published list of encrypted nonces, one per leaf.
+type CommitmentFile struct {
+	Root   mt.HexBytes      `json:"root"`
+	Nonces []EncryptedNonce `json:"nonces"`
+}
+
+// ExportCommitmentFile encrypts the nonce of every leaf to the X25519 public key of its subject,
+// recipients[i] being the subject of leaf i.
+func (t *Tree) ExportCommitmentFile(recipients []*ecdh.PublicKey) (*CommitmentFile, error) {
+	if len(recipients) != len(t.nonces) {
+		return nil, ErrRecipientsMismatch
+	}
+
+	file := &CommitmentFile{
+		Root:   t.Root,
+		Nonces: make([]EncryptedNonce, len(t.nonces)),
+	}
+
+	for i, recipient := range recipients {
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		aead, err := sealKey(ephemeral, recipient, ephemeral.PublicKey())
+		if err != nil {
+			return nil, err
+		}
+
+		file.Nonces[i] = EncryptedNonce{
+			Index:        i,
+			EphemeralKey: ephemeral.PublicKey().Bytes(),
+			Ciphertext:   aead.Seal(nil, make([]byte, aead.NonceSize()), t.nonces[i], nil),
+		}
+	}
+
+	return file, nil
+}
+
+// Decrypt recovers the nonce with the X25519 private key of the subject.
+func (e *EncryptedNonce) Decrypt(key *ecdh.PrivateKey) ([]byte, error) {
+	ephemeral, err := ecdh.X25519().NewPublicKey(e.EphemeralKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecryption, err)
+	}
+
+	aead, err := sealKey(key, ephemeral, ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := aead.Open(nil, make([]byte, aead.NonceSize()), e.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+
+	return nonce, nil
+}
+
+// sealKey derives the AES-256-GCM cipher from the X25519 shared secret and the ephemeral public key.
+// Every ephemeral key is used once, so a fixed GCM nonce is safe.
+func sealKey(priv *ecdh.PrivateKey, pub, ephemeral *ecdh.PublicKey) (cipher.AEAD, error) {
+	shared, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecryption, err)
+	}
+
+	h := sha256.New()
+	h.Write(shared)
+	h.Write(ephemeral.Bytes())
+
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,93 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commitment
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+func TestBuild(t *testing.T) {
+	values := [][]byte{[]byte("alice:100"), []byte("bob:250"), []byte("carol:7")}
+	keys := make([]*ecdh.PrivateKey, len(values))
+	recipients := make([]*ecdh.PublicKey, len(values))
+	for i := range keys {
+		key, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey() error = %v", err)
+		}
+		keys[i], recipients[i] = key, key.PublicKey()
+	}
+
+	tree, err := Build(nil, values)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	file, err := tree.ExportCommitmentFile(recipients)
+	if err != nil {
+		t.Fatalf("ExportCommitmentFile() error = %v", err)
+	}
+
+	for i := range values {
+		opening, err := tree.Open(i)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		if ok, err := VerifyOpening(opening, file.Root, nil); err != nil || !ok {
+			t.Errorf("VerifyOpening() %d = %v, error = %v", i, ok, err)
+		}
+		// The subject recovers its nonce from the commitment file and checks its own leaf.
+		nonce, err := file.Nonces[i].Decrypt(keys[i])
+		if err != nil {
+			t.Fatalf("Decrypt() error = %v", err)
+		}
+		if !bytes.Equal(nonce, opening.Nonce) {
+			t.Errorf("Decrypt() %d nonce mismatch", i)
+		}
+		if _, err := file.Nonces[i].Decrypt(keys[(i+1)%len(keys)]); err != ErrDecryption {
+			t.Errorf("Decrypt() with other key error = %v", err)
+		}
+		opening.Value = []byte("mallory:1000000")
+		if ok, err := VerifyOpening(opening, file.Root, nil); err != nil || ok {
+			t.Errorf("VerifyOpening() forged value = %v, error = %v", ok, err)
+		}
+	}
+
+	if _, err := tree.ExportCommitmentFile(recipients[:1]); err != ErrRecipientsMismatch {
+		t.Errorf("ExportCommitmentFile() error = %v", err)
+	}
+}
+
+func TestBuild_blockPositions(t *testing.T) {
+	values := [][]byte{[]byte("alice:100"), []byte("bob:250")}
+	for _, config := range []*mt.Config{{LeafLess: mt.LeafLessBytes}, {SkipNilBlocks: true}} {
+		if _, err := Build(config, values); !errors.Is(err, mt.ErrBlockOrderUnsupported) {
+			t.Errorf("Build() error = %v, want ErrBlockOrderUnsupported", err)
+		}
+	}
+}
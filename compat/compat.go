@@ -0,0 +1,294 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package compat checks golden fixtures of Merkle trees, e.g. computed by other implementations, against
+// this package: a Fixture lists the leaves of a tree, its root and proofs of some leaves, with the parameters
+// of the implementation that computed them. A Harness rebuilds the tree from the leaves, compares the root
+// and the regenerated proofs, and verifies the fixture proofs.
+//
+// The parameters follow mt.VerificationSpec. Fixtures whose sibling combination is not the one of this
+// package, e.g. the concatenation of merkletreejs, OpenZeppelin's merkle-tree or rs-merkle, cannot be
+// checked and are reported with ErrUnsupportedFixture. For this reason, the fixtures of the testdata
+// directory are only golden trees of this package, guarding its own output against regressions: checking
+// the fixtures of these implementations requires a concatenating sibling combination, which this package
+// does not implement yet.
+package compat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+var (
+	// ErrUnsupportedFixture is the error for a fixture whose parameters this package does not implement.
+	ErrUnsupportedFixture = errors.New("compat: unsupported fixture parameters")
+	// ErrMismatch is the error for a fixture whose root or proofs differ from the ones of this package.
+	ErrMismatch = errors.New("compat: fixture mismatch")
+)
+
+// Fixture is a golden tree: its leaves, root and proofs, and the parameters they were computed with.
+type Fixture struct {
+	// Name identifies the fixture in reports.
+	Name string `json:"name"`
+	// Source names the implementation that computed the fixture, e.g. "go-merkletree".
+	Source string `json:"source"`
+	// HashFunction is the name of the hash function, looked up in Harness.HashFuncs.
+	HashFunction string `json:"hashFunction"`
+	// LeafHashing tells whether the leaves are the hashes of the data blocks or the data blocks themselves.
+	LeafHashing bool `json:"leafHashing"`
+	// SortSiblingPairs tells whether sibling pairs are ordered lexicographically before being combined.
+	SortSiblingPairs bool `json:"sortSiblingPairs"`
+	// Combine names the combination of two children into the input of their parent hash,
+	// see mt.VerificationSpec.
+	Combine string `json:"combine"`
+	// DataBlocks are the serialized data blocks of the leaves, in leaf order.
+	DataBlocks []mt.HexBytes `json:"dataBlocks"`
+	// Root is the Merkle root.
+	Root mt.HexBytes `json:"root"`
+	// Proofs are proofs of some of the leaves.
+	Proofs []FixtureProof `json:"proofs"`
+}
+
+// FixtureProof is the proof of a leaf of a Fixture.
+type FixtureProof struct {
+	// Index is the index of the leaf.
+	Index int `json:"index"`
+	// Siblings are the siblings from the leaf level up.
+	Siblings []mt.HexBytes `json:"siblings"`
+	// SiblingIsLeft tells for each sibling whether it is on the left. It may be omitted if the fixture sorts
+	// sibling pairs, whose positions do not matter.
+	SiblingIsLeft []bool `json:"siblingIsLeft,omitempty"`
+}
+
+// dataBlock is a serialized data block of a fixture.
+type dataBlock []byte
+
+func (b dataBlock) Serialize() ([]byte, error) {
+	return b, nil
+}
+
+// LoadFixtures reads the fixtures of the JSON files of the directory, each holding a fixture or an array
+// of fixtures, in file name order.
+func LoadFixtures(dir string) ([]*Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+
+	var fixtures []*Fixture
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var batch []*Fixture
+		if len(bytes.TrimSpace(data)) > 0 && bytes.TrimSpace(data)[0] == '[' {
+			err = json.Unmarshal(data, &batch)
+		} else {
+			f := new(Fixture)
+			err = json.Unmarshal(data, f)
+			batch = []*Fixture{f}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("compat: %s: %w", path, err)
+		}
+
+		fixtures = append(fixtures, batch...)
+	}
+
+	return fixtures, nil
+}
+
+// Harness checks fixtures against this package.
+type Harness struct {
+	// HashFuncs are the hash functions of the fixtures by name. If nil, the presets of mt.Diagnose are used.
+	HashFuncs map[string]mt.TypeHashFunc
+}
+
+// hashFunc returns the hash function of the given name.
+func (h *Harness) hashFunc(name string) (mt.TypeHashFunc, bool) {
+	if h != nil && h.HashFuncs != nil {
+		hashFunc, ok := h.HashFuncs[name]
+
+		return hashFunc, ok
+	}
+
+	for _, preset := range mt.DiagnosisHashPresets {
+		if preset.Name == name {
+			return preset.HashFunc, true
+		}
+	}
+
+	return nil, false
+}
+
+// Config returns the configuration of this package reproducing the fixture, or an error matching
+// ErrUnsupportedFixture if there is none.
+func (h *Harness) Config(f *Fixture) (*mt.Config, error) {
+	hashFunc, ok := h.hashFunc(f.HashFunction)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown hash function %q", ErrUnsupportedFixture, f.HashFunction)
+	}
+
+	config := &mt.Config{
+		HashFunc:           hashFunc,
+		SortSiblingPairs:   f.SortSiblingPairs,
+		DisableLeafHashing: !f.LeafHashing,
+	}
+
+	spec, err := mt.NewVerificationSpec(config, f.HashFunction)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Combine != spec.Combine {
+		return nil, fmt.Errorf("%w: sibling combination %q, want %q", ErrUnsupportedFixture, f.Combine, spec.Combine)
+	}
+
+	return config, nil
+}
+
+// Check rebuilds the tree of the fixture, compares its root and the regenerated proofs with the fixture,
+// and verifies the fixture proofs. It returns an error matching ErrMismatch if they differ, or
+// ErrUnsupportedFixture if the fixture cannot be reproduced.
+func (h *Harness) Check(f *Fixture) error {
+	config, err := h.Config(f)
+	if err != nil {
+		return err
+	}
+
+	blocks := make([]mt.DataBlock, len(f.DataBlocks))
+	for i, data := range f.DataBlocks {
+		blocks[i] = dataBlock(data)
+	}
+
+	tree, err := mt.New(config, blocks)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(tree.Root, f.Root) {
+		return fmt.Errorf("%w: root %x, want %x", ErrMismatch, tree.Root, []byte(f.Root))
+	}
+
+	for _, fp := range f.Proofs {
+		if fp.Index < 0 || fp.Index >= len(blocks) {
+			return fmt.Errorf("%w: proof of leaf %d out of range", ErrMismatch, fp.Index)
+		}
+
+		proof, err := fixtureProof(fp, f.SortSiblingPairs)
+		if err != nil {
+			return err
+		}
+
+		ok, err := mt.Verify(blocks[fp.Index], proof, f.Root, config)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return fmt.Errorf("%w: proof of leaf %d does not verify", ErrMismatch, fp.Index)
+		}
+
+		// Sorted pairs do not bind the path, so only the siblings are regenerated.
+		want := tree.Proofs[fp.Index]
+		if len(want.Siblings) != len(proof.Siblings) ||
+			(!f.SortSiblingPairs && want.Path != proof.Path) {
+			return fmt.Errorf("%w: proof of leaf %d differs from the regenerated proof", ErrMismatch, fp.Index)
+		}
+
+		for i := range want.Siblings {
+			if !bytes.Equal(want.Siblings[i], proof.Siblings[i]) {
+				return fmt.Errorf("%w: sibling %d of the proof of leaf %d differs from the regenerated proof",
+					ErrMismatch, i, fp.Index)
+			}
+		}
+	}
+
+	return nil
+}
+
+// fixtureProof converts the proof of a fixture.
+func fixtureProof(fp FixtureProof, sorted bool) (*mt.Proof, error) {
+	dirs := fp.SiblingIsLeft
+	if dirs == nil && sorted {
+		dirs = make([]bool, len(fp.Siblings))
+	}
+
+	if len(dirs) != len(fp.Siblings) {
+		return nil, fmt.Errorf("%w: proof of leaf %d: %w", ErrMismatch, fp.Index, mt.ErrDirectionalProofLengthMismatch)
+	}
+
+	siblings := make([][]byte, len(fp.Siblings))
+	for i, sib := range fp.Siblings {
+		siblings[i] = sib
+	}
+
+	return (&mt.DirectionalProof{Siblings: siblings, SiblingIsLeft: dirs}).Proof(), nil
+}
+
+// Run checks the fixtures of the directory as subtests of t named after the fixtures, skipping the
+// unsupported ones, so that integrators can check their own fixtures from their tests:
+//
+//	func TestFixtures(t *testing.T) {
+//		compat.Run(t, "testdata/fixtures", nil)
+//	}
+func Run(t *testing.T, dir string, h *Harness) {
+	t.Helper()
+
+	fixtures, err := LoadFixtures(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fixtures) == 0 {
+		t.Fatalf("compat: no fixtures in %s", dir)
+	}
+
+	for _, f := range fixtures {
+		f := f
+
+		t.Run(f.Name, func(t *testing.T) {
+			err := h.Check(f)
+			if errors.Is(err, ErrUnsupportedFixture) {
+				t.Skipf("%s fixture: %v", f.Source, err)
+			}
+
+			if err != nil {
+				t.Errorf("%s fixture: %v", f.Source, err)
+			}
+		})
+	}
+}
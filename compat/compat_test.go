@@ -0,0 +1,83 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package compat
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFixtures(t *testing.T) {
+	Run(t, "testdata", nil)
+}
+
+func TestHarness_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(f *Fixture)
+		wantErr error
+	}{
+		{name: "test_valid", tamper: func(*Fixture) {}},
+		{name: "test_root", tamper: func(f *Fixture) { f.Root[0] ^= 1 }, wantErr: ErrMismatch},
+		{name: "test_sibling", tamper: func(f *Fixture) { f.Proofs[0].Siblings[0][0] ^= 1 }, wantErr: ErrMismatch},
+		{
+			name:    "test_direction",
+			tamper:  func(f *Fixture) { f.Proofs[1].SiblingIsLeft[0] = !f.Proofs[1].SiblingIsLeft[0] },
+			wantErr: ErrMismatch,
+		},
+		{name: "test_data_block", tamper: func(f *Fixture) { f.DataBlocks[1][0] ^= 1 }, wantErr: ErrMismatch},
+		{name: "test_concatenation", tamper: func(f *Fixture) { f.Combine = "concat" }, wantErr: ErrUnsupportedFixture},
+		{name: "test_keccak256", tamper: func(f *Fixture) { f.HashFunction = "keccak256" }, wantErr: ErrUnsupportedFixture},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Reload the fixtures, which are tampered with in place.
+			fixtures, err := LoadFixtures("testdata")
+			if err != nil {
+				t.Fatalf("LoadFixtures() error = %v", err)
+			}
+
+			f := fixture(t, fixtures, "sha256_8")
+			tt.tamper(f)
+
+			if err := new(Harness).Check(f); !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("Check() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fixture returns the fixture of the given name.
+func fixture(t *testing.T, fixtures []*Fixture, name string) *Fixture {
+	t.Helper()
+
+	for _, f := range fixtures {
+		if f.Name == name {
+			return f
+		}
+	}
+
+	t.Fatalf("no fixture %q", name)
+
+	return nil
+}
@@ -0,0 +1,284 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"math/bits"
+)
+
+// ConsistencyProof proves that a tree of NewSize leaves extends a tree of OldSize leaves, i.e. that its first
+// OldSize leaves are the leaves of the old tree. Nodes are the roots of the maximal complete subtrees covering
+// the leaves 0 to OldSize-1, then the leaves OldSize to NewSize-1, from left to right: complete subtrees do not
+// depend on the padding of odd levels, so they are shared by both trees, and the roots of both trees are
+// computed from them.
+type ConsistencyProof struct {
+	OldSize int
+	NewSize int
+	Nodes   [][]byte
+}
+
+// rangeNode is a node of a compact range, the root of a complete subtree of 2^level leaves,
+// at the given index of its level.
+type rangeNode struct {
+	level int
+	index int
+	node  []byte
+}
+
+// ConsistencyProof returns the proof that the tree extends its first oldSize leaves.
+// It requires the tree structure, see Proof.
+func (m *MerkleTree) ConsistencyProof(oldSize int) (*ConsistencyProof, error) {
+	if m.Mode == ModeLazy {
+		if err := m.materialize(); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.nodes == nil {
+		return nil, ErrTreeNotBuilt
+	}
+
+	return consistencyProof(oldSize, m.NumLeaves, func(ref NodeRef) []byte {
+		if ref.Level == m.Depth {
+			return m.Root
+		}
+
+		return m.nodes[ref.Level][ref.Index]
+	})
+}
+
+// ConsistencyProof returns the proof that the snapshot extends its first oldSize leaves.
+func (s *TreeSnapshot) ConsistencyProof(oldSize int) (*ConsistencyProof, error) {
+	return consistencyProof(oldSize, s.Size, func(ref NodeRef) []byte {
+		return s.levels[ref.Level][ref.Index]
+	})
+}
+
+// consistencyProof returns the consistency proof between the sizes, reading the complete subtree roots with node.
+func consistencyProof(oldSize, newSize int, node func(NodeRef) []byte) (*ConsistencyProof, error) {
+	if oldSize < 2 || oldSize > newSize {
+		return nil, ErrIndexOutOfRange
+	}
+
+	refs := append(compactRange(0, oldSize), compactRange(oldSize, newSize)...)
+	proof := &ConsistencyProof{
+		OldSize: oldSize,
+		NewSize: newSize,
+		Nodes:   make([][]byte, len(refs)),
+	}
+
+	for i, ref := range refs {
+		proof.Nodes[i] = node(ref)
+	}
+
+	return proof, nil
+}
+
+// VerifyConsistency checks that the tree with newRoot extends the tree with oldRoot according to the proof.
+func VerifyConsistency(proof *ConsistencyProof, oldRoot, newRoot []byte, config *Config) (bool, error) {
+	if proof == nil {
+		return false, ErrProofIsNil
+	}
+
+	if proof.OldSize < 2 || proof.OldSize > proof.NewSize {
+		return false, ErrIndexOutOfRange
+	}
+
+	config = consistencyConfig(config)
+
+	oldRange, ok := proof.oldRange()
+	if !ok || len(proof.Nodes) != len(oldRange)+len(compactRange(proof.OldSize, proof.NewSize)) {
+		return false, nil
+	}
+
+	root, err := compactRangeRoot(oldRange, proof.OldSize, config)
+	if err != nil || !bytes.Equal(root, oldRoot) {
+		return false, err
+	}
+
+	newRange := oldRange
+	for i, ref := range compactRange(proof.OldSize, proof.NewSize) {
+		if newRange, err = appendRangeNode(newRange, rangeNode{level: ref.Level, index: ref.Index,
+			node: proof.Nodes[len(oldRange)+i]}, config); err != nil {
+			return false, err
+		}
+	}
+
+	if root, err = compactRangeRoot(newRange, proof.NewSize, config); err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(root, newRoot), nil
+}
+
+// VerifyExtension checks that appending the data blocks to the tree with oldRoot yields the tree with newRoot,
+// using the nodes of the proof covering the old tree, e.g. a proof from the old size to any larger size.
+// Monitors use it to check the entries appended to a log without storing its previous entries.
+func VerifyExtension(proof *ConsistencyProof, oldRoot []byte, blocks []DataBlock, newRoot []byte,
+	config *Config,
+) (bool, error) {
+	if proof == nil {
+		return false, ErrProofIsNil
+	}
+
+	if proof.OldSize < 2 {
+		return false, ErrIndexOutOfRange
+	}
+
+	config = consistencyConfig(config)
+
+	oldRange, ok := proof.oldRange()
+	if !ok {
+		return false, nil
+	}
+
+	root, err := compactRangeRoot(oldRange, proof.OldSize, config)
+	if err != nil || !bytes.Equal(root, oldRoot) {
+		return false, err
+	}
+
+	newRange := oldRange
+	for i, block := range blocks {
+		if block == nil {
+			return false, ErrDataBlockIsNil
+		}
+
+		leaf, err := dataBlockToLeaf(block, config.leafHashFunc(), config.DisableLeafHashing)
+		if err != nil {
+			return false, err
+		}
+
+		if newRange, err = appendRangeNode(newRange, rangeNode{index: proof.OldSize + i, node: leaf}, config); err != nil {
+			return false, err
+		}
+	}
+
+	if root, err = compactRangeRoot(newRange, proof.OldSize+len(blocks), config); err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(root, newRoot), nil
+}
+
+// consistencyConfig returns the configuration, or the default one, with its default hash function.
+func consistencyConfig(config *Config) *Config {
+	if config == nil {
+		config = new(Config)
+	}
+
+	if config.HashFunc == nil {
+		config.HashFunc = DefaultHashFunc
+	}
+
+	return config
+}
+
+// oldRange returns the compact range of the old tree from the nodes of the proof,
+// or false if the proof has too few nodes.
+func (p *ConsistencyProof) oldRange() ([]rangeNode, bool) {
+	refs := compactRange(0, p.OldSize)
+	if len(p.Nodes) < len(refs) {
+		return nil, false
+	}
+
+	nodes := make([]rangeNode, len(refs))
+	for i, ref := range refs {
+		nodes[i] = rangeNode{level: ref.Level, index: ref.Index, node: p.Nodes[i]}
+	}
+
+	return nodes, true
+}
+
+// compactRange returns the roots of the maximal complete subtrees covering the leaves start to end-1,
+// from left to right.
+func compactRange(start, end int) []NodeRef {
+	var refs []NodeRef
+
+	for start < end {
+		level := bits.Len(uint(end-start)) - 1
+		if start > 0 {
+			level = min(level, bits.TrailingZeros(uint(start)))
+		}
+
+		refs = append(refs, NodeRef{Level: level, Index: start >> level})
+		start += 1 << level
+	}
+
+	return refs
+}
+
+// appendRangeNode appends the root of the complete subtree following the compact range to it,
+// merging the subtrees it completes.
+func appendRangeNode(nodes []rangeNode, n rangeNode, config *Config) ([]rangeNode, error) {
+	concat := concatHash
+	if config.SortSiblingPairs {
+		concat = concatSortHash
+	}
+
+	for len(nodes) > 0 && nodes[len(nodes)-1].level == n.level {
+		node, err := config.hashNode(n.level+1, n.index>>1, concat(nodes[len(nodes)-1].node, n.node))
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = nodes[:len(nodes)-1]
+		n = rangeNode{level: n.level + 1, index: n.index >> 1, node: node}
+	}
+
+	return append(nodes, n), nil
+}
+
+// compactRangeRoot computes the root of the tree of size leaves from its compact range, padding odd levels
+// by duplicating their last node as the tree does.
+func compactRangeRoot(nodes []rangeNode, size int, config *Config) ([]byte, error) {
+	concat := concatHash
+	if config.SortSiblingPairs {
+		concat = concatSortHash
+	}
+
+	// The last node is the smallest complete subtree, at the level of the lowest set bit of size.
+	var (
+		i     = len(nodes) - 1
+		root  = nodes[i].node
+		level = nodes[i].level
+		err   error
+	)
+
+	for ; (size-1)>>level > 0; level++ {
+		// The rightmost node of the level is a right child if its index, size>>level above the lowest
+		// set bit, is odd: its left sibling is the next complete subtree of the range.
+		if level > nodes[len(nodes)-1].level && size>>level&1 == 1 {
+			i--
+			root, err = config.hashNode(level+1, (size-1)>>(level+1), concat(nodes[i].node, root))
+		} else {
+			root, err = config.hashNode(level+1, (size-1)>>(level+1), concat(root, root))
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return root, nil
+}
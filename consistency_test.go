@@ -0,0 +1,127 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifyConsistency(t *testing.T) {
+	configs := []*Config{
+		{Mode: ModeTreeBuild},
+		{Mode: ModeLazy, SortSiblingPairs: true},
+		{Mode: ModeTreeBuild, PositionalHashFunc: PositionPrefixed(nil)},
+	}
+	blocks := mockDataBlocksFixedSize(37)
+	for _, config := range configs {
+		trees := make(map[int]*MerkleTree)
+		for size := 2; size <= len(blocks); size++ {
+			m, err := New(&Config{Mode: config.Mode, SortSiblingPairs: config.SortSiblingPairs,
+				PositionalHashFunc: config.PositionalHashFunc}, blocks[:size])
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			trees[size] = m
+		}
+		for newSize := 2; newSize <= len(blocks); newSize++ {
+			for oldSize := 2; oldSize <= newSize; oldSize++ {
+				proof, err := trees[newSize].ConsistencyProof(oldSize)
+				if err != nil {
+					t.Fatalf("ConsistencyProof() error = %v", err)
+				}
+				vc := &Config{SortSiblingPairs: config.SortSiblingPairs, PositionalHashFunc: config.PositionalHashFunc}
+				ok, err := VerifyConsistency(proof, trees[oldSize].Root, trees[newSize].Root, vc)
+				if err != nil || !ok {
+					t.Fatalf("VerifyConsistency() %d to %d = %v, error = %v", oldSize, newSize, ok, err)
+				}
+				ok, err = VerifyExtension(proof, trees[oldSize].Root, blocks[oldSize:newSize], trees[newSize].Root, vc)
+				if err != nil || !ok {
+					t.Fatalf("VerifyExtension() %d to %d = %v, error = %v", oldSize, newSize, ok, err)
+				}
+				if oldSize > 2 {
+					if ok, _ := VerifyConsistency(proof, trees[oldSize-1].Root, trees[newSize].Root, vc); ok {
+						t.Fatalf("VerifyConsistency() %d to %d with another old root = true", oldSize, newSize)
+					}
+				}
+				if newSize > oldSize {
+					ok, _ := VerifyExtension(proof, trees[oldSize].Root, blocks[oldSize+1:newSize], trees[newSize].Root, vc)
+					if ok {
+						t.Fatalf("VerifyExtension() %d to %d with a missing entry = true", oldSize, newSize)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestIngestTree_ConsistencyProof(t *testing.T) {
+	blocks := mockDataBlocksFixedSize(20)
+	tree := NewIngestTree(nil)
+	for _, block := range blocks[:7] {
+		if _, err := tree.Append(block); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	oldRoot, err := tree.Root()
+	if err != nil {
+		t.Fatalf("Root() error = %v", err)
+	}
+	for _, block := range blocks[7:] {
+		if _, err := tree.Append(block); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	s, err := tree.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	proof, err := s.ConsistencyProof(7)
+	if err != nil {
+		t.Fatalf("ConsistencyProof() error = %v", err)
+	}
+	newRoot, err := s.Root()
+	if err != nil {
+		t.Fatalf("Root() error = %v", err)
+	}
+	if ok, err := VerifyConsistency(proof, oldRoot, newRoot, nil); err != nil || !ok {
+		t.Errorf("VerifyConsistency() = %v, error = %v", ok, err)
+	}
+	proof.Nodes[len(proof.Nodes)-1] = oldRoot
+	if ok, err := VerifyConsistency(proof, oldRoot, newRoot, nil); err != nil || ok {
+		t.Errorf("VerifyConsistency() tampered = %v, error = %v", ok, err)
+	}
+	if _, err := s.ConsistencyProof(21); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("ConsistencyProof() error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+}
+
+func TestMerkleTree_ConsistencyProof_proofGen(t *testing.T) {
+	m, err := New(nil, mockDataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := m.ConsistencyProof(3); !errors.Is(err, ErrTreeNotBuilt) {
+		t.Errorf("ConsistencyProof() error = %v, want %v", err, ErrTreeNotBuilt)
+	}
+}
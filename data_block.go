@@ -22,6 +22,12 @@
 
 package merkletree
 
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
 // DataBlock is the interface for input data blocks used to generate the Merkle Tree.
 // Implementations of DataBlock should provide a serialization method
 // that converts the data block into a byte slice for hashing purposes.
@@ -30,3 +36,104 @@ type DataBlock interface {
 	// It returns the serialized byte slice and an error, if any occurs during the serialization process.
 	Serialize() ([]byte, error)
 }
+
+// isNilBlock reports whether the data block is nil, or a nil pointer whose Serialize would dereference it.
+// Using reflection, it only checks the inputs of New and ValidateBlocks, once per data block: leaves are
+// otherwise computed from nil pointers as their Serialize handles them.
+func isNilBlock(block DataBlock) bool {
+	if block == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(block)
+
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// checkNilBlocks returns the data blocks without their nil entries if skip is true, see Config.SkipNilBlocks,
+// with the input index of each kept data block if some are skipped, or a *BlockError locating the first nil
+// entry otherwise.
+func checkNilBlocks(blocks []DataBlock, skip bool) ([]DataBlock, []int, error) {
+	for i, block := range blocks {
+		if !isNilBlock(block) {
+			continue
+		}
+
+		if !skip {
+			return nil, nil, &BlockError{Index: i, Err: ErrDataBlockIsNil}
+		}
+
+		// Copy the non-nil data blocks, leaving the slice of the caller untouched.
+		kept := append(make([]DataBlock, 0, len(blocks)-1), blocks[:i]...)
+		indexes := make([]int, i, len(blocks)-1)
+		for j := range indexes {
+			indexes[j] = j
+		}
+
+		for j := i + 1; j < len(blocks); j++ {
+			if !isNilBlock(blocks[j]) {
+				kept = append(kept, blocks[j])
+				indexes = append(indexes, j)
+			}
+		}
+
+		return kept, indexes, nil
+	}
+
+	return blocks, nil, nil
+}
+
+// restoreBlockIndexes sets the index of every *BlockError of err, which locates a data block kept by
+// checkNilBlocks, to the input index of the data block.
+func restoreBlockIndexes(err error, indexes []int) {
+	switch e := err.(type) {
+	case *BlockError:
+		if e.Index >= 0 && e.Index < len(indexes) {
+			e.Index = indexes[e.Index]
+		}
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			restoreBlockIndexes(err, indexes)
+		}
+	case interface{ Unwrap() error }:
+		restoreBlockIndexes(e.Unwrap(), indexes)
+	}
+}
+
+// ValidateBlocks checks the data blocks before building a tree over them and reports all the problems
+// found at once, joined: too few data blocks, and a *BlockError for every nil data block and every data
+// block whose Serialize fails or panics. It returns nil if the data blocks are valid.
+func ValidateBlocks(blocks []DataBlock) error {
+	var errs []error
+
+	if len(blocks) <= 1 {
+		errs = append(errs, ErrInvalidNumOfDataBlocks)
+	}
+
+	for i, block := range blocks {
+		if isNilBlock(block) {
+			errs = append(errs, &BlockError{Index: i, Err: ErrDataBlockIsNil})
+
+			continue
+		}
+
+		if err := serializeSafely(block); err != nil {
+			errs = append(errs, &BlockError{Index: i, Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// serializeSafely serializes the data block, turning a panic of Serialize into an ErrDataBlockPanic error.
+func serializeSafely(block DataBlock) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrDataBlockPanic, r)
+		}
+	}()
+
+	_, err = block.Serialize()
+
+	return err
+}
@@ -0,0 +1,258 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// panicDataBlock is a data block whose Serialize panics.
+type panicDataBlock struct{}
+
+func (panicDataBlock) Serialize() ([]byte, error) {
+	panic("corrupted data block")
+}
+
+// nilSafeDataBlock is a data block whose Serialize handles a nil receiver.
+type nilSafeDataBlock struct{}
+
+func (b *nilSafeDataBlock) Serialize() ([]byte, error) {
+	if b == nil {
+		return []byte("nil"), nil
+	}
+
+	return []byte("not nil"), nil
+}
+
+func TestNew_nilBlocks(t *testing.T) {
+	var nilPointer *mock.DataBlock
+
+	blocks := mockDataBlocks(6)
+	withNils := []DataBlock{blocks[0], nil, blocks[1], blocks[2], nilPointer, blocks[3], blocks[4], blocks[5]}
+
+	tests := []struct {
+		name      string
+		config    *Config
+		wantIndex int
+	}{
+		{name: "test_nil_config", wantIndex: 1},
+		{name: "test_parallel", config: &Config{RunInParallel: true}, wantIndex: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config, withNils)
+
+			var be *BlockError
+			if !errors.Is(err, ErrDataBlockIsNil) || !errors.As(err, &be) || be.Index != tt.wantIndex {
+				t.Fatalf("New() error = %v, want data block %d nil", err, tt.wantIndex)
+			}
+		})
+	}
+
+	m, err := New(&Config{SkipNilBlocks: true}, withNils)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	want, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if m.NumLeaves != 6 || string(m.Root) != string(want.Root) {
+		t.Errorf("New() with SkipNilBlocks differs from the tree without the nil data blocks")
+	}
+
+	if withNils[1] != nil {
+		t.Errorf("New() with SkipNilBlocks modified the data blocks")
+	}
+
+	if _, err := Verify(nil, m.Proofs[0], m.Root, nil); !errors.Is(err, ErrDataBlockIsNil) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrDataBlockIsNil)
+	}
+
+	// Outside New, nil pointers are serialized as their type handles them.
+	nilSafe, err := New(nil, []DataBlock{&mock.DataBlock{Data: []byte("nil")}, blocks[0]})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if ok, err := Verify((*nilSafeDataBlock)(nil), nilSafe.Proofs[0], nilSafe.Root, nil); err != nil || !ok {
+		t.Errorf("Verify() nil pointer = %v, error = %v", ok, err)
+	}
+
+	if _, err := New(&Config{SkipNilBlocks: true}, []DataBlock{blocks[0], nil}); !errors.Is(err, ErrInvalidNumOfDataBlocks) {
+		t.Errorf("New() error = %v, want %v", err, ErrInvalidNumOfDataBlocks)
+	}
+}
+
+func TestValidateBlocks(t *testing.T) {
+	var nilPointer *mock.DataBlock
+
+	blocks := mockDataBlocks(2)
+	errSerialize := errors.New("serialize")
+
+	if err := ValidateBlocks(blocks); err != nil {
+		t.Fatalf("ValidateBlocks() error = %v", err)
+	}
+
+	err := ValidateBlocks([]DataBlock{
+		blocks[0], nil, &mock.DataBlock{Err: errSerialize}, nilPointer, panicDataBlock{}, blocks[1],
+	})
+
+	wantErrs := map[int]error{1: ErrDataBlockIsNil, 2: errSerialize, 3: ErrDataBlockIsNil, 4: ErrDataBlockPanic}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != len(wantErrs) {
+		t.Fatalf("ValidateBlocks() error = %v, want %d errors", err, len(wantErrs))
+	}
+
+	for _, e := range joined.Unwrap() {
+		var be *BlockError
+		if !errors.As(e, &be) || !errors.Is(be, wantErrs[be.Index]) {
+			t.Errorf("ValidateBlocks() error = %v", e)
+		}
+	}
+
+	if err := ValidateBlocks([]DataBlock{nil}); !errors.Is(err, ErrInvalidNumOfDataBlocks) || !errors.Is(err, ErrDataBlockIsNil) {
+		t.Errorf("ValidateBlocks() error = %v, want both %v and %v", err, ErrInvalidNumOfDataBlocks, ErrDataBlockIsNil)
+	}
+}
+
+func TestNew_CollectLeafErrors(t *testing.T) {
+	var (
+		errSerialize = errors.New("serialize")
+		errHash      = errors.New("hash")
+		blocks       = mockDataBlocks(6)
+	)
+
+	blocks[1] = &mock.DataBlock{Err: errSerialize}
+	blocks[2] = &mock.DataBlock{Data: []byte("bad")}
+	blocks[4] = &mock.DataBlock{Err: errSerialize}
+
+	hashFunc := func(data []byte) ([]byte, error) {
+		if string(data) == "bad" {
+			return nil, errHash
+		}
+		return DefaultHashFuncParallel(data)
+	}
+
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "test_sequential", config: &Config{HashFunc: hashFunc, CollectLeafErrors: true}},
+		{name: "test_parallel", config: &Config{HashFunc: hashFunc, CollectLeafErrors: true, RunInParallel: true, NumRoutines: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config, blocks)
+			wantErrs := []error{errSerialize, errHash, errSerialize}
+			wantIndexes := []int{1, 2, 4}
+
+			joined, ok := err.(interface{ Unwrap() []error })
+			if !ok || len(joined.Unwrap()) != len(wantErrs) {
+				t.Fatalf("New() error = %v, want %d errors", err, len(wantErrs))
+			}
+
+			for i, e := range joined.Unwrap() {
+				var be *BlockError
+				if !errors.As(e, &be) || be.Index != wantIndexes[i] || !errors.Is(be, wantErrs[i]) {
+					t.Errorf("New() error %d = %v, want data block %d: %v", i, e, wantIndexes[i], wantErrs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNew_CollectLeafErrors_blockOrder(t *testing.T) {
+	var (
+		errSerialize = errors.New("serialize")
+		errHash      = errors.New("hash")
+		blocks       = mockDataBlocks(4)
+		bad          = &mock.DataBlock{Data: []byte("bad")}
+		failing      = &mock.DataBlock{Err: errSerialize}
+	)
+
+	hashFunc := func(data []byte) ([]byte, error) {
+		if string(data) == "bad" {
+			return nil, errHash
+		}
+		return DefaultHashFuncParallel(data)
+	}
+
+	tests := []struct {
+		name        string
+		config      *Config
+		blocks      []DataBlock
+		wantIndexes []int
+	}{
+		{
+			name:        "test_skip_nil_blocks",
+			config:      &Config{SkipNilBlocks: true},
+			blocks:      []DataBlock{blocks[0], nil, failing, blocks[1], nil, bad, blocks[2]},
+			wantIndexes: []int{2, 5},
+		},
+		{
+			name:        "test_skip_nil_blocks_parallel",
+			config:      &Config{SkipNilBlocks: true, RunInParallel: true, NumRoutines: 2},
+			blocks:      []DataBlock{nil, blocks[0], failing, nil, blocks[1], bad, blocks[2]},
+			wantIndexes: []int{2, 5},
+		},
+		{
+			name:        "test_leaf_less",
+			config:      &Config{LeafLess: LeafLessBytes},
+			blocks:      []DataBlock{blocks[3], blocks[0], failing, blocks[1], bad, blocks[2]},
+			wantIndexes: []int{2, 4},
+		},
+		{
+			name:        "test_leaf_less_skip_nil_blocks",
+			config:      &Config{LeafLess: LeafLessBytes, SkipNilBlocks: true},
+			blocks:      []DataBlock{blocks[3], nil, blocks[0], failing, nil, blocks[1], bad, blocks[2]},
+			wantIndexes: []int{3, 6},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.HashFunc = hashFunc
+			tt.config.CollectLeafErrors = true
+			_, err := New(tt.config, tt.blocks)
+			wantErrs := []error{errSerialize, errHash}
+
+			joined, ok := err.(interface{ Unwrap() []error })
+			if !ok || len(joined.Unwrap()) != len(wantErrs) {
+				t.Fatalf("New() error = %v, want %d errors", err, len(wantErrs))
+			}
+
+			for i, e := range joined.Unwrap() {
+				var be *BlockError
+				if !errors.As(e, &be) || be.Index != tt.wantIndexes[i] || !errors.Is(be, wantErrs[i]) {
+					t.Errorf("New() error %d = %v, want data block %d: %v", i, e, tt.wantIndexes[i], wantErrs[i])
+				}
+			}
+		})
+	}
+}
@@ -22,7 +22,13 @@
 
 package merkletree
 
-import "crypto/sha256"
+import (
+	"crypto/sha256"
+	"io"
+	"reflect"
+
+	"github.com/txaty/go-merkletree/verifier"
+)
 
 // sha256Digest is the reusable digest for DefaultHashFunc.
 // It is used to avoid creating a new hash digest for every call to DefaultHashFunc and reduce memory allocations.
@@ -48,3 +54,47 @@ func DefaultHashFuncParallel(data []byte) ([]byte, error) {
 
 	return digest.Sum(make([]byte, 0, digest.Size())), nil
 }
+
+// DefaultHashSlices is the streaming variant of DefaultHashFuncParallel hashing the concatenation of the
+// byte slices, which are written one after the other into the digest instead of being copied into one slice,
+// e.g. a prefix followed by the combined children of a node. It is safe for concurrent use.
+func DefaultHashSlices(parts ...[]byte) ([]byte, error) {
+	digest := sha256.New()
+	for _, part := range parts {
+		digest.Write(part)
+	}
+
+	return digest.Sum(make([]byte, 0, digest.Size())), nil
+}
+
+// DefaultHashReader is the streaming variant of DefaultHashFuncParallel hashing the content read from r
+// until EOF, e.g. a large data block read from a file without buffering it. It is safe for concurrent use.
+func DefaultHashReader(r io.Reader) ([]byte, error) {
+	digest := sha256.New()
+	if _, err := io.Copy(digest, r); err != nil {
+		return nil, err
+	}
+
+	return digest.Sum(make([]byte, 0, digest.Size())), nil
+}
+
+// sha256Funcs are the entry points of the SHA-256 hash functions of the library, see isSHA256.
+//
+//nolint:gochecknoglobals // Ignoring this linting error as this has to be a global variable.
+var sha256Funcs = [...]uintptr{
+	reflect.ValueOf(DefaultHashFunc).Pointer(),
+	reflect.ValueOf(DefaultHashFuncParallel).Pointer(),
+	reflect.ValueOf(verifier.SHA256).Pointer(),
+}
+
+// isSHA256 reports whether the hash function is one of the SHA-256 functions of the library,
+// so that its input can be hashed with sha256.Sum256 instead.
+func isSHA256(hashFunc TypeHashFunc) bool {
+	if hashFunc == nil {
+		return false
+	}
+
+	p := reflect.ValueOf(hashFunc).Pointer()
+
+	return p == sha256Funcs[0] || p == sha256Funcs[1] || p == sha256Funcs[2]
+}
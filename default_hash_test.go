@@ -0,0 +1,101 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/txaty/go-merkletree/verifier"
+)
+
+func TestDefaultHashSlices(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts [][]byte
+	}{
+		{name: "test_empty"},
+		{name: "test_one", parts: [][]byte{[]byte("left")}},
+		{name: "test_three", parts: [][]byte{[]byte("left"), nil, []byte("right")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, _ := DefaultHashFunc(bytes.Join(tt.parts, nil))
+			got, err := DefaultHashSlices(tt.parts...)
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("DefaultHashSlices() = %x, error = %v, want %x", got, err, want)
+			}
+			got, err = DefaultHashReader(bytes.NewReader(bytes.Join(tt.parts, nil)))
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("DefaultHashReader() = %x, error = %v, want %x", got, err, want)
+			}
+		})
+	}
+}
+
+func TestPositionPrefixed_defaultHash(t *testing.T) {
+	data := []byte(strings.Repeat("children", 8))
+	want, _ := PositionPrefixed(verifier.SHA256)(3, 1<<30, data)
+	got, err := PositionPrefixed(nil)(3, 1<<30, data)
+	if err != nil || !bytes.Equal(got, want) {
+		t.Errorf("PositionPrefixed(nil)() = %x, error = %v, want %x", got, err, want)
+	}
+}
+
+func TestConfig_hashChildren(t *testing.T) {
+	left, _ := DefaultHashFunc([]byte("left"))
+	right, _ := DefaultHashFunc([]byte("right"))
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "test_default", config: &Config{HashFunc: DefaultHashFunc}},
+		{name: "test_parallel_sorted", config: &Config{HashFunc: DefaultHashFuncParallel, SortSiblingPairs: true}},
+		{name: "test_node_hash_func", config: &Config{HashFunc: DefaultHashFunc, NodeHashFunc: verifier.SHA256}},
+		{name: "test_custom", config: &Config{HashFunc: func(data []byte) ([]byte, error) {
+			return append([]byte{0x01}, data...), nil
+		}}},
+		{name: "test_positional", config: &Config{HashFunc: DefaultHashFunc, PositionalHashFunc: PositionPrefixed(nil)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, _ := tt.config.hashNode(2, 1, concatHash(left, right))
+			got, err := tt.config.hashChildren(2, 1, left, right)
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("hashChildren() = %x, error = %v, want %x", got, err, want)
+			}
+		})
+	}
+}
+
+// With SHA-256 node hashing, only the digest returned is allocated.
+func BenchmarkConfig_hashChildren(b *testing.B) {
+	left, _ := DefaultHashFunc([]byte("left"))
+	right, _ := DefaultHashFunc([]byte("right"))
+	config := &Config{HashFunc: DefaultHashFuncParallel}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = config.hashChildren(1, 0, left, right)
+	}
+}
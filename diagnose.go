@@ -0,0 +1,154 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+)
+
+// DiagnosisHashPresets are the hash functions tried by Diagnose besides the configured one, by name.
+var DiagnosisHashPresets = []struct {
+	Name     string
+	HashFunc TypeHashFunc
+}{
+	{Name: "sha256", HashFunc: DefaultHashFuncParallel},
+	{Name: "double-sha256", HashFunc: doubleSHA256},
+	{Name: "sha224", HashFunc: digestHashFunc(sha256.New224)},
+	{Name: "sha512/256", HashFunc: digestHashFunc(sha512.New512_256)},
+	{Name: "sha384", HashFunc: digestHashFunc(sha512.New384)},
+	{Name: "sha512", HashFunc: digestHashFunc(sha512.New)},
+}
+
+// Diagnosis is the result of Diagnose.
+type Diagnosis struct {
+	// Valid reports whether the proof verifies with the configuration as is.
+	Valid bool
+	// Matches are the alternative configurations the proof verifies with, if it is not Valid.
+	Matches []DiagnosisMatch
+}
+
+// DiagnosisMatch is an alternative configuration a proof verifies with.
+type DiagnosisMatch struct {
+	// HashName is the name of the hash function, "configured" for the hash function of the configuration.
+	HashName string
+	// SortSiblingPairs is the SortSiblingPairs of the configuration.
+	SortSiblingPairs bool
+	// DisableLeafHashing is the DisableLeafHashing of the configuration.
+	DisableLeafHashing bool
+	// Config is the configuration the proof verifies with.
+	Config *Config
+}
+
+// String describes the configuration.
+func (d DiagnosisMatch) String() string {
+	return fmt.Sprintf("hash function %s, SortSiblingPairs %t, DisableLeafHashing %t",
+		d.HashName, d.SortSiblingPairs, d.DisableLeafHashing)
+}
+
+// Diagnose explains why a proof fails to verify: if it does not verify with the configuration,
+// the common alternative configurations are tried, with sorted sibling pairs and leaf hashing toggled
+// and the hash functions of DiagnosisHashPresets, and the ones the proof verifies with are reported.
+// It is meant for debugging integrations, as it verifies the proof up to 4*(len(DiagnosisHashPresets)+1) times.
+func Diagnose(dataBlock DataBlock, proof *Proof, root []byte, config *Config) (*Diagnosis, error) {
+	if dataBlock == nil {
+		return nil, ErrDataBlockIsNil
+	}
+
+	if proof == nil {
+		return nil, ErrProofIsNil
+	}
+
+	if config == nil {
+		config = new(Config)
+	}
+
+	// The default hash function is tried as the sha256 preset.
+	configured := config.HashFunc
+
+	ok, err := Verify(dataBlock, proof, root, config)
+	if err == nil && ok {
+		return &Diagnosis{Valid: true}, nil
+	}
+
+	type namedHashFunc struct {
+		name     string
+		hashFunc TypeHashFunc
+	}
+
+	var hashFuncs []namedHashFunc
+	if configured != nil {
+		hashFuncs = append(hashFuncs, namedHashFunc{name: "configured", hashFunc: configured})
+	}
+
+	for _, preset := range DiagnosisHashPresets {
+		hashFuncs = append(hashFuncs, namedHashFunc{name: preset.Name, hashFunc: preset.HashFunc})
+	}
+
+	diagnosis := new(Diagnosis)
+
+	for _, h := range hashFuncs {
+		for _, sorted := range []bool{config.SortSiblingPairs, !config.SortSiblingPairs} {
+			for _, disabled := range []bool{config.DisableLeafHashing, !config.DisableLeafHashing} {
+				alt := *config
+				alt.HashFunc = h.hashFunc
+				alt.SortSiblingPairs = sorted
+				alt.DisableLeafHashing = disabled
+
+				// Errors, e.g. siblings too long for the configuration, only rule the configuration out.
+				if ok, err := Verify(dataBlock, proof, root, &alt); err != nil || !ok {
+					continue
+				}
+
+				diagnosis.Matches = append(diagnosis.Matches, DiagnosisMatch{
+					HashName:           h.name,
+					SortSiblingPairs:   sorted,
+					DisableLeafHashing: disabled,
+					Config:             &alt,
+				})
+			}
+		}
+	}
+
+	return diagnosis, nil
+}
+
+// digestHashFunc returns the concurrent-safe hash function of the digest constructor.
+func digestHashFunc(newDigest func() hash.Hash) TypeHashFunc {
+	return func(data []byte) ([]byte, error) {
+		digest := newDigest()
+		digest.Write(data)
+
+		return digest.Sum(nil), nil
+	}
+}
+
+// doubleSHA256 is SHA256 applied twice, as in Bitcoin.
+func doubleSHA256(data []byte) ([]byte, error) {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+
+	return second[:], nil
+}
@@ -0,0 +1,93 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/sha512"
+	"testing"
+)
+
+func TestDiagnose(t *testing.T) {
+	blocks := mockDataBlocks(10)
+	sha512Func := func(data []byte) ([]byte, error) {
+		sum := sha512.Sum512_256(data)
+		return sum[:], nil
+	}
+	tests := []struct {
+		name         string
+		buildConfig  *Config
+		verifyConfig *Config
+		wantValid    bool
+		wantMatch    DiagnosisMatch
+	}{
+		{name: "test_valid", buildConfig: &Config{}, verifyConfig: &Config{}, wantValid: true},
+		{
+			name:         "test_leaf_hashing_disabled_at_build",
+			buildConfig:  &Config{DisableLeafHashing: true},
+			verifyConfig: &Config{},
+			wantMatch:    DiagnosisMatch{HashName: "sha256", DisableLeafHashing: true},
+		},
+		{
+			name:         "test_other_hash_function",
+			buildConfig:  &Config{HashFunc: sha512Func},
+			verifyConfig: &Config{},
+			wantMatch:    DiagnosisMatch{HashName: "sha512/256"},
+		},
+		{
+			name:         "test_configured_hash_function_leaf_hashing",
+			buildConfig:  &Config{HashFunc: sha512Func},
+			verifyConfig: &Config{HashFunc: sha512Func, DisableLeafHashing: true},
+			wantMatch:    DiagnosisMatch{HashName: "configured"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.buildConfig, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			got, err := Diagnose(blocks[3], m.Proofs[3], m.Root, tt.verifyConfig)
+			if err != nil {
+				t.Fatalf("Diagnose() error = %v", err)
+			}
+			if got.Valid != tt.wantValid {
+				t.Fatalf("Diagnose() valid = %v, want %v", got.Valid, tt.wantValid)
+			}
+			if tt.wantValid {
+				return
+			}
+			found := false
+			for _, match := range got.Matches {
+				if match.HashName == tt.wantMatch.HashName && match.DisableLeafHashing == tt.wantMatch.DisableLeafHashing {
+					found = true
+					if ok, err := Verify(blocks[3], m.Proofs[3], m.Root, match.Config); err != nil || !ok {
+						t.Errorf("Verify() with %v = %v, %v", match, ok, err)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("Diagnose() matches = %v, want %v", got.Matches, tt.wantMatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package dirhash builds Merkle Trees over directory trees, producing a root manifest
+// and per-file proofs suitable for reproducible build attestation and artifact verification.
+//
+// Every regular file is a leaf committing to its slash-separated path and the SHA256 digest of its content.
+// Leaves are ordered by path in byte order, so that the root only depends on the directory content.
+package dirhash
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+// ErrFileNotInManifest is the error for verifying a file that is not listed in the manifest.
+var ErrFileNotInManifest = errors.New("file is not in the manifest")
+
+// File is the data block of a file: its path and content digest.
+type File struct {
+	// Path is the slash-separated path of the file relative to the directory root.
+	Path string
+	// Digest is the SHA256 digest of the file content.
+	Digest []byte
+}
+
+// Serialize encodes the file as the length of the path (uint32, big-endian), the path and the digest,
+// so that the path metadata is committed together with the content.
+func (f *File) Serialize() ([]byte, error) {
+	buf := make([]byte, 0, 4+len(f.Path)+len(f.Digest))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(f.Path)))
+	buf = append(buf, f.Path...)
+	buf = append(buf, f.Digest...)
+
+	return buf, nil
+}
+
+// Entry is a file listed in a manifest with its inclusion proof.
+type Entry struct {
+	Path   string              `json:"path"`
+	Digest mt.HexBytes         `json:"digest"`
+	Proof  *mt.SerializedProof `json:"proof"`
+}
+
+// Manifest is the root of a directory tree and the proofs of all its files.
+type Manifest struct {
+	Root  mt.HexBytes `json:"root"`
+	Files []Entry     `json:"files"`
+}
+
+// Build hashes every regular file of fsys and builds the Merkle Tree over them.
+// The directory must contain at least two files. Proofs are generated, so the configuration
+// mode must be ModeProofGen (default) or ModeProofGenAndTreeBuild, and neither LeafLess
+// nor SkipNilBlocks may be set.
+func Build(fsys fs.FS, config *mt.Config) (*Manifest, *mt.MerkleTree, error) {
+	if config != nil && (config.LeafLess != nil || config.SkipNilBlocks) {
+		return nil, nil, mt.ErrBlockOrderUnsupported
+	}
+
+	files, err := hashFiles(fsys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blocks := make([]mt.DataBlock, len(files))
+	for i := range files {
+		blocks[i] = files[i]
+	}
+
+	tree, err := mt.New(config, blocks)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifest := &Manifest{
+		Root:  tree.Root,
+		Files: make([]Entry, len(files)),
+	}
+
+	for i, f := range files {
+		manifest.Files[i] = Entry{
+			Path:   f.Path,
+			Digest: f.Digest,
+			Proof:  mt.NewSerializedProof(tree.Proofs[i]),
+		}
+	}
+
+	return manifest, tree, nil
+}
+
+// hashFiles returns the files of fsys ordered by path.
+func hashFiles(fsys fs.FS) ([]*File, error) {
+	var paths []string
+
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// WalkDir orders entries per directory, sort globally by byte order.
+	sort.Strings(paths)
+
+	files := make([]*File, len(paths))
+	for i, path := range paths {
+		digest, err := hashFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+
+		files[i] = &File{Path: path, Digest: digest}
+	}
+
+	return files, nil
+}
+
+// hashFile streams the file content into SHA256.
+func hashFile(fsys fs.FS, path string) ([]byte, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+// VerifyFile hashes the file at path in fsys and verifies it against the manifest root with its proof.
+// It returns false if the content or path does not match the manifest.
+func (m *Manifest) VerifyFile(fsys fs.FS, path string, config *mt.Config) (bool, error) {
+	entry := m.entry(path)
+	if entry == nil {
+		return false, ErrFileNotInManifest
+	}
+
+	digest, err := hashFile(fsys, path)
+	if err != nil {
+		return false, err
+	}
+
+	return mt.Verify(&File{Path: path, Digest: digest}, entry.Proof.Proof(), m.Root, config)
+}
+
+// entry returns the manifest entry of the path, or nil.
+func (m *Manifest) entry(path string) *Entry {
+	idx := sort.Search(len(m.Files), func(i int) bool {
+		return m.Files[i].Path >= path
+	})
+	if idx < len(m.Files) && m.Files[idx].Path == path {
+		return &m.Files[idx]
+	}
+
+	return nil
+}
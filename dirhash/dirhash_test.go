@@ -0,0 +1,89 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package dirhash
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"a.txt":         {Data: []byte("a")},
+		"a/b.txt":       {Data: []byte("b")},
+		"bin/tool":      {Data: []byte("binary")},
+		"docs/README":   {Data: []byte("readme")},
+		"docs/empty.md": {Data: nil},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	fsys := testFS()
+	manifest, tree, err := Build(fsys, nil)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if !bytes.Equal(manifest.Root, tree.Root) {
+		t.Errorf("Build() manifest root %x, tree root %x", manifest.Root, tree.Root)
+	}
+	wantPaths := []string{"a.txt", "a/b.txt", "bin/tool", "docs/README", "docs/empty.md"}
+	for i, entry := range manifest.Files {
+		if entry.Path != wantPaths[i] {
+			t.Errorf("Build() file %d path = %s, want %s", i, entry.Path, wantPaths[i])
+		}
+		ok, err := manifest.VerifyFile(fsys, entry.Path, nil)
+		if err != nil || !ok {
+			t.Errorf("VerifyFile() %s = %v, error = %v", entry.Path, ok, err)
+		}
+	}
+
+	// Rebuilding the same content yields the same root.
+	again, _, err := Build(testFS(), nil)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if !bytes.Equal(again.Root, manifest.Root) {
+		t.Errorf("Build() is not deterministic")
+	}
+
+	tampered := testFS()
+	tampered["bin/tool"] = &fstest.MapFile{Data: []byte("tampered")}
+	if ok, err := manifest.VerifyFile(tampered, "bin/tool", nil); err != nil || ok {
+		t.Errorf("VerifyFile() tampered = %v, error = %v", ok, err)
+	}
+	if _, err := manifest.VerifyFile(fsys, "missing", nil); err != ErrFileNotInManifest {
+		t.Errorf("VerifyFile() missing error = %v", err)
+	}
+}
+
+func TestBuild_blockPositions(t *testing.T) {
+	for _, config := range []*mt.Config{{LeafLess: mt.LeafLessBytes}, {SkipNilBlocks: true}} {
+		if _, _, err := Build(testFS(), config); !errors.Is(err, mt.ErrBlockOrderUnsupported) {
+			t.Errorf("Build() error = %v, want ErrBlockOrderUnsupported", err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// SubtreeTask is a unit of work of a distributed build: the subtree over the leaves Start to End-1,
+// aligned on a multiple of 2^Levels leaves in the whole tree. Tasks are planned by PlanSubtrees,
+// serialized to the workers, built with BuildSubtree and merged by MergeSubtrees.
+type SubtreeTask struct {
+	// Index is the index of the subtree in the whole tree.
+	Index int `json:"index"`
+	// Start is the index of the first leaf of the subtree.
+	Start int `json:"start"`
+	// End is the index following the last leaf of the subtree.
+	End int `json:"end"`
+	// Levels is the number of levels of the subtree.
+	Levels int `json:"levels"`
+}
+
+// SubtreeResult is the serializable result of a SubtreeTask.
+type SubtreeResult struct {
+	// Task is the task the result was built for.
+	Task SubtreeTask `json:"task"`
+	// Root is the root of the subtree.
+	Root HexBytes `json:"root"`
+	// Leaves are the leaves of the subtree.
+	Leaves []HexBytes `json:"leaves"`
+	// Proofs are the proofs of the leaves within the subtree, completed by MergeSubtrees.
+	Proofs []*SerializedProof `json:"proofs"`
+}
+
+// PlanSubtrees partitions a tree of numLeaves leaves into at most numTasks subtree tasks.
+// The subtrees have the same power-of-two number of leaves, except the last one.
+func PlanSubtrees(numLeaves, numTasks int) ([]SubtreeTask, error) {
+	if numLeaves <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	numTasks = max(numTasks, 1)
+
+	var (
+		depth  = bits.Len(uint(numLeaves - 1))
+		levels = min(bits.Len(uint((numLeaves+numTasks-1)/numTasks-1)), depth)
+		tasks  = make([]SubtreeTask, (numLeaves+1<<levels-1)>>levels)
+	)
+
+	for i := range tasks {
+		tasks[i] = SubtreeTask{
+			Index:  i,
+			Start:  i << levels,
+			End:    min((i+1)<<levels, numLeaves),
+			Levels: levels,
+		}
+	}
+
+	return tasks, nil
+}
+
+// BuildSubtree builds the subtree of the task over its data blocks, the data blocks Start to End-1
+// of the whole tree. The configuration must be the one of the coordinator.
+func BuildSubtree(config *Config, task SubtreeTask, blocks []DataBlock) (*SubtreeResult, error) {
+	if task.Start < 0 || task.End <= task.Start || task.End-task.Start > 1<<task.Levels ||
+		task.Start&(1<<task.Levels-1) != 0 {
+		return nil, fmt.Errorf("%w: task %d", ErrInvalidSubtreeResult, task.Index)
+	}
+
+	if len(blocks) != task.End-task.Start {
+		return nil, fmt.Errorf("%w: task %d covers %d data blocks, got %d",
+			ErrInvalidSubtreeResult, task.Index, task.End-task.Start, len(blocks))
+	}
+
+	m, err := newMerkleTree(config, len(blocks))
+	if err != nil {
+		return nil, err
+	}
+
+	m.init()
+
+	leaves := make([][]byte, len(blocks))
+	for i, block := range blocks {
+		if leaves[i], err = cachedDataBlockToLeaf(block, m.leafHashFunc(), m.DisableLeafHashing, m.LeafCache); err != nil {
+			return nil, err
+		}
+	}
+
+	proofs := make([]*Proof, len(leaves))
+	for i := range proofs {
+		proofs[i] = &Proof{Siblings: make([][]byte, 0, task.Levels)}
+	}
+
+	root, err := m.proofGenSubtree(m.buildChildren, leaves, proofs, task.Levels, 0, task.Start)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SubtreeResult{
+		Task:   task,
+		Root:   root,
+		Leaves: make([]HexBytes, len(leaves)),
+		Proofs: make([]*SerializedProof, len(proofs)),
+	}
+
+	for i := range leaves {
+		result.Leaves[i] = leaves[i]
+		result.Proofs[i] = NewSerializedProof(proofs[i])
+	}
+
+	return result, nil
+}
+
+// MergeSubtrees merges the results of the subtree tasks planned for numLeaves leaves into the whole tree.
+// The top tree over the subtree roots is built, and its proofs are stitched onto the proofs of the leaves
+// of each subtree. The merged tree is in ModeProofGen, with its leaves and proofs.
+func MergeSubtrees(config *Config, numLeaves int, results []*SubtreeResult) (*MerkleTree, error) {
+	if numLeaves <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	if config != nil && config.Mode != 0 && config.Mode != ModeProofGen {
+		return nil, ErrInvalidConfigMode
+	}
+
+	m, err := newMerkleTree(config, numLeaves)
+	if err != nil {
+		return nil, err
+	}
+
+	m.init()
+
+	levels, err := checkSubtreeResults(numLeaves, m.Depth, results)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		roots     = make([][]byte, len(results))
+		topProofs = make([]*Proof, len(results))
+	)
+
+	m.Leaves = make([][]byte, 0, numLeaves)
+	m.Proofs = make([]*Proof, 0, numLeaves)
+
+	for i, result := range results {
+		roots[i] = result.Root
+		topProofs[i] = &Proof{Siblings: make([][]byte, 0, m.Depth-levels)}
+
+		for j, leaf := range result.Leaves {
+			proof := &Proof{Siblings: make([][]byte, levels, m.Depth), Path: result.Proofs[j].Path}
+			for k, sibling := range result.Proofs[j].Proof().Siblings {
+				proof.Siblings[k] = sibling
+			}
+
+			m.Leaves = append(m.Leaves, leaf)
+			m.Proofs = append(m.Proofs, proof)
+		}
+	}
+
+	if m.Root, err = m.proofGenSubtree(m.buildChildren, roots, topProofs, m.Depth-levels, levels, 0); err != nil {
+		return nil, err
+	}
+
+	for i, result := range results {
+		for _, proof := range m.Proofs[result.Task.Start:result.Task.End] {
+			proof.Path |= topProofs[i].Path << levels
+			proof.Siblings = append(proof.Siblings, topProofs[i].Siblings...)
+		}
+	}
+
+	return m, nil
+}
+
+// checkSubtreeResults checks that the results are the complete and ordered partition of numLeaves leaves
+// into aligned subtrees of the same number of levels, at most depth, and returns it.
+func checkSubtreeResults(numLeaves, depth int, results []*SubtreeResult) (int, error) {
+	if len(results) == 0 || results[0] == nil {
+		return 0, fmt.Errorf("%w: no result", ErrInvalidSubtreeResult)
+	}
+
+	levels := results[0].Task.Levels
+	if levels < 0 || levels > depth {
+		return 0, fmt.Errorf("%w: invalid number of levels %d", ErrInvalidSubtreeResult, levels)
+	}
+
+	for i, result := range results {
+		if result == nil {
+			return 0, fmt.Errorf("%w: missing result %d", ErrInvalidSubtreeResult, i)
+		}
+
+		task := result.Task
+		if task.Index != i || task.Levels != levels || task.Start != i<<levels ||
+			task.End != min((i+1)<<levels, numLeaves) {
+			return 0, fmt.Errorf("%w: result %d does not match the partition", ErrInvalidSubtreeResult, i)
+		}
+
+		if len(result.Leaves) != task.End-task.Start || len(result.Proofs) != len(result.Leaves) {
+			return 0, fmt.Errorf("%w: result %d has %d leaves and %d proofs, want %d",
+				ErrInvalidSubtreeResult, i, len(result.Leaves), len(result.Proofs), task.End-task.Start)
+		}
+
+		for j, proof := range result.Proofs {
+			if proof == nil || len(proof.Siblings) != levels {
+				return 0, fmt.Errorf("%w: result %d has an invalid proof %d", ErrInvalidSubtreeResult, i, j)
+			}
+		}
+	}
+
+	if results[len(results)-1].Task.End != numLeaves {
+		return 0, fmt.Errorf("%w: results cover %d leaves, want %d",
+			ErrInvalidSubtreeResult, results[len(results)-1].Task.End, numLeaves)
+	}
+
+	return levels, nil
+}
@@ -0,0 +1,156 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMergeSubtrees(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *Config
+		numBlocks int
+		numTasks  int
+	}{
+		{name: "test_2_tasks_1", numBlocks: 2, numTasks: 1},
+		{name: "test_5_tasks_4", numBlocks: 5, numTasks: 4},
+		{name: "test_13_tasks_3", numBlocks: 13, numTasks: 3},
+		{name: "test_100_tasks_7_sorted", config: &Config{SortSiblingPairs: true}, numBlocks: 100, numTasks: 7},
+		{name: "test_33_tasks_64", numBlocks: 33, numTasks: 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.numBlocks)
+
+			tasks, err := PlanSubtrees(tt.numBlocks, tt.numTasks)
+			if err != nil {
+				t.Fatalf("PlanSubtrees() error = %v", err)
+			}
+
+			if len(tasks) > tt.numTasks {
+				t.Fatalf("PlanSubtrees() planned %d tasks, want at most %d", len(tasks), tt.numTasks)
+			}
+
+			results := make([]*SubtreeResult, len(tasks))
+			for i, task := range tasks {
+				config := new(Config)
+				if tt.config != nil {
+					*config = *tt.config
+				}
+
+				result, err := BuildSubtree(config, task, blocks[task.Start:task.End])
+				if err != nil {
+					t.Fatalf("BuildSubtree() error = %v", err)
+				}
+
+				// Results travel back to the coordinator serialized.
+				data, err := json.Marshal(result)
+				if err != nil {
+					t.Fatalf("json.Marshal() error = %v", err)
+				}
+
+				if err := json.Unmarshal(data, &results[i]); err != nil {
+					t.Fatalf("json.Unmarshal() error = %v", err)
+				}
+			}
+
+			m, err := MergeSubtrees(tt.config, tt.numBlocks, results)
+			if err != nil {
+				t.Fatalf("MergeSubtrees() error = %v", err)
+			}
+
+			var config *Config
+			if tt.config != nil {
+				config = &Config{SortSiblingPairs: tt.config.SortSiblingPairs}
+			}
+
+			want, err := New(config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if !bytes.Equal(m.Root, want.Root) {
+				t.Fatal("MergeSubtrees() root differs from New()")
+			}
+
+			for i := range blocks {
+				if !m.Proofs[i].Equal(want.Proofs[i]) {
+					t.Fatalf("MergeSubtrees() proof %d differs from New()", i)
+				}
+
+				if !bytes.Equal(m.Leaves[i], want.Leaves[i]) {
+					t.Fatalf("MergeSubtrees() leaf %d differs from New()", i)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeSubtrees_invalid(t *testing.T) {
+	blocks := mockDataBlocks(10)
+
+	tasks, err := PlanSubtrees(len(blocks), 3)
+	if err != nil {
+		t.Fatalf("PlanSubtrees() error = %v", err)
+	}
+
+	results := make([]*SubtreeResult, len(tasks))
+	for i, task := range tasks {
+		if results[i], err = BuildSubtree(nil, task, blocks[task.Start:task.End]); err != nil {
+			t.Fatalf("BuildSubtree() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		config    *Config
+		numLeaves int
+		results   []*SubtreeResult
+		wantErr   error
+	}{
+		{name: "missing_result", numLeaves: 10, results: results[:len(results)-1], wantErr: ErrInvalidSubtreeResult},
+		{name: "reordered", numLeaves: 10, results: []*SubtreeResult{results[1], results[0], results[2]},
+			wantErr: ErrInvalidSubtreeResult},
+		{name: "nil_result", numLeaves: 10, results: []*SubtreeResult{results[0], nil, results[2]},
+			wantErr: ErrInvalidSubtreeResult},
+		{name: "num_leaves", numLeaves: 11, results: results, wantErr: ErrInvalidSubtreeResult},
+		{name: "single_leaf", numLeaves: 1, results: results, wantErr: ErrInvalidNumOfDataBlocks},
+		{name: "tree_build_mode", config: &Config{Mode: ModeTreeBuild}, numLeaves: 10, results: results,
+			wantErr: ErrInvalidConfigMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := MergeSubtrees(tt.config, tt.numLeaves, tt.results); !errors.Is(err, tt.wantErr) {
+				t.Errorf("MergeSubtrees() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := BuildSubtree(nil, tasks[0], blocks[:1]); !errors.Is(err, ErrInvalidSubtreeResult) {
+		t.Errorf("BuildSubtree() error = %v, want %v", err, ErrInvalidSubtreeResult)
+	}
+}
@@ -0,0 +1,178 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"runtime"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DualTree holds two Merkle Trees over the same data blocks built with two hash functions,
+// e.g. SHA256 and BLAKE3, to support migration periods where verifiers on the old algorithm still exist.
+type DualTree struct {
+	// Primary is the tree built with the primary configuration.
+	Primary *MerkleTree
+	// Secondary is the tree built with the secondary configuration.
+	Secondary *MerkleTree
+}
+
+// DualProof holds the proofs of a data block in both trees of a DualTree.
+type DualProof struct {
+	Primary   *Proof
+	Secondary *Proof
+}
+
+// NewDual builds the two trees in a single pass over the data blocks: every block is serialized once
+// and hashed with both hash functions. If the primary configuration runs in parallel, so does the leaf pass.
+// The configurations must not share the same non concurrent-safe hash function when running in parallel.
+func NewDual(primary, secondary *Config, blocks []DataBlock) (*DualTree, error) {
+	if len(blocks) <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	if primary == nil {
+		primary = new(Config)
+	}
+
+	if secondary == nil {
+		secondary = new(Config)
+	}
+
+	primaryLeaves, secondaryLeaves, err := computeDualLeaves(primary, secondary, blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	d := new(DualTree)
+	if d.Primary, err = newFromLeaves(primary, primaryLeaves); err != nil {
+		return nil, err
+	}
+
+	if d.Secondary, err = newFromLeaves(secondary, secondaryLeaves); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// computeDualLeaves serializes every data block once and computes its leaf under both configurations.
+func computeDualLeaves(primary, secondary *Config, blocks []DataBlock) ([][]byte, [][]byte, error) {
+	var (
+		numLeaves       = len(blocks)
+		primaryLeaves   = make([][]byte, numLeaves)
+		secondaryLeaves = make([][]byte, numLeaves)
+		primaryHash     = primary.leafHashFunc()
+		secondaryHash   = secondary.leafHashFunc()
+		numRoutines     = 1
+		eg              = new(errgroup.Group)
+	)
+
+	if primary.RunInParallel {
+		numRoutines = primary.NumRoutines
+		if numRoutines <= 0 {
+			numRoutines = runtime.NumCPU()
+		}
+	}
+
+	numRoutines = min(numRoutines, numLeaves)
+
+	if primaryHash == nil {
+		primaryHash = DefaultHashFuncParallel
+	}
+
+	if secondaryHash == nil {
+		secondaryHash = DefaultHashFuncParallel
+	}
+
+	for startIdx := 0; startIdx < numRoutines; startIdx++ {
+		startIdx := startIdx
+
+		eg.Go(func() error {
+			for i := startIdx; i < numLeaves; i += numRoutines {
+				if blocks[i] == nil {
+					return ErrDataBlockIsNil
+				}
+
+				blockBytes, err := blocks[i].Serialize()
+				if err != nil {
+					return err
+				}
+
+				if primaryLeaves[i], err = bytesToLeaf(blockBytes, primaryHash, primary.DisableLeafHashing); err != nil {
+					return err
+				}
+
+				if secondaryLeaves[i], err = bytesToLeaf(blockBytes, secondaryHash,
+					secondary.DisableLeafHashing); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, nil, fmt.Errorf("computeDualLeaves: %w", err)
+	}
+
+	return primaryLeaves, secondaryLeaves, nil
+}
+
+// Proof returns the dual proof of the leaf at idx. Both trees must have generated proofs
+// or built their tree structure.
+func (d *DualTree) Proof(idx int) (*DualProof, error) {
+	primary, err := d.Primary.proofByIndex(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	secondary, err := d.Secondary.proofByIndex(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DualProof{Primary: primary, Secondary: secondary}, nil
+}
+
+// VerifyDual checks the data block against both roots with the dual proof.
+// It reports the result of each tree separately, so verifiers on either algorithm can rely on their own.
+func VerifyDual(dataBlock DataBlock, proof *DualProof, primaryRoot, secondaryRoot []byte,
+	primary, secondary *Config,
+) (primaryOK, secondaryOK bool, err error) {
+	if proof == nil {
+		return false, false, ErrProofIsNil
+	}
+
+	if primaryOK, err = Verify(dataBlock, proof.Primary, primaryRoot, primary); err != nil {
+		return false, false, err
+	}
+
+	if secondaryOK, err = Verify(dataBlock, proof.Secondary, secondaryRoot, secondary); err != nil {
+		return false, false, err
+	}
+
+	return primaryOK, secondaryOK, nil
+}
@@ -0,0 +1,97 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"testing"
+)
+
+func sha512_256HashFunc(data []byte) ([]byte, error) {
+	digest := sha512.Sum512_256(data)
+	return digest[:], nil
+}
+
+func TestNewDual(t *testing.T) {
+	tests := []struct {
+		name          string
+		runInParallel bool
+		mode          TypeConfigMode
+	}{
+		{
+			name: "test_proof_gen",
+			mode: ModeProofGen,
+		},
+		{
+			name:          "test_proof_gen_parallel",
+			runInParallel: true,
+			mode:          ModeProofGen,
+		},
+		{
+			name: "test_tree_build",
+			mode: ModeTreeBuild,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				blocks    = mockDataBlocks(33)
+				primary   = &Config{Mode: tt.mode, RunInParallel: tt.runInParallel}
+				secondary = &Config{Mode: tt.mode, RunInParallel: tt.runInParallel, HashFunc: sha512_256HashFunc}
+			)
+			d, err := NewDual(primary, secondary, blocks)
+			if err != nil {
+				t.Fatalf("NewDual() error = %v", err)
+			}
+			wantPrimary, err := New(nil, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			wantSecondary, err := New(&Config{HashFunc: sha512_256HashFunc}, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(d.Primary.Root, wantPrimary.Root) {
+				t.Errorf("NewDual() primary root = %x, want %x", d.Primary.Root, wantPrimary.Root)
+			}
+			if !bytes.Equal(d.Secondary.Root, wantSecondary.Root) {
+				t.Errorf("NewDual() secondary root = %x, want %x", d.Secondary.Root, wantSecondary.Root)
+			}
+			if bytes.Equal(d.Primary.Root, d.Secondary.Root) {
+				t.Errorf("NewDual() roots should differ")
+			}
+			for idx, block := range blocks {
+				proof, err := d.Proof(idx)
+				if err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+				primaryOK, secondaryOK, err := VerifyDual(block, proof, d.Primary.Root, d.Secondary.Root,
+					nil, &Config{HashFunc: sha512_256HashFunc})
+				if err != nil || !primaryOK || !secondaryOK {
+					t.Errorf("VerifyDual() %d = %v %v, error = %v", idx, primaryOK, secondaryOK, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// MaxExtendedShares is the maximum number of shares of an extended tree: the shares are the evaluations
+// of Reed-Solomon polynomials over GF(2^8), at distinct points.
+const MaxExtendedShares = 256
+
+// ShareBlock is a DataBlock holding a share of an extended tree.
+type ShareBlock []byte
+
+// Serialize returns the share bytes.
+func (s ShareBlock) Serialize() ([]byte, error) {
+	return s, nil
+}
+
+// ExtendedTree is a Merkle Tree over the Reed-Solomon 2x extension of the serialized data blocks,
+// as in data availability sampling constructions: the first half of the leaves are the original shares
+// and the second half the parity shares. Any half of the shares is enough to reconstruct the data,
+// so sampling random shares against the extended root probabilistically proves that the data is available.
+type ExtendedTree struct {
+	// MerkleTree is the tree over the extended shares. Its root is the extended root.
+	*MerkleTree
+	// Shares are the original shares followed by the parity shares.
+	Shares [][]byte
+	// NumOriginal is the number of original shares (data blocks).
+	NumOriginal int
+}
+
+// Sample is a share of an extended tree with its proof against the extended root.
+type Sample struct {
+	Index int
+	Share []byte
+	Proof *Proof
+}
+
+// NewExtended Reed-Solomon extends the serialized data blocks to twice their number of shares,
+// and builds the tree over the extended shares with the specified configuration.
+// All data blocks must serialize to the same length, and there must be at most MaxExtendedShares/2 of them.
+func NewExtended(config *Config, blocks []DataBlock) (*ExtendedTree, error) {
+	if err := config.checkBlockPositions(); err != nil {
+		return nil, err
+	}
+
+	if len(blocks) == 0 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	if 2*len(blocks) > MaxExtendedShares {
+		return nil, fmt.Errorf("%w: %d data blocks", ErrTooManyShares, len(blocks))
+	}
+
+	original := make([][]byte, len(blocks))
+	for i, block := range blocks {
+		data, err := block.Serialize()
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 && len(data) != len(original[0]) {
+			return nil, ErrShareSizeMismatch
+		}
+
+		original[i] = data
+	}
+
+	shares := extendShares(original)
+
+	extended := make([]DataBlock, len(shares))
+	for i, share := range shares {
+		extended[i] = ShareBlock(share)
+	}
+
+	m, err := New(config, extended)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExtendedTree{
+		MerkleTree:  m,
+		Shares:      shares,
+		NumOriginal: len(blocks),
+	}, nil
+}
+
+// Sample returns the share at the index with its proof against the extended root.
+func (t *ExtendedTree) Sample(idx int) (*Sample, error) {
+	proof, err := t.proofByIndex(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sample{
+		Index: idx,
+		Share: t.Shares[idx],
+		Proof: proof,
+	}, nil
+}
+
+// VerifySample checks that the sample is the share at its index under the extended root.
+func VerifySample(sample *Sample, root []byte, config *Config) (bool, error) {
+	if sample == nil || sample.Proof == nil {
+		return false, ErrProofIsNil
+	}
+
+	if sample.Index < 0 || sample.Proof.Index() != sample.Index {
+		return false, nil
+	}
+
+	return Verify(ShareBlock(sample.Share), sample.Proof, root, config)
+}
+
+// Reconstruct recovers the original shares of an extended tree with numOriginal data blocks
+// from any numOriginal of its shares, indexed by their position in the extended tree.
+func Reconstruct(shares map[int][]byte, numOriginal int) ([][]byte, error) {
+	if numOriginal <= 0 || 2*numOriginal > MaxExtendedShares {
+		return nil, ErrTooManyShares
+	}
+
+	var (
+		xs     = make([]byte, 0, numOriginal)
+		ys     = make([][]byte, 0, numOriginal)
+		length = -1
+	)
+
+	for idx := 0; idx < 2*numOriginal && len(xs) < numOriginal; idx++ {
+		share, ok := shares[idx]
+		if !ok {
+			continue
+		}
+
+		if length >= 0 && len(share) != length {
+			return nil, ErrShareSizeMismatch
+		}
+
+		length = len(share)
+		xs = append(xs, byte(idx))
+		ys = append(ys, share)
+	}
+
+	if len(xs) < numOriginal {
+		return nil, fmt.Errorf("%w: %d of %d shares", ErrNotEnoughShares, len(xs), numOriginal)
+	}
+
+	original := make([][]byte, numOriginal)
+	for i := range original {
+		original[i] = interpolateShare(xs, ys, byte(i))
+	}
+
+	return original, nil
+}
+
+// extendShares appends to the original shares, the evaluations at the points k..2k-1 of the polynomials
+// of degree k-1 taking the values of the original shares at the points 0..k-1, byte position by byte position.
+func extendShares(original [][]byte) [][]byte {
+	var (
+		k      = len(original)
+		xs     = make([]byte, k)
+		shares = make([][]byte, 2*k)
+	)
+
+	for i := range original {
+		xs[i] = byte(i)
+		shares[i] = bytes.Clone(original[i])
+	}
+
+	for i := k; i < 2*k; i++ {
+		shares[i] = interpolateShare(xs, original, byte(i))
+	}
+
+	return shares
+}
+
+// interpolateShare evaluates at x the polynomials interpolating the shares ys at the distinct points xs.
+func interpolateShare(xs []byte, ys [][]byte, x byte) []byte {
+	share := make([]byte, len(ys[0]))
+
+	for i, xi := range xs {
+		// Lagrange basis polynomial of xi evaluated at x, subtraction being XOR in GF(2^8).
+		coeff := byte(1)
+		for j, xj := range xs {
+			if j != i {
+				coeff = gfMul(coeff, gfDiv(x^xj, xi^xj))
+			}
+		}
+
+		if coeff == 0 {
+			continue
+		}
+
+		for b, y := range ys[i] {
+			share[b] ^= gfMul(coeff, y)
+		}
+	}
+
+	return share
+}
+
+// GF(2^8) arithmetic with the primitive polynomial x^8 + x^4 + x^3 + x^2 + 1 (0x11d).
+var gfExp, gfLog = gfTables()
+
+func gfTables() (exp [510]byte, log [256]byte) {
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i], exp[i+255] = byte(x), byte(x)
+		log[x] = byte(i)
+
+		if x <<= 1; x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+
+	return exp, log
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// gfDiv divides a by the non-zero b.
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
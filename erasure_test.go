@@ -0,0 +1,103 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestNewExtended(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  []DataBlock
+		keep    func(idx int) bool
+		wantErr error
+	}{
+		{name: "test_1_parity_only", blocks: mockDataBlocksFixedSize(1), keep: func(idx int) bool { return idx == 1 }},
+		{name: "test_7_parity_only", blocks: mockDataBlocksFixedSize(7), keep: func(idx int) bool { return idx >= 7 }},
+		{name: "test_100_odd_indices", blocks: mockDataBlocksFixedSize(100), keep: func(idx int) bool { return idx&1 == 1 }},
+		{name: "test_128_last_half", blocks: mockDataBlocksFixedSize(128), keep: func(idx int) bool { return idx >= 100 }},
+		{name: "test_too_many_blocks", blocks: mockDataBlocksFixedSize(129), wantErr: ErrTooManyShares},
+		{name: "test_different_sizes", blocks: mockDataBlocks(4), wantErr: ErrShareSizeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			et, err := NewExtended(nil, tt.blocks)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewExtended() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if et.NumLeaves != 2*len(tt.blocks) {
+				t.Fatalf("NewExtended() has %d leaves, want %d", et.NumLeaves, 2*len(tt.blocks))
+			}
+			shares := make(map[int][]byte)
+			for i := range et.Shares {
+				sample, err := et.Sample(i)
+				if err != nil {
+					t.Fatalf("Sample() error = %v", err)
+				}
+				if ok, err := VerifySample(sample, et.Root, nil); err != nil || !ok {
+					t.Fatalf("VerifySample() of share %d = %v, %v", i, ok, err)
+				}
+				if tt.keep(i) {
+					shares[i] = sample.Share
+				}
+			}
+			original, err := Reconstruct(shares, et.NumOriginal)
+			if err != nil {
+				t.Fatalf("Reconstruct() error = %v", err)
+			}
+			for i, block := range tt.blocks {
+				data, _ := block.Serialize()
+				if !bytes.Equal(original[i], data) {
+					t.Fatalf("Reconstruct() share %d differs from the data block", i)
+				}
+			}
+			delete(shares, len(et.Shares)-1)
+			if len(shares) < et.NumOriginal {
+				if _, err := Reconstruct(shares, et.NumOriginal); !errors.Is(err, ErrNotEnoughShares) {
+					t.Errorf("Reconstruct() error = %v, want %v", err, ErrNotEnoughShares)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifySample_wrongIndex(t *testing.T) {
+	et, err := NewExtended(nil, mockDataBlocksFixedSize(8))
+	if err != nil {
+		t.Fatalf("NewExtended() error = %v", err)
+	}
+	sample, err := et.Sample(3)
+	if err != nil {
+		t.Fatalf("Sample() error = %v", err)
+	}
+	sample.Index = 12
+	if ok, err := VerifySample(sample, et.Root, nil); err != nil || ok {
+		t.Errorf("VerifySample() = %v, %v, want false", ok, err)
+	}
+}
@@ -22,7 +22,10 @@
 
 package merkletree
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrInvalidNumOfDataBlocks is the error for an invalid number of data blocks.
@@ -31,11 +34,241 @@ var (
 	ErrInvalidConfigMode = errors.New("invalid configuration mode")
 	// ErrProofIsNil is the error for a nil proof.
 	ErrProofIsNil = errors.New("proof is nil")
-	// ErrDataBlockIsNil is the error for a nil data block.
+	// ErrDataBlockIsNil is the error for a nil data block, or a nil pointer data block given to New.
 	ErrDataBlockIsNil = errors.New("data block is nil")
 	// ErrProofInvalidModeTreeNotBuilt is the error for an invalid mode in Proof() function.
 	// Proof() function requires a built tree to generate the proof.
 	ErrProofInvalidModeTreeNotBuilt = errors.New("merkle tree is not in built, could not generate proof by this method")
 	// ErrProofInvalidDataBlock is the error for an invalid data block in Proof() function.
 	ErrProofInvalidDataBlock = errors.New("data block is not a member of the merkle tree")
+	// ErrDirectionalProofLengthMismatch is the error for a directional proof whose number of siblings
+	// differs from its number of directions.
+	ErrDirectionalProofLengthMismatch = errors.New("directional proof siblings and directions length mismatch")
+	// ErrProofSiblingTooLong is the error for a sibling that does not fit into a Solidity bytes32 value.
+	ErrProofSiblingTooLong = errors.New("proof sibling is longer than 32 bytes")
+	// ErrMemoryBudgetExceeded is the error for a build whose estimated memory exceeds Config.MaxMemoryBytes.
+	// Errors returned by New for this reason are of type *MemoryBudgetError and match it with errors.Is.
+	ErrMemoryBudgetExceeded = errors.New("estimated memory exceeds the configured budget")
+	// ErrRootMismatch is the error for a cached root that does not match the root recomputed from the leaves.
+	ErrRootMismatch = errors.New("merkle root does not match the leaves")
+	// ErrNodeMismatch is the error for a stored node that does not match the hash of its children.
+	ErrNodeMismatch = errors.New("merkle tree node does not match its children")
+	// ErrTreeNotBuilt is the error for an operation requiring the tree structure,
+	// which is only stored in ModeTreeBuild and ModeProofGenAndTreeBuild.
+	ErrTreeNotBuilt = errors.New("merkle tree structure is not built")
+	// ErrInvalidTreeEncoding is the error for a malformed serialized tree.
+	ErrInvalidTreeEncoding = errors.New("invalid serialized merkle tree")
+	// ErrNonUniformNodeLength is the error for serializing a tree whose leaves or nodes differ in length.
+	ErrNonUniformNodeLength = errors.New("merkle tree nodes of a level must have the same length")
+	// ErrIndexOutOfRange is the error for a leaf or tree index out of range.
+	ErrIndexOutOfRange = errors.New("index out of range")
+	// ErrTreeHeadMissing is the error for a serialized proof without the required signed tree head.
+	ErrTreeHeadMissing = errors.New("signed tree head is missing")
+	// ErrTreeHeadSignature is the error for a signed tree head with an invalid signature.
+	ErrTreeHeadSignature = errors.New("invalid signed tree head signature")
+	// ErrTreeHeadStale is the error for a signed tree head older than the accepted freshness window.
+	ErrTreeHeadStale = errors.New("signed tree head is stale")
+	// ErrSaltedLeafHashingDisabled is the error for building a salted tree with leaf hashing disabled.
+	ErrSaltedLeafHashingDisabled = errors.New("salted leaves require leaf hashing")
+	// ErrSumOverflow is the error for balances of a summation tree whose total overflows uint64.
+	ErrSumOverflow = errors.New("summation tree total overflows uint64")
+	// ErrInvalidArity is the error for a tree arity lower than 2.
+	ErrInvalidArity = errors.New("tree arity must be at least 2")
+	// ErrUnknownTreeID is the error for a tree ID that is not registered.
+	ErrUnknownTreeID = errors.New("unknown tree ID")
+	// ErrKeyNotFound is the error for a key that is not in a tree built from a map.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrInvalidCheckpoint is the error for a missing, incomplete or mismatching build checkpoint.
+	ErrInvalidCheckpoint = errors.New("invalid build checkpoint")
+	// ErrTooManyShares is the error when the extension of the data blocks exceeds MaxExtendedShares.
+	ErrTooManyShares = errors.New("too many shares for the Reed-Solomon extension")
+	// ErrShareSizeMismatch is the error when the shares of an extension have different lengths.
+	ErrShareSizeMismatch = errors.New("shares must have the same length")
+	// ErrNotEnoughShares is the error when there are not enough shares to reconstruct the data.
+	ErrNotEnoughShares = errors.New("not enough shares to reconstruct the data")
+	// ErrInvalidSampleSize is the error for a sample size that is not between 1 and the number of leaves.
+	ErrInvalidSampleSize = errors.New("sample size must be between 1 and the number of leaves")
+	// ErrVRFProof is the error for a sample whose VRF proof is invalid.
+	ErrVRFProof = errors.New("invalid sample VRF proof")
+	// ErrInvalidEnvelope is the error for a malformed proof envelope.
+	ErrInvalidEnvelope = errors.New("invalid proof envelope")
+	// ErrEnvelopeSignature is the error for a proof envelope with an invalid issuer signature.
+	ErrEnvelopeSignature = errors.New("invalid proof envelope signature")
+	// ErrEnvelopeDecryption is the error for a proof envelope that cannot be decrypted with the recipient key.
+	ErrEnvelopeDecryption = errors.New("proof envelope decryption failed")
+	// ErrEnvelopeBinding is the error for a proof envelope that is not bound to the expected recipient.
+	ErrEnvelopeBinding = errors.New("proof envelope is not bound to the recipient")
+	// ErrLeafHashingMismatch is the error for verifying a serialized proof with a configuration whose
+	// DisableLeafHashing differs from the leaf hashing policy of the tree the proof was generated from.
+	ErrLeafHashingMismatch = errors.New("proof leaf hashing policy does not match the verifier configuration")
+	// ErrInvalidPaddingScheme is the error for an unknown padding scheme.
+	ErrInvalidPaddingScheme = errors.New("invalid padding scheme")
+	// ErrInvalidLeafHashFile is the error for a leaf hash file that is not a sequence of fixed-size leaves.
+	ErrInvalidLeafHashFile = errors.New("invalid leaf hash file")
+	// ErrInvalidSubtreeResult is the error for a subtree task or result of a distributed build
+	// that does not match the planned partition of the leaves.
+	ErrInvalidSubtreeResult = errors.New("invalid subtree result")
+	// ErrLeafDataMissing is the error for verifying a serialized proof that does not embed the leaf data.
+	ErrLeafDataMissing = errors.New("serialized proof does not embed the leaf data")
+	// ErrEmbeddedLeafMismatch is the error for a serialized proof whose embedded leaf hash does not match
+	// the leaf computed from its embedded data.
+	ErrEmbeddedLeafMismatch = errors.New("embedded leaf hash does not match the embedded data")
+	// ErrTooManyLeaves is the error for a tree with more leaves than Config.MaxLeaves.
+	// Errors returned for this reason are of type *LimitError and match it with errors.Is.
+	ErrTooManyLeaves = errors.New("too many leaves")
+	// ErrTreeTooDeep is the error for a tree deeper than Config.MaxDepth.
+	// Errors returned for this reason are of type *LimitError and match it with errors.Is.
+	ErrTreeTooDeep = errors.New("tree is too deep")
+	// ErrInvalidBundle is the error for a malformed tree bundle file.
+	ErrInvalidBundle = errors.New("invalid tree bundle")
+	// ErrBundleConfigMismatch is the error for a tree bundle whose configuration fingerprint differs from
+	// the fingerprint of the configuration it is used with.
+	ErrBundleConfigMismatch = errors.New("tree bundle configuration fingerprint mismatch")
+	// ErrBundleLeavesMissing is the error for reassembling a tree from a bundle loaded without its leaves.
+	ErrBundleLeavesMissing = errors.New("tree bundle leaves are not loaded")
+	// ErrInvalidHex is the error for a leaf, sibling or root that is not a valid hexadecimal string.
+	ErrInvalidHex = errors.New("invalid hexadecimal string")
+	// ErrProofPathRequired is the error for verifying a hexadecimal proof without its path or leaf index
+	// while the configuration mixes node positions into the hashes.
+	ErrProofPathRequired = errors.New("proof path or leaf index is required by positional hashing")
+	// ErrAmbiguousTree is the error for a tree whose root is also the root of a tree over fewer leaves,
+	// because a level ends with two identical nodes, see MerkleTree.CheckAmbiguity.
+	ErrAmbiguousTree = errors.New("merkle root is shared with a tree over fewer leaves")
+	// ErrLeafCountRequired is the error for verifying against a size-bound root, see Config.BindLeafCount,
+	// without setting Config.ExpectedNumLeaves.
+	ErrLeafCountRequired = errors.New("number of leaves is required to verify against a size-bound root")
+	// ErrStreamMismatch is the error for a stream of leaves, or its helper, that does not match the root.
+	ErrStreamMismatch = errors.New("leaf stream does not match the merkle root")
+	// ErrLeafTimestampMissing is the error for a data block without timestamp while Config.TimestampLeaves is set.
+	ErrLeafTimestampMissing = errors.New("data block does not implement TimestampedDataBlock")
+	// ErrLeavesNotTimeOrdered is the error for a temporal query on a tree whose leaf timestamps are not
+	// in non-decreasing order.
+	ErrLeavesNotTimeOrdered = errors.New("leaf timestamps are not in order")
+	// ErrInvalidSiblingOrder is the error for an unknown sibling order of a serialized proof.
+	ErrInvalidSiblingOrder = errors.New("invalid proof sibling order")
+	// ErrDataBlockPanic is the error for a data block whose Serialize panics, see ValidateBlocks.
+	ErrDataBlockPanic = errors.New("data block serialization panicked")
+	// ErrBlocksNotRetained is the error for rebuilding a tree built without Config.RetainBlocks.
+	ErrBlocksNotRetained = errors.New("data blocks are not retained")
+	// ErrRebuildUnsupported is the error for rebuilding a tree whose leaf order or layout depends on the
+	// data blocks: ordered by Config.LeafLess, deduplicated by Config.DeduplicateLeaves or with the nil data
+	// blocks dropped by Config.SkipNilBlocks.
+	ErrRebuildUnsupported = errors.New("partial rebuild is not supported by the tree configuration")
+	// ErrExclusionUnsupported is the error for exclusion proofs with a configuration that does not bind
+	// the leaf positions: Config.LeafLess and Config.PositionalHashFunc are required.
+	ErrExclusionUnsupported = errors.New("exclusion proofs require sorted leaves and positional hashing")
+	// ErrLeafIncluded is the error for an exclusion proof of a data block that is in the tree.
+	ErrLeafIncluded = errors.New("data block is in the tree")
+	// ErrMultiProofUnsupported is the error for multiproofs of a tree hashing its nodes with their positions,
+	// which a multiproof does not carry: Config.PositionalHashFunc must not be set.
+	ErrMultiProofUnsupported = errors.New("multiproofs do not support positional hashing")
+	// ErrMultiProofInvalid is the error for a multiproof whose numbers of leaves, siblings and flags do not match.
+	ErrMultiProofInvalid = errors.New("invalid multiproof")
+	// ErrVerificationPanic is the error for a job of a VerifierPool whose verification panicked.
+	ErrVerificationPanic = errors.New("verification panicked")
+	// ErrProofDepthMismatch is the error for building a proof whose number of siblings is not the depth
+	// of the tree, see NewProof.
+	ErrProofDepthMismatch = errors.New("number of proof siblings does not match the tree depth")
+	// ErrInvalidEventLog is the error for an event log that cannot be part of a log tree: removed by a chain
+	// reorganization, with more than 4 topics, or at the position of another log.
+	ErrInvalidEventLog = errors.New("invalid event log")
+	// ErrLogNotFound is the error for an event log position that is not in a log tree.
+	ErrLogNotFound = errors.New("event log not found")
+	// ErrLeafWidthMismatch is the error for a serialized data block that does not fit Config.LeafWidth.
+	ErrLeafWidthMismatch = errors.New("data block does not fit the leaf width")
+	// ErrTreeClosed is the error for reading a MappedTree after it is closed.
+	ErrTreeClosed = errors.New("merkle tree is closed")
+	// ErrBlockOrderUnsupported is the error for building a tree locating its data blocks by their input
+	// positions, such as a MapTree, with a configuration moving the data blocks: ordered by Config.LeafLess
+	// or shifted by the nil data blocks dropped with Config.SkipNilBlocks.
+	ErrBlockOrderUnsupported = errors.New("configuration does not preserve the positions of the data blocks")
+	// ErrSpecUnsupported is the error for describing with a VerificationSpec a configuration hashing with
+	// Go functions the spec cannot name: Config.LeafHashFunc, Config.NodeHashFunc or Config.PositionalHashFunc.
+	ErrSpecUnsupported = errors.New("configuration cannot be described by a verification spec")
 )
+
+// MemoryBudgetError is the error returned when the estimated memory of a build exceeds Config.MaxMemoryBytes.
+type MemoryBudgetError struct {
+	// Estimated is the estimated number of bytes required by the build.
+	Estimated uint64
+	// Budget is the configured MaxMemoryBytes.
+	Budget uint64
+}
+
+func (e *MemoryBudgetError) Error() string {
+	return fmt.Sprintf("%s: estimated %d bytes, budget %d bytes", ErrMemoryBudgetExceeded, e.Estimated, e.Budget)
+}
+
+// Is reports whether target is ErrMemoryBudgetExceeded.
+func (e *MemoryBudgetError) Is(target error) bool {
+	return target == ErrMemoryBudgetExceeded
+}
+
+// LimitError is the error returned when a tree exceeds Config.MaxLeaves or Config.MaxDepth.
+type LimitError struct {
+	// Err is ErrTooManyLeaves or ErrTreeTooDeep.
+	Err error
+	// Value is the number of leaves or the depth of the tree.
+	Value int
+	// Limit is the configured limit.
+	Limit int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: %d, limit %d", e.Err, e.Value, e.Limit)
+}
+
+// Unwrap returns Err, so that the error matches it with errors.Is.
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// LeafWidthError is the error returned when data blocks do not fit Config.LeafWidth.
+type LeafWidthError struct {
+	// Width is the configured LeafWidth.
+	Width int
+	// Indices are the indexes of the data blocks that do not fit, in the input slice.
+	Indices []int
+}
+
+func (e *LeafWidthError) Error() string {
+	return fmt.Sprintf("%s: width %d, data blocks %v", ErrLeafWidthMismatch, e.Width, e.Indices)
+}
+
+// Is reports whether target is ErrLeafWidthMismatch.
+func (e *LeafWidthError) Is(target error) bool {
+	return target == ErrLeafWidthMismatch
+}
+
+// BlockError locates the data block of the input slice an error is about.
+type BlockError struct {
+	// Index is the index of the data block in the input slice.
+	Index int
+	// Err is the error about the data block.
+	Err error
+}
+
+func (e *BlockError) Error() string {
+	return fmt.Sprintf("data block %d: %s", e.Index, e.Err)
+}
+
+// Unwrap returns Err, so that the error matches it with errors.Is.
+func (e *BlockError) Unwrap() error {
+	return e.Err
+}
+
+// NodeMismatchError locates a node that does not match the hash of its children.
+// Level 0 is the leaf level and level Depth is the root.
+type NodeMismatchError struct {
+	Level int
+	Index int
+}
+
+func (e *NodeMismatchError) Error() string {
+	return fmt.Sprintf("%s: level %d, index %d", ErrNodeMismatch, e.Level, e.Index)
+}
+
+// Is reports whether target is ErrNodeMismatch.
+func (e *NodeMismatchError) Is(target error) bool {
+	return target == ErrNodeMismatch
+}
@@ -0,0 +1,314 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build evm
+
+// Package evmroot publishes Merkle roots to an EVM contract with a setRoot(bytes32) transaction, closing
+// the loop of airdrop and allowlist pipelines whose trees end up committed on chain. A Publisher sends the
+// transactions through a Transactor: RPC for a node or signer (e.g. Clef) signing eth_sendTransaction,
+// or an adapter of an abigen binding. It tracks the nonce of the sender, refreshing it when a transaction
+// is rejected for its nonce, retries failed attempts with exponential backoff and waits for the receipt.
+//
+// The package is built with the evm build tag.
+package evmroot
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxAttempts is the default maximum number of attempts of a publication.
+	DefaultMaxAttempts = 5
+	// DefaultMinBackoff is the default delay before the first retry, doubled at every retry.
+	DefaultMinBackoff = time.Second
+	// DefaultMaxBackoff is the default maximum delay between two retries.
+	DefaultMaxBackoff = 30 * time.Second
+	// DefaultPollInterval is the default interval between two receipt requests.
+	DefaultPollInterval = 2 * time.Second
+)
+
+// SetRootSelector is the function selector of setRoot(bytes32), the first 4 bytes of its keccak256 hash.
+var SetRootSelector = [4]byte{0xda, 0xb5, 0xf3, 0x40}
+
+var (
+	// ErrNonce is the error of a Transactor for a transaction rejected for its nonce, e.g. already used by
+	// another transaction of the sender. The Publisher refreshes the nonce and retries.
+	ErrNonce = errors.New("evmroot: invalid transaction nonce")
+	// ErrReverted is the error for a mined transaction that reverted.
+	ErrReverted = errors.New("evmroot: transaction reverted")
+	// ErrRootSize is the error for a root that does not fit into a bytes32 value.
+	ErrRootSize = errors.New("evmroot: root is longer than 32 bytes")
+)
+
+// Transactor sends the transactions of a Publisher to the contract.
+type Transactor interface {
+	// Nonce returns the next nonce of the sender, counting its pending transactions.
+	Nonce(ctx context.Context) (uint64, error)
+	// Send sends a transaction of the sender calling the contract with the calldata at the nonce, and returns
+	// its hash. It returns an error matching ErrNonce if the nonce is rejected.
+	Send(ctx context.Context, nonce uint64, calldata []byte) (txHash string, err error)
+	// Receipt returns whether the transaction is mined and, if so, whether it succeeded.
+	Receipt(ctx context.Context, txHash string) (mined, success bool, err error)
+}
+
+// permanentError is an error of the Transactor that is not retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks the error returned by a Transactor as permanent, so that the publication is not retried,
+// e.g. for a sender that is not allowed to set the root.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Config is the configuration of a Publisher. Zero values select the defaults.
+type Config struct {
+	// Selector is the function selector called with the root, SetRootSelector if zero.
+	Selector [4]byte
+	// MaxAttempts is the maximum number of attempts to send a transaction, including the first one.
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry, doubled at every retry up to MaxBackoff.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum delay between two retries.
+	MaxBackoff time.Duration
+	// PollInterval is the interval between two receipt requests while a transaction is pending.
+	PollInterval time.Duration
+}
+
+// withDefaults returns a copy of the configuration with the defaults of the zero values.
+func (c *Config) withDefaults() Config {
+	var config Config
+	if c != nil {
+		config = *c
+	}
+
+	if config.Selector == [4]byte{} {
+		config.Selector = SetRootSelector
+	}
+
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultMinBackoff
+	}
+
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+
+	return config
+}
+
+// Publisher publishes roots to a contract. Publications are serialized, so that each one uses the next nonce.
+// It is safe for concurrent use.
+type Publisher struct {
+	tx     Transactor
+	config Config
+
+	// mu serializes the publications and guards nonce.
+	mu       sync.Mutex
+	nonce    uint64
+	hasNonce bool
+
+	// pending holds the latest root passed to OnRootChanged and not published yet.
+	pending chan []byte
+}
+
+// NewPublisher creates a publisher sending its transactions with tx, with the configuration, nil for the
+// defaults.
+func NewPublisher(tx Transactor, config *Config) *Publisher {
+	return &Publisher{
+		tx:      tx,
+		config:  config.withDefaults(),
+		pending: make(chan []byte, 1),
+	}
+}
+
+// Calldata returns the calldata of the contract call setting the root: the selector followed by the root
+// as a bytes32 value, right-padded with zeros if it is shorter.
+func (p *Publisher) Calldata(root []byte) ([]byte, error) {
+	if len(root) > 32 {
+		return nil, ErrRootSize
+	}
+
+	calldata := make([]byte, 4+32)
+	copy(calldata, p.config.Selector[:])
+	copy(calldata[4:], root)
+
+	return calldata, nil
+}
+
+// Publish sends the transaction setting the root and waits until it is mined. It returns the hash of the
+// transaction, and ErrReverted if it reverted. Failed sends are retried with a new nonce if it was rejected.
+func (p *Publisher) Publish(ctx context.Context, root []byte) (string, error) {
+	calldata, err := p.Calldata(root)
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	txHash, err := p.send(ctx, calldata)
+	p.mu.Unlock()
+
+	if err != nil {
+		return "", err
+	}
+
+	return txHash, p.wait(ctx, txHash)
+}
+
+// send sends the transaction with the next nonce, retrying failed attempts with exponential backoff.
+func (p *Publisher) send(ctx context.Context, calldata []byte) (string, error) {
+	backoff := p.config.MinBackoff
+
+	for attempt := 1; ; attempt++ {
+		txHash, err := p.attempt(ctx, calldata)
+		if err == nil {
+			return txHash, nil
+		}
+
+		// A rejected nonce is refreshed by the next attempt.
+		if errors.Is(err, ErrNonce) {
+			p.hasNonce = false
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= p.config.MaxAttempts {
+			return "", fmt.Errorf("evmroot: publication failed after %d attempts: %w", attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, p.config.MaxBackoff)
+	}
+}
+
+// attempt sends the transaction once, fetching the nonce first if it is not known.
+func (p *Publisher) attempt(ctx context.Context, calldata []byte) (string, error) {
+	if !p.hasNonce {
+		nonce, err := p.tx.Nonce(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		p.nonce, p.hasNonce = nonce, true
+	}
+
+	txHash, err := p.tx.Send(ctx, p.nonce, calldata)
+	if err != nil {
+		return "", err
+	}
+
+	p.nonce++
+
+	return txHash, nil
+}
+
+// wait polls the receipt of the transaction until it is mined.
+func (p *Publisher) wait(ctx context.Context, txHash string) error {
+	ticker := time.NewTicker(p.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		mined, success, err := p.tx.Receipt(ctx, txHash)
+
+		switch {
+		case err != nil:
+			return err
+		case mined && !success:
+			return fmt.Errorf("%w: %s", ErrReverted, txHash)
+		case mined:
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// OnRootChanged queues the new root for Run, replacing the root queued before if it is not published yet.
+// It does not block, so that it can be set as the OnRootChanged hook of a merkletree.Config.
+func (p *Publisher) OnRootChanged(_, newRoot []byte) {
+	root := append([]byte(nil), newRoot...)
+
+	for {
+		select {
+		case p.pending <- root:
+			return
+		default:
+		}
+
+		// Drop the stale root, unless Run took it meanwhile.
+		select {
+		case <-p.pending:
+		default:
+		}
+	}
+}
+
+// Run publishes the roots queued by OnRootChanged until ctx is done, reporting each publication to report
+// if it is not nil. Only the latest root queued while a publication is in progress is published next.
+func (p *Publisher) Run(ctx context.Context, report func(root []byte, txHash string, err error)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case root := <-p.pending:
+			txHash, err := p.Publish(ctx, root)
+			if report != nil {
+				report(root, txHash, err)
+			}
+		}
+	}
+}
+
+// hexData encodes the bytes as a 0x-prefixed hexadecimal string.
+func hexData(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
@@ -0,0 +1,265 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build evm
+
+package evmroot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeChain is a Transactor recording the transactions, rejecting the nonces already used.
+type fakeChain struct {
+	mu         sync.Mutex
+	nonce      uint64
+	sent       [][]byte
+	sendErrs   []error
+	reverted   bool
+	pendingFor int
+}
+
+func (c *fakeChain) Nonce(context.Context) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.nonce, nil
+}
+
+func (c *fakeChain) Send(_ context.Context, nonce uint64, calldata []byte) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.sendErrs) > 0 {
+		err := c.sendErrs[0]
+		c.sendErrs = c.sendErrs[1:]
+
+		return "", err
+	}
+
+	if nonce != c.nonce {
+		return "", ErrNonce
+	}
+
+	c.nonce++
+	c.sent = append(c.sent, calldata)
+
+	return hexData([]byte{byte(nonce)}), nil
+}
+
+func (c *fakeChain) Receipt(context.Context, string) (bool, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pendingFor > 0 {
+		c.pendingFor--
+
+		return false, false, nil
+	}
+
+	return true, !c.reverted, nil
+}
+
+var testConfig = &Config{MinBackoff: time.Millisecond, PollInterval: time.Millisecond}
+
+// sendErrs returns n transient send errors.
+func sendErrs(n int) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = errors.New("unavailable")
+	}
+
+	return errs
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	root := bytes.Repeat([]byte{0xab}, 32)
+
+	tests := []struct {
+		name     string
+		chain    *fakeChain
+		wantErr  bool
+		reverted bool
+		wantTxs  int
+	}{
+		{name: "test_published", chain: &fakeChain{pendingFor: 2}, wantTxs: 1},
+		{name: "test_transient_error", chain: &fakeChain{sendErrs: sendErrs(1)}, wantTxs: 1},
+		{name: "test_reverted", chain: &fakeChain{reverted: true}, wantErr: true, reverted: true, wantTxs: 1},
+		{
+			name:    "test_permanent_error",
+			chain:   &fakeChain{sendErrs: []error{Permanent(errors.New("unauthorized"))}},
+			wantErr: true,
+		},
+		{name: "test_attempts_exhausted", chain: &fakeChain{sendErrs: sendErrs(DefaultMaxAttempts)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPublisher(tt.chain, testConfig)
+
+			_, err := p.Publish(context.Background(), root)
+			if (err != nil) != tt.wantErr || errors.Is(err, ErrReverted) != tt.reverted {
+				t.Fatalf("Publish() error = %v, want error %t, reverted %t", err, tt.wantErr, tt.reverted)
+			}
+
+			if len(tt.chain.sent) != tt.wantTxs {
+				t.Fatalf("Publish() sent %d transactions, want %d", len(tt.chain.sent), tt.wantTxs)
+			}
+
+			if tt.wantTxs > 0 {
+				want := append(SetRootSelector[:], root...)
+				if !bytes.Equal(tt.chain.sent[0], want) {
+					t.Errorf("calldata = %x, want %x", tt.chain.sent[0], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPublisher_nonce(t *testing.T) {
+	chain := new(fakeChain)
+	p := NewPublisher(chain, testConfig)
+	ctx := context.Background()
+
+	if _, err := p.Publish(ctx, []byte{1}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	// Another transaction of the sender takes the nonce the publisher expects.
+	chain.nonce++
+
+	if _, err := p.Publish(ctx, []byte{2}); err != nil {
+		t.Fatalf("Publish() error = %v, want the nonce refreshed", err)
+	}
+
+	if len(chain.sent) != 2 || chain.nonce != 3 {
+		t.Errorf("sent %d transactions up to nonce %d, want 2 up to 3", len(chain.sent), chain.nonce)
+	}
+
+	if _, err := p.Publish(ctx, make([]byte, 33)); !errors.Is(err, ErrRootSize) {
+		t.Errorf("Publish() error = %v, want %v", err, ErrRootSize)
+	}
+}
+
+func TestPublisher_Run(t *testing.T) {
+	chain := new(fakeChain)
+	p := NewPublisher(chain, testConfig)
+
+	// Only the latest of the roots queued before Run is published.
+	p.OnRootChanged(nil, []byte{1})
+	p.OnRootChanged([]byte{1}, []byte{2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan []byte)
+
+	go p.Run(ctx, func(root []byte, _ string, err error) {
+		if err != nil {
+			t.Errorf("Run() publication error = %v", err)
+		}
+		done <- root
+	})
+
+	if root := <-done; !bytes.Equal(root, []byte{2}) {
+		t.Errorf("Run() published %x, want 02", root)
+	}
+
+	cancel()
+
+	if len(chain.sent) != 1 {
+		t.Errorf("Run() sent %d transactions, want 1", len(chain.sent))
+	}
+}
+
+func TestRPC(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		sentNonce []string
+		receipts  int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		var result any
+
+		switch req.Method {
+		case "eth_getTransactionCount":
+			result = "0x7"
+		case "eth_sendTransaction":
+			var tx map[string]string
+			_ = json.Unmarshal(req.Params[0], &tx)
+			sentNonce = append(sentNonce, tx["nonce"])
+
+			if tx["nonce"] == "0x7" {
+				_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": -32000, "message": "nonce too low"}})
+
+				return
+			}
+
+			result = "0xhash"
+		case "eth_getTransactionReceipt":
+			if receipts++; receipts > 1 {
+				result = map[string]string{"status": "0x1"}
+			}
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer server.Close()
+
+	rpc := &RPC{URL: server.URL, From: "0x01", Contract: "0x02"}
+	p := NewPublisher(rpc, testConfig)
+
+	// The first send is rejected for its nonce, and the nonce refetched is 0x7 again, so the publisher
+	// exhausts its attempts; a signer returning the next nonce lets it through.
+	if _, err := p.Publish(context.Background(), []byte{1}); !errors.Is(err, ErrNonce) {
+		t.Fatalf("Publish() error = %v, want %v", err, ErrNonce)
+	}
+
+	p.nonce, p.hasNonce = 8, true
+
+	txHash, err := p.Publish(context.Background(), []byte{1})
+	if err != nil || txHash != "0xhash" {
+		t.Fatalf("Publish() = %q, %v, want 0xhash", txHash, err)
+	}
+
+	if sentNonce[len(sentNonce)-1] != "0x8" {
+		t.Errorf("sent nonces %v, want the last one 0x8", sentNonce)
+	}
+}
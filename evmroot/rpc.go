@@ -0,0 +1,159 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build evm
+
+package evmroot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// RPC is a Transactor sending the transactions over Ethereum JSON-RPC with eth_sendTransaction,
+// which the node or the signer behind the endpoint (e.g. Clef) signs for the sender account.
+type RPC struct {
+	// URL is the JSON-RPC endpoint.
+	URL string
+	// From is the address of the sender, Contract the address of the contract, both 0x-prefixed.
+	From     string
+	Contract string
+	// Client is the HTTP client of the requests, http.DefaultClient if nil.
+	Client *http.Client
+
+	id atomic.Int64
+}
+
+var _ Transactor = (*RPC)(nil)
+
+// RPCError is an error returned by the JSON-RPC endpoint. It matches ErrNonce if its message reports
+// a nonce already used.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("evmroot: json-rpc error %d: %s", e.Code, e.Message)
+}
+
+// Is reports whether target is ErrNonce and the error reports a nonce already used.
+func (e *RPCError) Is(target error) bool {
+	msg := strings.ToLower(e.Message)
+
+	return target == ErrNonce && (strings.Contains(msg, "nonce too low") || strings.Contains(msg, "already known"))
+}
+
+// call calls the JSON-RPC method with the parameters and decodes its result into result.
+func (r *RPC) call(ctx context.Context, method string, result any, params ...any) error {
+	body, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": r.id.Add(1), "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("evmroot: json-rpc %s: %s", method, resp.Status)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("evmroot: json-rpc %s: %w", method, err)
+	}
+
+	if response.Error != nil {
+		return response.Error
+	}
+
+	return json.Unmarshal(response.Result, result)
+}
+
+// Nonce returns the pending transaction count of the sender.
+func (r *RPC) Nonce(ctx context.Context) (uint64, error) {
+	var count string
+	if err := r.call(ctx, "eth_getTransactionCount", &count, r.From, "pending"); err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(strings.TrimPrefix(count, "0x"), 16, 64)
+}
+
+// Send sends the transaction with eth_sendTransaction.
+func (r *RPC) Send(ctx context.Context, nonce uint64, calldata []byte) (string, error) {
+	tx := map[string]string{
+		"from":  r.From,
+		"to":    r.Contract,
+		"data":  hexData(calldata),
+		"nonce": "0x" + strconv.FormatUint(nonce, 16),
+	}
+
+	var txHash string
+	if err := r.call(ctx, "eth_sendTransaction", &txHash, tx); err != nil {
+		return "", err
+	}
+
+	return txHash, nil
+}
+
+// Receipt returns the status of the receipt of the transaction, which is null while it is pending.
+func (r *RPC) Receipt(ctx context.Context, txHash string) (bool, bool, error) {
+	var receipt *struct {
+		Status string `json:"status"`
+	}
+
+	if err := r.call(ctx, "eth_getTransactionReceipt", &receipt, txHash); err != nil {
+		return false, false, err
+	}
+
+	if receipt == nil {
+		return false, false, nil
+	}
+
+	return true, receipt.Status == "0x1", nil
+}
@@ -0,0 +1,348 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// ExclusionProof proves that a data block is not in a tree whose leaves are sorted with Config.LeafLess:
+// the leaves adjacent in the tree that straddle its leaf, with their proofs. Only one of them is set if
+// the leaf precedes the first leaf or follows the last one.
+type ExclusionProof struct {
+	// Left is the greatest leaf preceding the leaf of the data block, nil if there is none.
+	Left []byte
+	// LeftProof is the proof of Left.
+	LeftProof *Proof
+	// Right is the smallest leaf following the leaf of the data block, nil if there is none.
+	Right []byte
+	// RightProof is the proof of Right.
+	RightProof *Proof
+}
+
+// ExclusionProof returns the proof that the data block is not in the tree, or ErrLeafIncluded if it is.
+// The tree must be built with LeafLess, so that the leaves straddling the leaf of the data block are
+// adjacent, and with PositionalHashFunc, so that the verifier can check their positions, see VerifyExclusion.
+func (m *MerkleTree) ExclusionProof(dataBlock DataBlock) (*ExclusionProof, error) {
+	if m.LeafLess == nil || m.PositionalHashFunc == nil {
+		return nil, ErrExclusionUnsupported
+	}
+
+	leaf, err := dataBlockToLeaf(dataBlock, m.leafHashFunc(), m.DisableLeafHashing)
+	if err != nil {
+		return nil, err
+	}
+
+	pos, err := m.straddle(leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(ExclusionProof)
+
+	if pos > 0 {
+		p.Left = m.Leaves[pos-1]
+		if p.LeftProof, err = m.proofByIndex(pos - 1); err != nil {
+			return nil, err
+		}
+	}
+
+	if pos < m.NumLeaves {
+		p.Right = m.Leaves[pos]
+		if p.RightProof, err = m.proofByIndex(pos); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// straddle returns the position of the first leaf following the leaf, or ErrLeafIncluded if the leaf is
+// in the tree. The leaves straddling it are at the positions before and at the returned one.
+func (m *MerkleTree) straddle(leaf []byte) (int, error) {
+	pos := sort.Search(m.NumLeaves, func(i int) bool { return !m.LeafLess(m.Leaves[i], leaf) })
+	if pos < m.NumLeaves && !m.LeafLess(leaf, m.Leaves[pos]) {
+		return -1, ErrLeafIncluded
+	}
+
+	return pos, nil
+}
+
+// VerifyExclusion checks the proof that the data block is not in the tree with the root. The configuration
+// must set LeafLess and PositionalHashFunc as the tree, and ExpectedNumLeaves to its number of leaves.
+//
+// Sibling hashes are combined whatever their order, so the verifier enforces the positions itself:
+// positional hashing binds the index of every interior node, the leaf index is bound up to its lowest bit
+// by the index of its parent, and the lowest bit is checked against the order of the leaf and its sibling
+// leaf. The straddling leaves must then be consecutive, or the first or last leaf of the tree, and in order
+// around the leaf of the data block.
+func VerifyExclusion(dataBlock DataBlock, p *ExclusionProof, root []byte, config *Config) (bool, error) {
+	if dataBlock == nil {
+		return false, ErrDataBlockIsNil
+	}
+
+	if p == nil {
+		return false, ErrProofIsNil
+	}
+
+	if err := checkExclusionConfig(config); err != nil {
+		return false, err
+	}
+
+	leaf, err := dataBlockToLeaf(dataBlock, config.leafHashFunc(), config.DisableLeafHashing)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		n           = config.ExpectedNumLeaves
+		less        = config.LeafLess
+		left, right = -1, n
+		hasNeighbor bool
+	)
+
+	if p.Left != nil {
+		if p.LeftProof == nil || !less(p.Left, leaf) {
+			return false, nil
+		}
+
+		if left, err = verifySortedLeaf(p.Left, p.LeftProof, root, config); err != nil || left < 0 {
+			return false, err
+		}
+
+		hasNeighbor = true
+	}
+
+	if p.Right != nil {
+		if p.RightProof == nil || !less(leaf, p.Right) {
+			return false, nil
+		}
+
+		if right, err = verifySortedLeaf(p.Right, p.RightProof, root, config); err != nil || right < 0 {
+			return false, err
+		}
+
+		hasNeighbor = true
+	}
+
+	return hasNeighbor && right == left+1, nil
+}
+
+// checkExclusionConfig checks that the verifier configuration binds the leaf positions, and sets its
+// default hash function.
+func checkExclusionConfig(config *Config) error {
+	if config == nil || config.LeafLess == nil || config.PositionalHashFunc == nil {
+		return ErrExclusionUnsupported
+	}
+
+	if config.ExpectedNumLeaves <= 0 {
+		return ErrLeafCountRequired
+	}
+
+	if config.HashFunc == nil {
+		config.HashFunc = DefaultHashFunc
+	}
+
+	return nil
+}
+
+// verifySortedLeaf verifies the leaf with its proof and returns its index, or -1 if the proof is invalid
+// or the leaf is out of order with its sibling leaf.
+func verifySortedLeaf(leaf []byte, proof *Proof, root []byte, config *Config) (int, error) {
+	n := config.ExpectedNumLeaves
+	if len(proof.Siblings) == 0 || len(proof.Siblings) != bits.Len(uint(n-1)) {
+		return -1, nil
+	}
+
+	idx := proof.Index()
+	if idx >= n {
+		return -1, nil
+	}
+
+	sibling := proof.Siblings[0]
+
+	switch {
+	case idx&1 == 1:
+		if config.LeafLess(leaf, sibling) {
+			return -1, nil
+		}
+	case idx+1 < n:
+		if config.LeafLess(sibling, leaf) {
+			return -1, nil
+		}
+	case !bytes.Equal(sibling, leaf):
+		// The last leaf of an odd number of leaves is paired with its duplicate.
+		return -1, nil
+	}
+
+	ok, err := config.verifyLeaf(leaf, proof.Siblings, proof.Path, root)
+	if err != nil || !ok {
+		return -1, err
+	}
+
+	return idx, nil
+}
+
+// Gap locates the leaves straddling an absent value in an AbsenceProof, by their indexes in its
+// Neighbors, -1 if the value precedes the first leaf or follows the last one.
+type Gap struct {
+	Left  int `json:"left"`
+	Right int `json:"right"`
+}
+
+// AbsenceProof proves that a batch of values is not in a tree whose leaves are sorted, as one
+// ExclusionProof per value, but with every straddling leaf and its proof included and verified once,
+// however many values fall next to it, e.g. when screening many addresses against a committed blocklist.
+type AbsenceProof struct {
+	// Neighbors are the distinct leaves straddling the values, in leaf order.
+	Neighbors []HexBytes `json:"neighbors"`
+	// Proofs are the proofs of the Neighbors.
+	Proofs []*SerializedProof `json:"proofs"`
+	// Gaps are the straddling leaves of the values, in the order of the values.
+	Gaps []Gap `json:"gaps"`
+}
+
+// ProveAbsent returns the proof that none of the values, serialized data blocks, is in the tree, or an
+// error matching ErrLeafIncluded for the first value that is. The tree must support exclusion proofs,
+// see ExclusionProof.
+func (m *MerkleTree) ProveAbsent(values [][]byte) (*AbsenceProof, error) {
+	if m.LeafLess == nil || m.PositionalHashFunc == nil {
+		return nil, ErrExclusionUnsupported
+	}
+
+	var (
+		p         = &AbsenceProof{Gaps: make([]Gap, len(values))}
+		neighbors = make(map[int]int)
+	)
+
+	// neighbor returns the index in Neighbors of the leaf at pos, adding it with its proof if needed.
+	neighbor := func(pos int) (int, error) {
+		if pos < 0 || pos >= m.NumLeaves {
+			return -1, nil
+		}
+
+		if k, ok := neighbors[pos]; ok {
+			return k, nil
+		}
+
+		proof, err := m.proofByIndex(pos)
+		if err != nil {
+			return -1, err
+		}
+
+		neighbors[pos] = len(p.Neighbors)
+		p.Neighbors = append(p.Neighbors, m.Leaves[pos])
+		p.Proofs = append(p.Proofs, NewSerializedProof(proof))
+
+		return neighbors[pos], nil
+	}
+
+	for i, value := range values {
+		leaf, err := bytesToLeaf(value, m.leafHashFunc(), m.DisableLeafHashing)
+		if err != nil {
+			return nil, err
+		}
+
+		pos, err := m.straddle(leaf)
+		if err != nil {
+			return nil, fmt.Errorf("%w: value %d", err, i)
+		}
+
+		if p.Gaps[i].Left, err = neighbor(pos - 1); err != nil {
+			return nil, err
+		}
+
+		if p.Gaps[i].Right, err = neighbor(pos); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// VerifyAbsent checks the proof that none of the values, serialized data blocks, is in the tree with
+// the root. Every neighbor leaf is verified once as in VerifyExclusion, whose configuration requirements
+// apply, then every value is checked to lie in its gap between consecutive neighbors.
+func VerifyAbsent(values [][]byte, p *AbsenceProof, root []byte, config *Config) (bool, error) {
+	if p == nil {
+		return false, ErrProofIsNil
+	}
+
+	if err := checkExclusionConfig(config); err != nil {
+		return false, err
+	}
+
+	if len(p.Gaps) != len(values) || len(p.Proofs) != len(p.Neighbors) {
+		return false, nil
+	}
+
+	indexes := make([]int, len(p.Neighbors))
+	for k, neighbor := range p.Neighbors {
+		if p.Proofs[k] == nil {
+			return false, nil
+		}
+
+		idx, err := verifySortedLeaf(neighbor, p.Proofs[k].Proof(), root, config)
+		if err != nil || idx < 0 {
+			return false, err
+		}
+
+		indexes[k] = idx
+	}
+
+	// at returns the leaf index and the leaf of the neighbor k, or the given bound if k is -1.
+	at := func(k, bound int) (int, []byte, bool) {
+		if k == -1 {
+			return bound, nil, true
+		}
+
+		if k < 0 || k >= len(indexes) {
+			return 0, nil, false
+		}
+
+		return indexes[k], p.Neighbors[k], true
+	}
+
+	for i, value := range values {
+		leaf, err := bytesToLeaf(value, config.leafHashFunc(), config.DisableLeafHashing)
+		if err != nil {
+			return false, err
+		}
+
+		gap := p.Gaps[i]
+
+		left, leftLeaf, okLeft := at(gap.Left, -1)
+		right, rightLeaf, okRight := at(gap.Right, config.ExpectedNumLeaves)
+
+		if !okLeft || !okRight || right != left+1 ||
+			(leftLeaf != nil && !config.LeafLess(leftLeaf, leaf)) ||
+			(rightLeaf != nil && !config.LeafLess(leaf, rightLeaf)) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,231 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestMerkleTree_ExclusionProof(t *testing.T) {
+	config := &Config{
+		LeafLess:           LeafLessBytes,
+		PositionalHashFunc: PositionPrefixed(nil),
+		DisableLeafHashing: true,
+	}
+
+	for _, letters := range []string{"hbdfjl", "lbdfhjn"} {
+		m, err := New(config, letterBlocks(letters))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		verifyConfig := *config
+		verifyConfig.ExpectedNumLeaves = m.NumLeaves
+
+		tests := []struct {
+			name      string
+			letter    byte
+			wantLeft  bool
+			wantRight bool
+		}{
+			{name: "test_before_first", letter: 'a', wantRight: true},
+			{name: "test_between", letter: 'e', wantLeft: true, wantRight: true},
+			{name: "test_after_last", letter: 'z', wantLeft: true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name+"_"+letters, func(t *testing.T) {
+				block := &mock.DataBlock{Data: []byte{tt.letter}}
+
+				p, err := m.ExclusionProof(block)
+				if err != nil {
+					t.Fatalf("ExclusionProof() error = %v", err)
+				}
+
+				if (p.Left != nil) != tt.wantLeft || (p.Right != nil) != tt.wantRight {
+					t.Fatalf("ExclusionProof() = %+v", p)
+				}
+
+				if ok, err := VerifyExclusion(block, p, m.Root, &verifyConfig); err != nil || !ok {
+					t.Errorf("VerifyExclusion() = %v, %v, want true", ok, err)
+				}
+
+				if ok, _ := VerifyExclusion(&mock.DataBlock{Data: []byte{'c'}}, p, m.Root, &verifyConfig); ok &&
+					tt.letter != 'c' {
+					t.Errorf("VerifyExclusion() accepted the proof for another data block")
+				}
+			})
+		}
+
+		if _, err := m.ExclusionProof(&mock.DataBlock{Data: []byte{'f'}}); !errors.Is(err, ErrLeafIncluded) {
+			t.Errorf("ExclusionProof() error = %v, want %v", err, ErrLeafIncluded)
+		}
+	}
+}
+
+func TestVerifyExclusion_forged(t *testing.T) {
+	config := &Config{
+		LeafLess:           LeafLessBytes,
+		PositionalHashFunc: PositionPrefixed(nil),
+		DisableLeafHashing: true,
+	}
+
+	// Leaves b d f h j l at indexes 0 to 5.
+	m, err := New(config, letterBlocks("bdfhjl"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	verifyConfig := *config
+	verifyConfig.ExpectedNumLeaves = m.NumLeaves
+
+	flipped := func(proof *Proof) *Proof {
+		return &Proof{Siblings: proof.Siblings, Path: proof.Path ^ 1}
+	}
+
+	tests := []struct {
+		name   string
+		letter byte
+		proof  *ExclusionProof
+	}{
+		{
+			name:   "test_skipping_leaf",
+			letter: 'f',
+			proof:  &ExclusionProof{Left: []byte("d"), LeftProof: m.Proofs[1], Right: []byte("h"), RightProof: m.Proofs[3]},
+		},
+		{
+			// f is claimed at the index of h, its pair sibling, to look adjacent to j.
+			name:   "test_swapped_pair",
+			letter: 'h',
+			proof: &ExclusionProof{
+				Left: []byte("f"), LeftProof: flipped(m.Proofs[2]), Right: []byte("j"), RightProof: m.Proofs[4],
+			},
+		},
+		{
+			name:   "test_not_last",
+			letter: 'k',
+			proof:  &ExclusionProof{Left: []byte("j"), LeftProof: m.Proofs[4]},
+		},
+		{
+			name:   "test_not_first",
+			letter: 'e',
+			proof:  &ExclusionProof{Right: []byte("f"), RightProof: m.Proofs[2]},
+		},
+		{
+			name:   "test_out_of_order",
+			letter: 'k',
+			proof:  &ExclusionProof{Left: []byte("h"), LeftProof: m.Proofs[3], Right: []byte("j"), RightProof: m.Proofs[4]},
+		},
+		{name: "test_empty", letter: 'k', proof: &ExclusionProof{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := &mock.DataBlock{Data: []byte{tt.letter}}
+			if ok, err := VerifyExclusion(block, tt.proof, m.Root, &verifyConfig); ok {
+				t.Errorf("VerifyExclusion() = %v, %v, want false", ok, err)
+			}
+		})
+	}
+
+	p, err := m.ExclusionProof(&mock.DataBlock{Data: []byte{'e'}})
+	if err != nil {
+		t.Fatalf("ExclusionProof() error = %v", err)
+	}
+
+	unbound := *config
+	if _, err := VerifyExclusion(&mock.DataBlock{Data: []byte{'e'}}, p, m.Root, &unbound); !errors.Is(err, ErrLeafCountRequired) {
+		t.Errorf("VerifyExclusion() error = %v, want %v", err, ErrLeafCountRequired)
+	}
+
+	unbound.PositionalHashFunc = nil
+	if _, err := VerifyExclusion(&mock.DataBlock{Data: []byte{'e'}}, p, m.Root, &unbound); !errors.Is(err, ErrExclusionUnsupported) {
+		t.Errorf("VerifyExclusion() error = %v, want %v", err, ErrExclusionUnsupported)
+	}
+}
+
+func TestMerkleTree_ProveAbsent(t *testing.T) {
+	config := &Config{
+		LeafLess:           LeafLessBytes,
+		PositionalHashFunc: PositionPrefixed(nil),
+	}
+
+	blocks := letterBlocks("bdfhjlnp")
+
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	verifyConfig := *config
+	verifyConfig.ExpectedNumLeaves = m.NumLeaves
+
+	values := make([][]byte, 0, 26)
+	for c := byte('a'); c <= 'z'; c++ {
+		if !bytes.ContainsRune([]byte("bdfhjlnp"), rune(c)) {
+			values = append(values, []byte{c})
+		}
+	}
+
+	p, err := m.ProveAbsent(values)
+	if err != nil {
+		t.Fatalf("ProveAbsent() error = %v", err)
+	}
+
+	if len(p.Neighbors) > m.NumLeaves || len(p.Gaps) != len(values) {
+		t.Errorf("ProveAbsent() = %d neighbors, %d gaps", len(p.Neighbors), len(p.Gaps))
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	decoded := new(AbsenceProof)
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if ok, err := VerifyAbsent(values, decoded, m.Root, &verifyConfig); err != nil || !ok {
+		t.Errorf("VerifyAbsent() = %v, %v, want true", ok, err)
+	}
+
+	tampered := append([][]byte{}, values...)
+	tampered[3] = []byte("h")
+
+	if ok, _ := VerifyAbsent(tampered, decoded, m.Root, &verifyConfig); ok {
+		t.Errorf("VerifyAbsent() accepted a value of the tree")
+	}
+
+	decoded.Gaps[0], decoded.Gaps[1] = decoded.Gaps[1], decoded.Gaps[0]
+	if ok, _ := VerifyAbsent(values, decoded, m.Root, &verifyConfig); ok {
+		t.Errorf("VerifyAbsent() accepted swapped gaps")
+	}
+
+	if _, err := m.ProveAbsent([][]byte{[]byte("a"), []byte("f")}); !errors.Is(err, ErrLeafIncluded) {
+		t.Errorf("ProveAbsent() error = %v, want %v", err, ErrLeafIncluded)
+	}
+}
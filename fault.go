@@ -0,0 +1,136 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"slices"
+)
+
+// FaultInjector corrupts the nodes and proofs of trees built by New on demand, so that integrators can test
+// how their systems handle invalid trees and proofs without patching the package. Set it in Config.FaultInjector.
+type FaultInjector interface {
+	// Node is called once the tree is built with every node it stores: the leaves (level 0), the interior nodes
+	// (with the padding duplicates of odd levels) if the structure is stored, and the root (level Depth).
+	// It returns the node to store in its place. The nodes of ModeLazy trees, but the root, are passed when
+	// the structure is materialized.
+	Node(level, idx int, node []byte) []byte
+	// Proof is called with every proof of the leaf at idx generated by the tree, and returns the proof
+	// to return in its place.
+	Proof(idx int, proof *Proof) *Proof
+}
+
+// Faults is a FaultInjector flipping the bits of the last byte of the listed nodes,
+// and of the last byte of the first sibling of the proofs of the listed leaves.
+type Faults struct {
+	// Nodes are the nodes to corrupt.
+	Nodes []NodeRef
+	// Proofs are the indices of the leaves whose proofs are corrupted.
+	Proofs []int
+}
+
+// Node corrupts the node if it is listed in Nodes.
+func (f *Faults) Node(level, idx int, node []byte) []byte {
+	if !slices.Contains(f.Nodes, NodeRef{Level: level, Index: idx}) {
+		return node
+	}
+
+	return flipLastByte(node)
+}
+
+// Proof corrupts the proof if idx is listed in Proofs.
+func (f *Faults) Proof(idx int, proof *Proof) *Proof {
+	if !slices.Contains(f.Proofs, idx) || len(proof.Siblings) == 0 {
+		return proof
+	}
+
+	corrupted := &Proof{
+		Siblings: slices.Clone(proof.Siblings),
+		Path:     proof.Path,
+	}
+	corrupted.Siblings[0] = flipLastByte(corrupted.Siblings[0])
+
+	return corrupted
+}
+
+// flipLastByte returns a copy of b with the bits of its last byte flipped, or a single byte if b is empty.
+func flipLastByte(b []byte) []byte {
+	if len(b) == 0 {
+		return []byte{0xff}
+	}
+
+	b = bytes.Clone(b)
+	b[len(b)-1] ^= 0xff
+
+	return b
+}
+
+// injectFaults passes the leaves, the stored nodes, the root and the generated proofs of the built tree
+// to the FaultInjector. The leaves of ModeLazy trees are passed with their structure, when materialized.
+func (m *MerkleTree) injectFaults() {
+	switch {
+	case m.nodes != nil:
+		m.injectNodeFaults(m.nodes)
+	case m.Mode != ModeLazy:
+		for i, leaf := range m.Leaves {
+			m.Leaves[i] = m.FaultInjector.Node(0, i, leaf)
+		}
+
+		// The stored levels of ModeLowMemory trees, from the level above the leaves.
+		levels := m.Depth - len(m.topNodes)
+		for i, nodes := range m.topNodes {
+			for idx, node := range nodes {
+				if levels+i > 0 {
+					nodes[idx] = m.FaultInjector.Node(levels+i, idx, node)
+				}
+			}
+		}
+	}
+
+	m.Root = m.FaultInjector.Node(m.Depth, 0, m.Root)
+
+	for i, proof := range m.Proofs {
+		m.Proofs[i] = m.FaultInjector.Proof(i, proof)
+	}
+}
+
+// injectNodeFaults passes the stored nodes to the FaultInjector, and keeps the leaves in sync with level 0.
+func (m *MerkleTree) injectNodeFaults(nodes [][][]byte) {
+	for level := range nodes {
+		for idx, node := range nodes[level] {
+			nodes[level][idx] = m.FaultInjector.Node(level, idx, node)
+			if level == 0 && idx < m.NumLeaves {
+				m.Leaves[idx] = nodes[0][idx]
+			}
+		}
+	}
+}
+
+// injectProofFault passes the proof of the leaf at idx computed from the tree structure to the FaultInjector.
+func (m *MerkleTree) injectProofFault(idx int, proof *Proof) *Proof {
+	if m.FaultInjector == nil {
+		return proof
+	}
+
+	return m.FaultInjector.Proof(idx, proof)
+}
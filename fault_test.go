@@ -0,0 +1,87 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConfig_FaultInjector(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "test_proof_gen", config: &Config{}},
+		{name: "test_tree_build", config: &Config{Mode: ModeTreeBuild}},
+		{name: "test_proof_gen_and_tree_build_parallel", config: &Config{Mode: ModeProofGenAndTreeBuild, RunInParallel: true}},
+		{name: "test_lazy", config: &Config{Mode: ModeLazy}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(10)
+			want, err := New(&Config{Mode: tt.config.Mode}, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			// Corrupting leaf 4 invalidates the proof of leaf 5 computed from the tree structure,
+			// but not the proofs generated by the build; the proof of leaf 8 is corrupted directly.
+			tt.config.FaultInjector = &Faults{Nodes: []NodeRef{{Level: 0, Index: 4}}, Proofs: []int{8}}
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(m.Root, want.Root) {
+				t.Fatalf("New() root = %x, want %x", m.Root, want.Root)
+			}
+			for i := range blocks {
+				proof, err := m.proofByIndex(i)
+				if err != nil {
+					t.Fatalf("proofByIndex() error = %v", err)
+				}
+				ok, err := Verify(blocks[i], proof, m.Root, nil)
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if wantOK := i != 8 && (i != 5 || m.Proofs != nil); ok != wantOK {
+					t.Errorf("Verify() %d = %v, want %v", i, ok, wantOK)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_FaultInjector_root(t *testing.T) {
+	blocks := mockDataBlocks(7)
+	m, err := New(&Config{Mode: ModeTreeBuild, FaultInjector: &Faults{Nodes: []NodeRef{{Level: 3, Index: 0}}}}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	proof, err := m.Proof(blocks[2])
+	if err != nil {
+		t.Fatalf("Proof() error = %v", err)
+	}
+	if ok, err := Verify(blocks[2], proof, m.Root, nil); err != nil || ok {
+		t.Errorf("Verify() = %v, error = %v, want false", ok, err)
+	}
+}
@@ -0,0 +1,166 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// VerifierStyle is the style of an on-chain proof verifier.
+type VerifierStyle int
+
+const (
+	// VerifierOZSorted is OpenZeppelin's MerkleProof.verify(bytes32[] proof, bytes32 root, bytes32 leaf),
+	// which hashes sorted sibling pairs (Config.SortSiblingPairs).
+	VerifierOZSorted VerifierStyle = iota
+	// VerifierDirectional is a position-aware verifier taking (bytes32[] siblings, bool[] siblingIsLeft),
+	// see DirectionalProof.
+	VerifierDirectional
+)
+
+// Gas costs of the EVM used by the estimator. Execution costs are approximations of the compiled
+// OpenZeppelin loop (keccak256 of two words, memory scratch writes, comparison and loop overhead),
+// accurate to within a few percent; calldata costs are exact (EIP-2028).
+const (
+	gasCalldataZeroByte    = 4
+	gasCalldataNonZeroByte = 16
+	// gasVerifyBase covers the call overhead, ABI decoding of the proof array and the root comparison.
+	gasVerifyBase = 800
+	// gasPerLevelSorted is the cost of one level with OZ's commutative keccak256 (includes the comparison).
+	gasPerLevelSorted = 350
+	// gasPerLevelDirectional is the cost of one level with a position-aware verifier (includes the bool load).
+	gasPerLevelDirectional = 380
+)
+
+// GasEstimate is the estimated cost of verifying a proof on-chain.
+type GasEstimate struct {
+	// CalldataBytes is the size of the ABI-encoded proof arguments.
+	CalldataBytes int
+	// CalldataGas is the intrinsic gas of the proof calldata.
+	CalldataGas uint64
+	// ExecutionGas is the approximate gas of the verification itself.
+	ExecutionGas uint64
+}
+
+// TotalGas returns the calldata and execution gas.
+func (g *GasEstimate) TotalGas() uint64 {
+	return g.CalldataGas + g.ExecutionGas
+}
+
+// EstimateGas estimates the calldata size and gas of verifying the proof with the verifier style.
+// Calldata is computed from the actual ABI encoding of the proof, so zero bytes are priced as such.
+func EstimateGas(proof *Proof, style VerifierStyle) (*GasEstimate, error) {
+	if proof == nil {
+		return nil, ErrProofIsNil
+	}
+
+	calldata, err := proofCalldata(proof, style)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &GasEstimate{
+		CalldataBytes: len(calldata),
+		ExecutionGas:  executionGas(len(proof.Siblings), style),
+	}
+
+	for _, b := range calldata {
+		if b == 0 {
+			estimate.CalldataGas += gasCalldataZeroByte
+		} else {
+			estimate.CalldataGas += gasCalldataNonZeroByte
+		}
+	}
+
+	return estimate, nil
+}
+
+// EstimateGasForTree estimates the gas of verifying a proof of a tree with numLeaves leaves, without
+// building it, so that tree shapes can be compared. Sibling bytes are assumed to be non-zero.
+func EstimateGasForTree(numLeaves int, style VerifierStyle) (*GasEstimate, error) {
+	if numLeaves <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	var (
+		depth = proofDepth(numLeaves, 2)
+		words = abiArrayWords(depth)
+		// The offset and the length of an array have a single non-zero byte.
+		nonZeros = depth*solidityWordSize + 2
+	)
+
+	if style == VerifierDirectional {
+		words += abiArrayWords(depth)
+		// Booleans are single non-zero bytes at worst.
+		nonZeros += depth + 2
+	}
+
+	estimate := &GasEstimate{
+		CalldataBytes: words * solidityWordSize,
+		ExecutionGas:  executionGas(depth, style),
+	}
+	estimate.CalldataGas = uint64(nonZeros)*gasCalldataNonZeroByte +
+		uint64(estimate.CalldataBytes-nonZeros)*gasCalldataZeroByte
+
+	return estimate, nil
+}
+
+// proofCalldata returns the ABI encoding of the proof arguments of the verifier style.
+func proofCalldata(proof *Proof, style VerifierStyle) ([]byte, error) {
+	if style == VerifierDirectional {
+		return proof.Directional().SolidityABIEncode()
+	}
+
+	// A single bytes32[] argument: its offset, its length and its elements.
+	var (
+		buf  = make([]byte, 0, abiArrayWords(len(proof.Siblings))*solidityWordSize)
+		word = make([]byte, solidityWordSize)
+	)
+
+	buf = append(buf, solidityUint(word, solidityWordSize)...)
+	buf = append(buf, solidityUint(word, uint64(len(proof.Siblings)))...)
+
+	for _, sib := range proof.Siblings {
+		if len(sib) > solidityWordSize {
+			return nil, ErrProofSiblingTooLong
+		}
+
+		clear(word)
+		copy(word, sib)
+		buf = append(buf, word...)
+	}
+
+	return buf, nil
+}
+
+// abiArrayWords returns the number of ABI words of a dynamic array argument of n static elements:
+// its offset in the head, its length and its elements.
+func abiArrayWords(n int) int {
+	return n + 2
+}
+
+// executionGas returns the approximate execution gas of verifying a proof of the depth.
+func executionGas(depth int, style VerifierStyle) uint64 {
+	perLevel := uint64(gasPerLevelSorted)
+	if style == VerifierDirectional {
+		perLevel = gasPerLevelDirectional
+	}
+
+	return gasVerifyBase + uint64(depth)*perLevel
+}
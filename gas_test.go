@@ -0,0 +1,83 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "testing"
+
+func TestEstimateGas(t *testing.T) {
+	m, err := New(&Config{SortSiblingPairs: true}, mockDataBlocks(100))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		name          string
+		style         VerifierStyle
+		wantCalldata  int
+		wantExecution uint64
+	}{
+		{
+			name:          "test_oz_sorted",
+			style:         VerifierOZSorted,
+			wantCalldata:  (7 + 2) * 32,
+			wantExecution: gasVerifyBase + 7*gasPerLevelSorted,
+		},
+		{
+			name:          "test_directional",
+			style:         VerifierDirectional,
+			wantCalldata:  2 * (7 + 2) * 32,
+			wantExecution: gasVerifyBase + 7*gasPerLevelDirectional,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EstimateGas(m.Proofs[0], tt.style)
+			if err != nil {
+				t.Fatalf("EstimateGas() error = %v", err)
+			}
+			if got.CalldataBytes != tt.wantCalldata {
+				t.Errorf("CalldataBytes = %d, want %d", got.CalldataBytes, tt.wantCalldata)
+			}
+			if got.ExecutionGas != tt.wantExecution {
+				t.Errorf("ExecutionGas = %d, want %d", got.ExecutionGas, tt.wantExecution)
+			}
+			bound, err := EstimateGasForTree(100, tt.style)
+			if err != nil {
+				t.Fatalf("EstimateGasForTree() error = %v", err)
+			}
+			if bound.CalldataBytes != got.CalldataBytes || bound.ExecutionGas != got.ExecutionGas {
+				t.Errorf("EstimateGasForTree() = %+v, EstimateGas() = %+v", bound, got)
+			}
+			if got.CalldataGas > bound.CalldataGas || got.CalldataGas < uint64(got.CalldataBytes)*gasCalldataZeroByte {
+				t.Errorf("CalldataGas = %d, want within [%d, %d]",
+					got.CalldataGas, got.CalldataBytes*gasCalldataZeroByte, bound.CalldataGas)
+			}
+		})
+	}
+
+	if _, err := EstimateGas(nil, VerifierOZSorted); err != ErrProofIsNil {
+		t.Errorf("EstimateGas() error = %v, want %v", err, ErrProofIsNil)
+	}
+	if _, err := EstimateGasForTree(1, VerifierOZSorted); err != ErrInvalidNumOfDataBlocks {
+		t.Errorf("EstimateGasForTree() error = %v, want %v", err, ErrInvalidNumOfDataBlocks)
+	}
+}
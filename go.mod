@@ -2,7 +2,4 @@ module github.com/txaty/go-merkletree
 
 go 1.21
 
-require (
-	github.com/agiledragon/gomonkey/v2 v2.11.0
-	golang.org/x/sync v0.5.0
-)
+require golang.org/x/sync v0.5.0
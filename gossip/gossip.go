@@ -0,0 +1,243 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package gossip exchanges signed tree heads between peers, e.g. the mirrors serving the published roots
+// of a log, and detects split views: two validly signed tree heads of the same size with different roots,
+// the evidence that a log shows different trees to different parties.
+package gossip
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+// ErrSplitView is the error for a tree head whose root differs from the one of a tree head of the same size.
+// Errors returned for this reason are of type *SplitView and match it with errors.Is.
+var ErrSplitView = errors.New("gossip: split view detected")
+
+// Transport fetches tree heads from peers.
+type Transport interface {
+	// Fetch returns the latest tree head of the peer.
+	Fetch(ctx context.Context, peer string) (*mt.SignedTreeHead, error)
+}
+
+// SplitView is the evidence of a split view: two validly signed tree heads of the same size
+// with different roots, and the peers they were received from.
+type SplitView struct {
+	First     *mt.SignedTreeHead
+	FirstPeer string
+	Other     *mt.SignedTreeHead
+	OtherPeer string
+}
+
+func (e *SplitView) Error() string {
+	return fmt.Sprintf("%s: size %d, root %x from %s, root %x from %s",
+		ErrSplitView, e.First.TreeSize, e.First.Root, e.FirstPeer, e.Other.Root, e.OtherPeer)
+}
+
+// Is reports whether target is ErrSplitView.
+func (e *SplitView) Is(target error) bool {
+	return target == ErrSplitView
+}
+
+// observation is a tree head and the peer it was first received from.
+type observation struct {
+	head *mt.SignedTreeHead
+	peer string
+}
+
+// Gossiper collects the tree heads of a log from its peers and checks that tree heads of the same size
+// commit to the same root. It is safe for concurrent use.
+type Gossiper struct {
+	verifier    mt.TreeHeadVerifier
+	transport   Transport
+	peers       []string
+	onSplitView func(*SplitView)
+
+	mu     sync.Mutex
+	bySize map[uint64]observation
+	latest map[string]*mt.SignedTreeHead
+}
+
+// New creates a gossiper verifying the tree heads of the log with the verifier and fetching them from the peers
+// with the transport. onSplitView, if not nil, is called with the evidence of every split view detected;
+// it may be called concurrently by the fetches of a round.
+func New(verifier mt.TreeHeadVerifier, transport Transport, peers []string, onSplitView func(*SplitView)) *Gossiper {
+	return &Gossiper{
+		verifier:    verifier,
+		transport:   transport,
+		peers:       peers,
+		onSplitView: onSplitView,
+		bySize:      make(map[uint64]observation),
+		latest:      make(map[string]*mt.SignedTreeHead),
+	}
+}
+
+// Observe records the tree head received from the peer, by Fetch or pushed by the peer.
+// It returns mt.ErrTreeHeadSignature if the signature of the tree head is invalid, and a *SplitView
+// if a tree head of the same size with another root was observed before.
+func (g *Gossiper) Observe(peer string, head *mt.SignedTreeHead) error {
+	if head == nil {
+		return mt.ErrTreeHeadMissing
+	}
+
+	if err := g.verifier.Verify(head.SigningInput(), head.Signature); err != nil {
+		return fmt.Errorf("%w: from %s", mt.ErrTreeHeadSignature, peer)
+	}
+
+	g.mu.Lock()
+
+	if latest := g.latest[peer]; latest == nil || head.TreeSize >= latest.TreeSize {
+		g.latest[peer] = head
+	}
+
+	seen, ok := g.bySize[head.TreeSize]
+	if !ok {
+		g.bySize[head.TreeSize] = observation{head: head, peer: peer}
+	}
+
+	g.mu.Unlock()
+
+	if !ok || bytes.Equal(seen.head.Root, head.Root) {
+		return nil
+	}
+
+	split := &SplitView{First: seen.head, FirstPeer: seen.peer, Other: head, OtherPeer: peer}
+	if g.onSplitView != nil {
+		g.onSplitView(split)
+	}
+
+	return split
+}
+
+// Round fetches and observes the tree head of every peer concurrently. It returns the errors of the peers joined.
+func (g *Gossiper) Round(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(g.peers))
+	)
+
+	for i, peer := range g.peers {
+		wg.Add(1)
+
+		go func(i int, peer string) {
+			defer wg.Done()
+
+			head, err := g.transport.Fetch(ctx, peer)
+			if err != nil {
+				errs[i] = fmt.Errorf("gossip: %s: %w", peer, err)
+				return
+			}
+
+			errs[i] = g.Observe(peer, head)
+		}(i, peer)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Run runs a round every interval until ctx is done. Round errors other than split views, which are
+// reported to the callback, are passed to onError if it is not nil.
+func (g *Gossiper) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := g.Round(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Latest returns the latest tree head observed from the peer, or nil if there is none.
+func (g *Gossiper) Latest(peer string) *mt.SignedTreeHead {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.latest[peer]
+}
+
+// HTTPTransport fetches the tree heads served as JSON by Handler at the peer URLs.
+type HTTPTransport struct {
+	// Client is the HTTP client. http.DefaultClient is used if nil.
+	Client *http.Client
+}
+
+// Fetch returns the tree head served at the peer URL. Round prefixes its errors with the peer.
+func (t *HTTPTransport) Fetch(ctx context.Context, peer string) (*mt.SignedTreeHead, error) {
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, peer, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	head := new(mt.SignedTreeHead)
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(head); err != nil {
+		return nil, err
+	}
+
+	return head, nil
+}
+
+// Handler serves the tree head returned by current as JSON, or 404 Not Found if it is nil.
+func Handler(current func() *mt.SignedTreeHead) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		head := current()
+		if head == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(head)
+	})
+}
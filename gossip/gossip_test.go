@@ -0,0 +1,151 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package gossip
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+type mapTransport map[string]*mt.SignedTreeHead
+
+func (t mapTransport) Fetch(_ context.Context, peer string) (*mt.SignedTreeHead, error) {
+	head, ok := t[peer]
+	if !ok {
+		return nil, errors.New("unreachable")
+	}
+	return head, nil
+}
+
+func signedHead(t *testing.T, priv ed25519.PrivateKey, size uint64, root string) *mt.SignedTreeHead {
+	t.Helper()
+	digest := sha256.Sum256([]byte(root))
+	head := &mt.SignedTreeHead{Root: digest[:], TreeSize: size, Timestamp: int64(size)}
+	signature, err := mt.Ed25519Signer(priv).Sign(head.SigningInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	head.Signature = signature
+	return head
+}
+
+func TestGossiper_Round(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		transport mapTransport
+		wantErr   error
+		wantSplit bool
+	}{
+		{
+			name: "test_consistent",
+			transport: mapTransport{
+				"a": signedHead(t, priv, 10, "root 10"),
+				"b": signedHead(t, priv, 10, "root 10"),
+				"c": signedHead(t, priv, 12, "root 12"),
+			},
+		},
+		{
+			name: "test_split_view",
+			transport: mapTransport{
+				"a": signedHead(t, priv, 10, "root 10"),
+				"b": signedHead(t, priv, 10, "forked root 10"),
+				"c": signedHead(t, priv, 12, "root 12"),
+			},
+			wantErr:   ErrSplitView,
+			wantSplit: true,
+		},
+		{
+			name: "test_forged_head",
+			transport: mapTransport{
+				"a": signedHead(t, priv, 10, "root 10"),
+				"b": signedHead(t, otherPriv, 10, "forked root 10"),
+				"c": signedHead(t, priv, 12, "root 12"),
+			},
+			wantErr: mt.ErrTreeHeadSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var splits []*SplitView
+			g := New(mt.Ed25519Verifier(pub), tt.transport, []string{"a", "b", "c", "d"}, func(s *SplitView) {
+				splits = append(splits, s)
+			})
+			err := g.Round(context.Background())
+			if err == nil {
+				t.Fatal("Round() error = nil, want the error of unreachable peer d")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Round() error = %v, want %v", err, tt.wantErr)
+			}
+			if (len(splits) == 1) != tt.wantSplit || len(splits) > 1 {
+				t.Fatalf("Round() reported %d split views, want split %v", len(splits), tt.wantSplit)
+			}
+			if tt.wantSplit {
+				var split *SplitView
+				if !errors.As(err, &split) || split.First.TreeSize != 10 || split.FirstPeer == split.OtherPeer {
+					t.Errorf("Round() split view = %v", split)
+				}
+			}
+			if latest := g.Latest("c"); latest == nil || latest.TreeSize != 12 {
+				t.Errorf("Latest() = %v, want size 12", latest)
+			}
+		})
+	}
+}
+
+func TestHTTPTransport(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := signedHead(t, priv, 7, "root 7")
+	served := httptest.NewServer(Handler(func() *mt.SignedTreeHead { return head }))
+	defer served.Close()
+	empty := httptest.NewServer(Handler(func() *mt.SignedTreeHead { return nil }))
+	defer empty.Close()
+
+	g := New(mt.Ed25519Verifier(pub), &HTTPTransport{}, []string{served.URL}, nil)
+	if err := g.Round(context.Background()); err != nil {
+		t.Fatalf("Round() error = %v", err)
+	}
+	if latest := g.Latest(served.URL); latest == nil || latest.TreeSize != 7 {
+		t.Errorf("Latest() = %v, want size 7", latest)
+	}
+	if _, err := (&HTTPTransport{}).Fetch(context.Background(), empty.URL); err == nil {
+		t.Error("Fetch() error = nil, want the error of a missing tree head")
+	}
+}
@@ -0,0 +1,375 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// IngestTree is an append-only Merkle Tree optimized for high-throughput ingestion from many goroutines.
+// Appends hash their leaf in the calling goroutine, allocate their index atomically and are handed over
+// through a lock-free queue; whoever holds the tree lock next links them into the tree.
+// Snapshot, Root and Proof return consistent views whose roots and proofs are identical to those
+// of New over the same data blocks.
+// The hash functions must be concurrent-safe; DefaultHashFuncParallel is used by default.
+type IngestTree struct {
+	config       *Config
+	leafHashFunc TypeHashFunc
+
+	// next is the next index to allocate.
+	next atomic.Uint64
+	// queue holds the appended leaves not yet linked into the tree.
+	queue ingestQueue
+
+	// mu guards the fields below. Appends only try to lock it, readers and drains lock it.
+	mu sync.Mutex
+	// levels[l] are the nodes of level l that are roots of complete subtrees; levels are append-only.
+	levels [][][]byte
+	// pending are the leaves received ahead of a lower index still in flight.
+	pending map[uint64][]byte
+}
+
+// TreeSnapshot is a consistent view of an IngestTree over its first Size leaves.
+type TreeSnapshot struct {
+	hashChildren typeChildrenHashFunc
+	levels       [][][]byte
+	// Size is the number of leaves of the snapshot.
+	Size int
+}
+
+// NewIngestTree creates an empty IngestTree. The Mode, RunInParallel and NumRoutines
+// configuration fields are not used.
+func NewIngestTree(config *Config) *IngestTree {
+	if config == nil {
+		config = new(Config)
+	}
+
+	if config.HashFunc == nil {
+		config.HashFunc = DefaultHashFuncParallel
+	}
+
+	t := &IngestTree{
+		config:       config,
+		leafHashFunc: config.leafHashFunc(),
+		pending:      make(map[uint64][]byte),
+	}
+
+	t.queue.init()
+
+	return t
+}
+
+// Append adds the data block to the tree and returns its index. It is safe for concurrent use.
+// Appends beyond the MaxLeaves or MaxDepth of the configuration fail with a *LimitError.
+func (t *IngestTree) Append(block DataBlock) (int, error) {
+	leaf, err := dataBlockToLeaf(block, t.leafHashFunc, t.config.DisableLeafHashing)
+	if err != nil {
+		return 0, err
+	}
+
+	idx, err := t.allocate()
+	if err != nil {
+		return 0, err
+	}
+
+	t.queue.push(&ingestNode{idx: idx, leaf: leaf})
+
+	// Opportunistically link the queued leaves, unless another goroutine already does.
+	if t.mu.TryLock() {
+		err = t.drain()
+		t.mu.Unlock()
+	}
+
+	return int(idx), err
+}
+
+// allocate returns the next index, or a LimitError if the tree would exceed the limits of the configuration.
+func (t *IngestTree) allocate() (uint64, error) {
+	if t.config.MaxLeaves <= 0 && t.config.MaxDepth <= 0 {
+		return t.next.Add(1) - 1, nil
+	}
+
+	for {
+		idx := t.next.Load()
+		if err := t.config.checkLimits(int(idx) + 1); err != nil {
+			return 0, err
+		}
+
+		if t.next.CompareAndSwap(idx, idx+1) {
+			return idx, nil
+		}
+	}
+}
+
+// Snapshot links the queued leaves and returns a view of the tree over the longest contiguous
+// sequence of appended leaves. Appends still in flight in other goroutines may not be included.
+func (t *IngestTree) Snapshot() (*TreeSnapshot, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.drain(); err != nil {
+		return nil, err
+	}
+
+	// Levels are append-only, so the captured slice headers stay valid without the lock.
+	levels := make([][][]byte, len(t.levels))
+	copy(levels, t.levels)
+
+	return &TreeSnapshot{
+		hashChildren: t.config.hashChildren,
+		levels:       levels,
+		Size:         t.size(),
+	}, nil
+}
+
+// Root returns the Merkle root of the current snapshot.
+func (t *IngestTree) Root() ([]byte, error) {
+	s, err := t.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Root()
+}
+
+// Proof returns the proof of the leaf at idx in the current snapshot.
+func (t *IngestTree) Proof(idx int) (*Proof, error) {
+	s, err := t.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Proof(idx)
+}
+
+// drain links the queued leaves into the tree. It must be called with mu held.
+func (t *IngestTree) drain() error {
+	for n := t.queue.pop(); n != nil; n = t.queue.pop() {
+		// Leaves mostly arrive in order and are linked directly.
+		if n.idx != uint64(t.size()) {
+			t.pending[n.idx] = n.leaf
+			continue
+		}
+
+		if err := t.link(n.leaf); err != nil {
+			return err
+		}
+
+		if err := t.linkPending(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// linkPending links the pending leaves that became contiguous.
+func (t *IngestTree) linkPending() error {
+	for len(t.pending) > 0 {
+		idx := uint64(t.size())
+
+		leaf, ok := t.pending[idx]
+		if !ok {
+			return nil
+		}
+
+		delete(t.pending, idx)
+
+		if err := t.link(leaf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// size returns the number of linked leaves.
+func (t *IngestTree) size() int {
+	if len(t.levels) == 0 {
+		return 0
+	}
+
+	return len(t.levels[0])
+}
+
+// link appends the leaf and the roots of the subtrees it completes.
+func (t *IngestTree) link(node []byte) error {
+	for level := 0; ; level++ {
+		if level == len(t.levels) {
+			t.levels = append(t.levels, nil)
+		}
+
+		t.levels[level] = append(t.levels[level], node)
+
+		n := len(t.levels[level])
+		if n&1 == 1 {
+			return nil
+		}
+
+		var err error
+		if node, err = t.config.hashChildren(level+1, (n-1)>>1, t.levels[level][n-2], node); err != nil {
+			return err
+		}
+	}
+}
+
+// Root returns the Merkle root of the snapshot.
+func (s *TreeSnapshot) Root() ([]byte, error) {
+	if s.Size <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	edge, err := s.edge()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.node(len(edge)-1, 0, edge), nil
+}
+
+// Proof returns the proof of the leaf at idx.
+func (s *TreeSnapshot) Proof(idx int) (*Proof, error) {
+	if s.Size <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	if idx < 0 || idx >= s.Size {
+		return nil, ErrIndexOutOfRange
+	}
+
+	edge, err := s.edge()
+	if err != nil {
+		return nil, err
+	}
+
+	depth := len(edge) - 1
+	proof := &Proof{Siblings: make([][]byte, depth)}
+
+	for level := 0; level < depth; level++ {
+		sibling := idx ^ 1
+		if idx&1 == 0 {
+			proof.Path |= 1 << level
+		}
+
+		// Odd levels are padded by duplicating their last node.
+		if sibling >= levelSize(s.Size, level) {
+			sibling = idx
+		}
+
+		proof.Siblings[level] = s.node(level, sibling, edge)
+		idx >>= 1
+	}
+
+	return proof, nil
+}
+
+// edge computes the rightmost node of every level that is not the root of a complete subtree,
+// i.e. that depends on padding. edge[l] is nil if the last node of level l is stored.
+// The last element is the root level.
+func (s *TreeSnapshot) edge() ([][]byte, error) {
+	edge := [][]byte{nil}
+
+	for level := 0; levelSize(s.Size, level) > 1; level++ {
+		var (
+			size   = levelSize(s.Size, level)
+			parent = levelSize(s.Size, level+1) - 1
+		)
+
+		if parent < s.Size>>(level+1) {
+			edge = append(edge, nil)
+			continue
+		}
+
+		left := s.node(level, 2*parent, edge)
+		right := left
+
+		if 2*parent+1 < size {
+			right = s.node(level, 2*parent+1, edge)
+		}
+
+		node, err := s.hashChildren(level+1, parent, left, right)
+		if err != nil {
+			return nil, err
+		}
+
+		edge = append(edge, node)
+	}
+
+	return edge, nil
+}
+
+// node returns the node at idx of the level, stored or on the right edge.
+func (s *TreeSnapshot) node(level, idx int, edge [][]byte) []byte {
+	if idx < s.Size>>level {
+		return s.levels[level][idx]
+	}
+
+	return edge[level]
+}
+
+// levelSize returns the number of nodes of the level of a tree with numLeaves leaves, padding excluded.
+func levelSize(numLeaves, level int) int {
+	for ; level > 0; level-- {
+		numLeaves = (numLeaves + 1) >> 1
+	}
+
+	return numLeaves
+}
+
+// ingestNode is a node of the lock-free ingestion queue.
+type ingestNode struct {
+	next atomic.Pointer[ingestNode]
+	idx  uint64
+	leaf []byte
+}
+
+// ingestQueue is an intrusive multi-producer single-consumer lock-free queue (Vyukov).
+// Producers push concurrently; pop must be called by a single consumer at a time.
+type ingestQueue struct {
+	head atomic.Pointer[ingestNode]
+	tail *ingestNode
+	stub ingestNode
+}
+
+func (q *ingestQueue) init() {
+	q.head.Store(&q.stub)
+	q.tail = &q.stub
+}
+
+func (q *ingestQueue) push(n *ingestNode) {
+	prev := q.head.Swap(n)
+	prev.next.Store(n)
+}
+
+// pop returns the next node, or nil if the queue is empty or the next push is not linked yet.
+func (q *ingestQueue) pop() *ingestNode {
+	next := q.tail.next.Load()
+	if next == nil {
+		return nil
+	}
+
+	q.tail = next
+
+	return next
+}
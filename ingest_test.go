@@ -0,0 +1,151 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestIngestTree(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     *Config
+		num        int
+		goroutines int
+	}{
+		{name: "test_2", num: 2, goroutines: 1},
+		{name: "test_5", num: 5, goroutines: 1},
+		{name: "test_8", num: 8, goroutines: 2},
+		{name: "test_13", num: 13, goroutines: 3},
+		{name: "test_1000", num: 1000, goroutines: 8},
+		{name: "test_1000_sorted", config: &Config{SortSiblingPairs: true}, num: 1000, goroutines: 8},
+		{name: "test_257_disable_leaf_hashing", config: &Config{DisableLeafHashing: true}, num: 257, goroutines: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.num)
+			config := new(Config)
+			if tt.config != nil {
+				*config = *tt.config
+			}
+			it := NewIngestTree(config)
+
+			order := make([]DataBlock, tt.num)
+			var wg sync.WaitGroup
+			for g := 0; g < tt.goroutines; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := g; i < tt.num; i += tt.goroutines {
+						idx, err := it.Append(blocks[i])
+						if err != nil {
+							t.Errorf("Append() error = %v", err)
+							return
+						}
+						order[idx] = blocks[i]
+					}
+				}(g)
+			}
+			wg.Wait()
+
+			want, err := New(&Config{
+				Mode:               ModeProofGenAndTreeBuild,
+				SortSiblingPairs:   config.SortSiblingPairs,
+				DisableLeafHashing: config.DisableLeafHashing,
+			}, order)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			s, err := it.Snapshot()
+			if err != nil {
+				t.Fatalf("Snapshot() error = %v", err)
+			}
+			if s.Size != tt.num {
+				t.Fatalf("Snapshot() Size = %d, want %d", s.Size, tt.num)
+			}
+			root, err := s.Root()
+			if err != nil || !bytes.Equal(root, want.Root) {
+				t.Fatalf("Root() = %x, error = %v, want %x", root, err, want.Root)
+			}
+			for i := range order {
+				proof, err := s.Proof(i)
+				if err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+				if !reflect.DeepEqual(proof, want.Proofs[i]) {
+					t.Errorf("Proof() %d = %v, want %v", i, proof, want.Proofs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIngestTree_snapshotIsolation(t *testing.T) {
+	blocks := mockDataBlocks(10)
+	it := NewIngestTree(nil)
+	for _, block := range blocks[:5] {
+		if _, err := it.Append(block); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	s, err := it.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	before, _ := s.Root()
+	for _, block := range blocks[5:] {
+		if _, err := it.Append(block); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	after, _ := s.Root()
+	if !bytes.Equal(before, after) {
+		t.Error("snapshot root changed after further appends")
+	}
+	want, _ := New(nil, blocks[:5])
+	if !bytes.Equal(after, want.Root) {
+		t.Errorf("snapshot Root() = %x, want %x", after, want.Root)
+	}
+	if _, err := s.Proof(5); err != ErrIndexOutOfRange {
+		t.Errorf("Proof() error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+}
+
+func BenchmarkIngestTree_Append(b *testing.B) {
+	blocks := mockDataBlocksFixedSize(1 << 16)
+	it := NewIngestTree(nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if _, err := it.Append(blocks[i&(1<<16-1)]); err != nil {
+				b.Fatalf("Append() error = %v", err)
+			}
+			i++
+		}
+	})
+}
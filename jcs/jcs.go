@@ -0,0 +1,235 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package jcs provides a DataBlock adapter serializing arbitrary Go values as RFC 8785
+// JSON Canonicalization Scheme (JCS) JSON, so that semantically identical records hash identically
+// across services and languages.
+package jcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrInvalidNumber is the error for numbers that cannot be represented in JCS (NaN and infinities).
+var ErrInvalidNumber = errors.New("jcs: number is not finite")
+
+// Block is a DataBlock whose serialization is the JCS canonical JSON of Value.
+type Block struct {
+	Value any
+}
+
+// NewBlock wraps the value into a Block.
+func NewBlock(value any) *Block {
+	return &Block{Value: value}
+}
+
+// Serialize returns the canonical JSON of the value.
+func (b *Block) Serialize() ([]byte, error) {
+	return Marshal(b.Value)
+}
+
+// Marshal returns the RFC 8785 canonical JSON of the value. The value is first encoded with
+// encoding/json, so struct tags and json.Marshaler implementations apply, then canonicalized:
+// object members are sorted by the UTF-16 code units of their names, strings use the minimal escaping
+// and numbers the ECMAScript double-precision representation.
+func Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return Canonicalize(data)
+}
+
+// Canonicalize returns the RFC 8785 canonical form of the JSON document.
+func Canonicalize(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := encode(buf, v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encode(buf *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
+			return err
+		}
+
+		s, err := formatNumber(f)
+		if err != nil {
+			return err
+		}
+
+		buf.WriteString(s)
+	case string:
+		encodeString(buf, v)
+	case []any:
+		buf.WriteByte('[')
+
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			if err := encode(buf, elem); err != nil {
+				return err
+			}
+		}
+
+		buf.WriteByte(']')
+	case map[string]any:
+		return encodeObject(buf, v)
+	default:
+		return fmt.Errorf("jcs: unexpected type %T", v)
+	}
+
+	return nil
+}
+
+func encodeObject(buf *bytes.Buffer, obj map[string]any) error {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return lessUTF16(keys[i], keys[j])
+	})
+
+	buf.WriteByte('{')
+
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		encodeString(buf, k)
+		buf.WriteByte(':')
+
+		if err := encode(buf, obj[k]); err != nil {
+			return err
+		}
+	}
+
+	buf.WriteByte('}')
+
+	return nil
+}
+
+// lessUTF16 compares two strings by their UTF-16 code units.
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+
+	return len(ua) < len(ub)
+}
+
+// encodeString writes the string with the minimal JSON escaping of RFC 8785.
+func encodeString(buf *bytes.Buffer, s string) {
+	const hexDigits = "0123456789abcdef"
+
+	buf.WriteByte('"')
+
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[r>>4])
+				buf.WriteByte(hexDigits[r&0xf])
+
+				continue
+			}
+
+			var b [utf8.UTFMax]byte
+			buf.Write(b[:utf8.EncodeRune(b[:], r)])
+		}
+	}
+
+	buf.WriteByte('"')
+}
+
+// formatNumber formats the number like ECMAScript Number.prototype.toString, as required by RFC 8785.
+func formatNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", ErrInvalidNumber
+	}
+
+	if f == 0 {
+		return "0", nil
+	}
+
+	abs := math.Abs(f)
+	if abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+
+	// Exponent notation without leading exponent zeros: 1e+21, 1e-7.
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exp, _ := strings.Cut(s, "e")
+	sign, digits := exp[:1], strings.TrimLeft(exp[1:], "0")
+
+	return mantissa + "e" + sign + digits, nil
+}
@@ -0,0 +1,90 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package jcs
+
+import (
+	"bytes"
+	"testing"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "test_sorted_members", input: `{"b": 1, "a": {"d": [true, null], "c": "x"}}`, want: `{"a":{"c":"x","d":[true,null]},"b":1}`},
+		{name: "test_utf16_order", input: `{"\u20ac": 1, "\r": 2, "\ud83d\ude00": 3, "1": 4, "\u00fc": 5}`, want: "{\"\\r\":2,\"1\":4,\"\u00fc\":5,\"\u20ac\":1,\"\U0001F600\":3}"},
+		{name: "test_string_escaping", input: `"\u0041\u000f\n\u2028</script>"`, want: "\"A\\u000f\\n\u2028</script>\""},
+		{name: "test_numbers", input: `[1.0, -0, 1e21, 1e20, 0.000001, 1E-7, 3.14159, 100e-2, 9007199254740993]`, want: `[1,0,1e+21,100000000000000000000,0.000001,1e-7,3.14159,1,9007199254740992]`},
+		{name: "test_number_negative_exponent", input: `-1.5e-10`, want: `-1.5e-10`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Canonicalize([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Canonicalize() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Canonicalize() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlock(t *testing.T) {
+	type record struct {
+		Name    string  `json:"name"`
+		Balance float64 `json:"balance"`
+	}
+	// Semantically identical records from different producers.
+	a := NewBlock(record{Name: "alice", Balance: 10})
+	b := NewBlock(map[string]any{"balance": 10.0, "name": "alice"})
+	c := NewBlock(map[string]any{"name": "bob", "balance": 1})
+
+	sa, err := a.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+	sb, err := b.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+	if !bytes.Equal(sa, sb) {
+		t.Errorf("Serialize() %s != %s", sa, sb)
+	}
+
+	m1, err := mt.New(nil, []mt.DataBlock{a, c})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	m2, err := mt.New(nil, []mt.DataBlock{b, c})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !bytes.Equal(m1.Root, m2.Root) {
+		t.Error("roots of semantically identical records differ")
+	}
+}
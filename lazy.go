@@ -0,0 +1,69 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// lazyBuild computes the root of a ModeLazy tree without storing its structure.
+func (m *MerkleTree) lazyBuild() (err error) {
+	m.Root, err = m.rootFromLeaves(m.Leaves, m.buildChildren)
+
+	return err
+}
+
+// materialize builds and caches the structure and the leaf map of a ModeLazy tree, once.
+// It is safe for concurrent use; the root computed by New is left untouched.
+func (m *MerkleTree) materialize() error {
+	m.lazyOnce.Do(func() {
+		// Checkpointed builds store the structure whatever the mode.
+		if m.nodes != nil {
+			return
+		}
+
+		nodes := make([][][]byte, m.Depth)
+		nodes[0] = make([][]byte, m.NumLeaves, m.NumLeaves+1)
+		copy(nodes[0], m.Leaves)
+
+		for i := 0; i < m.Depth-1; i++ {
+			nodes[i] = appendNodeIfOdd(nodes[i])
+			if nodes[i+1], m.lazyErr = m.hashLevel(m.hashChildren, i+1, 0, nodes[i]); m.lazyErr != nil {
+				return
+			}
+		}
+
+		leafMap := make(map[string]int, m.NumLeaves)
+		for i, leaf := range m.Leaves {
+			leafMap[string(leaf)] = i
+		}
+
+		m.leafMapMu.Lock()
+		m.leafMap = leafMap
+		m.leafMapMu.Unlock()
+
+		if m.FaultInjector != nil {
+			m.injectNodeFaults(nodes)
+		}
+
+		m.nodes = nodes
+	})
+
+	return m.lazyErr
+}
@@ -0,0 +1,106 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestMerkleTree_modeLazy(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		num    int
+	}{
+		{name: "test_2", config: &Config{Mode: ModeLazy, HashFunc: DefaultHashFuncParallel}, num: 2},
+		{name: "test_9", config: &Config{Mode: ModeLazy, HashFunc: DefaultHashFuncParallel}, num: 9},
+		{name: "test_1000_parallel", config: &Config{Mode: ModeLazy, RunInParallel: true, NumRoutines: 4}, num: 1000},
+		{name: "test_4099_parallel_levels", config: &Config{Mode: ModeLazy, RunInParallel: true, NumRoutines: 4},
+			num: 4099},
+	}
+	// Proofs are requested concurrently, so the hash functions must be concurrent-safe.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.num)
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			want, err := New(&Config{Mode: ModeProofGenAndTreeBuild}, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(m.Root, want.Root) {
+				t.Fatal("ModeLazy root differs from ModeProofGenAndTreeBuild")
+			}
+			if m.nodes != nil || m.Proofs != nil {
+				t.Fatal("ModeLazy built the tree structure or proofs eagerly")
+			}
+
+			var wg sync.WaitGroup
+			for i := range blocks {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					proof, err := m.Proof(blocks[i])
+					if err != nil {
+						t.Errorf("Proof() error = %v", err)
+						return
+					}
+					if !proof.Equal(want.Proofs[i]) {
+						t.Errorf("Proof() of block %d differs from ModeProofGenAndTreeBuild", i)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			var got, expected bytes.Buffer
+			if _, err := m.WriteTo(&got); err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+			if _, err := want.WriteTo(&expected); err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), expected.Bytes()) {
+				t.Error("WriteTo() of ModeLazy differs from ModeProofGenAndTreeBuild")
+			}
+		})
+	}
+}
+
+func TestMerkleTree_modeLazyWriteTo(t *testing.T) {
+	blocks := mockDataBlocksFixedSize(5)
+	m, err := New(&Config{Mode: ModeLazy}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// WriteTo materializes the structure on its own.
+	if _, err := m.WriteTo(new(bytes.Buffer)); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if m.nodes == nil {
+		t.Error("WriteTo() did not cache the tree structure")
+	}
+}
@@ -23,6 +23,7 @@
 package merkletree
 
 import (
+	"errors"
 	"fmt"
 
 	"golang.org/x/sync/errgroup"
@@ -32,13 +33,30 @@ import (
 func (m *MerkleTree) computeLeafNodes(blocks []DataBlock) ([][]byte, error) {
 	var (
 		leaves             = make([][]byte, m.NumLeaves)
-		hashFunc           = m.HashFunc
+		hashFunc           = m.leafHashFunc()
 		disableLeafHashing = m.DisableLeafHashing
+		cache              = m.LeafCache
 		err                error
 	)
 
+	if m.CollectLeafErrors {
+		var errs []error
+
+		for i := 0; i < m.NumLeaves; i++ {
+			if leaves[i], err = cachedDataBlockToLeaf(blocks[i], hashFunc, disableLeafHashing, cache); err != nil {
+				errs = append(errs, &BlockError{Index: i, Err: err})
+			}
+		}
+
+		if errs != nil {
+			return nil, errors.Join(errs...)
+		}
+
+		return leaves, nil
+	}
+
 	for i := 0; i < m.NumLeaves; i++ {
-		if leaves[i], err = dataBlockToLeaf(blocks[i], hashFunc, disableLeafHashing); err != nil {
+		if leaves[i], err = cachedDataBlockToLeaf(blocks[i], hashFunc, disableLeafHashing, cache); err != nil {
 			return nil, err
 		}
 	}
@@ -52,11 +70,18 @@ func (m *MerkleTree) computeLeafNodesParallel(blocks []DataBlock) ([][]byte, err
 		lenLeaves          = len(blocks)
 		leaves             = make([][]byte, lenLeaves)
 		numRoutines        = m.NumRoutines
-		hashFunc           = m.HashFunc
+		hashFunc           = m.leafHashFunc()
 		disableLeafHashing = m.DisableLeafHashing
+		cache              = m.LeafCache
 		eg                 = new(errgroup.Group)
+		// errs holds the error of every leaf if CollectLeafErrors is set, each written by a single goroutine.
+		errs []error
 	)
 
+	if m.CollectLeafErrors {
+		errs = make([]error, lenLeaves)
+	}
+
 	numRoutines = min(numRoutines, lenLeaves)
 
 	for startIdx := 0; startIdx < numRoutines; startIdx++ {
@@ -65,8 +90,12 @@ func (m *MerkleTree) computeLeafNodesParallel(blocks []DataBlock) ([][]byte, err
 		eg.Go(func() error {
 			var err error
 			for i := startIdx; i < lenLeaves; i += numRoutines {
-				if leaves[i], err = dataBlockToLeaf(blocks[i], hashFunc, disableLeafHashing); err != nil {
-					return err
+				if leaves[i], err = cachedDataBlockToLeaf(blocks[i], hashFunc, disableLeafHashing, cache); err != nil {
+					if errs == nil {
+						return err
+					}
+
+					errs[i] = &BlockError{Index: i, Err: err}
 				}
 			}
 
@@ -78,17 +107,32 @@ func (m *MerkleTree) computeLeafNodesParallel(blocks []DataBlock) ([][]byte, err
 		return nil, fmt.Errorf("computeLeafNodesParallel: %w", err)
 	}
 
+	// errors.Join discards the nil errors of the valid leaves.
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
 	return leaves, nil
 }
 
 // dataBlockToLeaf generates the leaf from the data block.
 // If the leaf hashing is disabled, the data block is returned as the leaf.
 func dataBlockToLeaf(block DataBlock, hashFunc TypeHashFunc, disableLeafHashing bool) ([]byte, error) {
+	if block == nil {
+		return nil, ErrDataBlockIsNil
+	}
+
 	blockBytes, err := block.Serialize()
 	if err != nil {
 		return nil, fmt.Errorf("dataBlockToLeaf: %w", err)
 	}
 
+	return bytesToLeaf(blockBytes, hashFunc, disableLeafHashing)
+}
+
+// bytesToLeaf generates the leaf from the serialized data block.
+// If the leaf hashing is disabled, a copy of the serialized data block is returned as the leaf.
+func bytesToLeaf(blockBytes []byte, hashFunc TypeHashFunc, disableLeafHashing bool) ([]byte, error) {
 	if disableLeafHashing {
 		// copy the value so that the original byte slice is not modified
 		leaf := make([]byte, len(blockBytes))
@@ -0,0 +1,167 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// LeafCache caches leaf hashes across builds, so that data blocks shared by overlapping datasets are not
+// re-serialized and re-hashed. Implementations must be safe for concurrent use when RunInParallel is true.
+// A cache must only be shared between trees using the same hash function.
+// Cached leaves are shared with the trees and must not be modified.
+type LeafCache interface {
+	// Get returns the cached leaf for the key, if any.
+	Get(key string) ([]byte, bool)
+	// Set stores the leaf for the key.
+	Set(key string, leaf []byte)
+}
+
+// CacheKeyer can be implemented by data blocks that have a stable identity.
+// If a data block implements CacheKeyer, its cache key is used to look up the leaf without serialization.
+// Otherwise, the serialized bytes of the data block are used as the key and only hashing is saved.
+type CacheKeyer interface {
+	// CacheKey returns the key identifying the content of the data block.
+	CacheKey() string
+}
+
+// cachedDataBlockToLeaf generates the leaf from the data block, looking it up in the cache first.
+func cachedDataBlockToLeaf(block DataBlock, hashFunc TypeHashFunc, disableLeafHashing bool,
+	cache LeafCache,
+) ([]byte, error) {
+	if block == nil {
+		return nil, ErrDataBlockIsNil
+	}
+
+	// Without leaf hashing, the leaf is the serialized data block itself, so there is nothing to cache.
+	if cache == nil || disableLeafHashing {
+		return dataBlockToLeaf(block, hashFunc, disableLeafHashing)
+	}
+
+	var (
+		key        string
+		blockBytes []byte
+		err        error
+	)
+
+	if keyer, ok := block.(CacheKeyer); ok {
+		key = keyer.CacheKey()
+	} else {
+		if blockBytes, err = block.Serialize(); err != nil {
+			return nil, fmt.Errorf("cachedDataBlockToLeaf: %w", err)
+		}
+
+		key = string(blockBytes)
+	}
+
+	if leaf, ok := cache.Get(key); ok {
+		return leaf, nil
+	}
+
+	if blockBytes == nil {
+		if blockBytes, err = block.Serialize(); err != nil {
+			return nil, fmt.Errorf("cachedDataBlockToLeaf: %w", err)
+		}
+	}
+
+	leaf, err := hashFunc(blockBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Set(key, leaf)
+
+	return leaf, nil
+}
+
+// LRULeafCache is an in-process, concurrent-safe LeafCache evicting the least recently used leaves.
+type LRULeafCache struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+	mu       sync.Mutex
+}
+
+type lruEntry struct {
+	key  string
+	leaf []byte
+}
+
+// NewLRULeafCache creates a LRULeafCache holding at most capacity leaves.
+func NewLRULeafCache(capacity int) *LRULeafCache {
+	return &LRULeafCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+// Get returns the cached leaf for the key and marks it as recently used.
+func (c *LRULeafCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+
+	return elem.Value.(*lruEntry).leaf, true
+}
+
+// Set stores the leaf for the key, evicting the least recently used leaf if the cache is full.
+func (c *LRULeafCache) Set(key string, leaf []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*lruEntry).leaf = leaf
+		c.order.MoveToFront(elem)
+
+		return
+	}
+
+	if c.capacity <= 0 {
+		return
+	}
+
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, leaf: leaf})
+}
+
+// Len returns the number of cached leaves.
+func (c *LRULeafCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
@@ -0,0 +1,144 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+type countingDataBlock struct {
+	data       []byte
+	key        string
+	serialized *atomic.Int64
+}
+
+func (b *countingDataBlock) Serialize() ([]byte, error) {
+	b.serialized.Add(1)
+	return b.data, nil
+}
+
+type keyedDataBlock struct {
+	countingDataBlock
+}
+
+func (b *keyedDataBlock) CacheKey() string {
+	return b.key
+}
+
+type countingLeafCache struct {
+	*LRULeafCache
+	hits atomic.Int64
+}
+
+func (c *countingLeafCache) Get(key string) ([]byte, bool) {
+	leaf, ok := c.LRULeafCache.Get(key)
+	if ok {
+		c.hits.Add(1)
+	}
+	return leaf, ok
+}
+
+func TestMerkleTreeNew_leafCache(t *testing.T) {
+	tests := []struct {
+		name           string
+		keyed          bool
+		runInParallel  bool
+		wantSerialized int64
+	}{
+		{
+			name:           "test_content_key",
+			wantSerialized: 2 * 10,
+		},
+		{
+			name:           "test_content_key_parallel",
+			runInParallel:  true,
+			wantSerialized: 2 * 10,
+		},
+		{
+			name:           "test_cache_keyer",
+			keyed:          true,
+			wantSerialized: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				serialized = new(atomic.Int64)
+				blocks     = make([]DataBlock, 10)
+				cache      = &countingLeafCache{LRULeafCache: NewLRULeafCache(100)}
+			)
+			for i := range blocks {
+				b := countingDataBlock{
+					data:       []byte("block" + strconv.Itoa(i)),
+					key:        strconv.Itoa(i),
+					serialized: serialized,
+				}
+				if tt.keyed {
+					blocks[i] = &keyedDataBlock{b}
+				} else {
+					blocks[i] = &b
+				}
+			}
+			config := &Config{LeafCache: cache, RunInParallel: tt.runInParallel}
+			m1, err := New(config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			m2, err := New(config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(m1.Root, m2.Root) {
+				t.Errorf("root mismatch, got %x, want %x", m2.Root, m1.Root)
+			}
+			if got := serialized.Load(); got != tt.wantSerialized {
+				t.Errorf("serialized %d times, want %d", got, tt.wantSerialized)
+			}
+			if got := cache.hits.Load(); got != 10 {
+				t.Errorf("cache hits = %d, want 10", got)
+			}
+		})
+	}
+}
+
+func TestLRULeafCache(t *testing.T) {
+	c := NewLRULeafCache(2)
+	c.Set("a", []byte("a"))
+	c.Set("b", []byte("b"))
+	if _, ok := c.Get("a"); !ok {
+		t.Errorf("Get() a not found")
+	}
+	c.Set("c", []byte("c"))
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("Get() b should have been evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Errorf("Get() a should not have been evicted")
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
@@ -0,0 +1,97 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"unsafe"
+)
+
+// leafRef is a unique leaf shared by all its positions in the tree, with its number of positions.
+type leafRef struct {
+	leaf []byte
+	refs int
+}
+
+// leafKey returns the leaf as a map key sharing its memory. Leaves are never modified in place.
+func leafKey(leaf []byte) string {
+	return unsafe.String(unsafe.SliceData(leaf), len(leaf))
+}
+
+// dedupLeaves makes the identical leaves share the backing array of their first occurrence and counts
+// the positions of each unique leaf, so that the duplicates are released before the tree is built.
+// The positions of the leaves, hence their proofs, are unchanged.
+func (m *MerkleTree) dedupLeaves() {
+	refs := make(map[string]*leafRef)
+
+	for i, leaf := range m.Leaves {
+		ref, ok := refs[leafKey(leaf)]
+		if !ok {
+			ref = &leafRef{leaf: leaf}
+			refs[leafKey(leaf)] = ref
+		}
+
+		ref.refs++
+		m.Leaves[i] = ref.leaf
+	}
+
+	m.leafRefs = refs
+}
+
+// LeafRefCount returns the number of leaves identical to the leaf at idx, itself included.
+// It is a map lookup if DeduplicateLeaves is set, and a scan of the leaves otherwise.
+func (m *MerkleTree) LeafRefCount(idx int) (int, error) {
+	if idx < 0 || idx >= m.NumLeaves {
+		return 0, ErrIndexOutOfRange
+	}
+
+	leaf := m.Leaves[idx]
+
+	if m.leafRefs != nil {
+		return m.leafRefs[leafKey(leaf)].refs, nil
+	}
+
+	var refs int
+
+	for _, l := range m.Leaves {
+		if bytes.Equal(l, leaf) {
+			refs++
+		}
+	}
+
+	return refs, nil
+}
+
+// NumUniqueLeaves returns the number of distinct leaves of the tree.
+func (m *MerkleTree) NumUniqueLeaves() int {
+	if m.leafRefs != nil {
+		return len(m.leafRefs)
+	}
+
+	unique := make(map[string]struct{}, m.NumLeaves)
+	for _, leaf := range m.Leaves {
+		unique[leafKey(leaf)] = struct{}{}
+	}
+
+	return len(unique)
+}
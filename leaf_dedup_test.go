@@ -0,0 +1,110 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// sparseBlocks returns num data blocks, every third one distinct and the others empty.
+func sparseBlocks(num int) []DataBlock {
+	blocks := make([]DataBlock, num)
+	for i := range blocks {
+		block := &mock.DataBlock{Data: []byte{}}
+		if i%3 == 0 {
+			block.Data = []byte{byte(i), byte(i >> 8)}
+		}
+		blocks[i] = block
+	}
+	return blocks
+}
+
+func TestConfig_DeduplicateLeaves(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "test_proof_gen", config: &Config{}},
+		{name: "test_proof_gen_parallel", config: &Config{RunInParallel: true, NumRoutines: 4}},
+		{name: "test_tree_build", config: &Config{Mode: ModeTreeBuild}},
+		{name: "test_low_memory", config: &Config{Mode: ModeLowMemory}},
+	}
+	blocks := sparseBlocks(300)
+	want, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.DeduplicateLeaves = true
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(m.Root, want.Root) {
+				t.Fatalf("Root = %x, want %x", m.Root, want.Root)
+			}
+			if got := m.NumUniqueLeaves(); got != 101 {
+				t.Errorf("NumUniqueLeaves() = %d, want 101", got)
+			}
+			// The empty blocks share the leaf of the first one.
+			if &m.Leaves[1][0] != &m.Leaves[299][0] {
+				t.Errorf("identical leaves do not share their hash")
+			}
+			if got, err := m.LeafRefCount(1); err != nil || got != 200 {
+				t.Errorf("LeafRefCount(1) = %d, %v, want 200", got, err)
+			}
+			if got, err := m.LeafRefCount(3); err != nil || got != 1 {
+				t.Errorf("LeafRefCount(3) = %d, %v, want 1", got, err)
+			}
+			for _, idx := range []int{0, 1, 2, 150, 299} {
+				proof, err := m.proofByIndex(idx)
+				if err != nil {
+					t.Fatalf("proofByIndex() error = %v", err)
+				}
+				if !proof.Equal(want.Proofs[idx]) {
+					t.Errorf("proofByIndex(%d) = %v, want %v", idx, proof, want.Proofs[idx])
+				}
+			}
+		})
+	}
+}
+
+func TestMerkleTree_LeafRefCount_withoutDeduplication(t *testing.T) {
+	m, err := New(nil, sparseBlocks(30))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got, err := m.LeafRefCount(2); err != nil || got != 20 {
+		t.Errorf("LeafRefCount(2) = %d, %v, want 20", got, err)
+	}
+	if got := m.NumUniqueLeaves(); got != 11 {
+		t.Errorf("NumUniqueLeaves() = %d, want 11", got)
+	}
+	if _, err := m.LeafRefCount(30); err != ErrIndexOutOfRange {
+		t.Errorf("LeafRefCount(30) error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+}
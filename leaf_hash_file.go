@@ -0,0 +1,319 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// leafHashFileChunkSize is the number of parents computed per chunk when building from a leaf hash file.
+const leafHashFileChunkSize = 1 << 16
+
+// TreeFile is the storage a tree is built into by NewFromLeafHashFile, e.g. an *os.File.
+type TreeFile interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// NewFromLeafHashFile builds the tree over the precomputed leaf hashes of a leaf file, e.g. produced by
+// a distributed map job: size bytes of consecutive hashLen-byte leaves read from leaves.
+// The tree is serialized into out, as written by WriteTo, level by level in chunks, so that memory stays
+// bounded whatever the number of leaves; the returned ProofArchive serves its root and proofs from out.
+//
+// The leaves are used as they are, like the leaves of trees built from data blocks with the same
+// configuration: proofs verify the original data blocks with leaf hashing enabled. The HashFunc,
+// SortSiblingPairs, RunInParallel and NumRoutines configuration fields are used.
+func NewFromLeafHashFile(config *Config, leaves io.ReaderAt, size int64, hashLen int, out TreeFile) (*ProofArchive, error) {
+	m, err := newLeafHashFileTree(config, size, hashLen)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.RunInParallel {
+		m.initParallel()
+	} else {
+		m.init()
+	}
+
+	h, err := m.leafHashFileHeader(leaves, hashLen)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := out.WriteAt(h.marshal(), 0); err != nil {
+		return nil, err
+	}
+
+	// The leaves are copied into the tree, then every level is hashed from the previous one in out.
+	if err := copyLeafHashes(leaves, out, h); err != nil {
+		return nil, err
+	}
+
+	lens := levelLens(h.numLeaves, h.depth)
+
+	for level := 0; level < h.depth-1; level++ {
+		if err := m.hashTreeFileLevel(out, h, level, lens[level]>>1); err != nil {
+			return nil, err
+		}
+	}
+
+	top, err := readNodes(out, h.levelOffset(h.depth-1), h.nodeLenAt(h.depth-1), 2)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := m.buildChildren(m.Depth, 0, top[0], top[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := out.WriteAt(root, h.rootOffset()); err != nil {
+		return nil, err
+	}
+
+	return OpenProofArchive(out)
+}
+
+// ProofFromLeafHashFile generates the proof of the leaf at idx, and returns it with the root, from the
+// precomputed leaf hashes of a leaf file alone, as read by NewFromLeafHashFile: the leaves are streamed once
+// and every node is recomputed, but only the pending left node of each level and the siblings of the leaf
+// are kept, so that occasional proofs of huge archived datasets are served in O(log n) memory without
+// storing the interior levels. The HashFunc, NodeHashFunc, PositionalHashFunc and SortSiblingPairs
+// configuration fields are used.
+func ProofFromLeafHashFile(config *Config, leaves io.ReaderAt, size int64, hashLen, idx int) (*Proof, []byte, error) {
+	m, err := newLeafHashFileTree(config, size, hashLen)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if idx < 0 || idx >= m.NumLeaves {
+		return nil, nil, ErrIndexOutOfRange
+	}
+
+	m.init()
+
+	var (
+		pending    = make([][]byte, m.Depth)
+		pendingIdx = make([]int, m.Depth)
+		proof      = &Proof{Siblings: make([][]byte, m.Depth)}
+		root       []byte
+	)
+
+	// add carries the node up its level, recording it if it is a sibling of the leaf, and pairing it with
+	// the pending left node of its level if it is a right node.
+	add := func(level, index int, node []byte) error {
+		for ; level < m.Depth; level, index = level+1, index>>1 {
+			if index == (idx>>level)^1 {
+				proof.Siblings[level] = node
+			}
+
+			if index&1 == 0 {
+				pending[level], pendingIdx[level] = node, index
+
+				return nil
+			}
+
+			var err error
+			if node, err = m.hashChildren(level+1, index>>1, pending[level], node); err != nil {
+				return err
+			}
+
+			pending[level] = nil
+		}
+
+		root = node
+
+		return nil
+	}
+
+	for start := 0; start < m.NumLeaves; start += leafHashFileChunkSize {
+		chunk, err := readNodes(leaves, int64(start)*int64(hashLen), hashLen, min(leafHashFileChunkSize, m.NumLeaves-start))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i, leaf := range chunk {
+			if err := add(0, start+i, leaf); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// Odd levels are padded by duplicating their last node, from the bottom up.
+	for level := range pending {
+		if pending[level] != nil {
+			if err := add(level, pendingIdx[level]+1, pending[level]); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	for level := range proof.Siblings {
+		if (idx>>level)&1 == 0 {
+			proof.Path |= 1 << level
+		}
+	}
+
+	return proof, root, nil
+}
+
+// newLeafHashFileTree returns the tree of the leaf file of size bytes of hashLen-byte leaves, without its nodes.
+func newLeafHashFileTree(config *Config, size int64, hashLen int) (*MerkleTree, error) {
+	if hashLen <= 0 || size%int64(hashLen) != 0 {
+		return nil, fmt.Errorf("%w: %d bytes of %d-byte leaves", ErrInvalidLeafHashFile, size, hashLen)
+	}
+
+	numLeaves := int(size / int64(hashLen))
+	if numLeaves <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	if config == nil {
+		config = new(Config)
+	}
+
+	m := &MerkleTree{
+		Config:    config,
+		NumLeaves: numLeaves,
+		Depth:     bits.Len(uint(numLeaves - 1)),
+	}
+
+	return m, nil
+}
+
+// leafHashFileHeader builds the header of the tree, hashing the first two leaves for the node length.
+func (m *MerkleTree) leafHashFileHeader(leaves io.ReaderAt, hashLen int) (*treeHeader, error) {
+	first, err := readNodes(leaves, 0, hashLen, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := m.hashChildren(1, 0, first[0], first[1])
+	if err != nil {
+		return nil, err
+	}
+
+	h := &treeHeader{
+		leafLen:   hashLen,
+		nodeLen:   len(node),
+		numLeaves: m.NumLeaves,
+		depth:     m.Depth,
+	}
+
+	if m.SortSiblingPairs {
+		h.flags |= treeFlagSortSiblingPairs
+	}
+
+	return h, nil
+}
+
+// copyLeafHashes copies the leaves into the leaf level of the tree, padded with the last leaf if odd.
+func copyLeafHashes(leaves io.ReaderAt, out io.WriterAt, h *treeHeader) error {
+	buf := make([]byte, leafHashFileChunkSize*h.leafLen)
+	size := int64(h.numLeaves) * int64(h.leafLen)
+
+	for offset := int64(0); offset < size; offset += int64(len(buf)) {
+		chunk := buf[:min(int64(len(buf)), size-offset)]
+		if _, err := leaves.ReadAt(chunk, offset); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidLeafHashFile, err)
+		}
+
+		if _, err := out.WriteAt(chunk, treeEncodingHeaderSize+offset); err != nil {
+			return err
+		}
+	}
+
+	if h.numLeaves&1 == 1 {
+		last := buf[:h.leafLen]
+		if _, err := leaves.ReadAt(last, size-int64(h.leafLen)); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidLeafHashFile, err)
+		}
+
+		if _, err := out.WriteAt(last, treeEncodingHeaderSize+size); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hashTreeFileLevel computes the numParents nodes of level+1 from the level, chunk by chunk,
+// and pads level+1 with its last node if it is odd and not the last level.
+func (m *MerkleTree) hashTreeFileLevel(f TreeFile, h *treeHeader, level, numParents int) error {
+	var (
+		childLen     = h.nodeLenAt(level)
+		childOffset  = h.levelOffset(level)
+		parentOffset = h.levelOffset(level + 1)
+		last         []byte
+	)
+
+	for start := 0; start < numParents; start += leafHashFileChunkSize {
+		end := min(start+leafHashFileChunkSize, numParents)
+
+		children, err := readNodes(f, childOffset+int64(2*start*childLen), childLen, 2*(end-start))
+		if err != nil {
+			return err
+		}
+
+		parents, err := m.hashLevel(m.buildChildren, level+1, start, children)
+		if err != nil {
+			return err
+		}
+
+		buf := make([]byte, 0, len(parents)*h.nodeLen)
+		for _, parent := range parents {
+			buf = append(buf, parent...)
+		}
+
+		if _, err := f.WriteAt(buf, parentOffset+int64(start*h.nodeLen)); err != nil {
+			return err
+		}
+
+		last = parents[len(parents)-1]
+	}
+
+	if level+1 < h.depth-1 && numParents&1 == 1 {
+		if _, err := f.WriteAt(last, parentOffset+int64(numParents*h.nodeLen)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readNodes reads count consecutive nodes of nodeLen bytes at the offset.
+func readNodes(r io.ReaderAt, offset int64, nodeLen, count int) ([][]byte, error) {
+	buf := make([]byte, nodeLen*count)
+	if _, err := r.ReadAt(buf, offset); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidLeafHashFile, err)
+	}
+
+	nodes := make([][]byte, count)
+	for i := range nodes {
+		nodes[i] = buf[i*nodeLen : (i+1)*nodeLen : (i+1)*nodeLen]
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,172 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewFromLeafHashFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		num    int
+	}{
+		{name: "test_2", num: 2},
+		{name: "test_7_sorted", config: &Config{SortSiblingPairs: true}, num: 7},
+		{name: "test_1000_parallel", config: &Config{RunInParallel: true, NumRoutines: 2}, num: 1000},
+		{name: "test_140001_chunks", num: 140001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leafData := make([]byte, tt.num*32)
+			if _, err := crand.Read(leafData); err != nil {
+				t.Fatal(err)
+			}
+			leaves := make([][]byte, tt.num)
+			for i := range leaves {
+				leaves[i] = leafData[i*32 : (i+1)*32]
+			}
+			out, err := os.Create(filepath.Join(t.TempDir(), "tree.bin"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer out.Close()
+
+			archive, err := NewFromLeafHashFile(tt.config, bytes.NewReader(leafData), int64(len(leafData)), 32, out)
+			if err != nil {
+				t.Fatalf("NewFromLeafHashFile() error = %v", err)
+			}
+
+			config := &Config{Mode: ModeTreeBuild}
+			if tt.config != nil {
+				config.SortSiblingPairs = tt.config.SortSiblingPairs
+			}
+			m, err := newFromLeaves(config, leaves)
+			if err != nil {
+				t.Fatalf("newFromLeaves() error = %v", err)
+			}
+			if !bytes.Equal(archive.Root, m.Root) {
+				t.Fatal("NewFromLeafHashFile() root differs from the tree built in memory")
+			}
+			want := new(bytes.Buffer)
+			if _, err := m.WriteTo(want); err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+			got, err := os.ReadFile(out.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want.Bytes()) {
+				t.Fatal("NewFromLeafHashFile() serialized tree differs from WriteTo()")
+			}
+			for _, idx := range []int{0, tt.num / 2, tt.num - 1} {
+				proof, err := archive.Proof(idx)
+				if err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+				if want := m.proofFromNodes(idx); !proof.Equal(want) {
+					t.Fatalf("Proof() of leaf %d differs from the tree proof", idx)
+				}
+			}
+		})
+	}
+}
+
+func TestProofFromLeafHashFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		num    int
+	}{
+		{name: "test_2", num: 2},
+		{name: "test_7_sorted", config: &Config{SortSiblingPairs: true}, num: 7},
+		{name: "test_1001_positional", config: &Config{PositionalHashFunc: PositionPrefixed(nil)}, num: 1001},
+		{name: "test_140001_chunks", num: 140001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leafData := make([]byte, tt.num*32)
+			if _, err := crand.Read(leafData); err != nil {
+				t.Fatal(err)
+			}
+			leaves := make([][]byte, tt.num)
+			for i := range leaves {
+				leaves[i] = leafData[i*32 : (i+1)*32]
+			}
+
+			config := &Config{Mode: ModeTreeBuild}
+			if tt.config != nil {
+				config.SortSiblingPairs = tt.config.SortSiblingPairs
+				config.PositionalHashFunc = tt.config.PositionalHashFunc
+			}
+			m, err := newFromLeaves(config, leaves)
+			if err != nil {
+				t.Fatalf("newFromLeaves() error = %v", err)
+			}
+			for _, idx := range []int{0, 1, tt.num / 2, tt.num - 2, tt.num - 1} {
+				proof, root, err := ProofFromLeafHashFile(tt.config, bytes.NewReader(leafData), int64(len(leafData)), 32, idx)
+				if err != nil {
+					t.Fatalf("ProofFromLeafHashFile() error = %v", err)
+				}
+				if !bytes.Equal(root, m.Root) {
+					t.Fatal("ProofFromLeafHashFile() root differs from the tree built in memory")
+				}
+				if want := m.proofFromNodes(idx); !proof.Equal(want) {
+					t.Fatalf("ProofFromLeafHashFile() proof of leaf %d differs from the tree proof", idx)
+				}
+			}
+		})
+	}
+
+	leafData := make([]byte, 3*32)
+	if _, _, err := ProofFromLeafHashFile(nil, bytes.NewReader(leafData), 96, 32, 3); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("ProofFromLeafHashFile() error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+	if _, _, err := ProofFromLeafHashFile(nil, bytes.NewReader(leafData[:64]), 96, 32, 0); !errors.Is(err, ErrInvalidLeafHashFile) {
+		t.Errorf("ProofFromLeafHashFile() error = %v, want %v", err, ErrInvalidLeafHashFile)
+	}
+}
+
+func TestNewFromLeafHashFile_invalid(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "tree.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if _, err := NewFromLeafHashFile(nil, bytes.NewReader(make([]byte, 65)), 65, 32, out); !errors.Is(err, ErrInvalidLeafHashFile) {
+		t.Errorf("NewFromLeafHashFile() error = %v, want %v", err, ErrInvalidLeafHashFile)
+	}
+	if _, err := NewFromLeafHashFile(nil, bytes.NewReader(make([]byte, 32)), 32, 32, out); !errors.Is(err, ErrInvalidNumOfDataBlocks) {
+		t.Errorf("NewFromLeafHashFile() error = %v, want %v", err, ErrInvalidNumOfDataBlocks)
+	}
+	// The leaf file is shorter than the announced size.
+	if _, err := NewFromLeafHashFile(nil, bytes.NewReader(make([]byte, 64)), 128, 32, out); !errors.Is(err, ErrInvalidLeafHashFile) {
+		t.Errorf("NewFromLeafHashFile() error = %v, want %v", err, ErrInvalidLeafHashFile)
+	}
+}
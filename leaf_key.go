@@ -0,0 +1,92 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// TypeKeyedHashFunc is the signature of the keyed hash functions used for leaves when Config.LeafKey is set.
+type TypeKeyedHashFunc func(key, data []byte) ([]byte, error)
+
+// HMACSHA256 is the default keyed leaf hash function, computing HMAC-SHA256 of the data with the key.
+// It is concurrent-safe.
+func HMACSHA256(key, data []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+
+	return mac.Sum(nil), nil
+}
+
+// leafHashFunc returns the hash function applied to the serialized data blocks.
+// If LeafKey is set, leaves are hashed with the keyed hash function, otherwise with LeafHashFunc if set,
+// while interior nodes keep using their own hash function, see nodeHashFunc.
+func (c *Config) leafHashFunc() TypeHashFunc {
+	if c.LeafKey == nil {
+		if c.LeafHashFunc != nil {
+			return c.LeafHashFunc
+		}
+
+		return c.HashFunc
+	}
+
+	var (
+		key   = c.LeafKey
+		keyed = c.LeafKeyedHashFunc
+	)
+
+	if keyed == nil {
+		keyed = HMACSHA256
+	}
+
+	return func(data []byte) ([]byte, error) {
+		return keyed(key, data)
+	}
+}
+
+// DomainSeparated returns the leaf and interior node hash functions hashing with hashFunc the data prefixed
+// with a 0x00 byte for leaves and a 0x01 byte for interior nodes, as in RFC 6962, to be set as
+// Config.LeafHashFunc and Config.NodeHashFunc, so that no interior node can be proven as a leaf, which
+// multiproofs would otherwise allow. SHA-256 is used if hashFunc is nil, streaming the prefix into the digest.
+// The returned functions are concurrent-safe if hashFunc is.
+func DomainSeparated(hashFunc TypeHashFunc) (leafHashFunc, nodeHashFunc TypeHashFunc) {
+	return domainPrefixed(hashFunc, 0x00), domainPrefixed(hashFunc, 0x01)
+}
+
+// domainPrefixed returns the hash function hashing with hashFunc the data prefixed with the byte.
+func domainPrefixed(hashFunc TypeHashFunc, prefix byte) TypeHashFunc {
+	if hashFunc == nil {
+		return func(data []byte) ([]byte, error) {
+			return DefaultHashSlices([]byte{prefix}, data)
+		}
+	}
+
+	return func(data []byte) ([]byte, error) {
+		prefixed := make([]byte, 1+len(data))
+		prefixed[0] = prefix
+		copy(prefixed[1:], data)
+
+		return hashFunc(prefixed)
+	}
+}
@@ -0,0 +1,156 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+)
+
+func TestConfig_LeafKey(t *testing.T) {
+	hmacSHA512 := func(key, data []byte) ([]byte, error) {
+		mac := hmac.New(sha512.New, key)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	}
+	tests := []struct {
+		name          string
+		keyedHashFunc TypeKeyedHashFunc
+		runInParallel bool
+		mode          TypeConfigMode
+	}{
+		{
+			name: "test_hmac_sha256",
+		},
+		{
+			name:          "test_hmac_sha256_parallel_tree_build",
+			runInParallel: true,
+			mode:          ModeTreeBuild,
+		},
+		{
+			name:          "test_custom_keyed_hash",
+			keyedHashFunc: hmacSHA512,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(6)
+			config := &Config{
+				LeafKey:           []byte("secret"),
+				LeafKeyedHashFunc: tt.keyedHashFunc,
+				RunInParallel:     tt.runInParallel,
+				Mode:              tt.mode,
+			}
+			m, err := New(config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			unkeyed, err := New(nil, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if bytes.Equal(m.Root, unkeyed.Root) {
+				t.Errorf("New() keyed root should differ from unkeyed root")
+			}
+			for idx, block := range blocks {
+				proof, err := m.proofByIndex(idx)
+				if err != nil {
+					t.Fatalf("proofByIndex() error = %v", err)
+				}
+				ok, err := Verify(block, proof, m.Root, &Config{
+					LeafKey:           []byte("secret"),
+					LeafKeyedHashFunc: tt.keyedHashFunc,
+				})
+				if err != nil || !ok {
+					t.Errorf("Verify() with key %d = %v, error = %v", idx, ok, err)
+				}
+				ok, err = Verify(block, proof, m.Root, &Config{LeafKey: []byte("guess")})
+				if err != nil || ok {
+					t.Errorf("Verify() with wrong key %d = %v, error = %v", idx, ok, err)
+				}
+				ok, err = Verify(block, proof, m.Root, nil)
+				if err != nil || ok {
+					t.Errorf("Verify() without key %d = %v, error = %v", idx, ok, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_LeafHashFuncNodeHashFunc(t *testing.T) {
+	leafHash := func(data []byte) ([]byte, error) {
+		sum := sha512.Sum512_256(data)
+		return sum[:], nil
+	}
+	nodeHash := func(data []byte) ([]byte, error) {
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	}
+	blocks := mockDataBlocks(4)
+	leaves := make([][]byte, len(blocks))
+	for i, block := range blocks {
+		data, _ := block.Serialize()
+		leaves[i], _ = leafHash(data)
+	}
+	left, _ := nodeHash(concatHash(leaves[0], leaves[1]))
+	right, _ := nodeHash(concatHash(leaves[2], leaves[3]))
+	wantRoot, _ := nodeHash(concatHash(left, right))
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "test_proof_gen", config: &Config{Mode: ModeProofGen}},
+		{name: "test_tree_build", config: &Config{Mode: ModeTreeBuild}},
+		{name: "test_proof_gen_and_tree_build", config: &Config{Mode: ModeProofGenAndTreeBuild}},
+		{name: "test_parallel", config: &Config{Mode: ModeProofGenAndTreeBuild, RunInParallel: true, NumRoutines: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.LeafHashFunc = leafHash
+			tt.config.NodeHashFunc = nodeHash
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(m.Root, wantRoot) {
+				t.Fatalf("Root = %x, want %x", m.Root, wantRoot)
+			}
+			for i, block := range blocks {
+				proof, err := m.proofByIndex(i)
+				if err != nil {
+					t.Fatalf("proofByIndex() error = %v", err)
+				}
+				ok, err := Verify(block, proof, m.Root, &Config{LeafHashFunc: leafHash, NodeHashFunc: nodeHash})
+				if err != nil || !ok {
+					t.Errorf("Verify() block %d = %v, %v, want true", i, ok, err)
+				}
+				if ok, _ := Verify(block, proof, m.Root, nil); ok {
+					t.Errorf("Verify() block %d with a single hash function = true, want false", i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MetaDataBlock is a data block carrying opaque metadata. The metadata is recorded at build time and
+// retrieved with MerkleTree.LeafMeta; it is not part of the leaf hash.
+type MetaDataBlock interface {
+	DataBlock
+	Meta() any
+}
+
+// metaBlock attaches metadata to a data block.
+type metaBlock struct {
+	DataBlock
+	meta any
+}
+
+// Meta returns the attached metadata.
+func (b *metaBlock) Meta() any {
+	return b.meta
+}
+
+// WithMeta attaches the metadata to the data block, for data blocks that do not implement MetaDataBlock.
+func WithMeta(block DataBlock, meta any) MetaDataBlock {
+	return &metaBlock{DataBlock: block, meta: meta}
+}
+
+// MetaMode selects how the leaf metadata is included in a serialized proof.
+type MetaMode int
+
+const (
+	// MetaOmit does not include the metadata.
+	MetaOmit MetaMode = iota
+	// MetaInline includes the JSON-encoded metadata.
+	MetaInline
+	// MetaHashed includes the hash of the JSON-encoded metadata only.
+	MetaHashed
+)
+
+// collectLeafMeta returns the metadata of the data blocks, or nil if no data block carries metadata.
+func collectLeafMeta(blocks []DataBlock) []any {
+	var meta []any
+
+	for i, block := range blocks {
+		mb, ok := block.(MetaDataBlock)
+		if !ok {
+			continue
+		}
+
+		if meta == nil {
+			meta = make([]any, len(blocks))
+		}
+
+		meta[i] = mb.Meta()
+	}
+
+	return meta
+}
+
+// LeafMeta returns the metadata of the leaf at idx, or nil if its data block carried none.
+func (m *MerkleTree) LeafMeta(idx int) (any, error) {
+	if idx < 0 || idx >= m.NumLeaves {
+		return nil, ErrIndexOutOfRange
+	}
+
+	if m.meta == nil {
+		return nil, nil
+	}
+
+	return m.meta[idx], nil
+}
+
+// SerializedProofWithMeta returns the serialized proof of the leaf at idx with its metadata
+// included according to the mode. Byte slice metadata is encoded as hexadecimal like HexBytes.
+func (m *MerkleTree) SerializedProofWithMeta(idx int, mode MetaMode) (*SerializedProof, error) {
+	sp, err := m.SerializedProof(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == MetaOmit || m.meta == nil || m.meta[idx] == nil {
+		return sp, nil
+	}
+
+	encoded, err := encodeLeafMeta(m.meta[idx])
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == MetaInline {
+		sp.Meta = encoded
+		return sp, nil
+	}
+
+	if sp.MetaHash, err = m.HashFunc(encoded); err != nil {
+		return nil, err
+	}
+
+	return sp, nil
+}
+
+// MetaMatches reports whether the metadata matches the hashed metadata of the serialized proof,
+// hashed with the hash function of the configuration.
+func (sp *SerializedProof) MetaMatches(meta any, config *Config) (bool, error) {
+	if config == nil || config.HashFunc == nil {
+		config = &Config{HashFunc: DefaultHashFunc}
+	}
+
+	encoded, err := encodeLeafMeta(meta)
+	if err != nil {
+		return false, err
+	}
+
+	hash, err := config.HashFunc(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(hash, sp.MetaHash), nil
+}
+
+// encodeLeafMeta JSON-encodes the metadata, byte slices as HexBytes.
+func encodeLeafMeta(meta any) ([]byte, error) {
+	if b, ok := meta.([]byte); ok {
+		meta = HexBytes(b)
+	}
+
+	return json.Marshal(meta)
+}
@@ -0,0 +1,105 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestMerkleTree_LeafMeta(t *testing.T) {
+	type claim struct {
+		Account string `json:"account"`
+		Amount  int    `json:"amount"`
+	}
+	var (
+		plain  = mockDataBlocks(4)
+		blocks = []DataBlock{
+			WithMeta(plain[0], claim{Account: "alice", Amount: 10}),
+			WithMeta(plain[1], []byte{0xca, 0xfe}),
+			plain[2],
+			WithMeta(plain[3], "memo"),
+		}
+	)
+	for _, config := range []*Config{nil, {Mode: ModeTreeBuild}, {RunInParallel: true}} {
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for i, want := range []any{claim{Account: "alice", Amount: 10}, []byte{0xca, 0xfe}, nil, "memo"} {
+			got, err := m.LeafMeta(i)
+			if err != nil {
+				t.Fatalf("LeafMeta() error = %v", err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			if !bytes.Equal(gotJSON, wantJSON) {
+				t.Errorf("LeafMeta(%d) = %v, want %v", i, got, want)
+			}
+			// Metadata is not part of the leaf hash.
+			proof, err := m.proofByIndex(i)
+			if err != nil {
+				t.Fatalf("proofByIndex() error = %v", err)
+			}
+			if ok, err := m.Verify(plain[i], proof); err != nil || !ok {
+				t.Errorf("Verify() %d = %v, error = %v", i, ok, err)
+			}
+		}
+
+		inline, err := m.SerializedProofWithMeta(1, MetaInline)
+		if err != nil {
+			t.Fatalf("SerializedProofWithMeta() error = %v", err)
+		}
+		if string(inline.Meta) != `"0xcafe"` {
+			t.Errorf("SerializedProofWithMeta() inline meta = %s", inline.Meta)
+		}
+		hashed, err := m.SerializedProofWithMeta(0, MetaHashed)
+		if err != nil {
+			t.Fatalf("SerializedProofWithMeta() error = %v", err)
+		}
+		if hashed.Meta != nil {
+			t.Errorf("SerializedProofWithMeta() hashed meta disclosed %s", hashed.Meta)
+		}
+		if ok, err := hashed.MetaMatches(claim{Account: "alice", Amount: 10}, nil); err != nil || !ok {
+			t.Errorf("MetaMatches() = %v, error = %v", ok, err)
+		}
+		if ok, _ := hashed.MetaMatches(claim{Account: "alice", Amount: 11}, nil); ok {
+			t.Error("MetaMatches() matched other metadata")
+		}
+		if none, _ := m.SerializedProofWithMeta(2, MetaInline); none.Meta != nil || none.MetaHash != nil {
+			t.Errorf("SerializedProofWithMeta() without metadata = %+v", none)
+		}
+	}
+
+	m, err := New(nil, plain)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got, err := m.LeafMeta(0); got != nil || err != nil {
+		t.Errorf("LeafMeta() = %v, error = %v", got, err)
+	}
+	if _, err := m.LeafMeta(4); err != ErrIndexOutOfRange {
+		t.Errorf("LeafMeta() error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+}
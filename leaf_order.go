@@ -0,0 +1,108 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// LeafLessBytes orders leaves lexicographically by their bytes, the order of sorted Merkle Tree
+// set commitments such as the OpenZeppelin StandardMerkleTree.
+func LeafLessBytes(a, b []byte) bool {
+	return bytes.Compare(a, b) < 0
+}
+
+// LeafLessNumeric orders leaves as unsigned big-endian integers, ignoring leading zero bytes,
+// e.g. variable-length encodings of amounts or identifiers with leaf hashing disabled.
+func LeafLessNumeric(a, b []byte) bool {
+	a, b = bytes.TrimLeft(a, "\x00"), bytes.TrimLeft(b, "\x00")
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+
+	return bytes.Compare(a, b) < 0
+}
+
+// orderBlocks computes the leaves of the data blocks, stores them in the tree sorted with LeafLess,
+// and returns the data blocks in the same order. Equal leaves keep the order of their data blocks.
+func (m *MerkleTree) orderBlocks(blocks []DataBlock) ([]DataBlock, error) {
+	var (
+		leaves [][]byte
+		err    error
+	)
+
+	if m.RunInParallel {
+		m.initParallel()
+		leaves, err = m.computeLeafNodesParallel(blocks)
+	} else {
+		m.init()
+		leaves, err = m.computeLeafNodes(blocks)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	order := make([]int, len(blocks))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return m.LeafLess(leaves[order[i]], leaves[order[j]])
+	})
+
+	var (
+		ordered = make([]DataBlock, len(blocks))
+		sorted  = make([][]byte, len(blocks))
+	)
+
+	for i, idx := range order {
+		ordered[i] = blocks[idx]
+		sorted[i] = leaves[idx]
+	}
+
+	m.Leaves = sorted
+
+	return ordered, nil
+}
+
+// checkBlockPositions returns ErrBlockOrderUnsupported if the configuration moves the data blocks away from
+// their input positions, which the trees locating their data blocks by position rely on.
+func (c *Config) checkBlockPositions() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.LeafLess != nil {
+		return fmt.Errorf("%w: LeafLess is set", ErrBlockOrderUnsupported)
+	}
+
+	if c.SkipNilBlocks {
+		return fmt.Errorf("%w: SkipNilBlocks is set", ErrBlockOrderUnsupported)
+	}
+
+	return nil
+}
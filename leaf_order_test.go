@@ -0,0 +1,139 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestConfig_LeafLess(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "test_proof_gen", config: Config{LeafLess: LeafLessBytes}},
+		{name: "test_tree_build", config: Config{Mode: ModeTreeBuild, LeafLess: LeafLessBytes}},
+		{name: "test_parallel", config: Config{RunInParallel: true, NumRoutines: 4, LeafLess: LeafLessBytes}},
+		{name: "test_sharded", config: Config{RunInParallel: true, NumRoutines: 4, NumShards: 2, LeafLess: LeafLessBytes}},
+		{name: "test_numeric", config: Config{Mode: ModeProofGenAndTreeBuild, DisableLeafHashing: true, LeafLess: LeafLessNumeric}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(33)
+			shuffled := append([]DataBlock(nil), blocks...)
+			rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+			config := tt.config
+			m1, err := New(&config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			config = tt.config
+			m2, err := New(&config, shuffled)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(m1.Root, m2.Root) {
+				t.Fatalf("New() roots differ across input orders: %x, %x", m1.Root, m2.Root)
+			}
+			if !sort.SliceIsSorted(m1.Leaves, func(i, j int) bool { return tt.config.LeafLess(m1.Leaves[i], m1.Leaves[j]) }) {
+				t.Error("New() leaves are not sorted")
+			}
+			for _, block := range blocks {
+				idx, err := m1.leafIndex(block)
+				if err != nil {
+					t.Fatalf("leafIndex() error = %v", err)
+				}
+				proof, err := m1.proofByIndex(idx)
+				if err != nil {
+					t.Fatalf("proofByIndex() error = %v", err)
+				}
+				if ok, err := m1.Verify(block, proof); err != nil || !ok {
+					t.Errorf("Verify() = %v, error = %v", ok, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLeafLessNumeric(t *testing.T) {
+	values := [][]byte{{0x01, 0x00}, {0x00, 0x00, 0xff}, {0x02}, {}, {0x00, 0x01, 0x00, 0x01}}
+	want := [][]byte{{}, {0x02}, {0x00, 0x00, 0xff}, {0x01, 0x00}, {0x00, 0x01, 0x00, 0x01}}
+	sort.Slice(values, func(i, j int) bool { return LeafLessNumeric(values[i], values[j]) })
+	for i := range values {
+		if !bytes.Equal(values[i], want[i]) {
+			t.Fatalf("LeafLessNumeric() order = %x, want %x", values, want)
+		}
+	}
+	blocks := []DataBlock{&mock.DataBlock{Data: []byte{0x02}}, &mock.DataBlock{Data: []byte{0x00, 0x01}}}
+	m, err := New(&Config{DisableLeafHashing: true, LeafLess: LeafLessNumeric}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !bytes.Equal(m.Leaves[0], []byte{0x00, 0x01}) {
+		t.Errorf("New() first leaf = %x, want 0001", m.Leaves[0])
+	}
+}
+
+func TestConfig_checkBlockPositions(t *testing.T) {
+	window, err := New(nil, mockDataBlocks(4))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		name  string
+		build func(config *Config) error
+	}{
+		{"test_map", func(config *Config) error {
+			_, err := NewFromMap(config, map[string]DataBlock{"a": mockDataBlocks(1)[0], "b": mockDataBlocks(2)[1]})
+			return err
+		}},
+		{"test_salted", func(config *Config) error { _, err := NewSalted(config, mockDataBlocks(4)); return err }},
+		{"test_logs", func(config *Config) error { _, err := NewFromLogs(config, mockEventLogs(4)); return err }},
+		{"test_shuffled", func(config *Config) error {
+			_, err := NewShuffled(config, mockDataBlocks(4), []byte("key"))
+			return err
+		}},
+		{"test_extended", func(config *Config) error { _, err := NewExtended(config, mockDataBlocks(4)); return err }},
+		{"test_registry", func(config *Config) error {
+			_, err := NewRegistry(config, map[string]*MerkleTree{"a": window, "b": window})
+			return err
+		}},
+		{"test_rollup", func(config *Config) error { _, err := NewRollup(config, []*MerkleTree{window, window}); return err }},
+	}
+	configs := []*Config{{LeafLess: LeafLessBytes}, {SkipNilBlocks: true}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, config := range configs {
+				if err := tt.build(config); !errors.Is(err, ErrBlockOrderUnsupported) {
+					t.Errorf("error = %v, want ErrBlockOrderUnsupported", err)
+				}
+			}
+		})
+	}
+}
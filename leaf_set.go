@@ -0,0 +1,83 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// LeafSet holds the leaves of a set of data blocks, hashed once to derive several trees from them,
+// e.g. an EVM-compatible root with sorted sibling pairs and an internal root over the same data.
+type LeafSet struct {
+	config *Config
+	// Leaves are the leaves of the data blocks, in order.
+	Leaves [][]byte
+}
+
+// NewLeafSet serializes and hashes the data blocks into leaves. Only the configuration fields affecting
+// leaves are used: HashFunc, LeafHashFunc, DisableLeafHashing, LeafKey, LeafKeyedHashFunc, LeafCache,
+// and RunInParallel with NumRoutines.
+func NewLeafSet(config *Config, blocks []DataBlock) (*LeafSet, error) {
+	if len(blocks) <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	if config == nil {
+		config = new(Config)
+	}
+
+	var (
+		m      = &MerkleTree{Config: config, NumLeaves: len(blocks)}
+		leaves [][]byte
+		err    error
+	)
+
+	if m.RunInParallel {
+		m.initParallel()
+		leaves, err = m.computeLeafNodesParallel(blocks)
+	} else {
+		m.init()
+		leaves, err = m.computeLeafNodes(blocks)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &LeafSet{config: config, Leaves: leaves}, nil
+}
+
+// Build derives a Merkle Tree from the leaves with the configuration, without re-serializing or
+// re-hashing the data blocks. The leaf hashing fields of the configuration are taken from the LeafSet
+// so that the tree verifies its data blocks; HashFunc must hash leaves as the LeafSet did unless LeafKey
+// or LeafHashFunc is set.
+func (s *LeafSet) Build(config *Config) (*MerkleTree, error) {
+	derived := new(Config)
+	if config != nil {
+		*derived = *config
+	}
+
+	derived.DisableLeafHashing = s.config.DisableLeafHashing
+	derived.LeafHashFunc = s.config.LeafHashFunc
+	derived.LeafKey = s.config.LeafKey
+	derived.LeafKeyedHashFunc = s.config.LeafKeyedHashFunc
+
+	// Cap the leaves so that no build can append to the shared backing array.
+	return newFromLeaves(derived, s.Leaves[:len(s.Leaves):len(s.Leaves)])
+}
@@ -0,0 +1,89 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"testing"
+)
+
+func TestLeafSet_Build(t *testing.T) {
+	blocks := mockDataBlocks(11)
+	leafHash := func(data []byte) ([]byte, error) {
+		sum := sha512.Sum512_256(data)
+		return sum[:], nil
+	}
+	tests := []struct {
+		name      string
+		setConfig *Config
+		config    *Config
+	}{
+		{name: "test_default"},
+		{name: "test_sorted", config: &Config{SortSiblingPairs: true}},
+		{name: "test_tree_build", config: &Config{Mode: ModeTreeBuild}},
+		{name: "test_parallel", setConfig: &Config{RunInParallel: true}, config: &Config{RunInParallel: true}},
+		{name: "test_disable_leaf_hashing", setConfig: &Config{DisableLeafHashing: true}, config: &Config{Mode: ModeProofGenAndTreeBuild}},
+		{name: "test_leaf_key", setConfig: &Config{LeafKey: []byte("key")}, config: &Config{SortSiblingPairs: true}},
+		{name: "test_leaf_hash_func", setConfig: &Config{LeafHashFunc: leafHash}, config: &Config{Mode: ModeTreeBuild}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := NewLeafSet(tt.setConfig, blocks)
+			if err != nil {
+				t.Fatalf("NewLeafSet() error = %v", err)
+			}
+			got, err := set.Build(tt.config)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			wantConfig := new(Config)
+			if tt.config != nil {
+				*wantConfig = *tt.config
+			}
+			if tt.setConfig != nil {
+				wantConfig.DisableLeafHashing = tt.setConfig.DisableLeafHashing
+				wantConfig.LeafKey = tt.setConfig.LeafKey
+				wantConfig.LeafHashFunc = tt.setConfig.LeafHashFunc
+			}
+			want, err := New(wantConfig, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(got.Root, want.Root) {
+				t.Errorf("Build() root = %x, want %x", got.Root, want.Root)
+			}
+			proof, err := got.proofByIndex(3)
+			if err != nil {
+				t.Fatalf("proofByIndex() error = %v", err)
+			}
+			if ok, err := got.Verify(blocks[3], proof); err != nil || !ok {
+				t.Errorf("Verify() = %v, error = %v", ok, err)
+			}
+		})
+	}
+
+	if _, err := NewLeafSet(nil, blocks[:1]); err != ErrInvalidNumOfDataBlocks {
+		t.Errorf("NewLeafSet() error = %v, want %v", err, ErrInvalidNumOfDataBlocks)
+	}
+}
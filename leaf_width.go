@@ -0,0 +1,140 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"time"
+)
+
+// TypeLeafWidthPolicy is the policy fitting the serialized data blocks to Config.LeafWidth.
+type TypeLeafWidthPolicy int
+
+const (
+	// LeafWidthPadRight appends zeros to shorter data blocks and rejects longer ones. It is the default.
+	LeafWidthPadRight TypeLeafWidthPolicy = iota
+	// LeafWidthPadLeft prepends zeros to shorter data blocks, as big-endian integers are widened,
+	// and rejects longer ones.
+	LeafWidthPadLeft
+	// LeafWidthExact rejects data blocks of any other width.
+	LeafWidthExact
+	// LeafWidthTruncate appends zeros to shorter data blocks and keeps the first LeafWidth bytes of longer ones.
+	LeafWidthTruncate
+)
+
+// fixedWidthBlock is a data block whose serialization is fitted to a width.
+type fixedWidthBlock struct {
+	DataBlock
+	width  int
+	policy TypeLeafWidthPolicy
+}
+
+// Serialize fits the serialized data block to the width with the policy.
+func (b *fixedWidthBlock) Serialize() ([]byte, error) {
+	data, err := b.DataBlock.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	return fitLeafWidth(data, b.width, b.policy)
+}
+
+// Meta returns the metadata of the data block if it carries any, see MetaDataBlock.
+func (b *fixedWidthBlock) Meta() any {
+	if mb, ok := b.DataBlock.(MetaDataBlock); ok {
+		return mb.Meta()
+	}
+
+	return nil
+}
+
+// Timestamp returns the timestamp of the data block if it carries any, see TimestampedDataBlock.
+func (b *fixedWidthBlock) Timestamp() time.Time {
+	if tb, ok := b.DataBlock.(TimestampedDataBlock); ok {
+		return tb.Timestamp()
+	}
+
+	return time.Time{}
+}
+
+// fitLeafWidth returns the data fitted to the width with the policy, or ErrLeafWidthMismatch.
+func fitLeafWidth(data []byte, width int, policy TypeLeafWidthPolicy) ([]byte, error) {
+	switch {
+	case len(data) == width:
+		return data, nil
+	case len(data) > width && policy == LeafWidthTruncate:
+		return data[:width], nil
+	case len(data) > width || policy == LeafWidthExact:
+		return nil, fmt.Errorf("%w: %d bytes, width %d", ErrLeafWidthMismatch, len(data), width)
+	}
+
+	fitted := make([]byte, width)
+	if policy == LeafWidthPadLeft {
+		copy(fitted[width-len(data):], data)
+	} else {
+		copy(fitted, data)
+	}
+
+	return fitted, nil
+}
+
+// fitBlock returns the data block fitted to LeafWidth, or the data block itself if LeafWidth is 0.
+func (c *Config) fitBlock(block DataBlock) DataBlock {
+	if c.LeafWidth <= 0 {
+		return block
+	}
+
+	return &fixedWidthBlock{DataBlock: block, width: c.LeafWidth, policy: c.LeafWidthPolicy}
+}
+
+// fitBlocks returns the data blocks fitted to LeafWidth. Unless the policy is LeafWidthTruncate, the data blocks
+// are serialized to check their widths first, and a *LeafWidthError lists all the data blocks that do not fit.
+func (c *Config) fitBlocks(blocks []DataBlock) ([]DataBlock, error) {
+	var (
+		fitted  = make([]DataBlock, len(blocks))
+		indices []int
+	)
+
+	for i, block := range blocks {
+		fitted[i] = c.fitBlock(block)
+
+		if c.LeafWidthPolicy == LeafWidthTruncate {
+			continue
+		}
+
+		data, err := block.Serialize()
+		if err != nil {
+			return nil, &BlockError{Index: i, Err: err}
+		}
+
+		if len(data) > c.LeafWidth || (len(data) < c.LeafWidth && c.LeafWidthPolicy == LeafWidthExact) {
+			indices = append(indices, i)
+		}
+	}
+
+	if indices != nil {
+		return nil, &LeafWidthError{Width: c.LeafWidth, Indices: indices}
+	}
+
+	return fitted, nil
+}
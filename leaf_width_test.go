@@ -0,0 +1,85 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func widthBlocks(lens ...int) []DataBlock {
+	blocks := make([]DataBlock, len(lens))
+	for i, n := range lens {
+		data := bytes.Repeat([]byte{0xff}, n)
+		blocks[i] = &mock.DataBlock{Data: data}
+	}
+	return blocks
+}
+
+func TestNew_LeafWidth(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      TypeLeafWidthPolicy
+		lens        []int
+		wantLeaf    []byte
+		wantIndices []int
+	}{
+		{name: "test_pad_right", lens: []int{2, 4, 3}, wantLeaf: []byte{0xff, 0xff, 0, 0}},
+		{name: "test_pad_left", policy: LeafWidthPadLeft, lens: []int{2, 4, 3}, wantLeaf: []byte{0, 0, 0xff, 0xff}},
+		{name: "test_truncate", policy: LeafWidthTruncate, lens: []int{6, 4, 3}, wantLeaf: []byte{0xff, 0xff, 0xff, 0xff}},
+		{name: "test_pad_overflow", lens: []int{5, 4, 6, 1}, wantIndices: []int{0, 2}},
+		{name: "test_exact", policy: LeafWidthExact, lens: []int{4, 3, 4, 5}, wantIndices: []int{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{LeafWidth: 4, LeafWidthPolicy: tt.policy, DisableLeafHashing: true}
+			blocks := widthBlocks(tt.lens...)
+			m, err := New(config, blocks)
+			if tt.wantIndices != nil {
+				var widthErr *LeafWidthError
+				if !errors.As(err, &widthErr) || !errors.Is(err, ErrLeafWidthMismatch) {
+					t.Fatalf("New() error = %v, want %T", err, widthErr)
+				}
+				if !slices.Equal(widthErr.Indices, tt.wantIndices) {
+					t.Errorf("New() indices = %v, want %v", widthErr.Indices, tt.wantIndices)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(m.Leaves[0], tt.wantLeaf) {
+				t.Errorf("New() leaf = %x, want %x", m.Leaves[0], tt.wantLeaf)
+			}
+			for i, block := range blocks {
+				if ok, err := m.Verify(block, m.Proofs[i]); err != nil || !ok {
+					t.Errorf("Verify() %d = %v, error = %v", i, ok, err)
+				}
+			}
+		})
+	}
+}
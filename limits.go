@@ -0,0 +1,38 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "math/bits"
+
+// checkLimits returns a LimitError if a tree of numLeaves leaves exceeds MaxLeaves or MaxDepth.
+func (c *Config) checkLimits(numLeaves int) error {
+	if c.MaxLeaves > 0 && numLeaves > c.MaxLeaves {
+		return &LimitError{Err: ErrTooManyLeaves, Value: numLeaves, Limit: c.MaxLeaves}
+	}
+
+	if depth := bits.Len(uint(numLeaves - 1)); c.MaxDepth > 0 && depth > c.MaxDepth {
+		return &LimitError{Err: ErrTreeTooDeep, Value: depth, Limit: c.MaxDepth}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConfig_limits(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *Config
+		numBlocks int
+		wantErr   error
+		wantValue int
+	}{
+		{name: "test_no_limits", config: &Config{}, numBlocks: 100},
+		{name: "test_max_leaves", config: &Config{MaxLeaves: 100}, numBlocks: 100},
+		{name: "test_too_many_leaves", config: &Config{MaxLeaves: 99}, numBlocks: 100, wantErr: ErrTooManyLeaves, wantValue: 100},
+		{name: "test_max_depth", config: &Config{MaxDepth: 7}, numBlocks: 128},
+		{name: "test_too_deep", config: &Config{MaxDepth: 7}, numBlocks: 129, wantErr: ErrTreeTooDeep, wantValue: 8},
+		{
+			name:      "test_too_deep_tree_build",
+			config:    &Config{Mode: ModeTreeBuild, RunInParallel: true, MaxDepth: 3},
+			numBlocks: 9,
+			wantErr:   ErrTreeTooDeep,
+			wantValue: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config, mockDataBlocks(tt.numBlocks))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("New() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("New() error = %T, want *LimitError", err)
+			}
+			if limitErr.Value != tt.wantValue {
+				t.Errorf("LimitError.Value = %d, want %d", limitErr.Value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestIngestTree_limits(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *Config
+		accepted int
+		wantErr  error
+	}{
+		{name: "test_max_leaves", config: &Config{MaxLeaves: 5}, accepted: 5, wantErr: ErrTooManyLeaves},
+		{name: "test_max_depth", config: &Config{MaxDepth: 2, MaxLeaves: 10}, accepted: 4, wantErr: ErrTreeTooDeep},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := NewIngestTree(tt.config)
+			blocks := mockDataBlocksFixedSize(tt.accepted + 1)
+			for i := 0; i < tt.accepted; i++ {
+				if _, err := tree.Append(blocks[i]); err != nil {
+					t.Fatalf("Append() %d error = %v", i, err)
+				}
+			}
+			if _, err := tree.Append(blocks[tt.accepted]); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Append() error = %v, want %v", err, tt.wantErr)
+			}
+			s, err := tree.Snapshot()
+			if err != nil {
+				t.Fatalf("Snapshot() error = %v", err)
+			}
+			if s.Size != tt.accepted {
+				t.Errorf("Snapshot() Size = %d, want %d", s.Size, tt.accepted)
+			}
+		})
+	}
+}
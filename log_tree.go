@@ -0,0 +1,155 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// maxLogTopics is the maximum number of topics of an Ethereum event log (LOG0 to LOG4).
+const maxLogTopics = 4
+
+// EventLog is an Ethereum event log, with the fields of go-ethereum's types.Log so that logs fetched with
+// FilterLogs convert field by field, the topics being converted one by one from common.Hash.
+type EventLog struct {
+	// Address is the address of the contract that emitted the log.
+	Address [20]byte
+	// Topics are the indexed topics of the log, the event signature hash first for non-anonymous events.
+	Topics [][32]byte
+	// Data is the ABI-encoded non-indexed data of the log.
+	Data []byte
+	// BlockNumber, BlockHash, TxHash, TxIndex and Index locate the log: Index is its position in the block.
+	BlockNumber uint64
+	BlockHash   [32]byte
+	TxHash      [32]byte
+	TxIndex     uint
+	Index       uint
+	// Removed is true if the log was reverted by a chain reorganization. Removed logs are rejected.
+	Removed bool
+}
+
+// Serialize encodes the log canonically: the block number, the log index and the transaction index as
+// big-endian uint64, the block hash, the transaction hash and the address, the number of topics as a byte
+// followed by the topics, and the data prefixed by its big-endian uint32 length.
+func (l *EventLog) Serialize() ([]byte, error) {
+	if len(l.Topics) > maxLogTopics {
+		return nil, fmt.Errorf("%w: %d topics", ErrInvalidEventLog, len(l.Topics))
+	}
+
+	buf := make([]byte, 0, 3*8+2*32+20+1+len(l.Topics)*32+4+len(l.Data))
+	buf = binary.BigEndian.AppendUint64(buf, l.BlockNumber)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(l.Index))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(l.TxIndex))
+	buf = append(buf, l.BlockHash[:]...)
+	buf = append(buf, l.TxHash[:]...)
+	buf = append(buf, l.Address[:]...)
+	buf = append(buf, byte(len(l.Topics)))
+
+	for _, topic := range l.Topics {
+		buf = append(buf, topic[:]...)
+	}
+
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(l.Data)))
+
+	return append(buf, l.Data...), nil
+}
+
+// LogTree is a Merkle Tree over Ethereum event logs in canonical order: by block number, then by log index,
+// so that the root of a dataset of logs does not depend on the order they were fetched in.
+type LogTree struct {
+	*MerkleTree
+	// Logs are the logs in leaf order.
+	Logs []EventLog
+}
+
+// NewFromLogs builds the tree over the event logs in canonical order, each leaf committing to the canonical
+// encoding of a log, see EventLog.Serialize. It returns ErrInvalidEventLog for removed logs and for two logs
+// at the same position.
+func NewFromLogs(config *Config, logs []EventLog) (*LogTree, error) {
+	if err := config.checkBlockPositions(); err != nil {
+		return nil, err
+	}
+
+	sorted := make([]EventLog, len(logs))
+	copy(sorted, logs)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return logBefore(&sorted[i], sorted[j].BlockNumber, sorted[j].Index)
+	})
+
+	blocks := make([]DataBlock, len(sorted))
+
+	for i := range sorted {
+		l := &sorted[i]
+		if l.Removed {
+			return nil, fmt.Errorf("%w: removed log %d of block %d", ErrInvalidEventLog, l.Index, l.BlockNumber)
+		}
+
+		if i > 0 && !logBefore(&sorted[i-1], l.BlockNumber, l.Index) {
+			return nil, fmt.Errorf("%w: duplicate log %d of block %d", ErrInvalidEventLog, l.Index, l.BlockNumber)
+		}
+
+		blocks[i] = l
+	}
+
+	m, err := New(config, blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LogTree{MerkleTree: m, Logs: sorted}, nil
+}
+
+// logBefore reports whether the log precedes the position of the given block number and log index.
+func logBefore(l *EventLog, blockNumber uint64, index uint) bool {
+	if l.BlockNumber != blockNumber {
+		return l.BlockNumber < blockNumber
+	}
+
+	return l.Index < index
+}
+
+// IndexOfLog returns the leaf index of the log at the log index of the block.
+func (t *LogTree) IndexOfLog(blockNumber uint64, logIndex uint) (int, error) {
+	idx := sort.Search(len(t.Logs), func(i int) bool {
+		return !logBefore(&t.Logs[i], blockNumber, logIndex)
+	})
+
+	if idx == len(t.Logs) || t.Logs[idx].BlockNumber != blockNumber || t.Logs[idx].Index != logIndex {
+		return 0, fmt.Errorf("%w: log %d of block %d", ErrLogNotFound, logIndex, blockNumber)
+	}
+
+	return idx, nil
+}
+
+// ProofOfLog returns the proof of the log at the log index of the block, verified with Verify against the log.
+func (t *LogTree) ProofOfLog(blockNumber uint64, logIndex uint) (*Proof, error) {
+	idx, err := t.IndexOfLog(blockNumber, logIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.proofByIndex(idx)
+}
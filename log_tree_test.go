@@ -0,0 +1,117 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func mockEventLogs(n int) []EventLog {
+	logs := make([]EventLog, n)
+	for i := range logs {
+		logs[i] = EventLog{
+			Address:     [20]byte{byte(i)},
+			Topics:      [][32]byte{{0xdd, byte(i)}},
+			Data:        []byte{byte(i), byte(i >> 8)},
+			BlockNumber: uint64(100 + i/3),
+			Index:       uint(i % 3),
+			TxIndex:     uint(i % 2),
+		}
+	}
+	return logs
+}
+
+func TestNewFromLogs(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		logs    []EventLog
+		wantErr error
+	}{
+		{name: "test_2", logs: mockEventLogs(2)},
+		{name: "test_10_tree_build", config: &Config{Mode: ModeTreeBuild}, logs: mockEventLogs(10)},
+		{name: "test_removed", logs: append(mockEventLogs(3), EventLog{BlockNumber: 200, Removed: true}),
+			wantErr: ErrInvalidEventLog},
+		{name: "test_duplicate", logs: append(mockEventLogs(3), EventLog{BlockNumber: 100, Index: 1}),
+			wantErr: ErrInvalidEventLog},
+		{name: "test_too_many_topics", logs: append(mockEventLogs(3), EventLog{BlockNumber: 200,
+			Topics: make([][32]byte, 5)}), wantErr: ErrInvalidEventLog},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewFromLogs(tt.config, tt.logs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewFromLogs() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			reversed := make([]EventLog, len(tt.logs))
+			for i := range tt.logs {
+				reversed[len(tt.logs)-1-i] = tt.logs[i]
+			}
+			again, err := NewFromLogs(tt.config, reversed)
+			if err != nil {
+				t.Fatalf("NewFromLogs() error = %v", err)
+			}
+			if !bytes.Equal(again.Root, m.Root) {
+				t.Fatal("NewFromLogs() root depends on the order of the logs")
+			}
+			for i := range tt.logs {
+				l := &tt.logs[i]
+				proof, err := m.ProofOfLog(l.BlockNumber, l.Index)
+				if err != nil {
+					t.Fatalf("ProofOfLog() error = %v", err)
+				}
+				if ok, err := m.Verify(l, proof); err != nil || !ok {
+					t.Errorf("Verify() log %d of block %d = %v, error = %v", l.Index, l.BlockNumber, ok, err)
+				}
+			}
+			if _, err := m.ProofOfLog(100, 3); !errors.Is(err, ErrLogNotFound) {
+				t.Errorf("ProofOfLog() error = %v, want %v", err, ErrLogNotFound)
+			}
+		})
+	}
+}
+
+func TestEventLog_Serialize(t *testing.T) {
+	l := EventLog{Topics: [][32]byte{{1}}, Data: []byte{2, 3}, BlockNumber: 1, Index: 2, TxIndex: 3}
+	got, err := l.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+	if want := 3*8 + 2*32 + 20 + 1 + 32 + 4 + 2; len(got) != want {
+		t.Fatalf("Serialize() length = %d, want %d", len(got), want)
+	}
+	changed := l
+	changed.Data = []byte{2, 3, 4}
+	other, err := changed.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+	if bytes.Equal(got, other) {
+		t.Error("Serialize() does not commit to the data")
+	}
+}
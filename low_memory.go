@@ -0,0 +1,162 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "golang.org/x/sync/errgroup"
+
+// recomputeLevels returns the number of lowest levels recomputed for each proof of a ModeLowMemory tree.
+func (m *MerkleTree) recomputeLevels() int {
+	if m.RecomputeLevels <= 0 {
+		return m.Depth / 2
+	}
+
+	return min(m.RecomputeLevels, m.Depth-1)
+}
+
+// lowMemoryBuild computes the roots of the chunks of 2^RecomputeLevels leaves, then stores the levels
+// above them and computes the root of a ModeLowMemory tree. Only one chunk is buffered per goroutine.
+func (m *MerkleTree) lowMemoryBuild() error {
+	var (
+		levels      = m.recomputeLevels()
+		numChunks   = (m.NumLeaves + 1<<levels - 1) >> levels
+		chunkRoots  = make([][]byte, numChunks)
+		numRoutines = 1
+		eg          = new(errgroup.Group)
+	)
+
+	if m.RunInParallel {
+		numRoutines = min(m.NumRoutines, numChunks)
+	}
+
+	for r := 0; r < numRoutines; r++ {
+		r := r
+
+		eg.Go(func() (err error) {
+			for k := r; k < numChunks; k += numRoutines {
+				if chunkRoots[k], err = m.chunkRoot(k<<levels, levels, -1, nil); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	m.topNodes = make([][][]byte, m.Depth-levels)
+	m.topNodes[0] = chunkRoots
+
+	for i := 0; i < len(m.topNodes)-1; i++ {
+		var err error
+
+		m.topNodes[i] = appendNodeIfOdd(m.topNodes[i])
+		if m.topNodes[i+1], err = m.hashLevel(m.buildChildren, levels+i+1, 0, m.topNodes[i]); err != nil {
+			return err
+		}
+	}
+
+	// The level below the root has two nodes.
+	top := m.topNodes[len(m.topNodes)-1]
+
+	var err error
+
+	m.Root, err = m.buildChildren(m.Depth, 0, top[0], top[1])
+
+	return err
+}
+
+// chunkRoot computes the root of the given number of levels over the chunk of leaves from start.
+// If proof is not nil, the siblings and path bits of the leaf at idx within these levels are appended to it.
+// Odd levels are padded by duplicating their last node, as in the whole tree. The nodes are visited, see
+// Config.NodeVisitor, only while building, without proof.
+func (m *MerkleTree) chunkRoot(start, levels, idx int, proof *Proof) ([]byte, error) {
+	hash := m.buildChildren
+	if proof != nil {
+		hash = m.hashChildren
+	}
+
+	level := make([][]byte, min(1<<levels, m.NumLeaves-start), 1<<levels)
+	copy(level, m.Leaves[start:])
+
+	pos := idx - start
+
+	for l := 0; l < levels; l++ {
+		level = appendNodeIfOdd(level)
+
+		if proof != nil {
+			if pos&1 == 0 {
+				proof.Path |= 1 << l
+			}
+
+			proof.Siblings = append(proof.Siblings, level[pos^1])
+			pos >>= 1
+		}
+
+		offset := start >> (l + 1)
+
+		for j := 0; j < len(level)>>1; j++ {
+			node, err := hash(l+1, offset+j, level[2*j], level[2*j+1])
+			if err != nil {
+				return nil, err
+			}
+
+			level[j] = node
+		}
+
+		level = level[:len(level)>>1]
+	}
+
+	return level[0], nil
+}
+
+// lowMemoryProof computes the proof of the leaf at idx of a ModeLowMemory tree, recomputing its chunk.
+func (m *MerkleTree) lowMemoryProof(idx int) (*Proof, error) {
+	var (
+		levels = m.recomputeLevels()
+		proof  = &Proof{Siblings: make([][]byte, 0, m.Depth)}
+	)
+
+	if _, err := m.chunkRoot(idx>>levels<<levels, levels, idx, proof); err != nil {
+		return nil, err
+	}
+
+	m.appendTopSiblings(idx, levels, proof)
+
+	return proof, nil
+}
+
+// appendTopSiblings appends the siblings and path bits of the leaf at idx from the stored levels above
+// the given number of levels to its proof.
+func (m *MerkleTree) appendTopSiblings(idx, levels int, proof *Proof) {
+	for i, nodes := range m.topNodes {
+		pos := idx >> (levels + i)
+		if pos&1 == 0 {
+			proof.Path |= 1 << (levels + i)
+		}
+
+		proof.Siblings = append(proof.Siblings, nodes[pos^1])
+	}
+}
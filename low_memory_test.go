@@ -0,0 +1,89 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMerkleTree_modeLowMemory(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		num    int
+	}{
+		{name: "test_2", config: &Config{}, num: 2},
+		{name: "test_3", config: &Config{}, num: 3},
+		{name: "test_9", config: &Config{}, num: 9},
+		{name: "test_1000", config: &Config{}, num: 1000},
+		{name: "test_1000_recompute_1", config: &Config{RecomputeLevels: 1}, num: 1000},
+		{name: "test_1000_recompute_all", config: &Config{RecomputeLevels: 64}, num: 1000},
+		{name: "test_1001_parallel", config: &Config{RunInParallel: true, NumRoutines: 4}, num: 1001},
+		{name: "test_1001_positional", config: &Config{PositionalHashFunc: PositionPrefixed(nil)}, num: 1001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.num)
+			want, err := New(&Config{PositionalHashFunc: tt.config.PositionalHashFunc}, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			tt.config.Mode = ModeLowMemory
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(m.Root, want.Root) {
+				t.Fatal("ModeLowMemory root differs from ModeProofGen")
+			}
+			if m.nodes != nil || m.Proofs != nil {
+				t.Fatal("ModeLowMemory stored the tree structure or proofs")
+			}
+			for idx, block := range blocks {
+				proof, err := m.Proof(block)
+				if err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+				if !proof.Equal(want.Proofs[idx]) {
+					t.Fatalf("Proof() leaf %d = %v, want %v", idx, proof, want.Proofs[idx])
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_EstimateMemory_modeLowMemory(t *testing.T) {
+	const numLeaves = 1 << 20
+	proofGen, err := (&Config{Mode: ModeProofGen}).EstimateMemory(numLeaves)
+	if err != nil {
+		t.Fatalf("EstimateMemory() error = %v", err)
+	}
+	lowMemory, err := (&Config{Mode: ModeLowMemory}).EstimateMemory(numLeaves)
+	if err != nil {
+		t.Fatalf("EstimateMemory() error = %v", err)
+	}
+	if lowMemory*4 > proofGen {
+		t.Errorf("EstimateMemory() ModeLowMemory = %d, want less than a quarter of ModeProofGen %d", lowMemory, proofGen)
+	}
+}
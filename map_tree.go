@@ -0,0 +1,89 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MapTree is a Merkle Tree built from a map, with its leaves ordered by key.
+// Ordering by key makes the root independent of the map iteration order.
+type MapTree struct {
+	*MerkleTree
+	// Keys are the keys in leaf order.
+	Keys  []string
+	index map[string]int
+}
+
+// NewFromMap builds the tree over the data blocks of the map in lexicographic key order.
+// Keys are not part of the leaves, they only determine the order.
+func NewFromMap(config *Config, blocks map[string]DataBlock) (*MapTree, error) {
+	if err := config.checkBlockPositions(); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(blocks))
+	for key := range blocks {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var (
+		ordered = make([]DataBlock, len(keys))
+		index   = make(map[string]int, len(keys))
+	)
+
+	for i, key := range keys {
+		ordered[i] = blocks[key]
+		index[key] = i
+	}
+
+	m, err := New(config, ordered)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MapTree{MerkleTree: m, Keys: keys, index: index}, nil
+}
+
+// IndexOfKey returns the leaf index of the data block under the key.
+func (t *MapTree) IndexOfKey(key string) (int, error) {
+	idx, ok := t.index[key]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrKeyNotFound, key)
+	}
+
+	return idx, nil
+}
+
+// ProofByKey returns the proof of the data block under the key.
+func (t *MapTree) ProofByKey(key string) (*Proof, error) {
+	idx, err := t.IndexOfKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.proofByIndex(idx)
+}
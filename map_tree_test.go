@@ -0,0 +1,75 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewFromMap(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		num    int
+	}{
+		{name: "test_2", num: 2},
+		{name: "test_7_tree_build", config: &Config{Mode: ModeTreeBuild}, num: 7},
+		{name: "test_100_parallel", config: &Config{RunInParallel: true}, num: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := make(map[string]DataBlock, tt.num)
+			for i, block := range mockDataBlocks(tt.num) {
+				blocks[fmt.Sprintf("key-%03d", i)] = block
+			}
+			m, err := NewFromMap(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("NewFromMap() error = %v", err)
+			}
+			for i := 0; i < 5; i++ {
+				again, err := NewFromMap(tt.config, blocks)
+				if err != nil {
+					t.Fatalf("NewFromMap() error = %v", err)
+				}
+				if !bytes.Equal(again.Root, m.Root) {
+					t.Fatal("NewFromMap() root depends on map iteration order")
+				}
+			}
+			for key, block := range blocks {
+				proof, err := m.ProofByKey(key)
+				if err != nil {
+					t.Fatalf("ProofByKey() error = %v", err)
+				}
+				if ok, err := m.Verify(block, proof); err != nil || !ok {
+					t.Errorf("Verify() %s = %v, error = %v", key, ok, err)
+				}
+			}
+			if _, err := m.ProofByKey("missing"); !errors.Is(err, ErrKeyNotFound) {
+				t.Errorf("ProofByKey() error = %v, want %v", err, ErrKeyNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// Sizes of the Go runtime structures backing the Merkle Tree, used for memory estimation.
+const (
+	sliceHeaderSize = uint64(unsafe.Sizeof([]byte(nil)))
+	pointerSize     = uint64(unsafe.Sizeof(uintptr(0)))
+	proofSize       = uint64(unsafe.Sizeof(Proof{}))
+	// leafMapEntrySize approximates the per-entry cost of leafMap: string header, int value and bucket overhead.
+	leafMapEntrySize = uint64(unsafe.Sizeof("")) + uint64(unsafe.Sizeof(0)) + 16
+)
+
+// EstimateMemory estimates the number of bytes allocated by New for numLeaves data blocks with this configuration.
+// The estimate assumes leaves have the length of the configured hash output, which also holds
+// for DisableLeafHashing as long as the data blocks are about that size.
+func (c *Config) EstimateMemory(numLeaves int) (uint64, error) {
+	hashFunc := c.HashFunc
+	if hashFunc == nil {
+		hashFunc = DefaultHashFuncParallel
+	}
+
+	// Probe the hash function for its output length.
+	probe, err := hashFunc(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	// ModeProofGen trees writing their proofs to ProofSink are built as ModeLowMemory trees.
+	if c.Mode == ModeLowMemory || (c.Mode == ModeProofGen && c.ProofSink != nil) {
+		m := &MerkleTree{Config: c, Depth: bits.Len(uint(numLeaves - 1))}
+
+		return estimateLowMemory(numLeaves, m.recomputeLevels(), uint64(len(probe))), nil
+	}
+
+	return estimateMemory(c.Mode, numLeaves, uint64(len(probe))), nil
+}
+
+// estimateLowMemory estimates the memory allocated for numLeaves leaves of hashLen bytes in ModeLowMemory,
+// recomputing the given number of levels: the leaves, the stored levels above and one chunk buffer.
+func estimateLowMemory(numLeaves, levels int, hashLen uint64) uint64 {
+	var (
+		n      = uint64(numLeaves)
+		stored = 2*(n>>levels) + 2
+	)
+
+	return (n+stored)*(sliceHeaderSize+hashLen) + (1<<levels)*sliceHeaderSize
+}
+
+// estimateMemory estimates the memory allocated for numLeaves leaves of hashLen bytes in the given mode.
+func estimateMemory(mode TypeConfigMode, numLeaves int, hashLen uint64) uint64 {
+	var (
+		n     = uint64(numLeaves)
+		depth = uint64(bits.Len(uint(numLeaves - 1)))
+		// Leaves and every interior node hash: a full binary tree has fewer than 2n nodes.
+		total = n*(sliceHeaderSize+hashLen) + n*hashLen
+	)
+
+	if mode == ModeProofGen || mode == ModeProofGenAndTreeBuild {
+		// Proof pointers, proof structs and their sibling slice headers.
+		total += n * (pointerSize + proofSize + depth*sliceHeaderSize)
+	}
+
+	if mode == ModeProofGen {
+		// Working buffer over the leaves.
+		total += (n + 1) * sliceHeaderSize
+	}
+
+	if mode == ModeTreeBuild || mode == ModeProofGenAndTreeBuild || mode == ModeLazy {
+		// Node levels and the leaf map, built on demand in ModeLazy.
+		total += 2*n*sliceHeaderSize + depth*sliceHeaderSize
+		total += n * (leafMapEntrySize + hashLen)
+	}
+
+	return total
+}
+
+// checkMemoryBudget returns a MemoryBudgetError if the estimated memory exceeds MaxMemoryBytes.
+func (m *MerkleTree) checkMemoryBudget() error {
+	if m.MaxMemoryBytes == 0 {
+		return nil
+	}
+
+	estimated, err := m.EstimateMemory(m.NumLeaves)
+	if err != nil {
+		return err
+	}
+
+	if estimated > m.MaxMemoryBytes {
+		return &MemoryBudgetError{Estimated: estimated, Budget: m.MaxMemoryBytes}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMerkleTreeNew_maxMemoryBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    TypeConfigMode
+		budget  uint64
+		wantErr bool
+	}{
+		{
+			name:   "test_proof_gen_within_budget",
+			mode:   ModeProofGen,
+			budget: 1 << 30,
+		},
+		{
+			name:    "test_proof_gen_exceeds_budget",
+			mode:    ModeProofGen,
+			budget:  1 << 10,
+			wantErr: true,
+		},
+		{
+			name:    "test_tree_build_exceeds_budget",
+			mode:    ModeTreeBuild,
+			budget:  1 << 10,
+			wantErr: true,
+		},
+		{
+			name:   "test_no_budget",
+			mode:   ModeProofGenAndTreeBuild,
+			budget: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&Config{Mode: tt.mode, MaxMemoryBytes: tt.budget}, mockDataBlocks(100))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				return
+			}
+			if !errors.Is(err, ErrMemoryBudgetExceeded) {
+				t.Errorf("New() error = %v, want ErrMemoryBudgetExceeded", err)
+			}
+			var budgetErr *MemoryBudgetError
+			if !errors.As(err, &budgetErr) || budgetErr.Budget != tt.budget || budgetErr.Estimated <= tt.budget {
+				t.Errorf("New() error = %#v, want *MemoryBudgetError over budget %d", err, tt.budget)
+			}
+		})
+	}
+}
+
+func TestConfig_EstimateMemory(t *testing.T) {
+	proofGen, err := (&Config{Mode: ModeProofGen}).EstimateMemory(1000)
+	if err != nil {
+		t.Fatalf("EstimateMemory() error = %v", err)
+	}
+	treeBuild, err := (&Config{Mode: ModeTreeBuild}).EstimateMemory(1000)
+	if err != nil {
+		t.Fatalf("EstimateMemory() error = %v", err)
+	}
+	both, err := (&Config{Mode: ModeProofGenAndTreeBuild}).EstimateMemory(1000)
+	if err != nil {
+		t.Fatalf("EstimateMemory() error = %v", err)
+	}
+	if both <= proofGen || both <= treeBuild {
+		t.Errorf("EstimateMemory() proof gen and tree build %d should exceed proof gen %d and tree build %d",
+			both, proofGen, treeBuild)
+	}
+	_, err = (&Config{HashFunc: func([]byte) ([]byte, error) {
+		return nil, errors.New("hash func error")
+	}}).EstimateMemory(1000)
+	if err == nil {
+		t.Errorf("EstimateMemory() expected hash func error")
+	}
+}
@@ -26,11 +26,13 @@
 package merkletree
 
 import (
-	"bytes"
-	"math/big"
+	"crypto/sha256"
 	"math/bits"
 	"runtime"
 	"sync"
+	"time"
+
+	"github.com/txaty/go-merkletree/verifier"
 )
 
 const (
@@ -40,6 +42,14 @@ const (
 	ModeTreeBuild
 	// ModeProofGenAndTreeBuild is the proof generation and tree building configuration mode.
 	ModeProofGenAndTreeBuild
+	// ModeLazy is the lazy configuration mode: only the leaves and the root are computed by New,
+	// and the tree structure is built on the first operation requiring it (Proof, WriteTo, etc.), then cached.
+	// Builds that only read the root skip the proof assembly of ModeProofGen.
+	ModeLazy
+	// ModeLowMemory is the low-memory proof generation mode: only the leaves, the root and the nodes of the levels
+	// above RecomputeLevels are stored, and the lower siblings of each proof are recomputed from the leaves
+	// on demand, trading CPU for the peak memory of ModeProofGen on very large trees.
+	ModeLowMemory
 )
 
 // TypeConfigMode is the type in the Merkle Tree configuration indicating what operations are performed.
@@ -54,6 +64,16 @@ type typeConcatHashFunc func([]byte, []byte) []byte
 type Config struct {
 	// Customizable hash function used for tree generation.
 	HashFunc TypeHashFunc
+	// LeafHashFunc is the optional hash function of the leaves, HashFunc if nil.
+	// It is ignored if LeafKey is set.
+	LeafHashFunc TypeHashFunc
+	// NodeHashFunc is the optional hash function of the interior nodes and the root, HashFunc if nil,
+	// e.g. SHA-256 interior nodes over leaves hashed with a faster LeafHashFunc.
+	NodeHashFunc TypeHashFunc
+	// PositionalHashFunc is the optional position-aware hash function of the interior nodes and the root.
+	// If set, it replaces NodeHashFunc and mixes the level and index of each node into its hash, as required
+	// by some protocols, see PositionPrefixed. Proofs are verified with the same configuration.
+	PositionalHashFunc TypePositionalHashFunc
 	// Number of goroutines run in parallel.
 	// If RunInParallel is true and NumRoutine is set to 0, use number of CPU as the number of goroutines.
 	NumRoutines int
@@ -67,6 +87,126 @@ type Config struct {
 	SortSiblingPairs bool
 	// If true, the leaf nodes are NOT hashed before being added to the Merkle Tree.
 	DisableLeafHashing bool
+	// MaxMemoryBytes is the memory budget of the build in bytes. If it is greater than 0, New estimates
+	// the memory required by the configured mode before allocating and fails fast with a
+	// *MemoryBudgetError if the estimate exceeds the budget.
+	MaxMemoryBytes uint64
+	// LeafCache is an optional cache of leaf hashes shared across builds.
+	// If set, data blocks already hashed by a previous build are not re-serialized and re-hashed.
+	LeafCache LeafCache
+	// LeafKey is the optional key of the leaf hash. If set, leaves are hashed with LeafKeyedHashFunc
+	// (HMAC-SHA256 by default) while interior nodes are not keyed, so that commitments over low-entropy
+	// data blocks resist brute-force dictionary reversal by proof recipients.
+	LeafKey []byte
+	// LeafKeyedHashFunc is the keyed hash function used for leaves when LeafKey is set.
+	LeafKeyedHashFunc TypeKeyedHashFunc
+	// NumShards is the number of shards of parallel ModeProofGen builds. If it is greater than 1,
+	// the leaf range is split into NumShards contiguous subtrees, each hashed from the data blocks up to
+	// its root by its own group of NumRoutines/NumShards goroutines locked to their OS threads,
+	// before their roots are merged. Combined with ShardInit pinning each group to a NUMA node,
+	// this minimizes cross-socket memory traffic on very large builds.
+	NumShards int
+	// ShardInit is called with the shard index by every goroutine of a shard, on its locked OS thread,
+	// e.g. to set the CPU affinity of the thread to the NUMA node of the shard.
+	ShardInit func(shard int)
+	// CheckpointDir is the optional directory where New checkpoints the stripes of leaves and the levels
+	// of the tree as they complete, so that an interrupted build can be resumed with ResumeBuild.
+	// The checkpoint is removed once the build completes.
+	CheckpointDir string
+	// FaultInjector is the optional hook corrupting the nodes and proofs of the tree, for testing the failure
+	// handling of downstream systems. It must not be set in production.
+	FaultInjector FaultInjector
+	// LeafLess is the optional canonical order of the leaves. If set, New sorts the data blocks by their leaves
+	// (the serialized data blocks if DisableLeafHashing is true), so that independent builders of a set
+	// commitment agree on the leaf positions whatever the order of their data blocks, see LeafLessBytes.
+	// Trees locating their data blocks by input position, such as MapTree, fail with ErrBlockOrderUnsupported.
+	LeafLess func(a, b []byte) bool
+	// MaxLeaves is the maximum number of leaves of the tree if it is greater than 0. Builds over more data blocks
+	// fail with a *LimitError matching ErrTooManyLeaves before allocating, to bound the resources used by
+	// services accepting untrusted block lists.
+	MaxLeaves int
+	// MaxDepth is the maximum depth of the tree if it is greater than 0. Deeper builds fail with a *LimitError
+	// matching ErrTreeTooDeep before allocating.
+	MaxDepth int
+	// RecomputeLevels is the number of lowest levels of ModeLowMemory trees whose nodes are not stored but
+	// recomputed from 2^RecomputeLevels leaves for each proof. Half of the depth is used if it is 0,
+	// balancing the stored nodes and the hashes per proof around the square root of the number of leaves.
+	RecomputeLevels int
+	// DeduplicateLeaves, if true, makes identical leaves share a single copy of their hash, with the number
+	// of their positions counted, to reduce the memory of trees over sparse data with many identical blocks.
+	// Positions and proofs are unchanged. Sharded and checkpointed builds, which hash the leaves while
+	// building, do not deduplicate.
+	DeduplicateLeaves bool
+	// StrictVerification, if true, makes verification reject structurally anomalous proofs with
+	// verifier.ErrMalformedProof instead of folding them: empty leaves or siblings, interior siblings of
+	// different lengths and path bits set beyond the siblings, see verifier.CheckProof.
+	StrictVerification bool
+	// ExpectedNumLeaves is the number of leaves of the tree the proofs are verified against. If it is not 0,
+	// strict verification also rejects proofs whose number of siblings is not the depth of the tree, which
+	// prove a leaf past the last one, or whose padding siblings are not duplicates of the proven nodes.
+	// MerkleTree.Verify uses the number of leaves of the tree if it is 0.
+	ExpectedNumLeaves int
+	// RejectAmbiguousTrees, if true, makes New fail with ErrAmbiguousTree for leaves whose root is also the
+	// root of a tree over fewer leaves, see MerkleTree.CheckAmbiguity. ModeLazy trees are materialized.
+	RejectAmbiguousTrees bool
+	// BindLeafCount, if true, makes the commitment of the tree its root bound to its number of leaves,
+	// see SizeBoundRoot: verification checks proofs against size-bound roots of trees of ExpectedNumLeaves
+	// leaves, which is required. Root is left unbound, the commitment is returned by MerkleTree.SizeBoundRoot.
+	BindLeafCount bool
+	// TimestampLeaves, if true, commits the timestamp of every data block, which must implement
+	// TimestampedDataBlock, into its leaf: the leaf is computed over the timestamp (Unix nanoseconds,
+	// int64 big-endian) followed by the serialized data block. Verification with this configuration
+	// commits the timestamps of the verified data blocks likewise. See MerkleTree.ProofsInRange.
+	TimestampLeaves bool
+	// LeafWidth, if greater than 0, is the width in bytes the serialized data blocks are fitted to before
+	// hashing, or used as leaves if DisableLeafHashing is true, for circuits and contracts assuming
+	// fixed-width leaves, e.g. 32 bytes. New fails with a *LeafWidthError listing all the data blocks that do
+	// not fit with LeafWidthPolicy. Verification with this configuration fits the data blocks likewise.
+	LeafWidth int
+	// LeafWidthPolicy is the policy fitting the serialized data blocks to LeafWidth: LeafWidthPadRight,
+	// the default, LeafWidthPadLeft, LeafWidthExact or LeafWidthTruncate.
+	LeafWidthPolicy TypeLeafWidthPolicy
+	// NodeVisitor, if set, is called with the level, index and hash of every interior node and of the root
+	// as they are computed by the build, e.g. to index or persist them while the tree is built. Levels start
+	// at 1 above the leaves. With RunInParallel, it is called from several goroutines concurrently.
+	// ModeLazy trees visit their interior nodes once, when their root is computed; nodes recomputed later,
+	// to materialize them or to generate ModeLowMemory proofs, and nodes resumed from checkpoints are not visited.
+	// Trees built with DeferProofs visit their interior nodes again when their proofs are generated.
+	// Nodes patched by MerkleTree.Rebuild are visited again.
+	NodeVisitor func(level, index int, hash []byte)
+	// ProofSiblingOrder is the order of the siblings of the serialized proofs of the tree, see
+	// MerkleTree.SerializedProof: SiblingsLeafFirst, the default, or SiblingsRootFirst.
+	ProofSiblingOrder SiblingOrder
+	// SkipNilBlocks, if true, makes New skip the nil data blocks, including nil pointers, so that the leaf
+	// indexes are the indexes of the remaining data blocks. Otherwise, New fails with a *BlockError
+	// matching ErrDataBlockIsNil. See ValidateBlocks to report all the problems of the data blocks at once.
+	// Trees locating their data blocks by input position, such as MapTree, fail with ErrBlockOrderUnsupported.
+	SkipNilBlocks bool
+	// CollectLeafErrors, if true, makes New attempt every leaf and fail with the errors of all the data blocks
+	// whose serialization or hashing fails, each a *BlockError locating the data block, joined in leaf order,
+	// instead of failing at the first one, so that all the bad records are fixed in one pass. Sharded and
+	// checkpointed builds, which hash the leaves while building, fail at the first error.
+	CollectLeafErrors bool
+	// RetainBlocks, if true, makes New keep a reference to the data blocks of the leaves, in leaf order,
+	// so that MerkleTree.Rebuild re-serializes the data blocks mutated in place.
+	RetainBlocks bool
+	// ProofSink, if set, receives the proofs of ModeProofGen trees as they are generated instead of Proofs,
+	// capping the memory of builds exporting all their proofs: only the leaves, the levels above
+	// RecomputeLevels and one chunk of proofs per goroutine are held, as in ModeLowMemory, from which
+	// the proofs of the built tree are recomputed. With RunInParallel, WriteProof is called from several
+	// goroutines concurrently, each writing the proofs of a chunk in leaf order.
+	ProofSink ProofSink
+	// DeferProofs, if true, makes New compute only the leaves and the root of ModeProofGen trees, leaving Proofs
+	// nil until MerkleTree.GenerateProofs is called, for builds whose proofs are often thrown away. Methods
+	// serving proofs generate them first. It is ignored with ProofSink.
+	DeferProofs bool
+	// OnProofsInvalidated, if set, is called when a mutation of the tree, such as MerkleTree.Rebuild, changes
+	// its root, with the sorted indexes of the changed leaves. Every proof generated before then proves
+	// the old root, as each proof includes a node above a changed leaf, so caches of proofs must drop them.
+	OnProofsInvalidated func(changedIndices []int)
+	// OnRootChanged, if set, is called after OnProofsInvalidated when a mutation of the tree changes its root,
+	// with the old and the new root, e.g. to publish the new root. The tree is already updated.
+	OnRootChanged func(oldRoot, newRoot []byte)
 }
 
 // MerkleTree implements the Merkle Tree data structure.
@@ -77,14 +217,25 @@ type MerkleTree struct {
 	leafMap map[string]int
 	// leafMapMu is a mutex that protects concurrent access to the leafMap.
 	leafMapMu sync.Mutex
-	// concatHashFunc is the function for concatenating two hashes.
-	// If SortSiblingPairs in Config is true, then the sibling pairs are first sorted and then concatenated,
-	// supporting the OpenZeppelin Merkle Tree protocol.
-	// Otherwise, the sibling pairs are concatenated directly.
-	concatHashFunc typeConcatHashFunc
 	// nodes contains the Merkle Tree's internal node structure.
 	// It is only available when the configuration mode is set to ModeTreeBuild or ModeProofGenAndTreeBuild.
 	nodes [][][]byte
+	// lazyOnce materializes the tree structure of ModeLazy trees once, with lazyErr its error.
+	lazyOnce sync.Once
+	lazyErr  error
+	// proofsOnce generates the proofs of trees built with DeferProofs once, with proofsErr its error.
+	proofsOnce sync.Once
+	proofsErr  error
+	// topNodes are the stored levels from RecomputeLevels up to Depth-1 of ModeLowMemory trees.
+	topNodes [][][]byte
+	// leafRefs maps the unique leaves to their shared copy and number of positions if DeduplicateLeaves is set.
+	leafRefs map[string]*leafRef
+	// meta holds the metadata of the leaves whose data blocks implement MetaDataBlock, nil if there is none.
+	meta []any
+	// timestamps holds the timestamps of the leaves if TimestampLeaves is set.
+	timestamps []time.Time
+	// blocks are the data blocks of the leaves if RetainBlocks is set, see Rebuild.
+	blocks []DataBlock
 	// Root is the hash of the Merkle root node.
 	Root []byte
 	// Leaves are the hashes of the data blocks that form the Merkle Tree's leaves.
@@ -102,47 +253,142 @@ type MerkleTree struct {
 
 // New generates a new Merkle Tree with the specified configuration and data blocks.
 func New(config *Config, blocks []DataBlock) (m *MerkleTree, err error) {
+	var indexes []int
+	if blocks, indexes, err = checkNilBlocks(blocks, config != nil && config.SkipNilBlocks); err != nil {
+		return nil, err
+	}
+
+	if indexes != nil {
+		// The errors locate the data blocks in the input slice, not among the kept ones.
+		defer func() {
+			if err != nil {
+				restoreBlockIndexes(err, indexes)
+			}
+		}()
+	}
+
 	// Check if there are enough data blocks to build the tree.
 	if len(blocks) <= 1 {
 		return nil, ErrInvalidNumOfDataBlocks
 	}
 
-	// Initialize the configuration if it is not provided.
-	if config == nil {
-		config = new(Config)
+	if m, err = newMerkleTree(config, len(blocks)); err != nil {
+		return nil, err
 	}
 
-	// Create a MerkleTree with the provided configuration.
-	m = &MerkleTree{
-		Config:    config,
-		NumLeaves: len(blocks),
-		Depth:     bits.Len(uint(len(blocks) - 1)),
+	if m.TimestampLeaves {
+		if blocks, err = commitTimestamps(blocks); err != nil {
+			return nil, err
+		}
 	}
 
-	// Hash concatenation function initialization.
-	if m.concatHashFunc == nil {
-		if m.SortSiblingPairs {
-			m.concatHashFunc = concatSortHash
-		} else {
-			m.concatHashFunc = concatHash
+	if m.LeafWidth > 0 {
+		if blocks, err = m.fitBlocks(blocks); err != nil {
+			return nil, err
 		}
 	}
 
-	// Perform actions based on the configured mode.
-	// Set the mode to ModeProofGen by default if not specified.
-	if m.Mode == 0 {
-		m.Mode = ModeProofGen
+	if m.LeafLess != nil {
+		if blocks, err = m.orderBlocks(blocks); err != nil {
+			return nil, err
+		}
+	}
+
+	m.meta = collectLeafMeta(blocks)
+	if m.RetainBlocks {
+		m.blocks = blocks
+	}
+
+	if m.TimestampLeaves {
+		m.timestamps = collectTimestamps(blocks)
+	}
+
+	switch {
+	case m.CheckpointDir != "":
+		err = m.buildCheckpointed(blocks, false)
+	case m.RunInParallel:
+		err = m.newParallel(blocks)
+	default:
+		err = m.new(blocks)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if m.RejectAmbiguousTrees {
+		if err := m.CheckAmbiguity(); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.FaultInjector != nil {
+		m.injectFaults()
+	}
+
+	return m, nil
+}
+
+// newFromLeaves generates a new Merkle Tree with the specified configuration over already computed leaves.
+func newFromLeaves(config *Config, leaves [][]byte) (m *MerkleTree, err error) {
+	if len(leaves) <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	if m, err = newMerkleTree(config, len(leaves)); err != nil {
+		return nil, err
 	}
 
+	m.Leaves = leaves
+
 	if m.RunInParallel {
-		if err := m.newParallel(blocks); err != nil {
+		m.initParallel()
+		err = m.buildParallel()
+	} else {
+		m.init()
+		err = m.build()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if m.RejectAmbiguousTrees {
+		if err := m.CheckAmbiguity(); err != nil {
 			return nil, err
 		}
+	}
+
+	return m, nil
+}
+
+// newMerkleTree creates a MerkleTree for numLeaves leaves with the provided configuration, without building it.
+func newMerkleTree(config *Config, numLeaves int) (*MerkleTree, error) {
+	// Initialize the configuration if it is not provided.
+	if config == nil {
+		config = new(Config)
+	}
+
+	// Enforce the size limits before allocating anything.
+	if err := config.checkLimits(numLeaves); err != nil {
+		return nil, err
+	}
+
+	// Create a MerkleTree with the provided configuration.
+	m := &MerkleTree{
+		Config:    config,
+		NumLeaves: numLeaves,
+		Depth:     bits.Len(uint(numLeaves - 1)),
+	}
 
-		return m, nil
+	// Perform actions based on the configured mode.
+	// Set the mode to ModeProofGen by default if not specified.
+	if m.Mode == 0 {
+		m.Mode = ModeProofGen
 	}
 
-	if err := m.new(blocks); err != nil {
+	// Fail fast if the build would exceed the memory budget.
+	if err := m.checkMemoryBudget(); err != nil {
 		return nil, err
 	}
 
@@ -150,23 +396,53 @@ func New(config *Config, blocks []DataBlock) (m *MerkleTree, err error) {
 }
 
 func (m *MerkleTree) new(blocks []DataBlock) error {
+	m.init()
+
+	// Generate leaves, unless they were computed to order them.
+	if m.Leaves == nil {
+		var err error
+		if m.Leaves, err = m.computeLeafNodes(blocks); err != nil {
+			return err
+		}
+	}
+
+	return m.build()
+}
+
+// init initializes the configuration defaults of sequential runs.
+func (m *MerkleTree) init() {
 	// Initialize the hash function.
 	if m.HashFunc == nil {
 		m.HashFunc = DefaultHashFunc
 	}
+}
+
+// build builds the tree over the leaves according to the configured mode.
+func (m *MerkleTree) build() error {
+	if m.DeduplicateLeaves {
+		m.dedupLeaves()
+	}
 
-	// Generate leaves.
-	var err error
-	m.Leaves, err = m.computeLeafNodes(blocks)
+	if m.Mode == ModeProofGen && m.ProofSink != nil {
+		return m.sinkBuild()
+	}
 
-	if err != nil {
-		return err
+	if m.Mode == ModeProofGen && m.DeferProofs {
+		return m.lazyBuild()
 	}
 
 	if m.Mode == ModeProofGen {
 		return m.proofGen()
 	}
 
+	if m.Mode == ModeLazy {
+		return m.lazyBuild()
+	}
+
+	if m.Mode == ModeLowMemory {
+		return m.lowMemoryBuild()
+	}
+
 	// Initialize the leafMap for ModeTreeBuild and ModeProofGenAndTreeBuild.
 	m.leafMap = make(map[string]int)
 
@@ -184,6 +460,26 @@ func (m *MerkleTree) new(blocks []DataBlock) error {
 }
 
 func (m *MerkleTree) newParallel(blocks []DataBlock) error {
+	m.initParallel()
+
+	// Sharded builds hash the leaves within their shard.
+	if m.NumShards > 1 && m.Mode == ModeProofGen && m.ProofSink == nil && !m.DeferProofs {
+		return m.proofGenSharded(blocks)
+	}
+
+	// Generate leaves, unless they were computed to order them.
+	if m.Leaves == nil {
+		var err error
+		if m.Leaves, err = m.computeLeafNodesParallel(blocks); err != nil {
+			return err
+		}
+	}
+
+	return m.buildParallel()
+}
+
+// initParallel initializes the configuration defaults of parallel runs.
+func (m *MerkleTree) initParallel() {
 	// Initialize the hash function.
 	if m.HashFunc == nil {
 		m.HashFunc = DefaultHashFuncParallel
@@ -193,19 +489,34 @@ func (m *MerkleTree) newParallel(blocks []DataBlock) error {
 	if m.NumRoutines <= 0 {
 		m.NumRoutines = runtime.NumCPU()
 	}
+}
 
-	// Generate leaves.
-	var err error
-	m.Leaves, err = m.computeLeafNodesParallel(blocks)
+// buildParallel builds the tree over the leaves in parallel according to the configured mode.
+func (m *MerkleTree) buildParallel() error {
+	if m.DeduplicateLeaves {
+		m.dedupLeaves()
+	}
 
-	if err != nil {
-		return err
+	if m.Mode == ModeProofGen && m.ProofSink != nil {
+		return m.sinkBuild()
+	}
+
+	if m.Mode == ModeProofGen && m.DeferProofs {
+		return m.lazyBuild()
 	}
 
 	if m.Mode == ModeProofGen {
 		return m.proofGenParallel()
 	}
 
+	if m.Mode == ModeLazy {
+		return m.lazyBuild()
+	}
+
+	if m.Mode == ModeLowMemory {
+		return m.lowMemoryBuild()
+	}
+
 	// Initialize the leafMap for ModeTreeBuild and ModeProofGenAndTreeBuild.
 	m.leafMap = make(map[string]int)
 
@@ -222,11 +533,66 @@ func (m *MerkleTree) newParallel(blocks []DataBlock) error {
 	return ErrInvalidConfigMode
 }
 
+// nodeHashFunc returns the hash function of the interior nodes.
+func (c *Config) nodeHashFunc() TypeHashFunc {
+	if c.NodeHashFunc != nil {
+		return c.NodeHashFunc
+	}
+
+	return c.HashFunc
+}
+
+// hashNode hashes the concatenation of two children into their parent node at the given index of the given
+// level, with PositionalHashFunc if set.
+func (c *Config) hashNode(level, index int, data []byte) ([]byte, error) {
+	if c.PositionalHashFunc != nil {
+		return c.PositionalHashFunc(level, index, data)
+	}
+
+	return c.nodeHashFunc()(data)
+}
+
+// typeChildrenHashFunc is the signature of the functions hashing two children into their parent node
+// at the given index of the given level, see Config.hashChildren.
+type typeChildrenHashFunc func(level, index int, left, right []byte) ([]byte, error)
+
+// hashChildren combines two children and hashes them into their parent node as hashNode. Children of at most
+// 32 bytes hashed with the SHA-256 functions of the library are combined on the stack and streamed into
+// the digest, without allocating their combination.
+func (c *Config) hashChildren(level, index int, left, right []byte) ([]byte, error) {
+	if len(left) > sha256.Size || len(right) > sha256.Size || c.PositionalHashFunc != nil ||
+		!isSHA256(c.nodeHashFunc()) {
+		return c.hashNode(level, index, c.combineChildren(nil, left, right))
+	}
+
+	var buf [sha256.Size + 1]byte
+	node := sha256.Sum256(c.combineChildren(buf[:0], left, right))
+
+	return node[:], nil
+}
+
+// buildChildren hashes a node as hashChildren while building the tree, and passes it to NodeVisitor if set.
+func (c *Config) buildChildren(level, index int, left, right []byte) ([]byte, error) {
+	node, err := c.hashChildren(level, index, left, right)
+	if err == nil && c.NodeVisitor != nil {
+		c.NodeVisitor(level, index, node)
+	}
+
+	return node, err
+}
+
+// combineChildren appends the combination of two children to dst, see concatHash and concatSortHash.
+func (c *Config) combineChildren(dst, left, right []byte) []byte {
+	if c.SortSiblingPairs {
+		return verifier.AppendConcatSorted(dst, left, right)
+	}
+
+	return verifier.AppendConcat(dst, left, right)
+}
+
+// concatHash combines two sibling hashes by big-endian integer addition, see verifier.Concat.
 func concatHash(b1, b2 []byte) []byte {
-	return new(big.Int).Add(
-		new(big.Int).SetBytes(b1),
-		new(big.Int).SetBytes(b2),
-	).Bytes()
+	return verifier.Concat(b1, b2)
 }
 
 // concatSortHash concatenates two byte slices, b1 and b2, in a sorted order.
@@ -234,9 +600,5 @@ func concatHash(b1, b2 []byte) []byte {
 // is placed before the larger one. This is used for compatibility with OpenZeppelin's
 // Merkle Proof verification implementation.
 func concatSortHash(b1, b2 []byte) []byte {
-	if bytes.Compare(b1, b2) < 0 {
-		return concatHash(b1, b2)
-	}
-
-	return concatHash(b2, b1)
+	return verifier.ConcatSorted(b1, b2)
 }
@@ -25,7 +25,10 @@ package merkletree
 import (
 	"bytes"
 	crand "crypto/rand"
+	"flag"
+	"fmt"
 	"math/rand"
+	"sync"
 	"testing"
 
 	"github.com/txaty/go-merkletree/mock"
@@ -241,3 +244,162 @@ func BenchmarkMerkleTreeNew_modeProofGenAndTreeBuildParallel(b *testing.B) {
 		}
 	}
 }
+
+// benchLarge enables the sweep benchmarks over more than one million leaves, which need several GB of memory.
+var benchLarge = flag.Bool("bench.large", false, "run sweep benchmarks with more than 1M leaves")
+
+// benchSweepSizes are the leaf counts covered by the sweep benchmarks.
+var benchSweepSizes = []int{2, 1 << 10, 1 << 16, 1 << 20, 10_000_000}
+
+// benchSweepRoutines are the numbers of goroutines covered by the parallel sweep benchmarks.
+var benchSweepRoutines = []int{1, 2, 4, 8, 16}
+
+func benchSweepBlocks(b *testing.B, size int) []DataBlock {
+	if size > 1<<20 && !*benchLarge {
+		b.Skipf("skipping %d leaves, run with -bench.large", size)
+	}
+	return mockDataBlocksFixedSize(size)
+}
+
+func BenchmarkSweep_New(b *testing.B) {
+	modes := []struct {
+		name string
+		mode TypeConfigMode
+	}{
+		{name: "proofGen", mode: ModeProofGen},
+		{name: "treeBuild", mode: ModeTreeBuild},
+		{name: "proofGenAndTreeBuild", mode: ModeProofGenAndTreeBuild},
+	}
+	for _, size := range benchSweepSizes {
+		for _, mode := range modes {
+			b.Run(fmt.Sprintf("leaves=%d/mode=%s", size, mode.name), func(b *testing.B) {
+				blocks := benchSweepBlocks(b, size)
+				config := &Config{Mode: mode.mode}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := New(config, blocks); err != nil {
+						b.Fatalf("New() error = %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkSweep_NewParallel(b *testing.B) {
+	for _, size := range benchSweepSizes {
+		for _, numRoutines := range benchSweepRoutines {
+			b.Run(fmt.Sprintf("leaves=%d/routines=%d", size, numRoutines), func(b *testing.B) {
+				blocks := benchSweepBlocks(b, size)
+				config := &Config{
+					RunInParallel: true,
+					NumRoutines:   numRoutines,
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := New(config, blocks); err != nil {
+						b.Fatalf("New() error = %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkSweep_Verify(b *testing.B) {
+	for _, size := range benchSweepSizes {
+		b.Run(fmt.Sprintf("leaves=%d", size), func(b *testing.B) {
+			blocks := benchSweepBlocks(b, size)
+			m, err := New(nil, blocks)
+			if err != nil {
+				b.Fatalf("New() error = %v", err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				idx := i % size
+				if _, err := m.Verify(blocks[idx], m.Proofs[idx]); err != nil {
+					b.Fatalf("Verify() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMerkleTreeNew_nodeVisitor(t *testing.T) {
+	blocks := mockDataBlocks(37)
+
+	ref, err := New(&Config{Mode: ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "test_proof_gen", config: &Config{}},
+		{name: "test_proof_gen_parallel", config: &Config{RunInParallel: true, NumRoutines: 4}},
+		{name: "test_tree_build", config: &Config{Mode: ModeTreeBuild}},
+		{name: "test_tree_build_parallel", config: &Config{Mode: ModeTreeBuild, RunInParallel: true, NumRoutines: 4}},
+		{name: "test_proof_gen_and_tree_build", config: &Config{Mode: ModeProofGenAndTreeBuild}},
+		{name: "test_lazy", config: &Config{Mode: ModeLazy}},
+		{name: "test_low_memory", config: &Config{Mode: ModeLowMemory, RecomputeLevels: 2}},
+		{name: "test_low_memory_parallel", config: &Config{Mode: ModeLowMemory, RunInParallel: true, NumRoutines: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				visited = make(map[[2]int][]byte)
+				repeats int
+			)
+
+			tt.config.NodeVisitor = func(level, index int, hash []byte) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if _, ok := visited[[2]int{level, index}]; ok {
+					repeats++
+				}
+
+				visited[[2]int{level, index}] = hash
+			}
+
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if repeats != 0 {
+				t.Errorf("NodeVisitor visited %d nodes more than once", repeats)
+			}
+
+			want := 1
+			for level := 1; level < ref.Depth; level++ {
+				want += levelSize(ref.NumLeaves, level)
+
+				for index := 0; index < levelSize(ref.NumLeaves, level); index++ {
+					if !bytes.Equal(visited[[2]int{level, index}], ref.nodes[level][index]) {
+						t.Fatalf("NodeVisitor() node (%d, %d) = %x, want %x", level, index,
+							visited[[2]int{level, index}], ref.nodes[level][index])
+					}
+				}
+			}
+
+			if len(visited) != want || !bytes.Equal(visited[[2]int{m.Depth, 0}], m.Root) {
+				t.Errorf("NodeVisitor visited %d nodes, want %d with the root", len(visited), want)
+			}
+
+			if _, err := m.proofByIndex(5); err != nil {
+				t.Fatalf("proofByIndex() error = %v", err)
+			}
+
+			if len(visited) != want || repeats != 0 {
+				t.Errorf("NodeVisitor visited nodes after the build")
+			}
+		})
+	}
+}
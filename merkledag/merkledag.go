@@ -0,0 +1,469 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package merkledag stores directory snapshots as a Merkle DAG: identical files and directories are stored
+// once and shared by reference between the directories and snapshots containing them.
+//
+// A file node is identified by the hash of its content. A directory node is identified by the root of the
+// Merkle Tree over a header committing to its number of entries followed by its entries, ordered by name,
+// each committing to the name, kind and hash of a child. Updating a path copies the directories along it
+// and shares every other node with the previous snapshot, which stays valid. Path proofs chain the
+// inclusion proofs of the entries along the path from a snapshot root.
+package merkledag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+var (
+	// ErrNodeNotFound is the error for a hash that is not stored.
+	ErrNodeNotFound = errors.New("node not found")
+	// ErrNotDir is the error for a path component that is not a directory.
+	ErrNotDir = errors.New("not a directory")
+	// ErrEntryNotFound is the error for a path that is not in the snapshot.
+	ErrEntryNotFound = errors.New("entry not found")
+	// ErrDuplicateEntry is the error for a directory with two entries of the same name.
+	ErrDuplicateEntry = errors.New("duplicate directory entry")
+	// ErrInvalidPath is the error for a path that is not a valid slash-separated relative path.
+	ErrInvalidPath = errors.New("invalid path")
+)
+
+// Kind is the kind of a node.
+type Kind uint8
+
+const (
+	// KindFile is the kind of file nodes.
+	KindFile Kind = iota + 1
+	// KindDir is the kind of directory nodes.
+	KindDir
+)
+
+// dirHeader is the kind byte of directory headers, distinct from the entry kinds.
+const dirHeader = 0
+
+// Entry is an entry of a directory: a named reference to a child node.
+type Entry struct {
+	Name string      `json:"name"`
+	Kind Kind        `json:"kind"`
+	Hash mt.HexBytes `json:"hash"`
+}
+
+// Serialize encodes the entry as its kind (uint8), the length of its name (uint32, big-endian), the name
+// and the hash of the child.
+func (e *Entry) Serialize() ([]byte, error) {
+	buf := make([]byte, 0, 5+len(e.Name)+len(e.Hash))
+	buf = append(buf, byte(e.Kind))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(e.Name)))
+	buf = append(buf, e.Name...)
+	buf = append(buf, e.Hash...)
+
+	return buf, nil
+}
+
+// header is the data block committing to the number of entries of a directory.
+type header int
+
+func (h header) Serialize() ([]byte, error) {
+	return binary.BigEndian.AppendUint64([]byte{dirHeader}, uint64(h)), nil
+}
+
+// node is a stored node.
+type node struct {
+	kind Kind
+	// data is the content of a file.
+	data []byte
+	// entries and tree are the entries of a directory and the tree over its header and entries,
+	// nil for an empty directory.
+	entries []Entry
+	tree    *mt.MerkleTree
+}
+
+// Store is a content-addressed store of file and directory nodes. It is safe for concurrent use.
+type Store struct {
+	config *mt.Config
+	mu     sync.RWMutex
+	nodes  map[string]*node
+}
+
+// NewStore creates an empty store hashing nodes with the configuration, whose mode is forced to
+// ModeProofGen so that path proofs can be served.
+func NewStore(config *mt.Config) *Store {
+	c := new(mt.Config)
+	if config != nil {
+		*c = *config
+	}
+
+	c.Mode = mt.ModeProofGen
+	if c.HashFunc == nil {
+		c.HashFunc = mt.DefaultHashFunc
+	}
+
+	return &Store{
+		config: c,
+		nodes:  make(map[string]*node),
+	}
+}
+
+// NumNodes returns the number of distinct nodes stored.
+func (s *Store) NumNodes() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.nodes)
+}
+
+// put stores the node under its hash, keeping the node already stored under it.
+func (s *Store) put(hash []byte, n *node) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.nodes[string(hash)]; !ok {
+		s.nodes[string(hash)] = n
+	}
+
+	return hash
+}
+
+// get returns the node stored under the hash.
+func (s *Store) get(hash []byte) (*node, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n, ok := s.nodes[string(hash)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %x", ErrNodeNotFound, hash)
+	}
+
+	return n, nil
+}
+
+// PutFile stores the file content and returns its hash.
+func (s *Store) PutFile(data []byte) ([]byte, error) {
+	hash, err := s.config.HashFunc(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.put(hash, &node{kind: KindFile, data: data}), nil
+}
+
+// File returns the content of the file node.
+func (s *Store) File(hash []byte) ([]byte, error) {
+	n, err := s.get(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.kind != KindFile {
+		return nil, fmt.Errorf("%w: %x is a directory", ErrEntryNotFound, hash)
+	}
+
+	return n.data, nil
+}
+
+// PutDir stores the directory of the entries and returns its hash. The children must be stored.
+// The configuration of the store must set neither LeafLess nor SkipNilBlocks, the entries being proven
+// by position.
+func (s *Store) PutDir(entries []Entry) ([]byte, error) {
+	if s.config.LeafLess != nil || s.config.SkipNilBlocks {
+		return nil, mt.ErrBlockOrderUnsupported
+	}
+
+	entries = append([]Entry(nil), entries...)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	for i := range entries {
+		if !validName(entries[i].Name) {
+			return nil, fmt.Errorf("%w: entry name %q", ErrInvalidPath, entries[i].Name)
+		}
+
+		if i > 0 && entries[i].Name == entries[i-1].Name {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateEntry, entries[i].Name)
+		}
+
+		child, err := s.get(entries[i].Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		if child.kind != entries[i].Kind {
+			return nil, fmt.Errorf("%w: entry %q has the wrong kind", ErrEntryNotFound, entries[i].Name)
+		}
+	}
+
+	// An empty directory has no tree, its hash is the hash of its header.
+	if len(entries) == 0 {
+		data, _ := header(0).Serialize()
+
+		hash, err := s.config.HashFunc(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.put(hash, &node{kind: KindDir}), nil
+	}
+
+	blocks := make([]mt.DataBlock, len(entries)+1)
+	blocks[0] = header(len(entries))
+
+	for i := range entries {
+		blocks[i+1] = &entries[i]
+	}
+
+	tree, err := mt.New(s.config, blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.put(tree.Root, &node{kind: KindDir, entries: entries, tree: tree}), nil
+}
+
+// Dir returns the entries of the directory node, ordered by name.
+func (s *Store) Dir(hash []byte) ([]Entry, error) {
+	n, err := s.get(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.kind != KindDir {
+		return nil, fmt.Errorf("%w: %x", ErrNotDir, hash)
+	}
+
+	return append([]Entry(nil), n.entries...), nil
+}
+
+// Snapshot stores the regular files and directories of fsys and returns the hash of its root directory.
+// Identical files and directories are stored once.
+func (s *Store) Snapshot(fsys fs.FS) ([]byte, error) {
+	return s.snapshotDir(fsys, ".")
+}
+
+func (s *Store) snapshotDir(fsys fs.FS, dir string) ([]byte, error) {
+	dirEntries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+
+	for _, d := range dirEntries {
+		var (
+			name = path.Join(dir, d.Name())
+			e    = Entry{Name: d.Name()}
+		)
+
+		switch {
+		case d.IsDir():
+			e.Kind = KindDir
+			e.Hash, err = s.snapshotDir(fsys, name)
+		case d.Type().IsRegular():
+			var data []byte
+			if data, err = fs.ReadFile(fsys, name); err == nil {
+				e.Kind = KindFile
+				e.Hash, err = s.PutFile(data)
+			}
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return s.PutDir(entries)
+}
+
+// Update returns the hash of the snapshot of root with the entry at the path set to the node of the kind
+// and hash, or removed if the hash is nil. The directories along the path are copied, created if they are
+// missing, and all other nodes are shared with root, which is left unchanged.
+func (s *Store) Update(root []byte, name string, kind Kind, hash []byte) ([]byte, error) {
+	components, err := splitPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.update(root, components, kind, hash)
+}
+
+func (s *Store) update(dir []byte, components []string, kind Kind, hash []byte) ([]byte, error) {
+	entries, err := s.Dir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Name >= components[0]
+	})
+	found := idx < len(entries) && entries[idx].Name == components[0]
+
+	if len(components) > 1 {
+		child := []byte(nil)
+
+		switch {
+		case found && entries[idx].Kind != KindDir:
+			return nil, fmt.Errorf("%w: %q", ErrNotDir, components[0])
+		case found:
+			child = entries[idx].Hash
+		default:
+			// Missing directories are created empty.
+			if child, err = s.PutDir(nil); err != nil {
+				return nil, err
+			}
+		}
+
+		if hash, err = s.update(child, components[1:], kind, hash); err != nil {
+			return nil, err
+		}
+
+		kind = KindDir
+	}
+
+	switch {
+	case hash == nil && !found:
+		return nil, fmt.Errorf("%w: %q", ErrEntryNotFound, components[0])
+	case hash == nil:
+		entries = append(entries[:idx], entries[idx+1:]...)
+	case found:
+		entries[idx] = Entry{Name: components[0], Kind: kind, Hash: hash}
+	default:
+		entries = append(entries, Entry{Name: components[0], Kind: kind, Hash: hash})
+	}
+
+	return s.PutDir(entries)
+}
+
+// PathStep is the step of a path proof through a directory: the entry of the next path component and its
+// inclusion proof in the directory tree.
+type PathStep struct {
+	Entry Entry               `json:"entry"`
+	Proof *mt.SerializedProof `json:"proof"`
+}
+
+// PathProof proves that a path of a snapshot resolves to a node.
+type PathProof struct {
+	Steps []PathStep `json:"steps"`
+}
+
+// Prove returns the proof that the path of the snapshot of root resolves to its node, and the hash of
+// the node.
+func (s *Store) Prove(root []byte, name string) (*PathProof, []byte, error) {
+	components, err := splitPath(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		proof = &PathProof{Steps: make([]PathStep, len(components))}
+		hash  = root
+	)
+
+	for i, component := range components {
+		n, err := s.get(hash)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if n.kind != KindDir {
+			return nil, nil, fmt.Errorf("%w: %q", ErrNotDir, path.Join(components[:i]...))
+		}
+
+		idx := sort.Search(len(n.entries), func(j int) bool {
+			return n.entries[j].Name >= component
+		})
+		if idx == len(n.entries) || n.entries[idx].Name != component {
+			return nil, nil, fmt.Errorf("%w: %q", ErrEntryNotFound, path.Join(components[:i+1]...))
+		}
+
+		// The header is the first leaf of the directory tree.
+		proof.Steps[i] = PathStep{Entry: n.entries[idx], Proof: mt.NewSerializedProof(n.tree.Proofs[idx+1])}
+		hash = n.entries[idx].Hash
+	}
+
+	return proof, hash, nil
+}
+
+// VerifyPath checks that the path of the snapshot of root resolves to the node of the hash, according to
+// the proof. The configuration must be the one of the store.
+func VerifyPath(proof *PathProof, root []byte, name string, hash []byte, config *mt.Config) (bool, error) {
+	if proof == nil {
+		return false, mt.ErrProofIsNil
+	}
+
+	components, err := splitPath(name)
+	if err != nil {
+		return false, err
+	}
+
+	if len(proof.Steps) != len(components) {
+		return false, nil
+	}
+
+	dir := root
+
+	for i, step := range proof.Steps {
+		// Every component but the last one must be a directory.
+		if step.Entry.Name != components[i] || (i < len(components)-1 && step.Entry.Kind != KindDir) {
+			return false, nil
+		}
+
+		if step.Proof == nil {
+			return false, mt.ErrProofIsNil
+		}
+
+		if ok, err := mt.Verify(&step.Entry, step.Proof.Proof(), dir, config); err != nil || !ok {
+			return false, err
+		}
+
+		dir = step.Entry.Hash
+	}
+
+	return bytes.Equal(dir, hash), nil
+}
+
+// splitPath splits the slash-separated relative path into its components.
+func splitPath(name string) ([]string, error) {
+	if name == "." || !fs.ValidPath(name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPath, name)
+	}
+
+	return strings.Split(name, "/"), nil
+}
+
+// validName reports whether the name is a valid path component.
+func validName(name string) bool {
+	return name != "." && !strings.Contains(name, "/") && fs.ValidPath(name)
+}
@@ -0,0 +1,186 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkledag
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"README":           {Data: []byte("readme")},
+		"v1/lib/a.go":      {Data: []byte("package a")},
+		"v1/lib/b.go":      {Data: []byte("package b")},
+		"v2/lib/a.go":      {Data: []byte("package a")},
+		"v2/lib/b.go":      {Data: []byte("package b")},
+		"v2/copy-of-a.go":  {Data: []byte("package a")},
+		"v2/empty/.keep":   {Data: []byte{}},
+		"assets/empty.txt": {Data: []byte{}},
+	}
+}
+
+func TestStore_Snapshot(t *testing.T) {
+	s := NewStore(nil)
+	root, err := s.Snapshot(testFS())
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	// Files: readme, a, b, empty. Directories: lib (shared by v1 and v2), v1, v2, v2/empty, assets, root.
+	if got := s.NumNodes(); got != 10 {
+		t.Errorf("NumNodes() = %d, want 10", got)
+	}
+	v1, _, err := s.Prove(root, "v1/lib")
+	if err != nil {
+		t.Fatalf("Prove() error = %v", err)
+	}
+	_, v2Hash, err := s.Prove(root, "v2/lib")
+	if err != nil {
+		t.Fatalf("Prove() error = %v", err)
+	}
+	if !bytes.Equal(v1.Steps[1].Entry.Hash, v2Hash) {
+		t.Errorf("identical directories v1/lib and v2/lib are not shared")
+	}
+	again, err := NewStore(nil).Snapshot(testFS())
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if !bytes.Equal(again, root) {
+		t.Errorf("Snapshot() = %x, want %x", again, root)
+	}
+}
+
+func TestVerifyPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *mt.Config
+	}{
+		{name: "test_default"},
+		{name: "test_sorted", config: &mt.Config{SortSiblingPairs: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStore(tt.config)
+			root, err := s.Snapshot(testFS())
+			if err != nil {
+				t.Fatalf("Snapshot() error = %v", err)
+			}
+			for name, file := range testFS() {
+				proof, hash, err := s.Prove(root, name)
+				if err != nil {
+					t.Fatalf("Prove(%s) error = %v", name, err)
+				}
+				data, err := s.File(hash)
+				if err != nil || !bytes.Equal(data, file.Data) {
+					t.Errorf("File(%s) = %q, %v, want %q", name, data, err, file.Data)
+				}
+				encoded, err := json.Marshal(proof)
+				if err != nil {
+					t.Fatalf("Marshal() error = %v", err)
+				}
+				proof = new(PathProof)
+				if err := json.Unmarshal(encoded, proof); err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				if ok, err := VerifyPath(proof, root, name, hash, tt.config); err != nil || !ok {
+					t.Errorf("VerifyPath(%s) = %t, %v, want true", name, ok, err)
+				}
+				if ok, _ := VerifyPath(proof, root, "v1/lib/c.go", hash, tt.config); ok {
+					t.Errorf("VerifyPath(%s) for another path = true, want false", name)
+				}
+			}
+		})
+	}
+}
+
+func TestStore_Update(t *testing.T) {
+	s := NewStore(nil)
+	root, err := s.Snapshot(testFS())
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	numNodes := s.NumNodes()
+	file, err := s.PutFile([]byte("package c"))
+	if err != nil {
+		t.Fatalf("PutFile() error = %v", err)
+	}
+	updated, err := s.Update(root, "v2/lib/a.go", KindFile, file)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	// Path copying adds the new file, v2/lib, v2 and the root.
+	if got := s.NumNodes(); got != numNodes+4 {
+		t.Errorf("NumNodes() = %d, want %d", got, numNodes+4)
+	}
+	proof, hash, err := s.Prove(updated, "v2/lib/a.go")
+	if err != nil || !bytes.Equal(hash, file) {
+		t.Fatalf("Prove() = %x, %v, want %x", hash, err, file)
+	}
+	if ok, err := VerifyPath(proof, updated, "v2/lib/a.go", file, nil); err != nil || !ok {
+		t.Errorf("VerifyPath() = %t, %v, want true", ok, err)
+	}
+	if ok, _ := VerifyPath(proof, root, "v2/lib/a.go", file, nil); ok {
+		t.Errorf("VerifyPath() against the previous snapshot = true, want false")
+	}
+	// The previous snapshot is unchanged and shares v1 with the new one.
+	_, old, err := s.Prove(root, "v2/lib/a.go")
+	if err != nil || bytes.Equal(old, file) {
+		t.Errorf("Prove() on the previous snapshot = %x, %v", old, err)
+	}
+	_, v1Old, _ := s.Prove(root, "v1")
+	_, v1New, _ := s.Prove(updated, "v1")
+	if !bytes.Equal(v1Old, v1New) {
+		t.Errorf("v1 is not shared between snapshots")
+	}
+
+	created, err := s.Update(updated, "new/dir/c.go", KindFile, file)
+	if err != nil {
+		t.Fatalf("Update() creating directories error = %v", err)
+	}
+	if _, hash, err := s.Prove(created, "new/dir/c.go"); err != nil || !bytes.Equal(hash, file) {
+		t.Errorf("Prove() = %x, %v, want %x", hash, err, file)
+	}
+	removed, err := s.Update(created, "new/dir/c.go", KindFile, nil)
+	if err != nil {
+		t.Fatalf("Update() removing error = %v", err)
+	}
+	if _, _, err := s.Prove(removed, "new/dir/c.go"); !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("Prove() of a removed path error = %v, want %v", err, ErrEntryNotFound)
+	}
+	if _, err := s.Update(root, "README/x", KindFile, file); !errors.Is(err, ErrNotDir) {
+		t.Errorf("Update() under a file error = %v, want %v", err, ErrNotDir)
+	}
+}
+
+func TestStore_blockPositions(t *testing.T) {
+	for _, config := range []*mt.Config{{LeafLess: mt.LeafLessBytes}, {SkipNilBlocks: true}} {
+		if _, err := NewStore(config).Snapshot(testFS()); !errors.Is(err, mt.ErrBlockOrderUnsupported) {
+			t.Errorf("Snapshot() error = %v, want ErrBlockOrderUnsupported", err)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package merklehttp provides net/http middleware rejecting requests that do not carry a valid
+// Merkle proof, the gateway pattern of token-gated APIs.
+//
+// A request proves the inclusion of some data (e.g. the caller address) with either
+//   - the X-Merkle-Data header (hexadecimal data) and the X-Merkle-Proof header
+//     (the JSON proof format of the merkletree package, optionally base64-encoded), or
+//   - a JSON body {"data": "0x...", "proof": {...}}, which is passed on unchanged to the next handler.
+package merklehttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+const (
+	// HeaderProof is the header carrying the JSON proof.
+	HeaderProof = "X-Merkle-Proof"
+	// HeaderData is the header carrying the hexadecimal proven data.
+	HeaderData = "X-Merkle-Data"
+	// maxBodySize bounds the body read to extract a proof.
+	maxBodySize = 1 << 20
+)
+
+var (
+	// ErrProofMissing is the error for a request without proof.
+	ErrProofMissing = errors.New("merklehttp: request carries no merkle proof")
+	// ErrProofInvalid is the error for a request whose proof does not verify against the current root.
+	ErrProofInvalid = errors.New("merklehttp: invalid merkle proof")
+)
+
+// RootSource provides the root proofs are verified against.
+type RootSource interface {
+	CurrentRoot(ctx context.Context) ([]byte, error)
+}
+
+// StaticRoot is a RootSource always returning the same root.
+type StaticRoot []byte
+
+// CurrentRoot returns the root.
+func (r StaticRoot) CurrentRoot(context.Context) ([]byte, error) {
+	return r, nil
+}
+
+// Body is the JSON request body carrying a proof.
+type Body struct {
+	Data  mt.HexBytes         `json:"data"`
+	Proof *mt.SerializedProof `json:"proof"`
+}
+
+// Options configures the middleware.
+type Options struct {
+	// Roots provides the root proofs are verified against. Required.
+	Roots RootSource
+	// Config is the tree configuration used for verification; nil uses the defaults.
+	Config *mt.Config
+	// ErrorHandler writes the response of rejected requests. By default, it replies
+	// 401 Unauthorized for missing proofs, 403 Forbidden for invalid ones and 500 otherwise.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type contextKey struct{}
+
+// dataBlock is the proven data as a data block.
+type dataBlock []byte
+
+// Serialize returns the data.
+func (d dataBlock) Serialize() ([]byte, error) {
+	return d, nil
+}
+
+// VerifiedData returns the data proven by the request, set by the middleware.
+func VerifiedData(ctx context.Context) ([]byte, bool) {
+	data, ok := ctx.Value(contextKey{}).([]byte)
+
+	return data, ok
+}
+
+// Middleware returns a middleware verifying the proof of every request before calling the next handler.
+// The proven data is available to the next handler with VerifiedData.
+func Middleware(opts Options) func(http.Handler) http.Handler {
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = defaultErrorHandler
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, err := Verify(r, opts.Roots, opts.Config)
+			if err != nil {
+				opts.ErrorHandler(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, data)))
+		})
+	}
+}
+
+// Verify extracts the proof of the request and verifies it against the current root,
+// returning the proven data. The request body, if read, is restored. A proof recording a leaf hashing
+// policy other than the one of the configuration is invalid, see mt.SerializedProof.CheckConfig.
+// Without hash function, the configuration uses DefaultHashFuncParallel, safe for concurrent requests.
+func Verify(r *http.Request, roots RootSource, config *mt.Config) ([]byte, error) {
+	body, err := extract(r)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := roots.CurrentRoot(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(mt.Config)
+	if config != nil {
+		*c = *config
+	}
+
+	if c.HashFunc == nil {
+		c.HashFunc = mt.DefaultHashFuncParallel
+	}
+
+	ok, err := mt.VerifySerialized(dataBlock(body.Data), body.Proof, root, c)
+	if errors.Is(err, mt.ErrLeafHashingMismatch) {
+		return nil, fmt.Errorf("%w: %w", ErrProofInvalid, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrProofInvalid
+	}
+
+	return body.Data, nil
+}
+
+// extract reads the proof and the data from the headers, or from the body.
+func extract(r *http.Request) (*Body, error) {
+	if proof := r.Header.Get(HeaderProof); proof != "" {
+		return extractHeaders(proof, r.Header.Get(HeaderData))
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, ErrProofMissing
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(raw))
+
+	body := new(Body)
+	if err := json.Unmarshal(raw, body); err != nil || body.Proof == nil {
+		return nil, ErrProofMissing
+	}
+
+	return body, nil
+}
+
+func extractHeaders(proof, data string) (*Body, error) {
+	raw := []byte(proof)
+	if !strings.HasPrefix(strings.TrimSpace(proof), "{") {
+		decoded, err := base64.StdEncoding.DecodeString(proof)
+		if err != nil {
+			return nil, ErrProofInvalid
+		}
+
+		raw = decoded
+	}
+
+	sp, err := mt.UnmarshalProof(raw)
+	if err != nil {
+		return nil, ErrProofInvalid
+	}
+
+	if data == "" {
+		return nil, ErrProofMissing
+	}
+
+	decoded, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(data, "0x"), "0X"))
+	if err != nil {
+		return nil, ErrProofInvalid
+	}
+
+	return &Body{Data: decoded, Proof: sp}, nil
+}
+
+func defaultErrorHandler(w http.ResponseWriter, _ *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrProofMissing):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, ErrProofInvalid):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
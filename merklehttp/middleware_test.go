@@ -0,0 +1,168 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merklehttp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+func TestMiddleware(t *testing.T) {
+	members := []mt.DataBlock{dataBlock("alice"), dataBlock("bob"), dataBlock("carol")}
+	tree, err := mt.New(nil, members)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	proof, err := mt.MarshalProof(tree.Proofs[1], nil)
+	if err != nil {
+		t.Fatalf("MarshalProof() error = %v", err)
+	}
+	body, err := json.Marshal(&Body{Data: []byte("bob"), Proof: mt.NewSerializedProof(tree.Proofs[1])})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	unhashed := mt.NewSerializedProof(tree.Proofs[1])
+	unhashed.LeafHashing = new(bool)
+	unhashedBody, err := json.Marshal(&Body{Data: []byte("bob"), Proof: unhashed})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	handler := Middleware(Options{Roots: StaticRoot(tree.Root)})(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			data, _ := VerifiedData(r.Context())
+			rest, _ := io.ReadAll(r.Body)
+			w.Write(append(data, rest...))
+		}))
+
+	tests := []struct {
+		name     string
+		header   map[string]string
+		body     []byte
+		want     int
+		wantBody []byte
+	}{
+		{
+			name:     "test_header_json",
+			header:   map[string]string{HeaderProof: string(proof), HeaderData: hex.EncodeToString([]byte("bob"))},
+			want:     http.StatusOK,
+			wantBody: []byte("bob"),
+		},
+		{
+			name:     "test_header_base64",
+			header:   map[string]string{HeaderProof: base64.StdEncoding.EncodeToString(proof), HeaderData: "0x" + hex.EncodeToString([]byte("bob"))},
+			want:     http.StatusOK,
+			wantBody: []byte("bob"),
+		},
+		{
+			name:     "test_body",
+			body:     body,
+			want:     http.StatusOK,
+			wantBody: append([]byte("bob"), body...),
+		},
+		{
+			name:   "test_wrong_data",
+			header: map[string]string{HeaderProof: string(proof), HeaderData: hex.EncodeToString([]byte("mallory"))},
+			want:   http.StatusForbidden,
+		},
+		{
+			name: "test_leaf_hashing_mismatch",
+			body: unhashedBody,
+			want: http.StatusForbidden,
+		},
+		{
+			name:   "test_missing_data",
+			header: map[string]string{HeaderProof: string(proof)},
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name: "test_missing_proof",
+			want: http.StatusUnauthorized,
+		},
+		{
+			name:   "test_malformed_proof",
+			header: map[string]string{HeaderProof: "not a proof", HeaderData: "00"},
+			want:   http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reqBody io.Reader
+			if tt.body != nil {
+				reqBody = bytes.NewReader(tt.body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/claim", reqBody)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.wantBody != nil && !bytes.Equal(rec.Body.Bytes(), tt.wantBody) {
+				t.Errorf("body = %s, want %s", rec.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestVerify_concurrent(t *testing.T) {
+	members := make([]mt.DataBlock, 64)
+	for i := range members {
+		members[i] = dataBlock{byte(i)}
+	}
+	tree, err := mt.New(nil, members)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	roots := StaticRoot(tree.Root)
+	var wg sync.WaitGroup
+	for w := 0; w < 16; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(members); i += 16 {
+				body, err := json.Marshal(&Body{Data: []byte{byte(i)}, Proof: mt.NewSerializedProof(tree.Proofs[i])})
+				if err != nil {
+					t.Errorf("Marshal() error = %v", err)
+					return
+				}
+				req := httptest.NewRequest(http.MethodPost, "/claim", bytes.NewReader(body))
+				if _, err := Verify(req, roots, nil); err != nil {
+					t.Errorf("Verify() %d error = %v", i, err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
@@ -1,12 +1,45 @@
-// Package mock provides a mock implementation of the DataBlock interface.
+// Package mock provides mock implementations of the DataBlock interface and of hash functions,
+// whose failures are injected by their fields, to exercise error paths without runtime patching.
 package mock
 
+import (
+	"crypto/sha256"
+	"sync/atomic"
+)
+
 // DataBlock is a mock implementation of the DataBlock interface.
 type DataBlock struct {
 	Data []byte
+	// Err is returned by Serialize if it is not nil.
+	Err error
 }
 
-// Serialize returns the serialized form of the DataBlock.
+// Serialize returns the serialized form of the DataBlock, or Err if it is set.
 func (t *DataBlock) Serialize() ([]byte, error) {
+	if t.Err != nil {
+		return nil, t.Err
+	}
+
 	return t.Data, nil
 }
+
+// HashFunc is a SHA-256 hash function failing with Err once it has hashed FailAfter inputs.
+// It is safe for concurrent use.
+type HashFunc struct {
+	// Err is returned by Hash once FailAfter inputs are hashed. Hash never fails if Err is nil.
+	Err error
+	// FailAfter is the number of inputs hashed successfully before failing.
+	FailAfter int64
+	calls     atomic.Int64
+}
+
+// Hash returns the SHA-256 digest of data, or Err once FailAfter inputs are hashed.
+func (h *HashFunc) Hash(data []byte) ([]byte, error) {
+	if h.Err != nil && h.calls.Add(1) > h.FailAfter {
+		return nil, h.Err
+	}
+
+	digest := sha256.Sum256(data)
+
+	return digest[:], nil
+}
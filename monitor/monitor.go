@@ -0,0 +1,352 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package monitor implements the monitor loop of a transparency log: it periodically fetches the latest
+// signed tree head of the log, verifies that the new tree extends the last verified one with a consistency
+// proof, optionally fetches and checks the appended entries, and persists the verified tree head.
+// Verification failures are reported to an alert callback.
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+var (
+	// ErrRollback is the error for a tree head smaller than the last verified one.
+	ErrRollback = errors.New("monitor: tree head is older than the verified tree head")
+	// ErrForked is the error for a tree head of the verified size with another root.
+	ErrForked = errors.New("monitor: tree head forks the verified tree")
+	// ErrInconsistent is the error for a tree head whose consistency proof with the verified tree head is invalid.
+	ErrInconsistent = errors.New("monitor: tree head is not consistent with the verified tree head")
+	// ErrInvalidEntries is the error for entries that do not extend the verified tree to the tree head.
+	ErrInvalidEntries = errors.New("monitor: entries do not match the tree head")
+)
+
+// Source serves the tree heads, consistency proofs and entries of a log.
+type Source interface {
+	// TreeHead returns the latest signed tree head.
+	TreeHead(ctx context.Context) (*mt.SignedTreeHead, error)
+	// ConsistencyProof returns the proof that the tree of newSize leaves extends the tree of oldSize leaves.
+	ConsistencyProof(ctx context.Context, oldSize, newSize uint64) (*mt.ConsistencyProof, error)
+	// Entries returns the entries start to end-1.
+	Entries(ctx context.Context, start, end uint64) ([]mt.DataBlock, error)
+}
+
+// Store persists the last verified tree head.
+type Store interface {
+	// Load returns the stored tree head, or nil if there is none.
+	Load(ctx context.Context) (*mt.SignedTreeHead, error)
+	// Save replaces the stored tree head.
+	Save(ctx context.Context, head *mt.SignedTreeHead) error
+}
+
+// Alert reports a verification failure: the tree head received from the source is not accepted.
+type Alert struct {
+	// Verified is the last verified tree head, nil before the first one.
+	Verified *mt.SignedTreeHead
+	// Received is the rejected tree head.
+	Received *mt.SignedTreeHead
+	// Err is the reason, e.g. ErrInconsistent or mt.ErrTreeHeadSignature.
+	Err error
+}
+
+// Monitor verifies the growth of a log. It is safe for concurrent use, but polls are serialized.
+type Monitor struct {
+	source   Source
+	verifier mt.TreeHeadVerifier
+	store    Store
+	config   *mt.Config
+	// OnEntries, if not nil, is called with the verified entries appended to the log, from the index start,
+	// before the tree head covering them is saved. Entries are only fetched if it is set.
+	OnEntries func(ctx context.Context, start uint64, entries []mt.DataBlock) error
+	// OnAlert, if not nil, is called with every verification failure.
+	OnAlert func(*Alert)
+
+	mu   sync.Mutex
+	head *mt.SignedTreeHead
+}
+
+// New creates a monitor of the log served by the source, whose tree heads are signed by the key of the verifier
+// and whose trees are built with the configuration, resuming from the tree head of the store.
+func New(ctx context.Context, source Source, verifier mt.TreeHeadVerifier, store Store, config *mt.Config) (*Monitor, error) {
+	head, err := store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Monitor{
+		source:   source,
+		verifier: verifier,
+		store:    store,
+		config:   config,
+		head:     head,
+	}, nil
+}
+
+// Verified returns the last verified tree head, or nil if there is none.
+func (m *Monitor) Verified() *mt.SignedTreeHead {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.head
+}
+
+// Poll fetches the latest tree head and, if it is verified, saves it. The first tree head is trusted,
+// after checking its entries if OnEntries is set. Verification failures are reported to OnAlert and returned.
+func (m *Monitor) Poll(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	head, err := m.source.TreeHead(ctx)
+	if err != nil {
+		return err
+	}
+
+	if head == nil {
+		return mt.ErrTreeHeadMissing
+	}
+
+	if err := m.verify(ctx, head); err != nil {
+		if isVerificationFailure(err) && m.OnAlert != nil {
+			m.OnAlert(&Alert{Verified: m.head, Received: head, Err: err})
+		}
+
+		return err
+	}
+
+	if m.head != nil && head.TreeSize == m.head.TreeSize {
+		return nil
+	}
+
+	if err := m.store.Save(ctx, head); err != nil {
+		return err
+	}
+
+	m.head = head
+
+	return nil
+}
+
+// verify checks the tree head against the verified one, and delivers the entries it appends.
+func (m *Monitor) verify(ctx context.Context, head *mt.SignedTreeHead) error {
+	if err := m.verifier.Verify(head.SigningInput(), head.Signature); err != nil {
+		return mt.ErrTreeHeadSignature
+	}
+
+	if head.TreeSize < 2 {
+		return mt.ErrInvalidNumOfDataBlocks
+	}
+
+	if m.head == nil {
+		return m.verifyFirst(ctx, head)
+	}
+
+	switch {
+	case head.TreeSize < m.head.TreeSize:
+		return fmt.Errorf("%w: size %d, verified size %d", ErrRollback, head.TreeSize, m.head.TreeSize)
+	case head.TreeSize == m.head.TreeSize:
+		if !bytes.Equal(head.Root, m.head.Root) {
+			return fmt.Errorf("%w: size %d, root %x, verified root %x", ErrForked, head.TreeSize, head.Root, m.head.Root)
+		}
+
+		return nil
+	}
+
+	proof, err := m.source.ConsistencyProof(ctx, m.head.TreeSize, head.TreeSize)
+	if err != nil {
+		return err
+	}
+
+	if proof == nil || uint64(proof.OldSize) != m.head.TreeSize || uint64(proof.NewSize) != head.TreeSize {
+		return fmt.Errorf("%w: proof does not cover sizes %d to %d", ErrInconsistent, m.head.TreeSize, head.TreeSize)
+	}
+
+	ok, err := mt.VerifyConsistency(proof, m.head.Root, head.Root, m.verifyConfig())
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: sizes %d to %d", ErrInconsistent, m.head.TreeSize, head.TreeSize)
+	}
+
+	if m.OnEntries == nil {
+		return nil
+	}
+
+	entries, err := m.source.Entries(ctx, m.head.TreeSize, head.TreeSize)
+	if err != nil {
+		return err
+	}
+
+	if ok, err = mt.VerifyExtension(proof, m.head.Root, entries, head.Root, m.verifyConfig()); err != nil {
+		return err
+	}
+
+	if !ok || uint64(len(entries)) != head.TreeSize-m.head.TreeSize {
+		return fmt.Errorf("%w: entries %d to %d", ErrInvalidEntries, m.head.TreeSize, head.TreeSize)
+	}
+
+	return m.OnEntries(ctx, m.head.TreeSize, entries)
+}
+
+// verifyFirst checks the entries of the first tree head if OnEntries is set.
+func (m *Monitor) verifyFirst(ctx context.Context, head *mt.SignedTreeHead) error {
+	if m.OnEntries == nil {
+		return nil
+	}
+
+	entries, err := m.source.Entries(ctx, 0, head.TreeSize)
+	if err != nil {
+		return err
+	}
+
+	if uint64(len(entries)) != head.TreeSize {
+		return fmt.Errorf("%w: %d entries, size %d", ErrInvalidEntries, len(entries), head.TreeSize)
+	}
+
+	config := m.verifyConfig()
+	config.Mode = mt.ModeLazy
+
+	tree, err := mt.New(config, entries)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(tree.Root, head.Root) {
+		return fmt.Errorf("%w: entries 0 to %d", ErrInvalidEntries, head.TreeSize)
+	}
+
+	return m.OnEntries(ctx, 0, entries)
+}
+
+// verifyConfig returns a copy of the configuration of the log, so that the defaults set by the
+// verification functions are not shared.
+func (m *Monitor) verifyConfig() *mt.Config {
+	if m.config == nil {
+		return new(mt.Config)
+	}
+
+	config := *m.config
+
+	return &config
+}
+
+// isVerificationFailure reports whether the error rejects a tree head, rather than failing to fetch or deliver.
+func isVerificationFailure(err error) bool {
+	for _, target := range []error{mt.ErrTreeHeadSignature, ErrRollback, ErrForked, ErrInconsistent, ErrInvalidEntries} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run polls every interval until ctx is done. Errors do not stop the monitor; those other than
+// verification failures, which are reported to OnAlert, are passed to onError if it is not nil.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Poll(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// MemoryStore is a Store keeping the tree head in memory.
+type MemoryStore struct {
+	mu   sync.Mutex
+	head *mt.SignedTreeHead
+}
+
+// Load returns the stored tree head.
+func (s *MemoryStore) Load(context.Context) (*mt.SignedTreeHead, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.head, nil
+}
+
+// Save replaces the stored tree head.
+func (s *MemoryStore) Save(_ context.Context, head *mt.SignedTreeHead) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.head = head
+
+	return nil
+}
+
+// FileStore is a Store keeping the tree head as JSON in the file at its path.
+// The file is replaced atomically, so that a crash never leaves a partially written tree head.
+type FileStore string
+
+// Load returns the stored tree head, or nil if the file does not exist.
+func (s FileStore) Load(context.Context) (*mt.SignedTreeHead, error) {
+	data, err := os.ReadFile(string(s))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	head := new(mt.SignedTreeHead)
+	if err := json.Unmarshal(data, head); err != nil {
+		return nil, fmt.Errorf("monitor: %s: %w", string(s), err)
+	}
+
+	return head, nil
+}
+
+// Save replaces the stored tree head.
+func (s FileStore) Save(_ context.Context, head *mt.SignedTreeHead) error {
+	data, err := json.Marshal(head)
+	if err != nil {
+		return err
+	}
+
+	tmp := string(s) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, string(s))
+}
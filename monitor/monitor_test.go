@@ -0,0 +1,237 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package monitor
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+type testBlock []byte
+
+func (b testBlock) Serialize() ([]byte, error) {
+	return b, nil
+}
+
+// testLog is a Source serving the tree of its entries, or the tree head set by the test.
+type testLog struct {
+	t       *testing.T
+	priv    ed25519.PrivateKey
+	entries []mt.DataBlock
+	head    *mt.SignedTreeHead
+}
+
+func newTestLog(t *testing.T, priv ed25519.PrivateKey, prefix string, n int) *testLog {
+	l := &testLog{t: t, priv: priv}
+	l.append(prefix, n)
+	return l
+}
+
+func (l *testLog) append(prefix string, n int) {
+	for i := 0; i < n; i++ {
+		l.entries = append(l.entries, testBlock(fmt.Sprintf("%s-%d", prefix, len(l.entries))))
+	}
+	l.head = l.signedHead(len(l.entries))
+}
+
+func (l *testLog) tree(size int) *mt.MerkleTree {
+	m, err := mt.New(&mt.Config{Mode: mt.ModeTreeBuild}, l.entries[:size])
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	return m
+}
+
+func (l *testLog) signedHead(size int) *mt.SignedTreeHead {
+	head := &mt.SignedTreeHead{Root: l.tree(size).Root, TreeSize: uint64(size)}
+	signature, err := mt.Ed25519Signer(l.priv).Sign(head.SigningInput())
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	head.Signature = signature
+	return head
+}
+
+func (l *testLog) TreeHead(context.Context) (*mt.SignedTreeHead, error) {
+	return l.head, nil
+}
+
+func (l *testLog) ConsistencyProof(_ context.Context, oldSize, newSize uint64) (*mt.ConsistencyProof, error) {
+	return l.tree(int(newSize)).ConsistencyProof(int(oldSize))
+}
+
+func (l *testLog) Entries(_ context.Context, start, end uint64) ([]mt.DataBlock, error) {
+	return l.entries[start:end], nil
+}
+
+func TestMonitor_Poll(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		tamper  func(l *testLog)
+		wantErr error
+	}{
+		{name: "test_append", tamper: func(l *testLog) { l.append("entry", 13) }},
+		{name: "test_unchanged", tamper: func(*testLog) {}},
+		{name: "test_rollback", tamper: func(l *testLog) { l.head = l.signedHead(5) }, wantErr: ErrRollback},
+		{
+			name: "test_forked",
+			tamper: func(l *testLog) {
+				l.entries[3] = testBlock("rewritten")
+				l.head = l.signedHead(len(l.entries))
+			},
+			wantErr: ErrForked,
+		},
+		{
+			name: "test_rewritten_history",
+			tamper: func(l *testLog) {
+				l.entries[3] = testBlock("rewritten")
+				l.append("entry", 4)
+			},
+			wantErr: ErrInconsistent,
+		},
+		{
+			name: "test_forged_head",
+			tamper: func(l *testLog) {
+				_, other, _ := ed25519.GenerateKey(nil)
+				l.append("entry", 4)
+				l.priv = other
+				l.head = l.signedHead(len(l.entries))
+			},
+			wantErr: mt.ErrTreeHeadSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLog(t, priv, "entry", 10)
+			m, err := New(context.Background(), l, mt.Ed25519Verifier(pub), new(MemoryStore), nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			var (
+				alerts    []*Alert
+				delivered int
+			)
+			m.OnAlert = func(a *Alert) { alerts = append(alerts, a) }
+			m.OnEntries = func(_ context.Context, start uint64, entries []mt.DataBlock) error {
+				if start != uint64(delivered) {
+					t.Errorf("OnEntries() start = %d, want %d", start, delivered)
+				}
+				delivered += len(entries)
+				return nil
+			}
+			if err := m.Poll(context.Background()); err != nil {
+				t.Fatalf("Poll() first error = %v", err)
+			}
+			tt.tamper(l)
+			err = m.Poll(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Poll() error = %v, want %v", err, tt.wantErr)
+			}
+			if (len(alerts) == 1) != (tt.wantErr != nil) {
+				t.Fatalf("Poll() raised %d alerts", len(alerts))
+			}
+			wantSize := uint64(len(l.entries))
+			if tt.wantErr != nil {
+				wantSize = 10
+			}
+			if m.Verified().TreeSize != wantSize || uint64(delivered) != wantSize {
+				t.Errorf("Verified() size = %d, delivered %d, want %d", m.Verified().TreeSize, delivered, wantSize)
+			}
+		})
+	}
+}
+
+// entriesLog serves other entries than the ones of its tree heads.
+type entriesLog struct {
+	*testLog
+	forged []mt.DataBlock
+}
+
+func (l *entriesLog) Entries(_ context.Context, start, end uint64) ([]mt.DataBlock, error) {
+	return l.forged[start:end], nil
+}
+
+func TestMonitor_Poll_invalidEntries(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &entriesLog{testLog: newTestLog(t, priv, "entry", 6)}
+	l.forged = newTestLog(t, priv, "forged", 12).entries
+	copy(l.forged, l.entries)
+	m, err := New(context.Background(), l, mt.Ed25519Verifier(pub), new(MemoryStore), nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	m.OnEntries = func(context.Context, uint64, []mt.DataBlock) error { return nil }
+	if err := m.Poll(context.Background()); err != nil {
+		t.Fatalf("Poll() first error = %v", err)
+	}
+	l.append("entry", 6)
+	if err := m.Poll(context.Background()); !errors.Is(err, ErrInvalidEntries) {
+		t.Errorf("Poll() error = %v, want %v", err, ErrInvalidEntries)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		store = FileStore(filepath.Join(t.TempDir(), "head.json"))
+		l     = newTestLog(t, priv, "entry", 8)
+	)
+	m, err := New(context.Background(), l, mt.Ed25519Verifier(pub), store, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if m.Verified() != nil {
+		t.Fatalf("Verified() = %v, want nil", m.Verified())
+	}
+	if err := m.Poll(context.Background()); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	// A restarted monitor resumes from the stored tree head, and detects a rollback.
+	l.head = l.signedHead(4)
+	m, err = New(context.Background(), l, mt.Ed25519Verifier(pub), store, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if m.Verified() == nil || m.Verified().TreeSize != 8 {
+		t.Fatalf("Verified() = %v, want size 8", m.Verified())
+	}
+	if err := m.Poll(context.Background()); !errors.Is(err, ErrRollback) {
+		t.Errorf("Poll() error = %v, want %v", err, ErrRollback)
+	}
+}
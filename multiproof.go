@@ -0,0 +1,331 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"sort"
+)
+
+// MultiProof proves several leaves of the tree at once: the nodes known to the verifier, starting with
+// the leaves, are consumed in order from a queue, and each flag tells whether the sibling of the next node
+// is the following node of the queue (true) or the next element of Proof (false).
+// The siblings are combined as in the rest of this package, see verifier.Concat, so multiproofs cannot be
+// verified by OpenZeppelin's MerkleProof, which hashes the concatenation of the siblings with keccak256.
+// As their leaves are hashes, trees whose interior nodes must not be proven as leaves separate the leaf and
+// node hashes, see DomainSeparated.
+type MultiProof struct {
+	// Leaves are the hashes of the proven leaves, in ascending order of their indexes.
+	Leaves [][]byte
+	// Proof are the sibling nodes that are not computed from the leaves, in the order they are consumed.
+	Proof [][]byte
+	// ProofFlags tells for each hash whether its second operand comes from the queue or from Proof.
+	ProofFlags []bool
+	// Indexes are the indexes of the proven leaves, in the order of Leaves.
+	Indexes []int
+}
+
+// MultiProof returns the multiproof of the leaves at the given indexes, which are sorted and deduplicated.
+// Multiproofs do not carry the positions of the nodes, so the tree must not be built with PositionalHashFunc.
+// The last node of an odd level is its own sibling and is taken from Proof when it is needed.
+func (m *MerkleTree) MultiProof(indexes []int) (*MultiProof, error) {
+	if m.PositionalHashFunc != nil {
+		return nil, ErrMultiProofUnsupported
+	}
+
+	known := make([]int, 0, len(indexes))
+	for _, idx := range indexes {
+		if idx < 0 || idx >= m.NumLeaves {
+			return nil, ErrIndexOutOfRange
+		}
+
+		known = append(known, idx)
+	}
+
+	sort.Ints(known)
+	known = compactInts(known)
+
+	mp := &MultiProof{
+		Leaves:  make([][]byte, len(known)),
+		Indexes: append([]int(nil), known...),
+	}
+
+	// Without leaves, the root is the proof on its own.
+	if len(known) == 0 {
+		mp.Proof = [][]byte{m.Root}
+
+		return mp, nil
+	}
+
+	for i, idx := range known {
+		leaf, err := m.levelNode(0, idx)
+		if err != nil {
+			return nil, err
+		}
+
+		mp.Leaves[i] = leaf
+	}
+
+	for level := 0; level < m.Depth; level++ {
+		next := known[:0]
+
+		for i := 0; i < len(known); i++ {
+			idx := known[i]
+			if i+1 < len(known) && known[i+1] == idx^1 {
+				mp.ProofFlags = append(mp.ProofFlags, true)
+				i++
+			} else {
+				sib, err := m.levelNode(level, idx^1)
+				if err != nil {
+					return nil, err
+				}
+
+				mp.Proof = append(mp.Proof, sib)
+				mp.ProofFlags = append(mp.ProofFlags, false)
+			}
+
+			next = append(next, idx>>1)
+		}
+
+		known = next
+	}
+
+	return mp, nil
+}
+
+// compactInts removes the consecutive duplicates of the sorted slice in place.
+func compactInts(s []int) []int {
+	if len(s) == 0 {
+		return s
+	}
+
+	n := 1
+	for _, v := range s[1:] {
+		if v != s[n-1] {
+			s[n] = v
+			n++
+		}
+	}
+
+	return s[:n]
+}
+
+// ProcessMultiProof returns the root computed from the leaves, the proof and the flags of a multiproof,
+// see MultiProof. It returns ErrMultiProofInvalid if the numbers of
+// leaves, proof nodes and flags do not match, or if the proof is not entirely consumed.
+func ProcessMultiProof(leaves, proof [][]byte, proofFlags []bool, config *Config) ([]byte, error) {
+	if len(leaves)+len(proof) != len(proofFlags)+1 {
+		return nil, ErrMultiProofInvalid
+	}
+
+	if config == nil {
+		config = new(Config)
+	}
+
+	if config.HashFunc == nil {
+		config.HashFunc = DefaultHashFunc
+	}
+
+	if config.PositionalHashFunc != nil {
+		return nil, ErrMultiProofUnsupported
+	}
+
+	concat := concatHash
+	if config.SortSiblingPairs {
+		concat = concatSortHash
+	}
+
+	var (
+		hashes                     = make([][]byte, len(proofFlags))
+		leafPos, hashPos, proofPos int
+	)
+
+	// next pops the next known node: the leaves first, then the computed hashes.
+	next := func() ([]byte, bool) {
+		if leafPos < len(leaves) {
+			leafPos++
+
+			return leaves[leafPos-1], true
+		}
+
+		// A hash can only be consumed once it is computed.
+		if hashPos < len(hashes) && hashes[hashPos] != nil {
+			hashPos++
+
+			return hashes[hashPos-1], true
+		}
+
+		return nil, false
+	}
+
+	for i, flag := range proofFlags {
+		a, ok := next()
+		if !ok {
+			return nil, ErrMultiProofInvalid
+		}
+
+		var b []byte
+		if flag {
+			if b, ok = next(); !ok {
+				return nil, ErrMultiProofInvalid
+			}
+		} else {
+			if proofPos == len(proof) {
+				return nil, ErrMultiProofInvalid
+			}
+
+			b = proof[proofPos]
+			proofPos++
+		}
+
+		hash, err := config.nodeHashFunc()(concat(a, b))
+		if err != nil {
+			return nil, err
+		}
+
+		hashes[i] = hash
+	}
+
+	if len(proofFlags) > 0 {
+		if proofPos != len(proof) {
+			return nil, ErrMultiProofInvalid
+		}
+
+		return hashes[len(proofFlags)-1], nil
+	}
+
+	if len(leaves) > 0 {
+		return leaves[0], nil
+	}
+
+	return proof[0], nil
+}
+
+// VerifyMultiProof checks the data blocks, in the order of the multiproof leaves, against the provided
+// Merkle root hash. The leaves are hashed from the data blocks with the leaf hash function, so that they
+// are kept apart from the interior nodes when Config.LeafHashFunc or Config.LeafKey is set.
+func VerifyMultiProof(dataBlocks []DataBlock, mp *MultiProof, root []byte, config *Config) (bool, error) {
+	if mp == nil {
+		return false, ErrProofIsNil
+	}
+
+	if config == nil {
+		config = new(Config)
+	}
+
+	if config.HashFunc == nil {
+		config.HashFunc = DefaultHashFunc
+	}
+
+	leaves := make([][]byte, len(dataBlocks))
+
+	for i, dataBlock := range dataBlocks {
+		if dataBlock == nil {
+			return false, ErrDataBlockIsNil
+		}
+
+		if config.TimestampLeaves {
+			var err error
+			if dataBlock, err = commitTimestamp(dataBlock); err != nil {
+				return false, err
+			}
+		}
+
+		dataBlock = config.fitBlock(dataBlock)
+
+		leaf, err := dataBlockToLeaf(dataBlock, config.leafHashFunc(), config.DisableLeafHashing)
+		if err != nil {
+			return false, err
+		}
+
+		leaves[i] = leaf
+	}
+
+	computed, err := ProcessMultiProof(leaves, mp.Proof, mp.ProofFlags, config)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(computed, root), nil
+}
+
+// SolidityABIEncode encodes the multiproof as the ABI encoding of (bytes32[] proof, bool[] proofFlags,
+// bytes32[] leaves), which can be decoded by a Solidity contract combining the siblings as this package
+// does with abi.decode(data, (bytes32[], bool[], bytes32[])).
+// Nodes shorter than 32 bytes are right-padded with zeros, as for any bytes32 value.
+func (mp *MultiProof) SolidityABIEncode() ([]byte, error) {
+	var (
+		proofLen  = solidityWordSize * (len(mp.Proof) + 1)
+		flagsLen  = solidityWordSize * (len(mp.ProofFlags) + 1)
+		leavesLen = solidityWordSize * (len(mp.Leaves) + 1)
+		buf       = new(bytes.Buffer)
+		wordBytes = make([]byte, solidityWordSize)
+	)
+
+	buf.Grow(3*solidityWordSize + proofLen + flagsLen + leavesLen)
+	// Head: offsets of the three dynamic arrays.
+	buf.Write(solidityUint(wordBytes, uint64(3*solidityWordSize)))
+	buf.Write(solidityUint(wordBytes, uint64(3*solidityWordSize+proofLen)))
+	buf.Write(solidityUint(wordBytes, uint64(3*solidityWordSize+proofLen+flagsLen)))
+
+	// Tail: the proof array.
+	if err := writeSolidityBytes32Array(buf, wordBytes, mp.Proof); err != nil {
+		return nil, err
+	}
+
+	// Tail: the flags array.
+	buf.Write(solidityUint(wordBytes, uint64(len(mp.ProofFlags))))
+
+	for _, flag := range mp.ProofFlags {
+		var v uint64
+		if flag {
+			v = 1
+		}
+
+		buf.Write(solidityUint(wordBytes, v))
+	}
+
+	// Tail: the leaves array.
+	if err := writeSolidityBytes32Array(buf, wordBytes, mp.Leaves); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeSolidityBytes32Array writes the length and the elements of a bytes32[] array.
+func writeSolidityBytes32Array(buf *bytes.Buffer, word []byte, elems [][]byte) error {
+	buf.Write(solidityUint(word, uint64(len(elems))))
+
+	for _, elem := range elems {
+		if len(elem) > solidityWordSize {
+			return ErrProofSiblingTooLong
+		}
+
+		clear(word)
+		copy(word, elem)
+		buf.Write(word)
+	}
+
+	return nil
+}
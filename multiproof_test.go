@@ -0,0 +1,228 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestMerkleTree_MultiProof(t *testing.T) {
+	configs := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "test_proof_gen", config: &Config{}},
+		{name: "test_tree_build_sorted", config: &Config{Mode: ModeTreeBuild, SortSiblingPairs: true}},
+		{name: "test_low_memory", config: &Config{Mode: ModeLowMemory, RecomputeLevels: 1}},
+	}
+	indexSets := [][]int{{0}, {1, 0}, {0, 2, 4}, {3, 3, 4}, {}, {1, 2, 3, 4, 5, 6}, {6}}
+
+	for _, cc := range configs {
+		for _, numLeaves := range []int{2, 5, 7, 8} {
+			blocks := mockDataBlocks(numLeaves)
+
+			m, err := New(cc.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			for _, indexes := range indexSets {
+				mp, err := m.MultiProof(indexes)
+				if err != nil {
+					if errors.Is(err, ErrIndexOutOfRange) {
+						continue
+					}
+
+					t.Fatalf("%s: MultiProof(%v) error = %v", cc.name, indexes, err)
+				}
+
+				provenBlocks := make([]DataBlock, len(mp.Indexes))
+				for i, idx := range mp.Indexes {
+					provenBlocks[i] = blocks[idx]
+				}
+
+				ok, err := VerifyMultiProof(provenBlocks, mp, m.Root, cc.config)
+				if err != nil || !ok {
+					t.Errorf("%s, %d leaves: VerifyMultiProof(%v) = %v, %v, want true",
+						cc.name, numLeaves, indexes, ok, err)
+				}
+
+				root, err := ProcessMultiProof(mp.Leaves, mp.Proof, mp.ProofFlags, cc.config)
+				if err != nil || !bytes.Equal(root, m.Root) {
+					t.Errorf("%s, %d leaves: ProcessMultiProof(%v) = %x, %v", cc.name, numLeaves, indexes, root, err)
+				}
+
+				if len(mp.ProofFlags) == 0 {
+					continue
+				}
+
+				// Flipping a flag must not verify.
+				flags := append([]bool(nil), mp.ProofFlags...)
+				flags[0] = !flags[0]
+
+				if root, err := ProcessMultiProof(mp.Leaves, mp.Proof, flags, cc.config); err == nil &&
+					bytes.Equal(root, m.Root) {
+					t.Errorf("%s, %d leaves: ProcessMultiProof(%v) accepted flipped flags", cc.name, numLeaves, indexes)
+				}
+			}
+		}
+	}
+}
+
+func TestMerkleTree_MultiProof_errors(t *testing.T) {
+	blocks := mockDataBlocks(5)
+
+	m, err := New(&Config{SortSiblingPairs: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := m.MultiProof([]int{5}); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("MultiProof() error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+
+	positional, err := New(&Config{PositionalHashFunc: PositionPrefixed(nil)}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := positional.MultiProof([]int{0}); !errors.Is(err, ErrMultiProofUnsupported) {
+		t.Errorf("MultiProof() error = %v, want %v", err, ErrMultiProofUnsupported)
+	}
+
+	mp, err := m.MultiProof([]int{0, 3})
+	if err != nil {
+		t.Fatalf("MultiProof() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		leaves [][]byte
+		proof  [][]byte
+		flags  []bool
+	}{
+		{name: "test_missing_leaf", leaves: mp.Leaves[:1], proof: mp.Proof, flags: mp.ProofFlags},
+		{name: "test_extra_proof_node", leaves: mp.Leaves, proof: append(mp.Proof, mp.Leaves[0]), flags: mp.ProofFlags},
+		{name: "test_unconsumed_proof", leaves: mp.Leaves[:1], proof: append(mp.Proof, mp.Leaves[1]),
+			flags: mp.ProofFlags},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ProcessMultiProof(tt.leaves, tt.proof, tt.flags, m.Config); !errors.Is(err,
+				ErrMultiProofInvalid) {
+				t.Errorf("ProcessMultiProof() error = %v, want %v", err, ErrMultiProofInvalid)
+			}
+		})
+	}
+}
+
+func TestMultiProof_SolidityABIEncode(t *testing.T) {
+	mp := &MultiProof{
+		Leaves:     [][]byte{{0x01}, {0x02}},
+		Proof:      [][]byte{{0x03}},
+		ProofFlags: []bool{false, true},
+	}
+
+	enc, err := mp.SolidityABIEncode()
+	if err != nil {
+		t.Fatalf("SolidityABIEncode() error = %v", err)
+	}
+
+	// 3 offsets, then the proof (1 + 1 words), the flags (1 + 2 words) and the leaves (1 + 2 words).
+	if len(enc) != 11*solidityWordSize {
+		t.Fatalf("SolidityABIEncode() length = %d, want %d", len(enc), 11*solidityWordSize)
+	}
+
+	var (
+		word      = func(i int) []byte { return enc[i*solidityWordSize : (i+1)*solidityWordSize] }
+		wantWord  = make([]byte, solidityWordSize)
+		wantUints = map[int]uint64{0: 0x60, 1: 0xa0, 2: 0x100, 3: 1, 5: 2, 6: 0, 7: 1, 8: 2}
+	)
+
+	for i, want := range wantUints {
+		if w := word(i); !bytes.Equal(w, solidityUint(wantWord, want)) {
+			t.Errorf("word %d = %x, want %d", i, w, want)
+		}
+	}
+
+	for i, want := range map[int]byte{4: 0x03, 9: 0x01, 10: 0x02} {
+		clear(wantWord)
+		wantWord[0] = want
+
+		if w := word(i); !bytes.Equal(w, wantWord) {
+			t.Errorf("word %d = %x, want %x right-padded", i, w, want)
+		}
+	}
+
+	mp.Leaves[0] = make([]byte, solidityWordSize+1)
+	if _, err := mp.SolidityABIEncode(); !errors.Is(err, ErrProofSiblingTooLong) {
+		t.Errorf("SolidityABIEncode() error = %v, want %v", err, ErrProofSiblingTooLong)
+	}
+}
+
+func TestVerifyMultiProof_domainSeparated(t *testing.T) {
+	leafHash, nodeHash := DomainSeparated(nil)
+	tests := []struct {
+		name   string
+		config *Config
+		want   bool
+	}{
+		{name: "test_shared_domain", config: &Config{Mode: ModeTreeBuild}, want: true},
+		{name: "test_separated", config: &Config{Mode: ModeTreeBuild, LeafHashFunc: leafHash, NodeHashFunc: nodeHash}},
+		{name: "test_separated_custom", config: func() *Config {
+			leafHash, nodeHash := DomainSeparated(DefaultHashFuncParallel)
+			return &Config{Mode: ModeTreeBuild, LeafHashFunc: leafHash, NodeHashFunc: nodeHash}
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(4)
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			mp, err := m.MultiProof([]int{1, 2})
+			if err != nil {
+				t.Fatalf("MultiProof() error = %v", err)
+			}
+			if ok, err := VerifyMultiProof([]DataBlock{blocks[1], blocks[2]}, mp, m.Root, tt.config); err != nil || !ok {
+				t.Fatalf("VerifyMultiProof() = %v, error = %v", ok, err)
+			}
+
+			// The preimage of the first interior node, presented as a data block.
+			forged := &mock.DataBlock{Data: concatHash(m.Leaves[0], m.Leaves[1])}
+			sibling, err := m.levelNode(1, 1)
+			if err != nil {
+				t.Fatalf("levelNode() error = %v", err)
+			}
+			mp = &MultiProof{Proof: [][]byte{sibling}, ProofFlags: []bool{false}}
+			if ok, err := VerifyMultiProof([]DataBlock{forged}, mp, m.Root, tt.config); err != nil || ok != tt.want {
+				t.Errorf("VerifyMultiProof() of an interior node = %v, error = %v, want %v", ok, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "encoding/binary"
+
+// TypePositionalHashFunc is the signature of the position-aware hash functions of interior nodes, see
+// Config.PositionalHashFunc. It hashes the combined children of the node at the given index of the given
+// level, leaves being level 0 and the root level Depth.
+type TypePositionalHashFunc func(level, index int, data []byte) ([]byte, error)
+
+// PositionPrefixed returns the positional hash function hashing with hashFunc the node level as a 4-byte
+// and its index as an 8-byte big-endian integer, followed by the combined children.
+// SHA-256 is used if hashFunc is nil, streaming the prefix and the children into the digest without copying
+// them. The returned function is concurrent-safe if hashFunc is.
+func PositionPrefixed(hashFunc TypeHashFunc) TypePositionalHashFunc {
+	if hashFunc == nil {
+		return func(level, index int, data []byte) ([]byte, error) {
+			var prefix [12]byte
+			binary.BigEndian.PutUint32(prefix[:], uint32(level))
+			binary.BigEndian.PutUint64(prefix[4:], uint64(index))
+
+			return DefaultHashSlices(prefix[:], data)
+		}
+	}
+
+	return func(level, index int, data []byte) ([]byte, error) {
+		buf := make([]byte, 12+len(data))
+		binary.BigEndian.PutUint32(buf, uint32(level))
+		binary.BigEndian.PutUint64(buf[4:], uint64(index))
+		copy(buf[12:], data)
+
+		return hashFunc(buf)
+	}
+}
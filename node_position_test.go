@@ -0,0 +1,161 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+// positionalRoot computes the root of the leaves with the positional hash function, as a reference.
+func positionalRoot(t *testing.T, leaves [][]byte, hashNode TypePositionalHashFunc) []byte {
+	t.Helper()
+	level := leaves
+	for height := 1; len(level) > 1; height++ {
+		if len(level)&1 == 1 {
+			level = append(level[:len(level):len(level)], level[len(level)-1])
+		}
+		next := make([][]byte, len(level)/2)
+		for j := range next {
+			node, err := hashNode(height, j, concatHash(level[2*j], level[2*j+1]))
+			if err != nil {
+				t.Fatalf("hashNode() error = %v", err)
+			}
+			next[j] = node
+		}
+		level = next
+	}
+	return level[0]
+}
+
+func TestConfig_PositionalHashFunc(t *testing.T) {
+	var (
+		hashNode = PositionPrefixed(nil)
+		blocks   = mockDataBlocksFixedSize(1001)
+	)
+	plain, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	wantRoot := positionalRoot(t, plain.Leaves, hashNode)
+	if bytes.Equal(wantRoot, plain.Root) {
+		t.Fatalf("positional root equals the plain root")
+	}
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "test_proof_gen", config: &Config{}},
+		{name: "test_proof_gen_parallel", config: &Config{RunInParallel: true, NumRoutines: 4}},
+		{name: "test_proof_gen_sharded", config: &Config{RunInParallel: true, NumRoutines: 4, NumShards: 2}},
+		{name: "test_tree_build", config: &Config{Mode: ModeTreeBuild}},
+		{name: "test_tree_build_parallel", config: &Config{Mode: ModeTreeBuild, RunInParallel: true, NumRoutines: 4}},
+		{name: "test_proof_gen_and_tree_build", config: &Config{Mode: ModeProofGenAndTreeBuild}},
+		{name: "test_lazy", config: &Config{Mode: ModeLazy}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.PositionalHashFunc = hashNode
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(m.Root, wantRoot) {
+				t.Fatalf("Root = %x, want %x", m.Root, wantRoot)
+			}
+			vc := &Config{PositionalHashFunc: hashNode}
+			for _, idx := range []int{0, 1, 255, 256, 511, 999, 1000} {
+				proof, err := m.proofByIndex(idx)
+				if err != nil {
+					t.Fatalf("proofByIndex() error = %v", err)
+				}
+				if ok, err := Verify(blocks[idx], proof, m.Root, vc); err != nil || !ok {
+					t.Errorf("Verify() leaf %d = %v, %v, want true", idx, ok, err)
+				}
+				if ok, _ := Verify(blocks[idx], proof, m.Root, nil); ok {
+					t.Errorf("Verify() leaf %d without PositionalHashFunc = true, want false", idx)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_PositionalHashFunc_ingestAndFile(t *testing.T) {
+	var (
+		config = &Config{Mode: ModeTreeBuild, PositionalHashFunc: PositionPrefixed(nil)}
+		blocks = mockDataBlocksFixedSize(21)
+	)
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ingest := NewIngestTree(&Config{PositionalHashFunc: config.PositionalHashFunc})
+	for _, block := range blocks {
+		if _, err := ingest.Append(block); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	root, err := ingest.Root()
+	if err != nil {
+		t.Fatalf("Root() error = %v", err)
+	}
+	if !bytes.Equal(root, m.Root) {
+		t.Errorf("IngestTree Root() = %x, want %x", root, m.Root)
+	}
+
+	pc := &Config{PositionalHashFunc: config.PositionalHashFunc}
+	tasks, err := PlanSubtrees(len(blocks), 3)
+	if err != nil {
+		t.Fatalf("PlanSubtrees() error = %v", err)
+	}
+	results := make([]*SubtreeResult, len(tasks))
+	for i, task := range tasks {
+		if results[i], err = BuildSubtree(pc, task, blocks[task.Start:task.End]); err != nil {
+			t.Fatalf("BuildSubtree() error = %v", err)
+		}
+	}
+	merged, err := MergeSubtrees(pc, len(blocks), results)
+	if err != nil {
+		t.Fatalf("MergeSubtrees() error = %v", err)
+	}
+	if !bytes.Equal(merged.Root, m.Root) {
+		t.Errorf("MergeSubtrees() Root = %x, want %x", merged.Root, m.Root)
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	r := bytes.NewReader(buf.Bytes())
+	if err := VerifyTreeFile(r, m.Root, &Config{PositionalHashFunc: config.PositionalHashFunc}); err != nil {
+		t.Errorf("VerifyTreeFile() error = %v", err)
+	}
+	if err := VerifyTreeFile(r, m.Root, nil); err == nil {
+		t.Errorf("VerifyTreeFile() without PositionalHashFunc error = nil")
+	}
+
+	if _, err := m.Translate(PaddingPromote); err != ErrInvalidPaddingScheme {
+		t.Errorf("Translate() error = %v, want %v", err, ErrInvalidPaddingScheme)
+	}
+}
@@ -22,25 +22,65 @@
 
 package merkletree
 
-// Proof represents a Merkle Tree proof.
+import "bytes"
+
+// Proof represents a Merkle Tree proof. Proofs generated by other systems can be built with NewProof
+// from their siblings and leaf index, or from their sibling directions with DirectionalProof.Proof.
 type Proof struct {
-	Siblings [][]byte // Sibling nodes to the Merkle Tree path of the data block.
-	Path     uint32   // Path variable indicating whether the neighbor is on the left or right.
+	Siblings [][]byte // Sibling nodes to the Merkle Tree path of the data block, from the leaf level up.
+	Path     uint32   // Path variable indicating whether the neighbor is on the left or right, see PathFromIndex.
+}
+
+// Prover is the interface of the proof operations of a MerkleTree. Code generating or checking proofs
+// can depend on it rather than on *MerkleTree, so that its tests substitute a mock tree.
+type Prover interface {
+	Proof(dataBlock DataBlock) (*Proof, error)
+	Verify(dataBlock DataBlock, proof *Proof) (bool, error)
 }
 
+var _ Prover = (*MerkleTree)(nil)
+
 // Proof generates the Merkle proof for a data block using the previously generated Merkle Tree structure.
-// This method is only available when the configuration mode is ModeTreeBuild or ModeProofGenAndTreeBuild.
+// This method is only available when the configuration mode is ModeTreeBuild, ModeProofGenAndTreeBuild
+// or ModeLazy, whose structure is built on the first call, and ModeLowMemory, which scans the leaves for
+// the data block and recomputes the lower levels of the proof.
 // In ModeProofGen, proofs for all the data blocks are already generated, and the Merkle Tree structure
 // is not cached.
 func (m *MerkleTree) Proof(dataBlock DataBlock) (*Proof, error) {
-	if m.Mode != ModeTreeBuild && m.Mode != ModeProofGenAndTreeBuild {
-		return nil, ErrProofInvalidModeTreeNotBuilt
+	proof, _, err := m.ProofWithIndex(dataBlock)
+
+	return proof, err
+}
+
+// ProofWithIndex generates the Merkle proof for a data block as Proof, and also returns the index of
+// the leaf it located, for the bookkeeping of the caller. The index is 0 on error.
+func (m *MerkleTree) ProofWithIndex(dataBlock DataBlock) (*Proof, uint64, error) {
+	if m.Mode == ModeLowMemory {
+		idx, err := m.leafIndex(dataBlock)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		proof, err := m.proofByIndex(idx)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return proof, uint64(idx), nil
+	}
+
+	if m.Mode == ModeLazy {
+		if err := m.materialize(); err != nil {
+			return nil, 0, err
+		}
+	} else if m.Mode != ModeTreeBuild && m.Mode != ModeProofGenAndTreeBuild {
+		return nil, 0, ErrProofInvalidModeTreeNotBuilt
 	}
 
 	// Convert the data block to a leaf.
-	leaf, err := dataBlockToLeaf(dataBlock, m.HashFunc, m.DisableLeafHashing)
+	leaf, err := cachedDataBlockToLeaf(dataBlock, m.leafHashFunc(), m.DisableLeafHashing, m.LeafCache)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// Retrieve the index of the leaf in the Merkle Tree.
@@ -49,9 +89,92 @@ func (m *MerkleTree) Proof(dataBlock DataBlock) (*Proof, error) {
 	m.leafMapMu.Unlock()
 
 	if !ok {
-		return nil, ErrProofInvalidDataBlock
+		return nil, 0, ErrProofInvalidDataBlock
+	}
+
+	return m.injectProofFault(idx, m.proofFromNodes(idx)), uint64(idx), nil
+}
+
+// IndexOf returns the index of the leaf of the data block, or 0 with ErrProofInvalidDataBlock if it is
+// not in the tree. It is available in all modes: the leaf map is used if the tree is built, the leaves
+// are scanned otherwise. If the data block occurs several times, any of its indexes may be returned.
+func (m *MerkleTree) IndexOf(dataBlock DataBlock) (uint64, error) {
+	idx, err := m.leafIndex(dataBlock)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(idx), nil
+}
+
+// leafIndex returns the index of the data block in the tree, from the leaf map if the tree is built,
+// or by scanning the leaves otherwise.
+func (m *MerkleTree) leafIndex(dataBlock DataBlock) (int, error) {
+	leaf, err := cachedDataBlockToLeaf(dataBlock, m.leafHashFunc(), m.DisableLeafHashing, m.LeafCache)
+	if err != nil {
+		return 0, err
+	}
+
+	if m.leafMap != nil {
+		m.leafMapMu.Lock()
+		idx, ok := m.leafMap[string(leaf)]
+		m.leafMapMu.Unlock()
+
+		if !ok {
+			return 0, ErrProofInvalidDataBlock
+		}
+
+		return idx, nil
+	}
+
+	for idx, l := range m.Leaves {
+		if bytes.Equal(l, leaf) {
+			return idx, nil
+		}
+	}
+
+	return 0, ErrProofInvalidDataBlock
+}
+
+// proofByIndex returns the proof of the leaf at idx, from the generated proofs if available,
+// or computed from the tree structure otherwise, whose lower levels are recomputed in ModeLowMemory.
+func (m *MerkleTree) proofByIndex(idx int) (*Proof, error) {
+	if idx < 0 || idx >= m.NumLeaves {
+		return nil, ErrIndexOutOfRange
 	}
 
+	if err := m.generateDeferredProofs(); err != nil {
+		return nil, err
+	}
+
+	if m.Proofs != nil {
+		return m.Proofs[idx], nil
+	}
+
+	if m.Mode == ModeLazy {
+		if err := m.materialize(); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.topNodes != nil {
+		proof, err := m.lowMemoryProof(idx)
+		if err != nil {
+			return nil, err
+		}
+
+		return m.injectProofFault(idx, proof), nil
+	}
+
+	if m.nodes == nil {
+		return nil, ErrTreeNotBuilt
+	}
+
+	return m.injectProofFault(idx, m.proofFromNodes(idx)), nil
+}
+
+// proofFromNodes computes the proof of the leaf at idx from the tree structure.
+func (m *MerkleTree) proofFromNodes(idx int) *Proof {
 	// Compute the path and siblings for the proof.
 	var (
 		path     uint32
@@ -72,5 +195,5 @@ func (m *MerkleTree) Proof(dataBlock DataBlock) (*Proof, error) {
 	return &Proof{
 		Path:     path,
 		Siblings: siblings,
-	}, nil
+	}
 }
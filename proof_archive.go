@@ -0,0 +1,239 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ProofArchive serves the proofs of a tree straight from a serialized tree, without a live tree.
+// The serialized layout stores every node once at a fixed width, so the proofs of all leaves share
+// their siblings, and each sibling of a proof is read at an offset computed from the header:
+// a proof costs Depth reads whatever the position of its leaf.
+type ProofArchive struct {
+	r       io.ReaderAt
+	h       *treeHeader
+	offsets []int64
+	// data is the serialized tree if it is mapped in memory, see OpenTree: nodes are sliced from it instead of read.
+	data []byte
+	// mu guards r and data, so that MappedTree.Close waits for the reads in progress before unmapping.
+	mu sync.RWMutex
+	// Root is the Merkle root of the archived tree.
+	Root []byte
+}
+
+// WriteProofArchive writes the proof archive of the tree to w. It is the tree serialization of WriteTo;
+// trees in ModeProofGen, which do not keep their structure, are reassembled from their proofs.
+func WriteProofArchive(w io.Writer, m *MerkleTree) (int64, error) {
+	if m.Mode == ModeLazy || m.nodes != nil {
+		return m.WriteTo(w)
+	}
+
+	if err := m.generateDeferredProofs(); err != nil {
+		return 0, err
+	}
+
+	if m.Proofs == nil {
+		return 0, ErrTreeNotBuilt
+	}
+
+	tree := &MerkleTree{
+		Config:    m.Config,
+		nodes:     m.nodesFromProofs(),
+		Root:      m.Root,
+		NumLeaves: m.NumLeaves,
+		Depth:     m.Depth,
+	}
+
+	return tree.WriteTo(w)
+}
+
+// nodesFromProofs reassembles the levels of the tree from the leaves and the proofs.
+func (m *MerkleTree) nodesFromProofs() [][][]byte {
+	nodes := make([][][]byte, m.Depth)
+
+	for level, size := range levelLens(m.NumLeaves, m.Depth) {
+		nodes[level] = make([][]byte, size)
+		numReal := levelSize(m.NumLeaves, level)
+
+		for k := range nodes[level] {
+			if level == 0 {
+				nodes[0][k] = m.Leaves[min(k, m.NumLeaves-1)]
+				continue
+			}
+
+			// Node k is the sibling of node k^1, or its own padding duplicate if k^1 is padding.
+			leaf := (k ^ 1) << level
+			if k^1 >= numReal {
+				leaf = k << level
+			}
+
+			nodes[level][k] = m.Proofs[leaf].Siblings[level]
+		}
+	}
+
+	return nodes
+}
+
+// OpenProofArchive opens the proof archive, or serialized tree, read from r. If r tells its size,
+// e.g. a *bytes.Reader or an *os.File, it must be the size of the serialized tree.
+func OpenProofArchive(r io.ReaderAt) (*ProofArchive, error) {
+	h, err := readTreeHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.checkSize(r); err != nil {
+		return nil, err
+	}
+
+	a := &ProofArchive{
+		r:       r,
+		h:       h,
+		offsets: make([]int64, h.depth+1),
+		Root:    make([]byte, h.nodeLen),
+	}
+
+	a.offsets[0] = treeEncodingHeaderSize
+	for level, size := range levelLens(h.numLeaves, h.depth) {
+		a.offsets[level+1] = a.offsets[level] + int64(size)*int64(h.nodeLenAt(level))
+	}
+
+	if _, err := r.ReadAt(a.Root, a.offsets[h.depth]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+	}
+
+	return a, nil
+}
+
+// NumLeaves returns the number of leaves of the archived tree.
+func (a *ProofArchive) NumLeaves() int {
+	return a.h.numLeaves
+}
+
+// Depth returns the depth of the archived tree: its levels are 0, the leaves, to Depth, the root.
+func (a *ProofArchive) Depth() int {
+	return a.h.depth
+}
+
+// NumNodes returns the number of stored nodes of the level, including the padding duplicate of odd levels,
+// or 0 if the level does not exist.
+func (a *ProofArchive) NumNodes(level int) int {
+	if level < 0 || level > a.h.depth {
+		return 0
+	}
+
+	if level == a.h.depth {
+		return 1
+	}
+
+	return int((a.offsets[level+1] - a.offsets[level]) / int64(a.h.nodeLenAt(level)))
+}
+
+// Node returns the stored node at idx of the level, see NumNodes.
+func (a *ProofArchive) Node(level, idx int) ([]byte, error) {
+	if idx < 0 || idx >= a.NumNodes(level) {
+		return nil, ErrIndexOutOfRange
+	}
+
+	if level == a.h.depth {
+		return a.Root, nil
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.node(level, idx)
+}
+
+// Config returns a verification configuration of the archived tree with the hash function,
+// restoring the SortSiblingPairs and DisableLeafHashing flags of the archive.
+func (a *ProofArchive) Config(hashFunc TypeHashFunc) *Config {
+	return &Config{
+		HashFunc:           hashFunc,
+		SortSiblingPairs:   a.h.flags&treeFlagSortSiblingPairs != 0,
+		DisableLeafHashing: a.h.flags&treeFlagDisableLeafHashing != 0,
+	}
+}
+
+// Leaf returns the leaf at idx.
+func (a *ProofArchive) Leaf(idx int) ([]byte, error) {
+	if idx < 0 || idx >= a.h.numLeaves {
+		return nil, ErrIndexOutOfRange
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.node(0, idx)
+}
+
+// Proof returns the proof of the leaf at idx.
+func (a *ProofArchive) Proof(idx int) (*Proof, error) {
+	if idx < 0 || idx >= a.h.numLeaves {
+		return nil, ErrIndexOutOfRange
+	}
+
+	proof := &Proof{Siblings: make([][]byte, a.h.depth)}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for level := 0; level < a.h.depth; level++ {
+		if idx&1 == 0 {
+			proof.Path |= 1 << level
+		}
+
+		sibling, err := a.node(level, idx^1)
+		if err != nil {
+			return nil, err
+		}
+
+		proof.Siblings[level] = sibling
+		idx >>= 1
+	}
+
+	return proof, nil
+}
+
+// node reads the node at idx of the level. The caller holds a read lock of mu.
+func (a *ProofArchive) node(level, idx int) ([]byte, error) {
+	var (
+		nodeLen = a.h.nodeLenAt(level)
+		offset  = a.offsets[level] + int64(idx)*int64(nodeLen)
+	)
+
+	// The root, stored last, was read when opening the archive, so the mapping holds every node.
+	if a.data != nil {
+		return a.data[offset : offset+int64(nodeLen) : offset+int64(nodeLen)], nil
+	}
+
+	node := make([]byte, nodeLen)
+	if _, err := a.r.ReadAt(node, offset); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+	}
+
+	return node, nil
+}
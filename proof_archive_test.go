@@ -0,0 +1,96 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestProofArchive(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		num    int
+	}{
+		{name: "test_2_proof_gen", num: 2},
+		{name: "test_5_proof_gen", num: 5},
+		{name: "test_13_proof_gen_sorted", config: &Config{SortSiblingPairs: true}, num: 13},
+		{name: "test_100_tree_build", config: &Config{Mode: ModeTreeBuild}, num: 100},
+		{name: "test_33_proof_gen_and_tree_build", config: &Config{Mode: ModeProofGenAndTreeBuild}, num: 33},
+		{name: "test_9_parallel", config: &Config{RunInParallel: true}, num: 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocksFixedSize(tt.num)
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			var buf bytes.Buffer
+			if _, err := WriteProofArchive(&buf, m); err != nil {
+				t.Fatalf("WriteProofArchive() error = %v", err)
+			}
+			a, err := OpenProofArchive(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("OpenProofArchive() error = %v", err)
+			}
+			if !bytes.Equal(a.Root, m.Root) || a.NumLeaves() != tt.num {
+				t.Fatalf("OpenProofArchive() root = %x, leaves = %d", a.Root, a.NumLeaves())
+			}
+			config := a.Config(nil)
+			for i, block := range blocks {
+				proof, err := a.Proof(i)
+				if err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+				want, err := m.proofByIndex(i)
+				if err != nil {
+					t.Fatalf("proofByIndex() error = %v", err)
+				}
+				if !reflect.DeepEqual(proof, want) {
+					t.Errorf("Proof() %d = %v, want %v", i, proof, want)
+				}
+				if ok, err := Verify(block, proof, a.Root, config); err != nil || !ok {
+					t.Errorf("Verify() %d = %v, error = %v", i, ok, err)
+				}
+			}
+			if a.Depth() != m.Depth || a.NumNodes(0) != tt.num+tt.num&1 || a.NumNodes(a.Depth()) != 1 {
+				t.Fatalf("Depth() = %d, NumNodes() = %d", a.Depth(), a.NumNodes(0))
+			}
+			if root, err := a.Node(a.Depth(), 0); err != nil || !bytes.Equal(root, m.Root) {
+				t.Errorf("Node() root = %x, error = %v", root, err)
+			}
+			if leaf, err := a.Node(0, a.NumNodes(0)-1); err != nil || !bytes.Equal(leaf, m.Leaves[tt.num-1]) {
+				t.Errorf("Node() last leaf = %x, error = %v", leaf, err)
+			}
+			if _, err := a.Node(1, a.NumNodes(1)); err != ErrIndexOutOfRange {
+				t.Errorf("Node() error = %v, want %v", err, ErrIndexOutOfRange)
+			}
+			if _, err := a.Proof(tt.num); err != ErrIndexOutOfRange {
+				t.Errorf("Proof() error = %v, want %v", err, ErrIndexOutOfRange)
+			}
+		})
+	}
+}
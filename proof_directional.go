@@ -0,0 +1,158 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// solidityWordSize is the size of an EVM ABI word in bytes.
+const solidityWordSize = 32
+
+// DirectionalProof is a Merkle proof with explicit left/right positions for every sibling.
+// It targets position-aware verifiers (e.g. Solidity contracts that do NOT sort sibling pairs),
+// where the verifier needs to know on which side each sibling is concatenated.
+type DirectionalProof struct {
+	// Siblings are the sibling nodes from the leaf level up to the level below the root.
+	Siblings [][]byte
+	// SiblingIsLeft indicates, for each sibling, whether it is the left operand of the concatenation.
+	SiblingIsLeft []bool
+}
+
+// Directional converts the proof into a DirectionalProof.
+// The conversion can be done per proof, regardless of the SortSiblingPairs configuration
+// used to build the tree, so that a single tree can serve both sorted and position-aware verifiers.
+func (p *Proof) Directional() *DirectionalProof {
+	var (
+		path = p.Path
+		dp   = &DirectionalProof{
+			Siblings:      make([][]byte, len(p.Siblings)),
+			SiblingIsLeft: make([]bool, len(p.Siblings)),
+		}
+	)
+
+	for i, sib := range p.Siblings {
+		dp.Siblings[i] = sib
+		// A set path bit means the current node is on the left, so the sibling is on the right.
+		dp.SiblingIsLeft[i] = path&1 == 0
+		path >>= 1
+	}
+
+	return dp
+}
+
+// Proof converts the DirectionalProof back into a Proof.
+func (dp *DirectionalProof) Proof() *Proof {
+	proof := &Proof{
+		Siblings: make([][]byte, len(dp.Siblings)),
+	}
+	copy(proof.Siblings, dp.Siblings)
+
+	for i, isLeft := range dp.SiblingIsLeft {
+		if !isLeft {
+			proof.Path |= 1 << i
+		}
+	}
+
+	return proof
+}
+
+// SolidityABIEncode encodes the proof as the ABI encoding of (bytes32[] siblings, bool[] siblingIsLeft),
+// which can be decoded by a Solidity contract with abi.decode(data, (bytes32[], bool[])) or passed as the
+// members of a struct { bytes32[] siblings; bool[] siblingIsLeft; }.
+// Siblings shorter than 32 bytes are right-padded with zeros, as for any bytes32 value.
+func (dp *DirectionalProof) SolidityABIEncode() ([]byte, error) {
+	if len(dp.Siblings) != len(dp.SiblingIsLeft) {
+		return nil, ErrDirectionalProofLengthMismatch
+	}
+
+	var (
+		numSiblings = len(dp.Siblings)
+		// Each dynamic array is encoded as its length followed by its elements.
+		arrayLen  = solidityWordSize * (numSiblings + 1)
+		buf       = new(bytes.Buffer)
+		wordBytes = make([]byte, solidityWordSize)
+	)
+
+	buf.Grow(2*solidityWordSize + 2*arrayLen)
+	// Head: offsets of the two dynamic arrays.
+	buf.Write(solidityUint(wordBytes, uint64(2*solidityWordSize)))
+	buf.Write(solidityUint(wordBytes, uint64(2*solidityWordSize+arrayLen)))
+
+	// Tail: the siblings array.
+	buf.Write(solidityUint(wordBytes, uint64(numSiblings)))
+
+	for _, sib := range dp.Siblings {
+		if len(sib) > solidityWordSize {
+			return nil, ErrProofSiblingTooLong
+		}
+
+		clear(wordBytes)
+		copy(wordBytes, sib)
+		buf.Write(wordBytes)
+	}
+
+	// Tail: the direction array.
+	buf.Write(solidityUint(wordBytes, uint64(numSiblings)))
+
+	for _, isLeft := range dp.SiblingIsLeft {
+		var v uint64
+		if isLeft {
+			v = 1
+		}
+
+		buf.Write(solidityUint(wordBytes, v))
+	}
+
+	return buf.Bytes(), nil
+}
+
+// solidityUint writes v as a big-endian 256-bit unsigned integer into word and returns it.
+func solidityUint(word []byte, v uint64) []byte {
+	clear(word)
+	binary.BigEndian.PutUint64(word[solidityWordSize-8:], v)
+
+	return word
+}
+
+// VerifyDirectional checks if the data block is valid using the DirectionalProof and the provided Merkle root hash.
+// The sibling positions of the proof are always respected, so the SortSiblingPairs configuration is ignored.
+func VerifyDirectional(dataBlock DataBlock, proof *DirectionalProof, root []byte, config *Config) (bool, error) {
+	if proof == nil {
+		return false, ErrProofIsNil
+	}
+
+	if len(proof.Siblings) != len(proof.SiblingIsLeft) {
+		return false, ErrDirectionalProofLengthMismatch
+	}
+
+	if config == nil {
+		config = new(Config)
+	}
+
+	positional := *config
+	positional.SortSiblingPairs = false
+
+	return Verify(dataBlock, proof.Proof(), root, &positional)
+}
@@ -0,0 +1,134 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestProof_Directional(t *testing.T) {
+	tests := []struct {
+		name      string
+		numBlocks int
+		config    *Config
+	}{
+		{
+			name:      "test_2",
+			numBlocks: 2,
+		},
+		{
+			name:      "test_5",
+			numBlocks: 5,
+		},
+		{
+			name:      "test_100_sorted",
+			numBlocks: 100,
+			config:    &Config{SortSiblingPairs: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.numBlocks)
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for idx, proof := range m.Proofs {
+				dp := proof.Directional()
+				if got := dp.Proof(); !reflect.DeepEqual(got, proof) {
+					t.Errorf("Proof() %d got = %v, want %v", idx, got, proof)
+					return
+				}
+				ok, err := VerifyDirectional(blocks[idx], dp, m.Root, nil)
+				if err != nil {
+					t.Errorf("VerifyDirectional() %d error = %v", idx, err)
+					return
+				}
+				if !ok {
+					t.Errorf("VerifyDirectional() %d failed", idx)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestDirectionalProof_SolidityABIEncode(t *testing.T) {
+	tests := []struct {
+		name    string
+		proof   *DirectionalProof
+		want    string
+		wantErr error
+	}{
+		{
+			name: "test_two_siblings",
+			proof: &DirectionalProof{
+				Siblings:      [][]byte{{0x01}, {0x02}},
+				SiblingIsLeft: []bool{true, false},
+			},
+			want: "0000000000000000000000000000000000000000000000000000000000000040" +
+				"00000000000000000000000000000000000000000000000000000000000000a0" +
+				"0000000000000000000000000000000000000000000000000000000000000002" +
+				"0100000000000000000000000000000000000000000000000000000000000000" +
+				"0200000000000000000000000000000000000000000000000000000000000000" +
+				"0000000000000000000000000000000000000000000000000000000000000002" +
+				"0000000000000000000000000000000000000000000000000000000000000001" +
+				"0000000000000000000000000000000000000000000000000000000000000000",
+		},
+		{
+			name: "test_length_mismatch",
+			proof: &DirectionalProof{
+				Siblings:      [][]byte{{0x01}},
+				SiblingIsLeft: []bool{true, false},
+			},
+			wantErr: ErrDirectionalProofLengthMismatch,
+		},
+		{
+			name: "test_sibling_too_long",
+			proof: &DirectionalProof{
+				Siblings:      [][]byte{make([]byte, 33)},
+				SiblingIsLeft: []bool{true},
+			},
+			wantErr: ErrProofSiblingTooLong,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.proof.SolidityABIEncode()
+			if err != tt.wantErr {
+				t.Errorf("SolidityABIEncode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			want, _ := hex.DecodeString(tt.want)
+			if !bytes.Equal(got, want) {
+				t.Errorf("SolidityABIEncode() got = %x, want %x", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// SerializedProofWithData returns the serialized proof of the leaf at idx embedding both the leaf hash and
+// the serialized data block, so that the proof can be checked with VerifyEmbedded without any other context.
+// It returns ErrProofInvalidDataBlock if the data block is not the leaf at idx.
+func (m *MerkleTree) SerializedProofWithData(idx int, dataBlock DataBlock) (*SerializedProof, error) {
+	if dataBlock == nil {
+		return nil, ErrDataBlockIsNil
+	}
+
+	sp, err := m.SerializedProof(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := dataBlock.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := bytesToLeaf(data, m.leafHashFunc(), m.DisableLeafHashing)
+	if err != nil {
+		return nil, err
+	}
+
+	if idx < len(m.Leaves) && !bytes.Equal(leaf, m.Leaves[idx]) {
+		return nil, ErrProofInvalidDataBlock
+	}
+
+	sp.Leaf = leaf
+	sp.Data = data
+
+	return sp, nil
+}
+
+// SerializedProofWithDataRef returns the serialized proof of the leaf at idx embedding the leaf hash and
+// a reference to the data block instead of the data block itself, e.g. for large data blocks stored elsewhere.
+// The verifier resolves the reference and sets Data before calling VerifyEmbedded.
+func (m *MerkleTree) SerializedProofWithDataRef(idx int, ref string) (*SerializedProof, error) {
+	if idx < 0 || idx >= len(m.Leaves) {
+		return nil, ErrIndexOutOfRange
+	}
+
+	sp, err := m.SerializedProof(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	sp.Leaf = m.Leaves[idx]
+	sp.DataRef = ref
+
+	return sp, nil
+}
+
+// VerifyEmbedded checks the serialized proof against the root using its embedded data, after checking the
+// configuration as VerifySerialized does. The leaf computed from the data must match the embedded leaf hash,
+// if any, or ErrEmbeddedLeafMismatch is returned. Proofs without embedded data fail with ErrLeafDataMissing.
+func VerifyEmbedded(sp *SerializedProof, root []byte, config *Config) (bool, error) {
+	if sp == nil {
+		return false, ErrProofIsNil
+	}
+
+	if err := sp.CheckConfig(config); err != nil {
+		return false, err
+	}
+
+	if sp.Data == nil {
+		if sp.DataRef != "" {
+			return false, fmt.Errorf("%w: unresolved reference %q", ErrLeafDataMissing, sp.DataRef)
+		}
+
+		return false, ErrLeafDataMissing
+	}
+
+	if config == nil {
+		config = new(Config)
+	}
+
+	if config.HashFunc == nil {
+		config.HashFunc = DefaultHashFunc
+	}
+
+	leaf, err := bytesToLeaf(sp.Data, config.leafHashFunc(), config.DisableLeafHashing)
+	if err != nil {
+		return false, err
+	}
+
+	if sp.Leaf != nil && !bytes.Equal(leaf, sp.Leaf) {
+		return false, ErrEmbeddedLeafMismatch
+	}
+
+	return config.verifyLeaf(leaf, sp.Proof().Siblings, sp.Path, root)
+}
@@ -0,0 +1,130 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestVerifyEmbedded(t *testing.T) {
+	blocks := mockDataBlocksFixedSize(7)
+	hashed, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	raw, err := New(&Config{DisableLeafHashing: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		tree    *MerkleTree
+		config  *Config
+		modify  func(sp *SerializedProof)
+		want    bool
+		wantErr error
+	}{
+		{name: "test_hashed", tree: hashed, want: true},
+		{name: "test_raw", tree: raw, config: &Config{DisableLeafHashing: true}, want: true},
+		{name: "test_no_leaf_hash", tree: hashed, modify: func(sp *SerializedProof) { sp.Leaf = nil }, want: true},
+		{
+			name:    "test_tampered_data",
+			tree:    hashed,
+			modify:  func(sp *SerializedProof) { sp.Data[0] ^= 1 },
+			wantErr: ErrEmbeddedLeafMismatch,
+		},
+		{
+			name:   "test_tampered_data_and_leaf",
+			tree:   hashed,
+			modify: func(sp *SerializedProof) { sp.Data[0] ^= 1; sp.Leaf = nil },
+			want:   false,
+		},
+		{name: "test_missing_data", tree: hashed, modify: func(sp *SerializedProof) { sp.Data = nil }, wantErr: ErrLeafDataMissing},
+		{name: "test_policy_mismatch", tree: raw, wantErr: ErrLeafHashingMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp, err := tt.tree.SerializedProofWithData(3, blocks[3])
+			if err != nil {
+				t.Fatalf("SerializedProofWithData() error = %v", err)
+			}
+			data, err := json.Marshal(sp)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if sp, err = UnmarshalProof(data); err != nil {
+				t.Fatalf("UnmarshalProof() error = %v", err)
+			}
+			if tt.modify != nil {
+				tt.modify(sp)
+			}
+			got, err := VerifyEmbedded(sp, tt.tree.Root, tt.config)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyEmbedded() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyEmbedded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerkleTree_SerializedProofWithData_wrongBlock(t *testing.T) {
+	blocks := mockDataBlocks(5)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := m.SerializedProofWithData(1, blocks[2]); !errors.Is(err, ErrProofInvalidDataBlock) {
+		t.Errorf("SerializedProofWithData() error = %v, want %v", err, ErrProofInvalidDataBlock)
+	}
+	if _, err := m.SerializedProofWithData(1, nil); !errors.Is(err, ErrDataBlockIsNil) {
+		t.Errorf("SerializedProofWithData() error = %v, want %v", err, ErrDataBlockIsNil)
+	}
+}
+
+func TestMerkleTree_SerializedProofWithDataRef(t *testing.T) {
+	blocks := mockDataBlocks(5)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	sp, err := m.SerializedProofWithDataRef(4, "ipfs://leaf-4")
+	if err != nil {
+		t.Fatalf("SerializedProofWithDataRef() error = %v", err)
+	}
+	if _, err := VerifyEmbedded(sp, m.Root, nil); !errors.Is(err, ErrLeafDataMissing) {
+		t.Fatalf("VerifyEmbedded() error = %v, want %v", err, ErrLeafDataMissing)
+	}
+	if sp.Data, err = blocks[4].Serialize(); err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+	if ok, err := VerifyEmbedded(sp, m.Root, nil); err != nil || !ok {
+		t.Errorf("VerifyEmbedded() = %v, %v, want true", ok, err)
+	}
+	if _, err := m.SerializedProofWithDataRef(5, "ref"); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("SerializedProofWithDataRef() error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+}
@@ -0,0 +1,267 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// HexBytes is a byte slice encoded in JSON as a 0x-prefixed hexadecimal string.
+type HexBytes []byte
+
+// MarshalJSON encodes the bytes as a 0x-prefixed hexadecimal string.
+func (b HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal("0x" + hex.EncodeToString(b))
+}
+
+// UnmarshalJSON decodes a hexadecimal string, with or without 0x prefix, in any case.
+func (b *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	decoded, err := decodeHex(s)
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+
+	return nil
+}
+
+// decodeHex decodes a hexadecimal string, with or without 0x prefix, in any case.
+func decodeHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+
+	return hex.DecodeString(s)
+}
+
+// SiblingOrder is the order of the siblings of a serialized proof.
+type SiblingOrder string
+
+const (
+	// SiblingsLeafFirst lists the siblings from the leaf level up, as Proof. It is the default, encoded
+	// by omitting the order.
+	SiblingsLeafFirst SiblingOrder = ""
+	// SiblingsRootFirst lists the siblings from the level below the root down.
+	SiblingsRootFirst SiblingOrder = "rootFirst"
+)
+
+// valid reports whether the order is known.
+func (o SiblingOrder) valid() bool {
+	return o == SiblingsLeafFirst || o == SiblingsRootFirst
+}
+
+// UnmarshalJSON decodes the order, rejecting unknown orders with ErrInvalidSiblingOrder.
+func (o *SiblingOrder) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	if !SiblingOrder(s).valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidSiblingOrder, s)
+	}
+
+	*o = SiblingOrder(s)
+
+	return nil
+}
+
+// SerializedProof is the JSON serialization format of proofs.
+type SerializedProof struct {
+	// Siblings are the sibling nodes of the proof, in the order given by Order.
+	Siblings []HexBytes `json:"siblings"`
+	// Order is the order of Siblings, from the leaf level up by default, see Reorder.
+	Order SiblingOrder `json:"order,omitempty"`
+	// Path is the proof path, see Proof. Its bit i is the position of the node at level i whatever the order.
+	Path uint32 `json:"path"`
+	// TreeHead is the optional signed tree head the proof was generated against.
+	TreeHead *SignedTreeHead `json:"treeHead,omitempty"`
+	// Meta is the optional JSON-encoded metadata of the leaf, see MerkleTree.LeafMeta.
+	Meta json.RawMessage `json:"meta,omitempty"`
+	// MetaHash is the optional hash of the JSON-encoded metadata of the leaf, disclosed instead of Meta.
+	MetaHash HexBytes `json:"metaHash,omitempty"`
+	// LeafHashing is the optional leaf hashing policy of the tree: true if its leaves are hashed data blocks,
+	// false if leaf hashing is disabled. Verifying with a configuration of the other policy fails with
+	// ErrLeafHashingMismatch instead of returning false.
+	LeafHashing *bool `json:"leafHashing,omitempty"`
+	// Leaf is the optional leaf hash, embedded with Data or DataRef, see MerkleTree.SerializedProofWithData.
+	Leaf HexBytes `json:"leaf,omitempty"`
+	// Data is the optional serialized data block of the leaf, making the proof self-contained, see VerifyEmbedded.
+	Data HexBytes `json:"data,omitempty"`
+	// DataRef is the optional reference to the serialized data block of the leaf, e.g. a URI or a content
+	// address, embedded instead of Data.
+	DataRef string `json:"dataRef,omitempty"`
+}
+
+// NewSerializedProof creates the serialized form of the proof.
+// A signed tree head can be attached by setting TreeHead.
+func NewSerializedProof(proof *Proof) *SerializedProof {
+	sp := &SerializedProof{
+		Siblings: make([]HexBytes, len(proof.Siblings)),
+		Path:     proof.Path,
+	}
+
+	for i, sib := range proof.Siblings {
+		sp.Siblings[i] = sib
+	}
+
+	return sp
+}
+
+// SerializedProof returns the serialized proof of the leaf at idx, recording the leaf hashing policy of the tree.
+func (m *MerkleTree) SerializedProof(idx int) (*SerializedProof, error) {
+	proof, err := m.proofByIndex(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	sp := NewSerializedProof(proof)
+	leafHashing := !m.DisableLeafHashing
+	sp.LeafHashing = &leafHashing
+
+	if err := sp.Reorder(m.ProofSiblingOrder); err != nil {
+		return nil, err
+	}
+
+	return sp, nil
+}
+
+// Reorder lists the siblings of the serialized proof in the order, reversing them if it differs from
+// the current one, for ecosystems that expect the siblings root-first. It returns ErrInvalidSiblingOrder
+// if either order is unknown.
+func (sp *SerializedProof) Reorder(order SiblingOrder) error {
+	if !order.valid() || !sp.Order.valid() {
+		return fmt.Errorf("%w: %q to %q", ErrInvalidSiblingOrder, sp.Order, order)
+	}
+
+	if order != sp.Order {
+		slices.Reverse(sp.Siblings)
+		sp.Order = order
+	}
+
+	return nil
+}
+
+// CheckConfig returns ErrLeafHashingMismatch if the leaf hashing policy recorded in the serialized proof
+// differs from the one of the verifier configuration. Proofs without a recorded policy pass the check.
+func (sp *SerializedProof) CheckConfig(config *Config) error {
+	if sp.LeafHashing == nil {
+		return nil
+	}
+
+	disabled := config != nil && config.DisableLeafHashing
+	if *sp.LeafHashing == disabled {
+		return fmt.Errorf("%w: proof leaf hashing %t, verifier leaf hashing %t",
+			ErrLeafHashingMismatch, *sp.LeafHashing, !disabled)
+	}
+
+	return nil
+}
+
+// Proof returns the proof carried by the serialized proof, with its siblings from the leaf level up
+// whatever the order of the serialized proof.
+func (sp *SerializedProof) Proof() *Proof {
+	proof := &Proof{
+		Siblings: make([][]byte, len(sp.Siblings)),
+		Path:     sp.Path,
+	}
+
+	for i, sib := range sp.Siblings {
+		proof.Siblings[i] = sib
+	}
+
+	if sp.Order == SiblingsRootFirst {
+		slices.Reverse(proof.Siblings)
+	}
+
+	return proof
+}
+
+// MarshalProof serializes the proof to JSON, embedding the signed tree head if it is not nil.
+func MarshalProof(proof *Proof, head *SignedTreeHead) ([]byte, error) {
+	if proof == nil {
+		return nil, ErrProofIsNil
+	}
+
+	sp := NewSerializedProof(proof)
+	sp.TreeHead = head
+
+	return json.Marshal(sp)
+}
+
+// UnmarshalProof deserializes a proof serialized by MarshalProof.
+func UnmarshalProof(data []byte) (*SerializedProof, error) {
+	sp := new(SerializedProof)
+	if err := json.Unmarshal(data, sp); err != nil {
+		return nil, err
+	}
+
+	return sp, nil
+}
+
+// VerifySerialized checks the data block against the root using the serialized proof, after checking that
+// the configuration matches the leaf hashing policy recorded in the proof, see CheckConfig.
+func VerifySerialized(dataBlock DataBlock, sp *SerializedProof, root []byte, config *Config) (bool, error) {
+	if sp == nil {
+		return false, ErrProofIsNil
+	}
+
+	if err := sp.CheckConfig(config); err != nil {
+		return false, err
+	}
+
+	return Verify(dataBlock, sp.Proof(), root, config)
+}
+
+// VerifyWithTreeHead checks the data block against the root of the signed tree head embedded in the proof.
+// The tree head must be present, carry a valid signature and be fresh according to the policy,
+// so that proofs against stale or unauthenticated roots are rejected in one call.
+func VerifyWithTreeHead(dataBlock DataBlock, sp *SerializedProof, policy *TreeHeadPolicy,
+	config *Config,
+) (bool, error) {
+	if sp == nil {
+		return false, ErrProofIsNil
+	}
+
+	if policy == nil {
+		policy = new(TreeHeadPolicy)
+	}
+
+	if err := policy.check(sp.TreeHead); err != nil {
+		return false, err
+	}
+
+	if err := sp.CheckConfig(config); err != nil {
+		return false, err
+	}
+
+	return Verify(dataBlock, sp.Proof(), sp.TreeHead.Root, config)
+}
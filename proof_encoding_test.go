@@ -0,0 +1,247 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalProof(t *testing.T) {
+	blocks := mockDataBlocks(7)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data, err := MarshalProof(m.Proofs[4], nil)
+	if err != nil {
+		t.Fatalf("MarshalProof() error = %v", err)
+	}
+	if strings.Contains(string(data), "treeHead") {
+		t.Errorf("MarshalProof() without tree head should omit it: %s", data)
+	}
+	sp, err := UnmarshalProof(data)
+	if err != nil {
+		t.Fatalf("UnmarshalProof() error = %v", err)
+	}
+	if !sp.Proof().Equal(m.Proofs[4]) {
+		t.Errorf("UnmarshalProof() got = %v, want %v", sp.Proof(), m.Proofs[4])
+	}
+	if _, err := UnmarshalProof([]byte(`{"siblings":["0xzz"]}`)); err == nil {
+		t.Errorf("UnmarshalProof() expected error for invalid hex")
+	}
+}
+
+func TestVerifyWithTreeHead(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	blocks := mockDataBlocks(9)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	signedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	head, err := NewSignedTreeHead(m, signedAt, Ed25519Signer(priv))
+	if err != nil {
+		t.Fatalf("NewSignedTreeHead() error = %v", err)
+	}
+	now := func() time.Time { return signedAt.Add(time.Hour) }
+
+	tests := []struct {
+		name    string
+		head    *SignedTreeHead
+		policy  *TreeHeadPolicy
+		want    bool
+		wantErr error
+	}{
+		{
+			name:   "test_valid",
+			head:   head,
+			policy: &TreeHeadPolicy{Verifier: Ed25519Verifier(pub), MaxAge: 2 * time.Hour, Now: now},
+			want:   true,
+		},
+		{
+			name:    "test_missing_head",
+			policy:  &TreeHeadPolicy{Verifier: Ed25519Verifier(pub)},
+			wantErr: ErrTreeHeadMissing,
+		},
+		{
+			name:    "test_wrong_key",
+			head:    head,
+			policy:  &TreeHeadPolicy{Verifier: Ed25519Verifier(otherPub)},
+			wantErr: ErrTreeHeadSignature,
+		},
+		{
+			name: "test_tampered_root",
+			head: &SignedTreeHead{
+				Root:      []byte("forged root"),
+				TreeSize:  head.TreeSize,
+				Timestamp: head.Timestamp,
+				Signature: head.Signature,
+			},
+			policy:  &TreeHeadPolicy{Verifier: Ed25519Verifier(pub)},
+			wantErr: ErrTreeHeadSignature,
+		},
+		{
+			name:    "test_stale",
+			head:    head,
+			policy:  &TreeHeadPolicy{Verifier: Ed25519Verifier(pub), MaxAge: 30 * time.Minute, Now: now},
+			wantErr: ErrTreeHeadStale,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := MarshalProof(m.Proofs[2], tt.head)
+			if err != nil {
+				t.Fatalf("MarshalProof() error = %v", err)
+			}
+			sp, err := UnmarshalProof(data)
+			if err != nil {
+				t.Fatalf("UnmarshalProof() error = %v", err)
+			}
+			got, err := VerifyWithTreeHead(blocks[2], sp, tt.policy, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyWithTreeHead() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("VerifyWithTreeHead() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifySerialized_leafHashingMismatch(t *testing.T) {
+	blocks := mockDataBlocksFixedSize(6)
+	hashed, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	raw, err := New(&Config{DisableLeafHashing: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		tree    *MerkleTree
+		config  *Config
+		strip   bool
+		want    bool
+		wantErr error
+	}{
+		{name: "test_hashed", tree: hashed, want: true},
+		{name: "test_raw", tree: raw, config: &Config{DisableLeafHashing: true}, want: true},
+		{name: "test_hashed_verified_raw", tree: hashed, config: &Config{DisableLeafHashing: true}, wantErr: ErrLeafHashingMismatch},
+		{name: "test_raw_verified_hashed", tree: raw, wantErr: ErrLeafHashingMismatch},
+		// Proofs serialized without the policy cannot detect the mismatch.
+		{name: "test_no_policy", tree: raw, strip: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp, err := tt.tree.SerializedProof(2)
+			if err != nil {
+				t.Fatalf("SerializedProof() error = %v", err)
+			}
+			if tt.strip {
+				sp.LeafHashing = nil
+			}
+			data, err := json.Marshal(sp)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if sp, err = UnmarshalProof(data); err != nil {
+				t.Fatalf("UnmarshalProof() error = %v", err)
+			}
+			got, err := VerifySerialized(blocks[2], sp, tt.tree.Root, tt.config)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifySerialized() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("VerifySerialized() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializedProof_Reorder(t *testing.T) {
+	blocks := mockDataBlocks(13)
+
+	m, err := New(&Config{ProofSiblingOrder: SiblingsRootFirst}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	sp, err := m.SerializedProof(5)
+	if err != nil {
+		t.Fatalf("SerializedProof() error = %v", err)
+	}
+
+	if sp.Order != SiblingsRootFirst || !bytes.Equal(sp.Siblings[0], m.Proofs[5].Siblings[m.Depth-1]) {
+		t.Fatalf("SerializedProof() siblings are not root-first")
+	}
+
+	data, err := json.Marshal(sp)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	decoded, err := UnmarshalProof(data)
+	if err != nil {
+		t.Fatalf("UnmarshalProof() error = %v", err)
+	}
+
+	if !decoded.Proof().Equal(m.Proofs[5]) {
+		t.Errorf("Proof() of the root-first proof differs from the tree proof")
+	}
+
+	if ok, err := VerifySerialized(blocks[5], decoded, m.Root, nil); err != nil || !ok {
+		t.Errorf("VerifySerialized() = %v, %v, want true", ok, err)
+	}
+
+	if err := decoded.Reorder(SiblingsLeafFirst); err != nil {
+		t.Fatalf("Reorder() error = %v", err)
+	}
+
+	if !bytes.Equal(decoded.Siblings[0], m.Proofs[5].Siblings[0]) || !decoded.Proof().Equal(m.Proofs[5]) {
+		t.Errorf("Reorder() siblings are not leaf-first")
+	}
+
+	if err := decoded.Reorder("middleOut"); !errors.Is(err, ErrInvalidSiblingOrder) {
+		t.Errorf("Reorder() error = %v, want %v", err, ErrInvalidSiblingOrder)
+	}
+
+	if _, err := UnmarshalProof([]byte(`{"siblings":[],"order":"middleOut","path":0}`)); !errors.Is(err, ErrInvalidSiblingOrder) {
+		t.Errorf("UnmarshalProof() error = %v, want %v", err, ErrInvalidSiblingOrder)
+	}
+}
@@ -0,0 +1,261 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+)
+
+const (
+	proofEnvelopeVersion = 1
+	// proofEnvelopeSigningDomain separates envelope signatures from any other signature made with the same key.
+	proofEnvelopeSigningDomain = "go-merkletree proof envelope v1"
+	// proofEnvelopeKeyDomain separates envelope encryption keys from any other use of the shared secret.
+	proofEnvelopeKeyDomain = "go-merkletree proof envelope key v1"
+)
+
+// ProofEnvelope wraps a serialized proof signed by its issuer, and optionally encrypted to its recipient,
+// for delivery to end users.
+type ProofEnvelope struct {
+	// Version is the envelope format version.
+	Version int `json:"version"`
+	// KeyID optionally identifies the issuer key, e.g. to select the verifier among rotated keys.
+	KeyID string `json:"keyId,omitempty"`
+	// Audience is the optional identity of the recipient the proof is issued to, e.g. an account address.
+	Audience HexBytes `json:"audience,omitempty"`
+	// Challenge is the optional recipient-supplied nonce the proof is issued for.
+	Challenge HexBytes `json:"challenge,omitempty"`
+	// Payload is the JSON-encoded SerializedProof, or its ciphertext if the envelope is encrypted.
+	Payload HexBytes `json:"payload"`
+	// Encryption holds the parameters of the payload encryption, nil if the payload is not encrypted.
+	Encryption *EnvelopeEncryption `json:"encryption,omitempty"`
+	// Signature is the issuer signature over the SigningInput of the envelope and the plaintext payload.
+	Signature HexBytes `json:"signature"`
+}
+
+// EnvelopeEncryption holds the parameters of an encrypted envelope payload: AES-256-GCM under a key
+// derived from the X25519 exchange between the ephemeral key and the recipient key.
+type EnvelopeEncryption struct {
+	// EphemeralKey is the ephemeral X25519 public key of the issuer.
+	EphemeralKey HexBytes `json:"ephemeralKey"`
+	// Nonce is the AES-GCM nonce.
+	Nonce HexBytes `json:"nonce"`
+}
+
+// EnvelopeOptions are the options of SealProof.
+type EnvelopeOptions struct {
+	// KeyID optionally identifies the issuer key.
+	KeyID string
+	// Recipient is the X25519 public key the payload is encrypted to. If nil, the payload is not encrypted.
+	Recipient *ecdh.PublicKey
+	// Audience is the identity of the recipient the envelope is bound to, e.g. an account address.
+	Audience []byte
+	// Challenge is the recipient-supplied nonce the envelope is bound to, e.g. issued by a token-gated
+	// service to the recipient for a single access.
+	Challenge []byte
+}
+
+// SealProof signs the serialized proof with the issuer signer and wraps it into an envelope,
+// encrypted to the recipient if one is set in the options.
+func SealProof(sp *SerializedProof, signer TreeHeadSigner, opts *EnvelopeOptions) (*ProofEnvelope, error) {
+	if sp == nil {
+		return nil, ErrProofIsNil
+	}
+
+	if opts == nil {
+		opts = new(EnvelopeOptions)
+	}
+
+	payload, err := json.Marshal(sp)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &ProofEnvelope{
+		Version:   proofEnvelopeVersion,
+		KeyID:     opts.KeyID,
+		Audience:  opts.Audience,
+		Challenge: opts.Challenge,
+	}
+
+	if env.Signature, err = signer.Sign(env.SigningInput(payload)); err != nil {
+		return nil, err
+	}
+
+	if opts.Recipient == nil {
+		env.Payload = payload
+
+		return env, nil
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := envelopeAEAD(ephemeral, opts.Recipient, ephemeral.PublicKey(), opts.Recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	env.Encryption = &EnvelopeEncryption{
+		EphemeralKey: ephemeral.PublicKey().Bytes(),
+		Nonce:        make([]byte, aead.NonceSize()),
+	}
+
+	if _, err := rand.Read(env.Encryption.Nonce); err != nil {
+		return nil, err
+	}
+
+	env.Payload = aead.Seal(nil, env.Encryption.Nonce, payload, env.additionalData())
+
+	return env, nil
+}
+
+// OpenProof decrypts the envelope with the recipient key if it is encrypted, verifies the issuer signature
+// and returns the serialized proof. The recipient key may be nil for envelopes that are not encrypted.
+// The proof itself is not verified: verify it against its root, e.g. with VerifyWithTreeHead.
+// The recipient binding is not checked either, see OpenBoundProof.
+func OpenProof(env *ProofEnvelope, verifier TreeHeadVerifier, recipient *ecdh.PrivateKey) (*SerializedProof, error) {
+	if env == nil {
+		return nil, ErrProofIsNil
+	}
+
+	if env.Version != proofEnvelopeVersion {
+		return nil, ErrInvalidEnvelope
+	}
+
+	payload := []byte(env.Payload)
+
+	if env.Encryption != nil {
+		if recipient == nil {
+			return nil, ErrEnvelopeDecryption
+		}
+
+		ephemeral, err := ecdh.X25519().NewPublicKey(env.Encryption.EphemeralKey)
+		if err != nil {
+			return nil, ErrEnvelopeDecryption
+		}
+
+		aead, err := envelopeAEAD(recipient, ephemeral, ephemeral, recipient.PublicKey())
+		if err != nil {
+			return nil, ErrEnvelopeDecryption
+		}
+
+		if len(env.Encryption.Nonce) != aead.NonceSize() {
+			return nil, ErrEnvelopeDecryption
+		}
+
+		if payload, err = aead.Open(nil, env.Encryption.Nonce, payload, env.additionalData()); err != nil {
+			return nil, ErrEnvelopeDecryption
+		}
+	}
+
+	if verifier == nil || verifier.Verify(env.SigningInput(payload), env.Signature) != nil {
+		return nil, ErrEnvelopeSignature
+	}
+
+	sp := new(SerializedProof)
+	if err := json.Unmarshal(payload, sp); err != nil {
+		return nil, ErrInvalidEnvelope
+	}
+
+	return sp, nil
+}
+
+// OpenBoundProof opens the envelope as OpenProof, and checks that it is bound to the audience and challenge,
+// so that a proof issued to one user cannot be replayed by another, or reused for another access.
+// It returns ErrEnvelopeBinding if the envelope is bound to another audience or challenge, or is not bound.
+func OpenBoundProof(env *ProofEnvelope, verifier TreeHeadVerifier, recipient *ecdh.PrivateKey,
+	audience, challenge []byte) (*SerializedProof, error) {
+	sp, err := OpenProof(env, verifier, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	// The binding is covered by the signature checked by OpenProof.
+	if !bytes.Equal(env.Audience, audience) || !bytes.Equal(env.Challenge, challenge) {
+		return nil, ErrEnvelopeBinding
+	}
+
+	return sp, nil
+}
+
+// SigningInput returns the deterministic byte representation of the envelope and of its plaintext payload
+// covered by the issuer signature.
+func (env *ProofEnvelope) SigningInput(payload []byte) []byte {
+	buf := make([]byte, 0, len(proofEnvelopeSigningDomain)+len(env.KeyID)+len(env.Audience)+len(env.Challenge)+
+		len(payload)+17)
+	buf = append(buf, proofEnvelopeSigningDomain...)
+	buf = append(buf, byte(env.Version))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(env.KeyID)))
+	buf = append(buf, env.KeyID...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(env.Audience)))
+	buf = append(buf, env.Audience...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(env.Challenge)))
+	buf = append(buf, env.Challenge...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = append(buf, payload...)
+
+	return buf
+}
+
+// additionalData binds the signature and key ID to the ciphertext.
+func (env *ProofEnvelope) additionalData() []byte {
+	buf := make([]byte, 0, len(env.KeyID)+len(env.Signature)+8)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(env.KeyID)))
+	buf = append(buf, env.KeyID...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(env.Signature)))
+	buf = append(buf, env.Signature...)
+
+	return buf
+}
+
+// envelopeAEAD derives the AES-256-GCM cipher of the exchange between the private and the public key,
+// bound to the ephemeral and recipient public keys.
+func envelopeAEAD(priv *ecdh.PrivateKey, pub, ephemeral, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	shared, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(proofEnvelopeKeyDomain))
+	h.Write(shared)
+	h.Write(ephemeral.Bytes())
+	h.Write(recipient.Bytes())
+
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,189 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSealProof(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	recipient, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	eavesdropper, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	blocks := mockDataBlocks(9)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     *EnvelopeOptions
+		tamper   func(env *ProofEnvelope)
+		verifier TreeHeadVerifier
+		key      *ecdh.PrivateKey
+		wantErr  error
+	}{
+		{name: "test_signed", verifier: Ed25519Verifier(pub)},
+		{name: "test_signed_key_id", opts: &EnvelopeOptions{KeyID: "issuer-2024"}, verifier: Ed25519Verifier(pub)},
+		{name: "test_encrypted", opts: &EnvelopeOptions{Recipient: recipient.PublicKey()}, verifier: Ed25519Verifier(pub), key: recipient},
+		{name: "test_wrong_issuer", verifier: Ed25519Verifier(otherPub), wantErr: ErrEnvelopeSignature},
+		{
+			name:     "test_tampered_payload",
+			tamper:   func(env *ProofEnvelope) { env.Payload[len(env.Payload)-2] ^= 1 },
+			verifier: Ed25519Verifier(pub),
+			wantErr:  ErrEnvelopeSignature,
+		},
+		{
+			name:     "test_tampered_key_id",
+			opts:     &EnvelopeOptions{KeyID: "issuer-2024"},
+			tamper:   func(env *ProofEnvelope) { env.KeyID = "issuer-2025" },
+			verifier: Ed25519Verifier(pub),
+			wantErr:  ErrEnvelopeSignature,
+		},
+		{
+			name:     "test_wrong_recipient",
+			opts:     &EnvelopeOptions{Recipient: recipient.PublicKey()},
+			verifier: Ed25519Verifier(pub),
+			key:      eavesdropper,
+			wantErr:  ErrEnvelopeDecryption,
+		},
+		{
+			name:     "test_missing_recipient_key",
+			opts:     &EnvelopeOptions{Recipient: recipient.PublicKey()},
+			verifier: Ed25519Verifier(pub),
+			wantErr:  ErrEnvelopeDecryption,
+		},
+		{
+			name:     "test_tampered_ciphertext",
+			opts:     &EnvelopeOptions{Recipient: recipient.PublicKey()},
+			tamper:   func(env *ProofEnvelope) { env.Payload[0] ^= 1 },
+			verifier: Ed25519Verifier(pub),
+			key:      recipient,
+			wantErr:  ErrEnvelopeDecryption,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := SealProof(NewSerializedProof(m.Proofs[3]), Ed25519Signer(priv), tt.opts)
+			if err != nil {
+				t.Fatalf("SealProof() error = %v", err)
+			}
+			// Envelopes travel as JSON.
+			data, err := json.Marshal(env)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			env = new(ProofEnvelope)
+			if err := json.Unmarshal(data, env); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if tt.tamper != nil {
+				tt.tamper(env)
+			}
+			sp, err := OpenProof(env, tt.verifier, tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("OpenProof() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if ok, err := Verify(blocks[3], sp.Proof(), m.Root, nil); err != nil || !ok {
+				t.Errorf("Verify() = %v, %v", ok, err)
+			}
+		})
+	}
+}
+
+func TestOpenBoundProof(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	m, err := New(nil, mockDataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var (
+		alice     = []byte("0xa11ce")
+		challenge = []byte("nonce-1")
+	)
+	bound, err := SealProof(NewSerializedProof(m.Proofs[1]), Ed25519Signer(priv),
+		&EnvelopeOptions{Audience: alice, Challenge: challenge})
+	if err != nil {
+		t.Fatalf("SealProof() error = %v", err)
+	}
+	unbound, err := SealProof(NewSerializedProof(m.Proofs[1]), Ed25519Signer(priv), nil)
+	if err != nil {
+		t.Fatalf("SealProof() error = %v", err)
+	}
+	tests := []struct {
+		name      string
+		env       *ProofEnvelope
+		audience  []byte
+		challenge []byte
+		wantErr   error
+	}{
+		{name: "test_bound", env: bound, audience: alice, challenge: challenge},
+		{name: "test_other_audience", env: bound, audience: []byte("0xb0b"), challenge: challenge, wantErr: ErrEnvelopeBinding},
+		{name: "test_replayed_challenge", env: bound, audience: alice, challenge: []byte("nonce-2"), wantErr: ErrEnvelopeBinding},
+		{name: "test_unbound", env: unbound, audience: alice, challenge: challenge, wantErr: ErrEnvelopeBinding},
+		{
+			name: "test_rebound",
+			env: func() *ProofEnvelope {
+				rebound := *bound
+				rebound.Audience = []byte("0xb0b")
+				return &rebound
+			}(),
+			audience:  []byte("0xb0b"),
+			challenge: challenge,
+			wantErr:   ErrEnvelopeSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OpenBoundProof(tt.env, Ed25519Verifier(pub), nil, tt.audience, tt.challenge)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("OpenBoundProof() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
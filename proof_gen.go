@@ -24,7 +24,9 @@ package merkletree
 
 import (
 	"fmt"
-	"sync"
+	"math/bits"
+	"runtime"
+	"sync/atomic"
 
 	"golang.org/x/sync/errgroup"
 )
@@ -33,54 +35,220 @@ import (
 // It returns an error if there is an issue during the generation process.
 func (m *MerkleTree) proofGen() (err error) {
 	m.initProofs()
-	buffer, bufferSize := initBuffer(m.Leaves)
+	m.Root, err = m.proofGenSubtree(m.buildChildren, m.Leaves, m.Proofs, m.Depth, 0, 0)
 
-	for step := 0; step < m.Depth; step++ {
-		bufferSize = fixOddNumOfNodes(buffer, bufferSize, step)
-		m.updateProofs(buffer, bufferSize, step)
+	return
+}
 
-		for idx := 0; idx < bufferSize; idx += 2 {
-			leftIdx := idx << step
-			rightIdx := min(leftIdx+(1<<step), len(buffer)-1)
-			buffer[leftIdx], err = m.HashFunc(m.concatHashFunc(buffer[leftIdx], buffer[rightIdx]))
+// GenerateProofs generates the proofs of a ModeProofGen tree built with Config.DeferProofs into Proofs, once,
+// and returns them. It is safe for concurrent use. The proofs of other trees are returned as they are.
+func (m *MerkleTree) GenerateProofs() ([]*Proof, error) {
+	if err := m.generateDeferredProofs(); err != nil {
+		return nil, err
+	}
 
-			if err != nil {
-				return
-			}
-		}
+	return m.Proofs, nil
+}
 
-		bufferSize >>= 1
+// generateDeferredProofs generates the proofs of a tree built with Config.DeferProofs if they are not yet.
+// The root computed by New is left untouched.
+func (m *MerkleTree) generateDeferredProofs() error {
+	if m.Mode != ModeProofGen || !m.DeferProofs || m.ProofSink != nil {
+		return nil
 	}
 
-	m.Root = buffer[0]
+	m.proofsOnce.Do(func() {
+		// Checkpointed builds generate the proofs whatever the configuration.
+		if m.Proofs != nil {
+			return
+		}
+
+		proofs := m.newProofs()
 
-	return
+		if m.RunInParallel {
+			levels := m.subtreeLevels(m.NumLeaves, m.NumRoutines, m.Depth)
+			_, m.proofsErr = m.proofGenPartitioned(m.Leaves, proofs, nil, 0, levels, m.Depth,
+				m.forEachSubtree(m.NumRoutines, -1), m.subtreeBuilder(levels))
+		} else {
+			_, m.proofsErr = m.proofGenSubtree(m.buildChildren, m.Leaves, proofs, m.Depth, 0, 0)
+		}
+
+		if m.proofsErr != nil {
+			return
+		}
+
+		for i, proof := range proofs {
+			proofs[i] = m.injectProofFault(i, proof)
+		}
+
+		m.Proofs = proofs
+	})
+
+	return m.proofsErr
 }
 
+// minSubtreeLevels is the minimum number of levels of the subtrees built by a goroutine
+// in proofGenParallel, below which the synchronization costs more than the hashing.
+const minSubtreeLevels = 8
+
+// subtreesPerRoutine is the number of subtrees per goroutine in proofGenParallel,
+// so that goroutines finishing early pick up more work.
+const subtreesPerRoutine = 4
+
 // proofGenParallel generates proofs concurrently for the MerkleTree.
-func (m *MerkleTree) proofGenParallel() error {
+// The leaves are partitioned into aligned subtrees of 2^k leaves, each built with the proofs
+// of its leaves by a single goroutine, so that goroutines never share buffers or proofs.
+// The small top tree over the subtree roots is then built, and its proofs appended to the proofs
+// of the leaves of each subtree.
+func (m *MerkleTree) proofGenParallel() (err error) {
 	m.initProofs()
-	buffer, bufferSize := initBuffer(m.Leaves)
-	numRoutines := m.NumRoutines
 
-	for step := 0; step < m.Depth; step++ {
-		// Limit the number of workers to the previous level length.
-		numRoutines = min(numRoutines, bufferSize)
-		bufferSize = fixOddNumOfNodes(buffer, bufferSize, step)
-		m.updateProofsParallel(buffer, bufferSize, step)
+	levels := m.subtreeLevels(m.NumLeaves, m.NumRoutines, m.Depth)
+	m.Root, err = m.proofGenPartitioned(m.Leaves, m.Proofs, nil, 0, levels, m.Depth,
+		m.forEachSubtree(m.NumRoutines, -1), m.subtreeBuilder(levels))
+
+	if err != nil {
+		return fmt.Errorf("proofGenParallel: %w", err)
+	}
+
+	return nil
+}
+
+// proofGenSharded generates proofs for the MerkleTree with one subtree per shard, see Config.NumShards.
+// Each shard is partitioned again among the goroutines of its group, which also compute its leaves.
+func (m *MerkleTree) proofGenSharded(blocks []DataBlock) (err error) {
+	m.initProofs()
+	m.Leaves = make([][]byte, m.NumLeaves)
+
+	var (
+		shardLevels = min(bits.Len(uint((m.NumLeaves+m.NumShards-1)/m.NumShards-1)), m.Depth)
+		numRoutines = max(m.NumRoutines/m.NumShards, 1)
+		shardLeaves = 1 << shardLevels
+		innerLevels = m.subtreeLevels(shardLeaves, numRoutines, shardLevels)
+	)
+
+	buildShard := func(shard, offset int, leaves [][]byte, proofs []*Proof, blocks []DataBlock) ([]byte, error) {
+		return m.proofGenPartitioned(leaves, proofs, blocks, offset, innerLevels, shardLevels,
+			m.forEachSubtree(numRoutines, shard), m.subtreeBuilder(innerLevels))
+	}
+
+	m.Root, err = m.proofGenPartitioned(m.Leaves, m.Proofs, blocks, 0, shardLevels, m.Depth,
+		m.forEachSubtree(m.NumShards, -1), buildShard)
+
+	if err != nil {
+		return fmt.Errorf("proofGenSharded: %w", err)
+	}
+
+	return nil
+}
+
+// subtreeLevels returns the number of levels of the subtrees numRoutines goroutines partition
+// numLeaves leaves into, with subtreesPerRoutine subtrees per goroutine and at most maxLevels levels.
+func (m *MerkleTree) subtreeLevels(numLeaves, numRoutines, maxLevels int) int {
+	chunk := (numLeaves + numRoutines*subtreesPerRoutine - 1) / (numRoutines * subtreesPerRoutine)
+
+	return min(max(bits.Len(uint(chunk-1)), minSubtreeLevels), maxLevels)
+}
+
+// subtreeBuilder builds the subtree i over its leaves, the leaves from the index offset of the whole tree,
+// appending to their proofs, and returns its root.
+// If blocks is not nil, the leaves are first computed from the data blocks.
+type subtreeBuilder func(i, offset int, leaves [][]byte, proofs []*Proof, blocks []DataBlock) ([]byte, error)
+
+// subtreeBuilder returns the builder of subtrees of the given number of levels by a single goroutine.
+func (m *MerkleTree) subtreeBuilder(levels int) subtreeBuilder {
+	hashFunc := m.leafHashFunc()
+
+	return func(_, offset int, leaves [][]byte, proofs []*Proof, blocks []DataBlock) (root []byte, err error) {
+		for j := range blocks {
+			if leaves[j], err = cachedDataBlockToLeaf(blocks[j], hashFunc, m.DisableLeafHashing, m.LeafCache); err != nil {
+				return nil, err
+			}
+		}
 
-		eg := new(errgroup.Group)
+		return m.proofGenSubtree(m.buildChildren, leaves, proofs, levels, 0, offset)
+	}
+}
+
+// proofGenPartitioned builds a tree of the given depth over the leaves, the leaves from the index offset
+// of the whole tree, which is a multiple of 2^depth: the subtrees of the given number of levels are built
+// with build through forEach, then the top tree over their roots, whose proofs are appended to the leaf proofs.
+// It returns the root.
+func (m *MerkleTree) proofGenPartitioned(leaves [][]byte, proofs []*Proof, blocks []DataBlock,
+	offset, levels, depth int,
+	forEach func(numSubtrees int, f func(int) error) error, build subtreeBuilder,
+) ([]byte, error) {
+	var (
+		numSubtrees = (len(leaves) + 1<<levels - 1) >> levels
+		roots       = make([][]byte, numSubtrees)
+	)
+
+	subtree := func(i int) (start, end int) {
+		return i << levels, min((i+1)<<levels, len(leaves))
+	}
+
+	err := forEach(numSubtrees, func(i int) (err error) {
+		start, end := subtree(i)
+
+		var subtreeBlocks []DataBlock
+		if blocks != nil {
+			subtreeBlocks = blocks[start:end]
+		}
+
+		roots[i], err = build(i, offset+start, leaves[start:end], proofs[start:end], subtreeBlocks)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		for workerIdx := 0; workerIdx < numRoutines; workerIdx++ {
-			startIdx := workerIdx << 1
+	topProofs := make([]*Proof, numSubtrees)
+	for i := range topProofs {
+		topProofs[i] = &Proof{Siblings: make([][]byte, 0, depth-levels)}
+	}
+
+	root, err := m.proofGenSubtree(m.buildChildren, roots, topProofs, depth-levels, levels, offset>>levels)
+	if err != nil {
+		return nil, err
+	}
 
+	err = forEach(numSubtrees, func(i int) error {
+		start, end := subtree(i)
+		for _, proof := range proofs[start:end] {
+			proof.Path |= topProofs[i].Path << levels
+			proof.Siblings = append(proof.Siblings, topProofs[i].Siblings...)
+		}
+
+		return nil
+	})
+
+	return root, err
+}
+
+// forEachSubtree returns a function running f for the subtrees 0 to numSubtrees-1 with numRoutines
+// goroutines pulling subtrees in order. If shard is not negative, the goroutines are locked to their
+// OS threads and initialized with ShardInit.
+func (m *MerkleTree) forEachSubtree(numRoutines, shard int) func(numSubtrees int, f func(int) error) error {
+	return func(numSubtrees int, f func(int) error) error {
+		var (
+			next atomic.Int64
+			eg   = new(errgroup.Group)
+		)
+
+		for r := 0; r < min(numRoutines, numSubtrees); r++ {
 			eg.Go(func() error {
-				var err error
-				for i := startIdx; i < bufferSize; i += numRoutines << 1 {
-					leftIdx := i << step
-					rightIdx := min(leftIdx+(1<<step), len(buffer)-1)
-					buffer[leftIdx], err = m.HashFunc(m.concatHashFunc(buffer[leftIdx], buffer[rightIdx]))
-					if err != nil {
+				if shard >= 0 {
+					runtime.LockOSThread()
+					defer runtime.UnlockOSThread()
+
+					if m.ShardInit != nil {
+						m.ShardInit(shard)
+					}
+				}
+
+				for i := int(next.Add(1) - 1); i < numSubtrees; i = int(next.Add(1) - 1) {
+					if err := f(i); err != nil {
 						return err
 					}
 				}
@@ -89,26 +257,53 @@ func (m *MerkleTree) proofGenParallel() error {
 			})
 		}
 
-		if err := eg.Wait(); err != nil {
-			return fmt.Errorf("proofGenParallel: %w", err)
+		return eg.Wait()
+	}
+}
+
+// proofGenSubtree builds the given number of levels over the leaves with hash, hashChildren or buildChildren, the leaves
+// being the nodes of the given level of the whole tree from the index offset, appending the siblings and path
+// bits of each level to their proofs, and returns the node reached at the top. Odd levels are padded by
+// duplicating their last node.
+func (m *MerkleTree) proofGenSubtree(hash typeChildrenHashFunc,
+	leaves [][]byte, proofs []*Proof, levels, level, offset int,
+) (root []byte, err error) {
+	buffer, bufferSize := initBuffer(leaves)
+
+	for step := 0; step < levels; step++ {
+		bufferSize = fixOddNumOfNodes(buffer, bufferSize, step)
+		updateProofs(proofs, buffer, bufferSize, step)
+
+		for idx := 0; idx < bufferSize; idx += 2 {
+			leftIdx := idx << step
+			rightIdx := min(leftIdx+(1<<step), len(buffer)-1)
+			buffer[leftIdx], err = hash(level+step+1, (offset>>step+idx)>>1, buffer[leftIdx], buffer[rightIdx])
+
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		bufferSize >>= 1
 	}
 
-	m.Root = buffer[0]
-
-	return nil
+	return buffer[0], nil
 }
 
 // initProofs initializes the MerkleTree's Proofs with the appropriate size and depth.
 // This is to reduce overhead of slice resizing during the generation process.
 func (m *MerkleTree) initProofs() {
-	m.Proofs = make([]*Proof, m.NumLeaves)
-	for i := 0; i < m.NumLeaves; i++ {
-		m.Proofs[i] = new(Proof)
-		m.Proofs[i].Siblings = make([][]byte, 0, m.Depth)
+	m.Proofs = m.newProofs()
+}
+
+// newProofs returns empty proofs for all the leaves, with room for their siblings.
+func (m *MerkleTree) newProofs() []*Proof {
+	proofs := make([]*Proof, m.NumLeaves)
+	for i := range proofs {
+		proofs[i] = &Proof{Siblings: make([][]byte, 0, m.Depth)}
 	}
+
+	return proofs
 }
 
 // initBuffer initializes the buffer with the leaves and returns the buffer size.
@@ -146,33 +341,11 @@ func fixOddNumOfNodes(buffer [][]byte, bufferSize, step int) int {
 }
 
 // updateProofs updates the proofs for all the leaves while constructing the Merkle Tree.
-func (m *MerkleTree) updateProofs(buffer [][]byte, bufferSize, step int) {
+func updateProofs(proofs []*Proof, buffer [][]byte, bufferSize, step int) {
 	batch := 1 << step
 	for i := 0; i < bufferSize; i += 2 {
-		updateProofInTwoBatches(m.Proofs, buffer, i, batch, step)
-	}
-}
-
-// updateProofsParallel updates the proofs for all the leaves while constructing the Merkle Tree in parallel.
-func (m *MerkleTree) updateProofsParallel(buffer [][]byte, bufferLength, step int) {
-	var (
-		batch = 1 << step
-		wg    sync.WaitGroup
-	)
-
-	numRoutines := min(m.NumRoutines, bufferLength)
-	wg.Add(numRoutines)
-
-	for startIdx := 0; startIdx < numRoutines; startIdx++ {
-		go func(startIdx int) {
-			defer wg.Done()
-
-			for i := startIdx; i < bufferLength; i += numRoutines << 1 {
-				updateProofInTwoBatches(m.Proofs, buffer, i, batch, step)
-			}
-		}(startIdx << 1)
+		updateProofInTwoBatches(proofs, buffer, i, batch, step)
 	}
-	wg.Wait()
 }
 
 // updateProofInTwoBatches updates the path and the siblings of the proof in two batches.
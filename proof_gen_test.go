@@ -24,15 +24,12 @@ package merkletree
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"sync/atomic"
 	"testing"
 
-	"github.com/agiledragon/gomonkey/v2"
-
 	"github.com/txaty/go-merkletree/mock"
 )
 
@@ -301,38 +298,25 @@ func TestMerkleTreeNew_modeProofGen(t *testing.T) {
 	}
 }
 
-func mockHashFunc(data []byte) ([]byte, error) {
-	sha256Func := sha256.New()
-	sha256Func.Write(data)
-	return sha256Func.Sum(nil), nil
-}
-
 func TestMerkleTree_proofGen(t *testing.T) {
-	patches := gomonkey.NewPatches()
-	defer patches.Reset()
 	type args struct {
 		config *Config
 		blocks []DataBlock
 	}
 	tests := []struct {
-		name    string
-		args    args
-		mock    func()
-		wantErr bool
+		name     string
+		args     args
+		hashFunc *mock.HashFunc
+		wantErr  bool
 	}{
 		{
 			name: "test_hash_func_err",
 			args: args{
-				config: &Config{
-					HashFunc: mockHashFunc,
-				},
+				config: &Config{},
 				blocks: mockDataBlocks(5),
 			},
-			mock: func() {
-				patches.ApplyFunc(mockHashFunc,
-					func([]byte) ([]byte, error) {
-						return nil, errors.New("test_hash_func_err")
-					})
+			hashFunc: &mock.HashFunc{
+				Err: errors.New("test_hash_func_err"),
 			},
 			wantErr: true,
 		},
@@ -344,10 +328,9 @@ func TestMerkleTree_proofGen(t *testing.T) {
 				t.Errorf("New() error = %v", err)
 				return
 			}
-			if tt.mock != nil {
-				tt.mock()
+			if tt.hashFunc != nil {
+				m.HashFunc = tt.hashFunc.Hash
 			}
-			defer patches.Reset()
 			if err := m.proofGen(); (err != nil) != tt.wantErr {
 				t.Errorf("proofGen() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -356,7 +339,10 @@ func TestMerkleTree_proofGen(t *testing.T) {
 }
 
 func TestMerkleTree_proofGenParallel(t *testing.T) {
-	var hashFuncCounter atomic.Uint32
+	hashFunc := &mock.HashFunc{
+		Err:       errors.New("test_goroutine_err"),
+		FailAfter: 9,
+	}
 	type args struct {
 		config *Config
 		blocks []DataBlock
@@ -370,13 +356,7 @@ func TestMerkleTree_proofGenParallel(t *testing.T) {
 			name: "test_goroutine_err",
 			args: args{
 				config: &Config{
-					HashFunc: func(data []byte) ([]byte, error) {
-						if hashFuncCounter.Load() == 9 {
-							return nil, errors.New("test_goroutine_err")
-						}
-						hashFuncCounter.Add(1)
-						return mockHashFunc(data)
-					},
+					HashFunc:      hashFunc.Hash,
 					RunInParallel: true,
 				},
 				blocks: mockDataBlocks(4),
@@ -397,3 +377,126 @@ func TestMerkleTree_proofGenParallel(t *testing.T) {
 		})
 	}
 }
+
+func TestMerkleTree_proofGenParallelSubtrees(t *testing.T) {
+	for _, numLeaves := range []int{2, 257, 1000, 2048, 5001, 9999} {
+		blocks := mockDataBlocksFixedSize(numLeaves)
+		want, err := New(nil, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for _, numRoutines := range []int{1, 2, 3, 16} {
+			t.Run(fmt.Sprintf("leaves=%d/routines=%d", numLeaves, numRoutines), func(t *testing.T) {
+				got, err := New(&Config{RunInParallel: true, NumRoutines: numRoutines}, blocks)
+				if err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+				if !bytes.Equal(got.Root, want.Root) {
+					t.Fatalf("root = %x, want %x", got.Root, want.Root)
+				}
+				for i := range want.Proofs {
+					if !got.Proofs[i].Equal(want.Proofs[i]) {
+						t.Fatalf("proof %d = %v, want %v", i, got.Proofs[i], want.Proofs[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestMerkleTree_proofGenSharded(t *testing.T) {
+	for _, numLeaves := range []int{2, 3, 1000, 4097, 10000} {
+		blocks := mockDataBlocksFixedSize(numLeaves)
+		want, err := New(nil, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for _, numShards := range []int{2, 3, 8} {
+			t.Run(fmt.Sprintf("leaves=%d/shards=%d", numLeaves, numShards), func(t *testing.T) {
+				var initialized atomic.Int32
+				got, err := New(&Config{
+					RunInParallel: true,
+					NumRoutines:   8,
+					NumShards:     numShards,
+					ShardInit: func(shard int) {
+						if shard < 0 || shard >= numShards {
+							t.Errorf("ShardInit() shard = %d", shard)
+						}
+						initialized.Add(1)
+					},
+				}, blocks)
+				if err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+				if initialized.Load() == 0 {
+					t.Error("ShardInit() not called")
+				}
+				if !bytes.Equal(got.Root, want.Root) {
+					t.Fatalf("root = %x, want %x", got.Root, want.Root)
+				}
+				for i := range want.Proofs {
+					if !bytes.Equal(got.Leaves[i], want.Leaves[i]) || !got.Proofs[i].Equal(want.Proofs[i]) {
+						t.Fatalf("proof %d = %v, want %v", i, got.Proofs[i], want.Proofs[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestConfig_DeferProofs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		num    int
+	}{
+		{name: "test_9", config: &Config{DeferProofs: true, HashFunc: DefaultHashFuncParallel}, num: 9},
+		{name: "test_1000_parallel", config: &Config{DeferProofs: true, RunInParallel: true, NumRoutines: 4}, num: 1000},
+		{name: "test_1000_sharded", config: &Config{DeferProofs: true, RunInParallel: true, NumShards: 4}, num: 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.num)
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			want, err := New(nil, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(m.Root, want.Root) {
+				t.Fatal("DeferProofs root differs from ModeProofGen")
+			}
+			if m.Proofs != nil {
+				t.Fatal("DeferProofs generated the proofs eagerly")
+			}
+
+			errs := make(chan error, 8)
+			for i := 0; i < cap(errs); i++ {
+				go func(i int) {
+					proof, err := m.SerializedProof(i)
+					if err == nil && !proof.Proof().Equal(want.Proofs[i]) {
+						err = fmt.Errorf("SerializedProof() of leaf %d differs from ModeProofGen", i)
+					}
+					errs <- err
+				}(i)
+			}
+			for i := 0; i < cap(errs); i++ {
+				if err := <-errs; err != nil {
+					t.Error(err)
+				}
+			}
+
+			proofs, err := m.GenerateProofs()
+			if err != nil {
+				t.Fatalf("GenerateProofs() error = %v", err)
+			}
+			for i := range proofs {
+				if !proofs[i].Equal(want.Proofs[i]) {
+					t.Fatalf("GenerateProofs() proof %d differs from ModeProofGen", i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "math/bits"
+
+// MaxProofDepth is the maximum depth of a proof, bounded by the width of Proof.Path.
+const MaxProofDepth = 32
+
+// Proof path encoding: bit i of Proof.Path (the least significant bit being the leaf level) is set when
+// the node at level i of the proven path is the left child of its parent, i.e. when Siblings[i] is
+// on its right. For the leaf at index idx, bit i is therefore the complement of bit i of idx.
+
+// PathFromIndex returns the path of the proof of the leaf at index in a tree of the depth.
+func PathFromIndex(index, depth int) uint32 {
+	return ^uint32(index) & pathMask(depth)
+}
+
+// IndexFromPath returns the index of the leaf proven by a proof of the path and depth.
+func IndexFromPath(path uint32, depth int) int {
+	return int(^path & pathMask(depth))
+}
+
+// NewProof builds the proof of the leaf at index in a tree of treeSize leaves from its siblings, from
+// the leaf level up, e.g. to verify a proof generated by another system. The siblings are not copied.
+// It returns ErrProofDepthMismatch if their number is not the depth of the tree.
+func NewProof(siblings [][]byte, index, treeSize int) (*Proof, error) {
+	if treeSize <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	if index < 0 || index >= treeSize {
+		return nil, ErrIndexOutOfRange
+	}
+
+	depth := bits.Len(uint(treeSize - 1))
+	if depth > MaxProofDepth || len(siblings) != depth {
+		return nil, ErrProofDepthMismatch
+	}
+
+	return &Proof{Siblings: siblings, Path: PathFromIndex(index, depth)}, nil
+}
+
+// IsRightSibling reports whether the sibling at level i of the proof is on the right of the proven path.
+func (p *Proof) IsRightSibling(i int) bool {
+	return p.Path>>i&1 == 1
+}
+
+// Index returns the index of the leaf proven by the proof, derived from its path and depth.
+func (p *Proof) Index() int {
+	return IndexFromPath(p.Path, len(p.Siblings))
+}
+
+// pathMask returns the mask of the path bits of a proof of the depth.
+func pathMask(depth int) uint32 {
+	if depth >= MaxProofDepth {
+		return ^uint32(0)
+	}
+
+	return uint32(1)<<depth - 1
+}
@@ -0,0 +1,118 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPathFromIndex(t *testing.T) {
+	for _, num := range []int{2, 3, 13, 64, 100} {
+		m, err := New(&Config{Mode: ModeProofGenAndTreeBuild}, mockDataBlocks(num))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for idx, proof := range m.Proofs {
+			if got := PathFromIndex(idx, m.Depth); got != proof.Path {
+				t.Fatalf("PathFromIndex(%d, %d) = %b, want %b", idx, m.Depth, got, proof.Path)
+			}
+			if got := proof.Index(); got != idx {
+				t.Fatalf("Index() = %d, want %d", got, idx)
+			}
+			for i := range proof.Siblings {
+				// The sibling on the right of the path has the next index at its level.
+				want := (idx>>i)&1 == 0
+				if got := proof.IsRightSibling(i); got != want {
+					t.Fatalf("IsRightSibling(%d) of leaf %d = %v, want %v", i, idx, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestIndexFromPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  uint32
+		depth int
+		want  int
+	}{
+		{name: "test_leftmost", path: 0b111, depth: 3, want: 0},
+		{name: "test_rightmost", path: 0, depth: 3, want: 7},
+		{name: "test_ignores_high_bits", path: 0xfffffff0 | 0b0101, depth: 4, want: 0b1010},
+		{name: "test_max_depth", path: 0xfffffffe, depth: MaxProofDepth, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IndexFromPath(tt.path, tt.depth); got != tt.want {
+				t.Errorf("IndexFromPath() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewProof(t *testing.T) {
+	blocks := mockDataBlocks(13)
+
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for idx, want := range m.Proofs {
+		proof, err := NewProof(want.Siblings, idx, m.NumLeaves)
+		if err != nil {
+			t.Fatalf("NewProof() error = %v", err)
+		}
+
+		if !proof.Equal(want) {
+			t.Fatalf("NewProof() of leaf %d = %v, want %v", idx, proof, want)
+		}
+
+		if ok, err := Verify(blocks[idx], proof, m.Root, nil); err != nil || !ok {
+			t.Fatalf("Verify() = %v, %v, want true", ok, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		siblings int
+		index    int
+		treeSize int
+		wantErr  error
+	}{
+		{name: "test_single_leaf", siblings: 0, index: 0, treeSize: 1, wantErr: ErrInvalidNumOfDataBlocks},
+		{name: "test_negative_index", siblings: 4, index: -1, treeSize: 13, wantErr: ErrIndexOutOfRange},
+		{name: "test_index_past_size", siblings: 4, index: 13, treeSize: 13, wantErr: ErrIndexOutOfRange},
+		{name: "test_too_few_siblings", siblings: 3, index: 0, treeSize: 13, wantErr: ErrProofDepthMismatch},
+		{name: "test_too_many_siblings", siblings: 5, index: 0, treeSize: 13, wantErr: ErrProofDepthMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewProof(make([][]byte, tt.siblings), tt.index, tt.treeSize); !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewProof() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
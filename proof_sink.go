@@ -0,0 +1,103 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "golang.org/x/sync/errgroup"
+
+// ProofSink receives the proofs of a ModeProofGen tree as they are generated, see Config.ProofSink.
+type ProofSink interface {
+	// WriteProof receives the proof of the leaf at idx. The proof is not used by the tree afterwards.
+	WriteProof(idx int, proof *Proof) error
+}
+
+// ProofSinkFunc adapts a function to a ProofSink, e.g. to send the proofs to a channel.
+type ProofSinkFunc func(idx int, proof *Proof) error
+
+// WriteProof calls f(idx, proof).
+func (f ProofSinkFunc) WriteProof(idx int, proof *Proof) error {
+	return f(idx, proof)
+}
+
+// sinkBuild builds a ModeProofGen tree whose proofs are written to ProofSink instead of Proofs.
+// The tree is first built as a ModeLowMemory tree, storing the levels above the chunks of 2^RecomputeLevels
+// leaves, then the proofs of each chunk are generated together and written in leaf order, so that a single
+// chunk of proofs is held per goroutine. The chunk levels are hashed twice, without visiting them again.
+// Proofs of the built tree are still available with RecomputeLevels of recomputation, as in ModeLowMemory.
+func (m *MerkleTree) sinkBuild() error {
+	if err := m.lowMemoryBuild(); err != nil {
+		return err
+	}
+
+	var (
+		levels      = m.recomputeLevels()
+		numChunks   = (m.NumLeaves + 1<<levels - 1) >> levels
+		numRoutines = 1
+		eg          = new(errgroup.Group)
+	)
+
+	if m.RunInParallel {
+		numRoutines = min(m.NumRoutines, numChunks)
+	}
+
+	for r := 0; r < numRoutines; r++ {
+		r := r
+
+		eg.Go(func() error {
+			for k := r; k < numChunks; k += numRoutines {
+				if err := m.sinkChunk(k<<levels, levels); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return eg.Wait()
+}
+
+// sinkChunk generates the proofs of the chunk of leaves from start and writes them to ProofSink.
+func (m *MerkleTree) sinkChunk(start, levels int) error {
+	var (
+		leaves = m.Leaves[start:min(start+1<<levels, m.NumLeaves)]
+		proofs = make([]*Proof, len(leaves))
+	)
+
+	for i := range proofs {
+		proofs[i] = &Proof{Siblings: make([][]byte, 0, m.Depth)}
+	}
+
+	if _, err := m.proofGenSubtree(m.hashChildren, leaves, proofs, levels, 0, start); err != nil {
+		return err
+	}
+
+	for i, proof := range proofs {
+		m.appendTopSiblings(start+i, levels, proof)
+
+		if err := m.ProofSink.WriteProof(start+i, m.injectProofFault(start+i, proof)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestMerkleTreeNew_proofSink(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		num        int
+		checkpoint bool
+	}{
+		{name: "test_2", num: 2},
+		{name: "test_9", num: 9},
+		{name: "test_1000_recompute_levels", config: Config{RecomputeLevels: 3}, num: 1000},
+		{name: "test_1000_parallel", config: Config{RunInParallel: true, NumRoutines: 4}, num: 1000},
+		{name: "test_100_checkpointed", num: 100, checkpoint: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.num)
+
+			want, err := New(&Config{HashFunc: DefaultHashFuncParallel}, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			var (
+				mu     sync.Mutex
+				got    = make([]*Proof, tt.num)
+				config = tt.config
+			)
+
+			config.HashFunc = DefaultHashFuncParallel
+			config.ProofSink = ProofSinkFunc(func(idx int, proof *Proof) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if got[idx] != nil {
+					t.Errorf("WriteProof() called twice for leaf %d", idx)
+				}
+
+				got[idx] = proof
+
+				return nil
+			})
+
+			if tt.checkpoint {
+				config.CheckpointDir = t.TempDir()
+			}
+
+			m, err := New(&config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if m.Proofs != nil {
+				t.Error("New() stored the proofs written to ProofSink")
+			}
+
+			for i := range blocks {
+				if !got[i].Equal(want.Proofs[i]) {
+					t.Fatalf("WriteProof() of leaf %d differs from ModeProofGen", i)
+				}
+
+				proof, err := m.proofByIndex(i)
+				if err != nil || !proof.Equal(want.Proofs[i]) {
+					t.Fatalf("proofByIndex(%d) = %v, %v, want the ModeProofGen proof", i, proof, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMerkleTreeNew_proofSinkError(t *testing.T) {
+	errSink := errors.New("sink full")
+	config := &Config{
+		ProofSink: ProofSinkFunc(func(idx int, _ *Proof) error {
+			if idx == 3 {
+				return errSink
+			}
+
+			return nil
+		}),
+	}
+
+	if _, err := New(config, mockDataBlocks(8)); !errors.Is(err, errSink) {
+		t.Errorf("New() error = %v, want %v", err, errSink)
+	}
+}
@@ -0,0 +1,66 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// pathSize is the size in bytes of the path of a proof.
+const pathSize = 4
+
+// Depth returns the depth of the proof, i.e. its number of siblings.
+func (p *Proof) Depth() int {
+	return len(p.Siblings)
+}
+
+// SizeBytes returns the size in bytes of the proof: its siblings and its 4-byte path.
+func (p *Proof) SizeBytes() int {
+	size := pathSize
+	for _, sibling := range p.Siblings {
+		size += len(sibling)
+	}
+
+	return size
+}
+
+// EstimateProofSize returns the size in bytes of a proof in a tree of numLeaves leaves with the given
+// hash length and arity: arity-1 siblings per level plus the 4-byte path.
+// The trees of this package are binary; other arities are supported for capacity planning.
+func EstimateProofSize(numLeaves, hashLen, arity int) (int, error) {
+	if numLeaves <= 1 {
+		return 0, ErrInvalidNumOfDataBlocks
+	}
+
+	if arity < 2 {
+		return 0, ErrInvalidArity
+	}
+
+	return proofDepth(numLeaves, arity)*(arity-1)*hashLen + pathSize, nil
+}
+
+// proofDepth returns the number of levels of a tree of numLeaves leaves with the given arity.
+func proofDepth(numLeaves, arity int) int {
+	depth := 0
+	for n := 1; n < numLeaves; n *= arity {
+		depth++
+	}
+
+	return depth
+}
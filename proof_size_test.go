@@ -0,0 +1,76 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "testing"
+
+func TestProof_SizeBytes(t *testing.T) {
+	for _, num := range []int{2, 3, 8, 9, 1000} {
+		m, err := New(nil, mockDataBlocks(num))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		want, err := EstimateProofSize(num, 32, 2)
+		if err != nil {
+			t.Fatalf("EstimateProofSize() error = %v", err)
+		}
+		for _, proof := range m.Proofs {
+			if proof.Depth() != m.Depth {
+				t.Errorf("Depth() = %d, want %d", proof.Depth(), m.Depth)
+			}
+			if proof.SizeBytes() != want {
+				t.Errorf("SizeBytes() = %d, want %d", proof.SizeBytes(), want)
+			}
+		}
+	}
+}
+
+func TestEstimateProofSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		numLeaves int
+		hashLen   int
+		arity     int
+		want      int
+		wantErr   error
+	}{
+		{name: "test_binary_2", numLeaves: 2, hashLen: 32, arity: 2, want: 36},
+		{name: "test_binary_1024", numLeaves: 1024, hashLen: 32, arity: 2, want: 10*32 + 4},
+		{name: "test_binary_1025", numLeaves: 1025, hashLen: 32, arity: 2, want: 11*32 + 4},
+		{name: "test_quaternary_1024", numLeaves: 1024, hashLen: 32, arity: 4, want: 5*3*32 + 4},
+		{name: "test_hexadecimal_17", numLeaves: 17, hashLen: 20, arity: 16, want: 2*15*20 + 4},
+		{name: "test_invalid_leaves", numLeaves: 1, hashLen: 32, arity: 2, wantErr: ErrInvalidNumOfDataBlocks},
+		{name: "test_invalid_arity", numLeaves: 8, hashLen: 32, arity: 1, wantErr: ErrInvalidArity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EstimateProofSize(tt.numLeaves, tt.hashLen, tt.arity)
+			if err != tt.wantErr {
+				t.Fatalf("EstimateProofSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EstimateProofSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+
+	"github.com/txaty/go-merkletree/verifier"
+)
+
+// RootBlock is a DataBlock whose content is the Merkle root of another tree.
+// It is used to build trees of tree roots, whose proofs can be stitched onto the proofs of the inner trees.
+type RootBlock []byte
+
+// Serialize returns the root bytes.
+func (r RootBlock) Serialize() ([]byte, error) {
+	return r, nil
+}
+
+// CompositeProof proves the inclusion of a data block through a chain of nested trees:
+// the root of each tree is a leaf (as a RootBlock) of the next one.
+type CompositeProof struct {
+	// Proofs are the proofs in each tree, from the innermost tree containing the data block
+	// to the outermost tree whose root is verified.
+	Proofs []*Proof
+}
+
+// StitchProofs combines a proof in an inner tree with the proof of the inner root in an outer tree.
+// Further levels of nesting can be added with CompositeProof.Stitch.
+func StitchProofs(inner, outer *Proof) *CompositeProof {
+	return &CompositeProof{Proofs: []*Proof{inner, outer}}
+}
+
+// Stitch returns a new composite proof extended by the proof of its current top-level root in an outer tree.
+func (c *CompositeProof) Stitch(outer *Proof) *CompositeProof {
+	proofs := make([]*Proof, len(c.Proofs), len(c.Proofs)+1)
+	copy(proofs, c.Proofs)
+
+	return &CompositeProof{Proofs: append(proofs, outer)}
+}
+
+// VerifyComposite checks if the data block is included under the outermost root using the composite proof.
+// All the nested trees must share the configuration, and outer trees must be built over RootBlock data blocks.
+func VerifyComposite(dataBlock DataBlock, proof *CompositeProof, root []byte, config *Config) (bool, error) {
+	if dataBlock == nil {
+		return false, ErrDataBlockIsNil
+	}
+
+	if proof == nil || len(proof.Proofs) == 0 {
+		return false, ErrProofIsNil
+	}
+
+	if config == nil {
+		config = new(Config)
+	}
+
+	if config.HashFunc == nil {
+		config.HashFunc = DefaultHashFunc
+	}
+
+	var (
+		vc   = config.verifierConfig()
+		data []byte
+		err  error
+	)
+
+	if data, err = dataBlock.Serialize(); err != nil {
+		return false, err
+	}
+
+	for _, p := range proof.Proofs {
+		if p == nil {
+			return false, ErrProofIsNil
+		}
+
+		leaf, err := bytesToLeaf(data, config.leafHashFunc(), config.DisableLeafHashing)
+		if err != nil {
+			return false, err
+		}
+
+		// The root of this tree is the data of the leaf in the next one.
+		if data, err = verifier.ComputeRoot(leaf, p.Siblings, p.Path, vc); err != nil {
+			return false, err
+		}
+	}
+
+	return bytes.Equal(data, root), nil
+}
@@ -0,0 +1,91 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "testing"
+
+func TestVerifyComposite(t *testing.T) {
+	var (
+		shards      = make([]*MerkleTree, 3)
+		shardBlocks = make([][]DataBlock, 3)
+		shardRoots  = make([]DataBlock, 3)
+	)
+	for i := range shards {
+		shardBlocks[i] = mockDataBlocks(5 + i)
+		shard, err := New(nil, shardBlocks[i])
+		if err != nil {
+			t.Fatalf("New() shard error = %v", err)
+		}
+		shards[i] = shard
+		shardRoots[i] = RootBlock(shard.Root)
+	}
+	daily, err := New(nil, shardRoots)
+	if err != nil {
+		t.Fatalf("New() daily error = %v", err)
+	}
+	global, err := New(nil, []DataBlock{RootBlock(daily.Root), mockDataBlocks(1)[0]})
+	if err != nil {
+		t.Fatalf("New() global error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		block DataBlock
+		proof *CompositeProof
+		root  []byte
+		want  bool
+	}{
+		{
+			name:  "test_two_levels",
+			block: shardBlocks[1][3],
+			proof: StitchProofs(shards[1].Proofs[3], daily.Proofs[1]),
+			root:  daily.Root,
+			want:  true,
+		},
+		{
+			name:  "test_three_levels",
+			block: shardBlocks[2][6],
+			proof: StitchProofs(shards[2].Proofs[6], daily.Proofs[2]).Stitch(global.Proofs[0]),
+			root:  global.Root,
+			want:  true,
+		},
+		{
+			name:  "test_wrong_shard",
+			block: shardBlocks[1][3],
+			proof: StitchProofs(shards[1].Proofs[3], daily.Proofs[0]),
+			root:  daily.Root,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyComposite(tt.block, tt.proof, tt.root, nil)
+			if err != nil {
+				t.Errorf("VerifyComposite() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("VerifyComposite() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -27,18 +27,13 @@ import (
 	"reflect"
 	"testing"
 
-	"github.com/agiledragon/gomonkey/v2"
-
 	"github.com/txaty/go-merkletree/mock"
 )
 
 func TestMerkleTree_Proof(t *testing.T) {
-	patches := gomonkey.NewPatches()
-	defer patches.Reset()
 	tests := []struct {
 		name        string
 		config      *Config
-		mock        func()
 		blocks      []DataBlock
 		proofBlocks []DataBlock
 		wantErr     bool
@@ -78,13 +73,12 @@ func TestMerkleTree_Proof(t *testing.T) {
 		{
 			name:   "test_data_block_serialize_error",
 			config: &Config{Mode: ModeTreeBuild},
-			mock: func() {
-				patches.ApplyMethod(reflect.TypeOf(&mock.DataBlock{}), "Serialize",
-					func(*mock.DataBlock) ([]byte, error) {
-						return nil, errors.New("data block serialize error")
-					})
+			blocks: mockDataBlocks(5),
+			proofBlocks: []DataBlock{
+				&mock.DataBlock{
+					Err: errors.New("data block serialize error"),
+				},
 			},
-			blocks:  mockDataBlocks(5),
 			wantErr: true,
 		},
 	}
@@ -103,10 +97,6 @@ func TestMerkleTree_Proof(t *testing.T) {
 			if tt.proofBlocks == nil {
 				tt.proofBlocks = tt.blocks
 			}
-			if tt.mock != nil {
-				tt.mock()
-			}
-			defer patches.Reset()
 			for idx, block := range tt.proofBlocks {
 				got, err := m2.Proof(block)
 				if (err != nil) != tt.wantErr {
@@ -124,3 +114,53 @@ func TestMerkleTree_Proof(t *testing.T) {
 		})
 	}
 }
+
+func TestMerkleTree_ProofWithIndex(t *testing.T) {
+	blocks := mockDataBlocks(11)
+	tests := []struct {
+		name    string
+		mode    TypeConfigMode
+		wantErr error
+	}{
+		{name: "test_tree_build", mode: ModeTreeBuild},
+		{name: "test_proof_gen_and_tree_build", mode: ModeProofGenAndTreeBuild},
+		{name: "test_lazy", mode: ModeLazy},
+		{name: "test_low_memory", mode: ModeLowMemory},
+		{name: "test_proof_gen", mode: ModeProofGen, wantErr: ErrProofInvalidModeTreeNotBuilt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(&Config{Mode: tt.mode}, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			for i, block := range blocks {
+				if idx, err := m.IndexOf(block); err != nil || idx != uint64(i) {
+					t.Fatalf("IndexOf() = %d, %v, want %d", idx, err, i)
+				}
+
+				proof, idx, err := m.ProofWithIndex(block)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ProofWithIndex() error = %v, want %v", err, tt.wantErr)
+				}
+
+				if tt.wantErr != nil {
+					if idx != 0 {
+						t.Fatalf("ProofWithIndex() index = %d, want 0", idx)
+					}
+
+					continue
+				}
+
+				if idx != uint64(i) || proof.Index() != i {
+					t.Fatalf("ProofWithIndex() index = %d, proof index %d, want %d", idx, proof.Index(), i)
+				}
+			}
+
+			if idx, err := m.IndexOf(mockDataBlocks(1)[0]); !errors.Is(err, ErrProofInvalidDataBlock) || idx != 0 {
+				t.Errorf("IndexOf() = %d, %v, want 0, %v", idx, err, ErrProofInvalidDataBlock)
+			}
+		})
+	}
+}
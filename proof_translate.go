@@ -0,0 +1,144 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// PaddingScheme is the handling of the odd node of odd-sized levels.
+type PaddingScheme int
+
+const (
+	// PaddingDuplicate pairs the odd node with a duplicate of itself, as the trees of this package.
+	PaddingDuplicate PaddingScheme = iota
+	// PaddingPromote promotes the odd node to the next level unchanged, as in RFC 6962 and
+	// OpenZeppelin's multi-proof trees. Proofs skip the levels where the node is promoted.
+	PaddingPromote
+	// PaddingZero pairs the odd node with a zero-filled node of the same length.
+	PaddingZero
+)
+
+// TranslatedTree is the tree over the leaves of a Merkle Tree under another padding scheme,
+// to derive the proofs of a protocol version with a different odd-node handling.
+// Only binary trees are supported: proofs of other arities cannot be verified by this package.
+type TranslatedTree struct {
+	// Scheme is the padding scheme of the tree.
+	Scheme PaddingScheme
+	// Root is the Merkle root under the padding scheme.
+	Root []byte
+	// levels are the levels from the leaves up to the root, padded for PaddingDuplicate and PaddingZero.
+	levels    [][][]byte
+	numLeaves int
+}
+
+// Translate rebuilds the interior nodes of the tree under the padding scheme, reusing its leaves
+// without re-serializing or re-hashing the data blocks. Proofs of the translated tree verify with Verify
+// and the configuration of the tree against the translated root.
+// PaddingPromote is not supported with PositionalHashFunc, since its proofs do not reveal the node levels.
+func (m *MerkleTree) Translate(scheme PaddingScheme) (*TranslatedTree, error) {
+	if scheme < PaddingDuplicate || scheme > PaddingZero ||
+		(scheme == PaddingPromote && m.PositionalHashFunc != nil) {
+		return nil, ErrInvalidPaddingScheme
+	}
+
+	var (
+		level  = m.Leaves[:len(m.Leaves):len(m.Leaves)]
+		levels [][][]byte
+	)
+
+	for len(level) > 1 {
+		last := level[len(level)-1]
+		odd := len(level)&1 == 1
+
+		if odd && scheme == PaddingDuplicate {
+			level = append(level, last)
+		} else if odd && scheme == PaddingZero {
+			level = append(level, make([]byte, len(last)))
+		}
+
+		next := make([][]byte, 0, (len(level)+1)>>1)
+
+		for j := 0; j+1 < len(level); j += 2 {
+			hash, err := m.hashChildren(len(levels)+1, j>>1, level[j], level[j+1])
+			if err != nil {
+				return nil, err
+			}
+
+			next = append(next, hash)
+		}
+
+		// Only PaddingPromote leaves an odd level unpadded.
+		if len(level)&1 == 1 {
+			next = append(next, last)
+		}
+
+		levels = append(levels, level)
+		level = next
+	}
+
+	return &TranslatedTree{
+		Scheme:    scheme,
+		Root:      level[0],
+		levels:    levels,
+		numLeaves: m.NumLeaves,
+	}, nil
+}
+
+// Proof returns the proof of the leaf at idx under the padding scheme of the tree.
+// With PaddingPromote, the proof has no sibling for the levels where the node is promoted,
+// and the bits of its path are those of the siblings present.
+func (t *TranslatedTree) Proof(idx int) (*Proof, error) {
+	if idx < 0 || idx >= t.numLeaves {
+		return nil, ErrIndexOutOfRange
+	}
+
+	proof := new(Proof)
+
+	for _, level := range t.levels {
+		sibling := idx ^ 1
+		if sibling < len(level) {
+			if idx&1 == 0 {
+				proof.Path |= 1 << len(proof.Siblings)
+			}
+
+			proof.Siblings = append(proof.Siblings, level[sibling])
+		}
+
+		idx >>= 1
+	}
+
+	return proof, nil
+}
+
+// TranslateProof returns the proof of the leaf at idx under the padding scheme, with the translated root.
+// Translate the tree once to derive the proofs of many leaves.
+func (m *MerkleTree) TranslateProof(idx int, scheme PaddingScheme) (*Proof, []byte, error) {
+	t, err := m.Translate(scheme)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proof, err := t.Proof(idx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return proof, t.Root, nil
+}
@@ -0,0 +1,121 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMerkleTree_Translate(t *testing.T) {
+	hash := func(a, b []byte) []byte {
+		h, _ := DefaultHashFunc(concatHash(a, b))
+		return h
+	}
+	tests := []struct {
+		name     string
+		num      int
+		scheme   PaddingScheme
+		wantRoot func(leaves [][]byte) []byte
+	}{
+		{
+			name:   "test_3_promote",
+			num:    3,
+			scheme: PaddingPromote,
+			wantRoot: func(l [][]byte) []byte {
+				return hash(hash(l[0], l[1]), l[2])
+			},
+		},
+		{
+			name:   "test_3_zero",
+			num:    3,
+			scheme: PaddingZero,
+			wantRoot: func(l [][]byte) []byte {
+				return hash(hash(l[0], l[1]), hash(l[2], make([]byte, len(l[2]))))
+			},
+		},
+		{
+			name:   "test_5_promote",
+			num:    5,
+			scheme: PaddingPromote,
+			wantRoot: func(l [][]byte) []byte {
+				return hash(hash(hash(l[0], l[1]), hash(l[2], l[3])), l[4])
+			},
+		},
+		{name: "test_13_duplicate", num: 13, scheme: PaddingDuplicate},
+		{name: "test_13_promote", num: 13, scheme: PaddingPromote},
+		{name: "test_13_zero", num: 13, scheme: PaddingZero},
+		{name: "test_100_duplicate", num: 100, scheme: PaddingDuplicate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.num)
+			m, err := New(nil, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			translated, err := m.Translate(tt.scheme)
+			if err != nil {
+				t.Fatalf("Translate() error = %v", err)
+			}
+			if tt.wantRoot != nil && !bytes.Equal(translated.Root, tt.wantRoot(m.Leaves)) {
+				t.Fatal("Translate() root differs from the padding scheme root")
+			}
+			if tt.scheme == PaddingDuplicate && !bytes.Equal(translated.Root, m.Root) {
+				t.Fatal("Translate(PaddingDuplicate) root differs from the tree root")
+			}
+			for i, block := range blocks {
+				proof, err := translated.Proof(i)
+				if err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+				if tt.scheme == PaddingDuplicate && !proof.Equal(m.Proofs[i]) {
+					t.Fatalf("Proof() of leaf %d differs from the tree proof", i)
+				}
+				if ok, err := Verify(block, proof, translated.Root, nil); err != nil || !ok {
+					t.Fatalf("Verify() of leaf %d = %v, %v", i, ok, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMerkleTree_TranslateProof_powerOfTwo(t *testing.T) {
+	m, err := New(nil, mockDataBlocks(16))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// Trees without odd levels are the same under every padding scheme.
+	for _, scheme := range []PaddingScheme{PaddingDuplicate, PaddingPromote, PaddingZero} {
+		proof, root, err := m.TranslateProof(9, scheme)
+		if err != nil {
+			t.Fatalf("TranslateProof() error = %v", err)
+		}
+		if !bytes.Equal(root, m.Root) || !proof.Equal(m.Proofs[9]) {
+			t.Errorf("TranslateProof(%d) differs from the tree proof", scheme)
+		}
+	}
+	if _, _, err := m.TranslateProof(0, PaddingScheme(7)); err != ErrInvalidPaddingScheme {
+		t.Errorf("TranslateProof() error = %v, want %v", err, ErrInvalidPaddingScheme)
+	}
+}
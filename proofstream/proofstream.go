@@ -0,0 +1,321 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package proofstream defines the wire schema and the server and client helpers to stream proof archives,
+// levels of nodes and proofs, e.g. over gRPC, for the distributed build and replica sync use cases.
+//
+// The schema is proofstream.proto. Messages are encoded by hand, so that the package does not depend on
+// the protobuf and gRPC modules; gRPC services use them with Codec. The helpers take the Send and Recv
+// methods of the streams, which gRPC server and client streams implement.
+package proofstream
+
+import (
+	"errors"
+	"fmt"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+var (
+	// ErrInvalidMessage is the error for a malformed message.
+	ErrInvalidMessage = errors.New("proofstream: invalid message")
+	// ErrInvalidRequest is the error for a request out of the range of the served tree.
+	ErrInvalidRequest = errors.New("proofstream: invalid request")
+	// ErrUnexpectedChunk is the error for a received chunk that does not follow the previous one.
+	ErrUnexpectedChunk = errors.New("proofstream: unexpected chunk")
+	// ErrIncompleteStream is the error for a stream that ends before the requested data is received.
+	ErrIncompleteStream = errors.New("proofstream: incomplete stream")
+)
+
+// Message is implemented by the messages of the schema.
+type Message interface {
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+// ArchiveRequest requests the serialized tree from Offset, in chunks of at most ChunkSize bytes,
+// 0 for the server default.
+type ArchiveRequest struct {
+	Offset    uint64
+	ChunkSize uint32
+}
+
+// Marshal encodes the message.
+func (m *ArchiveRequest) Marshal() ([]byte, error) {
+	b := appendUint(nil, 1, m.Offset)
+
+	return appendUint(b, 2, uint64(m.ChunkSize)), nil
+}
+
+// Unmarshal decodes the message, skipping unknown fields.
+func (m *ArchiveRequest) Unmarshal(data []byte) error {
+	*m = ArchiveRequest{}
+
+	return decodeFields(data, func(num, typ int, v uint64, _ []byte) error {
+		switch num {
+		case 1:
+			m.Offset = v
+		case 2:
+			if v > 1<<32-1 {
+				return fmt.Errorf("%w: chunk size overflows uint32", ErrInvalidMessage)
+			}
+			m.ChunkSize = uint32(v)
+		default:
+			return nil
+		}
+
+		return checkType(num, typ, wireVarint)
+	})
+}
+
+// ArchiveChunk is the chunk of the serialized tree at Offset. TotalSize is the size of the whole serialized tree.
+type ArchiveChunk struct {
+	Offset    uint64
+	Data      []byte
+	TotalSize uint64
+}
+
+// Marshal encodes the message.
+func (m *ArchiveChunk) Marshal() ([]byte, error) {
+	b := appendUint(nil, 1, m.Offset)
+	b = appendBytes(b, 2, m.Data)
+
+	return appendUint(b, 3, m.TotalSize), nil
+}
+
+// Unmarshal decodes the message, skipping unknown fields.
+func (m *ArchiveChunk) Unmarshal(data []byte) error {
+	*m = ArchiveChunk{}
+
+	return decodeFields(data, func(num, typ int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			m.Offset = v
+		case 2:
+			m.Data = b
+			return checkType(num, typ, wireBytes)
+		case 3:
+			m.TotalSize = v
+		default:
+			return nil
+		}
+
+		return checkType(num, typ, wireVarint)
+	})
+}
+
+// LevelRequest requests the stored nodes Start to End-1 of the level, 0 for the leaves.
+// End 0 requests the nodes up to the end of the level.
+type LevelRequest struct {
+	Level uint32
+	Start uint64
+	End   uint64
+}
+
+// Marshal encodes the message.
+func (m *LevelRequest) Marshal() ([]byte, error) {
+	b := appendUint(nil, 1, uint64(m.Level))
+	b = appendUint(b, 2, m.Start)
+
+	return appendUint(b, 3, m.End), nil
+}
+
+// Unmarshal decodes the message, skipping unknown fields.
+func (m *LevelRequest) Unmarshal(data []byte) error {
+	*m = LevelRequest{}
+
+	return decodeFields(data, func(num, typ int, v uint64, _ []byte) error {
+		switch num {
+		case 1:
+			if v > 1<<32-1 {
+				return fmt.Errorf("%w: level overflows uint32", ErrInvalidMessage)
+			}
+			m.Level = uint32(v)
+		case 2:
+			m.Start = v
+		case 3:
+			m.End = v
+		default:
+			return nil
+		}
+
+		return checkType(num, typ, wireVarint)
+	})
+}
+
+// LevelChunk carries the consecutive nodes of the level from index Start.
+type LevelChunk struct {
+	Level uint32
+	Start uint64
+	Nodes [][]byte
+}
+
+// Marshal encodes the message.
+func (m *LevelChunk) Marshal() ([]byte, error) {
+	b := appendUint(nil, 1, uint64(m.Level))
+	b = appendUint(b, 2, m.Start)
+	for _, node := range m.Nodes {
+		b = appendBytesElem(b, 3, node)
+	}
+
+	return b, nil
+}
+
+// Unmarshal decodes the message, skipping unknown fields.
+func (m *LevelChunk) Unmarshal(data []byte) error {
+	*m = LevelChunk{}
+
+	return decodeFields(data, func(num, typ int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			if v > 1<<32-1 {
+				return fmt.Errorf("%w: level overflows uint32", ErrInvalidMessage)
+			}
+			m.Level = uint32(v)
+		case 2:
+			m.Start = v
+		case 3:
+			m.Nodes = append(m.Nodes, b)
+			return checkType(num, typ, wireBytes)
+		default:
+			return nil
+		}
+
+		return checkType(num, typ, wireVarint)
+	})
+}
+
+// ProofRequest requests the leaves and proofs of the leaves at Indices.
+type ProofRequest struct {
+	Indices []uint64
+}
+
+// Marshal encodes the message.
+func (m *ProofRequest) Marshal() ([]byte, error) {
+	return appendPacked(nil, 1, m.Indices), nil
+}
+
+// Unmarshal decodes the message, skipping unknown fields.
+func (m *ProofRequest) Unmarshal(data []byte) error {
+	*m = ProofRequest{}
+
+	return decodeFields(data, func(num, typ int, v uint64, b []byte) (err error) {
+		if num == 1 {
+			m.Indices, err = decodeUints(m.Indices, typ, v, b)
+		}
+
+		return err
+	})
+}
+
+// ProofMessage carries the leaf at Index and its proof.
+type ProofMessage struct {
+	Index    uint64
+	Leaf     []byte
+	Siblings [][]byte
+	Path     uint32
+}
+
+// NewProofMessage returns the message of the leaf at idx and its proof.
+func NewProofMessage(idx uint64, leaf []byte, proof *mt.Proof) *ProofMessage {
+	return &ProofMessage{
+		Index:    idx,
+		Leaf:     leaf,
+		Siblings: proof.Siblings,
+		Path:     proof.Path,
+	}
+}
+
+// Proof returns the proof carried by the message.
+func (m *ProofMessage) Proof() *mt.Proof {
+	return &mt.Proof{
+		Siblings: m.Siblings,
+		Path:     m.Path,
+	}
+}
+
+// Marshal encodes the message.
+func (m *ProofMessage) Marshal() ([]byte, error) {
+	b := appendUint(nil, 1, m.Index)
+	b = appendBytes(b, 2, m.Leaf)
+	for _, sibling := range m.Siblings {
+		b = appendBytesElem(b, 3, sibling)
+	}
+
+	return appendUint(b, 4, uint64(m.Path)), nil
+}
+
+// Unmarshal decodes the message, skipping unknown fields.
+func (m *ProofMessage) Unmarshal(data []byte) error {
+	*m = ProofMessage{}
+
+	return decodeFields(data, func(num, typ int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			m.Index = v
+		case 2:
+			m.Leaf = b
+			return checkType(num, typ, wireBytes)
+		case 3:
+			m.Siblings = append(m.Siblings, b)
+			return checkType(num, typ, wireBytes)
+		case 4:
+			if v > 1<<32-1 {
+				return fmt.Errorf("%w: path overflows uint32", ErrInvalidMessage)
+			}
+			m.Path = uint32(v)
+		default:
+			return nil
+		}
+
+		return checkType(num, typ, wireVarint)
+	})
+}
+
+// Codec encodes the messages of the schema. It implements the encoding.Codec interface of gRPC,
+// to be installed with grpc.ForceServerCodec and grpc.ForceCodec.
+type Codec struct{}
+
+// Name returns the content subtype of the codec.
+func (Codec) Name() string {
+	return "proto"
+}
+
+// Marshal encodes v, which must be a Message.
+func (Codec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not a proofstream message", ErrInvalidMessage, v)
+	}
+
+	return m.Marshal()
+}
+
+// Unmarshal decodes data into v, which must be a Message.
+func (Codec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(Message)
+	if !ok {
+		return fmt.Errorf("%w: %T is not a proofstream message", ErrInvalidMessage, v)
+	}
+
+	return m.Unmarshal(data)
+}
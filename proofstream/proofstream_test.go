@@ -0,0 +1,98 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proofstream
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Message
+		zero Message
+	}{
+		{name: "archive_request", m: &ArchiveRequest{Offset: 1 << 40, ChunkSize: 4096}, zero: &ArchiveRequest{}},
+		{name: "archive_chunk", m: &ArchiveChunk{Offset: 7, Data: []byte("data"), TotalSize: 300}, zero: &ArchiveChunk{}},
+		{name: "level_request", m: &LevelRequest{Level: 3, Start: 2, End: 9}, zero: &LevelRequest{}},
+		{name: "level_chunk", m: &LevelChunk{Level: 1, Nodes: [][]byte{{1, 2}, {}, {3}}}, zero: &LevelChunk{}},
+		{name: "proof_request", m: &ProofRequest{Indices: []uint64{0, 300, 1 << 33}}, zero: &ProofRequest{}},
+		{name: "proof_message", m: &ProofMessage{Index: 5, Leaf: []byte{9}, Siblings: [][]byte{{1}, {2}}, Path: 5},
+			zero: &ProofMessage{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var codec Codec
+			data, err := codec.Marshal(tt.m)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			// Unknown fields are skipped.
+			data = append(data, 0x78, 0x01, 0x7a, 0x01, 0xff)
+			if err := codec.Unmarshal(data, tt.zero); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.zero, tt.m) {
+				t.Errorf("Unmarshal() = %+v, want %+v", tt.zero, tt.m)
+			}
+		})
+	}
+}
+
+func TestMessages_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Message
+		data []byte
+	}{
+		{name: "truncated_varint", m: &ArchiveRequest{}, data: []byte{0x08, 0x80}},
+		{name: "field_zero", m: &ArchiveRequest{}, data: []byte{0x00, 0x01}},
+		{name: "chunk_size_overflow", m: &ArchiveRequest{}, data: []byte{0x10, 0x80, 0x80, 0x80, 0x80, 0x10}},
+		{name: "truncated_bytes", m: &ArchiveChunk{}, data: []byte{0x12, 0x05, 0x01}},
+		{name: "wrong_wire_type", m: &LevelChunk{}, data: []byte{0x18, 0x01}},
+		{name: "group_wire_type", m: &ProofMessage{}, data: []byte{0x0b}},
+		{name: "invalid_packed", m: &ProofRequest{}, data: []byte{0x0a, 0x01, 0x80}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.m.Unmarshal(tt.data); !errors.Is(err, ErrInvalidMessage) {
+				t.Errorf("Unmarshal() error = %v, want %v", err, ErrInvalidMessage)
+			}
+		})
+	}
+	if _, err := (Codec{}).Marshal("not a message"); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Marshal() error = %v, want %v", err, ErrInvalidMessage)
+	}
+}
+
+func TestProofRequest_Unpacked(t *testing.T) {
+	var m ProofRequest
+	if err := m.Unmarshal([]byte{0x08, 0x03, 0x0a, 0x02, 0x04, 0x05, 0x08, 0x06}); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := []uint64{3, 4, 5, 6}; !reflect.DeepEqual(m.Indices, want) {
+		t.Errorf("Unmarshal() indices = %v, want %v", m.Indices, want)
+	}
+}
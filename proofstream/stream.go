@@ -0,0 +1,302 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proofstream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+const (
+	// DefaultChunkSize is the number of bytes per chunk of the server if the request does not set it.
+	DefaultChunkSize = 1 << 20
+	// MaxChunkSize bounds the chunk size requested by clients, under the 4 MiB default message limit of gRPC.
+	MaxChunkSize = 3 << 20
+)
+
+// ArchiveSender is the server stream of StreamArchive.
+type ArchiveSender interface {
+	Send(*ArchiveChunk) error
+}
+
+// LevelSender is the server stream of StreamLevel.
+type LevelSender interface {
+	Send(*LevelChunk) error
+}
+
+// ProofSender is the server stream of StreamProofs.
+type ProofSender interface {
+	Send(*ProofMessage) error
+}
+
+// ArchiveReceiver is the client stream of StreamArchive.
+type ArchiveReceiver interface {
+	Recv() (*ArchiveChunk, error)
+}
+
+// LevelReceiver is the client stream of StreamLevel.
+type LevelReceiver interface {
+	Recv() (*LevelChunk, error)
+}
+
+// ProofReceiver is the client stream of StreamProofs.
+type ProofReceiver interface {
+	Recv() (*ProofMessage, error)
+}
+
+// Server serves the ProofStream service from a serialized tree, see merkletree.WriteProofArchive.
+type Server struct {
+	r       io.ReaderAt
+	size    int64
+	archive *mt.ProofArchive
+}
+
+// NewServer returns the server of the serialized tree of size bytes read from r.
+func NewServer(r io.ReaderAt, size int64) (*Server, error) {
+	archive, err := mt.OpenProofArchive(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{r: r, size: size, archive: archive}, nil
+}
+
+// Archive returns the proof archive served.
+func (s *Server) Archive() *mt.ProofArchive {
+	return s.archive
+}
+
+// StreamArchive sends the serialized tree from the requested offset.
+func (s *Server) StreamArchive(req *ArchiveRequest, stream ArchiveSender) error {
+	if req.Offset > uint64(s.size) {
+		return fmt.Errorf("%w: offset %d beyond size %d", ErrInvalidRequest, req.Offset, s.size)
+	}
+
+	chunkSize := chunkSize(req.ChunkSize)
+	for offset := int64(req.Offset); offset < s.size; {
+		data := make([]byte, min(int64(chunkSize), s.size-offset))
+		if _, err := s.r.ReadAt(data, offset); err != nil {
+			return err
+		}
+
+		if err := stream.Send(&ArchiveChunk{Offset: uint64(offset), Data: data, TotalSize: uint64(s.size)}); err != nil {
+			return err
+		}
+
+		offset += int64(len(data))
+	}
+
+	return nil
+}
+
+// StreamLevel sends the requested nodes of the level, in chunks of about DefaultChunkSize bytes.
+func (s *Server) StreamLevel(req *LevelRequest, stream LevelSender) error {
+	if uint64(req.Level) > uint64(s.archive.Depth()) {
+		return fmt.Errorf("%w: level %d beyond depth %d", ErrInvalidRequest, req.Level, s.archive.Depth())
+	}
+
+	numNodes := uint64(s.archive.NumNodes(int(req.Level)))
+	end := req.End
+	if end == 0 {
+		end = numNodes
+	}
+
+	if req.Start > end || end > numNodes {
+		return fmt.Errorf("%w: nodes %d to %d of level %d with %d nodes",
+			ErrInvalidRequest, req.Start, end, req.Level, numNodes)
+	}
+
+	chunk := &LevelChunk{Level: req.Level, Start: req.Start}
+	size := 0
+
+	for idx := req.Start; idx < end; idx++ {
+		node, err := s.archive.Node(int(req.Level), int(idx))
+		if err != nil {
+			return err
+		}
+
+		chunk.Nodes = append(chunk.Nodes, node)
+		size += len(node)
+
+		if size >= DefaultChunkSize {
+			if err := stream.Send(chunk); err != nil {
+				return err
+			}
+
+			chunk = &LevelChunk{Level: req.Level, Start: idx + 1}
+			size = 0
+		}
+	}
+
+	if len(chunk.Nodes) == 0 {
+		return nil
+	}
+
+	return stream.Send(chunk)
+}
+
+// StreamProofs sends the leaves and proofs of the requested leaves, in the requested order.
+func (s *Server) StreamProofs(req *ProofRequest, stream ProofSender) error {
+	for _, idx := range req.Indices {
+		if idx >= uint64(s.archive.NumLeaves()) {
+			return fmt.Errorf("%w: leaf %d of %d", ErrInvalidRequest, idx, s.archive.NumLeaves())
+		}
+
+		leaf, err := s.archive.Leaf(int(idx))
+		if err != nil {
+			return err
+		}
+
+		proof, err := s.archive.Proof(int(idx))
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(NewProofMessage(idx, leaf, proof)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// chunkSize returns the chunk size of a request, bounded by MaxChunkSize.
+func chunkSize(requested uint32) int {
+	if requested == 0 {
+		return DefaultChunkSize
+	}
+
+	return min(int(requested), MaxChunkSize)
+}
+
+// ReceiveArchive writes the serialized tree received from the stream to w, and returns the number of bytes
+// written. offset is the offset of the request: the chunks must be contiguous from it to the total size.
+func ReceiveArchive(stream ArchiveReceiver, w io.Writer, offset uint64) (int64, error) {
+	var (
+		written   int64
+		totalSize = offset
+	)
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return written, err
+		}
+
+		if chunk.Offset != offset || (written > 0 && chunk.TotalSize != totalSize) ||
+			chunk.TotalSize < offset+uint64(len(chunk.Data)) {
+			return written, fmt.Errorf("%w: %d bytes at offset %d, want offset %d",
+				ErrUnexpectedChunk, len(chunk.Data), chunk.Offset, offset)
+		}
+
+		n, err := w.Write(chunk.Data)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+
+		offset += uint64(n)
+		totalSize = chunk.TotalSize
+	}
+
+	if offset != totalSize {
+		return written, fmt.Errorf("%w: received up to offset %d of %d", ErrIncompleteStream, offset, totalSize)
+	}
+
+	return written, nil
+}
+
+// ReceiveLevel returns the nodes of the level received from the stream. req is the request of the stream,
+// whose End must be set: the chunks must be contiguous from Start to End.
+func ReceiveLevel(stream LevelReceiver, req *LevelRequest) ([][]byte, error) {
+	if req.End < req.Start {
+		return nil, fmt.Errorf("%w: nodes %d to %d", ErrInvalidRequest, req.Start, req.End)
+	}
+
+	nodes := make([][]byte, 0, req.End-req.Start)
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		next := req.Start + uint64(len(nodes))
+		if chunk.Level != req.Level || chunk.Start != next || uint64(len(chunk.Nodes)) > req.End-next {
+			return nil, fmt.Errorf("%w: %d nodes at index %d of level %d, want index %d of level %d",
+				ErrUnexpectedChunk, len(chunk.Nodes), chunk.Start, chunk.Level, next, req.Level)
+		}
+
+		nodes = append(nodes, chunk.Nodes...)
+	}
+
+	if uint64(len(nodes)) != req.End-req.Start {
+		return nil, fmt.Errorf("%w: received %d of %d nodes", ErrIncompleteStream, len(nodes), req.End-req.Start)
+	}
+
+	return nodes, nil
+}
+
+// ReceiveProofs calls f with the index, the leaf and the proof of every message received from the stream.
+// req is the request of the stream: the messages must follow its indices.
+func ReceiveProofs(stream ProofReceiver, req *ProofRequest, f func(idx uint64, leaf []byte, proof *mt.Proof) error) error {
+	received := 0
+
+	for {
+		m, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if received == len(req.Indices) || m.Index != req.Indices[received] {
+			return fmt.Errorf("%w: proof of leaf %d", ErrUnexpectedChunk, m.Index)
+		}
+
+		if err := f(m.Index, m.Leaf, m.Proof()); err != nil {
+			return err
+		}
+
+		received++
+	}
+
+	if received != len(req.Indices) {
+		return fmt.Errorf("%w: received %d of %d proofs", ErrIncompleteStream, received, len(req.Indices))
+	}
+
+	return nil
+}
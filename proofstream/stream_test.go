@@ -0,0 +1,211 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proofstream
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+type testBlock []byte
+
+func (b testBlock) Serialize() ([]byte, error) {
+	return b, nil
+}
+
+// pipe is a client and server stream passing the messages through the wire encoding.
+type pipe[T any, PT interface {
+	*T
+	Message
+}] struct {
+	sent [][]byte
+}
+
+func (p *pipe[T, PT]) Send(m PT) error {
+	data, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+	p.sent = append(p.sent, data)
+	return nil
+}
+
+func (p *pipe[T, PT]) Recv() (PT, error) {
+	if len(p.sent) == 0 {
+		return nil, io.EOF
+	}
+	m := PT(new(T))
+	err := m.Unmarshal(p.sent[0])
+	p.sent = p.sent[1:]
+	return m, err
+}
+
+func testServer(t *testing.T, num int) (*Server, []mt.DataBlock, []byte) {
+	t.Helper()
+	blocks := make([]mt.DataBlock, num)
+	for i := range blocks {
+		blocks[i] = testBlock(fmt.Sprintf("block-%04d", i))
+	}
+	m, err := mt.New(&mt.Config{Mode: mt.ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := mt.WriteProofArchive(&buf, m); err != nil {
+		t.Fatalf("WriteProofArchive() error = %v", err)
+	}
+	s, err := NewServer(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	return s, blocks, buf.Bytes()
+}
+
+func TestServer_StreamArchive(t *testing.T) {
+	s, _, archive := testServer(t, 100)
+	for _, offset := range []uint64{0, 17, uint64(len(archive))} {
+		var (
+			stream pipe[ArchiveChunk, *ArchiveChunk]
+			buf    bytes.Buffer
+		)
+		if err := s.StreamArchive(&ArchiveRequest{Offset: offset, ChunkSize: 256}, &stream); err != nil {
+			t.Fatalf("StreamArchive() error = %v", err)
+		}
+		if want := (len(archive) - int(offset) + 255) / 256; len(stream.sent) != want {
+			t.Errorf("StreamArchive() sent %d chunks, want %d", len(stream.sent), want)
+		}
+		if _, err := ReceiveArchive(&stream, &buf, offset); err != nil {
+			t.Fatalf("ReceiveArchive() error = %v", err)
+		}
+		if !bytes.Equal(buf.Bytes(), archive[offset:]) {
+			t.Errorf("ReceiveArchive() from %d does not match the archive", offset)
+		}
+	}
+
+	var stream pipe[ArchiveChunk, *ArchiveChunk]
+	if err := s.StreamArchive(&ArchiveRequest{ChunkSize: 256}, &stream); err != nil {
+		t.Fatalf("StreamArchive() error = %v", err)
+	}
+	stream.sent = stream.sent[:len(stream.sent)-1]
+	if _, err := ReceiveArchive(&stream, io.Discard, 0); !errors.Is(err, ErrIncompleteStream) {
+		t.Errorf("ReceiveArchive() error = %v, want %v", err, ErrIncompleteStream)
+	}
+	if err := s.StreamArchive(&ArchiveRequest{ChunkSize: 256}, &stream); err != nil {
+		t.Fatalf("StreamArchive() error = %v", err)
+	}
+	stream.sent = append(stream.sent[:1], stream.sent[2:]...)
+	if _, err := ReceiveArchive(&stream, io.Discard, 0); !errors.Is(err, ErrUnexpectedChunk) {
+		t.Errorf("ReceiveArchive() error = %v, want %v", err, ErrUnexpectedChunk)
+	}
+	if err := s.StreamArchive(&ArchiveRequest{Offset: uint64(len(archive)) + 1}, &stream); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("StreamArchive() error = %v, want %v", err, ErrInvalidRequest)
+	}
+}
+
+func TestServer_StreamLevel(t *testing.T) {
+	s, _, _ := testServer(t, 13)
+	a := s.Archive()
+	tests := []struct {
+		name    string
+		req     *LevelRequest
+		wantErr error
+	}{
+		{name: "leaves", req: &LevelRequest{}},
+		{name: "range", req: &LevelRequest{Level: 1, Start: 2, End: 5}},
+		{name: "root", req: &LevelRequest{Level: uint32(a.Depth())}},
+		{name: "level_out_of_range", req: &LevelRequest{Level: uint32(a.Depth()) + 1}, wantErr: ErrInvalidRequest},
+		{name: "end_out_of_range", req: &LevelRequest{Level: 2, End: 5}, wantErr: ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stream pipe[LevelChunk, *LevelChunk]
+			err := s.StreamLevel(tt.req, &stream)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("StreamLevel() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			req := *tt.req
+			if req.End == 0 {
+				req.End = uint64(a.NumNodes(int(req.Level)))
+			}
+			nodes, err := ReceiveLevel(&stream, &req)
+			if err != nil {
+				t.Fatalf("ReceiveLevel() error = %v", err)
+			}
+			for i, node := range nodes {
+				want, _ := a.Node(int(req.Level), int(req.Start)+i)
+				if !bytes.Equal(node, want) {
+					t.Errorf("ReceiveLevel() node %d = %x, want %x", i, node, want)
+				}
+			}
+		})
+	}
+
+	var stream pipe[LevelChunk, *LevelChunk]
+	if err := s.StreamLevel(&LevelRequest{Level: 1}, &stream); err != nil {
+		t.Fatalf("StreamLevel() error = %v", err)
+	}
+	if _, err := ReceiveLevel(&stream, &LevelRequest{Level: 1, End: 3}); !errors.Is(err, ErrUnexpectedChunk) {
+		t.Errorf("ReceiveLevel() error = %v, want %v", err, ErrUnexpectedChunk)
+	}
+}
+
+func TestServer_StreamProofs(t *testing.T) {
+	s, blocks, _ := testServer(t, 21)
+	var (
+		stream pipe[ProofMessage, *ProofMessage]
+		req    = &ProofRequest{Indices: []uint64{20, 0, 7}}
+		config = s.Archive().Config(nil)
+	)
+	if err := s.StreamProofs(req, &stream); err != nil {
+		t.Fatalf("StreamProofs() error = %v", err)
+	}
+	err := ReceiveProofs(&stream, req, func(idx uint64, leaf []byte, proof *mt.Proof) error {
+		if ok, err := mt.Verify(blocks[idx], proof, s.Archive().Root, config); err != nil || !ok {
+			t.Errorf("Verify() %d = %v, error = %v", idx, ok, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReceiveProofs() error = %v", err)
+	}
+
+	if err := s.StreamProofs(req, &stream); err != nil {
+		t.Fatalf("StreamProofs() error = %v", err)
+	}
+	stream.sent = stream.sent[:2]
+	err = ReceiveProofs(&stream, req, func(uint64, []byte, *mt.Proof) error { return nil })
+	if !errors.Is(err, ErrIncompleteStream) {
+		t.Errorf("ReceiveProofs() error = %v, want %v", err, ErrIncompleteStream)
+	}
+	if err := s.StreamProofs(&ProofRequest{Indices: []uint64{21}}, &stream); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("StreamProofs() error = %v, want %v", err, ErrInvalidRequest)
+	}
+}
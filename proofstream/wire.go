@@ -0,0 +1,168 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proofstream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Protocol buffers wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// appendTag appends the key of the field.
+func appendTag(b []byte, num, typ int) []byte {
+	return binary.AppendUvarint(b, uint64(num)<<3|uint64(typ))
+}
+
+// appendUint appends a varint field, omitted if it is zero as in proto3.
+func appendUint(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+
+	return binary.AppendUvarint(appendTag(b, num, wireVarint), v)
+}
+
+// appendBytes appends a bytes field, omitted if it is empty as in proto3.
+func appendBytes(b []byte, num int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+
+	return appendBytesElem(b, num, v)
+}
+
+// appendBytesElem appends an element of a repeated bytes field, even if it is empty.
+func appendBytesElem(b []byte, num int, v []byte) []byte {
+	b = binary.AppendUvarint(appendTag(b, num, wireBytes), uint64(len(v)))
+
+	return append(b, v...)
+}
+
+// appendPacked appends a packed repeated varint field.
+func appendPacked(b []byte, num int, vs []uint64) []byte {
+	if len(vs) == 0 {
+		return b
+	}
+
+	var payload []byte
+	for _, v := range vs {
+		payload = binary.AppendUvarint(payload, v)
+	}
+
+	return appendBytesElem(b, num, payload)
+}
+
+// decodeFields calls f with the number, wire type and value of every field of the message.
+// Varint fields are passed in v, length-delimited fields in b, which is copied; fixed-size fields,
+// which the messages do not use, are skipped.
+func decodeFields(data []byte, f func(num, typ int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 || key>>3 == 0 || key>>3 > 1<<29-1 {
+			return fmt.Errorf("%w: invalid field key", ErrInvalidMessage)
+		}
+
+		data = data[n:]
+
+		var (
+			num = int(key >> 3)
+			typ = int(key & 7)
+			v   uint64
+			b   []byte
+		)
+
+		switch typ {
+		case wireVarint:
+			if v, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("%w: invalid varint of field %d", ErrInvalidMessage, num)
+			}
+
+			data = data[n:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("%w: invalid length of field %d", ErrInvalidMessage, num)
+			}
+
+			b = bytes.Clone(data[n : n+int(length)])
+			data = data[n+int(length):]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if typ == wireFixed32 {
+				size = 4
+			}
+
+			if len(data) < size {
+				return fmt.Errorf("%w: truncated field %d", ErrInvalidMessage, num)
+			}
+
+			data = data[size:]
+
+			continue
+		default:
+			return fmt.Errorf("%w: unsupported wire type %d of field %d", ErrInvalidMessage, typ, num)
+		}
+
+		if err := f(num, typ, v, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeUints appends the values of a repeated varint field, packed or not, to vs.
+func decodeUints(vs []uint64, typ int, v uint64, b []byte) ([]uint64, error) {
+	if typ == wireVarint {
+		return append(vs, v), nil
+	}
+
+	for len(b) > 0 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("%w: invalid packed varint", ErrInvalidMessage)
+		}
+
+		vs = append(vs, v)
+		b = b[n:]
+	}
+
+	return vs, nil
+}
+
+// checkType returns an error if the wire type of the field is not the expected one.
+func checkType(num, typ, want int) error {
+	if typ != want {
+		return fmt.Errorf("%w: field %d has wire type %d, want %d", ErrInvalidMessage, num, typ, want)
+	}
+
+	return nil
+}
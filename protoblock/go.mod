@@ -0,0 +1,13 @@
+module github.com/txaty/go-merkletree/protoblock
+
+go 1.21
+
+require (
+	github.com/txaty/go-merkletree v0.0.0-00010101000000-000000000000
+	google.golang.org/protobuf v1.33.0
+)
+
+require golang.org/x/sync v0.5.0 // indirect
+
+// The adapter builds against this repository until a release of the root module is tagged and required instead.
+replace github.com/txaty/go-merkletree => ../
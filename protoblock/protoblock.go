@@ -0,0 +1,67 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package protoblock provides a DataBlock adapter for protocol buffer messages.
+//
+// It is a separate module, so that the protobuf dependency is only pulled in by its users.
+package protoblock
+
+import (
+	"google.golang.org/protobuf/proto"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+// marshalOptions enables deterministic marshaling: map entries are ordered by key, whereas the default
+// encoding may order them differently across calls and processes and produce mismatched roots.
+// Determinism holds for a given version of the protobuf module, so all the services building or
+// verifying a tree should use the same version.
+var marshalOptions = proto.MarshalOptions{Deterministic: true}
+
+// Block is a DataBlock whose serialization is the deterministic wire encoding of Message.
+type Block struct {
+	Message proto.Message
+}
+
+// NewBlock wraps the message into a Block.
+func NewBlock(message proto.Message) *Block {
+	return &Block{Message: message}
+}
+
+// Blocks wraps the messages into data blocks.
+func Blocks[M proto.Message](messages []M) []mt.DataBlock {
+	blocks := make([]mt.DataBlock, len(messages))
+	for i, message := range messages {
+		blocks[i] = NewBlock(message)
+	}
+
+	return blocks
+}
+
+// Serialize returns the deterministic wire encoding of the message.
+func (b *Block) Serialize() ([]byte, error) {
+	if b.Message == nil {
+		return nil, mt.ErrDataBlockIsNil
+	}
+
+	return marshalOptions.Marshal(b.Message)
+}
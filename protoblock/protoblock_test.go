@@ -0,0 +1,79 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package protoblock
+
+import (
+	"bytes"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+func TestBlock_Serialize(t *testing.T) {
+	fields := make(map[string]any, 64)
+	for i := 0; i < 64; i++ {
+		fields[string(rune('A'+i))] = float64(i)
+	}
+	message, err := structpb.NewStruct(fields)
+	if err != nil {
+		t.Fatalf("NewStruct() error = %v", err)
+	}
+
+	want, err := NewBlock(message).Serialize()
+	if err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		// Rebuild the map, so that its iteration order differs.
+		again, err := structpb.NewStruct(fields)
+		if err != nil {
+			t.Fatalf("NewStruct() error = %v", err)
+		}
+		got, err := NewBlock(again).Serialize()
+		if err != nil {
+			t.Fatalf("Serialize() error = %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatal("Serialize() is not deterministic")
+		}
+	}
+
+	if _, err := NewBlock(nil).Serialize(); err != mt.ErrDataBlockIsNil {
+		t.Errorf("Serialize() error = %v, want %v", err, mt.ErrDataBlockIsNil)
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	messages := []*structpb.Value{structpb.NewStringValue("a"), structpb.NewStringValue("b"), structpb.NewNumberValue(3)}
+	tree, err := mt.New(nil, Blocks(messages))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for i, block := range Blocks(messages) {
+		if ok, err := tree.Verify(block, tree.Proofs[i]); err != nil || !ok {
+			t.Errorf("Verify() %d = %v, error = %v", i, ok, err)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Rebuild re-serializes and re-hashes the data blocks of the leaves at the changed indexes, which the caller
+// mutated in place since the build, and patches the nodes above them: the stored levels, the proofs
+// and the root, leaving every other node as it is. The tree must be built with Config.RetainBlocks.
+// Proofs are replaced rather than modified, so that the proofs returned before stay valid for the old root.
+// If an error is returned, the tree is left unchanged. If the root changes, the OnProofsInvalidated and
+// OnRootChanged hooks of the configuration are called, in this order.
+func (m *MerkleTree) Rebuild(changedIndices []int) error {
+	oldRoot := m.Root
+
+	if err := m.rebuild(changedIndices); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(oldRoot, m.Root) {
+		m.notifyRootChanged(oldRoot, changedIndices)
+	}
+
+	return nil
+}
+
+// notifyRootChanged calls the hooks of a root change from oldRoot, caused by the changed leaves.
+func (m *MerkleTree) notifyRootChanged(oldRoot []byte, changedIndices []int) {
+	if m.OnProofsInvalidated != nil {
+		changed := append([]int(nil), changedIndices...)
+		sort.Ints(changed)
+		m.OnProofsInvalidated(compactInts(changed))
+	}
+
+	if m.OnRootChanged != nil {
+		m.OnRootChanged(oldRoot, m.Root)
+	}
+}
+
+// rebuild implements Rebuild.
+func (m *MerkleTree) rebuild(changedIndices []int) error {
+	if m.blocks == nil {
+		return ErrBlocksNotRetained
+	}
+
+	if m.LeafLess != nil || m.DeduplicateLeaves || m.SkipNilBlocks {
+		return ErrRebuildUnsupported
+	}
+
+	if err := m.generateDeferredProofs(); err != nil {
+		return err
+	}
+
+	changed := make(map[int][]byte, len(changedIndices))
+	for _, idx := range changedIndices {
+		if idx < 0 || idx >= m.NumLeaves {
+			return ErrIndexOutOfRange
+		}
+
+		leaf, err := dataBlockToLeaf(m.blocks[idx], m.leafHashFunc(), m.DisableLeafHashing)
+		if err != nil {
+			return &BlockError{Index: idx, Err: err}
+		}
+
+		changed[idx] = leaf
+	}
+
+	// Lazy trees not materialized yet only store their leaves and root.
+	if m.Mode == ModeLazy && m.nodes == nil {
+		leaves := make([][]byte, m.NumLeaves)
+		copy(leaves, m.Leaves)
+
+		for idx, leaf := range changed {
+			leaves[idx] = leaf
+		}
+
+		root, err := m.rootFromLeaves(leaves, m.buildChildren)
+		if err != nil {
+			return err
+		}
+
+		m.updateLeaves(changed)
+		m.Root = root
+
+		return nil
+	}
+
+	dirty, err := m.dirtyNodes(changed)
+	if err != nil {
+		return err
+	}
+
+	m.updateLeaves(changed)
+	m.patchNodes(dirty)
+
+	return nil
+}
+
+// dirtyNodes computes the new nodes of every level above the changed leaves, level by level up to the root,
+// reading the unchanged children from the tree.
+func (m *MerkleTree) dirtyNodes(changed map[int][]byte) ([]map[int][]byte, error) {
+	dirty := make([]map[int][]byte, m.Depth+1)
+	dirty[0] = changed
+
+	for level := 0; level < m.Depth; level++ {
+		var (
+			size    = levelSize(m.NumLeaves, level)
+			parents = make(map[int]struct{}, len(dirty[level]))
+		)
+
+		for idx := range dirty[level] {
+			parents[idx>>1] = struct{}{}
+		}
+
+		dirty[level+1] = make(map[int][]byte, len(parents))
+
+		for parent := range parents {
+			left, err := m.nodeAt(dirty, level, 2*parent)
+			if err != nil {
+				return nil, err
+			}
+
+			// The right child of the last pair of an odd level is the padding duplicate of the left child.
+			right := left
+			if 2*parent+1 < size {
+				if right, err = m.nodeAt(dirty, level, 2*parent+1); err != nil {
+					return nil, err
+				}
+			}
+
+			if dirty[level+1][parent], err = m.buildChildren(level+1, parent, left, right); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return dirty, nil
+}
+
+// nodeAt returns the node at idx of the level, new if it is dirty, or read from the tree otherwise.
+func (m *MerkleTree) nodeAt(dirty []map[int][]byte, level, idx int) ([]byte, error) {
+	if node, ok := dirty[level][idx]; ok {
+		return node, nil
+	}
+
+	if node, ok := m.storedNode(level, idx); ok {
+		return node, nil
+	}
+
+	return m.levelNode(level, idx)
+}
+
+// updateLeaves replaces the changed leaves, with their leaf map entries, metadata and timestamps.
+func (m *MerkleTree) updateLeaves(changed map[int][]byte) {
+	m.leafMapMu.Lock()
+	for idx, leaf := range changed {
+		if m.leafMap != nil {
+			if i, ok := m.leafMap[string(m.Leaves[idx])]; ok && i == idx {
+				delete(m.leafMap, string(m.Leaves[idx]))
+			}
+
+			m.leafMap[string(leaf)] = idx
+		}
+
+		m.Leaves[idx] = leaf
+	}
+	m.leafMapMu.Unlock()
+
+	for idx := range changed {
+		if mb, ok := m.blocks[idx].(MetaDataBlock); ok {
+			if m.meta == nil {
+				m.meta = make([]any, m.NumLeaves)
+			}
+
+			m.meta[idx] = mb.Meta()
+		} else if m.meta != nil {
+			m.meta[idx] = nil
+		}
+
+		if m.timestamps != nil {
+			m.timestamps[idx] = m.blocks[idx].(TimestampedDataBlock).Timestamp()
+		}
+	}
+}
+
+// patchNodes stores the dirty nodes in the stored levels, including their padding duplicates, replaces
+// the proofs whose siblings are dirty, and sets the root.
+func (m *MerkleTree) patchNodes(dirty []map[int][]byte) {
+	var (
+		topLevel = m.Depth - len(m.topNodes)
+		replaced []bool
+	)
+
+	if m.Proofs != nil {
+		replaced = make([]bool, m.NumLeaves)
+	}
+
+	for level := 0; level < m.Depth; level++ {
+		var stored [][]byte
+
+		switch {
+		case m.nodes != nil:
+			stored = m.nodes[level]
+		case m.topNodes != nil && level >= topLevel:
+			stored = m.topNodes[level-topLevel]
+		}
+
+		size := levelSize(m.NumLeaves, level)
+
+		if stored != nil {
+			for idx, node := range dirty[level] {
+				stored[idx] = node
+				if idx == size-1 && len(stored) > size {
+					stored[size] = node
+				}
+			}
+		}
+
+		if m.Proofs != nil {
+			m.patchProofs(level, size, dirty[level], replaced)
+		}
+	}
+
+	m.Root = dirty[m.Depth][0]
+}
+
+// patchProofs sets the dirty siblings at the level in the proofs of the leaves below the other node of
+// their pair, or below the node itself if it is the last node of an odd level, padded with its duplicate.
+// The proofs not replaced yet by the rebuild are copied first.
+func (m *MerkleTree) patchProofs(level, size int, dirty map[int][]byte, replaced []bool) {
+	for idx, node := range dirty {
+		below := idx ^ 1
+		if idx == size-1 && size&1 == 1 {
+			below = idx
+		}
+
+		for leaf := below << level; leaf < min((below+1)<<level, m.NumLeaves); leaf++ {
+			if !replaced[leaf] {
+				proof := m.Proofs[leaf]
+				m.Proofs[leaf] = &Proof{Siblings: append([][]byte(nil), proof.Siblings...), Path: proof.Path}
+				replaced[leaf] = true
+			}
+
+			m.Proofs[leaf].Siblings[level] = node
+		}
+	}
+}
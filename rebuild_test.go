@@ -0,0 +1,220 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestMerkleTree_Rebuild(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *Config
+		num         int
+		materialize bool
+	}{
+		{name: "test_proof_gen", config: &Config{}, num: 13},
+		{name: "test_proof_gen_parallel", config: &Config{RunInParallel: true, NumRoutines: 3}, num: 16},
+		{name: "test_tree_build", config: &Config{Mode: ModeTreeBuild}, num: 13},
+		{name: "test_proof_gen_and_tree_build", config: &Config{Mode: ModeProofGenAndTreeBuild}, num: 21},
+		{name: "test_lazy", config: &Config{Mode: ModeLazy}, num: 13},
+		{name: "test_lazy_materialized", config: &Config{Mode: ModeLazy}, num: 13, materialize: true},
+		{name: "test_low_memory", config: &Config{Mode: ModeLowMemory, RecomputeLevels: 2}, num: 37},
+		{name: "test_positional", config: &Config{PositionalHashFunc: PositionPrefixed(nil)}, num: 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.num)
+			tt.config.RetainBlocks = true
+
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if tt.materialize {
+				if _, err := m.Proof(blocks[0]); err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+			}
+
+			oldRoot := m.Root
+
+			oldProof, err := m.proofByIndex(1)
+			if err != nil {
+				t.Fatalf("proofByIndex() error = %v", err)
+			}
+
+			changed := []int{0, 5, tt.num - 2, tt.num - 1}
+			for _, idx := range changed {
+				blocks[idx].(*mock.DataBlock).Data = append([]byte("mutated"), byte(idx))
+			}
+
+			if err := m.Rebuild(changed); err != nil {
+				t.Fatalf("Rebuild() error = %v", err)
+			}
+
+			config := *tt.config
+			config.Mode = ModeTreeBuild
+
+			want, err := New(&config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if !bytes.Equal(m.Root, want.Root) {
+				t.Fatalf("Rebuild() root differs from the tree built over the mutated data blocks")
+			}
+
+			for i := 0; i < tt.num; i++ {
+				proof, err := m.proofByIndex(i)
+				if err != nil {
+					t.Fatalf("proofByIndex() error = %v", err)
+				}
+
+				if !proof.Equal(want.proofFromNodes(i)) {
+					t.Fatalf("Rebuild() proof of leaf %d differs from the rebuilt tree proof", i)
+				}
+			}
+
+			if err := m.Recompute(); err != nil {
+				t.Errorf("Recompute() error = %v", err)
+			}
+
+			if m.nodes != nil {
+				if idx, err := m.IndexOf(blocks[5]); err != nil || idx != 5 {
+					t.Errorf("IndexOf() = %d, %v, want 5", idx, err)
+				}
+			}
+
+			if m.Proofs != nil {
+				if ok, err := Verify(blocks[1], oldProof, oldRoot, tt.config); !ok || err != nil {
+					t.Errorf("proof returned before Rebuild() no longer verifies against the old root")
+				}
+			}
+		})
+	}
+}
+
+func TestMerkleTree_Rebuild_errors(t *testing.T) {
+	blocks := mockDataBlocks(8)
+
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := m.Rebuild([]int{1}); !errors.Is(err, ErrBlocksNotRetained) {
+		t.Errorf("Rebuild() error = %v, want %v", err, ErrBlocksNotRetained)
+	}
+
+	if m, err = New(&Config{RetainBlocks: true}, blocks); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := m.Rebuild([]int{8}); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Rebuild() error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+
+	root := m.Root
+	blocks[3].(*mock.DataBlock).Err = errors.New("serialize")
+
+	if err := m.Rebuild([]int{2, 3}); err == nil || !bytes.Equal(m.Root, root) {
+		t.Errorf("Rebuild() error = %v, want an error leaving the tree unchanged", err)
+	}
+
+	if m, err = New(&Config{RetainBlocks: true, DeduplicateLeaves: true}, mockDataBlocks(8)); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := m.Rebuild([]int{1}); !errors.Is(err, ErrRebuildUnsupported) {
+		t.Errorf("Rebuild() error = %v, want %v", err, ErrRebuildUnsupported)
+	}
+
+	withNil := append(mockDataBlocks(4), nil, mockDataBlocks(5)[4])
+	if m, err = New(&Config{RetainBlocks: true, SkipNilBlocks: true}, withNil); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := m.Rebuild([]int{4}); !errors.Is(err, ErrRebuildUnsupported) {
+		t.Errorf("Rebuild() error = %v, want %v", err, ErrRebuildUnsupported)
+	}
+}
+
+func TestMerkleTree_Rebuild_hooks(t *testing.T) {
+	var (
+		events      []string
+		invalidated []int
+		oldR, newR  []byte
+		blocks      = mockDataBlocks(8)
+		config      = &Config{RetainBlocks: true}
+		wantInvalid = []int{2, 5}
+	)
+
+	config.OnProofsInvalidated = func(changed []int) {
+		events = append(events, "proofs")
+		invalidated = changed
+	}
+	config.OnRootChanged = func(oldRoot, newRoot []byte) {
+		events = append(events, "root")
+		oldR, newR = oldRoot, newRoot
+	}
+
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if len(events) != 0 {
+		t.Fatalf("New() called the hooks: %v", events)
+	}
+
+	// Unchanged data blocks leave the root as it is.
+	if err := m.Rebuild([]int{1}); err != nil || len(events) != 0 {
+		t.Fatalf("Rebuild() = %v, hooks %v, want no hook", err, events)
+	}
+
+	root := m.Root
+	blocks[2].(*mock.DataBlock).Data = []byte("changed")
+	blocks[5].(*mock.DataBlock).Data = []byte("changed too")
+
+	if err := m.Rebuild([]int{5, 2, 5}); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+
+	if len(events) != 2 || events[0] != "proofs" || events[1] != "root" {
+		t.Fatalf("Rebuild() hooks = %v, want [proofs root]", events)
+	}
+
+	if len(invalidated) != len(wantInvalid) || invalidated[0] != wantInvalid[0] || invalidated[1] != wantInvalid[1] {
+		t.Errorf("OnProofsInvalidated() indexes = %v, want %v", invalidated, wantInvalid)
+	}
+
+	if !bytes.Equal(oldR, root) || !bytes.Equal(newR, m.Root) || bytes.Equal(oldR, newR) {
+		t.Errorf("OnRootChanged() = %x, %x, want %x, %x", oldR, newR, root, m.Root)
+	}
+}
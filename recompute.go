@@ -0,0 +1,193 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "bytes"
+
+// Recompute re-derives the Merkle root from the stored leaves and compares it with the cached Root.
+// If the tree structure is stored (ModeTreeBuild or ModeProofGenAndTreeBuild), every interior node is
+// checked as well. It returns ErrRootMismatch or ErrNodeMismatch if the in-memory structure was corrupted
+// or tampered with after the build.
+func (m *MerkleTree) Recompute() error {
+	if m.nodes != nil {
+		if err := m.recomputeNodes(); err != nil {
+			return err
+		}
+	}
+
+	root, err := m.rootFromLeaves(m.Leaves, m.hashChildren)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(root, m.Root) {
+		return ErrRootMismatch
+	}
+
+	return nil
+}
+
+// recomputeNodes checks that every stored interior node is the hash of its children.
+func (m *MerkleTree) recomputeNodes() error {
+	if len(m.nodes) != m.Depth || len(m.nodes[0]) < m.NumLeaves {
+		return ErrNodeMismatch
+	}
+
+	for i := 0; i < m.NumLeaves; i++ {
+		if !bytes.Equal(m.nodes[0][i], m.Leaves[i]) {
+			return ErrNodeMismatch
+		}
+	}
+
+	for i := 0; i < m.Depth-1; i++ {
+		var (
+			level, next = m.nodes[i], m.nodes[i+1]
+			numParents  = len(level) >> 1
+		)
+
+		// The next level may have been padded with a duplicate of its last node.
+		if len(next) != numParents &&
+			(numParents&1 == 0 || len(next) != numParents+1 || !bytes.Equal(next[numParents], next[numParents-1])) {
+			return ErrNodeMismatch
+		}
+
+		for j := 0; j < numParents; j++ {
+			hash, err := m.hashChildren(i+1, j, level[j<<1], level[j<<1+1])
+			if err != nil {
+				return err
+			}
+
+			if !bytes.Equal(hash, next[j]) {
+				return ErrNodeMismatch
+			}
+		}
+	}
+
+	return nil
+}
+
+// minParallelLevelSize is the number of nodes below which rootFromLeaves hashes a level sequentially
+// in parallel runs, as spawning goroutines would cost more than the hashes they share.
+const minParallelLevelSize = 1 << 10
+
+// rootFromLeaves computes the Merkle root of the leaves with hash, hashChildren or buildChildren, without storing
+// nodes or generating proofs. Odd levels are padded by duplicating their last node, as during the build.
+// With RunInParallel, the levels of at least minParallelLevelSize nodes are hashed in parallel.
+func (m *MerkleTree) rootFromLeaves(leaves [][]byte, hash typeChildrenHashFunc) ([]byte, error) {
+	if len(leaves) <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	var (
+		buffer = leaves
+		level  = 1
+		err    error
+	)
+
+	// Each parallel level is hashed into a new slice, so that the leaves are not overwritten.
+	for ; m.RunInParallel && len(buffer) >= minParallelLevelSize; level++ {
+		if len(buffer)&1 == 1 {
+			buffer = append(buffer[:len(buffer):len(buffer)], buffer[len(buffer)-1])
+		}
+
+		if buffer, err = m.hashLevel(hash, level, 0, buffer); err != nil {
+			return nil, err
+		}
+	}
+
+	if level == 1 {
+		buffer = make([][]byte, len(leaves))
+		copy(buffer, leaves)
+	}
+
+	for size := len(buffer); size > 1; level, size = level+1, (size+1)>>1 {
+		for j := 0; j < size; j += 2 {
+			right := buffer[min(j+1, size-1)]
+			if buffer[j>>1], err = hash(level, j>>1, buffer[j], right); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return buffer[0], nil
+}
+
+// Equal reports whether two Merkle Trees are structurally identical: same configuration flags,
+// root, leaves, and, when present, the same stored nodes and proofs.
+// Hash functions cannot be compared and are not taken into account.
+func (m *MerkleTree) Equal(other *MerkleTree) bool {
+	if m == nil || other == nil {
+		return m == other
+	}
+
+	if m.NumLeaves != other.NumLeaves || m.Depth != other.Depth ||
+		m.SortSiblingPairs != other.SortSiblingPairs || m.DisableLeafHashing != other.DisableLeafHashing ||
+		!bytes.Equal(m.Root, other.Root) || !equalByteSlices(m.Leaves, other.Leaves) {
+		return false
+	}
+
+	if len(m.nodes) != len(other.nodes) {
+		return false
+	}
+
+	for i := range m.nodes {
+		if !equalByteSlices(m.nodes[i], other.nodes[i]) {
+			return false
+		}
+	}
+
+	if len(m.Proofs) != len(other.Proofs) {
+		return false
+	}
+
+	for i := range m.Proofs {
+		if !m.Proofs[i].Equal(other.Proofs[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Equal reports whether two proofs have the same path and siblings.
+func (p *Proof) Equal(other *Proof) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+
+	return p.Path == other.Path && equalByteSlices(p.Siblings, other.Siblings)
+}
+
+func equalByteSlices(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
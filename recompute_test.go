@@ -0,0 +1,120 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMerkleTree_Recompute(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		tamper  func(m *MerkleTree)
+		wantErr error
+	}{
+		{
+			name:   "test_proof_gen",
+			config: &Config{Mode: ModeProofGen},
+		},
+		{
+			name:   "test_tree_build_parallel",
+			config: &Config{Mode: ModeTreeBuild, RunInParallel: true},
+		},
+		{
+			name:   "test_proof_gen_and_tree_build",
+			config: &Config{Mode: ModeProofGenAndTreeBuild},
+		},
+		{
+			name:   "test_tampered_leaf",
+			config: &Config{Mode: ModeProofGen},
+			tamper: func(m *MerkleTree) {
+				m.Leaves[3] = []byte("tampered")
+			},
+			wantErr: ErrRootMismatch,
+		},
+		{
+			name:   "test_tampered_root",
+			config: &Config{Mode: ModeProofGen},
+			tamper: func(m *MerkleTree) {
+				m.Root = []byte("tampered")
+			},
+			wantErr: ErrRootMismatch,
+		},
+		{
+			name:   "test_tampered_node",
+			config: &Config{Mode: ModeTreeBuild},
+			tamper: func(m *MerkleTree) {
+				m.nodes[1][0] = []byte("tampered")
+			},
+			wantErr: ErrNodeMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.config, mockDataBlocks(11))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if tt.tamper != nil {
+				tt.tamper(m)
+			}
+			if err := m.Recompute(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Recompute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMerkleTree_Equal(t *testing.T) {
+	blocks := mockDataBlocks(9)
+	m1, err := New(&Config{Mode: ModeProofGenAndTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	m2, err := New(&Config{Mode: ModeProofGenAndTreeBuild, RunInParallel: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	m3, err := New(&Config{Mode: ModeProofGen}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	m4, err := New(&Config{Mode: ModeProofGenAndTreeBuild}, mockDataBlocks(9))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !m1.Equal(m2) {
+		t.Errorf("Equal() trees over the same blocks should be equal")
+	}
+	if m1.Equal(m3) {
+		t.Errorf("Equal() trees with and without stored nodes should differ")
+	}
+	if m1.Equal(m4) {
+		t.Errorf("Equal() trees over different blocks should differ")
+	}
+	if m1.Equal(nil) {
+		t.Errorf("Equal() tree should differ from nil")
+	}
+}
@@ -0,0 +1,253 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/txaty/go-merkletree/verifier"
+)
+
+// Serialized registry layout (all integers big-endian):
+//
+//	header:  magic "MKRG" | version (uint8) | number of trees (uint32)
+//	entries: ID length (uint32) | ID | tree length (uint64) | tree serialized with MerkleTree.WriteTo
+//
+// Entries are stored in ID order; the meta-tree is rebuilt on read.
+const registryEncodingVersion = 1
+
+// registryEncodingMagic identifies a serialized Registry.
+var registryEncodingMagic = [4]byte{'M', 'K', 'R', 'G'}
+
+// registryEntry is the leaf of the meta-tree of a Registry: the tree ID bound to the tree root,
+// so that a tree cannot be proven under another ID.
+type registryEntry struct {
+	id   string
+	root []byte
+}
+
+// Serialize returns the ID length, the ID and the root.
+func (e *registryEntry) Serialize() ([]byte, error) {
+	buf := make([]byte, 4, 4+len(e.id)+len(e.root))
+	binary.BigEndian.PutUint32(buf, uint32(len(e.id)))
+	buf = append(buf, e.id...)
+
+	return append(buf, e.root...), nil
+}
+
+// Registry maps IDs to built trees and commits to all of them with a meta-tree over their roots.
+type Registry struct {
+	// MerkleTree is the meta-tree over the (ID, root) entries, in ID order.
+	*MerkleTree
+	// IDs are the tree IDs in meta-tree leaf order.
+	IDs   []string
+	trees map[string]*MerkleTree
+	index map[string]int
+}
+
+// RegistryProof proves the inclusion of a data block in a tree of a Registry.
+type RegistryProof struct {
+	// TreeID is the ID of the tree containing the data block.
+	TreeID string
+	// Leaf is the proof of the data block in the tree.
+	Leaf *Proof
+	// Registry is the proof of the tree entry in the meta-tree.
+	Registry *Proof
+}
+
+// NewRegistry builds the meta-tree over the trees, ordered by ID. The configuration of the meta-tree must
+// match the one of the trees for registry proofs to verify. At least two trees are required.
+func NewRegistry(config *Config, trees map[string]*MerkleTree) (*Registry, error) {
+	if err := config.checkBlockPositions(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(trees))
+	for id := range trees {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	var (
+		blocks = make([]DataBlock, len(ids))
+		index  = make(map[string]int, len(ids))
+	)
+
+	for i, id := range ids {
+		blocks[i] = &registryEntry{id: id, root: trees[id].Root}
+		index[id] = i
+	}
+
+	meta, err := New(config, blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Registry{MerkleTree: meta, IDs: ids, trees: trees, index: index}, nil
+}
+
+// Tree returns the tree registered under the ID.
+func (r *Registry) Tree(treeID string) (*MerkleTree, bool) {
+	t, ok := r.trees[treeID]
+
+	return t, ok
+}
+
+// RegistryProof returns the proof of the data block in the tree registered under treeID,
+// composed with the proof of the tree in the meta-tree.
+func (r *Registry) RegistryProof(treeID string, dataBlock DataBlock) (*RegistryProof, error) {
+	tree, ok := r.trees[treeID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTreeID, treeID)
+	}
+
+	idx, err := tree.leafIndex(dataBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := tree.proofByIndex(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := r.proofByIndex(r.index[treeID])
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegistryProof{TreeID: treeID, Leaf: leaf, Registry: registry}, nil
+}
+
+// VerifyRegistry checks if the data block is included in the tree registered under proof.TreeID
+// in the registry with the root. The trees and the meta-tree must share the configuration.
+func VerifyRegistry(dataBlock DataBlock, proof *RegistryProof, root []byte, config *Config) (bool, error) {
+	if dataBlock == nil {
+		return false, ErrDataBlockIsNil
+	}
+
+	if proof == nil || proof.Leaf == nil || proof.Registry == nil {
+		return false, ErrProofIsNil
+	}
+
+	if config == nil {
+		config = new(Config)
+	}
+
+	if config.HashFunc == nil {
+		config.HashFunc = DefaultHashFunc
+	}
+
+	leaf, err := dataBlockToLeaf(dataBlock, config.leafHashFunc(), config.DisableLeafHashing)
+	if err != nil {
+		return false, err
+	}
+
+	treeRoot, err := verifier.ComputeRoot(leaf, proof.Leaf.Siblings, proof.Leaf.Path, config.verifierConfig())
+	if err != nil {
+		return false, err
+	}
+
+	return Verify(&registryEntry{id: proof.TreeID, root: treeRoot}, proof.Registry, root, config)
+}
+
+// WriteTo serializes the registered trees to w. It implements io.WriterTo and requires every tree
+// to be built (ModeTreeBuild or ModeProofGenAndTreeBuild).
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	buf := new(bytes.Buffer)
+	buf.Write(registryEncodingMagic[:])
+	buf.WriteByte(registryEncodingVersion)
+	buf.Write(binary.BigEndian.AppendUint32(nil, uint32(len(r.IDs))))
+
+	var tree bytes.Buffer
+
+	for _, id := range r.IDs {
+		tree.Reset()
+
+		if _, err := r.trees[id].WriteTo(&tree); err != nil {
+			return 0, fmt.Errorf("tree %q: %w", id, err)
+		}
+
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(len(id))))
+		buf.WriteString(id)
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(tree.Len())))
+		buf.Write(tree.Bytes())
+	}
+
+	return buf.WriteTo(w)
+}
+
+// ReadRegistry deserializes a registry written by Registry.WriteTo and rebuilds its meta-tree.
+// The configuration must provide the hash function used to build the trees.
+func ReadRegistry(r io.Reader, config *Config) (*Registry, error) {
+	header := make([]byte, len(registryEncodingMagic)+5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+	}
+
+	if !bytes.Equal(header[:4], registryEncodingMagic[:]) || header[4] != registryEncodingVersion {
+		return nil, ErrInvalidTreeEncoding
+	}
+
+	var (
+		numTrees = binary.BigEndian.Uint32(header[5:])
+		trees    = make(map[string]*MerkleTree)
+		lenBuf   = make([]byte, 8)
+	)
+
+	for i := uint32(0); i < numTrees; i++ {
+		if _, err := io.ReadFull(r, lenBuf[:4]); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+		}
+
+		// The identifier is read before being allocated whole, as its length is untrusted.
+		idLen := int64(binary.BigEndian.Uint32(lenBuf))
+
+		id, err := io.ReadAll(io.LimitReader(r, idLen))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+		}
+
+		if int64(len(id)) != idLen {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, io.ErrUnexpectedEOF)
+		}
+
+		if _, err := io.ReadFull(r, lenBuf); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+		}
+
+		tree, err := ReadTree(io.LimitReader(r, int64(binary.BigEndian.Uint64(lenBuf))), config)
+		if err != nil {
+			return nil, fmt.Errorf("tree %q: %w", id, err)
+		}
+
+		trees[string(id)] = tree
+	}
+
+	return NewRegistry(config, trees)
+}
@@ -0,0 +1,97 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	var (
+		blocks = map[string][]DataBlock{
+			"airdrop-1": mockDataBlocks(5),
+			"airdrop-2": mockDataBlocks(8),
+			"airdrop-3": mockDataBlocks(3),
+		}
+		trees = make(map[string]*MerkleTree, len(blocks))
+	)
+	for id, b := range blocks {
+		tree, err := New(&Config{Mode: ModeProofGenAndTreeBuild}, b)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		trees[id] = tree
+	}
+	r, err := NewRegistry(nil, trees)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	read, err := ReadRegistry(&buf, nil)
+	if err != nil {
+		t.Fatalf("ReadRegistry() error = %v", err)
+	}
+	if !bytes.Equal(read.Root, r.Root) {
+		t.Errorf("ReadRegistry() root = %x, want %x", read.Root, r.Root)
+	}
+
+	for _, registry := range []*Registry{r, read} {
+		for id, b := range blocks {
+			for _, block := range b {
+				proof, err := registry.RegistryProof(id, block)
+				if err != nil {
+					t.Fatalf("RegistryProof() error = %v", err)
+				}
+				if ok, err := VerifyRegistry(block, proof, r.Root, nil); err != nil || !ok {
+					t.Errorf("VerifyRegistry() = %v, error = %v", ok, err)
+				}
+				// The tree ID is bound to the tree root.
+				proof.TreeID = "airdrop-0"
+				if ok, _ := VerifyRegistry(block, proof, r.Root, nil); ok {
+					t.Errorf("VerifyRegistry() accepted a proof under another tree ID")
+				}
+			}
+		}
+	}
+
+	if _, err := r.RegistryProof("unknown", blocks["airdrop-1"][0]); !errors.Is(err, ErrUnknownTreeID) {
+		t.Errorf("RegistryProof() error = %v, want %v", err, ErrUnknownTreeID)
+	}
+	if _, err := r.RegistryProof("airdrop-2", blocks["airdrop-1"][0]); !errors.Is(err, ErrProofInvalidDataBlock) {
+		t.Errorf("RegistryProof() error = %v, want %v", err, ErrProofInvalidDataBlock)
+	}
+}
+
+func TestReadRegistry_forgedLengths(t *testing.T) {
+	data := append(registryEncodingMagic[:], registryEncodingVersion, 0xff, 0xff, 0xff, 0xff)
+	data = append(data, 0xff, 0xff, 0xff, 0xff, 'i', 'd')
+	if _, err := ReadRegistry(bytes.NewReader(data), nil); !errors.Is(err, ErrInvalidTreeEncoding) {
+		t.Errorf("ReadRegistry() error = %v, want %v", err, ErrInvalidTreeEncoding)
+	}
+}
@@ -0,0 +1,422 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package remotehash fulfills the hash function of a Merkle Tree with a remote hash service, e.g. an HSM,
+// a KMS or a signing proxy required by compliance regimes to perform the hashing.
+// Concurrent hash calls are coalesced into batches sent to the service, with a bounded number of requests
+// in flight, an optional request rate limit and retries with exponential backoff.
+//
+// The hash function of the client is Client.Hash:
+//
+//	client := remotehash.New(service, nil)
+//	defer client.Close()
+//	tree, err := mt.New(&mt.Config{HashFunc: client.Hash, RunInParallel: true}, blocks)
+//
+// Batches only fill up with concurrent calls, so trees should be built with RunInParallel.
+package remotehash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxBatchSize is the default maximum number of inputs of a request.
+	DefaultMaxBatchSize = 64
+	// DefaultBatchDelay is the default time a batch waits for more inputs before it is sent.
+	DefaultBatchDelay = time.Millisecond
+	// DefaultMaxInFlight is the default maximum number of concurrent requests.
+	DefaultMaxInFlight = 4
+	// DefaultMaxAttempts is the default maximum number of attempts of a request.
+	DefaultMaxAttempts = 3
+	// DefaultMinBackoff is the default delay before the first retry, doubled at every retry.
+	DefaultMinBackoff = 10 * time.Millisecond
+	// DefaultMaxBackoff is the default maximum delay between two retries.
+	DefaultMaxBackoff = time.Second
+)
+
+var (
+	// ErrClosed is the error for a hash requested from a closed client.
+	ErrClosed = errors.New("remotehash: client is closed")
+	// ErrBatchMismatch is the error for a service response whose number of hashes differs from the request.
+	ErrBatchMismatch = errors.New("remotehash: number of hashes does not match the batch")
+)
+
+// BatchHasher is a remote hash service hashing a batch of inputs in one request.
+type BatchHasher interface {
+	// HashBatch returns the hashes of the inputs, in order. Errors are retried unless wrapped with Permanent.
+	HashBatch(ctx context.Context, inputs [][]byte) ([][]byte, error)
+}
+
+// BatchHasherFunc adapts a function to the BatchHasher interface.
+type BatchHasherFunc func(ctx context.Context, inputs [][]byte) ([][]byte, error)
+
+// HashBatch calls f.
+func (f BatchHasherFunc) HashBatch(ctx context.Context, inputs [][]byte) ([][]byte, error) {
+	return f(ctx, inputs)
+}
+
+// AsyncHasher hashes inputs asynchronously.
+type AsyncHasher interface {
+	// HashAsync returns a channel receiving the result of the hash of data.
+	HashAsync(ctx context.Context, data []byte) <-chan Result
+}
+
+// Result is the result of an asynchronous hash.
+type Result struct {
+	Hash []byte
+	Err  error
+}
+
+// permanentError is an error of the service that is not retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks the error returned by a BatchHasher as permanent, so that the request is not retried,
+// e.g. for an authorization failure.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Config is the configuration of a client. Zero values select the defaults.
+type Config struct {
+	// MaxBatchSize is the maximum number of inputs of a request.
+	MaxBatchSize int
+	// BatchDelay is the time a batch waits for more inputs before it is sent.
+	BatchDelay time.Duration
+	// MaxInFlight is the maximum number of concurrent requests.
+	MaxInFlight int
+	// MaxAttempts is the maximum number of attempts of a request, including the first one.
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry, doubled at every retry up to MaxBackoff.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum delay between two retries.
+	MaxBackoff time.Duration
+	// RateLimit is the maximum number of requests per second, retries included. It is unlimited if 0.
+	RateLimit float64
+	// Timeout is the timeout of a request attempt. There is none if 0.
+	Timeout time.Duration
+}
+
+// withDefaults returns a copy of the configuration with the defaults of the zero values.
+func (c *Config) withDefaults() Config {
+	var config Config
+	if c != nil {
+		config = *c
+	}
+
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = DefaultMaxBatchSize
+	}
+
+	if config.BatchDelay <= 0 {
+		config.BatchDelay = DefaultBatchDelay
+	}
+
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = DefaultMaxInFlight
+	}
+
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultMinBackoff
+	}
+
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+
+	return config
+}
+
+// call is a hash requested from the client.
+type call struct {
+	ctx    context.Context
+	data   []byte
+	result chan Result
+}
+
+// Client batches hash calls to a remote hash service. It is safe for concurrent use.
+type Client struct {
+	hasher  BatchHasher
+	config  Config
+	limiter *limiter
+	queue   chan *call
+	sem     chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+}
+
+var _ AsyncHasher = (*Client)(nil)
+
+// New creates a client of the hash service with the configuration, nil for the defaults.
+// The client must be closed to release its resources.
+func New(hasher BatchHasher, config *Config) *Client {
+	c := &Client{
+		hasher: hasher,
+		config: config.withDefaults(),
+		queue:  make(chan *call),
+		done:   make(chan struct{}),
+	}
+
+	c.sem = make(chan struct{}, c.config.MaxInFlight)
+	if c.config.RateLimit > 0 {
+		c.limiter = &limiter{interval: time.Duration(float64(time.Second) / c.config.RateLimit)}
+	}
+
+	c.wg.Add(1)
+
+	go c.dispatch()
+
+	return c
+}
+
+// Hash returns the hash of data computed by the service. It has the signature of the hash function of
+// a Merkle Tree configuration.
+func (c *Client) Hash(data []byte) ([]byte, error) {
+	result := <-c.HashAsync(context.Background(), data)
+
+	return result.Hash, result.Err
+}
+
+// HashAsync queues data for hashing by the service and returns a channel receiving the result.
+// The result is ctx.Err() if the context is done before the batch of data is sent.
+func (c *Client) HashAsync(ctx context.Context, data []byte) <-chan Result {
+	result := make(chan Result, 1)
+
+	select {
+	case c.queue <- &call{ctx: ctx, data: data, result: result}:
+	case <-c.done:
+		result <- Result{Err: ErrClosed}
+	case <-ctx.Done():
+		result <- Result{Err: ctx.Err()}
+	}
+
+	return result
+}
+
+// HashBatch hashes the inputs, batched with the concurrent calls. It implements BatchHasher,
+// so that the client can be shared by several consumers.
+func (c *Client) HashBatch(ctx context.Context, inputs [][]byte) ([][]byte, error) {
+	results := make([]<-chan Result, len(inputs))
+	for i, data := range inputs {
+		results[i] = c.HashAsync(ctx, data)
+	}
+
+	var (
+		hashes = make([][]byte, len(inputs))
+		err    error
+	)
+
+	for i, result := range results {
+		r := <-result
+		if r.Err != nil && err == nil {
+			err = r.Err
+		}
+
+		hashes[i] = r.Hash
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// Close stops accepting hashes and waits for the requests in flight.
+func (c *Client) Close() error {
+	c.once.Do(func() {
+		close(c.done)
+	})
+	c.wg.Wait()
+
+	return nil
+}
+
+// dispatch groups the queued calls into batches and sends them, at most MaxInFlight at a time.
+func (c *Client) dispatch() {
+	defer c.wg.Done()
+
+	for {
+		var first *call
+
+		select {
+		case first = <-c.queue:
+		case <-c.done:
+			return
+		}
+
+		batch := c.fill([]*call{first})
+
+		c.sem <- struct{}{}
+
+		c.wg.Add(1)
+
+		go func() {
+			defer c.wg.Done()
+			defer func() { <-c.sem }()
+
+			c.send(batch)
+		}()
+	}
+}
+
+// fill adds the calls queued within BatchDelay to the batch, up to MaxBatchSize.
+func (c *Client) fill(batch []*call) []*call {
+	timer := time.NewTimer(c.config.BatchDelay)
+	defer timer.Stop()
+
+	for len(batch) < c.config.MaxBatchSize {
+		select {
+		case cl := <-c.queue:
+			batch = append(batch, cl)
+		case <-timer.C:
+			return batch
+		case <-c.done:
+			return batch
+		}
+	}
+
+	return batch
+}
+
+// send requests the hashes of the batch and delivers the results. Calls whose context is done are dropped.
+func (c *Client) send(batch []*call) {
+	var (
+		live   = batch[:0]
+		inputs = make([][]byte, 0, len(batch))
+	)
+
+	for _, cl := range batch {
+		if err := cl.ctx.Err(); err != nil {
+			cl.result <- Result{Err: err}
+			continue
+		}
+
+		live = append(live, cl)
+		inputs = append(inputs, cl.data)
+	}
+
+	if len(live) == 0 {
+		return
+	}
+
+	hashes, err := c.request(inputs)
+	for i, cl := range live {
+		if err != nil {
+			cl.result <- Result{Err: err}
+			continue
+		}
+
+		cl.result <- Result{Hash: hashes[i]}
+	}
+}
+
+// request sends the inputs to the service, retrying failed attempts with exponential backoff.
+func (c *Client) request(inputs [][]byte) ([][]byte, error) {
+	backoff := c.config.MinBackoff
+
+	for attempt := 1; ; attempt++ {
+		c.limiter.wait()
+
+		hashes, err := c.attempt(inputs)
+		if err == nil {
+			return hashes, nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= c.config.MaxAttempts {
+			return nil, err
+		}
+
+		time.Sleep(backoff)
+
+		backoff = min(2*backoff, c.config.MaxBackoff)
+	}
+}
+
+// attempt sends the inputs to the service once.
+func (c *Client) attempt(inputs [][]byte) ([][]byte, error) {
+	ctx := context.Background()
+
+	if c.config.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+		defer cancel()
+	}
+
+	hashes, err := c.hasher.HashBatch(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hashes) != len(inputs) {
+		return nil, Permanent(fmt.Errorf("%w: got %d hashes, want %d", ErrBatchMismatch, len(hashes), len(inputs)))
+	}
+
+	return hashes, nil
+}
+
+// limiter spaces the requests by a fixed interval.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the next request is allowed. A nil limiter never blocks.
+func (l *limiter) wait() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+
+	if l.next.Before(now) {
+		l.next = now
+	}
+
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(delay)
+}
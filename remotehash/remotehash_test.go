@@ -0,0 +1,209 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package remotehash
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mt "github.com/txaty/go-merkletree"
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// service is a SHA-256 hash service failing its first failures requests.
+type service struct {
+	failures  atomic.Int64
+	err       error
+	requests  atomic.Int64
+	inFlight  atomic.Int64
+	maxFlight atomic.Int64
+	maxBatch  atomic.Int64
+	delay     time.Duration
+}
+
+func (s *service) HashBatch(_ context.Context, inputs [][]byte) ([][]byte, error) {
+	s.requests.Add(1)
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		m := s.maxFlight.Load()
+		if n <= m || s.maxFlight.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	for {
+		m := s.maxBatch.Load()
+		if int64(len(inputs)) <= m || s.maxBatch.CompareAndSwap(m, int64(len(inputs))) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+	if s.failures.Add(-1) >= 0 {
+		return nil, s.err
+	}
+	hashes := make([][]byte, len(inputs))
+	for i, data := range inputs {
+		sum := sha256.Sum256(data)
+		hashes[i] = sum[:]
+	}
+	return hashes, nil
+}
+
+func TestClient_merkleTree(t *testing.T) {
+	blocks := make([]mt.DataBlock, 100)
+	for i := range blocks {
+		blocks[i] = &mock.DataBlock{Data: []byte(fmt.Sprintf("block %d", i))}
+	}
+	want, err := mt.New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	svc := &service{delay: time.Millisecond}
+	client := New(svc, &Config{MaxBatchSize: 8, MaxInFlight: 2})
+	defer client.Close()
+	got, err := mt.New(&mt.Config{HashFunc: client.Hash, RunInParallel: true, NumRoutines: 16}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !bytes.Equal(got.Root, want.Root) {
+		t.Errorf("Root = %x, want %x", got.Root, want.Root)
+	}
+	if n := svc.maxBatch.Load(); n > 8 {
+		t.Errorf("max batch size = %d, want at most 8", n)
+	}
+	if n := svc.maxFlight.Load(); n > 2 {
+		t.Errorf("max requests in flight = %d, want at most 2", n)
+	}
+}
+
+func TestClient_HashBatch(t *testing.T) {
+	svc := new(service)
+	client := New(svc, &Config{MaxBatchSize: 4, BatchDelay: 10 * time.Millisecond})
+	defer client.Close()
+	inputs := make([][]byte, 10)
+	for i := range inputs {
+		inputs[i] = []byte{byte(i)}
+	}
+	hashes, err := client.HashBatch(context.Background(), inputs)
+	if err != nil {
+		t.Fatalf("HashBatch() error = %v", err)
+	}
+	for i, data := range inputs {
+		sum := sha256.Sum256(data)
+		if !bytes.Equal(hashes[i], sum[:]) {
+			t.Errorf("HashBatch()[%d] = %x, want %x", i, hashes[i], sum)
+		}
+	}
+	if n := svc.requests.Load(); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+}
+
+func TestClient_retry(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+	tests := []struct {
+		name         string
+		failures     int64
+		err          error
+		wantErr      error
+		wantRequests int64
+	}{
+		{name: "test_no_failure", wantRequests: 1},
+		{name: "test_retried", failures: 2, err: errUnavailable, wantRequests: 3},
+		{name: "test_attempts_exhausted", failures: 3, err: errUnavailable, wantErr: errUnavailable, wantRequests: 3},
+		{name: "test_permanent", failures: 1, err: Permanent(errUnavailable), wantErr: errUnavailable, wantRequests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &service{err: tt.err}
+			svc.failures.Store(tt.failures)
+			client := New(svc, &Config{MinBackoff: time.Millisecond})
+			defer client.Close()
+			_, err := client.Hash([]byte("data"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Hash() error = %v, want %v", err, tt.wantErr)
+			}
+			if n := svc.requests.Load(); n != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", n, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestClient_batchMismatch(t *testing.T) {
+	var requests atomic.Int64
+	client := New(BatchHasherFunc(func(context.Context, [][]byte) ([][]byte, error) {
+		requests.Add(1)
+		return nil, nil
+	}), nil)
+	defer client.Close()
+	if _, err := client.Hash([]byte("data")); !errors.Is(err, ErrBatchMismatch) {
+		t.Errorf("Hash() error = %v, want %v", err, ErrBatchMismatch)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
+
+func TestClient_rateLimit(t *testing.T) {
+	svc := new(service)
+	client := New(svc, &Config{MaxBatchSize: 1, RateLimit: 100})
+	defer client.Close()
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Hash([]byte("data")); err != nil {
+				t.Errorf("Hash() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("5 requests at 100/s took %v, want at least 40ms", elapsed)
+	}
+}
+
+func TestClient_HashAsync(t *testing.T) {
+	client := New(new(service), nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if r := <-client.HashAsync(ctx, []byte("data")); !errors.Is(r.Err, context.Canceled) {
+		t.Errorf("HashAsync() error = %v, want %v", r.Err, context.Canceled)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if r := <-client.HashAsync(context.Background(), []byte("data")); !errors.Is(r.Err, ErrClosed) {
+		t.Errorf("HashAsync() error = %v, want %v", r.Err, ErrClosed)
+	}
+}
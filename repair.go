@@ -0,0 +1,206 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"io"
+)
+
+// NodeRef identifies a node of a tree by its level (0 for the leaves) and its index within the level.
+type NodeRef struct {
+	Level int
+	Index int
+}
+
+// LeafRange is the range [Start, End) of leaf indices.
+type LeafRange struct {
+	Start int
+	End   int
+}
+
+// RepairReport describes the repair of a damaged serialized tree.
+type RepairReport struct {
+	// Missing are the nodes that could not be read or were reported as damaged.
+	Missing []NodeRef
+	// Repaired are the nodes recomputed from their children (or from the node duplicated to pad their level),
+	// including the stored nodes found inconsistent with their children.
+	Repaired []NodeRef
+	// Unrecoverable are the nodes that are still missing after the repair.
+	Unrecoverable []NodeRef
+	// UnprovableRegions are the ranges of leaves that cannot be proven anymore,
+	// because the leaf or one of the siblings of its proof is unrecoverable.
+	UnprovableRegions []LeafRange
+}
+
+// RepairTree reads a damaged tree serialized by WriteTo and recomputes its missing interior nodes from
+// their available children, against the trusted root. Nodes are missing if they cannot be read, e.g. when
+// the serialized tree is truncated, or if they are listed as damaged (e.g. by the NodeMismatchError of
+// VerifyTreeFile or by the storage layer). The header must be intact.
+//
+// Leaves cannot be recomputed from their parents: missing leaves and the nodes whose both children cannot
+// be recovered are flagged in the report, and the returned tree cannot generate the proofs of the leaves
+// in its UnprovableRegions. It returns ErrRootMismatch if the repaired tree does not match the root,
+// e.g. when leaves were silently corrupted rather than lost.
+func RepairTree(r io.ReaderAt, root []byte, config *Config, damaged ...NodeRef) (*MerkleTree, *RepairReport, error) {
+	h, err := readTreeHeader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		report    = new(RepairReport)
+		isDamaged = make(map[NodeRef]bool, len(damaged))
+		nodes     = make([][][]byte, h.depth)
+		offset    = int64(treeEncodingHeaderSize)
+	)
+
+	for _, ref := range damaged {
+		isDamaged[ref] = true
+	}
+
+	for level, size := range levelLens(h.numLeaves, h.depth) {
+		width := h.nodeLenAt(level)
+		nodes[level] = make([][]byte, size)
+
+		for i := range nodes[level] {
+			ref := NodeRef{Level: level, Index: i}
+			node := make([]byte, width)
+
+			if _, err := r.ReadAt(node, offset); err != nil || isDamaged[ref] {
+				report.Missing = append(report.Missing, ref)
+			} else {
+				nodes[level][i] = node
+			}
+
+			offset += int64(width)
+		}
+	}
+
+	m := h.newTree(config, nodes, bytes.Clone(root))
+
+	if err := m.repairNodes(report); err != nil {
+		return nil, nil, err
+	}
+
+	// Unrecoverable leaves are not indexed.
+	m.leafMap = make(map[string]int, m.NumLeaves)
+	for i, leaf := range m.Leaves {
+		if leaf != nil {
+			m.leafMap[string(leaf)] = i
+		}
+	}
+
+	m.flagUnrecoverable(report)
+
+	return m, report, nil
+}
+
+// repairNodes recomputes the missing nodes bottom-up and checks the result against the root.
+func (m *MerkleTree) repairNodes(report *RepairReport) error {
+	for level := 0; level < m.Depth; level++ {
+		nodes := m.nodes[level]
+
+		// The padding node duplicates the last node of an odd level.
+		if size := levelSize(m.NumLeaves, level); len(nodes) > size {
+			if nodes[size] == nil && nodes[size-1] != nil {
+				nodes[size] = nodes[size-1]
+				report.Repaired = append(report.Repaired, NodeRef{Level: level, Index: size})
+			} else if nodes[size-1] == nil && nodes[size] != nil {
+				nodes[size-1] = nodes[size]
+				report.Repaired = append(report.Repaired, NodeRef{Level: level, Index: size - 1})
+			}
+		}
+
+		if level == m.Depth-1 {
+			break
+		}
+
+		next := m.nodes[level+1]
+
+		for j := 0; j < len(nodes)>>1; j++ {
+			left, right := nodes[j<<1], nodes[j<<1+1]
+			if left == nil || right == nil {
+				continue
+			}
+
+			hash, err := m.hashChildren(level+1, j, left, right)
+			if err != nil {
+				return err
+			}
+
+			if !bytes.Equal(hash, next[j]) {
+				next[j] = hash
+				report.Repaired = append(report.Repaired, NodeRef{Level: level + 1, Index: j})
+			}
+		}
+	}
+
+	top := m.nodes[m.Depth-1]
+	if top[0] == nil || top[1] == nil {
+		return nil
+	}
+
+	root, err := m.hashChildren(m.Depth, 0, top[0], top[1])
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(root, m.Root) {
+		return ErrRootMismatch
+	}
+
+	return nil
+}
+
+// flagUnrecoverable reports the nodes still missing and the leaves that cannot be proven.
+func (m *MerkleTree) flagUnrecoverable(report *RepairReport) {
+	for level, nodes := range m.nodes {
+		for i, node := range nodes {
+			if node == nil {
+				report.Unrecoverable = append(report.Unrecoverable, NodeRef{Level: level, Index: i})
+			}
+		}
+	}
+
+	if len(report.Unrecoverable) == 0 {
+		return
+	}
+
+	for i := 0; i < m.NumLeaves; i++ {
+		provable := m.nodes[0][i] != nil
+		for level := 0; provable && level < m.Depth; level++ {
+			provable = m.nodes[level][(i>>level)^1] != nil
+		}
+
+		if provable {
+			continue
+		}
+
+		if n := len(report.UnprovableRegions); n > 0 && report.UnprovableRegions[n-1].End == i {
+			report.UnprovableRegions[n-1].End++
+		} else {
+			report.UnprovableRegions = append(report.UnprovableRegions, LeafRange{Start: i, End: i + 1})
+		}
+	}
+}
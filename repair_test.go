@@ -0,0 +1,141 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRepairTree(t *testing.T) {
+	blocks := mockDataBlocks(9)
+	m, err := New(&Config{Mode: ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	buf := new(bytes.Buffer)
+	if _, err := m.WriteTo(buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	h, err := m.header()
+	if err != nil {
+		t.Fatalf("header() error = %v", err)
+	}
+	// Levels of 9 leaves: 10 (padded), 6 (padded), 4, 2.
+	tests := []struct {
+		name              string
+		corrupt           func(b []byte) []byte
+		damaged           []NodeRef
+		wantRepaired      int
+		wantUnrecoverable []NodeRef
+		wantRegions       []LeafRange
+		wantErr           error
+	}{
+		{
+			name:         "test_damaged_interior_nodes",
+			damaged:      []NodeRef{{Level: 1, Index: 2}, {Level: 2, Index: 0}, {Level: 3, Index: 1}},
+			wantRepaired: 3,
+		},
+		{
+			name:         "test_damaged_padding_node",
+			damaged:      []NodeRef{{Level: 0, Index: 9}, {Level: 1, Index: 4}},
+			wantRepaired: 2,
+		},
+		{
+			name: "test_truncated",
+			corrupt: func(b []byte) []byte {
+				return b[:h.levelOffset(2)]
+			},
+			wantRepaired: 6,
+		},
+		{
+			name: "test_silently_corrupted_interior_node",
+			corrupt: func(b []byte) []byte {
+				b[h.levelOffset(2)+int64(h.nodeLen)] ^= 0xff
+				return b
+			},
+			wantRepaired: 1,
+		},
+		{
+			name:              "test_lost_leaf",
+			damaged:           []NodeRef{{Level: 0, Index: 3}},
+			wantUnrecoverable: []NodeRef{{Level: 0, Index: 3}},
+			wantRegions:       []LeafRange{{Start: 2, End: 4}},
+		},
+		{
+			name:              "test_lost_subtree",
+			damaged:           []NodeRef{{Level: 0, Index: 4}, {Level: 0, Index: 5}, {Level: 1, Index: 2}},
+			wantUnrecoverable: []NodeRef{{Level: 0, Index: 4}, {Level: 0, Index: 5}, {Level: 1, Index: 2}},
+			wantRegions:       []LeafRange{{Start: 4, End: 8}},
+		},
+		{
+			name: "test_silently_corrupted_leaf",
+			corrupt: func(b []byte) []byte {
+				b[h.levelOffset(0)] ^= 0xff
+				return b
+			},
+			wantErr: ErrRootMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bytes.Clone(buf.Bytes())
+			if tt.corrupt != nil {
+				b = tt.corrupt(b)
+			}
+			got, report, err := RepairTree(bytes.NewReader(b), m.Root, nil, tt.damaged...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RepairTree() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(report.Repaired) != tt.wantRepaired {
+				t.Errorf("RepairTree() repaired %v, want %d nodes", report.Repaired, tt.wantRepaired)
+			}
+			if !reflect.DeepEqual(report.Unrecoverable, tt.wantUnrecoverable) {
+				t.Errorf("RepairTree() unrecoverable = %v, want %v", report.Unrecoverable, tt.wantUnrecoverable)
+			}
+			if !reflect.DeepEqual(report.UnprovableRegions, tt.wantRegions) {
+				t.Errorf("RepairTree() unprovable regions = %v, want %v", report.UnprovableRegions, tt.wantRegions)
+			}
+			if tt.wantUnrecoverable == nil && !got.Equal(m) {
+				t.Error("RepairTree() tree differs from the original")
+			}
+			for i, block := range blocks {
+				if tt.wantRegions != nil && i >= tt.wantRegions[0].Start && i < tt.wantRegions[0].End {
+					continue
+				}
+				proof, err := got.Proof(block)
+				if err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+				if ok, err := Verify(block, proof, m.Root, nil); err != nil || !ok {
+					t.Errorf("Verify() of block %d = %v, %v", i, ok, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// Rollup is a higher-level tree built over the roots of a sequence of window trees
+// (e.g. a daily tree over hourly roots). It keeps the window trees, so that any leaf of any window
+// can be proven against the rollup root with a composite proof.
+type Rollup struct {
+	// MerkleTree is the tree over the window roots, in window order.
+	*MerkleTree
+	// Windows are the window trees rolled up.
+	Windows []*MerkleTree
+}
+
+// NewRollup builds a Rollup over the roots of the window trees with the specified configuration.
+// The configuration must match the one of the window trees for composite proofs to verify.
+// Window trees must either have generated proofs or a built tree structure.
+func NewRollup(config *Config, windows []*MerkleTree) (*Rollup, error) {
+	if err := config.checkBlockPositions(); err != nil {
+		return nil, err
+	}
+
+	blocks := make([]DataBlock, len(windows))
+	for i, w := range windows {
+		blocks[i] = RootBlock(w.Root)
+	}
+
+	top, err := New(config, blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rollup{
+		MerkleTree: top,
+		Windows:    windows,
+	}, nil
+}
+
+// Proof returns the composite proof of the leaf at leafIndex in the window at windowIndex against the rollup root.
+// Verify it with VerifyComposite.
+func (r *Rollup) Proof(windowIndex, leafIndex int) (*CompositeProof, error) {
+	if windowIndex < 0 || windowIndex >= len(r.Windows) {
+		return nil, ErrIndexOutOfRange
+	}
+
+	inner, err := r.Windows[windowIndex].proofByIndex(leafIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	outer, err := r.proofByIndex(windowIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return StitchProofs(inner, outer), nil
+}
+
+// WindowProof returns the proof of the root of the window at windowIndex against the rollup root.
+func (r *Rollup) WindowProof(windowIndex int) (*Proof, error) {
+	return r.proofByIndex(windowIndex)
+}
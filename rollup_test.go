@@ -0,0 +1,70 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "testing"
+
+func TestRollup_Proof(t *testing.T) {
+	var (
+		windows      = make([]*MerkleTree, 24)
+		windowBlocks = make([][]DataBlock, 24)
+	)
+	for i := range windows {
+		windowBlocks[i] = mockDataBlocks(2 + i%5)
+		// Alternate modes: proofs are taken from Proofs or computed from the tree structure.
+		config := &Config{Mode: ModeProofGen}
+		if i&1 == 1 {
+			config.Mode = ModeTreeBuild
+		}
+		w, err := New(config, windowBlocks[i])
+		if err != nil {
+			t.Fatalf("New() window error = %v", err)
+		}
+		windows[i] = w
+	}
+	daily, err := NewRollup(nil, windows)
+	if err != nil {
+		t.Fatalf("NewRollup() error = %v", err)
+	}
+	for w, blocks := range windowBlocks {
+		for i, block := range blocks {
+			proof, err := daily.Proof(w, i)
+			if err != nil {
+				t.Fatalf("Proof() window %d leaf %d error = %v", w, i, err)
+			}
+			ok, err := VerifyComposite(block, proof, daily.Root, nil)
+			if err != nil || !ok {
+				t.Errorf("VerifyComposite() window %d leaf %d = %v, error = %v", w, i, ok, err)
+			}
+		}
+	}
+	if _, err := daily.Proof(24, 0); err != ErrIndexOutOfRange {
+		t.Errorf("Proof() window out of range error = %v", err)
+	}
+	if _, err := daily.Proof(0, 10); err != ErrIndexOutOfRange {
+		t.Errorf("Proof() leaf out of range error = %v", err)
+	}
+	if proof, err := daily.WindowProof(3); err != nil || !proof.Equal(daily.Proofs[3]) {
+		t.Errorf("WindowProof() = %v, error = %v", proof, err)
+	}
+}
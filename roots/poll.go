@@ -0,0 +1,199 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package roots
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// poller publishes the roots fetched periodically into its Memory provider.
+type poller struct {
+	*Memory
+	interval time.Duration
+	fetch    func(ctx context.Context) error
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+// Run polls until ctx is done. Fetch errors do not stop polling; the last one is returned by Err.
+func (p *poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := p.fetch(ctx)
+
+			p.mu.Lock()
+			p.lastErr = err
+			p.mu.Unlock()
+		}
+	}
+}
+
+// Err returns the error of the last poll, if any.
+func (p *poller) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.lastErr
+}
+
+// publishIfChanged publishes the root as the next version if it differs from the current root.
+func (m *Memory) publishIfChanged(root []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.current.Version != 0 && bytes.Equal(m.current.Root, root) {
+		return
+	}
+
+	m.publish(Update{Version: m.current.Version + 1, Root: root})
+}
+
+// FileWatcher is a Provider following a file holding the current root as a hexadecimal string,
+// e.g. a root published by a deployment pipeline. Every change of the root is a new version.
+type FileWatcher struct {
+	poller
+	path string
+}
+
+// NewFileWatcher reads the root from the file at path, which is polled every interval by Run.
+func NewFileWatcher(path string, interval time.Duration) (*FileWatcher, error) {
+	w := &FileWatcher{path: path}
+	w.poller = poller{Memory: NewMemory(), interval: interval, fetch: w.read}
+
+	if err := w.read(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *FileWatcher) read(context.Context) error {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+
+	root, err := decodeRoot(string(data))
+	if err != nil {
+		return fmt.Errorf("roots: %s: %w", w.path, err)
+	}
+
+	w.publishIfChanged(root)
+
+	return nil
+}
+
+// HTTPPoller is a Provider polling an HTTP endpoint serving the current root as JSON
+// {"version": 12, "root": "0x..."}. The version is optional: if it is 0, every change of the root
+// is a new version.
+type HTTPPoller struct {
+	poller
+	url    string
+	client *http.Client
+}
+
+// httpUpdate is the JSON document served by the endpoint.
+type httpUpdate struct {
+	Version uint64 `json:"version"`
+	Root    string `json:"root"`
+}
+
+// NewHTTPPoller fetches the root from the URL, which is polled every interval by Run.
+// If client is nil, http.DefaultClient is used.
+func NewHTTPPoller(ctx context.Context, url string, interval time.Duration, client *http.Client) (*HTTPPoller, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	p := &HTTPPoller{url: url, client: client}
+	p.poller = poller{Memory: NewMemory(), interval: interval, fetch: p.get}
+
+	if err := p.get(ctx); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *HTTPPoller) get(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("roots: %s: %s", p.url, resp.Status)
+	}
+
+	var u httpUpdate
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&u); err != nil {
+		return fmt.Errorf("roots: %s: %w", p.url, err)
+	}
+
+	root, err := decodeRoot(u.Root)
+	if err != nil {
+		return fmt.Errorf("roots: %s: %w", p.url, err)
+	}
+
+	if u.Version == 0 {
+		p.publishIfChanged(root)
+		return nil
+	}
+
+	if current, err := p.Current(); err == nil && current.Version == u.Version {
+		return nil
+	}
+
+	return p.PublishVersion(u.Version, root)
+}
+
+// decodeRoot decodes a hexadecimal root, with or without 0x prefix and surrounding spaces.
+func decodeRoot(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+
+	return hex.DecodeString(s)
+}
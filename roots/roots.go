@@ -0,0 +1,167 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package roots provides sources of published Merkle roots that rotate over time, so that verifiers
+// can follow the current root, look up past versions and be notified of new ones.
+package roots
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNoRoot is the error for a provider that has not received any root yet.
+var ErrNoRoot = errors.New("roots: no root published")
+
+// ErrUnknownVersion is the error for a root version that is not known to the provider.
+var ErrUnknownVersion = errors.New("roots: unknown root version")
+
+// ErrStaleVersion is the error for publishing a version that is not greater than the current one.
+var ErrStaleVersion = errors.New("roots: version is not greater than the current version")
+
+// Update is a published root and its version. Versions start at 1 and increase with every rotation.
+type Update struct {
+	Version uint64 `json:"version"`
+	Root    []byte `json:"root"`
+}
+
+// Provider provides the current root, past roots by version, and notifications of rotations.
+// Its CurrentRoot method satisfies merklehttp.RootSource.
+type Provider interface {
+	// CurrentRoot returns the latest root.
+	CurrentRoot(ctx context.Context) ([]byte, error)
+	// RootAt returns the root of the version.
+	RootAt(ctx context.Context, version uint64) ([]byte, error)
+	// Subscribe returns a channel receiving the updates published after the call, until ctx is done.
+	// A slow subscriber only receives the latest update.
+	Subscribe(ctx context.Context) <-chan Update
+}
+
+// Memory is an in-memory Provider whose roots are published with Publish.
+// The other providers of this package are built on it.
+type Memory struct {
+	mu          sync.RWMutex
+	versions    map[uint64][]byte
+	current     Update
+	subscribers map[chan Update]struct{}
+}
+
+// NewMemory creates an empty in-memory provider.
+func NewMemory() *Memory {
+	return &Memory{
+		versions:    make(map[uint64][]byte),
+		subscribers: make(map[chan Update]struct{}),
+	}
+}
+
+// Publish publishes the root as the next version and returns the version.
+func (m *Memory) Publish(root []byte) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.publish(Update{Version: m.current.Version + 1, Root: root})
+
+	return m.current.Version
+}
+
+// PublishVersion publishes the root under the version, which must be greater than the current one.
+func (m *Memory) PublishVersion(version uint64, root []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if version <= m.current.Version {
+		return ErrStaleVersion
+	}
+
+	m.publish(Update{Version: version, Root: root})
+
+	return nil
+}
+
+// publish records the update and notifies the subscribers. It must be called with mu held.
+func (m *Memory) publish(u Update) {
+	m.versions[u.Version] = u.Root
+	m.current = u
+
+	for ch := range m.subscribers {
+		// Replace a pending update not yet received, so that slow subscribers get the latest one.
+		select {
+		case <-ch:
+		default:
+		}
+
+		ch <- u
+	}
+}
+
+// Current returns the latest update.
+func (m *Memory) Current() (Update, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.current.Version == 0 {
+		return Update{}, ErrNoRoot
+	}
+
+	return m.current, nil
+}
+
+// CurrentRoot returns the latest root.
+func (m *Memory) CurrentRoot(context.Context) ([]byte, error) {
+	u, err := m.Current()
+
+	return u.Root, err
+}
+
+// RootAt returns the root of the version.
+func (m *Memory) RootAt(_ context.Context, version uint64) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	root, ok := m.versions[version]
+	if !ok {
+		return nil, ErrUnknownVersion
+	}
+
+	return root, nil
+}
+
+// Subscribe returns a channel receiving the updates published after the call, until ctx is done.
+func (m *Memory) Subscribe(ctx context.Context) <-chan Update {
+	ch := make(chan Update, 1)
+
+	m.mu.Lock()
+	m.subscribers[ch] = struct{}{}
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		m.mu.Lock()
+		delete(m.subscribers, ch)
+		m.mu.Unlock()
+		close(ch)
+	}()
+
+	return ch
+}
@@ -0,0 +1,143 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package roots
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := NewMemory()
+	if _, err := m.CurrentRoot(ctx); !errors.Is(err, ErrNoRoot) {
+		t.Errorf("CurrentRoot() error = %v, want %v", err, ErrNoRoot)
+	}
+	sub := m.Subscribe(ctx)
+
+	if v := m.Publish([]byte{1}); v != 1 {
+		t.Errorf("Publish() = %d, want 1", v)
+	}
+	if v := m.Publish([]byte{2}); v != 2 {
+		t.Errorf("Publish() = %d, want 2", v)
+	}
+	// The slow subscriber only gets the latest update.
+	if u := <-sub; u.Version != 2 || !bytes.Equal(u.Root, []byte{2}) {
+		t.Errorf("Subscribe() update = %+v", u)
+	}
+	if err := m.PublishVersion(2, []byte{3}); !errors.Is(err, ErrStaleVersion) {
+		t.Errorf("PublishVersion() error = %v, want %v", err, ErrStaleVersion)
+	}
+	if err := m.PublishVersion(10, []byte{10}); err != nil {
+		t.Errorf("PublishVersion() error = %v", err)
+	}
+
+	for version, want := range map[uint64][]byte{1: {1}, 2: {2}, 10: {10}} {
+		if got, err := m.RootAt(ctx, version); err != nil || !bytes.Equal(got, want) {
+			t.Errorf("RootAt(%d) = %x, error = %v", version, got, err)
+		}
+	}
+	if _, err := m.RootAt(ctx, 3); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("RootAt() error = %v, want %v", err, ErrUnknownVersion)
+	}
+	if got, _ := m.CurrentRoot(ctx); !bytes.Equal(got, []byte{10}) {
+		t.Errorf("CurrentRoot() = %x", got)
+	}
+
+	cancel()
+	for range sub {
+	}
+}
+
+func TestFileWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "root")
+	if err := os.WriteFile(path, []byte("0xaabb\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewFileWatcher(path, time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewFileWatcher() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := w.Subscribe(ctx)
+	go w.Run(ctx)
+
+	if err := os.WriteFile(path, []byte("ccdd"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if u := <-sub; u.Version != 2 || !bytes.Equal(u.Root, []byte{0xcc, 0xdd}) {
+		t.Errorf("Subscribe() update = %+v", u)
+	}
+	if got, err := w.RootAt(ctx, 1); err != nil || !bytes.Equal(got, []byte{0xaa, 0xbb}) {
+		t.Errorf("RootAt(1) = %x, error = %v", got, err)
+	}
+}
+
+func TestHTTPPoller(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		version = 5
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, `{"version": %d, "root": "0x%02x"}`, version, version)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p, err := NewHTTPPoller(ctx, srv.URL, time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("NewHTTPPoller() error = %v", err)
+	}
+	if got, _ := p.CurrentRoot(ctx); !bytes.Equal(got, []byte{5}) {
+		t.Errorf("CurrentRoot() = %x", got)
+	}
+	sub := p.Subscribe(ctx)
+	go p.Run(ctx)
+
+	mu.Lock()
+	version = 7
+	mu.Unlock()
+	if u := <-sub; u.Version != 7 || !bytes.Equal(u.Root, []byte{7}) {
+		t.Errorf("Subscribe() update = %+v", u)
+	}
+	if err := p.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+
+	var _ Provider = p
+}
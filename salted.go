@@ -0,0 +1,139 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// SaltSize is the size in bytes of the random salt of every leaf of a SaltedTree.
+const SaltSize = 32
+
+// saltedBlock is a data block prefixed with its salt.
+type saltedBlock struct {
+	salt  []byte
+	block DataBlock
+}
+
+// Serialize returns the salt followed by the serialized data block.
+func (s *saltedBlock) Serialize() ([]byte, error) {
+	if s.block == nil {
+		return nil, ErrDataBlockIsNil
+	}
+
+	data, err := s.block.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, len(s.salt)+len(data))
+	buf = append(buf, s.salt...)
+
+	return append(buf, data...), nil
+}
+
+// SaltedTree is a Merkle Tree whose leaves are the hashes of random per-leaf salts followed by the data blocks.
+// Disclosing a leaf reveals its salt only, so undisclosed siblings in proofs reveal nothing
+// about their contents, enabling SD-JWT-style selective disclosure.
+type SaltedTree struct {
+	*MerkleTree
+	salts [][]byte
+}
+
+// Disclosure reveals a single leaf of a SaltedTree: its salt and its proof.
+type Disclosure struct {
+	Salt  HexBytes         `json:"salt"`
+	Proof *SerializedProof `json:"proof"`
+}
+
+// NewSalted generates fresh random salts for the data blocks and builds the salted tree.
+// Leaf hashing cannot be disabled, as the salt would then be disclosed with every sibling.
+func NewSalted(config *Config, blocks []DataBlock) (*SaltedTree, error) {
+	if err := config.checkBlockPositions(); err != nil {
+		return nil, err
+	}
+
+	if config != nil && config.DisableLeafHashing {
+		return nil, ErrSaltedLeafHashingDisabled
+	}
+
+	var (
+		salts   = make([][]byte, len(blocks))
+		salted  = make([]DataBlock, len(blocks))
+		saltBuf = make([]byte, SaltSize*len(blocks))
+	)
+
+	if _, err := rand.Read(saltBuf); err != nil {
+		return nil, fmt.Errorf("NewSalted: %w", err)
+	}
+
+	for i := range blocks {
+		salts[i] = saltBuf[i*SaltSize : (i+1)*SaltSize]
+		salted[i] = &saltedBlock{salt: salts[i], block: blocks[i]}
+	}
+
+	m, err := New(config, salted)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SaltedTree{MerkleTree: m, salts: salts}, nil
+}
+
+// Salt returns the salt of the leaf at idx.
+func (s *SaltedTree) Salt(idx int) ([]byte, error) {
+	if idx < 0 || idx >= len(s.salts) {
+		return nil, ErrIndexOutOfRange
+	}
+
+	return s.salts[idx], nil
+}
+
+// Disclose returns the disclosure of the leaf at idx.
+func (s *SaltedTree) Disclose(idx int) (*Disclosure, error) {
+	salt, err := s.Salt(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := s.proofByIndex(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Disclosure{Salt: salt, Proof: NewSerializedProof(proof)}, nil
+}
+
+// VerifyDisclosure checks the disclosed data block against the root of a SaltedTree.
+func VerifyDisclosure(dataBlock DataBlock, disclosure *Disclosure, root []byte, config *Config) (bool, error) {
+	if dataBlock == nil {
+		return false, ErrDataBlockIsNil
+	}
+
+	if disclosure == nil || disclosure.Proof == nil {
+		return false, ErrProofIsNil
+	}
+
+	return Verify(&saltedBlock{salt: disclosure.Salt, block: dataBlock}, disclosure.Proof.Proof(), root, config)
+}
@@ -0,0 +1,77 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestNewSalted(t *testing.T) {
+	blocks := []DataBlock{
+		&mock.DataBlock{Data: []byte("name=alice")},
+		&mock.DataBlock{Data: []byte("age=42")},
+		&mock.DataBlock{Data: []byte("country=fr")},
+	}
+	s1, err := NewSalted(&Config{Mode: ModeProofGenAndTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("NewSalted() error = %v", err)
+	}
+	s2, err := NewSalted(nil, blocks)
+	if err != nil {
+		t.Fatalf("NewSalted() error = %v", err)
+	}
+	if bytes.Equal(s1.Root, s2.Root) {
+		t.Errorf("NewSalted() roots over the same blocks should differ with fresh salts")
+	}
+	disclosure, err := s1.Disclose(1)
+	if err != nil {
+		t.Fatalf("Disclose() error = %v", err)
+	}
+	data, err := json.Marshal(disclosure)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	decoded := new(Disclosure)
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if ok, err := VerifyDisclosure(blocks[1], decoded, s1.Root, nil); err != nil || !ok {
+		t.Errorf("VerifyDisclosure() = %v, error = %v", ok, err)
+	}
+	if ok, err := VerifyDisclosure(&mock.DataBlock{Data: []byte("age=18")}, decoded, s1.Root, nil); err != nil || ok {
+		t.Errorf("VerifyDisclosure() wrong data = %v, error = %v", ok, err)
+	}
+	if ok, err := Verify(blocks[1], decoded.Proof.Proof(), s1.Root, nil); err != nil || ok {
+		t.Errorf("Verify() without salt = %v, error = %v", ok, err)
+	}
+	if _, err := s1.Disclose(3); err != ErrIndexOutOfRange {
+		t.Errorf("Disclose() out of range error = %v", err)
+	}
+	if _, err := NewSalted(&Config{DisableLeafHashing: true}, blocks); err != ErrSaltedLeafHashingDisabled {
+		t.Errorf("NewSalted() leaf hashing disabled error = %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/txaty/go-merkletree/verifier"
+)
+
+// SampleBundle is a spot-audit sample of a committed tree: the leaves at indices selected
+// deterministically from a seed, with their proofs.
+type SampleBundle struct {
+	// Seed is the seed the indices are derived from, e.g. a beacon value fixed after the commitment.
+	Seed []byte
+	// NumLeaves is the number of leaves of the sampled tree.
+	NumLeaves int
+	// Indices are the sampled leaf indices, in increasing order.
+	Indices []int
+	// Leaves are the sampled leaves.
+	Leaves [][]byte
+	// Proofs are the proofs of the sampled leaves.
+	Proofs []*Proof
+}
+
+// SampleIndices returns n distinct leaf indices of a tree with numLeaves leaves, in increasing order,
+// selected uniformly and deterministically from the seed: the indices are drawn from HMAC-SHA256 under
+// the seed of a counter, so that anyone holding the seed re-derives the same sample.
+func SampleIndices(n int, seed []byte, numLeaves int) ([]int, error) {
+	if n <= 0 || n > numLeaves {
+		return nil, ErrInvalidSampleSize
+	}
+
+	var (
+		mac      = hmac.New(sha256.New, seed)
+		selected = make(map[int]struct{}, n)
+		indices  = make([]int, 0, n)
+		// Draws at or above limit are rejected so that every index is equally likely.
+		limit = math.MaxUint64 - math.MaxUint64%uint64(numLeaves)
+		buf   [8]byte
+		tag   []byte
+	)
+
+	for counter := uint64(0); len(indices) < n; counter++ {
+		binary.BigEndian.PutUint64(buf[:], counter)
+		mac.Reset()
+		mac.Write(buf[:])
+		tag = mac.Sum(tag[:0])
+
+		draw := binary.BigEndian.Uint64(tag)
+		if draw >= limit {
+			continue
+		}
+
+		idx := int(draw % uint64(numLeaves))
+		if _, ok := selected[idx]; ok {
+			continue
+		}
+
+		selected[idx] = struct{}{}
+		indices = append(indices, idx)
+	}
+
+	sort.Ints(indices)
+
+	return indices, nil
+}
+
+// SampleProofs selects n leaf indices deterministically from the seed with SampleIndices,
+// and returns the sampled leaves with their proofs. Verify the bundle with VerifySampleBundle.
+func (m *MerkleTree) SampleProofs(n int, seed []byte) (*SampleBundle, error) {
+	indices, err := SampleIndices(n, seed, m.NumLeaves)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &SampleBundle{
+		Seed:      bytes.Clone(seed),
+		NumLeaves: m.NumLeaves,
+		Indices:   indices,
+		Leaves:    make([][]byte, n),
+		Proofs:    make([]*Proof, n),
+	}
+
+	for i, idx := range indices {
+		bundle.Leaves[i] = m.Leaves[idx]
+		if bundle.Proofs[i], err = m.proofByIndex(idx); err != nil {
+			return nil, err
+		}
+	}
+
+	return bundle, nil
+}
+
+// VerifySampleBundle checks that the bundle holds exactly the leaves selected by its seed and that
+// every sampled leaf is included at its index under the root.
+// It returns false if the prover chose other indices than the ones derived from the seed.
+func VerifySampleBundle(bundle *SampleBundle, root []byte, config *Config) (bool, error) {
+	if bundle == nil {
+		return false, ErrProofIsNil
+	}
+
+	if len(bundle.Leaves) != len(bundle.Indices) || len(bundle.Proofs) != len(bundle.Indices) {
+		return false, nil
+	}
+
+	indices, err := SampleIndices(len(bundle.Indices), bundle.Seed, bundle.NumLeaves)
+	if err != nil {
+		return false, err
+	}
+
+	for i, idx := range indices {
+		if bundle.Indices[i] != idx {
+			return false, nil
+		}
+	}
+
+	if config == nil {
+		config = new(Config)
+	}
+
+	if config.HashFunc == nil {
+		config.HashFunc = DefaultHashFunc
+	}
+
+	var (
+		vc    = config.verifierConfig()
+		depth = bits.Len(uint(bundle.NumLeaves - 1))
+	)
+
+	for i, proof := range bundle.Proofs {
+		if proof == nil {
+			return false, ErrProofIsNil
+		}
+
+		if len(proof.Siblings) != depth || proof.Index() != indices[i] {
+			return false, nil
+		}
+
+		if ok, err := verifier.VerifyLeaf(bundle.Leaves[i], proof.Siblings, proof.Path, root, vc); err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,119 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMerkleTree_SampleProofs(t *testing.T) {
+	blocks := mockDataBlocks(1000)
+	m, err := New(&Config{Mode: ModeProofGenAndTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		n       int
+		tamper  func(b *SampleBundle)
+		want    bool
+		wantErr error
+	}{
+		{name: "test_1", n: 1, want: true},
+		{name: "test_50", n: 50, want: true},
+		{name: "test_all", n: 1000, want: true},
+		{name: "test_zero", n: 0, wantErr: ErrInvalidSampleSize},
+		{name: "test_too_many", n: 1001, wantErr: ErrInvalidSampleSize},
+		{
+			name: "test_cherry_picked_index",
+			n:    10,
+			tamper: func(b *SampleBundle) {
+				b.Indices[3]++
+			},
+		},
+		{
+			name: "test_swapped_proofs",
+			n:    10,
+			tamper: func(b *SampleBundle) {
+				b.Leaves[0], b.Leaves[1] = b.Leaves[1], b.Leaves[0]
+				b.Proofs[0], b.Proofs[1] = b.Proofs[1], b.Proofs[0]
+			},
+		},
+		{
+			name: "test_tampered_leaf",
+			n:    10,
+			tamper: func(b *SampleBundle) {
+				b.Leaves[5] = []byte("tampered")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := m.SampleProofs(tt.n, []byte("beacon round 42"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SampleProofs() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			again, err := m.SampleProofs(tt.n, []byte("beacon round 42"))
+			if err != nil {
+				t.Fatalf("SampleProofs() error = %v", err)
+			}
+			if !reflect.DeepEqual(bundle.Indices, again.Indices) {
+				t.Fatal("SampleProofs() is not deterministic")
+			}
+			if tt.tamper != nil {
+				tt.tamper(bundle)
+			}
+			got, err := VerifySampleBundle(bundle, m.Root, nil)
+			if err != nil {
+				t.Fatalf("VerifySampleBundle() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("VerifySampleBundle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampleIndices(t *testing.T) {
+	a, err := SampleIndices(20, []byte("seed a"), 100)
+	if err != nil {
+		t.Fatalf("SampleIndices() error = %v", err)
+	}
+	b, err := SampleIndices(20, []byte("seed b"), 100)
+	if err != nil {
+		t.Fatalf("SampleIndices() error = %v", err)
+	}
+	if reflect.DeepEqual(a, b) {
+		t.Error("SampleIndices() does not depend on the seed")
+	}
+	for i := range a {
+		if a[i] < 0 || a[i] >= 100 || i > 0 && a[i] <= a[i-1] {
+			t.Fatalf("SampleIndices() = %v, want distinct increasing indices in range", a)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+const (
+	// sampleSeedDomain separates sample seeds from any other hash of the same randomness.
+	sampleSeedDomain = "go-merkletree sample seed v1"
+	// sampleVRFInputDomain separates the VRF input of a sample from any other VRF evaluation of the same key.
+	sampleVRFInputDomain = "go-merkletree sample vrf input v1"
+)
+
+// VRFProver evaluates a verifiable random function under its private key, e.g. ECVRF (RFC 9381).
+type VRFProver interface {
+	// Prove returns the VRF output of the input and its proof.
+	Prove(input []byte) (output, proof []byte, err error)
+}
+
+// VRFVerifier verifies VRF proofs under the public key of a VRFProver.
+type VRFVerifier interface {
+	// Verify returns the VRF output of the input if the proof is valid, or an error otherwise.
+	Verify(input, proof []byte) ([]byte, error)
+}
+
+// VRFSample is a sample bundle whose seed is derived from the VRF output of the prover over the root,
+// so that the prover can neither choose the sampled indices nor predict them before committing to the tree.
+type VRFSample struct {
+	Bundle *SampleBundle
+	// Output is the VRF output the seed of the bundle is derived from.
+	Output []byte
+	// Proof is the VRF proof of Output.
+	Proof []byte
+}
+
+// SampleSeed derives the seed of a sample of the tree with the root from public randomness,
+// a VRF output or the hash of a block produced after the commitment of the root.
+// Binding the seed to the root prevents reusing the randomness for another tree.
+func SampleSeed(randomness, root []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(sampleSeedDomain))
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(root))))
+	h.Write(root)
+	h.Write(randomness)
+
+	return h.Sum(nil)
+}
+
+// SampleVRFInput returns the VRF input of a sample of the tree with the root and the number of leaves.
+func SampleVRFInput(root []byte, numLeaves int) []byte {
+	buf := make([]byte, 0, len(sampleVRFInputDomain)+len(root)+12)
+	buf = append(buf, sampleVRFInputDomain...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(root)))
+	buf = append(buf, root...)
+
+	return binary.BigEndian.AppendUint64(buf, uint64(numLeaves))
+}
+
+// SampleFromBlockHash samples n leaves with the seed derived by SampleSeed from the block hash.
+// The block must be produced after the root is committed. Verify the bundle with VerifyBlockHashSample.
+func (m *MerkleTree) SampleFromBlockHash(n int, blockHash []byte) (*SampleBundle, error) {
+	return m.SampleProofs(n, SampleSeed(blockHash, m.Root))
+}
+
+// VerifyBlockHashSample checks that the seed of the bundle is derived from the block hash, which the
+// verifier reads from the chain, and verifies the bundle with VerifySampleBundle.
+func VerifyBlockHashSample(bundle *SampleBundle, blockHash, root []byte, config *Config) (bool, error) {
+	if bundle == nil {
+		return false, ErrProofIsNil
+	}
+
+	if !bytes.Equal(bundle.Seed, SampleSeed(blockHash, root)) {
+		return false, nil
+	}
+
+	return VerifySampleBundle(bundle, root, config)
+}
+
+// SampleWithVRF samples n leaves with the seed derived from the VRF output of the prover over
+// SampleVRFInput. Verify the sample with VerifyVRFSample.
+func (m *MerkleTree) SampleWithVRF(n int, prover VRFProver) (*VRFSample, error) {
+	output, proof, err := prover.Prove(SampleVRFInput(m.Root, m.NumLeaves))
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := m.SampleProofs(n, SampleSeed(output, m.Root))
+	if err != nil {
+		return nil, err
+	}
+
+	return &VRFSample{Bundle: bundle, Output: output, Proof: proof}, nil
+}
+
+// VerifyVRFSample checks the VRF proof of the sample with the public key of the prover, that the seed
+// of its bundle is derived from the VRF output, and verifies the bundle with VerifySampleBundle.
+// It returns ErrVRFProof if the VRF proof is invalid.
+func VerifyVRFSample(sample *VRFSample, verifier VRFVerifier, root []byte, config *Config) (bool, error) {
+	if sample == nil || sample.Bundle == nil {
+		return false, ErrProofIsNil
+	}
+
+	output, err := verifier.Verify(SampleVRFInput(root, sample.Bundle.NumLeaves), sample.Proof)
+	if err != nil || !bytes.Equal(output, sample.Output) {
+		return false, ErrVRFProof
+	}
+
+	if !bytes.Equal(sample.Bundle.Seed, SampleSeed(output, root)) {
+		return false, nil
+	}
+
+	return VerifySampleBundle(sample.Bundle, root, config)
+}
@@ -0,0 +1,148 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+// testVRF is a VRF built from deterministic Ed25519 signatures, whose output is the hash of the signature.
+type testVRF struct {
+	priv ed25519.PrivateKey
+	pub  ed25519.PublicKey
+}
+
+func (v *testVRF) Prove(input []byte) ([]byte, []byte, error) {
+	proof := ed25519.Sign(v.priv, input)
+	output := sha256.Sum256(proof)
+	return output[:], proof, nil
+}
+
+func (v *testVRF) Verify(input, proof []byte) ([]byte, error) {
+	if !ed25519.Verify(v.pub, input, proof) {
+		return nil, ErrVRFProof
+	}
+	output := sha256.Sum256(proof)
+	return output[:], nil
+}
+
+func TestMerkleTree_SampleWithVRF(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(&Config{Mode: ModeProofGenAndTreeBuild}, mockDataBlocks(300))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		verifier VRFVerifier
+		root     []byte
+		tamper   func(s *VRFSample)
+		want     bool
+		wantErr  error
+	}{
+		{name: "test_valid", want: true},
+		{name: "test_other_key", verifier: &testVRF{pub: otherPub}, wantErr: ErrVRFProof},
+		{name: "test_other_root", root: make([]byte, 32), wantErr: ErrVRFProof},
+		{
+			name: "test_forged_output",
+			tamper: func(s *VRFSample) {
+				s.Output[0] ^= 1
+			},
+			wantErr: ErrVRFProof,
+		},
+		{
+			name: "test_chosen_seed",
+			tamper: func(s *VRFSample) {
+				bundle, err := m.SampleProofs(len(s.Bundle.Indices), []byte("chosen"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				s.Bundle = bundle
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vrf := &testVRF{priv: priv, pub: pub}
+			sample, err := m.SampleWithVRF(20, vrf)
+			if err != nil {
+				t.Fatalf("SampleWithVRF() error = %v", err)
+			}
+			if tt.tamper != nil {
+				tt.tamper(sample)
+			}
+			var verifier VRFVerifier = vrf
+			if tt.verifier != nil {
+				verifier = tt.verifier
+			}
+			root := m.Root
+			if tt.root != nil {
+				root = tt.root
+			}
+			got, err := VerifyVRFSample(sample, verifier, root, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyVRFSample() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyVRFSample() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerkleTree_SampleFromBlockHash(t *testing.T) {
+	m, err := New(nil, mockDataBlocks(100))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	blockHash := sha256.Sum256([]byte("block 19000000"))
+	bundle, err := m.SampleFromBlockHash(10, blockHash[:])
+	if err != nil {
+		t.Fatalf("SampleFromBlockHash() error = %v", err)
+	}
+	if ok, err := VerifyBlockHashSample(bundle, blockHash[:], m.Root, nil); err != nil || !ok {
+		t.Errorf("VerifyBlockHashSample() = %v, error = %v", ok, err)
+	}
+	otherHash := sha256.Sum256([]byte("block 19000001"))
+	if ok, err := VerifyBlockHashSample(bundle, otherHash[:], m.Root, nil); err != nil || ok {
+		t.Errorf("VerifyBlockHashSample() other block = %v, error = %v", ok, err)
+	}
+	// The seed of a bare sample bundle is not bound to the block hash.
+	chosen, err := m.SampleProofs(10, blockHash[:])
+	if err != nil {
+		t.Fatalf("SampleProofs() error = %v", err)
+	}
+	if ok, err := VerifyBlockHashSample(chosen, blockHash[:], m.Root, nil); err != nil || ok {
+		t.Errorf("VerifyBlockHashSample() unbound seed = %v, error = %v", ok, err)
+	}
+}
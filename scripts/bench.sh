@@ -0,0 +1,49 @@
+#!/bin/sh
+# Runs the sweep benchmarks in a benchstat-friendly format and compares them with the reference numbers,
+# exiting non-zero if benchstat reports a significant increase of time, memory or allocations above
+# BENCH_TOLERANCE percent (5 by default).
+#
+# Usage:
+#   scripts/bench.sh [output file]         # run and compare with testdata/bench/reference.txt
+#   BENCH_COUNT=10 BENCH_ARGS=-bench.large scripts/bench.sh
+#   BENCH_TOLERANCE=10 scripts/bench.sh    # only fail on regressions above 10%
+#   UPDATE_REFERENCE=1 scripts/bench.sh    # overwrite the reference numbers
+#
+# benchstat is installed with: go install golang.org/x/perf/cmd/benchstat@latest
+set -eu
+
+cd "$(dirname "$0")/.."
+
+OUT="${1:-bench_new.txt}"
+REFERENCE="testdata/bench/reference.txt"
+COUNT="${BENCH_COUNT:-6}"
+TOLERANCE="${BENCH_TOLERANCE:-5}"
+
+go test -run '^$' -bench '^BenchmarkSweep_' -benchmem -count "$COUNT" -timeout 0 . ${BENCH_ARGS:-} | tee "$OUT"
+
+if [ "${UPDATE_REFERENCE:-0}" = "1" ]; then
+	mkdir -p "$(dirname "$REFERENCE")"
+	cp "$OUT" "$REFERENCE"
+	exit 0
+fi
+
+if ! command -v benchstat >/dev/null 2>&1; then
+	echo "benchstat not found, cannot compare with $REFERENCE" >&2
+	exit 1
+fi
+
+STAT="$(benchstat "$REFERENCE" "$OUT")"
+echo "$STAT"
+
+# Every unit is better lower: a significant change reads "+x.xx% (p=...)", an insignificant one "~ (p=...)".
+echo "$STAT" | awk -v tolerance="$TOLERANCE" '
+	{
+		for (i = 1; i < NF; i++) {
+			if ($i ~ /^\+[0-9.]+%$/ && $(i + 1) ~ /^\(p=/ && substr($i, 2, length($i) - 2) + 0 > tolerance + 0) {
+				print "regression: " $0 > "/dev/stderr"
+				failed = 1
+			}
+		}
+	}
+	END { exit failed }
+'
@@ -0,0 +1,131 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+)
+
+// ShuffledTree is a Merkle Tree whose leaves are placed in an order derived from a secret key,
+// so that proof recipients cannot infer the positions or identities of their neighbors
+// from leaf indices, e.g. in a liabilities tree.
+// Data blocks keep being addressed by their original index; the tree maps them to their shuffled position.
+type ShuffledTree struct {
+	*MerkleTree
+	// positions[i] is the leaf position of the data block at original index i.
+	positions []int
+	// indices is the inverse of positions.
+	indices []int
+}
+
+// ShufflePermutation returns the deterministic permutation of n indices keyed by key:
+// the original index i is placed at position ShufflePermutation(key, n)[i].
+// Indices are ordered by their HMAC-SHA256 under the key, a keyed PRF, so the permutation
+// cannot be reproduced without the key.
+func ShufflePermutation(key []byte, n int) []int {
+	var (
+		tags    = make([][]byte, n)
+		indices = make([]int, n)
+		buf     [8]byte
+	)
+
+	mac := hmac.New(sha256.New, key)
+
+	for i := range indices {
+		indices[i] = i
+
+		binary.BigEndian.PutUint64(buf[:], uint64(i))
+		mac.Reset()
+		mac.Write(buf[:])
+		tags[i] = mac.Sum(nil)
+	}
+
+	sort.Slice(indices, func(a, b int) bool {
+		return bytes.Compare(tags[indices[a]], tags[indices[b]]) < 0
+	})
+
+	positions := make([]int, n)
+	for pos, idx := range indices {
+		positions[idx] = pos
+	}
+
+	return positions
+}
+
+// NewShuffled builds the tree over the data blocks permuted with ShufflePermutation under the key.
+func NewShuffled(config *Config, blocks []DataBlock, key []byte) (*ShuffledTree, error) {
+	if err := config.checkBlockPositions(); err != nil {
+		return nil, err
+	}
+
+	positions := ShufflePermutation(key, len(blocks))
+
+	var (
+		shuffled = make([]DataBlock, len(blocks))
+		indices  = make([]int, len(blocks))
+	)
+
+	for idx, pos := range positions {
+		shuffled[pos] = blocks[idx]
+		indices[pos] = idx
+	}
+
+	m, err := New(config, shuffled)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShuffledTree{MerkleTree: m, positions: positions, indices: indices}, nil
+}
+
+// Position returns the leaf position of the data block at the original index idx.
+func (s *ShuffledTree) Position(idx int) (int, error) {
+	if idx < 0 || idx >= len(s.positions) {
+		return 0, ErrIndexOutOfRange
+	}
+
+	return s.positions[idx], nil
+}
+
+// OriginalIndex returns the original index of the data block at leaf position pos.
+func (s *ShuffledTree) OriginalIndex(pos int) (int, error) {
+	if pos < 0 || pos >= len(s.indices) {
+		return 0, ErrIndexOutOfRange
+	}
+
+	return s.indices[pos], nil
+}
+
+// ProofOf returns the proof of the data block at the original index idx.
+func (s *ShuffledTree) ProofOf(idx int) (*Proof, error) {
+	pos, err := s.Position(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.proofByIndex(pos)
+}
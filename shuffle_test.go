@@ -0,0 +1,84 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShufflePermutation(t *testing.T) {
+	key := []byte("shuffle key")
+	positions := ShufflePermutation(key, 64)
+	if !reflect.DeepEqual(positions, ShufflePermutation(key, 64)) {
+		t.Fatal("ShufflePermutation() is not deterministic")
+	}
+	seen := make([]bool, len(positions))
+	for _, pos := range positions {
+		if pos < 0 || pos >= len(positions) || seen[pos] {
+			t.Fatalf("ShufflePermutation() = %v is not a permutation", positions)
+		}
+		seen[pos] = true
+	}
+	if reflect.DeepEqual(positions, ShufflePermutation([]byte("other key"), 64)) {
+		t.Error("ShufflePermutation() does not depend on the key")
+	}
+}
+
+func TestNewShuffled(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		num    int
+	}{
+		{name: "test_2", num: 2},
+		{name: "test_9", num: 9},
+		{name: "test_100_tree_build", config: &Config{Mode: ModeTreeBuild}, num: 100},
+		{name: "test_100_parallel", config: &Config{RunInParallel: true}, num: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.num)
+			s, err := NewShuffled(tt.config, blocks, []byte("key"))
+			if err != nil {
+				t.Fatalf("NewShuffled() error = %v", err)
+			}
+			for idx, block := range blocks {
+				proof, err := s.ProofOf(idx)
+				if err != nil {
+					t.Fatalf("ProofOf() error = %v", err)
+				}
+				if ok, err := s.Verify(block, proof); err != nil || !ok {
+					t.Errorf("Verify() %d = %v, error = %v", idx, ok, err)
+				}
+				pos, _ := s.Position(idx)
+				if got, _ := s.OriginalIndex(pos); got != idx {
+					t.Errorf("OriginalIndex(Position(%d)) = %d", idx, got)
+				}
+			}
+			if _, err := s.ProofOf(tt.num); err != ErrIndexOutOfRange {
+				t.Errorf("ProofOf() error = %v, want %v", err, ErrIndexOutOfRange)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/txaty/go-merkletree/verifier"
+)
+
+// SizeBoundRoot binds the root to the number of leaves of its tree: it returns the hash of the root
+// followed by the number of leaves as a big-endian uint64, with the interior node hash function of the
+// configuration. A size-bound root cannot be reinterpreted as the commitment of a tree of another size.
+func SizeBoundRoot(root []byte, numLeaves int, config *Config) ([]byte, error) {
+	if config == nil {
+		config = new(Config)
+	}
+
+	hashFunc := config.nodeHashFunc()
+	if hashFunc == nil {
+		hashFunc = DefaultHashFunc
+	}
+
+	data := make([]byte, 0, len(root)+8)
+	data = append(data, root...)
+	data = binary.BigEndian.AppendUint64(data, uint64(numLeaves))
+
+	return hashFunc(data)
+}
+
+// SizeBoundRoot returns the root of the tree bound to its number of leaves, see SizeBoundRoot.
+// It is the commitment to publish for trees configured with BindLeafCount, whose Root is left unbound.
+func (m *MerkleTree) SizeBoundRoot() ([]byte, error) {
+	return SizeBoundRoot(m.Root, m.NumLeaves, m.Config)
+}
+
+// computeRoot folds the proof onto the leaf and returns the root, bound to ExpectedNumLeaves if
+// BindLeafCount is set, after checking that the proof fits a tree of that size.
+func (c *Config) computeRoot(leaf []byte, siblings [][]byte, path uint32) ([]byte, error) {
+	root, err := verifier.ComputeRoot(leaf, siblings, path, c.verifierConfig())
+	if err != nil || !c.BindLeafCount {
+		return root, err
+	}
+
+	if c.ExpectedNumLeaves == 0 {
+		return nil, ErrLeafCountRequired
+	}
+
+	if err := verifier.CheckProof(leaf, siblings, path, c.ExpectedNumLeaves); err != nil {
+		return nil, err
+	}
+
+	return SizeBoundRoot(root, c.ExpectedNumLeaves, c)
+}
+
+// verifyLeaf checks the leaf against the root, bound to ExpectedNumLeaves if BindLeafCount is set.
+func (c *Config) verifyLeaf(leaf []byte, siblings [][]byte, path uint32, root []byte) (bool, error) {
+	result, err := c.computeRoot(leaf, siblings, path)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(result, root), nil
+}
@@ -0,0 +1,101 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/txaty/go-merkletree/verifier"
+)
+
+func TestSizeBoundRoot(t *testing.T) {
+	// The duplicated last leaf gives both trees the same unbound root.
+	short, err := New(nil, letterBlocks("abc"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	long, err := New(nil, letterBlocks("abcc"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !bytes.Equal(short.Root, long.Root) {
+		t.Fatalf("Root = %x, want %x", long.Root, short.Root)
+	}
+	shortBound, err := short.SizeBoundRoot()
+	if err != nil {
+		t.Fatalf("SizeBoundRoot() error = %v", err)
+	}
+	longBound, err := long.SizeBoundRoot()
+	if err != nil {
+		t.Fatalf("SizeBoundRoot() error = %v", err)
+	}
+	if bytes.Equal(shortBound, longBound) || bytes.Equal(shortBound, short.Root) {
+		t.Errorf("SizeBoundRoot() = %x and %x, want distinct roots", shortBound, longBound)
+	}
+}
+
+func TestVerify_bindLeafCount(t *testing.T) {
+	blocks := mockDataBlocks(11)
+	tests := []struct {
+		name      string
+		config    *Config
+		numLeaves int
+		wantOK    bool
+		wantErr   error
+	}{
+		{name: "test_bound", config: &Config{BindLeafCount: true}, numLeaves: 11, wantOK: true},
+		{name: "test_sorted", config: &Config{BindLeafCount: true, SortSiblingPairs: true}, numLeaves: 11, wantOK: true},
+		{name: "test_other_size", config: &Config{BindLeafCount: true}, numLeaves: 12},
+		{name: "test_other_depth", config: &Config{BindLeafCount: true}, numLeaves: 17, wantErr: verifier.ErrMalformedProof},
+		{name: "test_size_missing", config: &Config{BindLeafCount: true}, wantErr: ErrLeafCountRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			root, err := m.SizeBoundRoot()
+			if err != nil {
+				t.Fatalf("SizeBoundRoot() error = %v", err)
+			}
+			config := *tt.config
+			config.ExpectedNumLeaves = tt.numLeaves
+			for i, block := range blocks {
+				if ok, err := m.Verify(block, m.Proofs[i]); err != nil || !ok {
+					t.Errorf("MerkleTree.Verify(%d) = %t, %v, want true", i, ok, err)
+				}
+				ok, err := Verify(block, m.Proofs[i], root, &config)
+				if !errors.Is(err, tt.wantErr) || ok != tt.wantOK {
+					t.Errorf("Verify(%d) = %t, %v, want %t, %v", i, ok, err, tt.wantOK, tt.wantErr)
+				}
+				// The unbound root is not a valid commitment of the bound tree.
+				if ok, _ := Verify(block, m.Proofs[i], m.Root, &config); ok {
+					t.Errorf("Verify(%d) with unbound root = true, want false", i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package sqltree builds Merkle Trees over snapshots of database tables read with database/sql,
+// so that services can prove that a row existed in a published snapshot.
+//
+// Rows are streamed from a query ordered by primary key and appended to an incremental tree,
+// so only the leaf hashes of the table are kept in memory. The first selected column is the primary key.
+package sqltree
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+var (
+	// ErrDuplicateKey is the error for a primary key returned twice by the snapshot query.
+	ErrDuplicateKey = errors.New("duplicate primary key in snapshot")
+	// ErrUnknownKey is the error for a primary key that is not in the snapshot.
+	ErrUnknownKey = errors.New("primary key is not in the snapshot")
+	// ErrUnsupportedType is the error for a column value whose type cannot be encoded.
+	ErrUnsupportedType = errors.New("unsupported column value type")
+)
+
+// Value type tags of the row encoding.
+const (
+	tagNull byte = iota
+	tagInt
+	tagFloat
+	tagBool
+	tagBytes
+	tagTime
+)
+
+// Querier runs the snapshot query, e.g. *sql.DB, *sql.Tx or *sql.Conn.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Row is the data block of a table row: the selected column names and values.
+type Row struct {
+	Columns []string
+	// Values are the driver values of the columns: nil, int64, float64, bool, []byte, string or time.Time.
+	// Strings and byte slices are encoded alike, as drivers return text columns as either.
+	Values []any
+}
+
+// Serialize encodes the number of columns (uint32, big-endian) followed by every column name
+// and value, so that the leaf commits to the column names as well as to the values.
+func (r *Row) Serialize() ([]byte, error) {
+	if len(r.Columns) != len(r.Values) {
+		return nil, fmt.Errorf("sqltree: %d columns, %d values", len(r.Columns), len(r.Values))
+	}
+
+	buf := binary.BigEndian.AppendUint32(nil, uint32(len(r.Columns)))
+
+	for i, name := range r.Columns {
+		buf = appendBytes(buf, []byte(name))
+
+		var err error
+		if buf, err = appendValue(buf, r.Values[i]); err != nil {
+			return nil, fmt.Errorf("column %q: %w", name, err)
+		}
+	}
+
+	return buf, nil
+}
+
+// Snapshot is the Merkle Tree over the rows of a table snapshot, indexed by primary key.
+type Snapshot struct {
+	tree  *mt.TreeSnapshot
+	index map[string]int
+	// Root is the Merkle root of the snapshot.
+	Root []byte
+	// Config is the tree configuration, to verify the row proofs with.
+	Config *mt.Config
+}
+
+// Build runs the query, which must select the rows ordered by primary key with the primary key
+// as first column, and builds the snapshot tree over the rows in that order.
+// The Mode, RunInParallel and NumRoutines configuration fields are not used.
+func Build(ctx context.Context, q Querier, config *mt.Config, query string, args ...any) (*Snapshot, error) {
+	if config == nil {
+		config = new(mt.Config)
+	}
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		tree  = mt.NewIngestTree(config)
+		index = make(map[string]int)
+		ptrs  = make([]any, len(columns))
+	)
+
+	for rows.Next() {
+		row := &Row{Columns: columns, Values: make([]any, len(columns))}
+		for i := range ptrs {
+			ptrs[i] = &row.Values[i]
+		}
+
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		pk, err := keyOf(row.Values[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := index[pk]; ok {
+			return nil, fmt.Errorf("%w: %v", ErrDuplicateKey, row.Values[0])
+		}
+
+		if index[pk], err = tree.Append(row); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := tree.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := snapshot.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		tree:   snapshot,
+		index:  index,
+		Root:   root,
+		Config: config,
+	}, nil
+}
+
+// Len returns the number of rows of the snapshot.
+func (s *Snapshot) Len() int {
+	return s.tree.Size
+}
+
+// Index returns the leaf index of the row with the primary key.
+func (s *Snapshot) Index(pk any) (int, error) {
+	key, err := keyOf(pk)
+	if err != nil {
+		return 0, err
+	}
+
+	idx, ok := s.index[key]
+	if !ok {
+		return 0, fmt.Errorf("%w: %v", ErrUnknownKey, pk)
+	}
+
+	return idx, nil
+}
+
+// Proof returns the proof that the row with the primary key is in the snapshot.
+// The row, as currently stored or as returned by the snapshot query, is verified with Verify.
+func (s *Snapshot) Proof(pk any) (*mt.Proof, error) {
+	idx, err := s.Index(pk)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tree.Proof(idx)
+}
+
+// Verify checks that the row is included in the snapshot with the root using the proof.
+func Verify(row *Row, proof *mt.Proof, root []byte, config *mt.Config) (bool, error) {
+	return mt.Verify(row, proof, root, config)
+}
+
+// keyOf returns the index key of a primary key value.
+func keyOf(pk any) (string, error) {
+	buf, err := appendValue(nil, pk)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+// appendValue appends the type tag of the value followed by its encoding.
+func appendValue(buf []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, tagNull), nil
+	case int64:
+		return binary.BigEndian.AppendUint64(append(buf, tagInt), uint64(v)), nil
+	case int:
+		return binary.BigEndian.AppendUint64(append(buf, tagInt), uint64(v)), nil
+	case int32:
+		return binary.BigEndian.AppendUint64(append(buf, tagInt), uint64(v)), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(append(buf, tagFloat), math.Float64bits(v)), nil
+	case bool:
+		if v {
+			return append(buf, tagBool, 1), nil
+		}
+
+		return append(buf, tagBool, 0), nil
+	case []byte:
+		return appendBytes(append(buf, tagBytes), v), nil
+	case string:
+		return appendBytes(append(buf, tagBytes), []byte(v)), nil
+	case time.Time:
+		return binary.BigEndian.AppendUint64(append(buf, tagTime), uint64(v.UnixNano())), nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+}
+
+// appendBytes appends the length of b (uint32, big-endian) followed by b.
+func appendBytes(buf, b []byte) []byte {
+	return append(binary.BigEndian.AppendUint32(buf, uint32(len(b))), b...)
+}
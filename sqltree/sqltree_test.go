@@ -0,0 +1,173 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package sqltree
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+// testTables are the tables served by the test driver, keyed by query.
+var testTables = map[string]*testRows{}
+
+func init() {
+	sql.Register("sqltree_test", testDriver{})
+}
+
+type testDriver struct{}
+
+func (testDriver) Open(string) (driver.Conn, error) { return testConn{}, nil }
+
+type testConn struct{}
+
+func (testConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (testConn) Close() error                        { return nil }
+func (testConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (testConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	table, ok := testTables[query]
+	if !ok {
+		return nil, errors.New("unknown table")
+	}
+	return &testRows{columns: table.columns, values: table.values}, nil
+}
+
+type testRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *testRows) Columns() []string { return r.columns }
+func (r *testRows) Close() error      { return nil }
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestBuild(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	testTables["accounts"] = &testRows{
+		columns: []string{"id", "name", "balance", "active", "created_at", "note"},
+		values: [][]driver.Value{
+			{int64(1), []byte("alice"), 10.5, true, created, nil},
+			{int64(2), []byte("bob"), 0.0, false, created, []byte("vip")},
+			{int64(3), []byte("carol"), -3.25, true, created.Add(time.Hour), nil},
+		},
+	}
+	testTables["duplicates"] = &testRows{
+		columns: []string{"id"},
+		values:  [][]driver.Value{{"a"}, {"b"}, {"a"}},
+	}
+	db, err := sql.Open("sqltree_test", "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	snapshot, err := Build(context.Background(), db, nil, "accounts")
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if snapshot.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", snapshot.Len())
+	}
+	tests := []struct {
+		name    string
+		pk      any
+		row     *Row
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "test_row",
+			pk:   int64(2),
+			row: &Row{
+				Columns: []string{"id", "name", "balance", "active", "created_at", "note"},
+				Values:  []any{int64(2), "bob", 0.0, false, created, "vip"},
+			},
+			want: true,
+		},
+		{
+			name: "test_modified_row",
+			pk:   int64(3),
+			row: &Row{
+				Columns: []string{"id", "name", "balance", "active", "created_at", "note"},
+				Values:  []any{int64(3), "carol", 100.0, true, created.Add(time.Hour), nil},
+			},
+			want: false,
+		},
+		{name: "test_unknown_key", pk: int64(4), wantErr: ErrUnknownKey},
+		{name: "test_unsupported_key", pk: struct{}{}, wantErr: ErrUnsupportedType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proof, err := snapshot.Proof(tt.pk)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Proof() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			got, err := Verify(tt.row, proof, snapshot.Root, snapshot.Config)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The snapshot root is the root of a tree built over the rows at once.
+	blocks := make([]mt.DataBlock, 0, 3)
+	for _, values := range testTables["accounts"].values {
+		row := &Row{Columns: testTables["accounts"].columns}
+		for _, v := range values {
+			row.Values = append(row.Values, v)
+		}
+		blocks = append(blocks, row)
+	}
+	tree, err := mt.New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if string(tree.Root) != string(snapshot.Root) {
+		t.Error("Build() root differs from New()")
+	}
+
+	if _, err := Build(context.Background(), db, nil, "duplicates"); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("Build() error = %v, want %v", err, ErrDuplicateKey)
+	}
+}
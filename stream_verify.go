@@ -0,0 +1,159 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// StreamHelper is the helper data of a StreamVerifier: the number of leaves of the tree and the roots of
+// its maximal complete subtrees, from left to right. These lonely siblings are the only nodes whose pairs
+// are not complete, so at most Depth+1 nodes authenticate a stream of all leaves in order.
+type StreamHelper struct {
+	// NumLeaves is the number of leaves of the tree.
+	NumLeaves int `json:"numLeaves"`
+	// Peaks are the roots of the maximal complete subtrees covering the leaves, from left to right.
+	Peaks []HexBytes `json:"peaks"`
+}
+
+// StreamHelper returns the helper data verifying the stream of all leaves of the tree in order.
+func (m *MerkleTree) StreamHelper() (*StreamHelper, error) {
+	refs := compactRange(0, m.NumLeaves)
+	helper := &StreamHelper{
+		NumLeaves: m.NumLeaves,
+		Peaks:     make([]HexBytes, len(refs)),
+	}
+
+	for i, ref := range refs {
+		node, err := m.levelNode(ref.Level, ref.Index)
+		if err != nil {
+			return nil, err
+		}
+
+		helper.Peaks[i] = node
+	}
+
+	return helper, nil
+}
+
+// StreamVerifier verifies the leaves of a tree consumed one at a time, in order, against its root,
+// e.g. to validate a full dataset download without a proof per leaf. It keeps the compact range of the
+// leaves consumed so far, O(log n) nodes, and checks every complete subtree of the helper as soon as its
+// last leaf is consumed, so that a corrupted download fails early, locating the faulty leaves.
+type StreamVerifier struct {
+	config    *Config
+	numLeaves int
+	peaks     []rangeNode
+	// next is the index of the next peak to check.
+	next  int
+	nodes []rangeNode
+	count int
+}
+
+// NewStreamVerifier creates a verifier of the stream of leaves described by the helper, after checking
+// that the helper reproduces the root.
+func NewStreamVerifier(helper *StreamHelper, root []byte, config *Config) (*StreamVerifier, error) {
+	if helper == nil || helper.NumLeaves < 2 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	config = consistencyConfig(config)
+
+	refs := compactRange(0, helper.NumLeaves)
+	if len(helper.Peaks) != len(refs) {
+		return nil, fmt.Errorf("%w: helper has %d peaks, want %d", ErrStreamMismatch, len(helper.Peaks), len(refs))
+	}
+
+	peaks := make([]rangeNode, len(refs))
+	for i, ref := range refs {
+		peaks[i] = rangeNode{level: ref.Level, index: ref.Index, node: helper.Peaks[i]}
+	}
+
+	helperRoot, err := compactRangeRoot(peaks, helper.NumLeaves, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(helperRoot, root) {
+		return nil, fmt.Errorf("%w: helper does not reproduce the root", ErrStreamMismatch)
+	}
+
+	return &StreamVerifier{
+		config:    config,
+		numLeaves: helper.NumLeaves,
+		peaks:     peaks,
+	}, nil
+}
+
+// Add consumes the data block of the next leaf.
+func (v *StreamVerifier) Add(block DataBlock) error {
+	if block == nil {
+		return ErrDataBlockIsNil
+	}
+
+	leaf, err := dataBlockToLeaf(block, v.config.leafHashFunc(), v.config.DisableLeafHashing)
+	if err != nil {
+		return err
+	}
+
+	return v.AddLeaf(leaf)
+}
+
+// AddLeaf consumes the next leaf. It returns ErrStreamMismatch as soon as a complete subtree of the helper
+// does not match the leaves consumed, or if the stream has more leaves than the tree.
+func (v *StreamVerifier) AddLeaf(leaf []byte) error {
+	if v.count == v.numLeaves {
+		return fmt.Errorf("%w: more than %d leaves", ErrStreamMismatch, v.numLeaves)
+	}
+
+	var err error
+	if v.nodes, err = appendRangeNode(v.nodes, rangeNode{index: v.count, node: leaf}, v.config); err != nil {
+		return err
+	}
+
+	v.count++
+
+	// The next peak is completed when the node at its position in the compact range reaches its level.
+	if len(v.nodes) > v.next && v.nodes[v.next].level == v.peaks[v.next].level {
+		peak := v.peaks[v.next]
+		if !bytes.Equal(v.nodes[v.next].node, peak.node) {
+			start := peak.index << peak.level
+			return fmt.Errorf("%w: leaves %d to %d", ErrStreamMismatch, start, start+1<<peak.level-1)
+		}
+
+		v.next++
+	}
+
+	return nil
+}
+
+// Finish returns ErrStreamMismatch unless all leaves of the tree were consumed. With the complete subtrees
+// checked along the stream, it confirms that the leaves reproduce the root.
+func (v *StreamVerifier) Finish() error {
+	if v.count != v.numLeaves || v.next != len(v.peaks) {
+		return fmt.Errorf("%w: %d leaves consumed, want %d", ErrStreamMismatch, v.count, v.numLeaves)
+	}
+
+	return nil
+}
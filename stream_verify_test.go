@@ -0,0 +1,140 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestStreamVerifier(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "test_proof_gen", config: &Config{}},
+		{name: "test_tree_build", config: &Config{Mode: ModeTreeBuild}},
+		{name: "test_low_memory", config: &Config{Mode: ModeLowMemory}},
+		{name: "test_sorted", config: &Config{SortSiblingPairs: true}},
+		{name: "test_positional", config: &Config{PositionalHashFunc: PositionPrefixed(nil)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for numBlocks := 2; numBlocks <= 33; numBlocks++ {
+				blocks := mockDataBlocks(numBlocks)
+				m, err := New(tt.config, blocks)
+				if err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+				helper, err := m.StreamHelper()
+				if err != nil {
+					t.Fatalf("StreamHelper() error = %v", err)
+				}
+				data, err := json.Marshal(helper)
+				if err != nil {
+					t.Fatalf("Marshal() error = %v", err)
+				}
+				helper = new(StreamHelper)
+				if err := json.Unmarshal(data, helper); err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				v, err := NewStreamVerifier(helper, m.Root, m.Config)
+				if err != nil {
+					t.Fatalf("NewStreamVerifier(%d) error = %v", numBlocks, err)
+				}
+				for i, block := range blocks {
+					if err := v.Add(block); err != nil {
+						t.Fatalf("Add(%d) error = %v", i, err)
+					}
+				}
+				if err := v.Finish(); err != nil {
+					t.Errorf("Finish(%d) error = %v", numBlocks, err)
+				}
+				if err := v.AddLeaf(m.Leaves[0]); !errors.Is(err, ErrStreamMismatch) {
+					t.Errorf("AddLeaf() past the end error = %v, want %v", err, ErrStreamMismatch)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamVerifier_mismatch(t *testing.T) {
+	blocks := mockDataBlocks(13)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	helper, err := m.StreamHelper()
+	if err != nil {
+		t.Fatalf("StreamHelper() error = %v", err)
+	}
+
+	t.Run("test_corrupted_leaf", func(t *testing.T) {
+		v, err := NewStreamVerifier(helper, m.Root, nil)
+		if err != nil {
+			t.Fatalf("NewStreamVerifier() error = %v", err)
+		}
+		// Leaf 5 belongs to the first complete subtree, of leaves 0 to 7, which fails at leaf 7.
+		for i, leaf := range m.Leaves {
+			if i == 5 {
+				leaf = m.Leaves[4]
+			}
+			err := v.AddLeaf(leaf)
+			if i < 7 && err != nil {
+				t.Fatalf("AddLeaf(%d) error = %v", i, err)
+			}
+			if i == 7 {
+				if !errors.Is(err, ErrStreamMismatch) {
+					t.Fatalf("AddLeaf(%d) error = %v, want %v", i, err, ErrStreamMismatch)
+				}
+				break
+			}
+		}
+	})
+	t.Run("test_truncated", func(t *testing.T) {
+		v, err := NewStreamVerifier(helper, m.Root, nil)
+		if err != nil {
+			t.Fatalf("NewStreamVerifier() error = %v", err)
+		}
+		for _, leaf := range m.Leaves[:12] {
+			if err := v.AddLeaf(leaf); err != nil {
+				t.Fatalf("AddLeaf() error = %v", err)
+			}
+		}
+		if err := v.Finish(); !errors.Is(err, ErrStreamMismatch) {
+			t.Errorf("Finish() error = %v, want %v", err, ErrStreamMismatch)
+		}
+	})
+	t.Run("test_forged_helper", func(t *testing.T) {
+		forged := &StreamHelper{NumLeaves: helper.NumLeaves, Peaks: append([]HexBytes{}, helper.Peaks...)}
+		forged.Peaks[0] = m.Leaves[0]
+		if _, err := NewStreamVerifier(forged, m.Root, nil); !errors.Is(err, ErrStreamMismatch) {
+			t.Errorf("NewStreamVerifier() error = %v, want %v", err, ErrStreamMismatch)
+		}
+		forged.Peaks = forged.Peaks[1:]
+		if _, err := NewStreamVerifier(forged, m.Root, nil); !errors.Is(err, ErrStreamMismatch) {
+			t.Errorf("NewStreamVerifier() error = %v, want %v", err, ErrStreamMismatch)
+		}
+	})
+}
@@ -0,0 +1,256 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package streamtree commits ordered message streams, such as Kafka partitions, to incremental Merkle Trees.
+//
+// A Consumer appends every message as a leaf and emits a signed (offset, root) checkpoint every N messages,
+// so that downstream consumers replaying the stream can verify that the history they read is the one
+// that was checkpointed.
+package streamtree
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+// checkpointSigningDomain separates checkpoint signatures from any other signature made with the same key.
+const checkpointSigningDomain = "go-merkletree stream checkpoint v1"
+
+var (
+	// ErrOutOfOrder is the error for a message whose offset is not greater than the previous one.
+	ErrOutOfOrder = errors.New("message offset is out of order")
+	// ErrCheckpointMismatch is the error for replayed messages that do not match a checkpoint.
+	ErrCheckpointMismatch = errors.New("replayed messages do not match the checkpoint")
+)
+
+// Message is the data block of a stream message.
+type Message struct {
+	Offset int64
+	Key    []byte
+	Value  []byte
+}
+
+// Serialize encodes the offset (int64, big-endian), the length of the key (uint32, big-endian),
+// the key and the value, so that the leaf commits to the position of the message in the stream.
+func (m *Message) Serialize() ([]byte, error) {
+	buf := make([]byte, 0, 12+len(m.Key)+len(m.Value))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(m.Offset))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(m.Key)))
+	buf = append(buf, m.Key...)
+	buf = append(buf, m.Value...)
+
+	return buf, nil
+}
+
+// Checkpoint is a signed statement of the root of the tree over the first TreeSize messages of a stream,
+// the last of which is at Offset.
+type Checkpoint struct {
+	// Offset is the offset of the last message in the tree.
+	Offset int64 `json:"offset"`
+	// TreeSize is the number of messages in the tree.
+	TreeSize uint64 `json:"treeSize"`
+	// Root is the Merkle root of the tree.
+	Root mt.HexBytes `json:"root"`
+	// Timestamp is the signing time in milliseconds since the Unix epoch.
+	Timestamp int64 `json:"timestamp"`
+	// Signature is the signature over SigningInput.
+	Signature mt.HexBytes `json:"signature"`
+}
+
+// SigningInput returns the deterministic byte representation of the checkpoint covered by its signature.
+func (c *Checkpoint) SigningInput() []byte {
+	buf := make([]byte, 0, len(checkpointSigningDomain)+len(c.Root)+28)
+	buf = append(buf, checkpointSigningDomain...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(c.Offset))
+	buf = binary.BigEndian.AppendUint64(buf, c.TreeSize)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(c.Root)))
+	buf = append(buf, c.Root...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(c.Timestamp))
+
+	return buf
+}
+
+// Verify returns an error if the checkpoint signature is invalid.
+func (c *Checkpoint) Verify(verifier mt.TreeHeadVerifier) error {
+	return verifier.Verify(c.SigningInput(), c.Signature)
+}
+
+// Source is an ordered message stream. Next returns io.EOF at the end of the stream.
+type Source interface {
+	Next(ctx context.Context) (*Message, error)
+}
+
+// Consumer appends the messages of a stream to an incremental tree and emits signed checkpoints.
+// It is not safe for concurrent use.
+type Consumer struct {
+	tree       *mt.IngestTree
+	interval   int
+	signer     mt.TreeHeadSigner
+	emit       func(context.Context, *Checkpoint) error
+	size       int
+	lastOffset int64
+	// Now returns the checkpoint time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+// NewConsumer creates a Consumer emitting a checkpoint signed with signer every interval messages.
+// The first checkpoint is emitted once the tree has at least 2 messages.
+// The Mode, RunInParallel and NumRoutines configuration fields are not used.
+func NewConsumer(config *mt.Config, interval int, signer mt.TreeHeadSigner,
+	emit func(context.Context, *Checkpoint) error) *Consumer {
+	return &Consumer{
+		tree:       mt.NewIngestTree(config),
+		interval:   max(interval, 1),
+		signer:     signer,
+		emit:       emit,
+		lastOffset: -1,
+	}
+}
+
+// Append adds the message to the tree, and emits and returns a checkpoint if one is due, nil otherwise.
+// Offsets must be strictly increasing.
+func (c *Consumer) Append(ctx context.Context, msg *Message) (*Checkpoint, error) {
+	if msg.Offset <= c.lastOffset {
+		return nil, fmt.Errorf("%w: %d after %d", ErrOutOfOrder, msg.Offset, c.lastOffset)
+	}
+
+	if _, err := c.tree.Append(msg); err != nil {
+		return nil, err
+	}
+
+	c.size++
+	c.lastOffset = msg.Offset
+
+	if c.size < 2 || c.size%c.interval != 0 {
+		return nil, nil
+	}
+
+	return c.Checkpoint(ctx)
+}
+
+// Checkpoint signs and emits a checkpoint of the messages appended so far.
+func (c *Consumer) Checkpoint(ctx context.Context) (*Checkpoint, error) {
+	root, err := c.tree.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now
+	if c.Now != nil {
+		now = c.Now
+	}
+
+	cp := &Checkpoint{
+		Offset:    c.lastOffset,
+		TreeSize:  uint64(c.size),
+		Root:      root,
+		Timestamp: now().UnixMilli(),
+	}
+
+	if cp.Signature, err = c.signer.Sign(cp.SigningInput()); err != nil {
+		return nil, err
+	}
+
+	if c.emit != nil {
+		if err := c.emit(ctx, cp); err != nil {
+			return nil, err
+		}
+	}
+
+	return cp, nil
+}
+
+// Proof returns the proof of the message at the index in the stream against the root of the tree
+// over the messages appended so far, i.e. the root of the last checkpoint right after it is emitted.
+func (c *Consumer) Proof(idx int) (*mt.Proof, error) {
+	return c.tree.Proof(idx)
+}
+
+// Run consumes the source until the end of the stream or until the context is done.
+func (c *Consumer) Run(ctx context.Context, src Source) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		msg, err := src.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.Append(ctx, msg); err != nil {
+			return err
+		}
+	}
+}
+
+// VerifyReplay checks that the replayed messages, from the start of the stream, match the checkpoint:
+// the checkpoint is signed, and the first TreeSize messages end at the checkpoint offset and have its root.
+// Messages beyond TreeSize are ignored, so that a replay can be checked against every checkpoint it covers.
+func VerifyReplay(cp *Checkpoint, messages []*Message, config *mt.Config, verifier mt.TreeHeadVerifier) error {
+	if err := cp.Verify(verifier); err != nil {
+		return err
+	}
+
+	if cp.TreeSize < 2 || uint64(len(messages)) < cp.TreeSize {
+		return fmt.Errorf("%w: %d messages, tree size %d", ErrCheckpointMismatch, len(messages), cp.TreeSize)
+	}
+
+	messages = messages[:cp.TreeSize]
+	if messages[len(messages)-1].Offset != cp.Offset {
+		return fmt.Errorf("%w: last offset %d, checkpoint offset %d",
+			ErrCheckpointMismatch, messages[len(messages)-1].Offset, cp.Offset)
+	}
+
+	tree := mt.NewIngestTree(config)
+	for i, msg := range messages {
+		if i > 0 && msg.Offset <= messages[i-1].Offset {
+			return fmt.Errorf("%w: %d after %d", ErrOutOfOrder, msg.Offset, messages[i-1].Offset)
+		}
+
+		if _, err := tree.Append(msg); err != nil {
+			return err
+		}
+	}
+
+	root, err := tree.Root()
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(root, cp.Root) {
+		return ErrCheckpointMismatch
+	}
+
+	return nil
+}
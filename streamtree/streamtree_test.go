@@ -0,0 +1,151 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package streamtree
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+type sliceSource []*Message
+
+func (s *sliceSource) Next(context.Context) (*Message, error) {
+	if len(*s) == 0 {
+		return nil, io.EOF
+	}
+	msg := (*s)[0]
+	*s = (*s)[1:]
+	return msg, nil
+}
+
+func testMessages(n int) []*Message {
+	messages := make([]*Message, n)
+	for i := range messages {
+		messages[i] = &Message{
+			Offset: int64(100 + 3*i),
+			Key:    []byte(fmt.Sprintf("key-%d", i%7)),
+			Value:  []byte(fmt.Sprintf("value-%d", i)),
+		}
+	}
+	return messages
+}
+
+func TestConsumer(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		messages    = testMessages(25)
+		checkpoints []*Checkpoint
+		consumer    = NewConsumer(nil, 10, mt.Ed25519Signer(priv), func(_ context.Context, cp *Checkpoint) error {
+			checkpoints = append(checkpoints, cp)
+			return nil
+		})
+		src = sliceSource(messages)
+	)
+	if err := consumer.Run(context.Background(), &src); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(checkpoints) != 2 {
+		t.Fatalf("Run() emitted %d checkpoints, want 2", len(checkpoints))
+	}
+
+	tests := []struct {
+		name     string
+		cp       *Checkpoint
+		messages func() []*Message
+		wantErr  error
+	}{
+		{name: "test_first_checkpoint", cp: checkpoints[0], messages: func() []*Message { return messages }},
+		{name: "test_second_checkpoint", cp: checkpoints[1], messages: func() []*Message { return messages[:20] }},
+		{
+			name: "test_tampered_message",
+			cp:   checkpoints[1],
+			messages: func() []*Message {
+				tampered := append([]*Message(nil), messages...)
+				tampered[12] = &Message{Offset: messages[12].Offset, Key: messages[12].Key, Value: []byte("forged")}
+				return tampered
+			},
+			wantErr: ErrCheckpointMismatch,
+		},
+		{
+			name:     "test_dropped_message",
+			cp:       checkpoints[0],
+			messages: func() []*Message { return append(append([]*Message(nil), messages[:4]...), messages[5:]...) },
+			wantErr:  ErrCheckpointMismatch,
+		},
+		{name: "test_truncated_replay", cp: checkpoints[1], messages: func() []*Message { return messages[:15] }, wantErr: ErrCheckpointMismatch},
+		{
+			name: "test_forged_root",
+			cp: func() *Checkpoint {
+				forged := *checkpoints[0]
+				forged.Root = checkpoints[1].Root
+				return &forged
+			}(),
+			messages: func() []*Message { return messages },
+			wantErr:  mt.ErrTreeHeadSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyReplay(tt.cp, tt.messages(), nil, mt.Ed25519Verifier(pub))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyReplay() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Proofs of the consumed messages verify against the last checkpoint after the last append.
+	cp, err := consumer.Checkpoint(context.Background())
+	if err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+	proof, err := consumer.Proof(17)
+	if err != nil {
+		t.Fatalf("Proof() error = %v", err)
+	}
+	if ok, err := mt.Verify(messages[17], proof, cp.Root, nil); err != nil || !ok {
+		t.Errorf("Verify() = %v, %v", ok, err)
+	}
+}
+
+func TestConsumer_outOfOrder(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumer := NewConsumer(nil, 2, mt.Ed25519Signer(priv), nil)
+	if _, err := consumer.Append(context.Background(), &Message{Offset: 5}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if _, err := consumer.Append(context.Background(), &Message{Offset: 5}); !errors.Is(err, ErrOutOfOrder) {
+		t.Errorf("Append() error = %v, want %v", err, ErrOutOfOrder)
+	}
+}
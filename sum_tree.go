@@ -0,0 +1,221 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/bits"
+)
+
+// SumDataBlock is a data block carrying a balance, the leaf of a summation tree.
+type SumDataBlock interface {
+	DataBlock
+	Sum() uint64
+}
+
+// SumNode is a node of a summation tree: the hash of the subtree and the total of its balances.
+type SumNode struct {
+	Hash []byte `json:"hash"`
+	Sum  uint64 `json:"sum"`
+}
+
+// SumTree is a Maxwell/DAPOL-style summation Merkle Tree for proof of liabilities.
+// Every node carries the sum of its children, and both child sums are committed in the parent hash,
+// so that a proof verifies the inclusion of a balance and its correct aggregation into the root total.
+// Balances are unsigned, which rules out the negative-balance attack on the original Maxwell scheme.
+type SumTree struct {
+	hashFunc TypeHashFunc
+	// nodes[0] are the leaves, the last level has a single node, the root.
+	nodes [][]SumNode
+	// Root is the root node, whose Sum is the total liabilities.
+	Root SumNode
+	// Depth is the depth of the tree.
+	Depth int
+	// NumLeaves is the number of leaves.
+	NumLeaves int
+}
+
+// SumProof is the proof of a leaf of a summation tree. Path follows the convention of Proof.
+type SumProof struct {
+	Siblings []SumNode `json:"siblings"`
+	Path     uint32    `json:"path"`
+}
+
+// NewSumTree builds the summation tree of the data blocks. Odd levels are padded with a zero-sum node
+// instead of duplicating the last node, which would count its balance twice.
+// Only config.HashFunc is used.
+func NewSumTree(config *Config, blocks []SumDataBlock) (*SumTree, error) {
+	if len(blocks) <= 1 {
+		return nil, ErrInvalidNumOfDataBlocks
+	}
+
+	t := &SumTree{hashFunc: DefaultHashFunc, NumLeaves: len(blocks)}
+	if config != nil && config.HashFunc != nil {
+		t.hashFunc = config.HashFunc
+	}
+
+	leaves := make([]SumNode, len(blocks))
+	for i, block := range blocks {
+		leaf, err := sumLeaf(block, t.hashFunc)
+		if err != nil {
+			return nil, err
+		}
+
+		leaves[i] = leaf
+	}
+
+	t.Depth = bits.Len(uint(len(blocks) - 1))
+	t.nodes = make([][]SumNode, 0, t.Depth+1)
+	t.nodes = append(t.nodes, leaves)
+
+	for level := leaves; len(level) > 1; {
+		if len(level)&1 == 1 {
+			level = append(level, SumNode{Hash: make([]byte, len(level[0].Hash))})
+			t.nodes[len(t.nodes)-1] = level
+		}
+
+		next := make([]SumNode, len(level)>>1)
+		for i := range next {
+			node, err := sumParent(level[2*i], level[2*i+1], t.hashFunc)
+			if err != nil {
+				return nil, err
+			}
+
+			next[i] = node
+		}
+
+		t.nodes = append(t.nodes, next)
+		level = next
+	}
+
+	t.Root = t.nodes[len(t.nodes)-1][0]
+
+	return t, nil
+}
+
+// Proof returns the proof of the leaf at idx.
+func (t *SumTree) Proof(idx int) (*SumProof, error) {
+	if idx < 0 || idx >= t.NumLeaves {
+		return nil, ErrIndexOutOfRange
+	}
+
+	proof := &SumProof{Siblings: make([]SumNode, 0, t.Depth)}
+
+	for level := 0; level < t.Depth; level++ {
+		if idx&1 == 0 {
+			proof.Path |= 1 << level
+			proof.Siblings = append(proof.Siblings, t.nodes[level][idx+1])
+		} else {
+			proof.Siblings = append(proof.Siblings, t.nodes[level][idx-1])
+		}
+
+		idx >>= 1
+	}
+
+	return proof, nil
+}
+
+// Verify checks the data block against the proof and the root of the summation tree.
+func (t *SumTree) Verify(block SumDataBlock, proof *SumProof) (bool, error) {
+	return VerifySum(block, proof, t.Root, &Config{HashFunc: t.hashFunc})
+}
+
+// VerifySum checks that the balance of the data block is included in the root and that every
+// node on the path aggregates the balances of its children, the root total included.
+func VerifySum(block SumDataBlock, proof *SumProof, root SumNode, config *Config) (bool, error) {
+	if block == nil {
+		return false, ErrDataBlockIsNil
+	}
+
+	if proof == nil {
+		return false, ErrProofIsNil
+	}
+
+	hashFunc := TypeHashFunc(DefaultHashFunc)
+	if config != nil && config.HashFunc != nil {
+		hashFunc = config.HashFunc
+	}
+
+	node, err := sumLeaf(block, hashFunc)
+	if err != nil {
+		return false, err
+	}
+
+	path := proof.Path
+	for _, sibling := range proof.Siblings {
+		if path&1 == 1 {
+			node, err = sumParent(node, sibling, hashFunc)
+		} else {
+			node, err = sumParent(sibling, node, hashFunc)
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		path >>= 1
+	}
+
+	return node.Sum == root.Sum && bytes.Equal(node.Hash, root.Hash), nil
+}
+
+// sumLeaf returns the leaf node of the data block, the hash of its balance followed by its serialization.
+func sumLeaf(block SumDataBlock, hashFunc TypeHashFunc) (SumNode, error) {
+	data, err := block.Serialize()
+	if err != nil {
+		return SumNode{}, err
+	}
+
+	buf := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint64(buf, block.Sum())
+
+	hash, err := hashFunc(append(buf, data...))
+	if err != nil {
+		return SumNode{}, err
+	}
+
+	return SumNode{Hash: hash, Sum: block.Sum()}, nil
+}
+
+// sumParent returns the parent node Hash(left.Hash || left.Sum || right.Hash || right.Sum)
+// with the sum of both balances.
+func sumParent(left, right SumNode, hashFunc TypeHashFunc) (SumNode, error) {
+	sum, carry := bits.Add64(left.Sum, right.Sum, 0)
+	if carry != 0 {
+		return SumNode{}, ErrSumOverflow
+	}
+
+	buf := make([]byte, 0, len(left.Hash)+len(right.Hash)+16)
+	buf = append(buf, left.Hash...)
+	buf = binary.BigEndian.AppendUint64(buf, left.Sum)
+	buf = append(buf, right.Hash...)
+	buf = binary.BigEndian.AppendUint64(buf, right.Sum)
+
+	hash, err := hashFunc(buf)
+	if err != nil {
+		return SumNode{}, err
+	}
+
+	return SumNode{Hash: hash, Sum: sum}, nil
+}
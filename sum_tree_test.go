@@ -0,0 +1,113 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+type mockSumBlock struct {
+	id  string
+	sum uint64
+}
+
+func (b *mockSumBlock) Serialize() ([]byte, error) {
+	return []byte(b.id), nil
+}
+
+func (b *mockSumBlock) Sum() uint64 {
+	return b.sum
+}
+
+func mockSumBlocks(num int) []SumDataBlock {
+	blocks := make([]SumDataBlock, num)
+	for i := range blocks {
+		blocks[i] = &mockSumBlock{id: string(rune('a' + i%26)), sum: uint64(i*100 + 1)}
+	}
+	return blocks
+}
+
+func TestSumTree(t *testing.T) {
+	tests := []struct {
+		name    string
+		num     int
+		wantErr bool
+	}{
+		{name: "test_1", num: 1, wantErr: true},
+		{name: "test_2", num: 2},
+		{name: "test_3", num: 3},
+		{name: "test_5", num: 5},
+		{name: "test_8", num: 8},
+		{name: "test_33", num: 33},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockSumBlocks(tt.num)
+			tree, err := NewSumTree(nil, blocks)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSumTree() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var total uint64
+			for _, b := range blocks {
+				total += b.Sum()
+			}
+			if tree.Root.Sum != total {
+				t.Errorf("Root.Sum = %d, want %d", tree.Root.Sum, total)
+			}
+			for i, block := range blocks {
+				proof, err := tree.Proof(i)
+				if err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+				if ok, err := tree.Verify(block, proof); err != nil || !ok {
+					t.Errorf("Verify() %d = %v, error = %v", i, ok, err)
+				}
+				understated := &mockSumBlock{id: block.(*mockSumBlock).id, sum: block.Sum() - 1}
+				if ok, _ := tree.Verify(understated, proof); ok {
+					t.Errorf("Verify() %d accepted an understated balance", i)
+				}
+				if len(proof.Siblings) > 0 {
+					proof.Siblings[0].Sum++
+					if ok, _ := tree.Verify(block, proof); ok {
+						t.Errorf("Verify() %d accepted a tampered sibling sum", i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSumTree_overflow(t *testing.T) {
+	blocks := []SumDataBlock{
+		&mockSumBlock{id: "a", sum: math.MaxUint64},
+		&mockSumBlock{id: "b", sum: 1},
+	}
+	if _, err := NewSumTree(nil, blocks); !errors.Is(err, ErrSumOverflow) {
+		t.Errorf("NewSumTree() error = %v, want %v", err, ErrSumOverflow)
+	}
+}
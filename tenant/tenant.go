@@ -0,0 +1,399 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package tenant manages the trees of many tenants in one service: named trees per tenant, per-tenant
+// quotas on the number of trees, leaves and estimated memory, builds bounded by a worker pool shared by all
+// tenants, and lookup, proof and verification by tenant and tree ID.
+//
+// Quotas are reserved before a build starts, from the number of data blocks and the memory estimated by
+// Config.EstimateMemory, so that concurrent builds of a tenant cannot exceed them together, and released
+// when the build fails or the tree is deleted.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+var (
+	// ErrTreeNotFound is the error for a tree ID that is not built for the tenant.
+	ErrTreeNotFound = errors.New("tree not found")
+	// ErrTreeExists is the error for building a tree ID that is already built or being built for the tenant.
+	ErrTreeExists = errors.New("tree already exists")
+	// ErrQuotaExceeded is the error for a build exceeding the quota of its tenant.
+	// Errors returned for this reason are of type *QuotaError and match it with errors.Is.
+	ErrQuotaExceeded = errors.New("tenant quota exceeded")
+)
+
+// Resource is a resource limited by quotas.
+type Resource string
+
+const (
+	// ResourceTrees is the number of trees of a tenant.
+	ResourceTrees Resource = "trees"
+	// ResourceLeaves is the total number of leaves of the trees of a tenant.
+	ResourceLeaves Resource = "leaves"
+	// ResourceMemory is the total estimated memory of the trees of a tenant, in bytes.
+	ResourceMemory Resource = "memory"
+)
+
+// QuotaError is the error returned when a build exceeds the quota of its tenant.
+type QuotaError struct {
+	// Tenant is the tenant whose quota is exceeded.
+	Tenant string
+	// Resource is the exceeded resource.
+	Resource Resource
+	// Used is the amount of the resource used by the tenant, including the builds in progress.
+	Used uint64
+	// Requested is the amount of the resource requested by the build.
+	Requested uint64
+	// Limit is the quota of the resource.
+	Limit uint64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: tenant %q %s: used %d, requested %d, limit %d",
+		ErrQuotaExceeded, e.Tenant, e.Resource, e.Used, e.Requested, e.Limit)
+}
+
+// Is reports whether target is ErrQuotaExceeded.
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// Quota limits the resources of a tenant. Zero fields are unlimited.
+type Quota struct {
+	// MaxTrees is the maximum number of trees of the tenant.
+	MaxTrees int
+	// MaxLeaves is the maximum total number of leaves of the trees of the tenant.
+	MaxLeaves int
+	// MaxMemoryBytes is the maximum total estimated memory of the trees of the tenant, in bytes.
+	MaxMemoryBytes uint64
+}
+
+// Usage is the resources used by a tenant, including the builds in progress.
+type Usage struct {
+	Trees       int
+	Leaves      int
+	MemoryBytes uint64
+}
+
+// Options configures a Manager.
+type Options struct {
+	// Config is the configuration of the built trees, and of their verification. Its hash functions must be
+	// safe for concurrent use; the default is DefaultHashFuncParallel.
+	Config *mt.Config
+	// Workers is the number of builds run concurrently for all tenants; further builds wait for a worker.
+	// If it is 0, the number of CPUs is used. Builds running in parallel, see Config.RunInParallel,
+	// use their own goroutines within their worker.
+	Workers int
+	// DefaultQuota is the quota of the tenants without quota set by Manager.SetQuota.
+	DefaultQuota Quota
+}
+
+// entry is a tree of a tenant, nil while it is being built, with the resources reserved for it.
+type entry struct {
+	tree   *mt.MerkleTree
+	leaves int
+	memory uint64
+}
+
+// tenant is the state of a tenant.
+type tenant struct {
+	quota *Quota
+	trees map[string]*entry
+	usage Usage
+}
+
+// Manager owns the named trees of many tenants. It is safe for concurrent use.
+type Manager struct {
+	config       *mt.Config
+	workers      chan struct{}
+	defaultQuota Quota
+
+	mu      sync.RWMutex
+	tenants map[string]*tenant
+}
+
+// NewManager creates a manager without trees. Options may be nil.
+func NewManager(opts *Options) *Manager {
+	if opts == nil {
+		opts = new(Options)
+	}
+
+	c := new(mt.Config)
+	if opts.Config != nil {
+		*c = *opts.Config
+	}
+
+	// Builds run concurrently: the default hash function must be safe for concurrent use.
+	if c.HashFunc == nil {
+		c.HashFunc = mt.DefaultHashFuncParallel
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return &Manager{
+		config:       c,
+		workers:      make(chan struct{}, workers),
+		defaultQuota: opts.DefaultQuota,
+		tenants:      make(map[string]*tenant),
+	}
+}
+
+// SetQuota sets the quota of the tenant. Trees already built are kept even if they exceed it.
+func (m *Manager) SetQuota(tenantID string, quota Quota) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tenant(tenantID).quota = &quota
+}
+
+// Usage returns the resources used by the tenant, including the builds in progress.
+func (m *Manager) Usage(tenantID string) Usage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if t, ok := m.tenants[tenantID]; ok {
+		return t.usage
+	}
+
+	return Usage{}
+}
+
+// tenant returns the state of the tenant, created if needed. It must be called with mu locked.
+func (m *Manager) tenant(tenantID string) *tenant {
+	t, ok := m.tenants[tenantID]
+	if !ok {
+		t = &tenant{trees: make(map[string]*entry)}
+		m.tenants[tenantID] = t
+	}
+
+	return t
+}
+
+// Build builds the tree of the tenant under the ID over the data blocks, after reserving its resources
+// within the quota of the tenant and waiting for a worker of the shared pool, or for the context to be done.
+func (m *Manager) Build(ctx context.Context, tenantID, treeID string, blocks []mt.DataBlock) (*mt.MerkleTree, error) {
+	if len(blocks) <= 1 {
+		return nil, mt.ErrInvalidNumOfDataBlocks
+	}
+
+	config := *m.config
+
+	memory, err := config.EstimateMemory(len(blocks))
+	if err != nil {
+		return nil, err
+	}
+
+	e := &entry{leaves: len(blocks), memory: memory}
+	if err := m.reserve(tenantID, treeID, e); err != nil {
+		return nil, err
+	}
+
+	tree, err := m.build(ctx, &config, blocks)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.release(tenantID, treeID, e)
+
+		return nil, err
+	}
+
+	e.tree = tree
+
+	return tree, nil
+}
+
+// build builds the tree within a worker of the pool.
+func (m *Manager) build(ctx context.Context, config *mt.Config, blocks []mt.DataBlock) (*mt.MerkleTree, error) {
+	select {
+	case m.workers <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	defer func() { <-m.workers }()
+
+	return mt.New(config, blocks)
+}
+
+// reserve adds the tree entry, being built, to the tenant if its resources are within the quota.
+func (m *Manager) reserve(tenantID, treeID string, e *entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.tenant(tenantID)
+	if _, ok := t.trees[treeID]; ok {
+		return fmt.Errorf("%w: tenant %q tree %q", ErrTreeExists, tenantID, treeID)
+	}
+
+	quota := m.defaultQuota
+	if t.quota != nil {
+		quota = *t.quota
+	}
+
+	exceeds := func(resource Resource, used, requested, limit uint64) error {
+		if limit > 0 && used+requested > limit {
+			return &QuotaError{Tenant: tenantID, Resource: resource, Used: used, Requested: requested, Limit: limit}
+		}
+
+		return nil
+	}
+
+	if err := errors.Join(
+		exceeds(ResourceTrees, uint64(t.usage.Trees), 1, uint64(quota.MaxTrees)),
+		exceeds(ResourceLeaves, uint64(t.usage.Leaves), uint64(e.leaves), uint64(quota.MaxLeaves)),
+		exceeds(ResourceMemory, t.usage.MemoryBytes, e.memory, quota.MaxMemoryBytes),
+	); err != nil {
+		return err
+	}
+
+	t.trees[treeID] = e
+	t.usage.Trees++
+	t.usage.Leaves += e.leaves
+	t.usage.MemoryBytes += e.memory
+
+	return nil
+}
+
+// release removes the tree entry from the tenant and frees its resources. It must be called with mu locked.
+func (m *Manager) release(tenantID, treeID string, e *entry) {
+	t := m.tenants[tenantID]
+	delete(t.trees, treeID)
+	t.usage.Trees--
+	t.usage.Leaves -= e.leaves
+	t.usage.MemoryBytes -= e.memory
+}
+
+// Delete deletes the tree of the tenant and frees its resources. Trees being built cannot be deleted.
+func (m *Manager) Delete(tenantID, treeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, err := m.lookup(tenantID, treeID)
+	if err != nil {
+		return err
+	}
+
+	m.release(tenantID, treeID, e)
+
+	return nil
+}
+
+// lookup returns the built tree entry of the tenant. It must be called with mu locked.
+func (m *Manager) lookup(tenantID, treeID string) (*entry, error) {
+	if t, ok := m.tenants[tenantID]; ok {
+		if e, ok := t.trees[treeID]; ok && e.tree != nil {
+			return e, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: tenant %q tree %q", ErrTreeNotFound, tenantID, treeID)
+}
+
+// Tree returns the built tree of the tenant.
+func (m *Manager) Tree(tenantID, treeID string) (*mt.MerkleTree, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, err := m.lookup(tenantID, treeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.tree, nil
+}
+
+// Trees returns the IDs of the built trees of the tenant, in order.
+func (m *Manager) Trees(tenantID string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ids []string
+
+	if t, ok := m.tenants[tenantID]; ok {
+		for id, e := range t.trees {
+			if e.tree != nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+// ProveIndex returns the serialized proof of the leaf at idx of the tree of the tenant.
+func (m *Manager) ProveIndex(tenantID, treeID string, idx int) (*mt.SerializedProof, error) {
+	tree, err := m.Tree(tenantID, treeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree.SerializedProof(idx)
+}
+
+// Prove returns the serialized proof of the data block in the tree of the tenant, with its leaf index.
+func (m *Manager) Prove(tenantID, treeID string, block mt.DataBlock) (*mt.SerializedProof, int, error) {
+	tree, err := m.Tree(tenantID, treeID)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	idx, err := tree.IndexOf(block)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	sp, err := tree.SerializedProof(int(idx))
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return sp, int(idx), nil
+}
+
+// Verify checks the data block against the root of the tree of the tenant using the serialized proof,
+// see merkletree.VerifySerialized.
+func (m *Manager) Verify(tenantID, treeID string, block mt.DataBlock, sp *mt.SerializedProof) (bool, error) {
+	tree, err := m.Tree(tenantID, treeID)
+	if err != nil {
+		return false, err
+	}
+
+	config := *m.config
+
+	return mt.VerifySerialized(block, sp, tree.Root, &config)
+}
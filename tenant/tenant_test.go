@@ -0,0 +1,198 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+type block string
+
+func (b block) Serialize() ([]byte, error) {
+	return []byte(b), nil
+}
+
+func blocks(prefix string, n int) []mt.DataBlock {
+	bs := make([]mt.DataBlock, n)
+	for i := range bs {
+		bs[i] = block(fmt.Sprintf("%s-%d", prefix, i))
+	}
+
+	return bs
+}
+
+func TestManager(t *testing.T) {
+	m := NewManager(&Options{Workers: 2})
+	ctx := context.Background()
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, 8)
+	)
+
+	for i := range errs {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			_, errs[i] = m.Build(ctx, fmt.Sprintf("tenant-%d", i%2), fmt.Sprintf("tree-%d", i), blocks("b", 10+i))
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Build() tree %d error = %v", i, err)
+		}
+	}
+
+	if got := m.Trees("tenant-0"); len(got) != 4 || got[0] != "tree-0" {
+		t.Fatalf("Trees() = %v", got)
+	}
+
+	if u := m.Usage("tenant-1"); u.Trees != 4 || u.Leaves != 11+13+15+17 || u.MemoryBytes == 0 {
+		t.Errorf("Usage() = %+v", u)
+	}
+
+	bs := blocks("b", 13)
+
+	sp, idx, err := m.Prove("tenant-1", "tree-3", bs[7])
+	if err != nil || idx != 7 {
+		t.Fatalf("Prove() = %d, %v, want 7", idx, err)
+	}
+
+	if ok, err := m.Verify("tenant-1", "tree-3", bs[7], sp); err != nil || !ok {
+		t.Errorf("Verify() = %v, %v, want true", ok, err)
+	}
+
+	if ok, _ := m.Verify("tenant-1", "tree-5", bs[7], sp); ok {
+		t.Errorf("Verify() accepted the proof against another tree")
+	}
+
+	if _, err := m.ProveIndex("tenant-0", "tree-3", 0); !errors.Is(err, ErrTreeNotFound) {
+		t.Errorf("ProveIndex() error = %v, want %v", err, ErrTreeNotFound)
+	}
+
+	if _, err := m.Build(ctx, "tenant-1", "tree-3", bs); !errors.Is(err, ErrTreeExists) {
+		t.Errorf("Build() error = %v, want %v", err, ErrTreeExists)
+	}
+
+	if err := m.Delete("tenant-1", "tree-3"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, err := m.Tree("tenant-1", "tree-3"); !errors.Is(err, ErrTreeNotFound) {
+		t.Errorf("Tree() error = %v, want %v", err, ErrTreeNotFound)
+	}
+
+	if u := m.Usage("tenant-1"); u.Trees != 3 || u.Leaves != 11+15+17 {
+		t.Errorf("Usage() after Delete() = %+v", u)
+	}
+}
+
+func TestManager_quota(t *testing.T) {
+	ctx := context.Background()
+
+	config := &mt.Config{}
+
+	memory, err := config.EstimateMemory(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		quota        Quota
+		wantResource Resource
+	}{
+		{name: "test_trees", quota: Quota{MaxTrees: 1}, wantResource: ResourceTrees},
+		{name: "test_leaves", quota: Quota{MaxLeaves: 15}, wantResource: ResourceLeaves},
+		{name: "test_memory", quota: Quota{MaxMemoryBytes: memory + memory/2}, wantResource: ResourceMemory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(&Options{Config: config, DefaultQuota: Quota{MaxTrees: 100}})
+			m.SetQuota("acme", tt.quota)
+
+			if _, err := m.Build(ctx, "acme", "a", blocks("a", 10)); err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			_, err := m.Build(ctx, "acme", "b", blocks("b", 10))
+
+			var qe *QuotaError
+			if !errors.Is(err, ErrQuotaExceeded) || !errors.As(err, &qe) || qe.Resource != tt.wantResource {
+				t.Fatalf("Build() error = %v, want %s quota exceeded", err, tt.wantResource)
+			}
+
+			if u := m.Usage("acme"); u.Trees != 1 || u.Leaves != 10 {
+				t.Errorf("Usage() = %+v after a rejected build", u)
+			}
+
+			// Other tenants have the default quota.
+			if _, err := m.Build(ctx, "other", "b", blocks("b", 10)); err != nil {
+				t.Errorf("Build() error = %v", err)
+			}
+
+			if err := m.Delete("acme", "a"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+
+			if _, err := m.Build(ctx, "acme", "b", blocks("b", 10)); err != nil {
+				t.Errorf("Build() after Delete() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestManager_Build_canceled(t *testing.T) {
+	m := NewManager(&Options{Workers: 1})
+
+	// Occupy the only worker.
+	m.workers <- struct{}{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := m.Build(ctx, "acme", "a", blocks("a", 4)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Build() error = %v, want %v", err, context.Canceled)
+	}
+
+	<-m.workers
+
+	if u := m.Usage("acme"); u.Trees != 0 {
+		t.Errorf("Usage() = %+v after a canceled build", u)
+	}
+
+	if _, err := m.Build(context.Background(), "acme", "a", blocks("a", 4)); err != nil {
+		t.Errorf("Build() error = %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TimestampedDataBlock is a data block carrying the time of its entry, committed into its leaf if
+// Config.TimestampLeaves is set.
+type TimestampedDataBlock interface {
+	DataBlock
+	Timestamp() time.Time
+}
+
+// timestampBlock attaches a timestamp to a data block.
+type timestampBlock struct {
+	DataBlock
+	timestamp time.Time
+}
+
+// Timestamp returns the attached timestamp.
+func (b *timestampBlock) Timestamp() time.Time {
+	return b.timestamp
+}
+
+// WithTimestamp attaches the timestamp to the data block, for data blocks that do not implement
+// TimestampedDataBlock.
+func WithTimestamp(block DataBlock, timestamp time.Time) TimestampedDataBlock {
+	return &timestampBlock{DataBlock: block, timestamp: timestamp}
+}
+
+// committedBlock is a data block whose serialization commits to its timestamp.
+type committedBlock struct {
+	TimestampedDataBlock
+}
+
+// Serialize encodes the timestamp (Unix nanoseconds, int64 big-endian) followed by the serialized data block.
+func (b *committedBlock) Serialize() ([]byte, error) {
+	data, err := b.TimestampedDataBlock.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, 8+len(data))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(b.Timestamp().UnixNano()))
+
+	return append(buf, data...), nil
+}
+
+// Meta returns the metadata of the data block if it carries any, see MetaDataBlock.
+func (b *committedBlock) Meta() any {
+	if mb, ok := b.TimestampedDataBlock.(MetaDataBlock); ok {
+		return mb.Meta()
+	}
+
+	return nil
+}
+
+// commitTimestamp returns the data block committing to its timestamp.
+func commitTimestamp(block DataBlock) (DataBlock, error) {
+	tb, ok := block.(TimestampedDataBlock)
+	if !ok {
+		return nil, ErrLeafTimestampMissing
+	}
+
+	return &committedBlock{TimestampedDataBlock: tb}, nil
+}
+
+// commitTimestamps returns the data blocks committing to their timestamps.
+func commitTimestamps(blocks []DataBlock) ([]DataBlock, error) {
+	committed := make([]DataBlock, len(blocks))
+
+	for i, block := range blocks {
+		var err error
+		if committed[i], err = commitTimestamp(block); err != nil {
+			return nil, fmt.Errorf("%w: data block %d", err, i)
+		}
+	}
+
+	return committed, nil
+}
+
+// collectTimestamps returns the timestamps of the timestamped data blocks.
+func collectTimestamps(blocks []DataBlock) []time.Time {
+	timestamps := make([]time.Time, len(blocks))
+	for i, block := range blocks {
+		timestamps[i] = block.(TimestampedDataBlock).Timestamp()
+	}
+
+	return timestamps
+}
+
+// LeafTimestamp returns the committed timestamp of the leaf at idx, see Config.TimestampLeaves.
+func (m *MerkleTree) LeafTimestamp(idx int) (time.Time, error) {
+	if idx < 0 || idx >= m.NumLeaves {
+		return time.Time{}, ErrIndexOutOfRange
+	}
+
+	if m.timestamps == nil {
+		return time.Time{}, ErrLeafTimestampMissing
+	}
+
+	return m.timestamps[idx], nil
+}
+
+// TimeRangeProof proves the leaves committed between two times, when leaves are time-ordered: the proofs
+// of the consecutive leaves of the range, and the proofs of the leaves just before and after it, whose
+// timestamps outside of the range prove that no leaf of the range is omitted.
+type TimeRangeProof struct {
+	// First is the index of the first leaf of the range, or of the leaf following the empty range.
+	First int
+	// Proofs are the proofs of the leaves of the range, in order.
+	Proofs []*Proof
+	// Before is the proof of the leaf before the range, nil if the range starts at the first leaf.
+	Before *Proof
+	// After is the proof of the leaf after the range, nil if the range ends at the last leaf.
+	After *Proof
+}
+
+// ProofsInRange returns the proofs of the leaves committed between from and to, both included, with the
+// proofs of the leaves bounding the range. The leaves must be in non-decreasing order of their timestamps,
+// e.g. the entries of an audit log, and the tree built with TimestampLeaves.
+func (m *MerkleTree) ProofsInRange(from, to time.Time) (*TimeRangeProof, error) {
+	if m.timestamps == nil {
+		return nil, ErrLeafTimestampMissing
+	}
+
+	ts := m.timestamps
+	for i := 1; i < len(ts); i++ {
+		if ts[i].Before(ts[i-1]) {
+			return nil, fmt.Errorf("%w: leaf %d precedes leaf %d", ErrLeavesNotTimeOrdered, i, i-1)
+		}
+	}
+
+	var (
+		first = sort.Search(len(ts), func(i int) bool { return !ts[i].Before(from) })
+		end   = max(first, sort.Search(len(ts), func(i int) bool { return ts[i].After(to) }))
+		p     = &TimeRangeProof{First: first, Proofs: make([]*Proof, end-first)}
+		err   error
+	)
+
+	for i := range p.Proofs {
+		if p.Proofs[i], err = m.proofByIndex(first + i); err != nil {
+			return nil, err
+		}
+	}
+
+	if first > 0 {
+		if p.Before, err = m.proofByIndex(first - 1); err != nil {
+			return nil, err
+		}
+	}
+
+	if end < m.NumLeaves {
+		if p.After, err = m.proofByIndex(end); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// VerifyTimeRange checks that the data blocks are all the leaves committed between from and to, both
+// included, in the tree with the root: each is verified with its proof, committing its timestamp, its
+// timestamp is in the range and its position follows the previous one, and the bounding data blocks
+// before and after, nil if the proof has no such bound, are consecutive with the range and outside of it.
+// A range ending at the last leaf requires the number of leaves of the tree, set by Config.ExpectedNumLeaves.
+func VerifyTimeRange(p *TimeRangeProof, blocks []TimestampedDataBlock, before, after TimestampedDataBlock,
+	from, to time.Time, root []byte, config *Config,
+) (bool, error) {
+	if p == nil {
+		return false, ErrProofIsNil
+	}
+
+	c := new(Config)
+	if config != nil {
+		*c = *config
+	}
+
+	c.TimestampLeaves = true
+
+	if len(blocks) != len(p.Proofs) || (p.Before == nil) != (p.First == 0) ||
+		(p.Before == nil) != (before == nil) || (p.After == nil) != (after == nil) {
+		return false, nil
+	}
+
+	if p.After == nil {
+		if c.ExpectedNumLeaves == 0 {
+			return false, ErrLeafCountRequired
+		}
+
+		if p.First+len(blocks) != c.ExpectedNumLeaves {
+			return false, nil
+		}
+	}
+
+	check := func(block TimestampedDataBlock, proof *Proof, idx int, inRange bool) (bool, error) {
+		ts := block.Timestamp()
+		if proof.Index() != idx || inRange == (ts.Before(from) || ts.After(to)) {
+			return false, nil
+		}
+
+		return Verify(block, proof, root, c)
+	}
+
+	if p.Before != nil {
+		if ok, err := check(before, p.Before, p.First-1, false); err != nil || !ok {
+			return false, err
+		}
+
+		// The leaf before the range must precede it, not follow it.
+		if !before.Timestamp().Before(from) {
+			return false, nil
+		}
+	}
+
+	for i, block := range blocks {
+		if ok, err := check(block, p.Proofs[i], p.First+i, true); err != nil || !ok {
+			return false, err
+		}
+	}
+
+	if p.After != nil {
+		if ok, err := check(after, p.After, p.First+len(blocks), false); err != nil || !ok {
+			return false, err
+		}
+
+		if !after.Timestamp().After(to) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
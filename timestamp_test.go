@@ -0,0 +1,168 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// timedBlocks returns n data blocks stamped a minute apart from start.
+func timedBlocks(n int, start time.Time) []DataBlock {
+	blocks := mockDataBlocks(n)
+	for i := range blocks {
+		blocks[i] = WithTimestamp(blocks[i], start.Add(time.Duration(i)*time.Minute))
+	}
+
+	return blocks
+}
+
+func TestMerkleTree_TimestampLeaves(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	blocks := timedBlocks(5, start)
+	config := &Config{TimestampLeaves: true}
+
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	plain, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if string(m.Root) == string(plain.Root) {
+		t.Errorf("Root does not commit the timestamps")
+	}
+
+	if ts, err := m.LeafTimestamp(3); err != nil || !ts.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("LeafTimestamp() = %v, %v", ts, err)
+	}
+
+	ok, err := Verify(blocks[2], m.Proofs[2], m.Root, config)
+	if err != nil || !ok {
+		t.Errorf("Verify() = %v, %v, want true", ok, err)
+	}
+
+	moved := WithTimestamp(blocks[2], start)
+	if ok, _ := Verify(moved, m.Proofs[2], m.Root, config); ok {
+		t.Errorf("Verify() accepted a data block with another timestamp")
+	}
+
+	if _, err := New(config, mockDataBlocks(3)); !errors.Is(err, ErrLeafTimestampMissing) {
+		t.Errorf("New() error = %v, want %v", err, ErrLeafTimestampMissing)
+	}
+
+	if _, err := plain.LeafTimestamp(0); !errors.Is(err, ErrLeafTimestampMissing) {
+		t.Errorf("LeafTimestamp() error = %v, want %v", err, ErrLeafTimestampMissing)
+	}
+}
+
+func TestMerkleTree_ProofsInRange(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	blocks := timedBlocks(10, start)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	m, err := New(&Config{TimestampLeaves: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		from, to  time.Time
+		wantFirst int
+		wantLen   int
+	}{
+		{name: "test_middle", from: at(2), to: at(5), wantFirst: 2, wantLen: 4},
+		{name: "test_between_leaves", from: at(2).Add(time.Second), to: at(5).Add(time.Second), wantFirst: 3, wantLen: 3},
+		{name: "test_start", from: at(-5), to: at(1), wantFirst: 0, wantLen: 2},
+		{name: "test_end", from: at(8), to: at(20), wantFirst: 8, wantLen: 2},
+		{name: "test_all", from: at(-1), to: at(10), wantFirst: 0, wantLen: 10},
+		{name: "test_empty", from: at(4).Add(time.Second), to: at(5).Add(-time.Second), wantFirst: 5, wantLen: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := m.ProofsInRange(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("ProofsInRange() error = %v", err)
+			}
+
+			if p.First != tt.wantFirst || len(p.Proofs) != tt.wantLen {
+				t.Fatalf("ProofsInRange() = [%d, +%d), want [%d, +%d)", p.First, len(p.Proofs), tt.wantFirst, tt.wantLen)
+			}
+
+			var (
+				inRange       = make([]TimestampedDataBlock, len(p.Proofs))
+				before, after TimestampedDataBlock
+			)
+
+			for i := range inRange {
+				inRange[i] = blocks[p.First+i].(TimestampedDataBlock)
+			}
+
+			if p.Before != nil {
+				before = blocks[p.First-1].(TimestampedDataBlock)
+			}
+
+			if p.After != nil {
+				after = blocks[p.First+len(inRange)].(TimestampedDataBlock)
+			}
+
+			config := &Config{ExpectedNumLeaves: m.NumLeaves}
+
+			ok, err := VerifyTimeRange(p, inRange, before, after, tt.from, tt.to, m.Root, config)
+			if err != nil || !ok {
+				t.Errorf("VerifyTimeRange() = %v, %v, want true", ok, err)
+			}
+
+			if len(inRange) > 1 {
+				omitted := append([]TimestampedDataBlock{}, inRange[1:]...)
+				proofs := &TimeRangeProof{First: p.First, Proofs: p.Proofs[1:], Before: p.Before, After: p.After}
+
+				if ok, _ := VerifyTimeRange(proofs, omitted, before, after, tt.from, tt.to, m.Root, config); ok {
+					t.Errorf("VerifyTimeRange() accepted a range omitting a leaf")
+				}
+			}
+
+			if ok, _ := VerifyTimeRange(p, inRange, before, after, tt.from, tt.to.Add(time.Hour), m.Root, config); ok &&
+				p.After != nil {
+				t.Errorf("VerifyTimeRange() accepted a wider range")
+			}
+		})
+	}
+
+	unordered := timedBlocks(4, start)
+	unordered[1], unordered[2] = unordered[2], unordered[1]
+
+	u, err := New(&Config{TimestampLeaves: true}, unordered)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := u.ProofsInRange(start, at(3)); !errors.Is(err, ErrLeavesNotTimeOrdered) {
+		t.Errorf("ProofsInRange() error = %v, want %v", err, ErrLeavesNotTimeOrdered)
+	}
+}
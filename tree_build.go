@@ -40,17 +40,13 @@ func (m *MerkleTree) treeBuild() (err error) {
 		m.nodes[i+1] = make([][]byte, numNodes>>1)
 
 		for j := 0; j < numNodes; j += 2 {
-			if m.nodes[i+1][j>>1], err = m.HashFunc(
-				m.concatHashFunc(m.nodes[i][j], m.nodes[i][j+1]),
-			); err != nil {
+			if m.nodes[i+1][j>>1], err = m.buildChildren(i+1, j>>1, m.nodes[i][j], m.nodes[i][j+1]); err != nil {
 				return
 			}
 		}
 	}
 
-	if m.Root, err = m.HashFunc(m.concatHashFunc(
-		m.nodes[m.Depth-1][0], m.nodes[m.Depth-1][1],
-	)); err != nil {
+	if m.Root, err = m.buildChildren(m.Depth, 0, m.nodes[m.Depth-1][0], m.nodes[m.Depth-1][1]); err != nil {
 		return
 	}
 
@@ -77,9 +73,7 @@ func (m *MerkleTree) treeBuildParallel() error {
 
 			eg.Go(func() error {
 				for j := startIdx << 1; j < numNodes; j += numRoutines << 1 {
-					newHash, err := m.HashFunc(m.concatHashFunc(
-						m.nodes[i][j], m.nodes[i][j+1],
-					))
+					newHash, err := m.buildChildren(i+1, j>>1, m.nodes[i][j], m.nodes[i][j+1])
 					if err != nil {
 						return err
 					}
@@ -96,9 +90,7 @@ func (m *MerkleTree) treeBuildParallel() error {
 	}
 
 	var err error
-	if m.Root, err = m.HashFunc(m.concatHashFunc(
-		m.nodes[m.Depth-1][0], m.nodes[m.Depth-1][1],
-	)); err != nil {
+	if m.Root, err = m.buildChildren(m.Depth, 0, m.nodes[m.Depth-1][0], m.nodes[m.Depth-1][1]); err != nil {
 		return err
 	}
 
@@ -134,3 +126,35 @@ func appendNodeIfOdd(buffer [][]byte) [][]byte {
 
 	return buffer
 }
+
+// hashLevel computes the parents of the nodes of an even-sized level, which are the nodes of the given level
+// from the index offset, with hash, hashChildren or buildChildren.
+func (m *MerkleTree) hashLevel(hash typeChildrenHashFunc, level, offset int,
+	nodes [][]byte,
+) ([][]byte, error) {
+	var (
+		parents     = make([][]byte, len(nodes)>>1)
+		numRoutines = 1
+		eg          = new(errgroup.Group)
+	)
+
+	if m.RunInParallel {
+		numRoutines = min(m.NumRoutines, len(parents))
+	}
+
+	for r := 0; r < numRoutines; r++ {
+		r := r
+
+		eg.Go(func() (err error) {
+			for j := r; j < len(parents); j += numRoutines {
+				if parents[j], err = hash(level, offset+j, nodes[2*j], nodes[2*j+1]); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return parents, eg.Wait()
+}
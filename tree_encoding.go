@@ -0,0 +1,376 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+)
+
+// Serialized tree layout (all integers big-endian):
+//
+//	header: magic "MKTR" | version (uint8) | flags (uint8) | reserved (uint16) |
+//	        leaf length (uint32) | node length (uint32) | number of leaves (uint64) | depth (uint32)
+//	levels: level 0 (leaves, padded to an even count) ... level depth-1, every node stored at fixed width
+//	root:   node length bytes
+//
+// Levels are stored exactly as in the built tree, including the duplicated node padding odd levels,
+// so that the offset of any node can be computed from the header alone.
+const (
+	treeEncodingVersion    = 1
+	treeEncodingHeaderSize = 28
+
+	treeFlagSortSiblingPairs   = 1 << 0
+	treeFlagDisableLeafHashing = 1 << 1
+
+	// maxTreeNodeLen is the maximum width of the leaves and nodes of a serialized tree.
+	maxTreeNodeLen = 1 << 20
+	// readTreeNodesCap is the initial capacity of the levels decoded by ReadTree, which grow as nodes are read.
+	readTreeNodesCap = 1 << 10
+)
+
+// treeEncodingMagic identifies a serialized Merkle Tree.
+var treeEncodingMagic = [4]byte{'M', 'K', 'T', 'R'}
+
+// treeHeader is the decoded header of a serialized tree.
+type treeHeader struct {
+	flags     uint8
+	leafLen   int
+	nodeLen   int
+	numLeaves int
+	depth     int
+	// size is the number of bytes of the serialized tree.
+	size int64
+}
+
+// levelLens returns the number of stored nodes of every level of a tree with numLeaves leaves.
+func levelLens(numLeaves, depth int) []int {
+	lens := make([]int, depth)
+	size := numLeaves
+
+	for i := 0; i < depth; i++ {
+		// All levels but the last one (which always holds 2 nodes) are padded to an even count.
+		size += size & 1
+		lens[i] = size
+		size >>= 1
+	}
+
+	return lens
+}
+
+// levelOffset returns the byte offset of the first node of the level in the serialized tree.
+func (h *treeHeader) levelOffset(level int) int64 {
+	offset := int64(treeEncodingHeaderSize)
+	for i, n := range levelLens(h.numLeaves, h.depth)[:level] {
+		offset += int64(n) * int64(h.nodeLenAt(i))
+	}
+
+	return offset
+}
+
+// nodeLenAt returns the width of the nodes of the level.
+func (h *treeHeader) nodeLenAt(level int) int {
+	if level == 0 {
+		return h.leafLen
+	}
+
+	return h.nodeLen
+}
+
+// rootOffset returns the byte offset of the root in the serialized tree.
+func (h *treeHeader) rootOffset() int64 {
+	return h.levelOffset(h.depth)
+}
+
+func (h *treeHeader) marshal() []byte {
+	buf := make([]byte, 0, treeEncodingHeaderSize)
+	buf = append(buf, treeEncodingMagic[:]...)
+	buf = append(buf, treeEncodingVersion, h.flags, 0, 0)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(h.leafLen))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(h.nodeLen))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(h.numLeaves))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(h.depth))
+
+	return buf
+}
+
+func unmarshalTreeHeader(buf []byte) (*treeHeader, error) {
+	if len(buf) < treeEncodingHeaderSize || [4]byte(buf[:4]) != treeEncodingMagic {
+		return nil, ErrInvalidTreeEncoding
+	}
+
+	if buf[4] != treeEncodingVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidTreeEncoding, buf[4])
+	}
+
+	var (
+		leafLen   = binary.BigEndian.Uint32(buf[8:])
+		nodeLen   = binary.BigEndian.Uint32(buf[12:])
+		numLeaves = binary.BigEndian.Uint64(buf[16:])
+		depth     = binary.BigEndian.Uint32(buf[24:])
+	)
+
+	// The header is untrusted: its values are bounded before any size is derived from them.
+	if numLeaves <= 1 || numLeaves > math.MaxInt>>1 || int(depth) != bits.Len64(numLeaves-1) {
+		return nil, fmt.Errorf("%w: invalid number of leaves or depth", ErrInvalidTreeEncoding)
+	}
+
+	if leafLen > maxTreeNodeLen || nodeLen > maxTreeNodeLen {
+		return nil, fmt.Errorf("%w: node length exceeds %d bytes", ErrInvalidTreeEncoding, maxTreeNodeLen)
+	}
+
+	h := &treeHeader{
+		flags:     buf[5],
+		leafLen:   int(leafLen),
+		nodeLen:   int(nodeLen),
+		numLeaves: int(numLeaves),
+		depth:     int(depth),
+	}
+
+	var ok bool
+	if h.size, ok = h.encodedSize(); !ok {
+		return nil, fmt.Errorf("%w: size overflows", ErrInvalidTreeEncoding)
+	}
+
+	return h, nil
+}
+
+// encodedSize returns the number of bytes of the serialized tree, or false if it overflows an int64.
+func (h *treeHeader) encodedSize() (int64, bool) {
+	size := int64(treeEncodingHeaderSize)
+
+	for level, n := range levelLens(h.numLeaves, h.depth) {
+		width := int64(h.nodeLenAt(level))
+		if width > 0 && int64(n) > (math.MaxInt64-size)/width {
+			return 0, false
+		}
+
+		size += int64(n) * width
+	}
+
+	if size > math.MaxInt64-int64(h.nodeLen) {
+		return 0, false
+	}
+
+	return size + int64(h.nodeLen), true
+}
+
+// checkSize checks that the serialized tree read from r has the size of its header, if r tells its size.
+func (h *treeHeader) checkSize(r io.ReaderAt) error {
+	var size int64
+
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		size = r.Size()
+	case interface{ Stat() (os.FileInfo, error) }:
+		info, err := r.Stat()
+		if err != nil {
+			return nil
+		}
+
+		size = info.Size()
+	default:
+		return nil
+	}
+
+	if size != h.size {
+		return fmt.Errorf("%w: %d bytes, want %d", ErrInvalidTreeEncoding, size, h.size)
+	}
+
+	return nil
+}
+
+// readTreeHeader reads the header of a serialized tree.
+func readTreeHeader(r io.ReaderAt) (*treeHeader, error) {
+	buf := make([]byte, treeEncodingHeaderSize)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+	}
+
+	return unmarshalTreeHeader(buf)
+}
+
+// header builds the serialization header of the tree.
+// All leaves, and all interior nodes, must have the same length.
+func (m *MerkleTree) header() (*treeHeader, error) {
+	if m.Mode == ModeLazy {
+		if err := m.materialize(); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.nodes == nil {
+		return nil, ErrTreeNotBuilt
+	}
+
+	h := &treeHeader{
+		leafLen:   len(m.nodes[0][0]),
+		nodeLen:   len(m.Root),
+		numLeaves: m.NumLeaves,
+		depth:     m.Depth,
+	}
+
+	if m.SortSiblingPairs {
+		h.flags |= treeFlagSortSiblingPairs
+	}
+
+	if m.DisableLeafHashing {
+		h.flags |= treeFlagDisableLeafHashing
+	}
+
+	for level, nodes := range m.nodes {
+		for _, node := range nodes {
+			if len(node) != h.nodeLenAt(level) {
+				return nil, ErrNonUniformNodeLength
+			}
+		}
+	}
+
+	return h, nil
+}
+
+// WriteTo serializes the tree structure (leaves, interior nodes and root) to w.
+// It implements io.WriterTo and requires the tree to be built (ModeTreeBuild, ModeProofGenAndTreeBuild
+// or ModeLazy).
+// Proofs are not serialized, as they can be regenerated from the tree structure.
+func (m *MerkleTree) WriteTo(w io.Writer) (int64, error) {
+	h, err := m.header()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		bw      = bufio.NewWriter(w)
+		written int64
+		n       int
+	)
+
+	n, err = bw.Write(h.marshal())
+	written += int64(n)
+
+	for _, nodes := range m.nodes {
+		for _, node := range nodes {
+			if err != nil {
+				return written, err
+			}
+
+			n, err = bw.Write(node)
+			written += int64(n)
+		}
+	}
+
+	if err != nil {
+		return written, err
+	}
+
+	n, err = bw.Write(m.Root)
+	written += int64(n)
+
+	if err != nil {
+		return written, err
+	}
+
+	return written, bw.Flush()
+}
+
+// ReadTree deserializes a tree written by WriteTo.
+// The returned tree is in ModeTreeBuild: proofs can be generated with Proof().
+// The configuration must provide the hash function used to build the tree, the flags
+// SortSiblingPairs and DisableLeafHashing are restored from the serialized tree.
+func ReadTree(r io.Reader, config *Config) (*MerkleTree, error) {
+	br := bufio.NewReader(r)
+
+	buf := make([]byte, treeEncodingHeaderSize)
+	if _, err := io.ReadFull(br, buf); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+	}
+
+	h, err := unmarshalTreeHeader(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if config != nil {
+		if err := config.checkLimits(h.numLeaves); err != nil {
+			return nil, err
+		}
+	}
+
+	// The levels grow as their nodes are read, so that a forged header cannot allocate more than r holds.
+	nodes := make([][][]byte, h.depth)
+	for level, size := range levelLens(h.numLeaves, h.depth) {
+		nodes[level] = make([][]byte, 0, min(size, readTreeNodesCap))
+		for i := 0; i < size; i++ {
+			node := make([]byte, h.nodeLenAt(level))
+			if _, err := io.ReadFull(br, node); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+			}
+
+			nodes[level] = append(nodes[level], node)
+		}
+	}
+
+	root := make([]byte, h.nodeLen)
+	if _, err := io.ReadFull(br, root); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+	}
+
+	return h.newTree(config, nodes, root), nil
+}
+
+// newTree assembles a tree in ModeTreeBuild from decoded nodes.
+func (h *treeHeader) newTree(config *Config, nodes [][][]byte, root []byte) *MerkleTree {
+	if config == nil {
+		config = new(Config)
+	}
+
+	c := *config
+	c.Mode = ModeTreeBuild
+	c.SortSiblingPairs = h.flags&treeFlagSortSiblingPairs != 0
+	c.DisableLeafHashing = h.flags&treeFlagDisableLeafHashing != 0
+
+	if c.HashFunc == nil {
+		c.HashFunc = DefaultHashFunc
+	}
+
+	m := &MerkleTree{
+		Config:    &c,
+		NumLeaves: h.numLeaves,
+		Depth:     h.depth,
+		nodes:     nodes,
+		Root:      root,
+		Leaves:    nodes[0][:h.numLeaves],
+		leafMap:   make(map[string]int, h.numLeaves),
+	}
+
+	for i, leaf := range m.Leaves {
+		m.leafMap[string(leaf)] = i
+	}
+
+	return m
+}
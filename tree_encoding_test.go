@@ -0,0 +1,231 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMerkleTree_WriteTo(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *Config
+		numBlocks int
+		wantErr   error
+	}{
+		{
+			name:      "test_2",
+			config:    &Config{Mode: ModeTreeBuild},
+			numBlocks: 2,
+		},
+		{
+			name:      "test_odd_sorted",
+			config:    &Config{Mode: ModeTreeBuild, SortSiblingPairs: true},
+			numBlocks: 13,
+		},
+		{
+			name:      "test_large_parallel",
+			config:    &Config{Mode: ModeProofGenAndTreeBuild, RunInParallel: true},
+			numBlocks: 10001,
+		},
+		{
+			name:      "test_tree_not_built",
+			config:    &Config{Mode: ModeProofGen},
+			numBlocks: 5,
+			wantErr:   ErrTreeNotBuilt,
+		},
+		{
+			name:      "test_non_uniform_leaves",
+			config:    &Config{Mode: ModeTreeBuild, DisableLeafHashing: true},
+			numBlocks: 5,
+			wantErr:   ErrNonUniformNodeLength,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.numBlocks)
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			buf := new(bytes.Buffer)
+			n, err := m.WriteTo(buf)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WriteTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if n != int64(buf.Len()) {
+				t.Errorf("WriteTo() written = %d, want %d", n, buf.Len())
+			}
+			if err := VerifyTreeFile(bytes.NewReader(buf.Bytes()), m.Root, nil); err != nil {
+				t.Errorf("VerifyTreeFile() error = %v", err)
+			}
+			read, err := ReadTree(bytes.NewReader(buf.Bytes()), nil)
+			if err != nil {
+				t.Fatalf("ReadTree() error = %v", err)
+			}
+			if err := read.Recompute(); err != nil {
+				t.Errorf("Recompute() error = %v", err)
+			}
+			if !bytes.Equal(read.Root, m.Root) || read.SortSiblingPairs != m.SortSiblingPairs {
+				t.Errorf("ReadTree() root or config mismatch")
+			}
+			for _, idx := range []int{0, tt.numBlocks - 1} {
+				proof, err := read.Proof(blocks[idx])
+				if err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+				if ok, err := read.Verify(blocks[idx], proof); err != nil || !ok {
+					t.Errorf("Verify() %d = %v, error = %v", idx, ok, err)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyTreeFile(t *testing.T) {
+	m, err := New(&Config{Mode: ModeTreeBuild}, mockDataBlocks(9000))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	buf := new(bytes.Buffer)
+	if _, err := m.WriteTo(buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	h, err := m.header()
+	if err != nil {
+		t.Fatalf("header() error = %v", err)
+	}
+	tests := []struct {
+		name         string
+		corrupt      func(b []byte)
+		expectedRoot []byte
+		wantErr      error
+		wantLevel    int
+		wantIndex    int
+	}{
+		{
+			name:         "test_valid",
+			expectedRoot: m.Root,
+		},
+		{
+			name:         "test_wrong_expected_root",
+			expectedRoot: []byte("wrong root"),
+			wantErr:      ErrRootMismatch,
+		},
+		{
+			name: "test_corrupted_leaf",
+			corrupt: func(b []byte) {
+				b[h.levelOffset(0)+int64(5000*h.leafLen)] ^= 0xff
+			},
+			expectedRoot: m.Root,
+			wantErr:      ErrNodeMismatch,
+			wantLevel:    1,
+			wantIndex:    2500,
+		},
+		{
+			name: "test_corrupted_interior_node",
+			corrupt: func(b []byte) {
+				b[h.levelOffset(3)+int64(7*h.nodeLen)] ^= 0xff
+			},
+			// The node mismatches both its children and its parent, either may be reported first.
+			expectedRoot: m.Root,
+			wantErr:      ErrNodeMismatch,
+		},
+		{
+			name: "test_bad_magic",
+			corrupt: func(b []byte) {
+				b[0] = 0
+			},
+			expectedRoot: m.Root,
+			wantErr:      ErrInvalidTreeEncoding,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bytes.Clone(buf.Bytes())
+			if tt.corrupt != nil {
+				tt.corrupt(b)
+			}
+			err := VerifyTreeFile(bytes.NewReader(b), tt.expectedRoot, &Config{NumRoutines: 4})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyTreeFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var mismatch *NodeMismatchError
+			if errors.As(err, &mismatch) && tt.wantLevel != 0 && (mismatch.Level != tt.wantLevel || mismatch.Index != tt.wantIndex) {
+				t.Errorf("VerifyTreeFile() mismatch at level %d index %d, want level %d index %d",
+					mismatch.Level, mismatch.Index, tt.wantLevel, tt.wantIndex)
+			}
+		})
+	}
+}
+
+func TestReadTree_forgedHeader(t *testing.T) {
+	m, err := New(&Config{Mode: ModeTreeBuild}, mockDataBlocksFixedSize(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	forge := func(numLeaves uint64, depth, nodeLen uint32) []byte {
+		data := bytes.Clone(buf.Bytes())
+		h := &treeHeader{leafLen: 32, nodeLen: int(nodeLen), numLeaves: int(numLeaves), depth: int(depth)}
+		copy(data, h.marshal())
+		return data
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		config  *Config
+		wantErr error
+	}{
+		{name: "test_huge_num_leaves", data: forge(1<<40, 40, 32), wantErr: ErrInvalidTreeEncoding},
+		{name: "test_overflowing_size", data: forge(1<<61, 61, 1<<20), wantErr: ErrInvalidTreeEncoding},
+		{name: "test_huge_node_len", data: forge(5, 3, 1<<30), wantErr: ErrInvalidTreeEncoding},
+		{name: "test_max_leaves", data: buf.Bytes(), config: &Config{MaxLeaves: 4}, wantErr: ErrTooManyLeaves},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadTree(bytes.NewReader(tt.data), tt.config); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadTree() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.config != nil {
+				return
+			}
+			if _, err := OpenProofArchive(bytes.NewReader(tt.data)); !errors.Is(err, ErrInvalidTreeEncoding) {
+				t.Errorf("OpenProofArchive() error = %v, want %v", err, ErrInvalidTreeEncoding)
+			}
+		})
+	}
+
+	if _, err := OpenProofArchive(bytes.NewReader(append(bytes.Clone(buf.Bytes()), 0))); !errors.Is(err, ErrInvalidTreeEncoding) {
+		t.Errorf("OpenProofArchive() with a trailing byte error = %v, want %v", err, ErrInvalidTreeEncoding)
+	}
+}
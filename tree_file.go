@@ -0,0 +1,208 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// verifyTreeFileChunkSize is the number of parent nodes checked by one verification task.
+const verifyTreeFileChunkSize = 1 << 12
+
+// VerifyTreeFile validates every interior node of a tree serialized by WriteTo against its children,
+// and the root against expectedRoot, for integrity-scrubbing archived tree snapshots.
+// All levels are split into chunks verified in parallel by config.NumRoutines goroutines
+// (number of CPUs if not set), so the node hash function of the configuration must be concurrent-safe.
+// A *NodeMismatchError locating the first corrupted node found is returned on corruption.
+func VerifyTreeFile(r io.ReaderAt, expectedRoot []byte, config *Config) error {
+	if config == nil {
+		config = new(Config)
+	}
+
+	h, err := readTreeHeader(r)
+	if err != nil {
+		return err
+	}
+
+	var (
+		hashFunc    = config.nodeHashFunc()
+		hashNode    = config.PositionalHashFunc
+		numRoutines = config.NumRoutines
+		concatFunc  = concatHash
+		lens        = levelLens(h.numLeaves, h.depth)
+		eg          = new(errgroup.Group)
+	)
+
+	if hashFunc == nil {
+		hashFunc = DefaultHashFuncParallel
+	}
+
+	if hashNode == nil {
+		hashNode = func(_, _ int, data []byte) ([]byte, error) {
+			return hashFunc(data)
+		}
+	}
+
+	if numRoutines <= 0 {
+		numRoutines = runtime.NumCPU()
+	}
+
+	if h.flags&treeFlagSortSiblingPairs != 0 {
+		concatFunc = concatSortHash
+	}
+
+	eg.SetLimit(numRoutines)
+
+	for level := 0; level < h.depth-1; level++ {
+		numParents := lens[level] >> 1
+		for start := 0; start < numParents; start += verifyTreeFileChunkSize {
+			task := treeFileChunk{
+				header:     h,
+				level:      level,
+				start:      start,
+				end:        min(start+verifyTreeFileChunkSize, numParents),
+				hashNode:   hashNode,
+				concatFunc: concatFunc,
+			}
+
+			eg.Go(func() error {
+				return task.verify(r)
+			})
+		}
+	}
+
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	return verifyTreeFileRoot(r, h, hashNode, concatFunc, expectedRoot)
+}
+
+// treeFileChunk is the verification task of the parents [start, end) of the level.
+type treeFileChunk struct {
+	header     *treeHeader
+	level      int
+	start, end int
+	hashNode   TypePositionalHashFunc
+	concatFunc typeConcatHashFunc
+}
+
+func (c *treeFileChunk) verify(r io.ReaderAt) error {
+	var (
+		childLen  = c.header.nodeLenAt(c.level)
+		parentLen = c.header.nodeLen
+		children  = make([]byte, 2*(c.end-c.start)*childLen)
+		parents   = make([]byte, (c.end-c.start)*parentLen)
+	)
+
+	if _, err := r.ReadAt(children, c.header.levelOffset(c.level)+int64(2*c.start*childLen)); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+	}
+
+	if _, err := r.ReadAt(parents, c.header.levelOffset(c.level+1)+int64(c.start*parentLen)); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+	}
+
+	for i := 0; i < c.end-c.start; i++ {
+		left := children[2*i*childLen : (2*i+1)*childLen]
+		right := children[(2*i+1)*childLen : (2*i+2)*childLen]
+
+		hash, err := c.hashNode(c.level+1, c.start+i, c.concatFunc(left, right))
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(hash, parents[i*parentLen:(i+1)*parentLen]) {
+			return &NodeMismatchError{Level: c.level + 1, Index: c.start + i}
+		}
+	}
+
+	return c.verifyPadding(r)
+}
+
+// verifyPadding checks that the duplicated node padding an odd level equals the node it duplicates.
+func (c *treeFileChunk) verifyPadding(r io.ReaderAt) error {
+	if c.start != 0 {
+		return nil
+	}
+
+	lens := levelLens(c.header.numLeaves, c.header.depth)
+	actual := c.header.numLeaves
+	if c.level > 0 {
+		actual = lens[c.level-1] >> 1
+	}
+
+	if actual == lens[c.level] {
+		return nil
+	}
+
+	nodeLen := c.header.nodeLenAt(c.level)
+	pair := make([]byte, 2*nodeLen)
+
+	if _, err := r.ReadAt(pair, c.header.levelOffset(c.level)+int64((actual-1)*nodeLen)); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+	}
+
+	if !bytes.Equal(pair[:nodeLen], pair[nodeLen:]) {
+		return &NodeMismatchError{Level: c.level, Index: actual}
+	}
+
+	return nil
+}
+
+// verifyTreeFileRoot checks the stored root against the top level and the expected root.
+func verifyTreeFileRoot(r io.ReaderAt, h *treeHeader, hashNode TypePositionalHashFunc, concatFunc typeConcatHashFunc,
+	expectedRoot []byte,
+) error {
+	topLen := h.nodeLenAt(h.depth - 1)
+	top := make([]byte, 2*topLen)
+
+	if _, err := r.ReadAt(top, h.levelOffset(h.depth-1)); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+	}
+
+	root := make([]byte, h.nodeLen)
+	if _, err := r.ReadAt(root, h.rootOffset()); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTreeEncoding, err)
+	}
+
+	hash, err := hashNode(h.depth, 0, concatFunc(top[:topLen], top[topLen:]))
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(hash, root) {
+		return &NodeMismatchError{Level: h.depth, Index: 0}
+	}
+
+	if !bytes.Equal(root, expectedRoot) {
+		return ErrRootMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"time"
+)
+
+// treeHeadSigningDomain separates tree head signatures from any other signature made with the same key.
+const treeHeadSigningDomain = "go-merkletree signed tree head v1"
+
+// SignedTreeHead is a signed statement of the root of a tree at a point in time.
+type SignedTreeHead struct {
+	// Root is the Merkle root of the tree.
+	Root HexBytes `json:"root"`
+	// TreeSize is the number of leaves of the tree.
+	TreeSize uint64 `json:"treeSize"`
+	// Timestamp is the signing time in milliseconds since the Unix epoch.
+	Timestamp int64 `json:"timestamp"`
+	// Signature is the signature over SigningInput.
+	Signature HexBytes `json:"signature"`
+}
+
+// TreeHeadSigner signs tree heads.
+type TreeHeadSigner interface {
+	// Sign returns the signature of the message.
+	Sign(message []byte) ([]byte, error)
+}
+
+// TreeHeadVerifier verifies tree head signatures.
+type TreeHeadVerifier interface {
+	// Verify returns an error if the signature of the message is invalid.
+	Verify(message, signature []byte) error
+}
+
+// NewSignedTreeHead creates a tree head for the tree at the given time and signs it.
+func NewSignedTreeHead(m *MerkleTree, timestamp time.Time, signer TreeHeadSigner) (*SignedTreeHead, error) {
+	head := &SignedTreeHead{
+		Root:      m.Root,
+		TreeSize:  uint64(m.NumLeaves),
+		Timestamp: timestamp.UnixMilli(),
+	}
+
+	signature, err := signer.Sign(head.SigningInput())
+	if err != nil {
+		return nil, err
+	}
+
+	head.Signature = signature
+
+	return head, nil
+}
+
+// SigningInput returns the deterministic byte representation of the tree head covered by its signature.
+func (h *SignedTreeHead) SigningInput() []byte {
+	buf := make([]byte, 0, len(treeHeadSigningDomain)+len(h.Root)+20)
+	buf = append(buf, treeHeadSigningDomain...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(h.Root)))
+	buf = append(buf, h.Root...)
+	buf = binary.BigEndian.AppendUint64(buf, h.TreeSize)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(h.Timestamp))
+
+	return buf
+}
+
+// Time returns the signing time of the tree head.
+func (h *SignedTreeHead) Time() time.Time {
+	return time.UnixMilli(h.Timestamp)
+}
+
+// TreeHeadPolicy defines how the tree head embedded in a serialized proof is checked.
+type TreeHeadPolicy struct {
+	// Verifier checks the tree head signature. It is required.
+	Verifier TreeHeadVerifier
+	// MaxAge is the freshness window of the tree head. Tree heads older than MaxAge are rejected.
+	// If MaxAge is 0, the age of the tree head is not checked.
+	MaxAge time.Duration
+	// Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+// check verifies the signature and freshness of the tree head.
+func (p *TreeHeadPolicy) check(head *SignedTreeHead) error {
+	if head == nil {
+		return ErrTreeHeadMissing
+	}
+
+	if p.Verifier == nil || p.Verifier.Verify(head.SigningInput(), head.Signature) != nil {
+		return ErrTreeHeadSignature
+	}
+
+	if p.MaxAge == 0 {
+		return nil
+	}
+
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+
+	if now().Sub(head.Time()) > p.MaxAge {
+		return ErrTreeHeadStale
+	}
+
+	return nil
+}
+
+// Ed25519Signer signs tree heads with an Ed25519 private key.
+type Ed25519Signer ed25519.PrivateKey
+
+// Sign returns the Ed25519 signature of the message.
+func (s Ed25519Signer) Sign(message []byte) ([]byte, error) {
+	return ed25519.Sign(ed25519.PrivateKey(s), message), nil
+}
+
+// Ed25519Verifier verifies tree head signatures with an Ed25519 public key.
+type Ed25519Verifier ed25519.PublicKey
+
+// Verify returns ErrTreeHeadSignature if the signature is invalid.
+func (v Ed25519Verifier) Verify(message, signature []byte) error {
+	if !ed25519.Verify(ed25519.PublicKey(v), message, signature) {
+		return ErrTreeHeadSignature
+	}
+
+	return nil
+}
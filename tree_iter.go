@@ -0,0 +1,174 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// NodeIterator iterates over the nodes of a tree level by level, from the lowest level of its range up,
+// each level in index order: the leaves at level 0, the interior nodes, and the root at level Depth.
+// The duplicates padding odd levels are not iterated. Nodes are read from the tree as it stores them,
+// without copying its levels: the nodes of ModeTreeBuild and ModeProofGenAndTreeBuild trees, the proofs of
+// ModeProofGen trees, and the stored top levels of ModeLowMemory trees. The other nodes, such as the interior
+// nodes of ModeLazy trees, are recomputed from chunks of leaves, only one chunk being buffered at a time.
+// The returned hashes are shared with the tree and must not be modified.
+//
+//	it := tree.NodeIter()
+//	for it.Next() {
+//		level, index, hash := it.Node()
+//		// ...
+//	}
+//	if err := it.Err(); err != nil {
+//		// ...
+//	}
+type NodeIterator struct {
+	m *MerkleTree
+	// level and index are the position of the next node, up to the level last.
+	level, index, last int
+	// cur is the position of the current node, with its hash.
+	cur  NodeRef
+	hash []byte
+	err  error
+	// window are recomputed nodes of the level from the index windowStart.
+	window      [][]byte
+	windowStart int
+}
+
+// NodeIter returns an iterator over all the nodes of the tree, from the leaves up to the root.
+func (m *MerkleTree) NodeIter() *NodeIterator {
+	return &NodeIterator{m: m, last: m.Depth, err: m.generateDeferredProofs()}
+}
+
+// LevelIter returns an iterator over the nodes of the level, the leaves in order if it is 0.
+// The iterator fails with ErrIndexOutOfRange if the level is not between 0 and Depth.
+func (m *MerkleTree) LevelIter(level int) *NodeIterator {
+	it := &NodeIterator{m: m, level: level, last: level}
+	if level < 0 || level > m.Depth {
+		it.err = ErrIndexOutOfRange
+	} else {
+		it.err = m.generateDeferredProofs()
+	}
+
+	return it
+}
+
+// Next advances the iterator to the next node, which is then returned by Node. It returns false when
+// the iteration is over or failed, see Err.
+func (it *NodeIterator) Next() bool {
+	if it.err != nil || it.level > it.last {
+		return false
+	}
+
+	if it.index == (it.m.NumLeaves+1<<it.level-1)>>it.level {
+		it.level++
+		it.index = 0
+		it.window = nil
+
+		if it.level > it.last {
+			return false
+		}
+	}
+
+	hash, err := it.node(it.level, it.index)
+	if err != nil {
+		it.err = err
+
+		return false
+	}
+
+	it.cur = NodeRef{Level: it.level, Index: it.index}
+	it.hash = hash
+	it.index++
+
+	return true
+}
+
+// Node returns the level, the index in the level and the hash of the current node.
+func (it *NodeIterator) Node() (level, index int, hash []byte) {
+	return it.cur.Level, it.cur.Index, it.hash
+}
+
+// Err returns the error that ended the iteration, if any.
+func (it *NodeIterator) Err() error {
+	return it.err
+}
+
+// node returns the node at idx of the level, read from the tree or recomputed with its window.
+func (it *NodeIterator) node(level, idx int) ([]byte, error) {
+	m := it.m
+	if node, ok := m.storedNode(level, idx); ok {
+		return node, nil
+	}
+
+	if m.Proofs != nil {
+		return m.levelNode(level, idx)
+	}
+
+	if idx < it.windowStart || idx >= it.windowStart+len(it.window) {
+		var (
+			size  = max(level, m.recomputeLevels())
+			start = idx << level >> size << size
+			err   error
+		)
+
+		if it.window, err = m.levelWindow(level, start, size); err != nil {
+			return nil, err
+		}
+
+		it.windowStart = start >> level
+	}
+
+	return it.window[idx-it.windowStart], nil
+}
+
+// storedNode returns the node at idx of the level if the tree stores it: a leaf, the root, a node of
+// the tree structure or of the top levels of a ModeLowMemory tree.
+func (m *MerkleTree) storedNode(level, idx int) ([]byte, bool) {
+	switch {
+	case level == 0:
+		return m.Leaves[idx], true
+	case level == m.Depth:
+		return m.Root, true
+	case m.nodes != nil:
+		return m.nodes[level][idx], true
+	case m.topNodes != nil && level >= m.Depth-len(m.topNodes):
+		return m.topNodes[level-m.Depth+len(m.topNodes)][idx], true
+	}
+
+	return nil, false
+}
+
+// levelWindow recomputes the nodes of the level above the chunk of 2^size leaves from start, which is
+// a multiple of 2^size, with size at least the level.
+func (m *MerkleTree) levelWindow(level, start, size int) ([][]byte, error) {
+	nodes := make([][]byte, min(1<<size, m.NumLeaves-start), 1<<size+1)
+	copy(nodes, m.Leaves[start:])
+
+	for l := 0; l < level; l++ {
+		var err error
+
+		nodes = appendNodeIfOdd(nodes)
+		if nodes, err = m.hashLevel(m.hashChildren, l+1, start>>(l+1), nodes); err != nil {
+			return nil, err
+		}
+	}
+
+	return nodes, nil
+}
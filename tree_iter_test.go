@@ -0,0 +1,87 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMerkleTree_NodeIter(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		num    int
+	}{
+		{name: "test_2_proof_gen", config: &Config{}, num: 2},
+		{name: "test_5_tree_build", config: &Config{Mode: ModeTreeBuild}, num: 5},
+		{name: "test_100_proof_gen", config: &Config{}, num: 100},
+		{name: "test_100_lazy", config: &Config{Mode: ModeLazy}, num: 100},
+		{name: "test_100_low_memory", config: &Config{Mode: ModeLowMemory, RecomputeLevels: 2}, num: 100},
+		{name: "test_37_positional_lazy", config: &Config{Mode: ModeLazy, PositionalHashFunc: PositionPrefixed(nil)}, num: 37},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.num)
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			wantConfig := *tt.config
+			wantConfig.Mode = ModeTreeBuild
+			want, err := New(&wantConfig, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			it := m.NodeIter()
+			for level := 0; level <= m.Depth; level++ {
+				size := (tt.num + 1<<level - 1) >> level
+				for idx := 0; idx < size; idx++ {
+					if !it.Next() {
+						t.Fatalf("Next() = false at level %d, index %d, error = %v", level, idx, it.Err())
+					}
+					gotLevel, gotIdx, hash := it.Node()
+					wantHash, _ := want.storedNode(level, idx)
+					if gotLevel != level || gotIdx != idx || !bytes.Equal(hash, wantHash) {
+						t.Fatalf("Node() = (%d, %d, %x), want (%d, %d, %x)", gotLevel, gotIdx, hash, level, idx, wantHash)
+					}
+				}
+			}
+			if it.Next() || it.Err() != nil {
+				t.Errorf("Next() after the root = true, error = %v", it.Err())
+			}
+
+			leaves := m.LevelIter(0)
+			for i := 0; leaves.Next(); i++ {
+				if _, idx, leaf := leaves.Node(); idx != i || !bytes.Equal(leaf, m.Leaves[i]) {
+					t.Errorf("LevelIter(0) leaf %d = %x, want %x", idx, leaf, m.Leaves[i])
+				}
+			}
+			if it := m.LevelIter(m.Depth + 1); it.Next() || !errors.Is(it.Err(), ErrIndexOutOfRange) {
+				t.Errorf("LevelIter() error = %v, want %v", it.Err(), ErrIndexOutOfRange)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"os"
+)
+
+// MappedTree is a read-only tree serialized by WriteTo or WriteProofArchive and memory-mapped by OpenTree.
+// It is a ProofArchive whose nodes are sliced straight from the mapping: opening a tree reads its header
+// and root only, whatever its size, and the pages of the file are loaded by the operating system as the
+// proofs touch them, keeping the cold start of proof servers short for multi-GB trees.
+// The nodes and proofs it returns point into the mapping: they must not be modified, and are invalid
+// once the tree is closed. Platforms without mmap read the whole file instead.
+type MappedTree struct {
+	*ProofArchive
+}
+
+// OpenTree memory-maps the serialized tree at path.
+func OpenTree(path string) (*MappedTree, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size() < treeEncodingHeaderSize {
+		return nil, ErrInvalidTreeEncoding
+	}
+
+	data, err := mapFile(f, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := OpenProofArchive(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Join(err, unmapFile(data))
+	}
+
+	a.data = data
+
+	return &MappedTree{ProofArchive: a}, nil
+}
+
+// Layer returns the nodes of the level, the leaves if it is 0 and the root if it is Depth,
+// without the duplicate padding odd levels.
+func (t *MappedTree) Layer(level int) ([][]byte, error) {
+	if level < 0 || level > t.h.depth {
+		return nil, ErrIndexOutOfRange
+	}
+
+	if level == t.h.depth {
+		return [][]byte{t.Root}, nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	nodes := make([][]byte, levelSize(t.h.numLeaves, level))
+	for i := range nodes {
+		var err error
+		if nodes[i], err = t.node(level, i); err != nil {
+			return nil, err
+		}
+	}
+
+	return nodes, nil
+}
+
+// Close unmaps the tree once the reads in progress complete. Reading its nodes afterwards fails
+// with ErrTreeClosed.
+func (t *MappedTree) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.data == nil {
+		return nil
+	}
+
+	data := t.data
+	t.data, t.r = nil, closedReaderAt{}
+
+	return unmapFile(data)
+}
+
+// closedReaderAt is the reader of a closed MappedTree.
+type closedReaderAt struct{}
+
+func (closedReaderAt) ReadAt([]byte, int64) (int, error) {
+	return 0, ErrTreeClosed
+}
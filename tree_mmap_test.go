@@ -0,0 +1,168 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestOpenTree(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		num    int
+	}{
+		{name: "test_2_proof_gen", num: 2},
+		{name: "test_13_proof_gen_sorted", config: &Config{SortSiblingPairs: true}, num: 13},
+		{name: "test_100_tree_build", config: &Config{Mode: ModeTreeBuild}, num: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocksFixedSize(tt.num)
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			var buf bytes.Buffer
+			if _, err := WriteProofArchive(&buf, m); err != nil {
+				t.Fatalf("WriteProofArchive() error = %v", err)
+			}
+			path := filepath.Join(t.TempDir(), "tree.bin")
+			if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			tree, err := OpenTree(path)
+			if err != nil {
+				t.Fatalf("OpenTree() error = %v", err)
+			}
+			if !bytes.Equal(tree.Root, m.Root) || tree.NumLeaves() != tt.num {
+				t.Fatalf("OpenTree() root = %x, leaves = %d", tree.Root, tree.NumLeaves())
+			}
+			for i, block := range blocks {
+				proof, err := tree.Proof(i)
+				if err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+				want, _ := m.proofByIndex(i)
+				if !reflect.DeepEqual(proof, want) {
+					t.Errorf("Proof() %d = %v, want %v", i, proof, want)
+				}
+				if ok, err := Verify(block, proof, tree.Root, tree.Config(nil)); err != nil || !ok {
+					t.Errorf("Verify() %d = %v, error = %v", i, ok, err)
+				}
+			}
+			for level := 0; level <= m.Depth; level++ {
+				layer, err := tree.Layer(level)
+				if err != nil {
+					t.Fatalf("Layer() error = %v", err)
+				}
+				it := m.LevelIter(level)
+				for i := 0; it.Next(); i++ {
+					if _, _, want := it.Node(); i >= len(layer) || !bytes.Equal(layer[i], want) {
+						t.Fatalf("Layer() %d node %d differs", level, i)
+					}
+				}
+				if want := levelSize(tt.num, level); len(layer) != want {
+					t.Errorf("Layer() %d has %d nodes, want %d", level, len(layer), want)
+				}
+			}
+			if err := tree.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if _, err := tree.Proof(0); !errors.Is(err, ErrTreeClosed) {
+				t.Errorf("Proof() after Close() error = %v, want %v", err, ErrTreeClosed)
+			}
+		})
+	}
+}
+
+func TestOpenTree_truncated(t *testing.T) {
+	m, err := New(nil, mockDataBlocksFixedSize(10))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := WriteProofArchive(&buf, m); err != nil {
+		t.Fatalf("WriteProofArchive() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "tree.bin")
+	if err := os.WriteFile(path, buf.Bytes()[:buf.Len()-1], 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenTree(path); !errors.Is(err, ErrInvalidTreeEncoding) {
+		t.Errorf("OpenTree() error = %v, want %v", err, ErrInvalidTreeEncoding)
+	}
+}
+
+func TestMappedTree_Close_concurrent(t *testing.T) {
+	m, err := New(nil, mockDataBlocksFixedSize(64))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := WriteProofArchive(&buf, m); err != nil {
+		t.Fatalf("WriteProofArchive() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "tree.bin")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := OpenTree(path)
+	if err != nil {
+		t.Fatalf("OpenTree() error = %v", err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for w := 0; w < cap(errs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i = (i + 1) % m.NumLeaves {
+				if _, err := tree.Proof(i); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := tree.Layer(1); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if !errors.Is(err, ErrTreeClosed) {
+			t.Errorf("Proof() during Close() error = %v, want %v", err, ErrTreeClosed)
+		}
+	}
+}
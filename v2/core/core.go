@@ -0,0 +1,130 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package core is the Merkle Tree builder: its configuration, build modes and the built tree.
+package core
+
+import (
+	mt "github.com/txaty/go-merkletree"
+	"github.com/txaty/go-merkletree/v2/hashers"
+)
+
+// Configuration modes.
+const (
+	ModeProofGen             = mt.ModeProofGen
+	ModeTreeBuild            = mt.ModeTreeBuild
+	ModeProofGenAndTreeBuild = mt.ModeProofGenAndTreeBuild
+	ModeLazy                 = mt.ModeLazy
+	ModeLowMemory            = mt.ModeLowMemory
+)
+
+type (
+	// Config is the configuration of Merkle Tree builds.
+	Config = mt.Config
+	// MerkleTree is a built Merkle Tree.
+	MerkleTree = mt.MerkleTree
+	// DataBlock is the interface of the data blocks the leaves are built from.
+	DataBlock = mt.DataBlock
+	// TypeConfigMode is the type of the configuration modes.
+	TypeConfigMode = mt.TypeConfigMode
+	// LeafCache is the cache of leaf hashes shared across builds.
+	LeafCache = mt.LeafCache
+	// LeafSet is the set of leaves of data blocks, hashed once to derive several trees.
+	LeafSet = mt.LeafSet
+	// IngestTree is the append-only tree for concurrent ingestion.
+	IngestTree = mt.IngestTree
+)
+
+// New builds a Merkle Tree with the configuration over the data blocks.
+func New(config *Config, blocks []DataBlock) (*MerkleTree, error) {
+	return mt.New(config, blocks)
+}
+
+// NewLeafSet hashes the data blocks into a LeafSet.
+func NewLeafSet(config *Config, blocks []DataBlock) (*LeafSet, error) {
+	return mt.NewLeafSet(config, blocks)
+}
+
+// NewIngestTree creates an empty IngestTree.
+func NewIngestTree(config *Config) *IngestTree {
+	return mt.NewIngestTree(config)
+}
+
+// Option sets a field of the build configuration.
+type Option func(*Config)
+
+// Build builds a Merkle Tree over the data blocks with the configuration set by the options.
+// It is the option-based counterpart of New.
+func Build(blocks []DataBlock, opts ...Option) (*MerkleTree, error) {
+	config := new(Config)
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return New(config, blocks)
+}
+
+// WithConfig copies every field of a v1 configuration, to migrate existing configurations to Build.
+// Options following it override its fields.
+func WithConfig(c *Config) Option {
+	return func(config *Config) {
+		if c != nil {
+			*config = *c
+		}
+	}
+}
+
+// WithMode sets the configuration mode.
+func WithMode(mode TypeConfigMode) Option {
+	return func(config *Config) {
+		config.Mode = mode
+	}
+}
+
+// WithHashFunc sets the hash function.
+func WithHashFunc(hashFunc hashers.TypeHashFunc) Option {
+	return func(config *Config) {
+		config.HashFunc = hashFunc
+	}
+}
+
+// WithParallel runs the build in parallel with numRoutines goroutines, or one per CPU if it is 0.
+func WithParallel(numRoutines int) Option {
+	return func(config *Config) {
+		config.RunInParallel = true
+		config.NumRoutines = numRoutines
+	}
+}
+
+// WithSortedSiblingPairs sorts sibling pairs, for OpenZeppelin compatibility.
+func WithSortedSiblingPairs() Option {
+	return func(config *Config) {
+		config.SortSiblingPairs = true
+	}
+}
+
+// WithoutLeafHashing uses the serialized data blocks as leaves.
+func WithoutLeafHashing() Option {
+	return func(config *Config) {
+		config.DisableLeafHashing = true
+	}
+}
@@ -0,0 +1,87 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/txaty/go-merkletree/v2/hashers"
+	"github.com/txaty/go-merkletree/v2/proofs"
+)
+
+type testBlock []byte
+
+func (b testBlock) Serialize() ([]byte, error) {
+	return b, nil
+}
+
+func TestBuild(t *testing.T) {
+	blocks := make([]DataBlock, 100)
+	for i := range blocks {
+		blocks[i] = testBlock(fmt.Sprintf("block-%d", i))
+	}
+	tests := []struct {
+		name   string
+		opts   []Option
+		config *Config
+	}{
+		{name: "test_default", config: &Config{}},
+		{
+			name:   "test_options",
+			opts:   []Option{WithMode(ModeTreeBuild), WithParallel(2), WithSortedSiblingPairs(), WithHashFunc(hashers.SHA256Parallel)},
+			config: &Config{Mode: ModeTreeBuild, RunInParallel: true, NumRoutines: 2, SortSiblingPairs: true},
+		},
+		{
+			name:   "test_migrated_config",
+			opts:   []Option{WithConfig(&Config{Mode: ModeProofGenAndTreeBuild, SortSiblingPairs: true}), WithoutLeafHashing()},
+			config: &Config{Mode: ModeProofGenAndTreeBuild, SortSiblingPairs: true, DisableLeafHashing: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Build(blocks, tt.opts...)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			want, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(got.Root, want.Root) {
+				t.Fatal("Build() root differs from New()")
+			}
+			proof, err := got.Proof(blocks[7])
+			if tt.config.Mode == ModeProofGen {
+				proof, err = got.Proofs[7], nil
+			}
+			if err != nil {
+				t.Fatalf("Proof() error = %v", err)
+			}
+			if ok, err := proofs.Verify(blocks[7], proof, got.Root, got.Config); err != nil || !ok {
+				t.Errorf("Verify() = %v, %v", ok, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package encode serializes trees and proof archives, and checks and repairs serialized trees.
+package encode
+
+import (
+	"io"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+type (
+	// HexBytes is a byte slice encoded in JSON as a 0x-prefixed hexadecimal string.
+	HexBytes = mt.HexBytes
+	// ProofArchive serves the proofs of a serialized tree without loading it.
+	ProofArchive = mt.ProofArchive
+	// RepairReport describes the repair of a damaged serialized tree.
+	RepairReport = mt.RepairReport
+	// NodeRef identifies a node of a tree.
+	NodeRef = mt.NodeRef
+)
+
+// WriteTree serializes the tree structure to w.
+func WriteTree(w io.Writer, m *mt.MerkleTree) (int64, error) {
+	return m.WriteTo(w)
+}
+
+// ReadTree deserializes a tree written by WriteTree.
+func ReadTree(r io.Reader, config *mt.Config) (*mt.MerkleTree, error) {
+	return mt.ReadTree(r, config)
+}
+
+// VerifyTreeFile checks a serialized tree against the expected root without loading it.
+func VerifyTreeFile(r io.ReaderAt, expectedRoot []byte, config *mt.Config) error {
+	return mt.VerifyTreeFile(r, expectedRoot, config)
+}
+
+// RepairTree recomputes the missing nodes of a damaged serialized tree.
+func RepairTree(r io.ReaderAt, root []byte, config *mt.Config, damaged ...NodeRef) (*mt.MerkleTree, *RepairReport, error) {
+	return mt.RepairTree(r, root, config, damaged...)
+}
+
+// WriteProofArchive writes the proof archive of the tree to w.
+func WriteProofArchive(w io.Writer, m *mt.MerkleTree) (int64, error) {
+	return mt.WriteProofArchive(w, m)
+}
+
+// OpenProofArchive opens a proof archive.
+func OpenProofArchive(r io.ReaderAt) (*ProofArchive, error) {
+	return mt.OpenProofArchive(r)
+}
@@ -0,0 +1,11 @@
+module github.com/txaty/go-merkletree/v2
+
+go 1.21
+
+require github.com/txaty/go-merkletree v0.0.0-00010101000000-000000000000
+
+require golang.org/x/sync v0.5.0 // indirect
+
+// The v2 packages are facades over APIs of the v1 implementation that no release contains yet:
+// the module builds against this repository until one is tagged and required instead.
+replace github.com/txaty/go-merkletree => ../
@@ -0,0 +1,50 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package hashers provides the hash functions of Merkle Tree builds and keyed leaf hashing.
+package hashers
+
+import (
+	mt "github.com/txaty/go-merkletree"
+)
+
+type (
+	// TypeHashFunc is the signature of the hash functions of Merkle Tree builds.
+	TypeHashFunc = mt.TypeHashFunc
+	// TypeKeyedHashFunc is the signature of the keyed hash functions of leaves.
+	TypeKeyedHashFunc = mt.TypeKeyedHashFunc
+)
+
+// SHA256 is the default hash function of sequential builds.
+func SHA256(data []byte) ([]byte, error) {
+	return mt.DefaultHashFunc(data)
+}
+
+// SHA256Parallel is the default hash function of parallel builds. It is safe for concurrent use.
+func SHA256Parallel(data []byte) ([]byte, error) {
+	return mt.DefaultHashFuncParallel(data)
+}
+
+// HMACSHA256 is the default keyed hash function of leaves.
+func HMACSHA256(key, data []byte) ([]byte, error) {
+	return mt.HMACSHA256(key, data)
+}
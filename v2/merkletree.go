@@ -0,0 +1,117 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package merkletree is the compatibility shim of the v2 module for the flat v1 API.
+//
+// The v2 module organizes the library into subpackages:
+//
+//   - core: the tree builder and its configuration (New, Config, MerkleTree, modes).
+//   - proofs: proofs and their verification, serialization and envelopes.
+//   - encode: serialization of trees and proof archives, integrity checks and repair.
+//   - hashers: hash functions and keyed leaf hashing.
+//
+// There are no smt and mmr subpackages yet: the v1 API has no Sparse Merkle Tree or Merkle Mountain Range
+// to expose, so they are left to a follow-up adding the implementations.
+//
+// This package only re-exports the core of the flat API: the configuration and modes, the tree, proofs and
+// their verification, the default hash functions, ReadTree and the errors below. Code using this subset
+// migrates by changing its import path. The other v1 identifiers are not re-exported; as the types below
+// are aliases of the v1 types, code using them keeps importing github.com/txaty/go-merkletree alongside
+// this package and passes values between both. New code should import the subpackages instead;
+// identifiers are deprecated here as their subpackage counterparts stabilize.
+package merkletree
+
+import (
+	"io"
+
+	mt "github.com/txaty/go-merkletree"
+	"github.com/txaty/go-merkletree/v2/core"
+	"github.com/txaty/go-merkletree/v2/encode"
+	"github.com/txaty/go-merkletree/v2/hashers"
+	"github.com/txaty/go-merkletree/v2/proofs"
+)
+
+// Configuration modes, see core.
+const (
+	ModeProofGen             = core.ModeProofGen
+	ModeTreeBuild            = core.ModeTreeBuild
+	ModeProofGenAndTreeBuild = core.ModeProofGenAndTreeBuild
+	ModeLazy                 = core.ModeLazy
+	ModeLowMemory            = core.ModeLowMemory
+)
+
+type (
+	// Config is core.Config.
+	Config = core.Config
+	// MerkleTree is core.MerkleTree.
+	MerkleTree = core.MerkleTree
+	// DataBlock is core.DataBlock.
+	DataBlock = core.DataBlock
+	// TypeConfigMode is core.TypeConfigMode.
+	TypeConfigMode = core.TypeConfigMode
+	// TypeHashFunc is hashers.TypeHashFunc.
+	TypeHashFunc = hashers.TypeHashFunc
+	// Proof is proofs.Proof.
+	Proof = proofs.Proof
+	// SerializedProof is proofs.SerializedProof.
+	SerializedProof = proofs.SerializedProof
+	// HexBytes is encode.HexBytes.
+	HexBytes = encode.HexBytes
+)
+
+// New is core.New.
+func New(config *Config, blocks []DataBlock) (*MerkleTree, error) {
+	return core.New(config, blocks)
+}
+
+// Verify is proofs.Verify.
+func Verify(dataBlock DataBlock, proof *Proof, root []byte, config *Config) (bool, error) {
+	return proofs.Verify(dataBlock, proof, root, config)
+}
+
+// DefaultHashFunc is hashers.SHA256.
+func DefaultHashFunc(data []byte) ([]byte, error) {
+	return hashers.SHA256(data)
+}
+
+// DefaultHashFuncParallel is hashers.SHA256Parallel.
+func DefaultHashFuncParallel(data []byte) ([]byte, error) {
+	return hashers.SHA256Parallel(data)
+}
+
+// ReadTree is encode.ReadTree.
+func ReadTree(r io.Reader, config *Config) (*MerkleTree, error) {
+	return encode.ReadTree(r, config)
+}
+
+// Errors of the v1 API.
+var (
+	ErrInvalidNumOfDataBlocks       = mt.ErrInvalidNumOfDataBlocks
+	ErrInvalidConfigMode            = mt.ErrInvalidConfigMode
+	ErrProofIsNil                   = mt.ErrProofIsNil
+	ErrDataBlockIsNil               = mt.ErrDataBlockIsNil
+	ErrProofInvalidModeTreeNotBuilt = mt.ErrProofInvalidModeTreeNotBuilt
+	ErrProofInvalidDataBlock        = mt.ErrProofInvalidDataBlock
+	ErrTreeNotBuilt                 = mt.ErrTreeNotBuilt
+	ErrInvalidTreeEncoding          = mt.ErrInvalidTreeEncoding
+	ErrIndexOutOfRange              = mt.ErrIndexOutOfRange
+)
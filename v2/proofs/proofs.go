@@ -0,0 +1,73 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package proofs holds Merkle proofs, their verification, serialization and delivery envelopes.
+package proofs
+
+import (
+	mt "github.com/txaty/go-merkletree"
+)
+
+type (
+	// Proof is a Merkle proof.
+	Proof = mt.Proof
+	// SerializedProof is the JSON serialization format of proofs.
+	SerializedProof = mt.SerializedProof
+	// CompositeProof is a proof through a chain of nested trees.
+	CompositeProof = mt.CompositeProof
+	// ProofEnvelope is a signed, optionally encrypted, serialized proof.
+	ProofEnvelope = mt.ProofEnvelope
+	// EnvelopeOptions are the options of Seal.
+	EnvelopeOptions = mt.EnvelopeOptions
+	// SignedTreeHead is a signed statement of the root of a tree.
+	SignedTreeHead = mt.SignedTreeHead
+)
+
+// Verify checks the data block against the root using the proof.
+func Verify(dataBlock mt.DataBlock, proof *Proof, root []byte, config *mt.Config) (bool, error) {
+	return mt.Verify(dataBlock, proof, root, config)
+}
+
+// VerifyAny checks the data block against candidate roots and returns the index of the matching one, or -1.
+func VerifyAny(dataBlock mt.DataBlock, proof *Proof, roots [][]byte, config *mt.Config) (int, error) {
+	return mt.VerifyAny(dataBlock, proof, roots, config)
+}
+
+// VerifyComposite checks the data block against the outermost root of a composite proof.
+func VerifyComposite(dataBlock mt.DataBlock, proof *CompositeProof, root []byte, config *mt.Config) (bool, error) {
+	return mt.VerifyComposite(dataBlock, proof, root, config)
+}
+
+// Marshal serializes the proof to JSON, embedding the signed tree head if it is not nil.
+func Marshal(proof *Proof, head *SignedTreeHead) ([]byte, error) {
+	return mt.MarshalProof(proof, head)
+}
+
+// Unmarshal deserializes a proof serialized by Marshal.
+func Unmarshal(data []byte) (*SerializedProof, error) {
+	return mt.UnmarshalProof(data)
+}
+
+// Seal signs the serialized proof and wraps it into an envelope.
+func Seal(sp *SerializedProof, signer mt.TreeHeadSigner, opts *EnvelopeOptions) (*ProofEnvelope, error) {
+	return mt.SealProof(sp, signer, opts)
+}
@@ -0,0 +1,226 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/txaty/go-merkletree/verifier"
+)
+
+// VerificationSpec is a portable description of the proof verification recipe of a tree configuration,
+// so that external systems (e.g. data warehouses re-verifying sampled proofs) can reimplement it exactly.
+type VerificationSpec struct {
+	// HashFunction is the name of the hash function, e.g. "sha256".
+	HashFunction string `json:"hashFunction"`
+	// LeafHashing tells whether leaves are the hashes of the serialized data blocks or the data blocks themselves.
+	LeafHashing bool `json:"leafHashing"`
+	// KeyedLeaves tells whether leaves are hashed with a keyed hash function (Config.LeafKey).
+	KeyedLeaves bool `json:"keyedLeaves"`
+	// SortSiblingPairs tells whether sibling pairs are ordered lexicographically before being combined.
+	SortSiblingPairs bool `json:"sortSiblingPairs"`
+	// Combine names the operation combining two children into the input of their parent hash.
+	Combine string `json:"combine"`
+	// BindLeafCount tells whether the published root is bound to the number of leaves (Config.BindLeafCount).
+	BindLeafCount bool `json:"bindLeafCount"`
+	// Steps is the human-readable recipe.
+	Steps []string `json:"steps"`
+}
+
+// combineAddBigEndian is the name of the combination of verifier.Concat.
+const combineAddBigEndian = "add-big-endian-trimmed"
+
+// NewVerificationSpec describes the verification recipe of the configuration.
+// hashName names the configured hash function, which cannot be inferred from a Go function;
+// it defaults to "sha256", the hash function used when Config.HashFunc is nil.
+// It returns ErrSpecUnsupported if the configuration also hashes leaves or interior nodes with other
+// functions, set in Config.LeafHashFunc, Config.NodeHashFunc or Config.PositionalHashFunc.
+func NewVerificationSpec(config *Config, hashName string) (*VerificationSpec, error) {
+	if config == nil {
+		config = new(Config)
+	}
+
+	switch {
+	case config.LeafHashFunc != nil:
+		return nil, fmt.Errorf("%w: LeafHashFunc is set", ErrSpecUnsupported)
+	case config.NodeHashFunc != nil:
+		return nil, fmt.Errorf("%w: NodeHashFunc is set", ErrSpecUnsupported)
+	case config.PositionalHashFunc != nil:
+		return nil, fmt.Errorf("%w: PositionalHashFunc is set", ErrSpecUnsupported)
+	}
+
+	if hashName == "" {
+		hashName = "sha256"
+	}
+
+	s := &VerificationSpec{
+		HashFunction:     hashName,
+		LeafHashing:      !config.DisableLeafHashing,
+		KeyedLeaves:      config.LeafKey != nil && !config.DisableLeafHashing,
+		SortSiblingPairs: config.SortSiblingPairs,
+		Combine:          combineAddBigEndian,
+		BindLeafCount:    config.BindLeafCount,
+	}
+
+	switch {
+	case !s.LeafHashing:
+		s.Steps = append(s.Steps, "leaf = serialized data block")
+	case s.KeyedLeaves:
+		s.Steps = append(s.Steps, "leaf = keyed hash (HMAC-SHA256 by default) of the serialized data block under the leaf key")
+	default:
+		s.Steps = append(s.Steps, "leaf = "+hashName+"(serialized data block)")
+	}
+
+	s.Steps = append(s.Steps,
+		"node = leaf; for each sibling from the leaf level up:",
+	)
+
+	if s.SortSiblingPairs {
+		s.Steps = append(s.Steps, "  (a, b) = (node, sibling) ordered lexicographically as byte strings")
+	} else {
+		s.Steps = append(s.Steps, "  (a, b) = (sibling, node) if the sibling is on the left, (node, sibling) otherwise")
+	}
+
+	s.Steps = append(s.Steps,
+		"  strip the leading zero bytes of a and b, add them as big-endian unsigned integers",
+		"  and strip the leading zero bytes of the sum: c = a + b",
+		"  node = "+hashName+"(c)",
+	)
+
+	if s.BindLeafCount {
+		s.Steps = append(s.Steps,
+			"the proof must have one sibling per level of a tree of n leaves, n being the number of leaves",
+			"node = "+hashName+"(node || n as a big-endian uint64)",
+		)
+	}
+
+	s.Steps = append(s.Steps, "the proof is valid if node = root")
+
+	return s, nil
+}
+
+// PLpgSQL returns a reference PL/pgSQL implementation of the recipe: the function
+// merkle_verify(leaf bytea, siblings bytea[], sibling_is_left boolean[], root bytea) returns boolean,
+// taking the number of leaves as a trailing num_leaves bigint if BindLeafCount is set. It does not check
+// that the proof fits a tree of num_leaves leaves. Leaves must be computed beforehand. Hashing uses
+// digest() of the pgcrypto extension, so HashFunction must be one of its algorithm names.
+func (s *VerificationSpec) PLpgSQL() string {
+	step := `    IF sibling_is_left[i] THEN
+      node := digest(merkle_combine(siblings[i], node), '{{HASH}}');
+    ELSE
+      node := digest(merkle_combine(node, siblings[i]), '{{HASH}}');
+    END IF;`
+	if s.SortSiblingPairs {
+		step = `    IF siblings[i] < node THEN
+      node := digest(merkle_combine(siblings[i], node), '{{HASH}}');
+    ELSE
+      node := digest(merkle_combine(node, siblings[i]), '{{HASH}}');
+    END IF;`
+	}
+
+	params, result := "", "node = root"
+	if s.BindLeafCount {
+		params, result = ", num_leaves bigint", "digest(node || int8send(num_leaves), '{{HASH}}') = root"
+	}
+
+	r := strings.NewReplacer("{{STEP}}", step, "{{PARAMS}}", params, "{{RESULT}}", result)
+
+	return strings.ReplaceAll(r.Replace(plpgsqlTemplate), "{{HASH}}", s.HashFunction)
+}
+
+// plpgsqlTemplate is the reference PL/pgSQL implementation of the verification recipe.
+const plpgsqlTemplate = `CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+-- merkle_trim strips the leading zero bytes.
+CREATE OR REPLACE FUNCTION merkle_trim(b bytea) RETURNS bytea
+LANGUAGE plpgsql IMMUTABLE STRICT AS $$
+DECLARE
+  i int := 0;
+BEGIN
+  WHILE i < length(b) AND get_byte(b, i) = 0 LOOP
+    i := i + 1;
+  END LOOP;
+  RETURN substring(b FROM i + 1);
+END;
+$$;
+
+-- merkle_combine adds two nodes as big-endian unsigned integers.
+CREATE OR REPLACE FUNCTION merkle_combine(a bytea, b bytea) RETURNS bytea
+LANGUAGE plpgsql IMMUTABLE STRICT AS $$
+DECLARE
+  t bytea;
+  res bytea;
+  s int;
+  carry int := 0;
+BEGIN
+  a := merkle_trim(a);
+  b := merkle_trim(b);
+  IF length(a) < length(b) THEN
+    t := a; a := b; b := t;
+  END IF;
+  res := '\x00'::bytea || a;
+  FOR i IN 0 .. length(a) - 1 LOOP
+    s := get_byte(a, length(a) - 1 - i) + carry;
+    IF i < length(b) THEN
+      s := s + get_byte(b, length(b) - 1 - i);
+    END IF;
+    res := set_byte(res, length(res) - 1 - i, mod(s, 256));
+    carry := s / 256;
+  END LOOP;
+  res := set_byte(res, 0, carry);
+  RETURN merkle_trim(res);
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION merkle_verify(leaf bytea, siblings bytea[], sibling_is_left boolean[], root bytea{{PARAMS}})
+RETURNS boolean
+LANGUAGE plpgsql IMMUTABLE AS $$
+DECLARE
+  node bytea := leaf;
+  n int := coalesce(array_length(siblings, 1), 0);
+BEGIN
+  IF n <> coalesce(array_length(sibling_is_left, 1), 0) THEN
+    RAISE EXCEPTION 'siblings and sibling_is_left length mismatch';
+  END IF;
+  FOR i IN 1 .. n LOOP
+{{STEP}}
+  END LOOP;
+  RETURN {{RESULT}};
+END;
+$$;
+`
+
+// VerifyRaw verifies a proof given as raw bytes, for external verifiers following a VerificationSpec:
+// the leaf hash, the siblings from the leaf level up, whether each sibling is on the left, and the root.
+// It uses the default configuration (SHA-256). The combination of two nodes is commutative,
+// so the result does not depend on SortSiblingPairs.
+func VerifyRaw(leafHash []byte, siblings [][]byte, dirs []bool, root []byte) (bool, error) {
+	if len(siblings) != len(dirs) {
+		return false, ErrDirectionalProofLengthMismatch
+	}
+
+	proof := (&DirectionalProof{Siblings: siblings, SiblingIsLeft: dirs}).Proof()
+
+	return verifier.VerifyLeaf(leafHash, proof.Siblings, proof.Path, root, nil)
+}
@@ -0,0 +1,101 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVerifyRaw(t *testing.T) {
+	for _, config := range []*Config{nil, {SortSiblingPairs: true}} {
+		m, err := New(config, mockDataBlocks(9))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for i, proof := range m.Proofs {
+			dp := proof.Directional()
+			if ok, err := VerifyRaw(m.Leaves[i], dp.Siblings, dp.SiblingIsLeft, m.Root); err != nil || !ok {
+				t.Errorf("VerifyRaw() %d = %v, error = %v", i, ok, err)
+			}
+			if ok, _ := VerifyRaw(m.Leaves[(i+1)%len(m.Leaves)], dp.Siblings, dp.SiblingIsLeft, m.Root); ok {
+				t.Errorf("VerifyRaw() %d accepted another leaf", i)
+			}
+		}
+	}
+	if _, err := VerifyRaw(nil, [][]byte{{1}}, nil, nil); err != ErrDirectionalProofLengthMismatch {
+		t.Errorf("VerifyRaw() error = %v, want %v", err, ErrDirectionalProofLengthMismatch)
+	}
+}
+
+func TestNewVerificationSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *Config
+		hashName string
+		wantLeaf string
+		wantSQL  string
+	}{
+		{name: "test_default", wantLeaf: "sha256(serialized data block)", wantSQL: "IF sibling_is_left[i] THEN"},
+		{name: "test_sorted_sha512", config: &Config{SortSiblingPairs: true}, hashName: "sha512", wantLeaf: "sha512(", wantSQL: "IF siblings[i] < node THEN"},
+		{name: "test_disable_leaf_hashing", config: &Config{DisableLeafHashing: true}, wantLeaf: "leaf = serialized data block"},
+		{name: "test_keyed", config: &Config{LeafKey: []byte("k")}, wantLeaf: "keyed hash"},
+		{name: "test_bind_leaf_count", config: &Config{BindLeafCount: true}, wantLeaf: "sha256(", wantSQL: "num_leaves bigint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewVerificationSpec(tt.config, tt.hashName)
+			if err != nil {
+				t.Fatalf("NewVerificationSpec() error = %v", err)
+			}
+			if !strings.Contains(s.Steps[0], tt.wantLeaf) {
+				t.Errorf("Steps[0] = %q, want %q", s.Steps[0], tt.wantLeaf)
+			}
+			sql := s.PLpgSQL()
+			if !strings.Contains(sql, "digest(merkle_combine(siblings[i], node), '"+s.HashFunction+"')") ||
+				!strings.Contains(sql, tt.wantSQL) || strings.Contains(sql, "{{") {
+				t.Errorf("PLpgSQL() = %s", sql)
+			}
+		})
+	}
+}
+
+func TestNewVerificationSpec_unsupported(t *testing.T) {
+	leafHashFunc, nodeHashFunc := DomainSeparated(nil)
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "test_leaf_hash_func", config: &Config{LeafHashFunc: leafHashFunc}},
+		{name: "test_node_hash_func", config: &Config{NodeHashFunc: nodeHashFunc}},
+		{name: "test_positional_hash_func", config: &Config{PositionalHashFunc: PositionPrefixed(nil)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewVerificationSpec(tt.config, ""); !errors.Is(err, ErrSpecUnsupported) {
+				t.Errorf("NewVerificationSpec() error = %v, want %v", err, ErrSpecUnsupported)
+			}
+		})
+	}
+}
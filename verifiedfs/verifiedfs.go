@@ -0,0 +1,352 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package verifiedfs provides a read-only fs.FS whose file reads are verified chunk by chunk against a
+// trusted Merkle root, so that tampered content is detected when it is read, before it is used.
+//
+// Every file is split into chunks of a fixed size, and the SHA256 digests of its chunks are the leaves of
+// a chunk tree. The manifest tree commits to the path, size and chunk tree root of every file, ordered by
+// path in byte order; its root is the only value that must be trusted, the manifest itself is verified
+// against it when the file system is created.
+package verifiedfs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+// DefaultChunkSize is the chunk size used if it is not set.
+const DefaultChunkSize = 64 << 10
+
+var (
+	// ErrTampered is the error for file content that does not match the manifest.
+	ErrTampered = errors.New("file content does not match the manifest")
+	// ErrManifestMismatch is the error for a manifest that does not match the trusted root.
+	ErrManifestMismatch = errors.New("manifest does not match the root")
+)
+
+// FileEntry is a file listed in a manifest. It is the data block of the file in the manifest tree.
+type FileEntry struct {
+	// Path is the slash-separated path of the file relative to the file system root.
+	Path string `json:"path"`
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+	// Chunks are the SHA256 digests of the chunks of the file, at least one even for an empty file.
+	Chunks []mt.HexBytes `json:"chunks"`
+	// chunkRoot is the root of the chunk tree, computed by Build or verified by New.
+	chunkRoot []byte
+}
+
+// Serialize encodes the file as the length of the path (uint32, big-endian), the path, the size
+// (uint64, big-endian) and the chunk tree root, so that the path and size are committed with the content.
+func (e *FileEntry) Serialize() ([]byte, error) {
+	buf := make([]byte, 0, 12+len(e.Path)+len(e.chunkRoot))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(e.Path)))
+	buf = append(buf, e.Path...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(e.Size))
+	buf = append(buf, e.chunkRoot...)
+
+	return buf, nil
+}
+
+// digestBlock is the data block of a chunk digest in a chunk tree.
+type digestBlock []byte
+
+func (d digestBlock) Serialize() ([]byte, error) {
+	return d, nil
+}
+
+// computeChunkRoot computes the root of the chunk tree of the entry. A file of a single chunk has no tree,
+// the digest of its chunk is the root.
+func (e *FileEntry) computeChunkRoot(config *mt.Config) ([]byte, error) {
+	if len(e.Chunks) == 1 {
+		return e.Chunks[0], nil
+	}
+
+	blocks := make([]mt.DataBlock, len(e.Chunks))
+	for i, chunk := range e.Chunks {
+		blocks[i] = digestBlock(chunk)
+	}
+
+	tree, err := mt.New(config, blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree.Root, nil
+}
+
+// Manifest is the root of a file system and the chunk digests of all its files.
+type Manifest struct {
+	// ChunkSize is the size of the chunks of the files in bytes.
+	ChunkSize int `json:"chunkSize"`
+	// Root is the root of the manifest tree.
+	Root mt.HexBytes `json:"root"`
+	// Files are the files, ordered by path.
+	Files []*FileEntry `json:"files"`
+}
+
+// Build splits every regular file of fsys into chunks of chunkSize bytes, DefaultChunkSize if it is 0,
+// and builds the manifest of the file system. It must contain at least two files.
+func Build(fsys fs.FS, chunkSize int, config *mt.Config) (*Manifest, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	var paths []string
+
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// WalkDir orders entries per directory, sort globally by byte order.
+	sort.Strings(paths)
+
+	manifest := &Manifest{
+		ChunkSize: chunkSize,
+		Files:     make([]*FileEntry, len(paths)),
+	}
+
+	for i, path := range paths {
+		if manifest.Files[i], err = hashChunks(fsys, path, chunkSize); err != nil {
+			return nil, err
+		}
+
+		if manifest.Files[i].chunkRoot, err = manifest.Files[i].computeChunkRoot(config); err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest.Root, err = manifest.computeRoot(config); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// hashChunks streams the file content into the SHA256 digests of its chunks.
+func hashChunks(fsys fs.FS, path string, chunkSize int) (*FileEntry, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		entry = &FileEntry{Path: path}
+		buf   = make([]byte, chunkSize)
+	)
+
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 || len(entry.Chunks) == 0 {
+			digest := sha256.Sum256(buf[:n])
+			entry.Chunks = append(entry.Chunks, digest[:])
+			entry.Size += int64(n)
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return entry, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// computeRoot computes the root of the manifest tree over the files.
+func (m *Manifest) computeRoot(config *mt.Config) ([]byte, error) {
+	blocks := make([]mt.DataBlock, len(m.Files))
+	for i := range m.Files {
+		blocks[i] = m.Files[i]
+	}
+
+	tree, err := mt.New(config, blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree.Root, nil
+}
+
+// FS is a read-only file system serving the files of a manifest, whose reads are verified chunk by chunk.
+// Only the files of the manifest can be opened: directories are not listed.
+type FS struct {
+	fsys     fs.FS
+	manifest *Manifest
+}
+
+// New verifies the manifest against the trusted root and returns the file system serving its files
+// from fsys. The configuration must be the one the manifest was built with.
+func New(fsys fs.FS, manifest *Manifest, root []byte, config *mt.Config) (*FS, error) {
+	if manifest.ChunkSize <= 0 {
+		return nil, fmt.Errorf("%w: invalid chunk size %d", ErrManifestMismatch, manifest.ChunkSize)
+	}
+
+	for i, entry := range manifest.Files {
+		if i > 0 && manifest.Files[i-1].Path >= entry.Path {
+			return nil, fmt.Errorf("%w: files are not ordered by path at %q", ErrManifestMismatch, entry.Path)
+		}
+
+		// Every file has at least one chunk, empty for an empty file.
+		numChunks := max((entry.Size+int64(manifest.ChunkSize)-1)/int64(manifest.ChunkSize), 1)
+		if entry.Size < 0 || int64(len(entry.Chunks)) != numChunks {
+			return nil, fmt.Errorf("%w: %q has %d chunks for %d bytes", ErrManifestMismatch, entry.Path,
+				len(entry.Chunks), entry.Size)
+		}
+
+		var err error
+		if entry.chunkRoot, err = entry.computeChunkRoot(config); err != nil {
+			return nil, err
+		}
+	}
+
+	manifestRoot, err := manifest.computeRoot(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(manifestRoot, root) {
+		return nil, ErrManifestMismatch
+	}
+
+	return &FS{fsys: fsys, manifest: manifest}, nil
+}
+
+// Open opens the file of the manifest at name. Its reads return ErrTampered as soon as a chunk does not
+// match its digest, or if the file is shorter or longer than its size in the manifest.
+func (f *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	files := f.manifest.Files
+
+	idx := sort.Search(len(files), func(i int) bool {
+		return files[i].Path >= name
+	})
+	if idx == len(files) || files[idx].Path != name {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &verifiedFile{
+		File:  file,
+		entry: files[idx],
+		chunk: make([]byte, 0, f.manifest.ChunkSize),
+	}, nil
+}
+
+// verifiedFile is a file whose reads are verified chunk by chunk.
+type verifiedFile struct {
+	fs.File
+	entry *FileEntry
+	// chunk is the verified chunk being read, buf its unread part.
+	chunk []byte
+	buf   []byte
+	// next is the index of the next chunk to read.
+	next int
+	err  error
+}
+
+// Read reads verified content, reading and verifying the next chunk of the file when needed.
+func (f *verifiedFile) Read(p []byte) (int, error) {
+	for len(f.buf) == 0 {
+		if f.err != nil {
+			return 0, f.err
+		}
+
+		f.err = f.readChunk()
+	}
+
+	n := copy(p, f.buf)
+	f.buf = f.buf[n:]
+
+	return n, nil
+}
+
+// readChunk reads and verifies the next chunk, returning io.EOF after the last one.
+func (f *verifiedFile) readChunk() error {
+	if f.next == len(f.entry.Chunks) {
+		// Content appended past the size of the manifest is tampering too.
+		if n, _ := f.File.Read(make([]byte, 1)); n > 0 {
+			return f.tampered("content past the end of the file")
+		}
+
+		return io.EOF
+	}
+
+	size := int64(cap(f.chunk))
+	if f.next == len(f.entry.Chunks)-1 {
+		size = f.entry.Size - int64(f.next)*size
+	}
+
+	f.chunk = f.chunk[:size]
+	if _, err := io.ReadFull(f.File, f.chunk); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return f.tampered("truncated file")
+		}
+
+		return err
+	}
+
+	if digest := sha256.Sum256(f.chunk); !bytes.Equal(digest[:], f.entry.Chunks[f.next]) {
+		return f.tampered(fmt.Sprintf("chunk %d", f.next))
+	}
+
+	f.buf = f.chunk
+	f.next++
+
+	if f.next == len(f.entry.Chunks) && len(f.buf) == 0 {
+		return f.readChunk()
+	}
+
+	return nil
+}
+
+func (f *verifiedFile) tampered(reason string) error {
+	return &fs.PathError{Op: "read", Path: f.entry.Path, Err: fmt.Errorf("%w: %s", ErrTampered, reason)}
+}
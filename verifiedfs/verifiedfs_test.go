@@ -0,0 +1,150 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package verifiedfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	mt "github.com/txaty/go-merkletree"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"a.txt":         {Data: []byte("hello world")},
+		"a/b.txt":       {Data: []byte("0123456789abcdef")},
+		"bin/tool":      {Data: bytes.Repeat([]byte{0x7f}, 37)},
+		"docs/empty.md": {Data: []byte{}},
+		"docs/one":      {Data: []byte("x")},
+	}
+}
+
+func TestFS(t *testing.T) {
+	tests := []struct {
+		name      string
+		chunkSize int
+		config    *mt.Config
+	}{
+		{name: "test_small_chunks", chunkSize: 4},
+		{name: "test_exact_chunks", chunkSize: 8, config: &mt.Config{SortSiblingPairs: true}},
+		{name: "test_default_chunk_size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := testFS()
+			manifest, err := Build(fsys, tt.chunkSize, tt.config)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			// The manifest is shipped separately from the trusted root.
+			data, err := json.Marshal(manifest)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			shipped := new(Manifest)
+			if err := json.Unmarshal(data, shipped); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			vfs, err := New(fsys, shipped, manifest.Root, tt.config)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for path, file := range fsys {
+				got, err := fs.ReadFile(vfs, path)
+				if err != nil {
+					t.Fatalf("ReadFile(%s) error = %v", path, err)
+				}
+				if !bytes.Equal(got, file.Data) {
+					t.Errorf("ReadFile(%s) = %q, want %q", path, got, file.Data)
+				}
+			}
+			if _, err := vfs.Open("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Open() error = %v, want %v", err, fs.ErrNotExist)
+			}
+		})
+	}
+}
+
+func TestFS_tampered(t *testing.T) {
+	manifest, err := Build(testFS(), 4, nil)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		data     []byte
+		wantRead int
+	}{
+		{name: "test_modified_chunk", data: []byte("0123456789Abcdef"), wantRead: 8},
+		{name: "test_truncated", data: []byte("0123456789abcde"), wantRead: 12},
+		{name: "test_appended", data: []byte("0123456789abcdef!"), wantRead: 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := testFS()
+			fsys["a/b.txt"] = &fstest.MapFile{Data: tt.data}
+			vfs, err := New(fsys, manifest, manifest.Root, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			f, err := vfs.Open("a/b.txt")
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer f.Close()
+			got, err := io.ReadAll(f)
+			if !errors.Is(err, ErrTampered) {
+				t.Fatalf("ReadAll() error = %v, want %v", err, ErrTampered)
+			}
+			// The chunks before the tampered one are served.
+			if len(got) != tt.wantRead {
+				t.Errorf("ReadAll() read %d bytes, want %d", len(got), tt.wantRead)
+			}
+		})
+	}
+}
+
+func TestNew_manifestMismatch(t *testing.T) {
+	fsys := testFS()
+	manifest, err := Build(fsys, 4, nil)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if _, err := New(fsys, manifest, manifest.Files[0].Chunks[0], nil); !errors.Is(err, ErrManifestMismatch) {
+		t.Errorf("New() with wrong root error = %v, want %v", err, ErrManifestMismatch)
+	}
+	root := manifest.Root
+	manifest.Files[1].Chunks[2] = manifest.Files[1].Chunks[1]
+	if _, err := New(fsys, manifest, root, nil); !errors.Is(err, ErrManifestMismatch) {
+		t.Errorf("New() with forged chunk error = %v, want %v", err, ErrManifestMismatch)
+	}
+	manifest.Files[1].Chunks = manifest.Files[1].Chunks[1:]
+	if _, err := New(fsys, manifest, root, nil); !errors.Is(err, ErrManifestMismatch) {
+		t.Errorf("New() with missing chunk error = %v, want %v", err, ErrManifestMismatch)
+	}
+}
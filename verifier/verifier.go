@@ -0,0 +1,309 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package verifier is the dependency-light Merkle proof verification core of go-merkletree.
+// It uses no reflection, no pools and no goroutines, so that it compiles under TinyGo for
+// embedded and secure-enclave verifiers. The main package builds on the same primitives,
+// guaranteeing that proofs are verified with exactly the same arithmetic that produced them.
+package verifier
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// ErrMalformedProof is the error for a structurally anomalous proof rejected by strict verification.
+var ErrMalformedProof = errors.New("malformed merkle proof")
+
+// HashFunc is the signature of the hash functions used for Merkle Tree verification.
+type HashFunc func([]byte) ([]byte, error)
+
+// PositionalHashFunc is the signature of the position-aware hash functions of interior nodes. It hashes
+// the combined children of the node at the given index of the given level, leaves being level 0.
+type PositionalHashFunc func(level, index int, data []byte) ([]byte, error)
+
+// Config is the configuration of the verification core.
+// It mirrors the verification related fields of the main package configuration.
+type Config struct {
+	// Customizable hash function used for verification. SHA256 is used if nil.
+	HashFunc HashFunc
+	// If true, the hashing sibling pairs are sorted (OpenZeppelin compatibility).
+	SortSiblingPairs bool
+	// If true, the data block is NOT hashed before being used as the leaf.
+	DisableLeafHashing bool
+	// PositionalHashFunc, if not nil, replaces HashFunc for interior nodes.
+	PositionalHashFunc PositionalHashFunc
+	// If true, structurally anomalous proofs are rejected with ErrMalformedProof, see CheckProof.
+	Strict bool
+	// NumLeaves is the number of leaves of the tree, checked against the proofs by strict verification
+	// if it is not 0.
+	NumLeaves int
+}
+
+// SHA256 is the default hash function of the verification core.
+func SHA256(data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+
+	return digest[:], nil
+}
+
+// Concat combines two sibling hashes into the input of their parent hash.
+// The hashes are interpreted as big-endian unsigned integers and added, and the sum
+// is returned in its minimal big-endian form (without leading zero bytes).
+func Concat(b1, b2 []byte) []byte {
+	return AppendConcat(nil, b1, b2)
+}
+
+// AppendConcat appends Concat(b1, b2) to dst and returns the extended slice, so that siblings can be
+// combined into a reused buffer.
+func AppendConcat(dst, b1, b2 []byte) []byte {
+	b1, b2 = trimLeadingZeros(b1), trimLeadingZeros(b2)
+	if len(b1) < len(b2) {
+		b1, b2 = b2, b1
+	}
+
+	n := len(dst)
+	dst = append(dst, make([]byte, len(b1)+1)...)
+	sum := dst[n:]
+
+	var carry uint16
+
+	for i := 0; i < len(b1); i++ {
+		s := uint16(b1[len(b1)-1-i]) + carry
+		if i < len(b2) {
+			s += uint16(b2[len(b2)-1-i])
+		}
+
+		sum[len(sum)-1-i] = byte(s)
+		carry = s >> 8
+	}
+
+	sum[0] = byte(carry)
+
+	// Drop the leading zero bytes of the sum.
+	zeros := len(sum) - len(trimLeadingZeros(sum))
+	copy(sum, sum[zeros:])
+
+	return dst[:len(dst)-zeros]
+}
+
+// ConcatSorted is like Concat but orders the operands lexicographically first,
+// which is used for compatibility with OpenZeppelin's Merkle proof verification.
+func ConcatSorted(b1, b2 []byte) []byte {
+	return AppendConcatSorted(nil, b1, b2)
+}
+
+// AppendConcatSorted appends ConcatSorted(b1, b2) to dst and returns the extended slice.
+func AppendConcatSorted(dst, b1, b2 []byte) []byte {
+	if bytes.Compare(b1, b2) < 0 {
+		return AppendConcat(dst, b1, b2)
+	}
+
+	return AppendConcat(dst, b2, b1)
+}
+
+func trimLeadingZeros(b []byte) []byte {
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+
+	return b
+}
+
+// Leaf converts serialized data block bytes into the leaf used in the tree.
+// If leaf hashing is disabled, a copy of the data is returned.
+func Leaf(data []byte, config *Config) ([]byte, error) {
+	config = withDefaults(config)
+	if config.DisableLeafHashing {
+		leaf := make([]byte, len(data))
+		copy(leaf, data)
+
+		return leaf, nil
+	}
+
+	return config.HashFunc(data)
+}
+
+// ComputeRoot folds the siblings onto the leaf following the path bits and returns the resulting root.
+// A set path bit at level i means the current node is the left operand at that level.
+func ComputeRoot(leaf []byte, siblings [][]byte, path uint32, config *Config) ([]byte, error) {
+	config = withDefaults(config)
+
+	concatFunc := Concat
+	if config.SortSiblingPairs {
+		concatFunc = ConcatSorted
+	}
+
+	hashFunc := func(_ int, data []byte) ([]byte, error) {
+		return config.HashFunc(data)
+	}
+
+	if config.PositionalHashFunc != nil {
+		// The cleared path bits are the bits of the leaf index.
+		index := int(^path & (1<<len(siblings) - 1))
+		hashFunc = func(level int, data []byte) ([]byte, error) {
+			return config.PositionalHashFunc(level, index>>level, data)
+		}
+	}
+
+	if config.Strict {
+		if err := CheckProof(leaf, siblings, path, config.NumLeaves); err != nil {
+			return nil, err
+		}
+	}
+
+	// Copy the slice so that the original leaf won't be modified.
+	result := make([]byte, len(leaf))
+	copy(result, leaf)
+
+	var (
+		index = int(^path & (1<<len(siblings) - 1))
+		err   error
+	)
+
+	for i, sib := range siblings {
+		if config.Strict {
+			if err := checkPadding(result, sib, index>>i, levelSize(config.NumLeaves, i)); err != nil {
+				return nil, fmt.Errorf("%w: level %d: %w", ErrMalformedProof, i, err)
+			}
+		}
+
+		if path&1 == 1 {
+			result, err = hashFunc(i+1, concatFunc(result, sib))
+		} else {
+			result, err = hashFunc(i+1, concatFunc(sib, result))
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		path >>= 1
+	}
+
+	return result, nil
+}
+
+// Verify checks that the serialized data block is included under root according to the proof siblings and path.
+func Verify(data []byte, siblings [][]byte, path uint32, root []byte, config *Config) (bool, error) {
+	leaf, err := Leaf(data, config)
+	if err != nil {
+		return false, err
+	}
+
+	return VerifyLeaf(leaf, siblings, path, root, config)
+}
+
+// VerifyLeaf is like Verify but takes the already computed leaf.
+func VerifyLeaf(leaf []byte, siblings [][]byte, path uint32, root []byte, config *Config) (bool, error) {
+	result, err := ComputeRoot(leaf, siblings, path, config)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(result, root), nil
+}
+
+// CheckProof checks the structure of a proof of the leaf, returning ErrMalformedProof if the leaf or a
+// sibling is empty, if the interior siblings do not all have the length of the first interior sibling,
+// or if the path has bits set beyond the siblings. If numLeaves is not 0, the number of siblings must
+// also be the depth of a tree of numLeaves leaves and the path must prove one of its leaves, rejecting
+// the proofs of the padding duplicates past the last leaf.
+func CheckProof(leaf []byte, siblings [][]byte, path uint32, numLeaves int) error {
+	if len(leaf) == 0 {
+		return fmt.Errorf("%w: empty leaf", ErrMalformedProof)
+	}
+
+	if len(siblings) > 32 {
+		return fmt.Errorf("%w: %d siblings exceed the path capacity", ErrMalformedProof, len(siblings))
+	}
+
+	if len(siblings) < 32 && path>>len(siblings) != 0 {
+		return fmt.Errorf("%w: path bits set beyond %d siblings", ErrMalformedProof, len(siblings))
+	}
+
+	for i, sib := range siblings {
+		if len(sib) == 0 {
+			return fmt.Errorf("%w: sibling %d is empty", ErrMalformedProof, i)
+		}
+
+		if i > 1 && len(sib) != len(siblings[1]) {
+			return fmt.Errorf("%w: sibling %d has length %d, want %d", ErrMalformedProof, i, len(sib), len(siblings[1]))
+		}
+	}
+
+	if numLeaves == 0 {
+		return nil
+	}
+
+	depth := 0
+	for n := numLeaves - 1; n > 0; n >>= 1 {
+		depth++
+	}
+
+	if len(siblings) != depth {
+		return fmt.Errorf("%w: %d siblings, want %d for %d leaves", ErrMalformedProof, len(siblings), depth, numLeaves)
+	}
+
+	if index := int(^path & (1<<len(siblings) - 1)); index >= numLeaves {
+		return fmt.Errorf("%w: leaf index %d out of range for %d leaves", ErrMalformedProof, index, numLeaves)
+	}
+
+	return nil
+}
+
+// checkPadding checks the sibling of the node at index of a level of size nodes, 0 if it is unknown.
+// Odd levels are padded by duplicating their last node, so a sibling past the end of the level must be
+// the duplicate of the node.
+func checkPadding(node, sib []byte, index, size int) error {
+	if size > 0 && index^1 >= size && !bytes.Equal(node, sib) {
+		return errors.New("padding sibling is not a duplicate of the node")
+	}
+
+	return nil
+}
+
+// levelSize returns the number of nodes of the level of a tree of numLeaves leaves, 0 if it is unknown.
+func levelSize(numLeaves, level int) int {
+	for ; level > 0; level-- {
+		numLeaves = (numLeaves + 1) >> 1
+	}
+
+	return numLeaves
+}
+
+func withDefaults(config *Config) *Config {
+	if config == nil {
+		return &Config{HashFunc: SHA256}
+	}
+
+	if config.HashFunc == nil {
+		c := *config
+		c.HashFunc = SHA256
+
+		return &c
+	}
+
+	return config
+}
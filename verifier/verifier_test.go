@@ -0,0 +1,251 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package verifier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestConcat(t *testing.T) {
+	tests := []struct {
+		name string
+		b1   []byte
+		b2   []byte
+	}{
+		{
+			name: "test_empty",
+		},
+		{
+			name: "test_carry",
+			b1:   []byte{0xff, 0xff},
+			b2:   []byte{0x01},
+		},
+		{
+			name: "test_leading_zeros",
+			b1:   []byte{0x00, 0x00, 0x01},
+			b2:   []byte{0x00, 0x02},
+		},
+		{
+			name: "test_random",
+			b1:   randBytes(32),
+			b2:   randBytes(32),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := new(big.Int).Add(new(big.Int).SetBytes(tt.b1), new(big.Int).SetBytes(tt.b2)).Bytes()
+			if got := Concat(tt.b1, tt.b2); !bytes.Equal(got, want) {
+				t.Errorf("Concat() got = %x, want %x", got, want)
+			}
+			if got := ConcatSorted(tt.b2, tt.b1); !bytes.Equal(got, want) {
+				t.Errorf("ConcatSorted() got = %x, want %x", got, want)
+			}
+			prefix := []byte("prefix")
+			if got := AppendConcat(prefix, tt.b1, tt.b2); !bytes.Equal(got, append(prefix, want...)) {
+				t.Errorf("AppendConcat() got = %x, want %x", got, append(prefix, want...))
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	var (
+		data    = [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+		leaves  = make([][]byte, len(data))
+		hash    = func(b []byte) []byte { h, _ := SHA256(b); return h }
+		sorting = &Config{SortSiblingPairs: true}
+	)
+	for i, d := range data {
+		leaves[i] = hash(d)
+	}
+	// Three leaves: the last one is duplicated to form the second pair.
+	n01 := hash(Concat(leaves[0], leaves[1]))
+	n22 := hash(Concat(leaves[2], leaves[2]))
+	root := hash(Concat(n01, n22))
+
+	tests := []struct {
+		name     string
+		data     []byte
+		siblings [][]byte
+		path     uint32
+		config   *Config
+		want     bool
+	}{
+		{
+			name:     "test_leaf_0",
+			data:     data[0],
+			siblings: [][]byte{leaves[1], n22},
+			path:     0b11,
+			want:     true,
+		},
+		{
+			name:     "test_leaf_2_sorted",
+			data:     data[2],
+			siblings: [][]byte{leaves[2], n01},
+			path:     0b01,
+			config:   sorting,
+			want:     true,
+		},
+		{
+			name:     "test_wrong_data",
+			data:     []byte("d"),
+			siblings: [][]byte{leaves[1], n22},
+			path:     0b11,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Verify(tt.data, tt.siblings, tt.path, root, tt.config)
+			if err != nil {
+				t.Errorf("Verify() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Verify() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify_strict(t *testing.T) {
+	var (
+		data   = [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+		leaves = make([][]byte, len(data))
+		hash   = func(b []byte) []byte { h, _ := SHA256(b); return h }
+	)
+	for i, d := range data {
+		leaves[i] = hash(d)
+	}
+	n01 := hash(Concat(leaves[0], leaves[1]))
+	n22 := hash(Concat(leaves[2], leaves[2]))
+	root := hash(Concat(n01, n22))
+
+	tests := []struct {
+		name      string
+		data      []byte
+		siblings  [][]byte
+		path      uint32
+		numLeaves int
+		wantErr   bool
+	}{
+		{name: "test_leaf_0", data: data[0], siblings: [][]byte{leaves[1], n22}, path: 0b11, numLeaves: 3},
+		{name: "test_padded_leaf_2", data: data[2], siblings: [][]byte{leaves[2], n01}, path: 0b01, numLeaves: 3},
+		{name: "test_padded_leaf_2_unknown_size", data: data[2], siblings: [][]byte{leaves[2], n01}, path: 0b01},
+		{name: "test_empty_sibling", data: data[0], siblings: [][]byte{leaves[1], {}}, path: 0b11, wantErr: true},
+		{name: "test_path_beyond_siblings", data: data[0], siblings: [][]byte{leaves[1], n22}, path: 0b111, wantErr: true},
+		{
+			name:     "test_sibling_length",
+			data:     data[0],
+			siblings: [][]byte{leaves[1], n22, n22[1:]},
+			path:     0b011,
+			wantErr:  true,
+		},
+		{
+			// The padding duplicate of leaf 2 verifies against the root without the number of leaves.
+			name:      "test_padding_duplicate_index",
+			data:      data[2],
+			siblings:  [][]byte{leaves[2], n01},
+			path:      0b00,
+			numLeaves: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "test_padding_not_duplicate",
+			data:      data[2],
+			siblings:  [][]byte{leaves[1], n01},
+			path:      0b01,
+			numLeaves: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "test_depth_mismatch",
+			data:      data[0],
+			siblings:  [][]byte{leaves[1], n22},
+			path:      0b11,
+			numLeaves: 5,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Strict: true, NumLeaves: tt.numLeaves}
+			got, err := Verify(tt.data, tt.siblings, tt.path, root, config)
+			if errors.Is(err, ErrMalformedProof) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got {
+				t.Errorf("Verify() got = %v, want true", got)
+			}
+		})
+	}
+}
+
+func TestComputeRoot_positional(t *testing.T) {
+	var (
+		leaves = [][]byte{{1}, {2}, {3}}
+		config = &Config{PositionalHashFunc: func(level, index int, data []byte) ([]byte, error) {
+			return append([]byte{byte(level), byte(index)}, data...), nil
+		}}
+		node = func(level, index int, data []byte) []byte {
+			h, _ := config.PositionalHashFunc(level, index, data)
+			return h
+		}
+	)
+	n01 := node(1, 0, Concat(leaves[0], leaves[1]))
+	n22 := node(1, 1, Concat(leaves[2], leaves[2]))
+	root := node(2, 0, Concat(n01, n22))
+
+	tests := []struct {
+		name     string
+		leaf     []byte
+		siblings [][]byte
+		path     uint32
+	}{
+		{name: "test_leaf_0", leaf: leaves[0], siblings: [][]byte{leaves[1], n22}, path: 0b11},
+		{name: "test_leaf_1", leaf: leaves[1], siblings: [][]byte{leaves[0], n22}, path: 0b10},
+		{name: "test_leaf_2", leaf: leaves[2], siblings: [][]byte{leaves[2], n01}, path: 0b01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ComputeRoot(tt.leaf, tt.siblings, tt.path, config)
+			if err != nil {
+				t.Fatalf("ComputeRoot() error = %v", err)
+			}
+			if !bytes.Equal(got, root) {
+				t.Errorf("ComputeRoot() got = %x, want %x", got, root)
+			}
+		})
+	}
+}
+
+func randBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
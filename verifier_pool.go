@@ -0,0 +1,223 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// VerifyJob is a verification job of a VerifierPool: the data block is verified with the proof against the root.
+type VerifyJob struct {
+	DataBlock DataBlock
+	Proof     *Proof
+	Root      []byte
+	// Done, if set, receives the result of the job. It should be buffered, as workers block on sending it.
+	Done chan<- *VerifyResult
+}
+
+// VerifyResult is the result of a VerifyJob.
+type VerifyResult struct {
+	Job *VerifyJob
+	// OK is the result of Verify.
+	OK bool
+	// Err is the error of Verify, or an error matching ErrVerificationPanic if the verification panicked.
+	Err error
+	// Latency is the duration of the verification.
+	Latency time.Duration
+}
+
+// VerifierPoolStats are the counters of a VerifierPool since it was started.
+type VerifierPoolStats struct {
+	// Verified is the number of completed jobs, which are valid, invalid or failed.
+	Verified uint64
+	Valid    uint64
+	Invalid  uint64
+	// Failed is the number of jobs whose verification returned an error, including the panics.
+	Failed uint64
+	// Panics is the number of jobs whose verification panicked.
+	Panics uint64
+	// TotalLatency is the sum of the latencies of the completed jobs, and MaxLatency the greatest one.
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+	// Elapsed is the duration since the pool was started.
+	Elapsed time.Duration
+}
+
+// MeanLatency returns the mean latency of the completed jobs, 0 if there is none.
+func (s VerifierPoolStats) MeanLatency() time.Duration {
+	if s.Verified == 0 {
+		return 0
+	}
+
+	return s.TotalLatency / time.Duration(s.Verified)
+}
+
+// Throughput returns the number of completed jobs per second since the pool was started.
+func (s VerifierPoolStats) Throughput() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+
+	return float64(s.Verified) / s.Elapsed.Seconds()
+}
+
+// VerifierPool verifies the jobs sent to its Jobs channel on a fixed number of worker goroutines, isolating
+// the panics of each verification, e.g. of a malformed data block, and counting latency and throughput.
+// It is the scaffolding of proof verification services.
+type VerifierPool struct {
+	config *Config
+	jobs   chan *VerifyJob
+	wg     sync.WaitGroup
+	start  time.Time
+	close  sync.Once
+
+	verified, valid, invalid, failed, panics atomic.Uint64
+	totalLatency, maxLatency                 atomic.Int64
+}
+
+// NewVerifierPool starts a VerifierPool of the given number of workers verifying with the configuration,
+// the number of CPUs if workers is not positive. If the hash function is not set, the concurrent-safe
+// DefaultHashFuncParallel is used. The pool must be closed with Close.
+func NewVerifierPool(config *Config, workers int) *VerifierPool {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	// The workers share a copy of the configuration, whose defaults Verify would otherwise set concurrently.
+	poolConfig := new(Config)
+	if config != nil {
+		*poolConfig = *config
+	}
+
+	if poolConfig.HashFunc == nil {
+		poolConfig.HashFunc = DefaultHashFuncParallel
+	}
+
+	p := &VerifierPool{
+		config: poolConfig,
+		jobs:   make(chan *VerifyJob, workers),
+		start:  time.Now(),
+	}
+
+	p.wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+
+	return p
+}
+
+// Jobs returns the channel the jobs to verify are sent to. It must not be used after Close.
+func (p *VerifierPool) Jobs() chan<- *VerifyJob {
+	return p.jobs
+}
+
+// Close stops accepting jobs and waits for the workers to complete the jobs already sent.
+func (p *VerifierPool) Close() {
+	p.close.Do(func() {
+		close(p.jobs)
+	})
+	p.wg.Wait()
+}
+
+// Stats returns the counters of the pool. They are read one by one while jobs complete, so they may be
+// off by the jobs completing during the call.
+func (p *VerifierPool) Stats() VerifierPoolStats {
+	return VerifierPoolStats{
+		Verified:     p.verified.Load(),
+		Valid:        p.valid.Load(),
+		Invalid:      p.invalid.Load(),
+		Failed:       p.failed.Load(),
+		Panics:       p.panics.Load(),
+		TotalLatency: time.Duration(p.totalLatency.Load()),
+		MaxLatency:   time.Duration(p.maxLatency.Load()),
+		Elapsed:      time.Since(p.start),
+	}
+}
+
+// work verifies jobs until the jobs channel is closed.
+func (p *VerifierPool) work() {
+	defer p.wg.Done()
+
+	for job := range p.jobs {
+		result := p.verify(job)
+		p.record(result)
+
+		if job.Done != nil {
+			job.Done <- result
+		}
+	}
+}
+
+// verify verifies the job, turning a panic into an ErrVerificationPanic error.
+func (p *VerifierPool) verify(job *VerifyJob) (result *VerifyResult) {
+	result = &VerifyResult{Job: job}
+	start := time.Now()
+
+	defer func() {
+		if r := recover(); r != nil {
+			result.OK = false
+			result.Err = fmt.Errorf("%w: %v", ErrVerificationPanic, r)
+		}
+
+		result.Latency = time.Since(start)
+	}()
+
+	result.OK, result.Err = Verify(job.DataBlock, job.Proof, job.Root, p.config)
+
+	return result
+}
+
+// record counts the result in the stats of the pool.
+func (p *VerifierPool) record(result *VerifyResult) {
+	switch {
+	case result.Err != nil:
+		p.failed.Add(1)
+	case result.OK:
+		p.valid.Add(1)
+	default:
+		p.invalid.Add(1)
+	}
+
+	if errors.Is(result.Err, ErrVerificationPanic) {
+		p.panics.Add(1)
+	}
+
+	latency := int64(result.Latency)
+	p.totalLatency.Add(latency)
+
+	for maxLatency := p.maxLatency.Load(); latency > maxLatency; maxLatency = p.maxLatency.Load() {
+		if p.maxLatency.CompareAndSwap(maxLatency, latency) {
+			break
+		}
+	}
+
+	// Verified is counted last, so that it does not count jobs missing from the other counters.
+	p.verified.Add(1)
+}
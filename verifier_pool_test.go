@@ -0,0 +1,85 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifierPool(t *testing.T) {
+	blocks := mockDataBlocks(16)
+
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	pool := NewVerifierPool(nil, 4)
+	defer pool.Close()
+
+	tests := []struct {
+		name    string
+		job     *VerifyJob
+		wantOK  bool
+		wantErr error
+	}{
+		{name: "test_valid", job: &VerifyJob{DataBlock: blocks[3], Proof: m.Proofs[3], Root: m.Root}, wantOK: true},
+		{name: "test_invalid", job: &VerifyJob{DataBlock: blocks[3], Proof: m.Proofs[4], Root: m.Root}},
+		{name: "test_nil_proof", job: &VerifyJob{DataBlock: blocks[3], Root: m.Root}, wantErr: ErrProofIsNil},
+		{
+			name:    "test_panic",
+			job:     &VerifyJob{DataBlock: panicDataBlock{}, Proof: m.Proofs[0], Root: m.Root},
+			wantErr: ErrVerificationPanic,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan *VerifyResult, 1)
+			tt.job.Done = done
+			pool.Jobs() <- tt.job
+
+			result := <-done
+			if result.Job != tt.job || result.OK != tt.wantOK || !errors.Is(result.Err, tt.wantErr) {
+				t.Errorf("VerifyResult = %v, %v, want %v, %v", result.OK, result.Err, tt.wantOK, tt.wantErr)
+			}
+		})
+	}
+
+	// Jobs without Done are only counted.
+	for i := range blocks {
+		pool.Jobs() <- &VerifyJob{DataBlock: blocks[i], Proof: m.Proofs[i], Root: m.Root}
+	}
+
+	pool.Close()
+
+	stats := pool.Stats()
+	if stats.Verified != 20 || stats.Valid != 17 || stats.Invalid != 1 || stats.Failed != 2 || stats.Panics != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	if stats.MeanLatency() <= 0 || stats.MaxLatency < stats.MeanLatency() || stats.Throughput() <= 0 {
+		t.Errorf("Stats() latency %v, max %v, throughput %v", stats.MeanLatency(), stats.MaxLatency,
+			stats.Throughput())
+	}
+}
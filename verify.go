@@ -22,11 +22,34 @@
 
 package merkletree
 
-import "bytes"
+import (
+	"bytes"
+
+	"github.com/txaty/go-merkletree/verifier"
+)
 
 // Verify checks if the data block is valid using the Merkle Tree proof and the cached Merkle root hash.
+// Strict verification checks the proof against the number of leaves of the tree, and trees configured
+// with BindLeafCount check it against their size-bound root.
 func (m *MerkleTree) Verify(dataBlock DataBlock, proof *Proof) (bool, error) {
-	return Verify(dataBlock, proof, m.Root, m.Config)
+	if !m.StrictVerification && !m.BindLeafCount {
+		return Verify(dataBlock, proof, m.Root, m.Config)
+	}
+
+	config := *m.Config
+	if config.ExpectedNumLeaves == 0 {
+		config.ExpectedNumLeaves = m.NumLeaves
+	}
+
+	root := m.Root
+	if m.BindLeafCount {
+		var err error
+		if root, err = m.SizeBoundRoot(); err != nil {
+			return false, err
+		}
+	}
+
+	return Verify(dataBlock, proof, root, &config)
 }
 
 // Verify checks if the data block is valid using the Merkle Tree proof and the provided Merkle root hash.
@@ -50,38 +73,107 @@ func Verify(dataBlock DataBlock, proof *Proof, root []byte, config *Config) (boo
 		config.HashFunc = DefaultHashFunc
 	}
 
-	// Determine the concatenation function based on the configuration.
-	concatFunc := concatHash
-	if config.SortSiblingPairs {
-		concatFunc = concatSortHash
+	if config.TimestampLeaves {
+		var err error
+		if dataBlock, err = commitTimestamp(dataBlock); err != nil {
+			return false, err
+		}
 	}
 
+	dataBlock = config.fitBlock(dataBlock)
+
 	// Convert the data block to a leaf.
-	leaf, err := dataBlockToLeaf(dataBlock, config.HashFunc, config.DisableLeafHashing)
+	leaf, err := dataBlockToLeaf(dataBlock, config.leafHashFunc(), config.DisableLeafHashing)
 	if err != nil {
 		return false, err
 	}
 
-	// Traverse the Merkle proof and compute the resulting hash.
-	// Copy the slice so that the original leaf won't be modified.
-	result := make([]byte, len(leaf))
-	copy(result, leaf)
+	// Traverse the Merkle proof with the shared verification core.
+	return config.verifyLeaf(leaf, proof.Siblings, proof.Path, root)
+}
 
-	path := proof.Path
+// VerifyWithIndex checks the leaf hash at index in a tree of treeSize leaves against the root with the
+// siblings of its proof, from the leaf level up: the stateless verification of transparency logs, for proofs
+// built by any implementation of the tree shape of this package, whose odd levels are padded by duplicating
+// their last node. The number of siblings must be the depth of the tree, see NewProof. The tree size is
+// checked as ExpectedNumLeaves by strict verification and by trees with BindLeafCount.
+func VerifyWithIndex(leafHash []byte, index, treeSize int, siblings [][]byte, root []byte, config *Config,
+) (bool, error) {
+	proof, err := NewProof(siblings, index, treeSize)
+	if err != nil {
+		return false, err
+	}
 
-	for _, sib := range proof.Siblings {
-		if path&1 == 1 {
-			result, err = config.HashFunc(concatFunc(result, sib))
-		} else {
-			result, err = config.HashFunc(concatFunc(sib, result))
-		}
+	c := new(Config)
+	if config != nil {
+		*c = *config
+	}
 
-		if err != nil {
-			return false, err
+	if c.HashFunc == nil {
+		c.HashFunc = DefaultHashFunc
+	}
+
+	c.ExpectedNumLeaves = treeSize
+
+	return c.verifyLeaf(leafHash, proof.Siblings, proof.Path, root)
+}
+
+// VerifyAny checks the data block against a set of candidate roots, e.g. the last published roots during
+// a root rotation window. It returns the index of the first matching root, or -1 if none matches.
+// The root of the proof is computed once, whatever the number of candidates.
+func VerifyAny(dataBlock DataBlock, proof *Proof, roots [][]byte, config *Config) (int, error) {
+	if dataBlock == nil {
+		return -1, ErrDataBlockIsNil
+	}
+
+	if proof == nil {
+		return -1, ErrProofIsNil
+	}
+
+	if config == nil {
+		config = new(Config)
+	}
+
+	if config.HashFunc == nil {
+		config.HashFunc = DefaultHashFunc
+	}
+
+	if config.TimestampLeaves {
+		var err error
+		if dataBlock, err = commitTimestamp(dataBlock); err != nil {
+			return -1, err
 		}
+	}
+
+	dataBlock = config.fitBlock(dataBlock)
+
+	leaf, err := dataBlockToLeaf(dataBlock, config.leafHashFunc(), config.DisableLeafHashing)
+	if err != nil {
+		return -1, err
+	}
+
+	root, err := config.computeRoot(leaf, proof.Siblings, proof.Path)
+	if err != nil {
+		return -1, err
+	}
 
-		path >>= 1
+	for i, candidate := range roots {
+		if bytes.Equal(root, candidate) {
+			return i, nil
+		}
 	}
 
-	return bytes.Equal(result, root), nil
+	return -1, nil
+}
+
+// verifierConfig converts the configuration into the configuration of the verification core.
+func (c *Config) verifierConfig() *verifier.Config {
+	return &verifier.Config{
+		HashFunc:           verifier.HashFunc(c.nodeHashFunc()),
+		PositionalHashFunc: verifier.PositionalHashFunc(c.PositionalHashFunc),
+		SortSiblingPairs:   c.SortSiblingPairs,
+		DisableLeafHashing: c.DisableLeafHashing,
+		Strict:             c.StrictVerification,
+		NumLeaves:          c.ExpectedNumLeaves,
+	}
 }
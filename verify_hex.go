@@ -0,0 +1,132 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VerifyHexOption sets a parameter of VerifyHex.
+type VerifyHexOption func(*hexVerification)
+
+// hexVerification holds the parameters of VerifyHex.
+type hexVerification struct {
+	config   *Config
+	path     uint32
+	index    int
+	hasPath  bool
+	hasIndex bool
+}
+
+// WithVerifyConfig sets the configuration the tree was built with. The default configuration is used
+// if it is not set.
+func WithVerifyConfig(config *Config) VerifyHexOption {
+	return func(v *hexVerification) {
+		v.config = config
+	}
+}
+
+// WithProofPath sets the path of the proof, see Proof.
+func WithProofPath(path uint32) VerifyHexOption {
+	return func(v *hexVerification) {
+		v.path = path
+		v.hasPath = true
+	}
+}
+
+// WithLeafIndex sets the index of the leaf, from which the path of the proof is derived.
+func WithLeafIndex(idx int) VerifyHexOption {
+	return func(v *hexVerification) {
+		v.index = idx
+		v.hasIndex = true
+	}
+}
+
+// VerifyHex checks the leaf hash against the root using the proof siblings, all given as hexadecimal
+// strings as found in JSON proofs: with or without 0x prefix, in any case, surrounding spaces ignored.
+// The path of the proof is set with WithProofPath or WithLeafIndex. As sibling hashes are combined by
+// addition, it may be omitted unless the configuration, set with WithVerifyConfig, has a PositionalHashFunc.
+// Malformed strings fail with ErrInvalidHex, locating the faulty sibling.
+func VerifyHex(leafHex string, proofHex []string, rootHex string, opts ...VerifyHexOption) (bool, error) {
+	v := new(hexVerification)
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	config := new(Config)
+	if v.config != nil {
+		*config = *v.config
+	}
+
+	if config.HashFunc == nil {
+		config.HashFunc = DefaultHashFunc
+	}
+
+	leaf, err := decodeHexValue(leafHex)
+	if err != nil {
+		return false, fmt.Errorf("%w: leaf: %w", ErrInvalidHex, err)
+	}
+
+	root, err := decodeHexValue(rootHex)
+	if err != nil {
+		return false, fmt.Errorf("%w: root: %w", ErrInvalidHex, err)
+	}
+
+	siblings := make([][]byte, len(proofHex))
+	for i, sibHex := range proofHex {
+		if siblings[i], err = decodeHexValue(sibHex); err != nil {
+			return false, fmt.Errorf("%w: sibling %d: %w", ErrInvalidHex, i, err)
+		}
+	}
+
+	path := v.path
+
+	switch {
+	case v.hasIndex:
+		if v.index < 0 || v.index >= 1<<len(siblings) {
+			return false, ErrIndexOutOfRange
+		}
+
+		// A set path bit means the node is on the left, i.e. the index bit is cleared.
+		path = uint32(^v.index & (1<<len(siblings) - 1))
+	case !v.hasPath && config.PositionalHashFunc != nil:
+		return false, ErrProofPathRequired
+	}
+
+	return config.verifyLeaf(leaf, siblings, path, root)
+}
+
+// decodeHexValue decodes a non-empty hexadecimal string, with or without 0x prefix, in any case.
+func decodeHexValue(s string) ([]byte, error) {
+	b, err := decodeHex(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+
+	return b, nil
+}
@@ -0,0 +1,191 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// hexProof returns the leaf, siblings and root of the proof as hexadecimal strings formatted by format.
+func hexProof(leaf []byte, proof *Proof, root []byte, format func([]byte) string) (string, []string, string) {
+	siblings := make([]string, len(proof.Siblings))
+	for i, sib := range proof.Siblings {
+		siblings[i] = format(sib)
+	}
+
+	return format(leaf), siblings, format(root)
+}
+
+func TestVerifyHex(t *testing.T) {
+	var (
+		blocks = mockDataBlocks(11)
+		lower  = func(b []byte) string { return "0x" + hex.EncodeToString(b) }
+		upper  = func(b []byte) string { return " 0X" + strings.ToUpper(hex.EncodeToString(b)) + " " }
+		plain  = hex.EncodeToString
+	)
+	tests := []struct {
+		name     string
+		config   *Config
+		format   func([]byte) string
+		opts     func(idx int, proof *Proof) []VerifyHexOption
+		tamper   string
+		wantErr  error
+		wantFail bool
+	}{
+		{
+			name:   "test_path",
+			format: lower,
+			opts: func(_ int, proof *Proof) []VerifyHexOption {
+				return []VerifyHexOption{WithProofPath(proof.Path)}
+			},
+		},
+		{
+			name:   "test_leaf_index_upper_case",
+			format: upper,
+			opts: func(idx int, _ *Proof) []VerifyHexOption {
+				return []VerifyHexOption{WithLeafIndex(idx)}
+			},
+		},
+		{
+			name:   "test_without_path",
+			format: plain,
+			opts: func(_ int, _ *Proof) []VerifyHexOption {
+				return nil
+			},
+		},
+		{
+			name:   "test_sorted_without_path",
+			config: &Config{SortSiblingPairs: true},
+			format: plain,
+			opts: func(_ int, _ *Proof) []VerifyHexOption {
+				return []VerifyHexOption{WithVerifyConfig(&Config{SortSiblingPairs: true})}
+			},
+		},
+		{
+			name:   "test_positional_leaf_index",
+			config: &Config{PositionalHashFunc: PositionPrefixed(nil)},
+			format: lower,
+			opts: func(idx int, _ *Proof) []VerifyHexOption {
+				return []VerifyHexOption{
+					WithVerifyConfig(&Config{PositionalHashFunc: PositionPrefixed(nil)}),
+					WithLeafIndex(idx),
+				}
+			},
+		},
+		{
+			name:   "test_positional_path_missing",
+			config: &Config{PositionalHashFunc: PositionPrefixed(nil)},
+			format: lower,
+			opts: func(_ int, _ *Proof) []VerifyHexOption {
+				return []VerifyHexOption{WithVerifyConfig(&Config{PositionalHashFunc: PositionPrefixed(nil)})}
+			},
+			wantErr: ErrProofPathRequired,
+		},
+		{
+			name:   "test_positional_wrong_leaf_index",
+			config: &Config{PositionalHashFunc: PositionPrefixed(nil)},
+			format: lower,
+			opts: func(idx int, _ *Proof) []VerifyHexOption {
+				return []VerifyHexOption{
+					WithVerifyConfig(&Config{PositionalHashFunc: PositionPrefixed(nil)}),
+					WithLeafIndex(idx ^ 2),
+				}
+			},
+			wantFail: true,
+		},
+		{
+			name:   "test_wrong_root",
+			format: lower,
+			opts: func(_ int, _ *Proof) []VerifyHexOption {
+				return nil
+			},
+			tamper:   "root",
+			wantFail: true,
+		},
+		{
+			name:   "test_invalid_hex",
+			format: lower,
+			opts: func(_ int, proof *Proof) []VerifyHexOption {
+				return []VerifyHexOption{WithProofPath(proof.Path)}
+			},
+			tamper:  "sibling",
+			wantErr: ErrInvalidHex,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for idx, proof := range m.Proofs {
+				leafHex, proofHex, rootHex := hexProof(m.Leaves[idx], proof, m.Root, tt.format)
+				switch tt.tamper {
+				case "root":
+					rootHex = lower(m.Leaves[idx])
+				case "sibling":
+					proofHex[len(proofHex)-1] = "0xzz"
+				}
+				ok, err := VerifyHex(leafHex, proofHex, rootHex, tt.opts(idx, proof)...)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("VerifyHex() error = %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr == nil && ok == tt.wantFail {
+					t.Errorf("VerifyHex(%d) = %t, want %t", idx, ok, !tt.wantFail)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyHex_invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		leafHex  string
+		proofHex []string
+		rootHex  string
+		opts     []VerifyHexOption
+		wantErr  error
+	}{
+		{name: "test_empty_leaf", leafHex: "0x", proofHex: []string{"01"}, rootHex: "02", wantErr: ErrInvalidHex},
+		{name: "test_odd_length_root", leafHex: "01", proofHex: []string{"01"}, rootHex: "0x123", wantErr: ErrInvalidHex},
+		{
+			name:     "test_leaf_index_out_of_range",
+			leafHex:  "01",
+			proofHex: []string{"01", "02"},
+			rootHex:  "03",
+			opts:     []VerifyHexOption{WithLeafIndex(4)},
+			wantErr:  ErrIndexOutOfRange,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifyHex(tt.leafHex, tt.proofHex, tt.rootHex, tt.opts...); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyHex() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
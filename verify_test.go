@@ -24,12 +24,10 @@ package merkletree
 
 import (
 	"errors"
-	"reflect"
 	"testing"
 
-	"github.com/agiledragon/gomonkey/v2"
-
 	"github.com/txaty/go-merkletree/mock"
+	"github.com/txaty/go-merkletree/verifier"
 )
 
 func setupTestVerify(size int) (*MerkleTree, []DataBlock) {
@@ -170,8 +168,6 @@ func TestVerify(t *testing.T) {
 		t.Errorf("New() error = %v", err)
 		return
 	}
-	patches := gomonkey.NewPatches()
-	defer patches.Reset()
 	type args struct {
 		dataBlock DataBlock
 		proof     *Proof
@@ -181,7 +177,6 @@ func TestVerify(t *testing.T) {
 	tests := []struct {
 		name    string
 		args    args
-		mock    func()
 		want    bool
 		wantErr bool
 	}{
@@ -303,29 +298,21 @@ func TestVerify(t *testing.T) {
 		{
 			name: "data_block_serialize_err",
 			args: args{
-				dataBlock: blocks[0],
-				proof:     m.Proofs[0],
-				root:      m.Root,
+				dataBlock: &mock.DataBlock{
+					Err: errors.New("test_data_block_serialize_err"),
+				},
+				proof: m.Proofs[0],
+				root:  m.Root,
 				config: &Config{
 					HashFunc: m.HashFunc,
 				},
 			},
-			mock: func() {
-				patches.ApplyMethod(reflect.TypeOf(&mock.DataBlock{}), "Serialize",
-					func(m *mock.DataBlock) ([]byte, error) {
-						return nil, errors.New("test_data_block_serialize_err")
-					})
-			},
 			want:    false,
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.mock != nil {
-				tt.mock()
-			}
-			defer patches.Reset()
 			got, err := Verify(tt.args.dataBlock, tt.args.proof, tt.args.root, tt.args.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
@@ -337,3 +324,125 @@ func TestVerify(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyAny(t *testing.T) {
+	m, blocks := setupTestVerify(100)
+	other, _ := setupTestVerify(10)
+	tests := []struct {
+		name  string
+		roots [][]byte
+		want  int
+	}{
+		{name: "test_current_root", roots: [][]byte{m.Root}, want: 0},
+		{name: "test_rotated_root", roots: [][]byte{other.Root, []byte("stale"), m.Root}, want: 2},
+		{name: "test_no_match", roots: [][]byte{other.Root, []byte("stale")}, want: -1},
+		{name: "test_no_roots", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyAny(blocks[42], m.Proofs[42], tt.roots, nil)
+			if err != nil {
+				t.Fatalf("VerifyAny() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyAny() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+	if _, err := VerifyAny(blocks[0], nil, [][]byte{m.Root}, nil); !errors.Is(err, ErrProofIsNil) {
+		t.Errorf("VerifyAny() error = %v, want %v", err, ErrProofIsNil)
+	}
+}
+
+func TestVerifyWithIndex(t *testing.T) {
+	blocks := mockDataBlocks(13)
+
+	positional, err := New(&Config{PositionalHashFunc: PositionPrefixed(nil)}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	bound, err := New(&Config{BindLeafCount: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	boundRoot, err := bound.SizeBoundRoot()
+	if err != nil {
+		t.Fatalf("SizeBoundRoot() error = %v", err)
+	}
+
+	// leaf is the leaf whose hash and siblings are verified at index.
+	tests := []struct {
+		name     string
+		m        *MerkleTree
+		root     []byte
+		leaf     int
+		index    int
+		treeSize int
+		want     bool
+		wantErr  error
+	}{
+		{name: "test_positional", m: positional, root: positional.Root, leaf: 6, index: 6, treeSize: 13, want: true},
+		{name: "test_positional_wrong_index", m: positional, root: positional.Root, leaf: 6, index: 4, treeSize: 13},
+		{name: "test_bound", m: bound, root: boundRoot, leaf: 12, index: 12, treeSize: 13, want: true},
+		{name: "test_bound_wrong_size", m: bound, root: boundRoot, leaf: 12, index: 12, treeSize: 14},
+		{name: "test_wrong_depth", m: bound, root: boundRoot, index: 0, treeSize: 17, wantErr: ErrProofDepthMismatch},
+		{name: "test_index_out_of_range", m: bound, root: boundRoot, index: 13, treeSize: 13, wantErr: ErrIndexOutOfRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyWithIndex(tt.m.Leaves[tt.leaf], tt.index, tt.treeSize, tt.m.Proofs[tt.leaf].Siblings,
+				tt.root, tt.m.Config)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("VerifyWithIndex() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerify_strict(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *Config
+		numBlocks int
+	}{
+		{name: "test_proof_gen", config: &Config{}, numBlocks: 13},
+		{name: "test_proof_gen_parallel", config: &Config{RunInParallel: true, NumRoutines: 4}, numBlocks: 37},
+		{name: "test_tree_build", config: &Config{Mode: ModeTreeBuild}, numBlocks: 21},
+		{name: "test_sorted", config: &Config{SortSiblingPairs: true}, numBlocks: 9},
+		{name: "test_no_leaf_hashing", config: &Config{DisableLeafHashing: true}, numBlocks: 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := mockDataBlocks(tt.numBlocks)
+			tt.config.StrictVerification = true
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for i, block := range blocks {
+				proof, err := m.proofByIndex(i)
+				if err != nil {
+					t.Fatalf("proofByIndex() error = %v", err)
+				}
+				if ok, err := m.Verify(block, proof); err != nil || !ok {
+					t.Errorf("Verify(%d) = %t, %v, want true", i, ok, err)
+				}
+			}
+			// The proof of the padding duplicate past the last leaf of an odd level.
+			last, err := m.proofByIndex(m.NumLeaves - 1)
+			if err != nil {
+				t.Fatalf("proofByIndex() error = %v", err)
+			}
+			forged := &Proof{Siblings: last.Siblings, Path: last.Path &^ 1}
+			if ok, err := m.Verify(blocks[m.NumLeaves-1], forged); !errors.Is(err, verifier.ErrMalformedProof) {
+				t.Errorf("Verify(forged) = %t, %v, want %v", ok, err, verifier.ErrMalformedProof)
+			}
+			empty := &Proof{Siblings: append([][]byte{{}}, last.Siblings[1:]...), Path: last.Path}
+			if _, err := Verify(blocks[0], empty, m.Root, tt.config); !errors.Is(err, verifier.ErrMalformedProof) {
+				t.Errorf("Verify(empty sibling) error = %v, want %v", err, verifier.ErrMalformedProof)
+			}
+		})
+	}
+}